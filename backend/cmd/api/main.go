@@ -8,11 +8,14 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/uc-package/genet/internal/auth"
 	"github.com/uc-package/genet/internal/handlers"
+	"github.com/uc-package/genet/internal/imagegc"
 	"github.com/uc-package/genet/internal/k8s"
 	"github.com/uc-package/genet/internal/logger"
+	"github.com/uc-package/genet/internal/metrics"
 	"github.com/uc-package/genet/internal/models"
 	"github.com/uc-package/genet/internal/oidc"
 	"github.com/uc-package/genet/internal/prometheus"
+	"github.com/uc-package/genet/internal/registry"
 	"go.uber.org/zap"
 )
 
@@ -87,6 +90,20 @@ func main() {
 	// 启动节点池污点同步（共享池/非共享池）
 	k8sClient.StartNodePoolTaintReconciler(context.Background())
 
+	// 记录镜像保存任务的耗时与结果
+	k8sClient.StartCommitJobMetrics(context.Background())
+
+	// 保存镜像任务历史，并依次放行同一 Pod 排队中的任务
+	k8sClient.StartCommitHistorySync(context.Background())
+
+	// 按保留策略定时清理 registry 中的用户镜像
+	registries, err := registry.NewSet(config)
+	if err != nil {
+		log.Warn("Failed to initialize registry clients for image gc", zap.Error(err))
+	}
+	imageCollector := imagegc.NewCollector(config, k8sClient, registries)
+	imageCollector.Start(context.Background())
+
 	// 初始化 Prometheus 客户端
 	var promClient *prometheus.Client
 	if config.PrometheusURL != "" {
@@ -99,6 +116,10 @@ func main() {
 		}
 	}
 
+	if config.Pod.AppProxy.Enabled && !config.Pod.AppProxy.IsAvailable() {
+		log.Warn("App proxy is enabled but pod.appProxy.domain is not set, app proxy stays unavailable")
+	}
+
 	// 初始化处理器
 	podHandler := handlers.NewPodHandler(k8sClient, promClient, config)
 	deploymentHandler := handlers.NewDeploymentHandler(k8sClient, config)
@@ -110,10 +131,13 @@ func main() {
 	kubeconfigHandler := handlers.NewKubeconfigHandler(config, k8sClient)
 	clusterHandler := handlers.NewClusterHandler(k8sClient, promClient, config)
 	imageHandler := handlers.NewImageHandler(k8sClient, config)
+	sshKeyHandler := handlers.NewSSHKeyHandler(k8sClient, config)
+	registryCredentialHandler := handlers.NewRegistryCredentialHandler(k8sClient, config)
 	registryHandler, err := handlers.NewRegistryHandler(config, log)
 	if err != nil {
 		log.Warn("Failed to initialize registry handler", zap.Error(err))
 	}
+	imageGCHandler := handlers.NewImageGCHandler(imageCollector, config)
 	log.Info("Handlers initialized")
 
 	// 初始化 OIDC Provider（如果启用）
@@ -140,6 +164,9 @@ func main() {
 	// 使用自定义日志中间件
 	r.Use(logger.GinRecovery())
 	r.Use(logger.GinLogger())
+	r.Use(metrics.GinMiddleware())
+	// 应用代理的独立域名（*.pod.appProxy.domain），需在 CORS 之前处理
+	r.Use(podHandler.PodAppOriginMiddleware())
 
 	// CORS 配置
 	r.Use(cors.New(cors.Config{
@@ -155,6 +182,9 @@ func main() {
 		c.JSON(200, gin.H{"status": "healthy"})
 	})
 
+	// Genet 自身的 Prometheus 指标
+	r.GET("/metrics", gin.WrapH(metrics.Handler()))
+
 	// 注册 OIDC Provider 路由（如果启用）
 	if oidcProvider != nil {
 		oidcProvider.RegisterRoutes(r)
@@ -197,30 +227,69 @@ func main() {
 			pods.GET("/:id", podHandler.GetPod)
 			pods.Any("/:id/apps/code-server", podHandler.ProxyCodeServer)
 			pods.Any("/:id/apps/code-server/*path", podHandler.ProxyCodeServer)
+			pods.Any("/:id/proxy/:port", podHandler.ProxyPodApp)
+			pods.Any("/:id/proxy/:port/*path", podHandler.ProxyPodApp)
+			pods.PUT("/:id/proxy-ports", podHandler.UpdatePodProxyPorts)
+			pods.GET("/:id/proxy-shares", podHandler.ListPodProxyShares)
+			pods.POST("/:id/proxy-shares", podHandler.CreatePodProxyShare)
+			pods.DELETE("/:id/proxy-shares/:shareId", podHandler.RevokePodProxyShare)
+			pods.GET("/:id/webshell/options", podHandler.GetWebShellOptions)
+			pods.GET("/:id/webshell/sessions", podHandler.ListWebShellSessions)
 			pods.POST("/:id/webshell/sessions", podHandler.CreateWebShellSession)
 			pods.GET("/:id/webshell/sessions/:sessionId/ws", podHandler.WebShellWebSocket)
 			pods.DELETE("/:id/webshell/sessions/:sessionId", podHandler.DeleteWebShellSession)
+			pods.POST("/:id/webshell/sessions/:sessionId/shares", podHandler.CreateWebShellShare)
+			pods.GET("/:id/webshell/sessions/:sessionId/participants", podHandler.ListWebShellParticipants)
+			pods.GET("/:id/webshell/recordings", podHandler.ListWebShellRecordings)
+			pods.GET("/:id/webshell/recordings/:recordingId", podHandler.GetWebShellRecording)
+			pods.GET("/:id/ssh/tunnel", podHandler.SSHTunnel)
+			pods.GET("/:id/port-forward", podHandler.PortForward)
+			pods.GET("/:id/files", podHandler.DownloadPodFiles)
+			pods.PUT("/:id/files", podHandler.UploadPodFiles)
 			pods.DELETE("/:id", podHandler.DeletePod)
 			pods.POST("/:id/extend", podHandler.ExtendPod) // 延长 Pod 保护期
+			pods.GET("/history/:id/logs", podHandler.GetPodHistoryLogs)
+			pods.GET("/history/:id/archives", podHandler.ListPodHistoryArchives)
 			pods.GET("/:id/logs", podHandler.GetPodLogs)
 			pods.GET("/:id/logs/stream", podHandler.PodLogsWebSocket)
+			pods.GET("/:id/metrics", podHandler.GetPodMetrics)
 			pods.GET("/:id/events", podHandler.GetPodEvents)
 			pods.GET("/:id/describe", podHandler.GetPodDescribe)
 			pods.GET("/:id/yaml", podHandler.DownloadPodYAML)
 			pods.GET("/:id/shared-gpus", podHandler.GetSharedGPUPods) // 获取共用 GPU 的 Pod
+			// Dockerfile 镜像构建
 			pods.POST("/:id/build", podHandler.BuildImage)
+			pods.GET("/:id/build/status", podHandler.GetBuildStatus)
+			pods.GET("/:id/build/logs", podHandler.GetBuildLogs)
 			// 镜像 commit 相关
 			pods.POST("/:id/commit", podHandler.CommitImage)
 			pods.GET("/:id/commit/status", podHandler.GetCommitStatus)
 			pods.GET("/:id/commit/logs", podHandler.GetCommitLogs)
 		}
 
+		// Pod 应用分享链接（签名 + 过期时间，访问者只需登录）
+		proxyShares := api.Group("/proxy-shares")
+		proxyShares.Use(auth.AuthMiddleware(config), auth.RequireAuth)
+		{
+			proxyShares.Any("/:token", podHandler.ProxySharedPodApp)
+			proxyShares.Any("/:token/*path", podHandler.ProxySharedPodApp)
+		}
+
+		// Web Shell 会话分享（访客只需登录）
+		webShellShares := api.Group("/webshell/shares")
+		webShellShares.Use(auth.AuthMiddleware(config), auth.RequireAuth)
+		{
+			webShellShares.GET("/:token", podHandler.GetWebShellShare)
+			webShellShares.GET("/:token/ws", podHandler.SharedWebShellWebSocket)
+		}
+
 		statefulSets := api.Group("/statefulsets")
 		statefulSets.Use(auth.AuthMiddleware(config))
 		{
 			statefulSets.GET("", statefulSetHandler.ListStatefulSets)
 			statefulSets.POST("", statefulSetHandler.CreateStatefulSet)
 			statefulSets.GET("/:id", statefulSetHandler.GetStatefulSet)
+			statefulSets.GET("/:id/logs/stream", statefulSetHandler.StatefulSetLogsWebSocket)
 			statefulSets.POST("/:id/resume", statefulSetHandler.ResumeStatefulSet)
 			statefulSets.DELETE("/:id", statefulSetHandler.DeleteStatefulSet)
 		}
@@ -231,6 +300,7 @@ func main() {
 			deployments.GET("", deploymentHandler.ListDeployments)
 			deployments.POST("", deploymentHandler.CreateDeployment)
 			deployments.GET("/:id", deploymentHandler.GetDeployment)
+			deployments.GET("/:id/logs/stream", deploymentHandler.DeploymentLogsWebSocket)
 			deployments.POST("/:id/resume", deploymentHandler.ResumeDeployment)
 			deployments.DELETE("/:id", deploymentHandler.DeleteDeployment)
 		}
@@ -244,6 +314,31 @@ func main() {
 			images.DELETE("", imageHandler.DeleteUserImage)
 		}
 
+		// 镜像保存历史（需要认证）
+		commits := api.Group("/commits")
+		commits.Use(auth.AuthMiddleware(config))
+		{
+			commits.GET("", imageHandler.ListCommits)
+		}
+
+		// 用户 SSH 公钥管理端点（需要认证）
+		sshKeys := api.Group("/ssh-keys")
+		sshKeys.Use(auth.AuthMiddleware(config))
+		{
+			sshKeys.GET("", sshKeyHandler.ListSSHKeys)
+			sshKeys.POST("", sshKeyHandler.AddSSHKey)
+			sshKeys.DELETE("/:id", sshKeyHandler.DeleteSSHKey)
+		}
+
+		// 用户私有仓库凭据管理端点（需要认证）
+		registryCredentials := api.Group("/registry-credentials")
+		registryCredentials.Use(auth.AuthMiddleware(config))
+		{
+			registryCredentials.GET("", registryCredentialHandler.ListRegistryCredentials)
+			registryCredentials.POST("", registryCredentialHandler.SaveRegistryCredential)
+			registryCredentials.DELETE("/:name", registryCredentialHandler.DeleteRegistryCredential)
+		}
+
 		// Registry 镜像搜索端点（需要认证）
 		if registryHandler != nil {
 			registry := api.Group("/registry")
@@ -251,6 +346,7 @@ func main() {
 			{
 				registry.GET("/images", registryHandler.SearchImages)
 				registry.GET("/tags", registryHandler.GetImageTags)
+				registry.GET("/scan", registryHandler.GetImageScan)
 			}
 		}
 
@@ -264,6 +360,7 @@ func main() {
 		{
 			admin.GET("/me", adminHandler.GetMe)
 			admin.GET("/overview", adminHandler.GetOverview)
+			admin.GET("/gpu-history", clusterHandler.GetGPUHistory)
 			admin.GET("/nodes/pools", adminHandler.ListNodePools)
 			admin.PATCH("/nodes/:name/pool", adminHandler.UpdateNodePool)
 			admin.GET("/users/pools", adminHandler.ListUserPools)
@@ -273,6 +370,10 @@ func main() {
 			admin.POST("/apikeys", adminHandler.CreateAPIKey)
 			admin.PATCH("/apikeys/:id", adminHandler.UpdateAPIKey)
 			admin.DELETE("/apikeys/:id", adminHandler.DeleteAPIKey)
+			admin.GET("/images/gc", imageGCHandler.GetImageGC)
+			admin.POST("/images/gc", imageGCHandler.RunImageGC)
+			admin.GET("/webshell/recordings", podHandler.AdminListWebShellRecordings)
+			admin.GET("/webshell/recordings/:user/:recordingId", podHandler.AdminGetWebShellRecording)
 		}
 	}
 
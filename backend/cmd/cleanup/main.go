@@ -6,6 +6,7 @@ import (
 
 	"github.com/uc-package/genet/internal/cleanup"
 	"github.com/uc-package/genet/internal/k8s"
+	"github.com/uc-package/genet/internal/metrics"
 	"github.com/uc-package/genet/internal/models"
 )
 
@@ -33,7 +34,15 @@ func main() {
 	log.Println("Genet Pod Cleanup - triggered by CronJob")
 
 	// 执行清理
-	if err := cleaner.CleanupAllPods(); err != nil {
+	err = cleaner.CleanupAllPods()
+
+	// CronJob 无法被抓取，配置了 Pushgateway 时推送本次清理统计
+	if url := config.Metrics.PushgatewayURL; url != "" {
+		if pushErr := metrics.PushCleanupStats(url, cleaner.LastRunStats()); pushErr != nil {
+			log.Printf("Warning: Failed to push cleanup metrics to %s: %v", url, pushErr)
+		}
+	}
+	if err != nil {
 		log.Fatalf("Error during cleanup: %v", err)
 	}
 
@@ -7,7 +7,9 @@ import (
 	"time"
 
 	"github.com/uc-package/genet/internal/k8s"
+	"github.com/uc-package/genet/internal/logarchive"
 	"github.com/uc-package/genet/internal/logger"
+	"github.com/uc-package/genet/internal/metrics"
 	"github.com/uc-package/genet/internal/models"
 	"go.uber.org/zap"
 	appsv1 "k8s.io/api/apps/v1"
@@ -21,7 +23,9 @@ type PodCleaner struct {
 	config                *models.Config
 	log                   *zap.Logger
 	nowFn                 func() time.Time
+	logArchiver           *logarchive.Archiver
 	commitWorkloadImageFn func(ctx context.Context, workloadKind, workloadName, namespace, userIdentifier string, pod *corev1.Pod) (string, error)
+	lastRun               metrics.CleanupStats
 }
 
 // NewPodCleaner 创建 Pod 清理器
@@ -33,6 +37,11 @@ func NewPodCleaner(k8sClient *k8s.Client, config *models.Config) *PodCleaner {
 		nowFn:     time.Now,
 	}
 	cleaner.commitWorkloadImageFn = cleaner.commitWorkloadImage
+	if archiver, err := logarchive.NewArchiver(config.LogArchive, k8sClient); err != nil {
+		cleaner.log.Warn("Log archive disabled: invalid config", zap.Error(err))
+	} else {
+		cleaner.logArchiver = archiver
+	}
 	return cleaner
 }
 
@@ -60,8 +69,25 @@ func (c *PodCleaner) isPodProtected(annotations map[string]string) bool {
 
 // CleanupAllPods 清理所有用户 Pod
 // 由 CronJob 在每天 23:00 触发，删除所有未受保护的用户 Pod
-func (c *PodCleaner) CleanupAllPods() error {
+func (c *PodCleaner) CleanupAllPods() (err error) {
 	c.log.Info("Starting pod cleanup")
+	startedAt := c.nowFn()
+	totalChecked := 0
+	totalDeleted := 0
+	totalProtected := 0
+	totalSuspended := 0
+	totalFailed := 0
+	defer func() {
+		c.lastRun = metrics.CleanupStats{
+			Checked:   totalChecked,
+			Deleted:   totalDeleted,
+			Protected: totalProtected,
+			Suspended: totalSuspended,
+			Failed:    totalFailed,
+			Duration:  c.nowFn().Sub(startedAt),
+			Err:       err,
+		}
+	}()
 
 	ctx := context.Background()
 	clientset := c.k8sClient.GetClientset()
@@ -74,11 +100,6 @@ func (c *PodCleaner) CleanupAllPods() error {
 		return fmt.Errorf("failed to list namespaces: %w", err)
 	}
 
-	totalChecked := 0
-	totalDeleted := 0
-	totalProtected := 0
-	totalSuspended := 0
-
 	// 遍历每个用户 namespace
 	for _, ns := range namespaces.Items {
 		if !strings.HasPrefix(ns.Name, "user-") {
@@ -122,8 +143,17 @@ func (c *PodCleaner) CleanupAllPods() error {
 				zap.String("namespace", ns.Name),
 				zap.String("reason", "scheduled cleanup"))
 
+			// 删除前归档日志与事件，失败不影响清理
+			if err := c.archivePodLogs(ctx, &pod, "cleanup"); err != nil {
+				c.log.Warn("Failed to archive pod logs",
+					zap.String("pod", pod.Name),
+					zap.String("namespace", ns.Name),
+					zap.Error(err))
+			}
+
 			err := c.k8sClient.DeletePod(ctx, ns.Name, pod.Name)
 			if err != nil {
+				totalFailed++
 				c.log.Error("Error deleting pod",
 					zap.String("pod", pod.Name),
 					zap.Error(err))
@@ -157,10 +187,37 @@ func (c *PodCleaner) CleanupAllPods() error {
 		zap.Int("checked", totalChecked),
 		zap.Int("deleted", totalDeleted),
 		zap.Int("protected", totalProtected),
-		zap.Int("suspended", totalSuspended))
+		zap.Int("suspended", totalSuspended),
+		zap.Int("failed", totalFailed))
 	return nil
 }
 
+// LastRunStats 最近一次 CleanupAllPods 的统计
+func (c *PodCleaner) LastRunStats() metrics.CleanupStats {
+	return c.lastRun
+}
+
+func (c *PodCleaner) archivePodLogs(ctx context.Context, pod *corev1.Pod, reason string) error {
+	if !c.logArchiver.Enabled() {
+		return nil
+	}
+	archiveCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+	return c.logArchiver.ArchivePod(archiveCtx, pod, reason)
+}
+
+// archiveReplicaLogs 休眠会把副本数缩到 0，缩容前归档所有副本（失败只告警）
+func (c *PodCleaner) archiveReplicaLogs(ctx context.Context, pods []corev1.Pod) {
+	for i := range pods {
+		if err := c.archivePodLogs(ctx, &pods[i], "suspend"); err != nil {
+			c.log.Warn("Failed to archive replica logs before suspend",
+				zap.String("pod", pods[i].Name),
+				zap.String("namespace", pods[i].Namespace),
+				zap.Error(err))
+		}
+	}
+}
+
 func (c *PodCleaner) cleanupManagedWorkloads(ctx context.Context, namespace string) (int, error) {
 	suspended := 0
 	var errs []string
@@ -198,7 +255,7 @@ func (c *PodCleaner) cleanupManagedWorkloads(ctx context.Context, namespace stri
 	}
 
 	if len(errs) > 0 {
-		return suspended, fmt.Errorf(strings.Join(errs, "; "))
+		return suspended, fmt.Errorf("%s", strings.Join(errs, "; "))
 	}
 	return suspended, nil
 }
@@ -226,6 +283,7 @@ func (c *PodCleaner) suspendDeployment(ctx context.Context, namespace string, de
 		return false, err
 	}
 
+	c.archiveReplicaLogs(ctx, pods)
 	if _, err := c.k8sClient.SuspendDeployment(ctx, namespace, deploy.Name, image, selected.Name, c.nowFn()); err != nil {
 		return false, err
 	}
@@ -255,6 +313,7 @@ func (c *PodCleaner) suspendStatefulSet(ctx context.Context, namespace string, s
 		return false, err
 	}
 
+	c.archiveReplicaLogs(ctx, pods)
 	if _, err := c.k8sClient.SuspendStatefulSet(ctx, namespace, sts.Name, image, selected.Name, c.nowFn()); err != nil {
 		return false, err
 	}
@@ -271,11 +330,13 @@ func workloadUserIdentifier(labels map[string]string, namespace string) string {
 	return namespace
 }
 
-func (c *PodCleaner) buildSuspendImageName(userIdentifier, workloadName string) (string, error) {
-	registryURL := strings.TrimSuffix(strings.TrimSpace(c.config.Registry.URL), "/")
-	if registryURL == "" {
+// buildSuspendImageName 休眠快照推送到用户的推送仓库（按用户标识和邮箱匹配 registries[].users）
+func (c *PodCleaner) buildSuspendImageName(userIdentifier, email, workloadName string) (string, error) {
+	registry := c.config.PushRegistryFor(userIdentifier, email)
+	if registry == nil || registry.Host() == "" {
 		return "", fmt.Errorf("registry url not configured")
 	}
+	registryURL := registry.Host()
 	tag := c.nowFn().UTC().Format("20060102-150405")
 	return fmt.Sprintf("%s/%s/suspend-%s:%s", registryURL, userIdentifier, workloadName, tag), nil
 }
@@ -285,7 +346,7 @@ func (c *PodCleaner) commitWorkloadImage(ctx context.Context, workloadKind, work
 		return "", fmt.Errorf("representative pod missing")
 	}
 
-	targetImage, err := c.buildSuspendImageName(userIdentifier, workloadName)
+	targetImage, err := c.buildSuspendImageName(userIdentifier, pod.Annotations["genet.io/email"], workloadName)
 	if err != nil {
 		return "", err
 	}
@@ -294,8 +355,12 @@ func (c *PodCleaner) commitWorkloadImage(ctx context.Context, workloadKind, work
 		PodName:     pod.Name,
 		Namespace:   namespace,
 		Username:    userIdentifier,
+		Email:       pod.Annotations["genet.io/email"],
 		TargetImage: targetImage,
 		NodeName:    pod.Spec.NodeName,
+		ContainerID: k8s.WorkspaceContainerID(pod),
+		Excludes:    c.config.Commit.DefaultExcludes,
+		Squash:      c.config.Commit.Squash,
 	})
 	if err != nil {
 		return "", err
@@ -88,6 +88,7 @@ func TestCleanupAllPodsSuspendsDeploymentWhenCommitSucceeds(t *testing.T) {
 		},
 	)
 
+	config.LogArchive = models.LogArchiveConfig{Enabled: true, Dir: t.TempDir()}
 	cleaner := NewPodCleaner(k8s.NewClientWithClientset(clientset, config), config)
 	cleaner.nowFn = func() time.Time {
 		return time.Date(2026, 3, 15, 10, 0, 0, 0, time.UTC)
@@ -113,6 +114,10 @@ func TestCleanupAllPodsSuspendsDeploymentWhenCommitSucceeds(t *testing.T) {
 	if deploy.Annotations["genet.io/suspended"] != "true" {
 		t.Fatalf("expected suspended=true, got %q", deploy.Annotations["genet.io/suspended"])
 	}
+	archives, err := cleaner.logArchiver.List(t.Context(), "user-alice", "deploy-alice-train-0")
+	if err != nil || len(archives) == 0 || archives[len(archives)-1].Reason != "suspend" {
+		t.Fatalf("expected replica logs archived before suspend, got %+v err=%v", archives, err)
+	}
 }
 
 func TestCleanupAllPodsLeavesStatefulSetRunningWhenCommitFails(t *testing.T) {
@@ -9,6 +9,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/gorilla/websocket"
 	"github.com/uc-package/genet/internal/models"
 )
 
@@ -75,6 +76,92 @@ func (c *APIClient) do(ctx context.Context, method, path string, payload []byte)
 	return nil, &apiError{StatusCode: resp.StatusCode, Message: extractErrorMessage(body, resp.Status)}
 }
 
+// DoStream 发送流式请求并返回原始响应（不受 30s 超时限制），调用方负责关闭 Body
+// newBody 在 401 刷新 token 重试时会被再次调用，以重新生成请求体
+func (c *APIClient) DoStream(ctx context.Context, method, path, contentType string, newBody func() (io.ReadCloser, error)) (*http.Response, error) {
+	resp, err := c.doStream(ctx, method, path, contentType, newBody)
+	if err == nil || !isUnauthorized(err) {
+		return resp, err
+	}
+	if err := c.refresh(ctx); err != nil {
+		return nil, err
+	}
+	return c.doStream(ctx, method, path, contentType, newBody)
+}
+
+func (c *APIClient) doStream(ctx context.Context, method, path, contentType string, newBody func() (io.ReadCloser, error)) (*http.Response, error) {
+	var body io.ReadCloser
+	if newBody != nil {
+		var err error
+		if body, err = newBody(); err != nil {
+			return nil, err
+		}
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, body)
+	if err != nil {
+		if body != nil {
+			_ = body.Close()
+		}
+		return nil, err
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	if c.config != nil && c.config.AccessToken != "" {
+		req.Header.Set("Authorization", "Bearer "+c.config.AccessToken)
+	}
+	streamClient := &http.Client{Transport: c.httpClient.Transport}
+	resp, err := streamClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return resp, nil
+	}
+	defer resp.Body.Close()
+	data, _ := io.ReadAll(resp.Body)
+	return nil, &apiError{StatusCode: resp.StatusCode, Message: extractErrorMessage(data, resp.Status)}
+}
+
+// DialWebSocket 建立到 API 的 WebSocket 连接，401 时刷新 token 后重试一次
+func (c *APIClient) DialWebSocket(ctx context.Context, path string) (*websocket.Conn, error) {
+	conn, err := c.dialWebSocket(ctx, path)
+	if err == nil {
+		return conn, nil
+	}
+	if !isUnauthorized(err) {
+		return nil, err
+	}
+	if err := c.refresh(ctx); err != nil {
+		return nil, err
+	}
+	return c.dialWebSocket(ctx, path)
+}
+
+func (c *APIClient) dialWebSocket(ctx context.Context, path string) (*websocket.Conn, error) {
+	wsURL := c.baseURL + path
+	switch {
+	case strings.HasPrefix(wsURL, "https://"):
+		wsURL = "wss://" + strings.TrimPrefix(wsURL, "https://")
+	case strings.HasPrefix(wsURL, "http://"):
+		wsURL = "ws://" + strings.TrimPrefix(wsURL, "http://")
+	}
+	header := http.Header{}
+	if c.config != nil && c.config.AccessToken != "" {
+		header.Set("Authorization", "Bearer "+c.config.AccessToken)
+	}
+	conn, resp, err := websocket.DefaultDialer.DialContext(ctx, wsURL, header)
+	if err == nil {
+		return conn, nil
+	}
+	if resp != nil {
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return nil, &apiError{StatusCode: resp.StatusCode, Message: extractErrorMessage(body, resp.Status)}
+	}
+	return nil, err
+}
+
 func (c *APIClient) refresh(ctx context.Context) error {
 	payload, err := json.Marshal(map[string]string{"refreshToken": c.config.RefreshToken})
 	if err != nil {
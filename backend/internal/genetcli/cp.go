@@ -0,0 +1,296 @@
+package genetcli
+
+import (
+	"archive/tar"
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+func newCpCmd(app *App) *cobra.Command {
+	var quiet bool
+	cmd := &cobra.Command{
+		Use:   "cp <src> <dst>",
+		Short: "Copy files between the local machine and a pod (POD:/path)",
+		Long: "Copy files between the local machine and a pod.\n\n" +
+			"  genet cp ./data.csv POD:/workspace-genet    upload into the remote directory\n" +
+			"  genet cp POD:/workspace-genet/ckpt ./ckpt   download a file or directory",
+		Args: cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			srcPod, srcPath, srcRemote := parseCpTarget(args[0])
+			dstPod, dstPath, dstRemote := parseCpTarget(args[1])
+			if srcRemote == dstRemote {
+				return fmt.Errorf("exactly one of <src> and <dst> must be POD:/path")
+			}
+			client, err := app.apiClient()
+			if err != nil {
+				return err
+			}
+			var progress io.Writer = cmd.ErrOrStderr()
+			if quiet {
+				progress = io.Discard
+			}
+			if srcRemote {
+				return copyFromPod(cmd.Context(), client, srcPod, srcPath, dstPath, progress)
+			}
+			return copyToPod(cmd.Context(), client, srcPath, dstPod, dstPath, progress)
+		},
+	}
+	cmd.Flags().BoolVarP(&quiet, "quiet", "q", false, "Do not print progress")
+	return cmd
+}
+
+// parseCpTarget 解析 POD:/path；不含冒号或冒号前带路径分隔符的视为本地路径
+func parseCpTarget(arg string) (pod, filePath string, remote bool) {
+	prefix, rest, found := strings.Cut(arg, ":")
+	if !found || prefix == "" || strings.ContainsAny(prefix, `/\`) || len(prefix) == 1 {
+		return "", arg, false
+	}
+	return prefix, rest, true
+}
+
+func podFilesPath(pod, filePath string) string {
+	return "/api/pods/" + url.PathEscape(pod) + "/files?path=" + url.QueryEscape(filePath)
+}
+
+// copyFromPod 下载 Pod 内文件或目录；本地目标为已存在目录时放到其下同名位置
+func copyFromPod(ctx context.Context, client *APIClient, pod, remotePath, localPath string, progressOut io.Writer) error {
+	resp, err := client.DoStream(ctx, "GET", podFilesPath(pod, remotePath), "", nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	base := path.Base(path.Clean(remotePath))
+	root := localPath
+	if info, err := os.Stat(localPath); err == nil && info.IsDir() {
+		root = filepath.Join(localPath, base)
+	}
+
+	progress := newTransferProgress(progressOut, "Downloading "+pod+":"+remotePath, 0)
+	defer progress.Done()
+	return extractTar(tar.NewReader(progress.Reader(resp.Body)), base, root, progressOut)
+}
+
+func extractTar(tr *tar.Reader, base, root string, warnOut io.Writer) error {
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("read archive: %w", err)
+		}
+		name := path.Clean(strings.TrimPrefix(hdr.Name, "./"))
+		first, rest, _ := strings.Cut(name, "/")
+		if first != base || rest == ".." || strings.HasPrefix(rest, "../") {
+			return fmt.Errorf("unexpected archive entry %q", hdr.Name)
+		}
+		target := filepath.Join(root, filepath.FromSlash(rest))
+
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, hdr.FileInfo().Mode().Perm()|0o700); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+				return err
+			}
+			if err := writeFileFromReader(target, tr, hdr.FileInfo().Mode().Perm()); err != nil {
+				return err
+			}
+		default:
+			fmt.Fprintf(warnOut, "skipping %s: unsupported file type\n", hdr.Name)
+		}
+	}
+}
+
+func writeFileFromReader(target string, r io.Reader, mode os.FileMode) error {
+	f, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, mode)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, r); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// copyToPod 将本地文件或目录上传到 Pod 内目录 remoteDir 下
+func copyToPod(ctx context.Context, client *APIClient, localPath, pod, remoteDir string, progressOut io.Writer) error {
+	localPath, err := filepath.Abs(localPath)
+	if err != nil {
+		return err
+	}
+	total, err := localTreeSize(localPath)
+	if err != nil {
+		return err
+	}
+	progress := newTransferProgress(progressOut, "Uploading "+localPath, total)
+	defer progress.Done()
+
+	newBody := func() (io.ReadCloser, error) {
+		pr, pw := io.Pipe()
+		go func() {
+			_ = pw.CloseWithError(writeTar(pw, localPath))
+		}()
+		progress.Reset()
+		return struct {
+			io.Reader
+			io.Closer
+		}{progress.Reader(pr), pr}, nil
+	}
+	var resp map[string]any
+	httpResp, err := client.DoStream(ctx, "PUT", podFilesPath(pod, remoteDir), "application/x-tar", newBody)
+	if err != nil {
+		return err
+	}
+	return decodeResponse(httpResp, &resp)
+}
+
+func localTreeSize(root string) (int64, error) {
+	var total int64
+	err := filepath.Walk(root, func(_ string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.Mode().IsRegular() {
+			total += info.Size()
+		}
+		return nil
+	})
+	return total, err
+}
+
+// writeTar 以 base 名称为顶层条目打包本地文件或目录
+func writeTar(w io.Writer, root string) error {
+	tw := tar.NewWriter(w)
+	base := filepath.Base(root)
+	err := filepath.Walk(root, func(current string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() && !info.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(root, current)
+		if err != nil {
+			return err
+		}
+		hdr, err := tar.FileInfoHeader(info, "")
+		if err != nil {
+			return err
+		}
+		hdr.Name = path.Join(base, filepath.ToSlash(rel))
+		if info.IsDir() {
+			hdr.Name += "/"
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+		f, err := os.Open(current)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		_, err = io.Copy(tw, f)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	return tw.Close()
+}
+
+// transferProgress 以单行刷新方式输出已传输字节数
+type transferProgress struct {
+	mu      sync.Mutex
+	out     io.Writer
+	label   string
+	total   int64
+	current int64
+	last    time.Time
+}
+
+func newTransferProgress(out io.Writer, label string, total int64) *transferProgress {
+	return &transferProgress{out: out, label: label, total: total}
+}
+
+func (p *transferProgress) Reader(r io.Reader) io.Reader {
+	return &progressReader{r: r, progress: p}
+}
+
+func (p *transferProgress) Reset() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.current = 0
+}
+
+func (p *transferProgress) add(n int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.current += int64(n)
+	if time.Since(p.last) >= 200*time.Millisecond {
+		p.render()
+	}
+}
+
+func (p *transferProgress) render() {
+	p.last = time.Now()
+	if p.total > 0 {
+		percent := p.current * 100 / p.total
+		if percent > 100 {
+			percent = 100
+		}
+		fmt.Fprintf(p.out, "\r%s: %s / %s (%d%%)", p.label, formatBytes(p.current), formatBytes(p.total), percent)
+		return
+	}
+	fmt.Fprintf(p.out, "\r%s: %s", p.label, formatBytes(p.current))
+}
+
+func (p *transferProgress) Done() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.render()
+	fmt.Fprintln(p.out)
+}
+
+type progressReader struct {
+	r        io.Reader
+	progress *transferProgress
+}
+
+func (r *progressReader) Read(b []byte) (int, error) {
+	n, err := r.r.Read(b)
+	if n > 0 {
+		r.progress.add(n)
+	}
+	return n, err
+}
+
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
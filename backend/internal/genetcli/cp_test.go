@@ -0,0 +1,114 @@
+package genetcli
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseCpTarget(t *testing.T) {
+	cases := []struct {
+		arg    string
+		pod    string
+		path   string
+		remote bool
+	}{
+		{"pod-alice-dev:/workspace/a.txt", "pod-alice-dev", "/workspace/a.txt", true},
+		{"./local:file", "", "./local:file", false},
+		{"C:\\data", "", "C:\\data", false},
+		{"data.csv", "", "data.csv", false},
+	}
+	for _, tc := range cases {
+		pod, path, remote := parseCpTarget(tc.arg)
+		if pod != tc.pod || path != tc.path || remote != tc.remote {
+			t.Fatalf("%q: got (%q, %q, %v)", tc.arg, pod, path, remote)
+		}
+	}
+}
+
+func TestCopyToPodStreamsTarArchive(t *testing.T) {
+	src := filepath.Join(t.TempDir(), "dataset")
+	if err := os.MkdirAll(filepath.Join(src, "sub"), 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(src, "sub", "a.txt"), []byte("hello"), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	var entries []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPut || r.URL.Path != "/api/pods/pod-alice-dev/files" || r.URL.Query().Get("path") != "/workspace" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.String())
+		}
+		tr := tar.NewReader(r.Body)
+		for {
+			hdr, err := tr.Next()
+			if err != nil {
+				break
+			}
+			body, _ := io.ReadAll(tr)
+			entries = append(entries, hdr.Name+"="+string(body))
+		}
+		_, _ = w.Write([]byte(`{"message":"ok"}`))
+	}))
+	defer server.Close()
+
+	client := NewAPIClient(server.URL, &Config{Server: server.URL, AccessToken: "token"}, "")
+	var progress bytes.Buffer
+	if err := copyToPod(context.Background(), client, src, "pod-alice-dev", "/workspace", &progress); err != nil {
+		t.Fatalf("copyToPod: %v", err)
+	}
+	want := "dataset/=,dataset/sub/=,dataset/sub/a.txt=hello"
+	if strings.Join(entries, ",") != want {
+		t.Fatalf("unexpected entries %v", entries)
+	}
+	if !strings.Contains(progress.String(), "(100%)") {
+		t.Fatalf("expected final progress line, got %q", progress.String())
+	}
+}
+
+func TestCopyFromPodExtractsIntoExistingDirectory(t *testing.T) {
+	var archive bytes.Buffer
+	tw := tar.NewWriter(&archive)
+	_ = tw.WriteHeader(&tar.Header{Name: "./ckpt/", Typeflag: tar.TypeDir, Mode: 0o755})
+	_ = tw.WriteHeader(&tar.Header{Name: "./ckpt/model.bin", Typeflag: tar.TypeReg, Mode: 0o644, Size: 4})
+	_, _ = tw.Write([]byte("data"))
+	_ = tw.Close()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("path") != "/workspace/ckpt" {
+			t.Errorf("unexpected path %q", r.URL.Query().Get("path"))
+		}
+		_, _ = w.Write(archive.Bytes())
+	}))
+	defer server.Close()
+
+	dst := t.TempDir()
+	client := NewAPIClient(server.URL, &Config{Server: server.URL, AccessToken: "token"}, "")
+	if err := copyFromPod(context.Background(), client, "pod-alice-dev", "/workspace/ckpt", dst, io.Discard); err != nil {
+		t.Fatalf("copyFromPod: %v", err)
+	}
+	got, err := os.ReadFile(filepath.Join(dst, "ckpt", "model.bin"))
+	if err != nil || string(got) != "data" {
+		t.Fatalf("unexpected file content %q err=%v", got, err)
+	}
+}
+
+func TestExtractTarRejectsEscapingEntries(t *testing.T) {
+	var archive bytes.Buffer
+	tw := tar.NewWriter(&archive)
+	_ = tw.WriteHeader(&tar.Header{Name: "../etc/passwd", Typeflag: tar.TypeReg, Mode: 0o644})
+	_ = tw.Close()
+
+	err := extractTar(tar.NewReader(&archive), "ckpt", t.TempDir(), io.Discard)
+	if err == nil {
+		t.Fatalf("expected escaping entry to be rejected")
+	}
+}
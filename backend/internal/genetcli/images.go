@@ -2,6 +2,14 @@ package genetcli
 
 import (
 	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/uc-package/genet/internal/models"
@@ -13,6 +21,18 @@ type CommitStatusResponse struct {
 	Status      string `json:"status,omitempty"`
 	Message     string `json:"message,omitempty"`
 	TargetImage string `json:"targetImage,omitempty"`
+	Size        int64  `json:"size,omitempty"`
+	BaseSize    int64  `json:"baseSize,omitempty"`
+	SizeDelta   int64  `json:"sizeDelta,omitempty"`
+}
+
+// CommitImageRequest 镜像保存请求，未指定的选项使用管理员配置的默认值
+type CommitImageRequest struct {
+	ImageName         string   `json:"imageName"`
+	Exclude           []string `json:"exclude,omitempty"`
+	NoDefaultExcludes bool     `json:"noDefaultExcludes,omitempty"`
+	Squash            *bool    `json:"squash,omitempty"`
+	PreCommitHook     string   `json:"preCommitHook,omitempty"`
 }
 
 type UserImageListResponse struct {
@@ -21,21 +41,7 @@ type UserImageListResponse struct {
 
 func newCommitCmd(app *App) *cobra.Command {
 	cmd := &cobra.Command{Use: "commit", Short: "Commit pod images"}
-	cmd.AddCommand(&cobra.Command{
-		Use:   "<pod-id> <image>",
-		Short: "Commit a pod to an image",
-		Args:  cobra.ExactArgs(2),
-		RunE: func(cmd *cobra.Command, args []string) error {
-			client, err := app.apiClient()
-			if err != nil {
-				return err
-			}
-			if err := commitImage(cmd.Context(), client, args[0], args[1]); err != nil {
-				return err
-			}
-			return app.print(map[string]string{"message": "commit started"})
-		},
-	})
+	cmd.AddCommand(newCommitImageCmd(app))
 	cmd.AddCommand(&cobra.Command{
 		Use:   "status <pod-id>",
 		Short: "Get commit status",
@@ -52,6 +58,7 @@ func newCommitCmd(app *App) *cobra.Command {
 			return app.print(resp)
 		},
 	})
+	cmd.AddCommand(newCommitListCmd(app))
 	cmd.AddCommand(&cobra.Command{
 		Use:   "logs <pod-id>",
 		Short: "Get commit logs",
@@ -71,23 +78,195 @@ func newCommitCmd(app *App) *cobra.Command {
 	return cmd
 }
 
-func newImageCmd(app *App) *cobra.Command {
-	cmd := &cobra.Command{Use: "image", Short: "Manage saved images"}
-	cmd.AddCommand(&cobra.Command{
+func newCommitImageCmd(app *App) *cobra.Command {
+	var req CommitImageRequest
+	var squash bool
+	var hookFile string
+	cmd := &cobra.Command{
+		Use:   "<pod-id> <image>",
+		Short: "Commit a pod to an image",
+		Long: "Commit a pod to an image. Paths matching the admin default excludes and --exclude are removed from the\n" +
+			"image (the running pod is not changed); use --squash to merge the new layers so removed files free space.\n" +
+			"A pre-commit hook runs inside the running container before the commit, e.g. \"pip cache purge\".",
+		Args: cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if hookFile != "" {
+				data, err := os.ReadFile(hookFile)
+				if err != nil {
+					return fmt.Errorf("read pre-commit hook: %w", err)
+				}
+				req.PreCommitHook = string(data)
+			}
+			if cmd.Flags().Changed("squash") {
+				req.Squash = &squash
+			}
+			client, err := app.apiClient()
+			if err != nil {
+				return err
+			}
+			req.ImageName = args[1]
+			if err := commitImage(cmd.Context(), client, args[0], req); err != nil {
+				return err
+			}
+			return app.print(map[string]string{"message": "commit started"})
+		},
+	}
+	cmd.Flags().StringSliceVar(&req.Exclude, "exclude", nil, "Extra path globs to exclude (requires squash), e.g. /root/.cache/* or **/node_modules")
+	cmd.Flags().BoolVar(&req.NoDefaultExcludes, "no-default-excludes", false, "Do not apply the admin default excludes")
+	cmd.Flags().BoolVar(&squash, "squash", false, "Squash the layers created by this commit (default from admin config)")
+	cmd.Flags().StringVar(&req.PreCommitHook, "pre-commit-hook", "", "Shell script to run inside the container before committing")
+	cmd.Flags().StringVar(&hookFile, "pre-commit-hook-file", "", "Local file with the pre-commit hook script")
+	return cmd
+}
+
+func newCommitListCmd(app *App) *cobra.Command {
+	var page, pageSize int
+	var pod, status string
+	cmd := &cobra.Command{
 		Use:   "ls",
-		Short: "List saved images",
+		Short: "List commit history",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, err := app.apiClient()
+			if err != nil {
+				return err
+			}
+			resp, err := listCommits(cmd.Context(), client, page, pageSize, pod, status)
+			if err != nil {
+				return err
+			}
+			if app.JSONOutput {
+				return app.print(resp)
+			}
+			return writeCommitList(cmd.OutOrStdout(), resp)
+		},
+	}
+	cmd.Flags().IntVar(&page, "page", 1, "Page number")
+	cmd.Flags().IntVar(&pageSize, "page-size", 20, "Records per page (max 100)")
+	cmd.Flags().StringVar(&pod, "pod", "", "Only show commits of this pod")
+	cmd.Flags().StringVar(&status, "status", "", "Only show commits with this status (Queued, Running, Succeeded, Failed)")
+	return cmd
+}
+
+func listCommits(ctx context.Context, client *APIClient, page, pageSize int, pod, status string) (*models.CommitListResponse, error) {
+	query := url.Values{}
+	query.Set("page", strconv.Itoa(page))
+	query.Set("pageSize", strconv.Itoa(pageSize))
+	if pod != "" {
+		query.Set("pod", pod)
+	}
+	if status != "" {
+		query.Set("status", status)
+	}
+	var resp models.CommitListResponse
+	if err := client.DoJSON(ctx, "GET", "/api/commits?"+query.Encode(), nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+func writeCommitList(out io.Writer, resp *models.CommitListResponse) error {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tIMAGE\tPOD\tSTATUS\tSIZE\tDURATION\tCREATED")
+	for _, record := range resp.Items {
+		size := "-"
+		if record.Size > 0 {
+			size = formatBytes(record.Size)
+		}
+		duration := "-"
+		if record.DurationSeconds > 0 {
+			duration = (time.Duration(record.DurationSeconds) * time.Second).String()
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", record.ID, record.TargetImage, record.SourcePod, record.Status,
+			size, duration, record.CreatedAt.Local().Format("2006-01-02 15:04:05"))
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	if pages := (resp.Total + resp.PageSize - 1) / max(resp.PageSize, 1); pages > 1 {
+		fmt.Fprintf(out, "page %d/%d, %d commits\n", resp.Page, pages, resp.Total)
+	}
+	return nil
+}
+
+// BuildImageRequest Dockerfile 构建请求，Dockerfile 与 DockerfilePath 二选一
+type BuildImageRequest struct {
+	ImageName      string `json:"imageName"`
+	Dockerfile     string `json:"dockerfile,omitempty"`
+	DockerfilePath string `json:"dockerfilePath,omitempty"`
+	Context        string `json:"context,omitempty"`
+}
+
+func newBuildCmd(app *App) *cobra.Command {
+	var localFile string
+	var req BuildImageRequest
+	cmd := &cobra.Command{
+		Use:   "build <pod-id> <image>",
+		Short: "Build an image from a Dockerfile in the pod workspace",
+		Long: "Build an image with a Dockerfile in the pod workspace (--dockerfile-path) or a local Dockerfile (-f).\n" +
+			"Paths are absolute paths inside the pod; the context defaults to the Dockerfile's directory.",
+		Args: cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if localFile != "" {
+				data, err := os.ReadFile(localFile)
+				if err != nil {
+					return fmt.Errorf("read dockerfile: %w", err)
+				}
+				req.Dockerfile = string(data)
+			}
+			client, err := app.apiClient()
+			if err != nil {
+				return err
+			}
+			req.ImageName = args[1]
+			var resp map[string]any
+			if err := buildImage(cmd.Context(), client, args[0], req, &resp); err != nil {
+				return err
+			}
+			return app.print(resp)
+		},
+	}
+	cmd.Flags().StringVarP(&localFile, "file", "f", "", "Local Dockerfile to send with the request")
+	cmd.Flags().StringVar(&req.DockerfilePath, "dockerfile-path", "", "Dockerfile path inside the pod workspace")
+	cmd.Flags().StringVar(&req.Context, "context", "", "Build context directory inside the pod workspace")
+	cmd.AddCommand(&cobra.Command{
+		Use:   "status <pod-id>",
+		Short: "Get build status",
+		Args:  cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			client, err := app.apiClient()
 			if err != nil {
 				return err
 			}
-			var resp UserImageListResponse
-			if err := client.DoJSON(cmd.Context(), "GET", "/api/images", nil, &resp); err != nil {
+			var resp CommitStatusResponse
+			if err := client.DoJSON(cmd.Context(), "GET", "/api/pods/"+args[0]+"/build/status", nil, &resp); err != nil {
 				return err
 			}
 			return app.print(resp)
 		},
 	})
+	cmd.AddCommand(&cobra.Command{
+		Use:   "logs <pod-id>",
+		Short: "Get build logs",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, err := app.apiClient()
+			if err != nil {
+				return err
+			}
+			var resp map[string]any
+			if err := client.DoJSON(cmd.Context(), "GET", "/api/pods/"+args[0]+"/build/logs", nil, &resp); err != nil {
+				return err
+			}
+			return app.print(resp)
+		},
+	})
+	return cmd
+}
+
+func newImageCmd(app *App) *cobra.Command {
+	cmd := &cobra.Command{Use: "image", Short: "Manage saved images"}
+	cmd.AddCommand(newImageListCmd(app))
 	cmd.AddCommand(&cobra.Command{
 		Use:   "add <image>",
 		Short: "Add a saved image record",
@@ -123,6 +302,93 @@ func newImageCmd(app *App) *cobra.Command {
 	return cmd
 }
 
-func commitImage(ctx context.Context, client *APIClient, podID, imageName string) error {
-	return client.DoJSON(ctx, "POST", "/api/pods/"+podID+"/commit", map[string]string{"imageName": imageName}, &map[string]any{})
+func newImageListCmd(app *App) *cobra.Command {
+	var wide bool
+	cmd := &cobra.Command{
+		Use:   "ls",
+		Short: "List saved images",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, err := app.apiClient()
+			if err != nil {
+				return err
+			}
+			resp, err := listUserImages(cmd.Context(), client, wide)
+			if err != nil {
+				return err
+			}
+			if app.JSONOutput {
+				return app.print(resp)
+			}
+			return writeUserImageList(cmd.OutOrStdout(), resp, wide)
+		},
+	}
+	cmd.Flags().BoolVar(&wide, "wide", false, "Also show digest, size, platforms and creation time from the registry")
+	return cmd
+}
+
+func listUserImages(ctx context.Context, client *APIClient, details bool) (*UserImageListResponse, error) {
+	path := "/api/images"
+	if details {
+		path += "?details=true"
+	}
+	var resp UserImageListResponse
+	if err := client.DoJSON(ctx, "GET", path, nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+func writeUserImageList(out io.Writer, resp *UserImageListResponse, wide bool) error {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	if wide {
+		fmt.Fprintln(w, "IMAGE\tPOD\tSAVED\tDIGEST\tSIZE\tPLATFORMS\tCREATED")
+	} else {
+		fmt.Fprintln(w, "IMAGE\tPOD\tSAVED")
+	}
+	for _, image := range resp.Images {
+		pod := image.SourcePod
+		if pod == "" {
+			pod = "-"
+		}
+		saved := image.SavedAt.Local().Format("2006-01-02 15:04:05")
+		if !wide {
+			fmt.Fprintf(w, "%s\t%s\t%s\n", image.Image, pod, saved)
+			continue
+		}
+		digest, size, platforms, created := "-", "-", "-", "-"
+		if m := image.Manifest; m != nil {
+			digest = shortDigest(m.Digest)
+			if m.Size > 0 {
+				size = formatBytes(m.Size)
+			}
+			if len(m.Platforms) > 0 {
+				platforms = strings.Join(m.Platforms, ",")
+			}
+			if m.Created != nil {
+				created = m.Created.Local().Format("2006-01-02 15:04:05")
+			}
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", image.Image, pod, saved, digest, size, platforms, created)
+	}
+	return w.Flush()
+}
+
+// shortDigest sha256:0123456789ab... 只显示前 12 位
+func shortDigest(digest string) string {
+	if digest == "" {
+		return "-"
+	}
+	if i := strings.Index(digest, ":"); i >= 0 && len(digest) > i+13 {
+		return digest[:i+13]
+	}
+	return digest
+}
+
+func commitImage(ctx context.Context, client *APIClient, podID string, req CommitImageRequest) error {
+	return client.DoJSON(ctx, "POST", "/api/pods/"+podID+"/commit", req, &map[string]any{})
+}
+
+func buildImage(ctx context.Context, client *APIClient, podID string, req BuildImageRequest, resp any) error {
+	return client.DoJSON(ctx, "POST", "/api/pods/"+podID+"/build", req, resp)
 }
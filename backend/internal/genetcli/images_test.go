@@ -5,7 +5,11 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/uc-package/genet/internal/models"
 )
 
 func TestCommitImageUsesExpectedPathAndPayload(t *testing.T) {
@@ -28,7 +32,133 @@ func TestCommitImageUsesExpectedPathAndPayload(t *testing.T) {
 	defer server.Close()
 
 	client := NewAPIClient(server.URL, &Config{Server: server.URL, AccessToken: "token"}, "")
-	if err := commitImage(context.Background(), client, "pod-1", "registry.local/alice/train:latest"); err != nil {
+	if err := commitImage(context.Background(), client, "pod-1", CommitImageRequest{ImageName: "registry.local/alice/train:latest"}); err != nil {
+		t.Fatalf("commitImage: %v", err)
+	}
+}
+
+func TestCommitImageSendsOptions(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req CommitImageRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatalf("decode payload: %v", err)
+		}
+		if len(req.Exclude) != 1 || req.Exclude[0] != "**/node_modules" || req.Squash == nil || !*req.Squash || req.PreCommitHook != "pip cache purge" {
+			t.Fatalf("unexpected payload: %+v", req)
+		}
+		_ = json.NewEncoder(w).Encode(map[string]string{"message": "ok"})
+	}))
+	defer server.Close()
+
+	client := NewAPIClient(server.URL, &Config{Server: server.URL, AccessToken: "token"}, "")
+	squash := true
+	req := CommitImageRequest{ImageName: "registry.local/alice/train:v2", Exclude: []string{"**/node_modules"}, Squash: &squash, PreCommitHook: "pip cache purge"}
+	if err := commitImage(context.Background(), client, "pod-1", req); err != nil {
 		t.Fatalf("commitImage: %v", err)
 	}
 }
+
+func TestBuildImageSendsWorkspaceDockerfilePath(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/pods/pod-1/build" || r.Method != http.MethodPost {
+			t.Fatalf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		var req map[string]string
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatalf("decode payload: %v", err)
+		}
+		if req["imageName"] != "registry.local/alice/train:v1" || req["dockerfilePath"] != "/workspace/train/Dockerfile" {
+			t.Fatalf("unexpected payload: %+v", req)
+		}
+		if _, ok := req["dockerfile"]; ok {
+			t.Fatalf("inline dockerfile should be omitted: %+v", req)
+		}
+		_ = json.NewEncoder(w).Encode(map[string]string{"jobName": "build-alice-1"})
+	}))
+	defer server.Close()
+
+	client := NewAPIClient(server.URL, &Config{Server: server.URL, AccessToken: "token"}, "")
+	var resp map[string]any
+	req := BuildImageRequest{ImageName: "registry.local/alice/train:v1", DockerfilePath: "/workspace/train/Dockerfile"}
+	if err := buildImage(context.Background(), client, "pod-1", req, &resp); err != nil {
+		t.Fatalf("buildImage: %v", err)
+	}
+	if resp["jobName"] != "build-alice-1" {
+		t.Fatalf("unexpected response %+v", resp)
+	}
+}
+
+func TestListCommitsSendsPaginationAndFilters(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/commits" {
+			t.Fatalf("unexpected path %q", r.URL.Path)
+		}
+		query := r.URL.Query()
+		if query.Get("page") != "2" || query.Get("pageSize") != "10" || query.Get("pod") != "pod-1" || query.Has("status") {
+			t.Fatalf("unexpected query %q", r.URL.RawQuery)
+		}
+		_ = json.NewEncoder(w).Encode(models.CommitListResponse{
+			Items:    []models.CommitRecord{{ID: "commit-alice-1", TargetImage: "registry.local/alice/a:v1", Status: "Succeeded", Size: 3 << 20, DurationSeconds: 75}},
+			Total:    11,
+			Page:     2,
+			PageSize: 10,
+		})
+	}))
+	defer server.Close()
+
+	client := NewAPIClient(server.URL, &Config{Server: server.URL, AccessToken: "token"}, "")
+	resp, err := listCommits(context.Background(), client, 2, 10, "pod-1", "")
+	if err != nil {
+		t.Fatalf("listCommits: %v", err)
+	}
+
+	var out strings.Builder
+	if err := writeCommitList(&out, resp); err != nil {
+		t.Fatalf("writeCommitList: %v", err)
+	}
+	for _, want := range []string{"commit-alice-1", "Succeeded", "1m15s", "page 2/2, 11 commits"} {
+		if !strings.Contains(out.String(), want) {
+			t.Fatalf("output missing %q:\n%s", want, out.String())
+		}
+	}
+}
+
+func TestListUserImagesWideRequestsDetails(t *testing.T) {
+	created := time.Date(2026, 9, 1, 10, 0, 0, 0, time.UTC)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/images" || r.URL.Query().Get("details") != "true" {
+			t.Fatalf("unexpected request %s", r.URL.String())
+		}
+		_ = json.NewEncoder(w).Encode(UserImageListResponse{Images: []models.UserSavedImage{{
+			Image:     "registry.local/alice/train:v1",
+			SourcePod: "pod-1",
+			SavedAt:   created,
+			Manifest: &models.ImageManifest{
+				Digest:    "sha256:0123456789abcdef0123",
+				Size:      5 << 20,
+				Platforms: []string{"linux/amd64", "linux/arm64"},
+				Created:   &created,
+			},
+		}}})
+	}))
+	defer server.Close()
+
+	client := NewAPIClient(server.URL, &Config{Server: server.URL, AccessToken: "token"}, "")
+	resp, err := listUserImages(context.Background(), client, true)
+	if err != nil {
+		t.Fatalf("listUserImages: %v", err)
+	}
+
+	var out strings.Builder
+	if err := writeUserImageList(&out, resp, true); err != nil {
+		t.Fatalf("writeUserImageList: %v", err)
+	}
+	for _, want := range []string{"DIGEST", "sha256:0123456789ab", "5.0 MiB", "linux/amd64,linux/arm64"} {
+		if !strings.Contains(out.String(), want) {
+			t.Fatalf("output missing %q:\n%s", want, out.String())
+		}
+	}
+	if strings.Contains(out.String(), "sha256:0123456789abc") {
+		t.Fatalf("expected shortened digest:\n%s", out.String())
+	}
+}
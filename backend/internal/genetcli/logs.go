@@ -0,0 +1,145 @@
+package genetcli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+type podLogsOptions struct {
+	grep       string
+	regex      bool
+	ignoreCase bool
+	since      string
+	until      string
+	tail       int64
+	previous   bool
+	output     string
+}
+
+type podLogsResponse struct {
+	Logs   string `json:"logs"`
+	Cursor string `json:"cursor,omitempty"`
+}
+
+func newLogsCmd(app *App) *cobra.Command {
+	var opts podLogsOptions
+	cmd := &cobra.Command{
+		Use:   "logs ID",
+		Short: "Get pod logs",
+		Long: "Get pod logs, optionally filtered on the server.\n\n" +
+			"  genet logs POD --grep 'loss=' --since 1h          matching lines from the last hour\n" +
+			"  genet logs POD --grep 'err(or)?' -E -i            case-insensitive regular expression\n" +
+			"  genet logs POD -o logs.gz                         download full logs (previous + current container)",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, err := app.apiClient()
+			if err != nil {
+				return err
+			}
+			path, err := podLogsPath(args[0], opts, time.Now())
+			if err != nil {
+				return err
+			}
+			if opts.output != "" {
+				return downloadPodLogs(cmd.Context(), client, path, opts.output)
+			}
+			var resp podLogsResponse
+			if err := client.DoJSON(cmd.Context(), "GET", path, nil, &resp); err != nil {
+				return err
+			}
+			if app.JSONOutput {
+				return app.print(resp)
+			}
+			_, err = io.WriteString(cmd.OutOrStdout(), resp.Logs)
+			return err
+		},
+	}
+	cmd.Flags().StringVar(&opts.grep, "grep", "", "Only show lines containing this text")
+	cmd.Flags().BoolVarP(&opts.regex, "regex", "E", false, "Treat --grep as a regular expression")
+	cmd.Flags().BoolVarP(&opts.ignoreCase, "ignore-case", "i", false, "Case-insensitive --grep")
+	cmd.Flags().StringVar(&opts.since, "since", "", "Only show lines after this time (RFC3339 or duration like 1h)")
+	cmd.Flags().StringVar(&opts.until, "until", "", "Only show lines before this time (RFC3339 or duration like 10m)")
+	cmd.Flags().Int64Var(&opts.tail, "tail", 0, "Number of lines to show (server default 100)")
+	cmd.Flags().BoolVar(&opts.previous, "previous", false, "Show logs of the previous container instance")
+	cmd.Flags().StringVarP(&opts.output, "output", "o", "", "Download full gzip-compressed logs to this file")
+	return cmd
+}
+
+// podLogsPath 构造日志请求路径；--since/--until 的相对时长换算为绝对时间
+func podLogsPath(id string, opts podLogsOptions, now time.Time) (string, error) {
+	query := url.Values{}
+	if opts.grep != "" {
+		query.Set("grep", opts.grep)
+		if opts.regex {
+			query.Set("regex", "true")
+		}
+		if opts.ignoreCase {
+			query.Set("ignoreCase", "true")
+		}
+	}
+	for _, item := range []struct{ name, value string }{{"since", opts.since}, {"until", opts.until}} {
+		if item.value == "" {
+			continue
+		}
+		ts, err := parseLogTime(item.value, now)
+		if err != nil {
+			return "", fmt.Errorf("invalid --%s %q: %w", item.name, item.value, err)
+		}
+		query.Set(item.name, ts)
+	}
+	if opts.output != "" {
+		query.Set("download", "true")
+	} else {
+		if opts.tail > 0 {
+			query.Set("tailLines", strconv.FormatInt(opts.tail, 10))
+		}
+		if opts.previous {
+			query.Set("previous", "true")
+		}
+	}
+	path := "/api/pods/" + url.PathEscape(id) + "/logs"
+	if encoded := query.Encode(); encoded != "" {
+		path += "?" + encoded
+	}
+	return path, nil
+}
+
+func parseLogTime(value string, now time.Time) (string, error) {
+	if d, err := time.ParseDuration(value); err == nil {
+		return now.Add(-d).UTC().Format(time.RFC3339), nil
+	}
+	ts, err := time.Parse(time.RFC3339Nano, value)
+	if err != nil {
+		return "", fmt.Errorf("expected RFC3339 time or duration")
+	}
+	return ts.UTC().Format(time.RFC3339Nano), nil
+}
+
+func downloadPodLogs(ctx context.Context, client *APIClient, path, output string) error {
+	resp, err := client.DoStream(ctx, "GET", path, "", nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if output == "-" {
+		_, err = io.Copy(os.Stdout, resp.Body)
+		return err
+	}
+	file, err := os.Create(output)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(file, resp.Body); err != nil {
+		_ = file.Close()
+		return err
+	}
+	return file.Close()
+}
@@ -0,0 +1,75 @@
+package genetcli
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestPodLogsPathBuildsFilterQuery(t *testing.T) {
+	now := time.Date(2026, 3, 15, 12, 0, 0, 0, time.UTC)
+	path, err := podLogsPath("pod-alice-dev", podLogsOptions{
+		grep:       "loss=",
+		ignoreCase: true,
+		since:      "1h",
+		until:      "2026-03-15T11:30:00+08:00",
+		tail:       50,
+	}, now)
+	if err != nil {
+		t.Fatalf("podLogsPath: %v", err)
+	}
+	parsed, _ := url.Parse(path)
+	query := parsed.Query()
+	if parsed.Path != "/api/pods/pod-alice-dev/logs" {
+		t.Fatalf("unexpected path %s", parsed.Path)
+	}
+	want := map[string]string{
+		"grep":       "loss=",
+		"ignoreCase": "true",
+		"regex":      "",
+		"since":      "2026-03-15T11:00:00Z",
+		"until":      "2026-03-15T03:30:00Z",
+		"tailLines":  "50",
+	}
+	for key, value := range want {
+		if got := query.Get(key); got != value {
+			t.Fatalf("%s: expected %q, got %q", key, value, got)
+		}
+	}
+}
+
+func TestPodLogsPathRejectsInvalidTime(t *testing.T) {
+	if _, err := podLogsPath("pod-alice-dev", podLogsOptions{since: "yesterday"}, time.Now()); err == nil {
+		t.Fatal("expected error for invalid --since")
+	}
+}
+
+func TestDownloadPodLogsWritesArchive(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("download") != "true" {
+			t.Errorf("expected download query, got %s", r.URL.String())
+		}
+		w.Header().Set("Content-Type", "application/gzip")
+		_, _ = w.Write([]byte("gzip-bytes"))
+	}))
+	defer server.Close()
+
+	path, err := podLogsPath("pod-alice-dev", podLogsOptions{output: "logs.gz", tail: 10}, time.Now())
+	if err != nil {
+		t.Fatalf("podLogsPath: %v", err)
+	}
+	output := filepath.Join(t.TempDir(), "logs.gz")
+	client := NewAPIClient(server.URL, &Config{Server: server.URL, AccessToken: "token"}, "")
+	if err := downloadPodLogs(context.Background(), client, path, output); err != nil {
+		t.Fatalf("downloadPodLogs: %v", err)
+	}
+	data, err := os.ReadFile(output)
+	if err != nil || string(data) != "gzip-bytes" {
+		t.Fatalf("unexpected archive %q (%v)", data, err)
+	}
+}
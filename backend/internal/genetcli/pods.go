@@ -45,10 +45,6 @@ func newPodCmd(app *App) *cobra.Command {
 	return cmd
 }
 
-func newLogsCmd(app *App) *cobra.Command {
-	return simpleGETCmd(app, "logs", "Get pod logs", func(id string) string { return "/api/pods/" + id + "/logs" })
-}
-
 func newEventsCmd(app *App) *cobra.Command {
 	return simpleGETCmd(app, "events", "Get pod events", func(id string) string { return "/api/pods/" + id + "/events" })
 }
@@ -0,0 +1,130 @@
+package genetcli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/uc-package/genet/internal/wsmux"
+)
+
+type portMapping struct {
+	Local  int
+	Remote int
+}
+
+func newPortForwardCmd(app *App) *cobra.Command {
+	var address string
+	cmd := &cobra.Command{
+		Use:   "port-forward <pod-id> [LOCAL:]REMOTE [[LOCAL:]REMOTE...]",
+		Short: "Forward local ports to a pod through the Genet API server",
+		Args:  cobra.MinimumNArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			mappings, err := parsePortMappings(args[1:])
+			if err != nil {
+				return err
+			}
+			client, err := app.apiClient()
+			if err != nil {
+				return err
+			}
+
+			listeners := make([]net.Listener, 0, len(mappings))
+			defer func() {
+				for _, listener := range listeners {
+					_ = listener.Close()
+				}
+			}()
+			for _, mapping := range mappings {
+				listener, err := net.Listen("tcp", net.JoinHostPort(address, strconv.Itoa(mapping.Local)))
+				if err != nil {
+					return err
+				}
+				listeners = append(listeners, listener)
+				fmt.Fprintf(cmd.OutOrStdout(), "Forwarding from %s -> %d\n", listener.Addr(), mapping.Remote)
+			}
+			return servePortForward(cmd.Context(), client, args[0], mappings, listeners, cmd.ErrOrStderr())
+		},
+	}
+	cmd.Flags().StringVar(&address, "address", "127.0.0.1", "Local address to listen on")
+	return cmd
+}
+
+// parsePortMappings 解析 LOCAL:REMOTE、REMOTE（本地同端口）和 :REMOTE（随机本地端口）
+func parsePortMappings(specs []string) ([]portMapping, error) {
+	mappings := make([]portMapping, 0, len(specs))
+	for _, spec := range specs {
+		localPart, remotePart, found := strings.Cut(spec, ":")
+		if !found {
+			localPart, remotePart = spec, spec
+		}
+		remote, err := strconv.Atoi(remotePart)
+		if err != nil || remote < 1 || remote > 65535 {
+			return nil, fmt.Errorf("invalid remote port in %q", spec)
+		}
+		local := 0
+		if localPart != "" {
+			local, err = strconv.Atoi(localPart)
+			if err != nil || local < 0 || local > 65535 {
+				return nil, fmt.Errorf("invalid local port in %q", spec)
+			}
+		}
+		mappings = append(mappings, portMapping{Local: local, Remote: remote})
+	}
+	return mappings, nil
+}
+
+// servePortForward 所有端口的本地连接复用同一条到 API Server 的 WebSocket，listeners 与 mappings 一一对应
+func servePortForward(ctx context.Context, client *APIClient, podID string, mappings []portMapping, listeners []net.Listener, errOut io.Writer) error {
+	query := url.Values{}
+	for _, mapping := range mappings {
+		query.Add("port", strconv.Itoa(mapping.Remote))
+	}
+	conn, err := client.DialWebSocket(ctx, "/api/pods/"+url.PathEscape(podID)+"/port-forward?"+query.Encode())
+	if err != nil {
+		return err
+	}
+	session := wsmux.NewSession(conn, nil)
+	defer session.Close()
+
+	sessionErr := make(chan error, 1)
+	go func() {
+		sessionErr <- session.Serve()
+	}()
+	acceptErr := make(chan error, len(listeners))
+	for i, listener := range listeners {
+		go func(listener net.Listener, remote int) {
+			for {
+				localConn, err := listener.Accept()
+				if err != nil {
+					acceptErr <- err
+					return
+				}
+				if err := session.Open(remote, localConn); err != nil {
+					fmt.Fprintf(errOut, "port %d: %v\n", remote, err)
+				}
+			}
+		}(listener, mappings[i].Remote)
+	}
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case err := <-acceptErr:
+		if errors.Is(err, net.ErrClosed) && ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return err
+	case err := <-sessionErr:
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return fmt.Errorf("port-forward connection closed: %w", err)
+	}
+}
@@ -0,0 +1,103 @@
+package genetcli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/websocket"
+	"github.com/uc-package/genet/internal/wsmux"
+)
+
+func TestParsePortMappings(t *testing.T) {
+	mappings, err := parsePortMappings([]string{"8888:8080", "6006", ":7860"})
+	if err != nil {
+		t.Fatalf("parsePortMappings: %v", err)
+	}
+	want := []portMapping{{Local: 8888, Remote: 8080}, {Local: 6006, Remote: 6006}, {Local: 0, Remote: 7860}}
+	for i := range want {
+		if mappings[i] != want[i] {
+			t.Fatalf("mapping %d: got %+v want %+v", i, mappings[i], want[i])
+		}
+	}
+	for _, bad := range []string{"8888:", "abc", "1:70000"} {
+		if _, err := parsePortMappings([]string{bad}); err == nil {
+			t.Fatalf("expected %q to be rejected", bad)
+		}
+	}
+}
+
+func TestServePortForwardMultiplexesLocalConnections(t *testing.T) {
+	upgrader := websocket.Upgrader{}
+	dials := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/pods/pod-alice-dev/port-forward" || fmt.Sprint(r.URL.Query()["port"]) != "[8080 6006]" {
+			t.Errorf("unexpected request %s", r.URL.String())
+		}
+		dials++
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		session := wsmux.NewSession(conn, func(port int) (net.Conn, error) {
+			serverSide, clientSide := net.Pipe()
+			go func() {
+				defer serverSide.Close()
+				buf := make([]byte, 64)
+				n, err := serverSide.Read(buf)
+				if err != nil {
+					return
+				}
+				_, _ = fmt.Fprintf(serverSide, "pod/%d:%s", port, buf[:n])
+			}()
+			return clientSide, nil
+		})
+		_ = session.Serve()
+	}))
+	defer server.Close()
+
+	mappings := []portMapping{{Remote: 8080}, {Remote: 6006}}
+	var listeners []net.Listener
+	for range mappings {
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatalf("listen: %v", err)
+		}
+		defer listener.Close()
+		listeners = append(listeners, listener)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	client := NewAPIClient(server.URL, &Config{Server: server.URL, AccessToken: "token"}, "")
+	done := make(chan error, 1)
+	go func() {
+		done <- servePortForward(ctx, client, "pod-alice-dev", mappings, listeners, io.Discard)
+	}()
+
+	for _, round := range []int{0, 1, 0} {
+		local, err := net.Dial("tcp", listeners[round].Addr().String())
+		if err != nil {
+			t.Fatalf("dial local: %v", err)
+		}
+		if _, err := local.Write([]byte("GET /")); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+		got, err := io.ReadAll(local)
+		local.Close()
+		want := fmt.Sprintf("pod/%d:GET /", mappings[round].Remote)
+		if err != nil || string(got) != want {
+			t.Fatalf("unexpected response %q err=%v, want %q", got, err, want)
+		}
+	}
+
+	cancel()
+	if err := <-done; err != context.Canceled {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+	if dials != 1 {
+		t.Fatalf("expected all connections to share one WebSocket, got %d", dials)
+	}
+}
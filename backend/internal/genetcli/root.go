@@ -38,11 +38,18 @@ func NewRootCmd() *cobra.Command {
 		newPodCmd(app),
 		newLogsCmd(app),
 		newEventsCmd(app),
+		newTopCmd(app),
 		newDescribeCmd(app),
 		newRmCmd(app),
 		newProtectCmd(app),
 		newCommitCmd(app),
+		newBuildCmd(app),
 		newImageCmd(app),
+		newSSHKeyCmd(app),
+		newSSHCmd(app),
+		newSSHProxyCmd(app),
+		newPortForwardCmd(app),
+		newCpCmd(app),
 		newRegistryCmd(app),
 		newKubeconfigCmd(app),
 	)
@@ -0,0 +1,278 @@
+package genetcli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/spf13/cobra"
+	"github.com/uc-package/genet/internal/models"
+)
+
+// defaultPublicKeyFiles 未指定公钥文件时按顺序尝试
+var defaultPublicKeyFiles = []string{"id_ed25519.pub", "id_ecdsa.pub", "id_rsa.pub"}
+
+func newSSHKeyCmd(app *App) *cobra.Command {
+	cmd := &cobra.Command{Use: "ssh-key", Short: "Manage SSH public keys injected into pods"}
+	cmd.AddCommand(&cobra.Command{
+		Use:   "ls",
+		Short: "List registered SSH keys",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, err := app.apiClient()
+			if err != nil {
+				return err
+			}
+			var resp models.UserSSHKeyList
+			if err := client.DoJSON(cmd.Context(), "GET", "/api/ssh-keys", nil, &resp); err != nil {
+				return err
+			}
+			return app.print(resp)
+		},
+	})
+
+	var name string
+	addCmd := &cobra.Command{
+		Use:   "add [public-key-file]",
+		Short: "Register an SSH public key (defaults to ~/.ssh/id_ed25519.pub)",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			path := ""
+			if len(args) == 1 {
+				path = args[0]
+			}
+			publicKey, err := readPublicKeyFile(path)
+			if err != nil {
+				return err
+			}
+			client, err := app.apiClient()
+			if err != nil {
+				return err
+			}
+			key, err := addSSHKey(cmd.Context(), client, name, publicKey)
+			if err != nil {
+				return err
+			}
+			return app.print(key)
+		},
+	}
+	addCmd.Flags().StringVar(&name, "name", "", "Key name (defaults to the key comment)")
+	cmd.AddCommand(addCmd)
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "rm <key-id>",
+		Short: "Delete a registered SSH key",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, err := app.apiClient()
+			if err != nil {
+				return err
+			}
+			var resp map[string]any
+			if err := client.DoJSON(cmd.Context(), "DELETE", "/api/ssh-keys/"+args[0], nil, &resp); err != nil {
+				return err
+			}
+			return app.print(resp)
+		},
+	})
+	return cmd
+}
+
+func addSSHKey(ctx context.Context, client *APIClient, name, publicKey string) (*models.UserSSHKey, error) {
+	var key models.UserSSHKey
+	req := models.AddSSHKeyRequest{Name: name, PublicKey: publicKey}
+	if err := client.DoJSON(ctx, "POST", "/api/ssh-keys", req, &key); err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+func readPublicKeyFile(path string) (string, error) {
+	candidates := []string{path}
+	if path == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		candidates = candidates[:0]
+		for _, file := range defaultPublicKeyFiles {
+			candidates = append(candidates, filepath.Join(home, ".ssh", file))
+		}
+	}
+	for _, candidate := range candidates {
+		data, err := os.ReadFile(candidate)
+		if err != nil {
+			if os.IsNotExist(err) && path == "" {
+				continue
+			}
+			return "", err
+		}
+		publicKey := strings.TrimSpace(string(data))
+		if strings.Contains(publicKey, "PRIVATE KEY") {
+			return "", fmt.Errorf("%s looks like a private key; pass the .pub file", candidate)
+		}
+		return publicKey, nil
+	}
+	return "", fmt.Errorf("no SSH public key found in ~/.ssh; pass the key file explicitly")
+}
+
+func newSSHCmd(app *App) *cobra.Command {
+	var user, mode string
+	var printConfig bool
+	cmd := &cobra.Command{
+		Use:   "ssh <pod-id> [-- ssh-args...]",
+		Short: "SSH into a pod through the Genet API server",
+		Args:  cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			exe, err := os.Executable()
+			if err != nil {
+				return err
+			}
+			opts := sshProxyOptions{Executable: exe, Server: app.Server, PodID: args[0], User: user, Mode: mode}
+			if printConfig {
+				fmt.Fprint(cmd.OutOrStdout(), buildSSHConfigBlock(opts))
+				return nil
+			}
+			sshBin, err := exec.LookPath("ssh")
+			if err != nil {
+				return fmt.Errorf("ssh client not found in PATH: %w", err)
+			}
+			sshCmd := exec.CommandContext(cmd.Context(), sshBin, buildSSHArgs(opts, args[1:])...)
+			sshCmd.Stdin = os.Stdin
+			sshCmd.Stdout = os.Stdout
+			sshCmd.Stderr = os.Stderr
+			return sshCmd.Run()
+		},
+	}
+	cmd.Flags().StringVarP(&user, "user", "l", "root", "Remote user")
+	cmd.Flags().StringVar(&mode, "mode", "tcp", "Tunnel mode: tcp (pod sshd port) or exec (sshd -i in container)")
+	cmd.Flags().BoolVar(&printConfig, "print-config", false, "Print an ~/.ssh/config block (for VS Code Remote-SSH) instead of connecting")
+	return cmd
+}
+
+func newSSHProxyCmd(app *App) *cobra.Command {
+	var mode string
+	cmd := &cobra.Command{
+		Use:   "ssh-proxy <pod-id>",
+		Short: "Relay stdin/stdout to a pod's sshd (use as OpenSSH ProxyCommand)",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, err := app.apiClient()
+			if err != nil {
+				return err
+			}
+			return runSSHProxy(cmd.Context(), client, args[0], mode, os.Stdin, os.Stdout)
+		},
+	}
+	cmd.Flags().StringVar(&mode, "mode", "tcp", "Tunnel mode: tcp (pod sshd port) or exec (sshd -i in container)")
+	return cmd
+}
+
+type sshProxyOptions struct {
+	Executable string
+	Server     string
+	PodID      string
+	User       string
+	Mode       string
+}
+
+func sshHostAlias(podID string) string {
+	return "genet-" + podID
+}
+
+func buildProxyCommand(opts sshProxyOptions) string {
+	parts := []string{quoteShellArg(opts.Executable)}
+	if opts.Server != "" {
+		parts = append(parts, "--server", quoteShellArg(opts.Server))
+	}
+	parts = append(parts, "ssh-proxy", quoteShellArg(opts.PodID))
+	if opts.Mode != "" && opts.Mode != "tcp" {
+		parts = append(parts, "--mode", quoteShellArg(opts.Mode))
+	}
+	return strings.Join(parts, " ")
+}
+
+func buildSSHArgs(opts sshProxyOptions, extra []string) []string {
+	alias := sshHostAlias(opts.PodID)
+	args := []string{
+		"-o", "ProxyCommand=" + buildProxyCommand(opts),
+		"-o", "HostKeyAlias=" + alias,
+	}
+	args = append(args, extra...)
+	target := alias
+	if opts.User != "" {
+		target = opts.User + "@" + alias
+	}
+	return append(args, target)
+}
+
+func buildSSHConfigBlock(opts sshProxyOptions) string {
+	alias := sshHostAlias(opts.PodID)
+	var b strings.Builder
+	fmt.Fprintf(&b, "Host %s\n", alias)
+	fmt.Fprintf(&b, "  HostName %s\n", alias)
+	if opts.User != "" {
+		fmt.Fprintf(&b, "  User %s\n", opts.User)
+	}
+	fmt.Fprintf(&b, "  ProxyCommand %s\n", buildProxyCommand(opts))
+	return b.String()
+}
+
+func quoteShellArg(value string) string {
+	if value != "" && !strings.ContainsAny(value, " \t\n'\"\\$`;&|<>()*?[]#~") {
+		return value
+	}
+	return "'" + strings.ReplaceAll(value, "'", `'"'"'`) + "'"
+}
+
+// runSSHProxy 在 stdin/stdout 与 Pod sshd 隧道之间转发字节
+func runSSHProxy(ctx context.Context, client *APIClient, podID, mode string, stdin io.Reader, stdout io.Writer) error {
+	path := "/api/pods/" + url.PathEscape(podID) + "/ssh/tunnel"
+	if mode != "" {
+		path += "?mode=" + url.QueryEscape(mode)
+	}
+	conn, err := client.DialWebSocket(ctx, path)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	go func() {
+		buf := make([]byte, 32*1024)
+		for {
+			n, err := stdin.Read(buf)
+			if n > 0 {
+				if writeErr := conn.WriteMessage(websocket.BinaryMessage, buf[:n]); writeErr != nil {
+					return
+				}
+			}
+			if err != nil {
+				_ = conn.WriteControl(websocket.CloseMessage,
+					websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""),
+					time.Now().Add(time.Second))
+				return
+			}
+		}
+	}()
+
+	for {
+		_, payload, err := conn.ReadMessage()
+		if err != nil {
+			var closeErr *websocket.CloseError
+			if errors.As(err, &closeErr) && closeErr.Code == websocket.CloseNormalClosure {
+				return nil
+			}
+			return err
+		}
+		if _, err := stdout.Write(payload); err != nil {
+			return err
+		}
+	}
+}
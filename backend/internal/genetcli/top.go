@@ -0,0 +1,131 @@
+package genetcli
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+type metricPoint struct {
+	Time  time.Time `json:"t"`
+	Value float64   `json:"v"`
+}
+
+type deviceMetricSeries struct {
+	Type        string        `json:"type"`
+	DeviceID    string        `json:"deviceId"`
+	Node        string        `json:"node"`
+	Utilization []metricPoint `json:"utilization"`
+	MemoryUsed  []metricPoint `json:"memoryUsed"`
+}
+
+type podMetricsResponse struct {
+	Start   time.Time            `json:"start"`
+	End     time.Time            `json:"end"`
+	Step    int64                `json:"step"`
+	CPU     []metricPoint        `json:"cpu"`
+	Memory  []metricPoint        `json:"memory"`
+	Devices []deviceMetricSeries `json:"devices"`
+}
+
+func newTopCmd(app *App) *cobra.Command {
+	var window string
+	cmd := &cobra.Command{
+		Use:   "top ID",
+		Short: "Show pod CPU, memory and GPU usage",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, err := app.apiClient()
+			if err != nil {
+				return err
+			}
+			var resp podMetricsResponse
+			path := "/api/pods/" + url.PathEscape(args[0]) + "/metrics?range=" + url.QueryEscape(window)
+			if err := client.DoJSON(cmd.Context(), "GET", path, nil, &resp); err != nil {
+				return err
+			}
+			if app.JSONOutput {
+				return app.print(resp)
+			}
+			return writePodTop(cmd.OutOrStdout(), window, resp)
+		},
+	}
+	cmd.Flags().StringVar(&window, "range", "15m", "Time range for the trend and peak (e.g. 15m, 1h, 1d)")
+	return cmd
+}
+
+// writePodTop 输出当前值、区间峰值和趋势
+func writePodTop(out io.Writer, window string, metrics podMetricsResponse) error {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "RESOURCE\tCURRENT\tPEAK(%s)\tTREND\n", window)
+	fmt.Fprintf(w, "cpu\t%s\t%s\t%s\n", formatCores(lastValue(metrics.CPU)), formatCores(peakValue(metrics.CPU)), sparkline(metrics.CPU))
+	fmt.Fprintf(w, "memory\t%s\t%s\t%s\n", formatBytes(int64(lastValue(metrics.Memory))), formatBytes(int64(peakValue(metrics.Memory))), sparkline(metrics.Memory))
+	for _, device := range metrics.Devices {
+		name := device.Type + "/" + device.DeviceID
+		if device.Node != "" {
+			name = device.Node + ":" + name
+		}
+		fmt.Fprintf(w, "%s util\t%s\t%s\t%s\n", name, formatPercent(lastValue(device.Utilization)), formatPercent(peakValue(device.Utilization)), sparkline(device.Utilization))
+		if len(device.MemoryUsed) > 0 {
+			fmt.Fprintf(w, "%s mem\t%s\t%s\t%s\n", name, formatMiB(lastValue(device.MemoryUsed)), formatMiB(peakValue(device.MemoryUsed)), sparkline(device.MemoryUsed))
+		}
+	}
+	return w.Flush()
+}
+
+func lastValue(points []metricPoint) float64 {
+	if len(points) == 0 {
+		return 0
+	}
+	return points[len(points)-1].Value
+}
+
+func peakValue(points []metricPoint) float64 {
+	peak := 0.0
+	for _, point := range points {
+		if point.Value > peak {
+			peak = point.Value
+		}
+	}
+	return peak
+}
+
+var sparkBlocks = []rune("▁▂▃▄▅▆▇█")
+
+// sparkline 将序列压缩为最多 30 个字符的趋势图
+func sparkline(points []metricPoint) string {
+	if len(points) == 0 {
+		return "-"
+	}
+	const width = 30
+	values := make([]float64, 0, width)
+	for i := 0; i < width && i < len(points); i++ {
+		idx := i * len(points) / min(width, len(points))
+		values = append(values, points[idx].Value)
+	}
+	lo, hi := values[0], values[0]
+	for _, v := range values {
+		lo = min(lo, v)
+		hi = max(hi, v)
+	}
+	var b strings.Builder
+	for _, v := range values {
+		level := 0
+		if hi > lo {
+			level = int((v - lo) / (hi - lo) * float64(len(sparkBlocks)-1))
+		}
+		b.WriteRune(sparkBlocks[level])
+	}
+	return b.String()
+}
+
+func formatCores(v float64) string { return fmt.Sprintf("%.2f", v) }
+
+func formatPercent(v float64) string { return fmt.Sprintf("%.0f%%", v) }
+
+func formatMiB(v float64) string { return formatBytes(int64(v * 1024 * 1024)) }
@@ -0,0 +1,44 @@
+package genetcli
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestWritePodTop(t *testing.T) {
+	var out bytes.Buffer
+	err := writePodTop(&out, "15m", podMetricsResponse{
+		CPU:    []metricPoint{{Value: 0.5}, {Value: 3}, {Value: 1.5}},
+		Memory: []metricPoint{{Value: 2 << 30}},
+		Devices: []deviceMetricSeries{{
+			Type:        "nvidia",
+			DeviceID:    "0",
+			Node:        "gpu-node-1",
+			Utilization: []metricPoint{{Value: 10}, {Value: 95}},
+			MemoryUsed:  []metricPoint{{Value: 40960}},
+		}},
+	})
+	if err != nil {
+		t.Fatalf("writePodTop: %v", err)
+	}
+	text := out.String()
+	for _, want := range []string{"PEAK(15m)", "1.50", "3.00", "2.0 GiB", "gpu-node-1:nvidia/0 util", "95%", "40.0 GiB", "▁█▃"} {
+		if !strings.Contains(text, want) {
+			t.Fatalf("missing %q in output:\n%s", want, text)
+		}
+	}
+}
+
+func TestSparklineHandlesFlatAndEmptySeries(t *testing.T) {
+	if got := sparkline(nil); got != "-" {
+		t.Fatalf("unexpected empty sparkline %q", got)
+	}
+	if got := sparkline([]metricPoint{{Value: 1}, {Value: 1}}); got != "▁▁" {
+		t.Fatalf("unexpected flat sparkline %q", got)
+	}
+	points := make([]metricPoint, 120)
+	if got := []rune(sparkline(points)); len(got) != 30 {
+		t.Fatalf("expected sparkline capped at 30, got %d", len(got))
+	}
+}
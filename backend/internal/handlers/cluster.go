@@ -24,16 +24,22 @@ type ClusterHandler struct {
 	promClient *prometheus.Client
 	config     *models.Config
 	log        *zap.Logger
+
+	queryAcceleratorHistoryFn func(ctx context.Context, accType prometheus.AcceleratorTypeConfig, start, end time.Time, step time.Duration) (*prometheus.AcceleratorHistory, error)
 }
 
 // NewClusterHandler 创建集群处理器
 func NewClusterHandler(k8sClient *k8s.Client, promClient *prometheus.Client, config *models.Config) *ClusterHandler {
-	return &ClusterHandler{
+	handler := &ClusterHandler{
 		k8sClient:  k8sClient,
 		promClient: promClient,
 		config:     config,
 		log:        logger.Named("cluster"),
 	}
+	if promClient != nil && promClient.IsEnabled() {
+		handler.queryAcceleratorHistoryFn = promClient.QueryAcceleratorHistory
+	}
+	return handler
 }
 
 // AcceleratorGroup 加速卡分组
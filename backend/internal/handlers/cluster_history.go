@@ -0,0 +1,189 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/uc-package/genet/internal/models"
+	"github.com/uc-package/genet/internal/prometheus"
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const defaultGPUHistoryRange = 24 * time.Hour
+
+// GPUHistoryResponse 加速卡历史利用率响应
+type GPUHistoryResponse struct {
+	Start         time.Time         `json:"start"`
+	End           time.Time         `json:"end"`
+	Step          int64             `json:"step"`          // 采样间隔（秒）
+	BusyThreshold float64           `json:"busyThreshold"` // 利用率达到该值视为在用（%）
+	Groups        []GPUHistoryGroup `json:"groups"`        // 按加速卡类型分组
+}
+
+// GPUHistoryGroup 某类加速卡的历史汇总
+type GPUHistoryGroup struct {
+	Type              string                   `json:"type"`
+	Label             string                   `json:"label"`
+	TotalDevices      int                      `json:"totalDevices"`      // 当前物理卡数
+	AvgAllocationRate float64                  `json:"avgAllocationRate"` // 平均分配率 0-100（无分配数据时为 -1）
+	AvgUtilization    float64                  `json:"avgUtilization"`    // 平均利用率 0-100
+	AvgBusyRate       float64                  `json:"avgBusyRate"`       // 平均在用率 0-100（利用率达到阈值的卡占比）
+	Utilization       []prometheus.SamplePoint `json:"utilization"`       // 平均利用率曲线
+	Allocated         []prometheus.SamplePoint `json:"allocated"`         // 已分配卡数曲线
+	Busy              []prometheus.SamplePoint `json:"busy"`              // 在用卡数曲线
+	Nodes             []GPUHistoryNode         `json:"nodes"`             // 按节点名排序
+}
+
+// GPUHistoryNode 单个节点的历史汇总
+type GPUHistoryNode struct {
+	NodeName          string                   `json:"nodeName"`
+	PoolType          string                   `json:"poolType"`          // "shared" | "exclusive"，节点已不存在时为空
+	DeviceType        string                   `json:"deviceType"`        // 设备型号
+	TotalDevices      int                      `json:"totalDevices"`      // 当前物理卡数，节点已不存在时为 0
+	AvgAllocationRate float64                  `json:"avgAllocationRate"` // 平均分配率 0-100（无分配数据时为 -1）
+	AvgUtilization    float64                  `json:"avgUtilization"`    // 平均利用率 0-100
+	Utilization       []prometheus.SamplePoint `json:"utilization"`
+	Allocated         []prometheus.SamplePoint `json:"allocated"`
+}
+
+// GetGPUHistory 查询各类加速卡在一段时间内的利用率与分配情况（range 如 1d、7d，默认 1d）
+// 用于对比“已分配”与“实际在用”，如 A100 分配率 90% 但利用率只有 20%
+func (h *ClusterHandler) GetGPUHistory(c *gin.Context) {
+	window := defaultGPUHistoryRange
+	if value := c.Query("range"); value != "" {
+		parsed, err := parsePodMetricsRange(value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		window = parsed
+	}
+	if h.queryAcceleratorHistoryFn == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "未配置 Prometheus，无法查询历史利用率"})
+		return
+	}
+
+	ctx := c.Request.Context()
+	nodes, err := h.k8sClient.GetClientset().CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		h.log.Error("Failed to list nodes", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取节点列表失败"})
+		return
+	}
+
+	end := time.Now()
+	start := end.Add(-window)
+	step := prometheus.RangeStep(window)
+	response := GPUHistoryResponse{
+		Start:         start,
+		End:           end,
+		Step:          int64(step / time.Second),
+		BusyThreshold: prometheus.BusyUtilizationThreshold,
+		Groups:        []GPUHistoryGroup{},
+	}
+	for _, accType := range h.config.GetAcceleratorTypes() {
+		promTypes := toPrometheusAcceleratorTypes([]models.AcceleratorType{accType})
+		history, err := h.queryAcceleratorHistoryFn(ctx, promTypes[0], start, end, step)
+		if err != nil {
+			h.log.Error("Failed to query accelerator history",
+				zap.String("type", accType.Type),
+				zap.Error(err))
+			c.JSON(http.StatusBadGateway, gin.H{"error": fmt.Sprintf("查询历史利用率失败: %v", err)})
+			return
+		}
+		group := h.buildGPUHistoryGroup(accType, nodes.Items, history)
+		if group.TotalDevices > 0 || len(group.Nodes) > 0 {
+			response.Groups = append(response.Groups, group)
+		}
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// buildGPUHistoryGroup 以当前节点容量为分母计算分配率与在用率
+func (h *ClusterHandler) buildGPUHistoryGroup(accType models.AcceleratorType, nodes []corev1.Node, history *prometheus.AcceleratorHistory) GPUHistoryGroup {
+	group := GPUHistoryGroup{
+		Type:              accType.Type,
+		Label:             accType.Label,
+		AvgAllocationRate: -1,
+		Utilization:       history.Utilization,
+		Allocated:         history.Allocated,
+		Busy:              history.Busy,
+		Nodes:             []GPUHistoryNode{},
+	}
+
+	resourceName := corev1.ResourceName(accType.ResourceName)
+	byName := make(map[string]*GPUHistoryNode)
+	for _, node := range nodes {
+		total := nodeDeviceCapacity(node, resourceName)
+		if total == 0 {
+			continue
+		}
+		group.TotalDevices += total
+		byName[node.Name] = &GPUHistoryNode{
+			NodeName:     node.Name,
+			PoolType:     getNodePoolType(node, h.config),
+			DeviceType:   getDeviceType(node, accType.Type),
+			TotalDevices: total,
+		}
+	}
+	for _, series := range history.Nodes {
+		item, ok := byName[series.Node]
+		if !ok {
+			item = &GPUHistoryNode{NodeName: series.Node}
+			byName[series.Node] = item
+		}
+		item.Utilization = series.Utilization
+		item.Allocated = series.Allocated
+	}
+
+	for _, item := range byName {
+		if item.Utilization == nil {
+			item.Utilization = []prometheus.SamplePoint{}
+		}
+		if item.Allocated == nil {
+			item.Allocated = []prometheus.SamplePoint{}
+		}
+		item.AvgUtilization = prometheus.AverageValue(item.Utilization)
+		item.AvgAllocationRate = averageRate(item.Allocated, item.TotalDevices)
+		group.Nodes = append(group.Nodes, *item)
+	}
+	sort.Slice(group.Nodes, func(i, j int) bool {
+		return group.Nodes[i].NodeName < group.Nodes[j].NodeName
+	})
+
+	group.AvgUtilization = prometheus.AverageValue(history.Utilization)
+	group.AvgAllocationRate = averageRate(history.Allocated, group.TotalDevices)
+	if busyRate := averageRate(history.Busy, group.TotalDevices); busyRate >= 0 {
+		group.AvgBusyRate = busyRate
+	}
+	return group
+}
+
+// nodeDeviceCapacity 节点物理卡数，取 Capacity 与 Allocatable 的较大值（与 GPU 概览一致）
+func nodeDeviceCapacity(node corev1.Node, resourceName corev1.ResourceName) int {
+	total := 0
+	if capacity, ok := node.Status.Capacity[resourceName]; ok {
+		total = int(capacity.Value())
+	}
+	if allocatable, ok := node.Status.Allocatable[resourceName]; ok && int(allocatable.Value()) > total {
+		total = int(allocatable.Value())
+	}
+	return total
+}
+
+// averageRate 卡数曲线的平均值占总卡数的百分比；无数据或总数为 0 时返回 -1
+func averageRate(points []prometheus.SamplePoint, total int) float64 {
+	if len(points) == 0 || total <= 0 {
+		return -1
+	}
+	rate := prometheus.AverageValue(points) / float64(total) * 100
+	if rate > 100 {
+		rate = 100
+	}
+	return rate
+}
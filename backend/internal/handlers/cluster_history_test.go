@@ -0,0 +1,129 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/uc-package/genet/internal/k8s"
+	"github.com/uc-package/genet/internal/models"
+	"github.com/uc-package/genet/internal/prometheus"
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func newGPUHistoryTestNode(name string, gpus string) *corev1.Node {
+	return &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Labels: map[string]string{"nvidia.com/gpu.product": "A100"}},
+		Status: corev1.NodeStatus{
+			Capacity: corev1.ResourceList{corev1.ResourceName("nvidia.com/gpu"): resource.MustParse(gpus)},
+		},
+	}
+}
+
+func TestBuildGPUHistoryGroupComparesAllocationAndUtilization(t *testing.T) {
+	handler := &ClusterHandler{config: models.DefaultConfig(), log: zap.NewNop()}
+	now := time.Unix(1773568800, 0)
+	points := func(values ...float64) []prometheus.SamplePoint {
+		out := make([]prometheus.SamplePoint, len(values))
+		for i, value := range values {
+			out[i] = prometheus.SamplePoint{Time: now.Add(time.Duration(i) * time.Minute), Value: value}
+		}
+		return out
+	}
+	history := &prometheus.AcceleratorHistory{
+		Type:        "nvidia",
+		Utilization: points(20, 20),
+		Allocated:   points(8, 10),
+		Busy:        points(2, 2),
+		Nodes: []prometheus.NodeHistory{
+			{Node: "gpu-2", Utilization: points(10, 30), Allocated: points(4, 6)},
+			{Node: "gpu-removed", Utilization: points(50), Allocated: points(1)},
+		},
+	}
+	nodes := []corev1.Node{*newGPUHistoryTestNode("gpu-2", "8"), *newGPUHistoryTestNode("gpu-1", "2"), {ObjectMeta: metav1.ObjectMeta{Name: "cpu-1"}}}
+
+	group := handler.buildGPUHistoryGroup(models.AcceleratorType{Type: "nvidia", Label: "NVIDIA GPU", ResourceName: "nvidia.com/gpu"}, nodes, history)
+
+	if group.TotalDevices != 10 {
+		t.Fatalf("expected 10 devices, got %d", group.TotalDevices)
+	}
+	if group.AvgAllocationRate != 90 || group.AvgUtilization != 20 || group.AvgBusyRate != 20 {
+		t.Fatalf("unexpected averages: allocation=%v utilization=%v busy=%v", group.AvgAllocationRate, group.AvgUtilization, group.AvgBusyRate)
+	}
+	if len(group.Nodes) != 3 || group.Nodes[0].NodeName != "gpu-1" || group.Nodes[1].NodeName != "gpu-2" || group.Nodes[2].NodeName != "gpu-removed" {
+		t.Fatalf("unexpected nodes %+v", group.Nodes)
+	}
+	if group.Nodes[0].AvgAllocationRate != -1 || len(group.Nodes[0].Utilization) != 0 {
+		t.Fatalf("node without history should have no allocation data: %+v", group.Nodes[0])
+	}
+	if group.Nodes[1].AvgAllocationRate != 62.5 || group.Nodes[1].AvgUtilization != 20 || group.Nodes[1].DeviceType != "A100" {
+		t.Fatalf("unexpected gpu-2 summary %+v", group.Nodes[1])
+	}
+	if group.Nodes[2].TotalDevices != 0 || group.Nodes[2].AvgAllocationRate != -1 {
+		t.Fatalf("removed node should keep series without capacity: %+v", group.Nodes[2])
+	}
+}
+
+func TestGetGPUHistory(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	config := models.DefaultConfig()
+	config.GPU.AvailableTypes = []models.GPUType{
+		{Name: "A100", ResourceName: "nvidia.com/gpu", Type: "nvidia", MetricName: "DCGM_FI_DEV_GPU_UTIL"},
+	}
+	handler := &ClusterHandler{
+		k8sClient: k8s.NewClientForTest(fake.NewSimpleClientset(newGPUHistoryTestNode("gpu-1", "4")), config),
+		config:    config,
+		log:       zap.NewNop(),
+	}
+	router := gin.New()
+	router.GET("/api/admin/gpu-history", handler.GetGPUHistory)
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/api/admin/gpu-history", nil))
+	if recorder.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503 without prometheus, got %d", recorder.Code)
+	}
+
+	var gotWindow, gotStep time.Duration
+	handler.queryAcceleratorHistoryFn = func(_ context.Context, accType prometheus.AcceleratorTypeConfig, start, end time.Time, step time.Duration) (*prometheus.AcceleratorHistory, error) {
+		if accType.ResourceName != "nvidia.com/gpu" || accType.MetricName != "DCGM_FI_DEV_GPU_UTIL" {
+			t.Errorf("unexpected accelerator type %+v", accType)
+		}
+		gotWindow, gotStep = end.Sub(start), step
+		return &prometheus.AcceleratorHistory{
+			Type:        "nvidia",
+			Utilization: []prometheus.SamplePoint{{Time: end, Value: 35}},
+			Allocated:   []prometheus.SamplePoint{{Time: end, Value: 3}},
+		}, nil
+	}
+
+	recorder = httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/api/admin/gpu-history?range=7d", nil))
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", recorder.Code, recorder.Body.String())
+	}
+	if gotWindow != 7*24*time.Hour || gotStep != prometheus.RangeStep(7*24*time.Hour) {
+		t.Fatalf("unexpected window %s step %s", gotWindow, gotStep)
+	}
+	var resp GPUHistoryResponse
+	if err := json.Unmarshal(recorder.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(resp.Groups) != 1 || resp.Groups[0].TotalDevices != 4 || resp.Groups[0].AvgAllocationRate != 75 {
+		t.Fatalf("unexpected response %+v", resp.Groups)
+	}
+
+	recorder = httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/api/admin/gpu-history?range=30d", nil))
+	if recorder.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for range beyond 7d, got %d", recorder.Code)
+	}
+}
@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/uc-package/genet/internal/auth"
+	"github.com/uc-package/genet/internal/k8s"
+	"github.com/uc-package/genet/internal/models"
+	"go.uber.org/zap"
+)
+
+const (
+	defaultCommitPageSize = 20
+	maxCommitPageSize     = 100
+)
+
+// ListCommits 分页获取镜像保存历史（最新在前）
+// GET /api/commits?page=1&pageSize=20&pod=xxx&status=Failed
+func (h *ImageHandler) ListCommits(c *gin.Context) {
+	username, _ := auth.GetUsername(c)
+	email, _ := auth.GetEmail(c)
+	userIdentifier := k8s.GetUserIdentifier(username, email)
+	namespace := k8s.GetNamespaceForUserIdentifier(userIdentifier)
+	ctx := c.Request.Context()
+
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil || page <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的 page 参数"})
+		return
+	}
+	pageSize, err := strconv.Atoi(c.DefaultQuery("pageSize", strconv.Itoa(defaultCommitPageSize)))
+	if err != nil || pageSize <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的 pageSize 参数"})
+		return
+	}
+	if pageSize > maxCommitPageSize {
+		pageSize = maxCommitPageSize
+	}
+
+	// 先同步当前 Job 状态，列表不必等待后台同步周期
+	if err := h.k8sClient.SyncCommitHistory(ctx, namespace); err != nil {
+		h.log.Warn("Failed to sync commit history before listing",
+			zap.String("user", username),
+			zap.Error(err))
+	}
+
+	history, err := h.k8sClient.GetCommitHistory(ctx, namespace)
+	if err != nil {
+		h.log.Error("Failed to get commit history",
+			zap.String("user", username),
+			zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取镜像保存历史失败"})
+		return
+	}
+
+	pod := c.Query("pod")
+	status := c.Query("status")
+	records := make([]models.CommitRecord, 0, len(history.Records))
+	for _, record := range history.Records {
+		if (pod != "" && record.SourcePod != pod) || (status != "" && record.Status != status) {
+			continue
+		}
+		records = append(records, record)
+	}
+
+	start := (page - 1) * pageSize
+	if start > len(records) {
+		start = len(records)
+	}
+	end := start + pageSize
+	if end > len(records) {
+		end = len(records)
+	}
+
+	c.JSON(http.StatusOK, models.CommitListResponse{
+		Items:    records[start:end],
+		Total:    len(records),
+		Page:     page,
+		PageSize: pageSize,
+	})
+}
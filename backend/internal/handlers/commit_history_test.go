@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/uc-package/genet/internal/auth"
+	"github.com/uc-package/genet/internal/k8s"
+	"github.com/uc-package/genet/internal/models"
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func newCommitHistoryTestRouter(t *testing.T, records []models.CommitRecord) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	data, _ := json.Marshal(models.CommitHistory{Records: records})
+	clientset := fake.NewSimpleClientset(&corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: k8s.CommitHistoryConfigMapName, Namespace: "user-alice-alice"},
+		Data:       map[string]string{k8s.CommitHistoryDataKey: string(data)},
+	})
+	cfg := models.DefaultConfig()
+	cfg.OAuth.Enabled = true
+	auth.InitAuthMiddleware(cfg)
+	handler := &ImageHandler{k8sClient: k8s.NewClientForTest(clientset, cfg), config: cfg, log: zap.NewNop()}
+
+	router := gin.New()
+	router.GET("/api/commits", auth.AuthMiddleware(cfg), handler.ListCommits)
+	return router
+}
+
+func TestListCommitsPaginatesAndFilters(t *testing.T) {
+	base := time.Now()
+	var records []models.CommitRecord
+	for i := 0; i < 5; i++ {
+		pod := "pod-alice-a"
+		if i%2 == 1 {
+			pod = "pod-alice-b"
+		}
+		records = append(records, models.CommitRecord{
+			ID:          fmt.Sprintf("commit-alice-%d", i),
+			SourcePod:   pod,
+			Status:      "Succeeded",
+			TargetImage: fmt.Sprintf("registry.local/alice/a:v%d", i),
+			CreatedAt:   base.Add(-time.Duration(i) * time.Minute),
+		})
+	}
+	router := newCommitHistoryTestRouter(t, records)
+
+	get := func(target string) models.CommitListResponse {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, newPodProxyTestRequest(http.MethodGet, target, nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s: expected 200, got %d: %s", target, rec.Code, rec.Body.String())
+		}
+		var resp models.CommitListResponse
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+		return resp
+	}
+
+	resp := get("/api/commits?page=2&pageSize=2")
+	if resp.Total != 5 || len(resp.Items) != 2 || resp.Items[0].ID != "commit-alice-2" {
+		t.Fatalf("unexpected page %+v", resp)
+	}
+	resp = get("/api/commits?page=9")
+	if resp.Total != 5 || len(resp.Items) != 0 {
+		t.Fatalf("expected empty page past the end, got %+v", resp)
+	}
+	resp = get("/api/commits?pod=pod-alice-b")
+	if resp.Total != 2 || resp.Items[0].ID != "commit-alice-1" {
+		t.Fatalf("unexpected pod filter result %+v", resp)
+	}
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, newPodProxyTestRequest(http.MethodGet, "/api/commits?pageSize=abc", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for invalid pageSize, got %d", rec.Code)
+	}
+}
+
+func TestListCommitsEmptyHistory(t *testing.T) {
+	router := newCommitHistoryTestRouter(t, nil)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, newPodProxyTestRequest(http.MethodGet, "/api/commits", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+	var resp models.CommitListResponse
+	_ = json.Unmarshal(rec.Body.Bytes(), &resp)
+	if resp.Items == nil || resp.Total != 0 || resp.PageSize != defaultCommitPageSize {
+		t.Fatalf("unexpected response %s", rec.Body.String())
+	}
+}
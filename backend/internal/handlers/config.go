@@ -47,14 +47,23 @@ func (h *ConfigHandler) GetConfig(c *gin.Context) {
 		UserMountAllowedPaths: h.config.Storage.UserMountAllowedPaths,
 		StorageVolumes:    storageVolumes,
 		RegistryURL:       h.config.Registry.URL,
+		SearchRegistries:  h.getSearchRegistriesInfo(),
+		CommitDefaultExcludes: h.config.Commit.DefaultExcludes,
+		CommitSquash:          h.config.Commit.Squash,
 		CleanupSchedule:   h.config.Cleanup.Schedule,
 		CleanupTimezone:   h.config.Cleanup.Timezone,
 	}
 
+	// 多仓库时按用户/团队返回其推送仓库，用于拼接保存镜像的完整名称
+	username, _ := auth.GetUsername(c)
+	email, _ := auth.GetEmail(c)
+	if registry := h.config.PushRegistryFor(username, email); registry != nil {
+		response.RegistryURL = registry.Host()
+	}
+
 	// 如果用户已认证，返回其保存的镜像列表
 	if h.k8sClient != nil {
-		if username, exists := auth.GetUsername(c); exists && username != "" {
-			email, _ := auth.GetEmail(c)
+		if username != "" {
 			userIdentifier := k8s.GetUserIdentifier(username, email)
 			namespace := k8s.GetNamespaceForUserIdentifier(userIdentifier)
 			ctx := c.Request.Context()
@@ -73,6 +82,20 @@ func (h *ConfigHandler) GetConfig(c *gin.Context) {
 	c.JSON(http.StatusOK, response)
 }
 
+// getSearchRegistriesInfo 返回用于镜像搜索的仓库（不含凭据）
+func (h *ConfigHandler) getSearchRegistriesInfo() []models.RegistryInfo {
+	registries := h.config.RegistriesFor(models.RegistryPurposeSearch)
+	result := make([]models.RegistryInfo, 0, len(registries))
+	for _, registry := range registries {
+		result = append(result, models.RegistryInfo{
+			Name: registry.DisplayName(),
+			Host: registry.Host(),
+			Type: registry.Type,
+		})
+	}
+	return result
+}
+
 // getStorageVolumesInfo 获取存储卷信息（用于前端展示）
 func (h *ConfigHandler) getStorageVolumesInfo() []models.StorageVolumeInfo {
 	volumes := h.config.Storage.GetEffectiveVolumes()
@@ -13,6 +13,7 @@ import (
 	"github.com/uc-package/genet/internal/auth"
 	"github.com/uc-package/genet/internal/k8s"
 	"github.com/uc-package/genet/internal/logger"
+	"github.com/uc-package/genet/internal/metrics"
 	"github.com/uc-package/genet/internal/models"
 	"go.uber.org/zap"
 	appsv1 "k8s.io/api/apps/v1"
@@ -46,6 +47,10 @@ func (h *DeploymentHandler) CreateDeployment(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	warnings, ok := h.podHandler.imageScan.checkWorkloadImage(c, req.Image)
+	if !ok {
+		return
+	}
 	if err := ValidateCPU(req.CPU); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
@@ -102,6 +107,7 @@ func (h *DeploymentHandler) CreateDeployment(c *gin.Context) {
 	}
 
 	if err := h.checkQuota(ctx, namespace, req.Replicas, req.GPUCount); err != nil {
+		metrics.RecordQuotaRejection("deployment")
 		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
 		return
 	}
@@ -168,11 +174,15 @@ func (h *DeploymentHandler) CreateDeployment(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusCreated, gin.H{
+	resp := gin.H{
 		"message": "Deployment 创建成功",
 		"id":      workloadName,
 		"name":    workloadName,
-	})
+	}
+	if len(warnings) > 0 {
+		resp["warnings"] = warnings
+	}
+	c.JSON(http.StatusCreated, resp)
 }
 
 func (h *DeploymentHandler) ListDeployments(c *gin.Context) {
@@ -228,6 +238,10 @@ func (h *DeploymentHandler) DeleteDeployment(c *gin.Context) {
 		c.JSON(http.StatusConflict, gin.H{"error": "外部 Deployment 为只读展示，不能在 Genet 中删除"})
 		return
 	}
+	// 副本随工作负载一起删除，先归档日志（失败不影响删除）
+	if pods, err := h.k8sClient.ListDeploymentPods(ctx, namespace, name); err == nil {
+		h.podHandler.archiveWorkloadPodLogs(ctx, pods, "workload-delete")
+	}
 	if err := h.k8sClient.DeleteDeployment(ctx, namespace, name); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"context"
 	"net/http"
 	"sort"
 	"time"
@@ -10,26 +11,35 @@ import (
 	"github.com/uc-package/genet/internal/k8s"
 	"github.com/uc-package/genet/internal/logger"
 	"github.com/uc-package/genet/internal/models"
+	"github.com/uc-package/genet/internal/registry"
 	"go.uber.org/zap"
 )
 
 // ImageHandler 用户镜像处理器
 type ImageHandler struct {
-	k8sClient *k8s.Client
-	config    *models.Config
-	log       *zap.Logger
+	k8sClient  *k8s.Client
+	registries *registry.Set
+	config     *models.Config
+	log        *zap.Logger
 }
 
 // NewImageHandler 创建用户镜像处理器
 func NewImageHandler(k8sClient *k8s.Client, config *models.Config) *ImageHandler {
+	log := logger.Named("image-handler")
+	registries, err := registry.NewSet(config)
+	if err != nil {
+		log.Warn("Failed to initialize registry clients, image details disabled", zap.Error(err))
+	}
 	return &ImageHandler{
-		k8sClient: k8sClient,
-		config:    config,
-		log:       logger.Named("image-handler"),
+		k8sClient:  k8sClient,
+		registries: registries,
+		config:     config,
+		log:        log,
 	}
 }
 
-// ListUserImages 获取用户保存的镜像列表
+// ListUserImages 获取用户保存的镜像列表；details=true 时为配置的 registry 中的镜像附带 digest、大小等元数据
+// GET /api/images?details=true
 func (h *ImageHandler) ListUserImages(c *gin.Context) {
 	username, _ := auth.GetUsername(c)
 	email, _ := auth.GetEmail(c)
@@ -51,6 +61,10 @@ func (h *ImageHandler) ListUserImages(c *gin.Context) {
 		return imageList.Images[i].SavedAt.After(imageList.Images[j].SavedAt)
 	})
 
+	if c.Query("details") == "true" {
+		h.fillImageManifests(ctx, imageList.Images)
+	}
+
 	c.JSON(http.StatusOK, imageList)
 }
 
@@ -120,3 +134,31 @@ func (h *ImageHandler) DeleteUserImage(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{"message": "镜像记录已删除"})
 }
+
+// fillImageManifests 查询镜像元数据；不在已配置的 registry 中或查询失败的镜像保持为空
+func (h *ImageHandler) fillImageManifests(ctx context.Context, images []models.UserSavedImage) {
+	if !h.registries.IsConfigured() {
+		return
+	}
+	refs := make([]manifestRef, 0, len(images))
+	indexes := make([]int, 0, len(images))
+	for i, image := range images {
+		client, repository, reference, ok := h.registries.Resolve(image.Image)
+		if !ok {
+			continue
+		}
+		refs = append(refs, manifestRef{Client: client, Repository: repository, Reference: reference})
+		indexes = append(indexes, i)
+	}
+
+	manifests, errs := fetchManifests(ctx, refs)
+	for j, i := range indexes {
+		if errs[j] != nil {
+			h.log.Debug("Failed to get image manifest",
+				zap.String("image", images[i].Image),
+				zap.Error(errs[j]))
+			continue
+		}
+		images[i].Manifest = manifests[j]
+	}
+}
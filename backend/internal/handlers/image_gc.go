@@ -0,0 +1,72 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/uc-package/genet/internal/imagegc"
+	"github.com/uc-package/genet/internal/logger"
+	"github.com/uc-package/genet/internal/models"
+	"go.uber.org/zap"
+)
+
+// ImageGCHandler 镜像保留策略与 GC 管理接口
+type ImageGCHandler struct {
+	collector *imagegc.Collector
+	config    *models.Config
+	log       *zap.Logger
+}
+
+// NewImageGCHandler 创建镜像 GC 处理器
+func NewImageGCHandler(collector *imagegc.Collector, config *models.Config) *ImageGCHandler {
+	return &ImageGCHandler{
+		collector: collector,
+		config:    config,
+		log:       logger.Named("imagegc"),
+	}
+}
+
+// RunImageGCRequest 手动触发 GC 请求
+type RunImageGCRequest struct {
+	DryRun bool `json:"dryRun"` // 只生成报告，不删除
+}
+
+// ImageGCStatusResponse 当前保留策略与最近一次 GC 结果
+type ImageGCStatusResponse struct {
+	Policy     models.ImageGCConfig  `json:"policy"`
+	LastReport *models.ImageGCReport `json:"lastReport,omitempty"`
+}
+
+// GetImageGC 获取保留策略与最近一次 GC 报告
+// GET /api/admin/images/gc
+func (h *ImageGCHandler) GetImageGC(c *gin.Context) {
+	c.JSON(http.StatusOK, ImageGCStatusResponse{
+		Policy:     h.config.ImageGC,
+		LastReport: h.collector.LastReport(),
+	})
+}
+
+// RunImageGC 立即执行一次 GC，dryRun=true 时只返回将要删除的镜像
+// POST /api/admin/images/gc
+func (h *ImageGCHandler) RunImageGC(c *gin.Context) {
+	var req RunImageGCRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "无效的请求参数: " + err.Error()})
+			return
+		}
+	}
+
+	report, err := h.collector.Run(c.Request.Context(), req.DryRun, imagegc.TriggerAdmin)
+	if err != nil {
+		if errors.Is(err, imagegc.ErrRunning) {
+			c.JSON(http.StatusConflict, gin.H{"error": "已有镜像 GC 正在运行"})
+			return
+		}
+		h.log.Warn("Image gc failed", zap.Bool("dryRun", req.DryRun), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "镜像 GC 失败: " + err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
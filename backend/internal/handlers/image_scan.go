@@ -0,0 +1,145 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/uc-package/genet/internal/models"
+	"github.com/uc-package/genet/internal/registry"
+)
+
+// 镜像漏洞策略的检查结果
+const (
+	ImageScanVerdictAllow = "allow"
+	ImageScanVerdictWarn  = "warn"
+	ImageScanVerdictBlock = "block"
+)
+
+// ImageScanResult 镜像按漏洞策略检查的结果
+type ImageScanResult struct {
+	Image           string                       `json:"image"`
+	Verdict         string                       `json:"verdict"`           // allow | warn | block
+	Message         string                       `json:"message,omitempty"` // 告警或阻止原因
+	Vulnerabilities *models.VulnerabilitySummary `json:"vulnerabilities,omitempty"`
+}
+
+// imageScanChecker 创建工作负载前按 registry 漏洞扫描结果检查镜像
+type imageScanChecker struct {
+	registries *registry.Set
+	config     *models.Config
+}
+
+func newImageScanChecker(config *models.Config) *imageScanChecker {
+	registries, _ := registry.NewSet(config)
+	return &imageScanChecker{registries: registries, config: config}
+}
+
+// Check 检查镜像；不在配置的 registry 中的镜像按 externalImages 处理，策略关闭时直接返回 allow，不访问 registry
+func (p *imageScanChecker) Check(ctx context.Context, image string) ImageScanResult {
+	if p == nil {
+		return ImageScanResult{Image: image, Verdict: ImageScanVerdictAllow}
+	}
+	if _, _, _, ok := p.registries.Resolve(image); !ok {
+		return p.externalImageResult(image)
+	}
+	if !p.enforced() {
+		return ImageScanResult{Image: image, Verdict: ImageScanVerdictAllow}
+	}
+	return p.Report(ctx, image)
+}
+
+// Report 查询镜像的漏洞扫描摘要并按当前策略给出结论；策略关闭时结论始终为 allow
+func (p *imageScanChecker) Report(ctx context.Context, image string) ImageScanResult {
+	result := ImageScanResult{Image: image, Verdict: ImageScanVerdictAllow}
+	if p == nil {
+		return result
+	}
+	client, repository, reference, ok := p.registries.Resolve(image)
+	if !ok {
+		return p.externalImageResult(image)
+	}
+	policy := p.config.ImageScan
+	verdict := ImageScanVerdictAllow
+	if p.enforced() {
+		verdict = policy.Policy
+	}
+	threshold := policy.SeverityThreshold
+	if threshold == "" {
+		threshold = models.SeverityCritical
+	}
+
+	manifest, err := client.GetManifest(ctx, repository, reference)
+	if err != nil {
+		if policy.RequireScan {
+			result.Verdict = verdict
+			result.Message = fmt.Sprintf("无法获取镜像漏洞扫描结果: %v", err)
+		}
+		return result
+	}
+	summary := manifest.Vulnerabilities
+	result.Vulnerabilities = summary
+
+	if summary == nil || summary.ScanStatus != "Success" {
+		if policy.RequireScan {
+			status := "NotScanned"
+			if summary != nil {
+				status = summary.ScanStatus
+			}
+			result.Verdict = verdict
+			result.Message = fmt.Sprintf("镜像尚未完成漏洞扫描（%s）", status)
+		}
+		return result
+	}
+
+	if count := summary.CountAtLeast(threshold); count > 0 {
+		result.Verdict = verdict
+		result.Message = fmt.Sprintf("镜像包含 %d 个 %s 及以上级别的漏洞（%s）", count, threshold, formatSeverityCounts(summary))
+	}
+	return result
+}
+
+// externalImageResult 不在配置的 registry 中的镜像拿不到扫描结果，按 imageScan.externalImages 告警或阻止
+func (p *imageScanChecker) externalImageResult(image string) ImageScanResult {
+	result := ImageScanResult{Image: image, Verdict: ImageScanVerdictAllow}
+	switch policy := p.config.ImageScan.ExternalImages; policy {
+	case ImageScanVerdictWarn, ImageScanVerdictBlock:
+		result.Verdict = policy
+		result.Message = "镜像不在已配置的镜像仓库中，无法获取漏洞扫描结果"
+	}
+	return result
+}
+
+func (p *imageScanChecker) enforced() bool {
+	policy := p.config.ImageScan.Policy
+	return policy == ImageScanVerdictWarn || policy == ImageScanVerdictBlock
+}
+
+// checkWorkloadImage 创建工作负载前检查镜像；被阻止时写入 403 响应并返回 false，告警时返回告警信息
+func (p *imageScanChecker) checkWorkloadImage(c *gin.Context, image string) (warnings []string, ok bool) {
+	result := p.Check(c.Request.Context(), image)
+	switch result.Verdict {
+	case ImageScanVerdictBlock:
+		c.JSON(http.StatusForbidden, gin.H{
+			"error":     "镜像未通过漏洞策略检查: " + result.Message,
+			"imageScan": result,
+		})
+		return nil, false
+	case ImageScanVerdictWarn:
+		return []string{result.Message}, true
+	}
+	return nil, true
+}
+
+// formatSeverityCounts 如 "Critical 1, High 3"
+func formatSeverityCounts(summary *models.VulnerabilitySummary) string {
+	parts := make([]string, 0, len(summary.Counts))
+	for _, severity := range []string{models.SeverityCritical, models.SeverityHigh, models.SeverityMedium, models.SeverityLow} {
+		if count := summary.Counts[severity]; count > 0 {
+			parts = append(parts, fmt.Sprintf("%s %d", severity, count))
+		}
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/uc-package/genet/internal/models"
+)
+
+func TestImageScanCheckerPolicy(t *testing.T) {
+	cfg := models.DefaultConfig()
+	cfg.Registry.URL = "registry.local"
+	reg := &fakeManifestRegistry{manifests: map[string]*models.ImageManifest{
+		"alice/train:v1": {Vulnerabilities: &models.VulnerabilitySummary{
+			ScanStatus: "Success",
+			Counts:     map[string]int{models.SeverityHigh: 2, models.SeverityLow: 4},
+		}},
+		"alice/train:v2": {Vulnerabilities: &models.VulnerabilitySummary{ScanStatus: "Running"}},
+	}}
+	checker := &imageScanChecker{registries: testRegistrySet(cfg, reg), config: cfg}
+	ctx := context.Background()
+
+	if result := checker.Check(ctx, "registry.local/alice/train:v1"); result.Verdict != ImageScanVerdictAllow || result.Vulnerabilities != nil {
+		t.Fatalf("policy off should allow without lookup, got %+v", result)
+	}
+	if result := checker.Report(ctx, "registry.local/alice/train:v1"); result.Verdict != ImageScanVerdictAllow || result.Vulnerabilities == nil {
+		t.Fatalf("report should include vulnerabilities when policy off, got %+v", result)
+	}
+
+	cfg.ImageScan.Policy = ImageScanVerdictBlock
+	if result := checker.Check(ctx, "registry.local/alice/train:v1"); result.Verdict != ImageScanVerdictAllow {
+		t.Fatalf("High below Critical threshold should be allowed, got %+v", result)
+	}
+
+	cfg.ImageScan.SeverityThreshold = models.SeverityHigh
+	if result := checker.Check(ctx, "registry.local/alice/train:v1"); result.Verdict != ImageScanVerdictBlock || result.Message == "" {
+		t.Fatalf("expected block at High threshold, got %+v", result)
+	}
+
+	cfg.ImageScan.Policy = ImageScanVerdictWarn
+	if result := checker.Check(ctx, "registry.local/alice/train:v2"); result.Verdict != ImageScanVerdictAllow {
+		t.Fatalf("unfinished scan should be allowed unless requireScan, got %+v", result)
+	}
+	cfg.ImageScan.RequireScan = true
+	if result := checker.Check(ctx, "registry.local/alice/train:v2"); result.Verdict != ImageScanVerdictWarn {
+		t.Fatalf("expected warn for unfinished scan, got %+v", result)
+	}
+	if result := checker.Check(ctx, "docker.io/library/ubuntu:22.04"); result.Verdict != ImageScanVerdictAllow {
+		t.Fatalf("images outside the registry are allowed by default, got %+v", result)
+	}
+
+	// externalImages 与 policy 独立生效
+	cfg.ImageScan.Policy = "off"
+	cfg.ImageScan.ExternalImages = ImageScanVerdictBlock
+	if result := checker.Check(ctx, "docker.io/library/ubuntu:22.04"); result.Verdict != ImageScanVerdictBlock || result.Message == "" {
+		t.Fatalf("expected external image blocked, got %+v", result)
+	}
+	if result := checker.Check(ctx, "registry.local/alice/train:v1"); result.Verdict != ImageScanVerdictAllow {
+		t.Fatalf("configured registry image should follow policy, got %+v", result)
+	}
+}
@@ -0,0 +1,99 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/uc-package/genet/internal/auth"
+	"github.com/uc-package/genet/internal/k8s"
+	"github.com/uc-package/genet/internal/models"
+	"github.com/uc-package/genet/internal/registry"
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// fakeManifestRegistry 只实现 GetManifest 的测试 registry
+type fakeManifestRegistry struct {
+	registry.Client
+	manifests map[string]*models.ImageManifest
+}
+
+func (r *fakeManifestRegistry) GetManifest(ctx context.Context, repository, reference string) (*models.ImageManifest, error) {
+	if manifest, ok := r.manifests[repository+":"+reference]; ok {
+		return manifest, nil
+	}
+	return nil, registry.ErrNotFound
+}
+
+func (r *fakeManifestRegistry) IsConfigured() bool {
+	return true
+}
+
+// testRegistrySet 以 cfg.Registry 注册假 registry
+func testRegistrySet(cfg *models.Config, client registry.Client) *registry.Set {
+	set := &registry.Set{}
+	set.Add(cfg.Registry, client)
+	return set
+}
+
+func TestListUserImagesWithDetails(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	data, _ := json.Marshal(models.UserImageList{Images: []models.UserSavedImage{
+		{Image: "registry.local/alice/train:v1"},
+		{Image: "docker.io/library/ubuntu:22.04"},
+	}})
+	clientset := fake.NewSimpleClientset(&corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: k8s.UserImagesConfigMapName, Namespace: "user-alice-alice"},
+		Data:       map[string]string{k8s.UserImagesDataKey: string(data)},
+	})
+	cfg := models.DefaultConfig()
+	cfg.OAuth.Enabled = true
+	cfg.Registry.URL = "registry.local"
+	auth.InitAuthMiddleware(cfg)
+	handler := &ImageHandler{
+		k8sClient: k8s.NewClientForTest(clientset, cfg),
+		registries: testRegistrySet(cfg, &fakeManifestRegistry{manifests: map[string]*models.ImageManifest{
+			"alice/train:v1": {Digest: "sha256:abc", Size: 1024, Platforms: []string{"linux/amd64"}},
+		}}),
+		config: cfg,
+		log:    zap.NewNop(),
+	}
+	router := gin.New()
+	router.GET("/api/images", auth.AuthMiddleware(cfg), handler.ListUserImages)
+
+	get := func(target string) models.UserImageList {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, newPodProxyTestRequest(http.MethodGet, target, nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s: expected 200, got %d: %s", target, rec.Code, rec.Body.String())
+		}
+		var resp models.UserImageList
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+		return resp
+	}
+
+	resp := get("/api/images")
+	if len(resp.Images) != 2 || resp.Images[0].Manifest != nil {
+		t.Fatalf("expected no manifest without details, got %+v", resp.Images)
+	}
+
+	resp = get("/api/images?details=true")
+	byImage := make(map[string]models.UserSavedImage)
+	for _, image := range resp.Images {
+		byImage[image.Image] = image
+	}
+	if m := byImage["registry.local/alice/train:v1"].Manifest; m == nil || m.Digest != "sha256:abc" || m.Size != 1024 {
+		t.Fatalf("expected manifest for registry image, got %+v", m)
+	}
+	if byImage["docker.io/library/ubuntu:22.04"].Manifest != nil {
+		t.Fatalf("expected no manifest for image outside the registry")
+	}
+}
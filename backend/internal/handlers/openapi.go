@@ -11,6 +11,7 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/uc-package/genet/internal/k8s"
 	"github.com/uc-package/genet/internal/logger"
+	"github.com/uc-package/genet/internal/metrics"
 	"github.com/uc-package/genet/internal/models"
 	"go.uber.org/zap"
 )
@@ -127,7 +128,7 @@ func (h *OpenAPIHandler) UpdatePod(c *gin.Context) {
 	ctx := c.Request.Context()
 	namespace := k8s.GetNamespaceForUserIdentifier(userIdentifier)
 
-	_, err := h.k8sClient.GetPod(ctx, namespace, podID)
+	pod, err := h.k8sClient.GetPod(ctx, namespace, podID)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Pod 不存在"})
 		return
@@ -135,7 +136,7 @@ func (h *OpenAPIHandler) UpdatePod(c *gin.Context) {
 
 	commitStatus, err := h.k8sClient.GetCommitJobStatus(ctx, namespace, podID)
 	if err == nil && commitStatus != nil {
-		if commitStatus.Status == "Running" || commitStatus.Status == "Pending" {
+		if commitStatus.Status == "Running" || commitStatus.Status == "Pending" || commitStatus.Status == "Queued" {
 			c.JSON(http.StatusConflict, gin.H{
 				"error": fmt.Sprintf("Pod 有正在进行的镜像保存任务（%s），请等待完成后再更新", commitStatus.Status),
 			})
@@ -143,7 +144,15 @@ func (h *OpenAPIHandler) UpdatePod(c *gin.Context) {
 		}
 	}
 
-	if err := h.k8sClient.DeletePod(ctx, namespace, podID); err != nil {
+	// 在删除旧 Pod 之前检查镜像，被阻止时保留旧 Pod（CreatePod 会再检查一次并返回告警）
+	if _, ok := h.podHandler.imageScan.checkWorkloadImage(c, req.Image); !ok {
+		return
+	}
+
+	h.podHandler.archivePodLogs(ctx, pod, "update")
+	err = h.k8sClient.DeletePod(ctx, namespace, podID)
+	metrics.RecordPodOperation("delete", err)
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("删除旧 Pod 失败: %v", err)})
 		return
 	}
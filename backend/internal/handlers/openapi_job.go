@@ -64,6 +64,11 @@ func (h *OpenAPIHandler) CreateJob(c *gin.Context) {
 		return
 	}
 
+	warnings, ok := h.podHandler.imageScan.checkWorkloadImage(c, req.Image)
+	if !ok {
+		return
+	}
+
 	namespace := openAPIOwnerNamespace(ownerUser)
 	ctx := c.Request.Context()
 	if err := h.k8sClient.EnsureNamespace(ctx, namespace); err != nil {
@@ -88,7 +93,9 @@ func (h *OpenAPIHandler) CreateJob(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusCreated, buildOpenAPIJobResponse(created))
+	resp := buildOpenAPIJobResponse(created)
+	resp.Warnings = warnings
+	c.JSON(http.StatusCreated, resp)
 }
 
 func (h *OpenAPIHandler) ListJobs(c *gin.Context) {
@@ -158,6 +165,11 @@ func (h *OpenAPIHandler) UpdateJob(c *gin.Context) {
 		c.JSON(http.StatusConflict, gin.H{"error": "running job cannot be updated"})
 		return
 	}
+	// 在删除旧 Job 之前检查镜像，被阻止时保留旧 Job
+	warnings, ok := h.podHandler.imageScan.checkWorkloadImage(c, req.Image)
+	if !ok {
+		return
+	}
 
 	job, err := h.k8sClient.BuildJobFromOpenAPIRequest(ctx, namespace, ownerUser, &req)
 	if err != nil {
@@ -178,7 +190,9 @@ func (h *OpenAPIHandler) UpdateJob(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, buildOpenAPIJobResponse(created))
+	resp := buildOpenAPIJobResponse(created)
+	resp.Warnings = warnings
+	c.JSON(http.StatusOK, resp)
 }
 
 func (h *OpenAPIHandler) DeleteJob(c *gin.Context) {
@@ -121,3 +121,34 @@ func TestOpenAPIJobUpdateRejectsActiveJob(t *testing.T) {
 		t.Fatalf("expected status 409, got %d, body=%s", rec.Code, rec.Body.String())
 	}
 }
+
+func TestOpenAPIJobCreateEnforcesImagePolicy(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	ownerUser := "alice"
+	namespace := k8s.GetNamespaceForUserIdentifier(k8s.GetUserIdentifier(ownerUser, ""))
+	clientset := fake.NewSimpleClientset()
+	cfg := models.DefaultConfig()
+	cfg.Registry.URL = "registry.local"
+	cfg.ImageScan.ExternalImages = ImageScanVerdictBlock
+	handler := NewOpenAPIHandler(k8s.NewClientWithClientset(clientset, cfg), cfg)
+
+	payload, err := json.Marshal(models.OpenAPIJobRequest{Name: "job-demo", Image: "docker.io/library/busybox:latest"})
+	if err != nil {
+		t.Fatalf("marshal request: %v", err)
+	}
+	rec := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(rec)
+	c.Request = httptest.NewRequest(http.MethodPost, "/api/open/jobs", bytes.NewReader(payload))
+	c.Request.Header.Set("Content-Type", "application/json")
+	c.Set("openapiOwnerUser", ownerUser)
+
+	handler.CreateJob(c)
+
+	if rec.Code != http.StatusForbidden {
+		t.Fatalf("expected status 403, got %d, body=%s", rec.Code, rec.Body.String())
+	}
+	if _, err := clientset.BatchV1().Jobs(namespace).Get(c.Request.Context(), "job-demo", metav1.GetOptions{}); err == nil {
+		t.Fatal("expected blocked job not to be created")
+	}
+}
@@ -3,12 +3,14 @@ package handlers
 import (
 	"bufio"
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"net"
 	"net/http"
 	"net/http/httputil"
 	"net/url"
+	"path"
 	"sort"
 	"strconv"
 	"strings"
@@ -18,7 +20,9 @@ import (
 	"github.com/gorilla/websocket"
 	"github.com/uc-package/genet/internal/auth"
 	"github.com/uc-package/genet/internal/k8s"
+	"github.com/uc-package/genet/internal/logarchive"
 	"github.com/uc-package/genet/internal/logger"
+	"github.com/uc-package/genet/internal/metrics"
 	"github.com/uc-package/genet/internal/models"
 	"github.com/uc-package/genet/internal/prometheus"
 	"go.uber.org/zap"
@@ -38,10 +42,22 @@ type PodHandler struct {
 	streamPodLogsFn     func(ctx context.Context, namespace, name string, options k8s.PodLogOptions) (io.ReadCloser, error)
 	codeServerProbe     func(ctx context.Context, host string, port int32) bool
 	codeServerTargetURL func(pod *corev1.Pod) (*url.URL, error)
+	podAppTargetURL     func(pod *corev1.Pod, port int) (*url.URL, error)
+	imageScan           *imageScanChecker
 	sessions            *WebShellSessionManager
+	recordings          *WebShellRecordingStore
+	logArchiver         *logarchive.Archiver
 	podLogsUpgrader     websocket.Upgrader
 	webShellUpgrader    websocket.Upgrader
 	webShellStreamFn    func(ctx context.Context, session WebShellSession, conn *websocket.Conn) error
+	sshTunnelDialFn     func(ctx context.Context, address string) (io.ReadWriteCloser, error)
+	sshTunnelExecFn     func(ctx context.Context, pod *corev1.Pod) (io.ReadWriteCloser, error)
+	startPortForwardFn  func(ctx context.Context, pod *corev1.Pod, ports []int) (map[int]string, error)
+	podExecFn           func(ctx context.Context, pod *corev1.Pod, command []string, stdin io.Reader, stdout io.Writer) error
+	listWorkloadPodsFn  func(ctx context.Context, namespace, kind, name string) ([]corev1.Pod, error)
+	queryPodMetricsFn   func(ctx context.Context, namespace, pod string, acceleratorTypes []prometheus.AcceleratorTypeConfig, start, end time.Time, step time.Duration) (*prometheus.PodMetrics, error)
+	// workloadLogResyncPeriod 合并日志时重新列出副本的间隔
+	workloadLogResyncPeriod time.Duration
 }
 
 var autoInjectedEnvVarOrder = []string{
@@ -86,6 +102,9 @@ func NewPodHandler(k8sClient *k8s.Client, promClient *prometheus.Client, config
 		config:     config,
 		log:        logger.Named("pod"),
 	}
+	if promClient != nil && promClient.IsEnabled() {
+		handler.queryPodMetricsFn = promClient.QueryPodMetrics
+	}
 	if k8sClient != nil {
 		handler.getPodFn = k8sClient.GetPod
 		handler.getPodLogsFn = k8sClient.GetPodLogs
@@ -96,9 +115,21 @@ func NewPodHandler(k8sClient *k8s.Client, promClient *prometheus.Client, config
 			return true
 		},
 	}
+	handler.imageScan = newImageScanChecker(config)
 	handler.codeServerProbe = probeCodeServer
 	handler.codeServerTargetURL = handler.defaultCodeServerTargetURL
+	handler.podAppTargetURL = handler.defaultPodAppTargetURL
 	handler.sessions = NewWebShellSessionManager(5 * time.Minute)
+	metrics.SetWebShellSessionsFunc(handler.sessions.AttachedCount)
+	if minutes := config.Pod.WebShell.DetachedSessionTTLMinutes; minutes > 0 {
+		handler.sessions.detachedTTL = time.Duration(minutes) * time.Minute
+	}
+	handler.recordings = NewWebShellRecordingStore(config.Pod.WebShell.Recording, handler.log)
+	if archiver, err := logarchive.NewArchiver(config.LogArchive, k8sClient); err != nil {
+		handler.log.Warn("Log archive disabled: invalid config", zap.Error(err))
+	} else {
+		handler.logArchiver = archiver
+	}
 	handler.webShellUpgrader = websocket.Upgrader{
 		CheckOrigin: func(_ *http.Request) bool {
 			return true
@@ -173,9 +204,18 @@ func (h *PodHandler) buildPodConnections(ctx context.Context, pod *corev1.Pod) *
 
 	if pod.Status.Phase == corev1.PodRunning {
 		connections.Apps.WebShellURL = fmt.Sprintf("/pods/%s/webshell", url.PathEscape(pod.Name))
+		if kind, name := podWorkloadKind(pod), pod.Labels["genet.io/workload-name"]; kind != "pod" && name != "" {
+			// 副本 Pod 带上所属工作负载，便于在 Web Shell 页面切换副本并校验归属
+			connections.Apps.WebShellURL += "?" + url.Values{
+				"workloadKind": {strings.TrimSuffix(kind, "-pod")},
+				"workloadName": {name},
+			}.Encode()
+		}
 		connections.Apps.WebShellReady = true
 		connections.Apps.WebShellStatus = "enabled"
 	}
+	connections.Apps.AppProxies = h.buildPodAppProxies(pod)
+	h.applyPodSSHConnections(connections, pod)
 
 	codeServerCfg := h.config.Pod.CodeServer
 	if !codeServerCfg.Enabled {
@@ -249,6 +289,8 @@ type podLogsResponse struct {
 
 type podLogStreamMessage struct {
 	Type    string `json:"type"`
+	Pod     string `json:"pod,omitempty"`     // 工作负载合并日志时标注来源副本
+	Ordinal *int   `json:"ordinal,omitempty"` // StatefulSet 副本序号
 	Content string `json:"content,omitempty"`
 	Cursor  string `json:"cursor,omitempty"`
 	Message string `json:"message,omitempty"`
@@ -328,27 +370,8 @@ func probeCodeServer(ctx context.Context, host string, port int32) bool {
 	return true
 }
 
-func buildCodeServerProxy(target *url.URL, forwardedPath string) *httputil.ReverseProxy {
-	return &httputil.ReverseProxy{
-		Director: func(req *http.Request) {
-			originalHost := req.Host
-			req.URL.Scheme = target.Scheme
-			req.URL.Host = target.Host
-			if forwardedPath == "" {
-				forwardedPath = "/"
-			}
-			req.URL.Path = forwardedPath
-			req.URL.RawPath = forwardedPath
-			req.Host = target.Host
-			req.Header.Set("X-Forwarded-Host", originalHost)
-			req.Header.Set("X-Forwarded-Proto", "https")
-		},
-		ErrorHandler: func(rw http.ResponseWriter, _ *http.Request, err error) {
-			rw.Header().Set("Content-Type", "application/json")
-			rw.WriteHeader(http.StatusBadGateway)
-			_, _ = rw.Write([]byte(fmt.Sprintf(`{"error":"代理 code-server 失败: %s"}`, err.Error())))
-		},
-	}
+func buildCodeServerProxy(target *url.URL, basePath, forwardedPath string) *httputil.ReverseProxy {
+	return buildPodAppProxy(target, basePath, forwardedPath, "code-server")
 }
 
 // ListPods 列出用户的所有 Pod
@@ -504,6 +527,11 @@ func (h *PodHandler) CreatePod(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	warnings, ok := h.imageScan.checkWorkloadImage(c, req.Image)
+	if !ok {
+		h.log.Warn("Image blocked by vulnerability policy", zap.String("image", req.Image))
+		return
+	}
 	if err := ValidateCPU(req.CPU); err != nil {
 		h.log.Warn("Invalid CPU value", zap.String("cpu", req.CPU), zap.Error(err))
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
@@ -580,6 +608,7 @@ func (h *PodHandler) CreatePod(c *gin.Context) {
 
 	// 检查配额
 	if err := h.checkQuota(ctx, userIdentifier, namespace, req.GPUCount); err != nil {
+		metrics.RecordQuotaRejection("pod")
 		h.log.Warn("Quota exceeded",
 			zap.String("user", username),
 			zap.String("userIdentifier", userIdentifier),
@@ -698,6 +727,16 @@ func (h *PodHandler) CreatePod(c *gin.Context) {
 			zap.Int("mountCount", len(req.UserMounts)))
 	}
 
+	// 验证开放给应用代理的端口
+	if err := validatePodProxyPorts(h.config.Pod.AppProxy, req.ProxyPorts); err != nil {
+		h.log.Warn("Invalid proxy ports",
+			zap.String("user", username),
+			zap.Ints("proxyPorts", req.ProxyPorts),
+			zap.Error(err))
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
 	// 确保命名空间存在
 	h.log.Debug("Ensuring namespace exists", zap.String("namespace", namespace))
 	if err := h.k8sClient.EnsureNamespace(ctx, namespace); err != nil {
@@ -790,12 +829,14 @@ func (h *PodHandler) CreatePod(c *gin.Context) {
 		NodeName:   req.NodeName,
 		GPUDevices: req.GPUDevices,
 		UserMounts: req.UserMounts,
+		ProxyPorts: req.ProxyPorts,
 	}
 
 	h.log.Debug("Creating pod resource",
 		zap.String("podName", podName))
 
 	_, err = h.k8sClient.CreatePod(ctx, spec)
+	metrics.RecordPodOperation("create", err)
 	if err != nil {
 		h.log.Error("Failed to create pod",
 			zap.String("user", username),
@@ -811,11 +852,15 @@ func (h *PodHandler) CreatePod(c *gin.Context) {
 		zap.String("image", req.Image),
 		zap.Int("gpuCount", req.GPUCount))
 
-	c.JSON(http.StatusCreated, gin.H{
+	resp := gin.H{
 		"message": "Pod 创建成功",
 		"id":      podName,
 		"name":    podName,
-	})
+	}
+	if len(warnings) > 0 {
+		resp["warnings"] = warnings
+	}
+	c.JSON(http.StatusCreated, resp)
 }
 
 // GetPod 获取 Pod 详情
@@ -889,7 +934,8 @@ func (h *PodHandler) ProxyCodeServer(c *gin.Context) {
 		forwardedPath = "/"
 	}
 
-	buildCodeServerProxy(targetURL, forwardedPath).ServeHTTP(c.Writer, c.Request)
+	basePath := fmt.Sprintf("/api/pods/%s/apps/code-server", url.PathEscape(pod.Name))
+	buildCodeServerProxy(targetURL, basePath, forwardedPath).ServeHTTP(c.Writer, c.Request)
 }
 
 // DownloadPodYAML 下载 Pod 的 portable YAML
@@ -1043,8 +1089,11 @@ func (h *PodHandler) DeletePod(c *gin.Context) {
 		}
 	}
 
+	h.archivePodLogs(ctx, pod, "user")
+
 	// 删除 Pod
 	err = h.k8sClient.DeletePod(ctx, namespace, podID)
+	metrics.RecordPodOperation("delete", err)
 	if err != nil {
 		h.log.Error("Failed to delete pod",
 			zap.String("user", username),
@@ -1092,12 +1141,67 @@ func (h *PodHandler) GetPodLogs(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的 since 参数"})
 		return
 	}
+	filter, err := parsePodLogFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	download, _ := strconv.ParseBool(c.DefaultQuery("download", "false"))
 
 	h.log.Debug("Getting pod logs",
 		zap.String("user", username),
 		zap.String("podID", podID),
 		zap.Bool("previous", previous),
-		zap.Int64("tailLines", tailLines))
+		zap.Int64("tailLines", tailLines),
+		zap.Bool("filtered", filter != nil),
+		zap.Bool("download", download))
+
+	if download {
+		// 下载完整日志：当前容器必须可读，之前容器不存在时跳过
+		current, err := h.streamPodLogs(ctx, namespace, podID, k8s.PodLogOptions{Timestamps: true, SinceTime: sinceTime})
+		if err != nil {
+			h.log.Error("Failed to open pod logs for download",
+				zap.String("user", username),
+				zap.String("podID", podID),
+				zap.Error(err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("获取日志失败: %v", err)})
+			return
+		}
+		defer current.Close()
+		var previousReader io.Reader
+		if prev, err := h.streamPodLogs(ctx, namespace, podID, k8s.PodLogOptions{Previous: true, Timestamps: true, SinceTime: sinceTime}); err == nil {
+			defer prev.Close()
+			previousReader = prev
+		}
+		h.writePodLogsArchive(c, podID, previousReader, current, filter)
+		return
+	}
+
+	if filter != nil {
+		// 过滤后再截取末尾 tailLines 行，因此需要读取完整日志
+		stream, err := h.streamPodLogs(ctx, namespace, podID, k8s.PodLogOptions{
+			Previous:   previous,
+			Timestamps: true,
+			SinceTime:  sinceTime,
+		})
+		if err != nil {
+			h.log.Error("Failed to get pod logs",
+				zap.String("user", username),
+				zap.String("podID", podID),
+				zap.Bool("previous", previous),
+				zap.Error(err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("获取日志失败: %v", err)})
+			return
+		}
+		defer stream.Close()
+		resp, err := filterPodLogs(stream, filter, tailLines)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("读取日志失败: %v", err)})
+			return
+		}
+		c.JSON(http.StatusOK, resp)
+		return
+	}
 
 	logs, err := h.getPodLogs(ctx, namespace, podID, k8s.PodLogOptions{
 		TailLines:  tailLines,
@@ -1173,6 +1277,11 @@ func (h *PodHandler) PodLogsWebSocket(c *gin.Context) {
 // CommitImageRequest 镜像 commit 请求
 type CommitImageRequest struct {
 	ImageName string `json:"imageName" binding:"required"` // 目标镜像名称（包含 tag）
+	// 以下为可选项，未指定时使用管理员配置的 commit 默认值
+	Exclude           []string `json:"exclude,omitempty"`           // 追加排除的路径 glob
+	NoDefaultExcludes bool     `json:"noDefaultExcludes,omitempty"` // 不使用管理员配置的默认排除规则
+	Squash            *bool    `json:"squash,omitempty"`            // 合并本次提交产生的层
+	PreCommitHook     string   `json:"preCommitHook,omitempty"`     // 提交前在容器内执行的清理脚本
 }
 
 // CommitImage 将 Pod 保存为镜像（类似 docker commit）
@@ -1192,10 +1301,35 @@ func (h *PodHandler) CommitImage(c *gin.Context) {
 		return
 	}
 
+	excludes := req.Exclude
+	if !req.NoDefaultExcludes {
+		excludes = k8s.MergeCommitExcludes(h.config.Commit.DefaultExcludes, req.Exclude)
+	}
+	if err := k8s.ValidateCommitExcludes(excludes); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("无效的排除规则: %v", err)})
+		return
+	}
+	if err := k8s.ValidateCommitHook(req.PreCommitHook); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	squash := h.config.Commit.Squash
+	if req.Squash != nil {
+		squash = *req.Squash
+	}
+	excludesRequired := len(req.Exclude) > 0
+	if _, err := k8s.ResolveCommitExcludes(excludes, excludesRequired, squash); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
 	h.log.Info("Committing pod to image",
 		zap.String("user", username),
 		zap.String("podID", podID),
-		zap.String("targetImage", req.ImageName))
+		zap.String("targetImage", req.ImageName),
+		zap.Strings("excludes", excludes),
+		zap.Bool("squash", squash),
+		zap.Bool("preCommitHook", req.PreCommitHook != ""))
 
 	// 获取 Pod 信息
 	pod, err := h.k8sClient.GetPod(ctx, namespace, podID)
@@ -1223,12 +1357,32 @@ func (h *PodHandler) CommitImage(c *gin.Context) {
 		PodName:     podID,
 		Namespace:   namespace,
 		Username:    username,
+		Email:       email,
 		TargetImage: req.ImageName,
 		NodeName:    pod.Spec.NodeName,
+		ContainerID: k8s.WorkspaceContainerID(pod),
+
+		Excludes:         excludes,
+		Squash:           squash,
+		ExcludesRequired: excludesRequired,
+		PreCommitHook:    req.PreCommitHook,
 	}
 
 	// 创建 commit job
 	job, err := h.k8sClient.CreateCommitJob(ctx, spec)
+	if errors.Is(err, k8s.ErrPushRegistryNotAllowed) {
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	}
+	if errors.Is(err, k8s.ErrContainerIDNotFound) || errors.Is(err, k8s.ErrUnsupportedContainerRuntime) || errors.Is(err, k8s.ErrCommitExcludesRequireSquash) {
+		h.log.Warn("Cannot commit pod container",
+			zap.String("user", username),
+			zap.String("podID", podID),
+			zap.String("containerID", spec.ContainerID),
+			zap.Error(err))
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 	if err != nil {
 		h.log.Error("Failed to create commit job",
 			zap.String("user", username),
@@ -1283,30 +1437,7 @@ func (h *PodHandler) GetCommitStatus(c *gin.Context) {
 	}
 
 	// commit 成功且尚未保存镜像记录时，自动保存
-	if status.Status == "Succeeded" && !status.ImageSaved && status.TargetImage != "" {
-		image := &models.UserSavedImage{
-			Image:     status.TargetImage,
-			SourcePod: status.SourcePod,
-			SavedAt:   time.Now(),
-		}
-		if err := h.k8sClient.SaveUserImage(ctx, namespace, image); err != nil {
-			h.log.Warn("Failed to auto-save user image",
-				zap.String("user", username),
-				zap.String("image", status.TargetImage),
-				zap.Error(err))
-		} else {
-			// 标记 Job 已保存，避免重复保存
-			if err := h.k8sClient.MarkCommitJobImageSaved(ctx, namespace, status.JobName); err != nil {
-				h.log.Warn("Failed to mark job image-saved",
-					zap.String("jobName", status.JobName),
-					zap.Error(err))
-			}
-			status.ImageSaved = true
-			h.log.Info("Auto-saved user image on commit success",
-				zap.String("user", username),
-				zap.String("image", status.TargetImage))
-		}
-	}
+	h.autoSaveJobImage(ctx, username, namespace, status)
 
 	c.JSON(http.StatusOK, gin.H{
 		"hasJob":      true,
@@ -1316,6 +1447,9 @@ func (h *PodHandler) GetCommitStatus(c *gin.Context) {
 		"startTime":   status.StartTime,
 		"endTime":     status.EndTime,
 		"targetImage": status.TargetImage,
+		"size":        status.Size,
+		"baseSize":    status.BaseSize,
+		"sizeDelta":   status.SizeDelta,
 	})
 }
 
@@ -1345,9 +1479,218 @@ func (h *PodHandler) GetCommitLogs(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"logs": logs})
 }
 
-// BuildImage 触发镜像构建（保留原接口，重定向到 CommitImage）
+// autoSaveJobImage commit/构建任务成功且尚未保存镜像记录时，写入用户镜像列表并标记 Job
+func (h *PodHandler) autoSaveJobImage(ctx context.Context, username, namespace string, status *k8s.CommitJobStatus) {
+	if status.Status != "Succeeded" || status.ImageSaved || status.TargetImage == "" {
+		return
+	}
+	image := &models.UserSavedImage{
+		Image:     status.TargetImage,
+		SourcePod: status.SourcePod,
+		SavedAt:   time.Now(),
+	}
+	if err := h.k8sClient.SaveUserImage(ctx, namespace, image); err != nil {
+		h.log.Warn("Failed to auto-save user image",
+			zap.String("user", username),
+			zap.String("image", status.TargetImage),
+			zap.Error(err))
+		return
+	}
+	// 标记 Job 已保存，避免重复保存
+	if err := h.k8sClient.MarkCommitJobImageSaved(ctx, namespace, status.JobName); err != nil {
+		h.log.Warn("Failed to mark job image-saved",
+			zap.String("jobName", status.JobName),
+			zap.Error(err))
+	}
+	status.ImageSaved = true
+	h.log.Info("Auto-saved user image on job success",
+		zap.String("user", username),
+		zap.String("jobName", status.JobName),
+		zap.String("image", status.TargetImage))
+}
+
+// BuildImageRequest Dockerfile 镜像构建请求
+// Dockerfile 与 DockerfilePath 二选一；路径均为 Pod 内的绝对路径，且必须位于工作空间存储卷内
+type BuildImageRequest struct {
+	ImageName      string `json:"imageName" binding:"required"` // 目标镜像名称（包含 tag）
+	Dockerfile     string `json:"dockerfile"`                   // 内联 Dockerfile 内容
+	DockerfilePath string `json:"dockerfilePath"`               // 工作空间内 Dockerfile 路径
+	Context        string `json:"context"`                      // 构建上下文目录，默认为 Dockerfile 所在目录
+}
+
+// BuildImage 使用工作空间中的 Dockerfile 与构建上下文构建镜像并推送到镜像仓库
 func (h *PodHandler) BuildImage(c *gin.Context) {
-	h.CommitImage(c)
+	username, _ := auth.GetUsername(c)
+	email, _ := auth.GetEmail(c)
+	podID := c.Param("id")
+	userIdentifier := k8s.GetUserIdentifier(username, email)
+	namespace := k8s.GetNamespaceForUserIdentifier(userIdentifier)
+	ctx := c.Request.Context()
+
+	var req BuildImageRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.log.Warn("Invalid build request", zap.Error(err))
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请指定目标镜像名称"})
+		return
+	}
+	contextPath, dockerfilePath, err := normalizeBuildPaths(&req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	pod, err := h.getPod(ctx, namespace, podID)
+	if err != nil {
+		h.log.Warn("Pod not found for build",
+			zap.String("user", username),
+			zap.String("podID", podID),
+			zap.Error(err))
+		c.JSON(http.StatusNotFound, gin.H{"error": "Pod 不存在"})
+		return
+	}
+	// 构建 Job 需要调度到 Pod 所在节点才能挂载工作空间
+	if pod.Status.Phase != "Running" || pod.Spec.NodeName == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "只能在运行中的 Pod 工作空间中构建镜像"})
+		return
+	}
+
+	spec := &k8s.BuildSpec{
+		PodName:        podID,
+		Namespace:      namespace,
+		Username:       username,
+		Email:          email,
+		UserIdentifier: userIdentifier,
+		TargetImage:    req.ImageName,
+		NodeName:       pod.Spec.NodeName,
+		Dockerfile:     req.Dockerfile,
+		DockerfilePath: dockerfilePath,
+		ContextPath:    contextPath,
+	}
+
+	h.log.Info("Building image from Dockerfile",
+		zap.String("user", username),
+		zap.String("podID", podID),
+		zap.String("context", contextPath),
+		zap.String("dockerfile", dockerfilePath),
+		zap.Bool("inlineDockerfile", req.Dockerfile != ""),
+		zap.String("targetImage", req.ImageName))
+
+	job, err := h.k8sClient.CreateBuildJob(ctx, spec)
+	if errors.Is(err, k8s.ErrPushRegistryNotAllowed) {
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	}
+	if errors.Is(err, k8s.ErrBuildPathOutsideWorkspace) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		h.log.Error("Failed to create build job",
+			zap.String("user", username),
+			zap.String("podID", podID),
+			zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("创建构建任务失败: %v", err)})
+		return
+	}
+
+	h.log.Info("Build job created",
+		zap.String("user", username),
+		zap.String("podID", podID),
+		zap.String("jobName", job.Name),
+		zap.String("targetImage", req.ImageName))
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":     "镜像构建任务已创建",
+		"jobName":     job.Name,
+		"targetImage": req.ImageName,
+	})
+}
+
+// normalizeBuildPaths 校验并规范化构建请求中的路径，返回构建上下文与 Dockerfile 路径（内联时为空）
+func normalizeBuildPaths(req *BuildImageRequest) (string, string, error) {
+	if (req.Dockerfile == "") == (req.DockerfilePath == "") {
+		return "", "", fmt.Errorf("请提供 Dockerfile 内容或工作空间中的 Dockerfile 路径（二选一）")
+	}
+	dockerfilePath := ""
+	if req.DockerfilePath != "" {
+		if !path.IsAbs(req.DockerfilePath) {
+			return "", "", fmt.Errorf("Dockerfile 路径必须为绝对路径")
+		}
+		dockerfilePath = path.Clean(req.DockerfilePath)
+	}
+	contextPath := req.Context
+	if contextPath == "" {
+		if dockerfilePath == "" {
+			return "", "", fmt.Errorf("使用内联 Dockerfile 时必须指定构建上下文目录")
+		}
+		contextPath = path.Dir(dockerfilePath)
+	}
+	if !path.IsAbs(contextPath) {
+		return "", "", fmt.Errorf("构建上下文必须为绝对路径")
+	}
+	return path.Clean(contextPath), dockerfilePath, nil
+}
+
+// GetBuildStatus 获取构建任务状态
+func (h *PodHandler) GetBuildStatus(c *gin.Context) {
+	username, _ := auth.GetUsername(c)
+	email, _ := auth.GetEmail(c)
+	podID := c.Param("id")
+	userIdentifier := k8s.GetUserIdentifier(username, email)
+	namespace := k8s.GetNamespaceForUserIdentifier(userIdentifier)
+	ctx := c.Request.Context()
+
+	status, err := h.k8sClient.GetBuildJobStatus(ctx, namespace, podID)
+	if err != nil {
+		h.log.Error("Failed to get build status",
+			zap.String("user", username),
+			zap.String("podID", podID),
+			zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("获取状态失败: %v", err)})
+		return
+	}
+
+	if status == nil {
+		c.JSON(http.StatusOK, gin.H{
+			"hasJob":  false,
+			"message": "没有进行中的镜像构建任务",
+		})
+		return
+	}
+
+	h.autoSaveJobImage(ctx, username, namespace, status)
+
+	c.JSON(http.StatusOK, gin.H{
+		"hasJob":      true,
+		"jobName":     status.JobName,
+		"status":      status.Status,
+		"message":     status.Message,
+		"startTime":   status.StartTime,
+		"endTime":     status.EndTime,
+		"targetImage": status.TargetImage,
+	})
+}
+
+// GetBuildLogs 获取构建任务日志
+func (h *PodHandler) GetBuildLogs(c *gin.Context) {
+	username, _ := auth.GetUsername(c)
+	email, _ := auth.GetEmail(c)
+	podID := c.Param("id")
+	userIdentifier := k8s.GetUserIdentifier(username, email)
+	namespace := k8s.GetNamespaceForUserIdentifier(userIdentifier)
+	ctx := c.Request.Context()
+
+	logs, err := h.k8sClient.GetBuildJobLogs(ctx, namespace, podID)
+	if err != nil {
+		h.log.Error("Failed to get build logs",
+			zap.String("user", username),
+			zap.String("podID", podID),
+			zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("获取日志失败: %v", err)})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"logs": logs})
 }
 
 // GetPodEvents 获取 Pod 事件（类似 kubectl describe 的 Events 部分）
@@ -1362,13 +1705,7 @@ func (h *PodHandler) GetPodEvents(c *gin.Context) {
 		zap.String("user", username),
 		zap.String("podID", podID))
 
-	ctx := c.Request.Context()
-	clientset := h.k8sClient.GetClientset()
-
-	// 获取 Pod 相关的 Events
-	events, err := clientset.CoreV1().Events(namespace).List(ctx, metav1.ListOptions{
-		FieldSelector: fmt.Sprintf("involvedObject.name=%s,involvedObject.kind=Pod", podID),
-	})
+	events, err := h.k8sClient.GetPodEvents(c.Request.Context(), namespace, podID)
 	if err != nil {
 		h.log.Error("Failed to get pod events",
 			zap.String("user", username),
@@ -1380,7 +1717,7 @@ func (h *PodHandler) GetPodEvents(c *gin.Context) {
 
 	// 格式化事件
 	var eventList []map[string]interface{}
-	for _, event := range events.Items {
+	for _, event := range events {
 		eventList = append(eventList, map[string]interface{}{
 			"type":      event.Type,
 			"reason":    event.Reason,
@@ -1687,6 +2024,15 @@ func (h *PodHandler) queryAcceleratorMetricsForTypes(ctx context.Context, accele
 		return nil
 	}
 
+	metrics, err := h.promClient.QueryAcceleratorMetrics(ctx, toPrometheusAcceleratorTypes(acceleratorTypes))
+	if err != nil {
+		h.log.Warn("Failed to query accelerator metrics for auto-scheduling", zap.Error(err))
+		return nil
+	}
+	return metrics
+}
+
+func toPrometheusAcceleratorTypes(acceleratorTypes []models.AcceleratorType) []prometheus.AcceleratorTypeConfig {
 	promTypes := make([]prometheus.AcceleratorTypeConfig, len(acceleratorTypes))
 	for i, t := range acceleratorTypes {
 		promTypes[i] = prometheus.AcceleratorTypeConfig{
@@ -1702,13 +2048,7 @@ func (h *PodHandler) queryAcceleratorMetricsForTypes(ctx context.Context, accele
 			},
 		}
 	}
-
-	metrics, err := h.promClient.QueryAcceleratorMetrics(ctx, promTypes)
-	if err != nil {
-		h.log.Warn("Failed to query accelerator metrics for auto-scheduling", zap.Error(err))
-		return nil
-	}
-	return metrics
+	return promTypes
 }
 
 // checkQuota 检查用户配额
@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/uc-package/genet/internal/auth"
+	"github.com/uc-package/genet/internal/k8s"
+	"github.com/uc-package/genet/internal/models"
+	batchv1 "k8s.io/api/batch/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func newPodBuildTestRouter(t *testing.T) (*gin.Engine, *fake.Clientset) {
+	t.Helper()
+	pod := newPodProxyTestPod("10.0.0.8", "")
+	pod.Spec.NodeName = "node-1"
+	handler, _ := newPodProxyTestRouter(t, pod, "")
+	clientset := fake.NewSimpleClientset()
+	handler.k8sClient = k8s.NewClientForTest(clientset, handler.config)
+
+	router := gin.New()
+	pods := router.Group("/api/pods", auth.AuthMiddleware(handler.config))
+	pods.POST("/:id/build", handler.BuildImage)
+	pods.GET("/:id/build/status", handler.GetBuildStatus)
+	return router, clientset
+}
+
+func TestBuildImageCreatesBuildJob(t *testing.T) {
+	router, clientset := newPodBuildTestRouter(t)
+
+	body := `{"imageName":"registry.local/alice/train:v1","dockerfilePath":"` + models.DefaultWorkspaceDir + `/train/Dockerfile"}`
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, newPodProxyTestRequest(http.MethodPost, "/api/pods/pod-alice-dev/build", strings.NewReader(body)))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	jobs, _ := clientset.BatchV1().Jobs("user-alice-alice").List(context.Background(), metav1.ListOptions{LabelSelector: "genet.io/type=build"})
+	if len(jobs.Items) != 1 {
+		t.Fatalf("expected one build job, got %d", len(jobs.Items))
+	}
+	spec := jobs.Items[0].Spec.Template.Spec
+	if spec.NodeName != "node-1" {
+		t.Fatalf("expected build on pod node, got %q", spec.NodeName)
+	}
+	// 未指定 context 时使用 Dockerfile 所在目录
+	if !strings.Contains(strings.Join(spec.Containers[0].Args, " "), "--context=dir://"+models.DefaultWorkspaceDir+"/train") {
+		t.Fatalf("unexpected args %v", spec.Containers[0].Args)
+	}
+}
+
+func TestBuildImageRejectsInvalidRequests(t *testing.T) {
+	router, _ := newPodBuildTestRouter(t)
+	for name, body := range map[string]string{
+		"missing dockerfile":  `{"imageName":"a:v1","context":"/workspace-genet"}`,
+		"both dockerfiles":    `{"imageName":"a:v1","dockerfile":"FROM busybox","dockerfilePath":"/workspace-genet/Dockerfile"}`,
+		"inline no context":   `{"imageName":"a:v1","dockerfile":"FROM busybox"}`,
+		"relative context":    `{"imageName":"a:v1","dockerfile":"FROM busybox","context":"train"}`,
+		"outside workspace":   `{"imageName":"a:v1","dockerfile":"FROM busybox","context":"/etc"}`,
+		"traversal workspace": `{"imageName":"a:v1","dockerfilePath":"/workspace-genet/../etc/Dockerfile"}`,
+	} {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, newPodProxyTestRequest(http.MethodPost, "/api/pods/pod-alice-dev/build", strings.NewReader(body)))
+		if rec.Code != http.StatusBadRequest {
+			t.Fatalf("%s: expected 400, got %d: %s", name, rec.Code, rec.Body.String())
+		}
+	}
+}
+
+func TestGetBuildStatusSavesImageOnSuccess(t *testing.T) {
+	router, clientset := newPodBuildTestRouter(t)
+	_, err := clientset.BatchV1().Jobs("user-alice-alice").Create(context.Background(), &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "build-alice-1",
+			Namespace:   "user-alice-alice",
+			Labels:      map[string]string{"genet.io/type": "build", "genet.io/pod": "pod-alice-dev"},
+			Annotations: map[string]string{"genet.io/target-image": "registry.local/alice/train:v1", "genet.io/source-pod": "pod-alice-dev"},
+		},
+		Status: batchv1.JobStatus{Succeeded: 1},
+	}, metav1.CreateOptions{})
+	if err != nil {
+		t.Fatalf("create job: %v", err)
+	}
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, newPodProxyTestRequest(http.MethodGet, "/api/pods/pod-alice-dev/build/status", nil))
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"status":"Succeeded"`) {
+		t.Fatalf("unexpected response %d: %s", rec.Code, rec.Body.String())
+	}
+
+	job, _ := clientset.BatchV1().Jobs("user-alice-alice").Get(context.Background(), "build-alice-1", metav1.GetOptions{})
+	if job.Annotations["genet.io/image-saved"] != "true" {
+		t.Fatalf("expected job marked image-saved, got %+v", job.Annotations)
+	}
+	images, err := k8s.NewClientForTest(clientset, models.DefaultConfig()).GetUserImages(context.Background(), "user-alice-alice")
+	if err != nil || len(images.Images) != 1 || images.Images[0].Image != "registry.local/alice/train:v1" {
+		t.Fatalf("unexpected saved images %+v, %v", images, err)
+	}
+}
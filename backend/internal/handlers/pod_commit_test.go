@@ -0,0 +1,136 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/uc-package/genet/internal/auth"
+	"github.com/uc-package/genet/internal/k8s"
+	"github.com/uc-package/genet/internal/models"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func newPodCommitTestRouter(t *testing.T, configure ...func(*models.Config)) (*gin.Engine, *fake.Clientset) {
+	t.Helper()
+	pod := newPodProxyTestPod("10.0.0.8", "")
+	pod.Spec.NodeName = "node-1"
+	pod.Status.ContainerStatuses = []corev1.ContainerStatus{{Name: "workspace", ContainerID: "containerd://abc123"}}
+	handler, _ := newPodProxyTestRouter(t, pod, "")
+	handler.config.Commit.DefaultExcludes = []string{"**/__pycache__"}
+	handler.config.Commit.Squash = true
+	for _, fn := range configure {
+		fn(handler.config)
+	}
+	clientset := fake.NewSimpleClientset(pod)
+	handler.k8sClient = k8s.NewClientForTest(clientset, handler.config)
+
+	router := gin.New()
+	router.POST("/api/pods/:id/commit", auth.AuthMiddleware(handler.config), handler.CommitImage)
+	return router, clientset
+}
+
+func TestCommitImageAppliesDefaultExcludesAndOverrides(t *testing.T) {
+	router, clientset := newPodCommitTestRouter(t)
+
+	body := `{"imageName":"registry.local/alice/train:v1","exclude":["/root/.cache/*"]}`
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, newPodProxyTestRequest(http.MethodPost, "/api/pods/pod-alice-dev/commit", strings.NewReader(body)))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	jobs, _ := clientset.BatchV1().Jobs("user-alice-alice").List(context.Background(), metav1.ListOptions{LabelSelector: "genet.io/type=commit"})
+	if len(jobs.Items) != 1 {
+		t.Fatalf("expected one commit job, got %d", len(jobs.Items))
+	}
+	script := jobs.Items[0].Spec.Template.Spec.Containers[0].Args[0]
+	for _, want := range []string{"-name '__pycache__'", "rm -rf -- /root/.cache/*", `SQUASH_FLAG="--squash"`} {
+		if !strings.Contains(script, want) {
+			t.Fatalf("expected %q in commit script", want)
+		}
+	}
+
+	// 关闭 squash 时显式排除无法减小体积，直接拒绝
+	body = `{"imageName":"registry.local/alice/train:v2","exclude":["/root/.cache/*"],"squash":false}`
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, newPodProxyTestRequest(http.MethodPost, "/api/pods/pod-alice-dev/commit", strings.NewReader(body)))
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for excludes without squash, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	// 只有默认规则时关闭 squash 会跳过排除；Job 名按毫秒时间戳生成，先删掉上一个避免同名
+	_ = clientset.BatchV1().Jobs("user-alice-alice").Delete(context.Background(), jobs.Items[0].Name, metav1.DeleteOptions{})
+	body = `{"imageName":"registry.local/alice/train:v3","squash":false}`
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, newPodProxyTestRequest(http.MethodPost, "/api/pods/pod-alice-dev/commit", strings.NewReader(body)))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	jobs, _ = clientset.BatchV1().Jobs("user-alice-alice").List(context.Background(), metav1.ListOptions{LabelSelector: "genet.io/type=commit"})
+	for _, job := range jobs.Items {
+		script := job.Spec.Template.Spec.Containers[0].Args[0]
+		if !strings.Contains(script, "train:v3") {
+			continue
+		}
+		if strings.Contains(script, "__pycache__") || strings.Contains(script, `SQUASH_FLAG="--squash"`) {
+			t.Fatal("expected default excludes and squash to be skipped")
+		}
+		return
+	}
+	t.Fatal("expected a commit job for train:v3")
+}
+
+func TestCommitImageRejectsInvalidExclude(t *testing.T) {
+	router, clientset := newPodCommitTestRouter(t)
+
+	body := `{"imageName":"registry.local/alice/train:v1","exclude":["/*"]}`
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, newPodProxyTestRequest(http.MethodPost, "/api/pods/pod-alice-dev/commit", strings.NewReader(body)))
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d: %s", rec.Code, rec.Body.String())
+	}
+	jobs, _ := clientset.BatchV1().Jobs("user-alice-alice").List(context.Background(), metav1.ListOptions{})
+	if len(jobs.Items) != 0 {
+		t.Fatalf("expected no job, got %d", len(jobs.Items))
+	}
+}
+
+func TestCommitImageRequiresContainerID(t *testing.T) {
+	pod := newPodProxyTestPod("10.0.0.8", "")
+	handler, _ := newPodProxyTestRouter(t, pod, "")
+	handler.k8sClient = k8s.NewClientForTest(fake.NewSimpleClientset(pod), handler.config)
+	router := gin.New()
+	router.POST("/api/pods/:id/commit", auth.AuthMiddleware(handler.config), handler.CommitImage)
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, newPodProxyTestRequest(http.MethodPost, "/api/pods/pod-alice-dev/commit", strings.NewReader(`{"imageName":"registry.local/alice/train:v1"}`)))
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 without container ID, got %d: %s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestCommitImageRejectsOtherTeamRegistry(t *testing.T) {
+	router, clientset := newPodCommitTestRouter(t, func(cfg *models.Config) {
+		cfg.Registry = models.RegistryConfig{URL: "registry.local", Username: "robot", Password: "push"}
+		cfg.Registries = []models.RegistryConfig{
+			{Name: "team", URL: "harbor.team.local", Username: "team-robot", Password: "team", Users: []string{"bob"}},
+		}
+	})
+
+	body := `{"imageName":"harbor.team.local/alice/train:v1"}`
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, newPodProxyTestRequest(http.MethodPost, "/api/pods/pod-alice-dev/commit", strings.NewReader(body)))
+	if rec.Code != http.StatusForbidden {
+		t.Fatalf("expected 403, got %d: %s", rec.Code, rec.Body.String())
+	}
+	jobs, _ := clientset.BatchV1().Jobs("user-alice-alice").List(context.Background(), metav1.ListOptions{})
+	if len(jobs.Items) != 0 {
+		t.Fatalf("expected no commit job, got %d", len(jobs.Items))
+	}
+}
@@ -0,0 +1,339 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/uc-package/genet/internal/auth"
+	"github.com/uc-package/genet/internal/k8s"
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/remotecommand"
+	utilexec "k8s.io/client-go/util/exec"
+)
+
+const (
+	defaultMaxDownloadMB = 2048
+	defaultMaxUploadMB   = 1024
+	// podExecStderrLimit exec 失败时保留的 stderr 字节数
+	podExecStderrLimit = 4096
+)
+
+const (
+	// podPathNotFoundExitCode podPathSizeScript 在路径不存在时的退出码
+	podPathNotFoundExitCode = 3
+	// podPathSizeScript 先判断路径是否存在，用退出码区分“不存在”与其它 du 错误
+	podPathSizeScript = `[ -e "$1" ] || [ -L "$1" ] || exit 3; exec du -sk "$1"`
+	// podDownloadScript 打包 $1 下的 $2，最多输出 $3 字节：超限时 head 退出，tar 随 SIGPIPE 结束，
+	// 不必把剩余数据全部传回 API Server。shell 不支持 pipefail 时直接输出 tar，超限部分由服务端丢弃。
+	// ./ 前缀避免以 - 开头的文件名被 tar 当作参数
+	podDownloadScript = `if (set -o pipefail) 2>/dev/null; then
+	set -o pipefail
+	tar cf - -C "$1" "./$2" | head -c "$3"
+else
+	exec tar cf - -C "$1" "./$2"
+fi`
+)
+
+var (
+	errFileTransferTooLarge = errors.New("file transfer size limit exceeded")
+	errPodPathNotFound      = errors.New("path not found in pod")
+)
+
+// DownloadPodFiles 以 tar 流下载 Pod 内文件或目录
+func (h *PodHandler) DownloadPodFiles(c *gin.Context) {
+	pod, filePath, ok := h.preparePodFileTransfer(c)
+	if !ok {
+		return
+	}
+
+	limit := fileTransferLimit(h.config.Pod.FileTransfer.MaxDownloadMB, defaultMaxDownloadMB)
+	size, err := h.podPathSize(c.Request.Context(), pod, filePath)
+	if err != nil {
+		if errors.Is(err, errPodPathNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "路径不存在: " + filePath})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("读取文件大小失败: %v", err)})
+		return
+	}
+	if size > limit {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": fmt.Sprintf("文件超过下载上限 %d MiB", limit>>20)})
+		return
+	}
+
+	out := &fileDownloadWriter{c: c, filename: path.Base(filePath) + ".tar", remaining: limit}
+	command := []string{"/bin/sh", "-c", podDownloadScript, "sh", path.Dir(filePath), path.Base(filePath), strconv.FormatInt(limit+1, 10)}
+	err = h.podExec(c.Request.Context(), pod, command, nil, out)
+	if out.exceeded {
+		// du 按占用块统计，稀疏文件或下载期间增长的文件可能超出上限
+		h.log.Warn("Pod file download aborted at size limit",
+			zap.String("pod", pod.Name),
+			zap.String("path", filePath),
+			zap.Int64("limit", limit))
+		if !out.started {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": fmt.Sprintf("文件超过下载上限 %d MiB", limit>>20)})
+			return
+		}
+		// 已开始输出，中断连接让客户端看到下载失败，而不是一个被截断却“完整”的 tar
+		panic(http.ErrAbortHandler)
+	}
+	if err == nil {
+		return
+	}
+	h.log.Warn("Pod file download failed",
+		zap.String("pod", pod.Name),
+		zap.String("path", filePath),
+		zap.Error(err))
+	if out.started {
+		panic(http.ErrAbortHandler)
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("下载失败: %v", err)})
+}
+
+// podPathSize 通过 du -sk 估算 Pod 内路径占用的字节数，路径不存在时返回 errPodPathNotFound
+func (h *PodHandler) podPathSize(ctx context.Context, pod *corev1.Pod, filePath string) (int64, error) {
+	var out bytes.Buffer
+	command := []string{"/bin/sh", "-c", podPathSizeScript, "sh", filePath}
+	if err := h.podExec(ctx, pod, command, nil, &out); err != nil {
+		var exitErr utilexec.ExitError
+		if errors.As(err, &exitErr) && exitErr.ExitStatus() == podPathNotFoundExitCode {
+			return 0, errPodPathNotFound
+		}
+		return 0, err
+	}
+	fields := strings.Fields(out.String())
+	if len(fields) == 0 {
+		return 0, fmt.Errorf("unexpected du output %q", out.String())
+	}
+	kib, err := strconv.ParseInt(fields[0], 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("unexpected du output %q", out.String())
+	}
+	return kib << 10, nil
+}
+
+// UploadPodFiles 将请求体中的 tar 流解压到 Pod 内目录 path
+func (h *PodHandler) UploadPodFiles(c *gin.Context) {
+	pod, filePath, ok := h.preparePodFileTransfer(c)
+	if !ok {
+		return
+	}
+
+	limit := fileTransferLimit(h.config.Pod.FileTransfer.MaxUploadMB, defaultMaxUploadMB)
+	if c.Request.ContentLength > limit {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": fmt.Sprintf("文件超过上传上限 %d MiB", limit>>20)})
+		return
+	}
+	body := &limitedReader{r: c.Request.Body, remaining: limit}
+	command := []string{"/bin/sh", "-c", `mkdir -p "$1" && exec tar xof - -C "$1"`, "sh", filePath}
+	if err := h.podExec(c.Request.Context(), pod, command, body, io.Discard); err != nil {
+		h.log.Warn("Pod file upload failed",
+			zap.String("pod", pod.Name),
+			zap.String("path", filePath),
+			zap.Error(err))
+		if body.exceeded {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": fmt.Sprintf("文件超过上传上限 %d MiB", limit>>20)})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("上传失败: %v", err)})
+		return
+	}
+
+	h.log.Info("Uploaded files to pod",
+		zap.String("pod", pod.Name),
+		zap.String("path", filePath),
+		zap.Int64("bytes", limit-body.remaining))
+	c.JSON(http.StatusOK, gin.H{"message": "上传成功", "path": filePath, "bytes": limit - body.remaining})
+}
+
+// preparePodFileTransfer 校验开关、路径和 Pod 状态
+func (h *PodHandler) preparePodFileTransfer(c *gin.Context) (*corev1.Pod, string, bool) {
+	if !h.config.Pod.FileTransfer.Enabled {
+		c.JSON(http.StatusForbidden, gin.H{"error": "文件传输未启用"})
+		return nil, "", false
+	}
+	filePath, err := validatePodFilePath(c.Query("path"), h.config.Pod.FileTransfer.DeniedPaths)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return nil, "", false
+	}
+
+	username, _ := auth.GetUsername(c)
+	email, _ := auth.GetEmail(c)
+	userIdentifier := k8s.GetUserIdentifier(username, email)
+	namespace := k8s.GetNamespaceForUserIdentifier(userIdentifier)
+	pod, err := h.getPod(c.Request.Context(), namespace, c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Pod 不存在"})
+		return nil, "", false
+	}
+	if pod.Status.Phase != corev1.PodRunning {
+		c.JSON(http.StatusConflict, gin.H{"error": "Pod 未处于运行状态，暂时无法传输文件"})
+		return nil, "", false
+	}
+	return pod, filePath, true
+}
+
+// validatePodFilePath 要求绝对路径、不含 .. 且不在禁止的前缀下
+func validatePodFilePath(raw string, denied []string) (string, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return "", fmt.Errorf("path 不能为空")
+	}
+	if !strings.HasPrefix(raw, "/") {
+		return "", fmt.Errorf("path 必须是绝对路径")
+	}
+	if strings.ContainsRune(raw, 0) {
+		return "", fmt.Errorf("path 包含非法字符")
+	}
+	for _, segment := range strings.Split(raw, "/") {
+		if segment == ".." {
+			return "", fmt.Errorf("path 不能包含 ..")
+		}
+	}
+	cleaned := path.Clean(raw)
+	if cleaned == "/" {
+		return "", fmt.Errorf("不允许传输根目录")
+	}
+	for _, prefix := range denied {
+		prefix = path.Clean("/" + strings.TrimSpace(prefix))
+		if prefix == "/" {
+			continue
+		}
+		if cleaned == prefix || strings.HasPrefix(cleaned, prefix+"/") {
+			return "", fmt.Errorf("不允许访问 %s", prefix)
+		}
+	}
+	return cleaned, nil
+}
+
+func fileTransferLimit(configuredMB, defaultMB int64) int64 {
+	if configuredMB <= 0 {
+		configuredMB = defaultMB
+	}
+	return configuredMB << 20
+}
+
+// podExecContainer 返回执行命令使用的容器（与 Web Shell 一致，默认主容器）
+func (h *PodHandler) podExecContainer(pod *corev1.Pod) string {
+	container := h.getPodDisplayInfo(pod).ContainerName
+	if container == "" && len(pod.Spec.Containers) > 0 {
+		container = pod.Spec.Containers[0].Name
+	}
+	return container
+}
+
+// podExec 在 Pod 主容器内执行命令并转发 stdin/stdout，失败时附带 stderr
+func (h *PodHandler) podExec(ctx context.Context, pod *corev1.Pod, command []string, stdin io.Reader, stdout io.Writer) error {
+	if h.podExecFn != nil {
+		return h.podExecFn(ctx, pod, command, stdin, stdout)
+	}
+	restConfig := h.k8sClient.GetRESTConfig()
+	if restConfig == nil {
+		return fmt.Errorf("kubernetes rest config unavailable")
+	}
+
+	req := h.k8sClient.GetClientset().CoreV1().RESTClient().Post().
+		Resource("pods").
+		Namespace(pod.Namespace).
+		Name(pod.Name).
+		SubResource("exec").
+		VersionedParams(&corev1.PodExecOptions{
+			Container: h.podExecContainer(pod),
+			Command:   command,
+			Stdin:     stdin != nil,
+			Stdout:    true,
+			Stderr:    true,
+		}, scheme.ParameterCodec)
+	executor, err := remotecommand.NewSPDYExecutor(restConfig, http.MethodPost, req.URL())
+	if err != nil {
+		return err
+	}
+
+	stderr := &boundedBuffer{limit: podExecStderrLimit}
+	err = executor.Stream(remotecommand.StreamOptions{
+		Stdin:  stdin,
+		Stdout: stdout,
+		Stderr: stderr,
+	})
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return fmt.Errorf("%w: %s", err, msg)
+		}
+		return err
+	}
+	return nil
+}
+
+// fileDownloadWriter 首次写入时才发送响应头，便于在 tar 启动失败时返回 JSON 错误。
+// 超过上限后只记录 exceeded 并丢弃剩余输出：exec 流无法中途取消，返回错误会让远端 tar 阻塞；
+// 调用方据此中断响应
+type fileDownloadWriter struct {
+	c         *gin.Context
+	filename  string
+	remaining int64
+	started   bool
+	exceeded  bool
+}
+
+func (w *fileDownloadWriter) Write(p []byte) (int, error) {
+	if w.exceeded || int64(len(p)) > w.remaining {
+		w.exceeded = true
+		return len(p), nil
+	}
+	if !w.started {
+		w.started = true
+		w.c.Header("Content-Type", "application/x-tar")
+		w.c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", w.filename))
+		w.c.Status(http.StatusOK)
+	}
+	w.remaining -= int64(len(p))
+	return w.c.Writer.Write(p)
+}
+
+// limitedReader 超过上限时返回错误（而不是 io.LimitReader 的静默 EOF）
+type limitedReader struct {
+	r         io.Reader
+	remaining int64
+	exceeded  bool
+}
+
+func (l *limitedReader) Read(p []byte) (int, error) {
+	n, err := l.r.Read(p)
+	if int64(n) > l.remaining {
+		l.exceeded = true
+		return 0, errFileTransferTooLarge
+	}
+	l.remaining -= int64(n)
+	return n, err
+}
+
+type boundedBuffer struct {
+	buf   bytes.Buffer
+	limit int
+}
+
+func (b *boundedBuffer) Write(p []byte) (int, error) {
+	if room := b.limit - b.buf.Len(); room > 0 {
+		if len(p) > room {
+			b.buf.Write(p[:room])
+		} else {
+			b.buf.Write(p)
+		}
+	}
+	return len(p), nil
+}
+
+func (b *boundedBuffer) String() string {
+	return b.buf.String()
+}
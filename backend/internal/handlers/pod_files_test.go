@@ -0,0 +1,172 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/uc-package/genet/internal/auth"
+	corev1 "k8s.io/api/core/v1"
+	utilexec "k8s.io/client-go/util/exec"
+)
+
+func newPodFilesTestRouter(t *testing.T, pod *corev1.Pod) (*PodHandler, *gin.Engine) {
+	t.Helper()
+	handler, _ := newPodProxyTestRouter(t, pod, "")
+	router := gin.New()
+	pods := router.Group("/api/pods", auth.AuthMiddleware(handler.config))
+	pods.GET("/:id/files", handler.DownloadPodFiles)
+	pods.PUT("/:id/files", handler.UploadPodFiles)
+	return handler, router
+}
+
+func TestValidatePodFilePath(t *testing.T) {
+	denied := []string{"/proc", "/sys"}
+	if got, err := validatePodFilePath("/workspace/ckpt/", denied); err != nil || got != "/workspace/ckpt" {
+		t.Fatalf("unexpected result %q err=%v", got, err)
+	}
+	for _, bad := range []string{"", "workspace", "/workspace/../etc", "/", "/proc/1/environ", "/sys"} {
+		if _, err := validatePodFilePath(bad, denied); err == nil {
+			t.Fatalf("expected %q to be rejected", bad)
+		}
+	}
+	if _, err := validatePodFilePath("/process", denied); err != nil {
+		t.Fatalf("expected /process to be allowed: %v", err)
+	}
+}
+
+func TestDownloadPodFilesStreamsTar(t *testing.T) {
+	handler, router := newPodFilesTestRouter(t, newPodProxyTestPod("10.0.0.8", ""))
+	var commands [][]string
+	handler.podExecFn = func(_ context.Context, _ *corev1.Pod, command []string, _ io.Reader, stdout io.Writer) error {
+		commands = append(commands, command)
+		if command[2] == podPathSizeScript {
+			_, _ = io.WriteString(stdout, "12\t/workspace/ckpt\n")
+			return nil
+		}
+		_, _ = io.WriteString(stdout, "tar-bytes")
+		return nil
+	}
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, newPodProxyTestRequest(http.MethodGet, "/api/pods/pod-alice-dev/files?path=/workspace/ckpt", nil))
+	if rec.Code != http.StatusOK || rec.Body.String() != "tar-bytes" {
+		t.Fatalf("unexpected response %d %q", rec.Code, rec.Body.String())
+	}
+	if rec.Header().Get("Content-Type") != "application/x-tar" {
+		t.Fatalf("unexpected content type %q", rec.Header().Get("Content-Type"))
+	}
+	if got := strings.Join(commands[1][3:], " "); commands[1][2] != podDownloadScript || got != "sh /workspace ckpt 2147483649" {
+		t.Fatalf("unexpected tar command %q", commands[1])
+	}
+}
+
+func TestDownloadPodFilesAbortsOverLimit(t *testing.T) {
+	handler, router := newPodFilesTestRouter(t, newPodProxyTestPod("10.0.0.8", ""))
+	handler.config.Pod.FileTransfer.MaxDownloadMB = 1
+	handler.podExecFn = func(_ context.Context, _ *corev1.Pod, command []string, _ io.Reader, stdout io.Writer) error {
+		if command[2] == podPathSizeScript {
+			// du 按占用块统计，稀疏文件会被低估
+			_, _ = io.WriteString(stdout, "4\t/workspace/sparse\n")
+			return nil
+		}
+		if command[len(command)-2] == "head-first" {
+			_, _ = stdout.Write(make([]byte, 2<<20))
+			return nil
+		}
+		_, _ = stdout.Write(make([]byte, 512<<10))
+		_, _ = stdout.Write(make([]byte, 1<<20))
+		return nil
+	}
+
+	// 首次写入即超限：尚未发送响应头，返回 413
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, newPodProxyTestRequest(http.MethodGet, "/api/pods/pod-alice-dev/files?path=/workspace/head-first", nil))
+	if rec.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("expected 413, got %d", rec.Code)
+	}
+
+	// 已开始输出后超限：中断响应而不是正常结束一个截断的 tar
+	defer func() {
+		if recovered := recover(); recovered != http.ErrAbortHandler {
+			t.Fatalf("expected http.ErrAbortHandler, got %v", recovered)
+		}
+	}()
+	router.ServeHTTP(httptest.NewRecorder(), newPodProxyTestRequest(http.MethodGet, "/api/pods/pod-alice-dev/files?path=/workspace/sparse", nil))
+	t.Fatal("expected the download to be aborted")
+}
+
+func TestDownloadPodFilesErrors(t *testing.T) {
+	handler, router := newPodFilesTestRouter(t, newPodProxyTestPod("10.0.0.8", ""))
+	handler.config.Pod.FileTransfer.MaxDownloadMB = 1
+	handler.podExecFn = func(_ context.Context, _ *corev1.Pod, command []string, _ io.Reader, stdout io.Writer) error {
+		switch command[len(command)-1] {
+		case "/workspace/missing":
+			return fmt.Errorf("%w: du: /workspace/missing", utilexec.CodeExitError{Err: errors.New("command terminated with non-zero exit code"), Code: podPathNotFoundExitCode})
+		case "/workspace/denied":
+			// 其它 du 错误（即使 stderr 含 No such file）不能被当成路径不存在
+			return utilexec.CodeExitError{Err: errors.New("du: ./x: No such file or directory"), Code: 1}
+		}
+		_, _ = io.WriteString(stdout, "4096\t/workspace/big\n")
+		return nil
+	}
+
+	for target, want := range map[string]int{
+		"/api/pods/pod-alice-dev/files?path=/workspace/missing": http.StatusNotFound,
+		"/api/pods/pod-alice-dev/files?path=/workspace/denied":  http.StatusInternalServerError,
+		"/api/pods/pod-alice-dev/files?path=/workspace/big":     http.StatusRequestEntityTooLarge,
+		"/api/pods/pod-alice-dev/files?path=/proc/self":         http.StatusBadRequest,
+	} {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, newPodProxyTestRequest(http.MethodGet, target, nil))
+		if rec.Code != want {
+			t.Fatalf("%s: expected %d, got %d", target, want, rec.Code)
+		}
+	}
+
+	handler.config.Pod.FileTransfer.Enabled = false
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, newPodProxyTestRequest(http.MethodGet, "/api/pods/pod-alice-dev/files?path=/workspace/big", nil))
+	if rec.Code != http.StatusForbidden {
+		t.Fatalf("expected 403 when disabled, got %d", rec.Code)
+	}
+}
+
+func TestUploadPodFilesExtractsIntoDirectory(t *testing.T) {
+	handler, router := newPodFilesTestRouter(t, newPodProxyTestPod("10.0.0.8", ""))
+	var received string
+	var command []string
+	handler.podExecFn = func(_ context.Context, _ *corev1.Pod, cmd []string, stdin io.Reader, _ io.Writer) error {
+		command = cmd
+		data, err := io.ReadAll(stdin)
+		received = string(data)
+		return err
+	}
+
+	rec := httptest.NewRecorder()
+	req := newPodProxyTestRequest(http.MethodPut, "/api/pods/pod-alice-dev/files?path=/workspace/data", strings.NewReader("tar-bytes"))
+	router.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("unexpected status %d: %s", rec.Code, rec.Body.String())
+	}
+	if received != "tar-bytes" {
+		t.Fatalf("unexpected stdin %q", received)
+	}
+	if command[len(command)-1] != "/workspace/data" || !strings.Contains(command[2], "tar xof -") {
+		t.Fatalf("unexpected command %q", command)
+	}
+
+	handler.config.Pod.FileTransfer.MaxUploadMB = 1
+	rec = httptest.NewRecorder()
+	req = newPodProxyTestRequest(http.MethodPut, "/api/pods/pod-alice-dev/files?path=/workspace/data", strings.NewReader(strings.Repeat("x", 2<<20)))
+	router.ServeHTTP(rec, req)
+	if rec.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("expected 413 for oversized upload, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,259 @@
+package handlers
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/uc-package/genet/internal/auth"
+	"github.com/uc-package/genet/internal/k8s"
+	"github.com/uc-package/genet/internal/logarchive"
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// podLogArchiveTimeout 删除前归档日志的最长等待时间，超时不阻塞删除
+const podLogArchiveTimeout = 30 * time.Second
+
+// podLogFilter 服务端日志过滤条件：关键字/正则匹配及 until 截止时间（since 交给 kubelet 处理）
+type podLogFilter struct {
+	match func(string) bool
+	until *time.Time
+}
+
+// parsePodLogFilter 解析 grep/regex/ignoreCase/until 查询参数；未指定任何条件时返回 nil
+func parsePodLogFilter(c *gin.Context) (*podLogFilter, error) {
+	filter := &podLogFilter{}
+	if pattern := c.Query("grep"); pattern != "" {
+		useRegex, _ := strconv.ParseBool(c.DefaultQuery("regex", "false"))
+		ignoreCase, _ := strconv.ParseBool(c.DefaultQuery("ignoreCase", "false"))
+		if !useRegex {
+			pattern = regexp.QuoteMeta(pattern)
+		}
+		if ignoreCase {
+			pattern = "(?i)" + pattern
+		}
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("无效的 grep 参数: %v", err)
+		}
+		filter.match = re.MatchString
+	}
+	until, err := parsePodLogSince(c.Query("until"))
+	if err != nil {
+		return nil, fmt.Errorf("无效的 until 参数")
+	}
+	filter.until = until
+	if filter.match == nil && filter.until == nil {
+		return nil, nil
+	}
+	return filter, nil
+}
+
+// accept 判断一行是否保留；past 表示已超过 until，后续行无需再读
+func (f *podLogFilter) accept(content, cursor string) (keep, past bool) {
+	if f == nil {
+		return true, false
+	}
+	if f.until != nil && cursor != "" {
+		if ts, err := time.Parse(time.RFC3339Nano, cursor); err == nil && ts.After(*f.until) {
+			return false, true
+		}
+	}
+	if f.match != nil && !f.match(strings.TrimSuffix(content, "\n")) {
+		return false, false
+	}
+	return true, false
+}
+
+// scanPodLogLines 逐行读取带时间戳的日志并交给 fn，fn 返回 false 时停止
+func scanPodLogLines(r io.Reader, fn func(segment, content, cursor string) bool) error {
+	reader := bufio.NewReader(r)
+	for {
+		segment, err := reader.ReadString('\n')
+		if segment != "" {
+			content, cursor := parseTimestampedPodLogLine(segment)
+			if !fn(segment, content, cursor) {
+				return nil
+			}
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// filterPodLogs 读取完整日志流并过滤，仅保留最后 tailLines 条匹配行
+func filterPodLogs(r io.Reader, filter *podLogFilter, tailLines int64) (podLogsResponse, error) {
+	type matchedLine struct {
+		content string
+		cursor  string
+	}
+	var matched []matchedLine
+	err := scanPodLogLines(r, func(_, content, cursor string) bool {
+		keep, past := filter.accept(content, cursor)
+		if past {
+			return false
+		}
+		if keep {
+			matched = append(matched, matchedLine{content: content, cursor: cursor})
+			if tailLines > 0 && int64(len(matched)) > tailLines {
+				matched = matched[1:]
+			}
+		}
+		return true
+	})
+	if err != nil {
+		return podLogsResponse{}, err
+	}
+
+	var resp podLogsResponse
+	var builder strings.Builder
+	for _, line := range matched {
+		builder.WriteString(line.content)
+		if line.cursor != "" {
+			resp.Cursor = line.cursor
+		}
+	}
+	resp.Logs = builder.String()
+	return resp, nil
+}
+
+// writePodLogsArchive 以 gzip 流式输出完整日志（之前容器在前、当前容器在后），保留时间戳。
+// 读取或写出失败时不写 gzip 尾部：尚未输出时返回 500，已开始输出则中断连接，避免客户端拿到截断却“完整”的压缩包
+func (h *PodHandler) writePodLogsArchive(c *gin.Context, podID string, previous, current io.Reader, filter *podLogFilter) {
+	c.Header("Content-Type", "application/gzip")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s-logs.gz", podID))
+	c.Status(http.StatusOK)
+
+	gz := gzip.NewWriter(c.Writer)
+	err := func() error {
+		for _, stream := range []io.Reader{previous, current} {
+			if stream == nil {
+				continue
+			}
+			var writeErr error
+			err := scanPodLogLines(stream, func(segment, content, cursor string) bool {
+				keep, past := filter.accept(content, cursor)
+				if past {
+					return false
+				}
+				if keep {
+					_, writeErr = io.WriteString(gz, segment)
+				}
+				return writeErr == nil
+			})
+			if err == nil {
+				err = writeErr
+			}
+			if err != nil {
+				return err
+			}
+		}
+		return gz.Close()
+	}()
+	if err == nil {
+		return
+	}
+	h.log.Warn("Failed to write pod logs archive", zap.String("podID", podID), zap.Error(err))
+	if c.Writer.Written() {
+		panic(http.ErrAbortHandler)
+	}
+	c.Writer.Header().Del("Content-Type")
+	c.Writer.Header().Del("Content-Disposition")
+	c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("获取日志失败: %v", err)})
+}
+
+// archivePodLogs 删除 Pod 前归档日志与事件；失败只告警，不影响删除
+func (h *PodHandler) archivePodLogs(ctx context.Context, pod *corev1.Pod, reason string) {
+	if !h.logArchiver.Enabled() {
+		return
+	}
+	archiveCtx, cancel := context.WithTimeout(ctx, podLogArchiveTimeout)
+	defer cancel()
+	if err := h.logArchiver.ArchivePod(archiveCtx, pod, reason); err != nil {
+		h.log.Warn("Failed to archive pod logs before deletion",
+			zap.String("pod", pod.Name),
+			zap.String("namespace", pod.Namespace),
+			zap.Error(err))
+	}
+}
+
+// archiveWorkloadPodLogs 删除 Deployment/StatefulSet 前归档所有副本，所有副本共用一个超时
+func (h *PodHandler) archiveWorkloadPodLogs(ctx context.Context, pods []corev1.Pod, reason string) {
+	if !h.logArchiver.Enabled() || len(pods) == 0 {
+		return
+	}
+	archiveCtx, cancel := context.WithTimeout(ctx, podLogArchiveTimeout)
+	defer cancel()
+	for i := range pods {
+		if err := h.logArchiver.ArchivePod(archiveCtx, &pods[i], reason); err != nil {
+			h.log.Warn("Failed to archive replica logs before deletion",
+				zap.String("pod", pods[i].Name),
+				zap.String("namespace", pods[i].Namespace),
+				zap.Error(err))
+		}
+	}
+}
+
+// GetPodHistoryLogs 读取已删除 Pod 的归档日志与事件，?archive= 指定归档 ID，默认最近一次
+func (h *PodHandler) GetPodHistoryLogs(c *gin.Context) {
+	username, _ := auth.GetUsername(c)
+	email, _ := auth.GetEmail(c)
+	podID := c.Param("id")
+	userIdentifier := k8s.GetUserIdentifier(username, email)
+	namespace := k8s.GetNamespaceForUserIdentifier(userIdentifier)
+
+	if !h.logArchiver.Enabled() {
+		c.JSON(http.StatusNotFound, gin.H{"error": "未启用日志归档"})
+		return
+	}
+	archive, err := h.logArchiver.Load(c.Request.Context(), namespace, podID, c.Query("archive"))
+	if errors.Is(err, logarchive.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "没有该 Pod 的归档日志"})
+		return
+	}
+	if err != nil {
+		h.log.Error("Failed to load pod log archive",
+			zap.String("user", username),
+			zap.String("podID", podID),
+			zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("读取归档日志失败: %v", err)})
+		return
+	}
+	c.JSON(http.StatusOK, archive)
+}
+
+// ListPodHistoryArchives 列出同名 Pod 的历次归档（Pod 名称被重复使用时用于区分）
+func (h *PodHandler) ListPodHistoryArchives(c *gin.Context) {
+	username, _ := auth.GetUsername(c)
+	email, _ := auth.GetEmail(c)
+	namespace := k8s.GetNamespaceForUserIdentifier(k8s.GetUserIdentifier(username, email))
+
+	if !h.logArchiver.Enabled() {
+		c.JSON(http.StatusNotFound, gin.H{"error": "未启用日志归档"})
+		return
+	}
+	archives, err := h.logArchiver.List(c.Request.Context(), namespace, c.Param("id"))
+	if err != nil {
+		h.log.Error("Failed to list pod log archives",
+			zap.String("user", username),
+			zap.String("podID", c.Param("id")),
+			zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("读取归档列表失败: %v", err)})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"archives": archives})
+}
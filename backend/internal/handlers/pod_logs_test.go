@@ -1,13 +1,21 @@
 package handlers
 
 import (
+	"compress/gzip"
 	"context"
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"testing/iotest"
 
 	"github.com/gin-gonic/gin"
 	"github.com/uc-package/genet/internal/k8s"
+	"github.com/uc-package/genet/internal/logarchive"
+	"github.com/uc-package/genet/internal/models"
 	"go.uber.org/zap"
 )
 
@@ -95,3 +103,190 @@ func TestGetPodLogsSupportsTailLinesAndSince(t *testing.T) {
 		t.Fatalf("unexpected response body: %s", body)
 	}
 }
+
+const podLogsSearchFixture = "2026-03-15T10:00:00Z epoch 1 loss=0.9\n" +
+	"2026-03-15T10:00:01Z WARN grad overflow\n" +
+	"2026-03-15T10:00:02Z epoch 2 loss=0.5\n" +
+	"2026-03-15T10:00:03Z epoch 3 loss=0.3\n"
+
+func newPodLogsSearchContext(target string) (*gin.Context, *httptest.ResponseRecorder) {
+	gin.SetMode(gin.TestMode)
+	recorder := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(recorder)
+	c.Request = httptest.NewRequest(http.MethodGet, target, nil)
+	c.Params = gin.Params{{Key: "id", Value: "pod-alice-dev"}}
+	c.Set("username", "alice")
+	c.Set("email", "alice@example.com")
+	return c, recorder
+}
+
+func TestGetPodLogsFiltersByRegexAndUntil(t *testing.T) {
+	c, recorder := newPodLogsSearchContext("/pods/pod-alice-dev/logs?grep=EPOCH%20%5B0-9%5D&regex=true&ignoreCase=true&until=2026-03-15T10:00:02Z&tailLines=1")
+
+	handler := &PodHandler{
+		log: zap.NewNop(),
+		streamPodLogsFn: func(_ context.Context, _, _ string, options k8s.PodLogOptions) (io.ReadCloser, error) {
+			if options.TailLines != 0 || !options.Timestamps {
+				t.Errorf("expected full timestamped logs, got %+v", options)
+			}
+			return io.NopCloser(strings.NewReader(podLogsSearchFixture)), nil
+		},
+	}
+	handler.GetPodLogs(c)
+
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", recorder.Code, recorder.Body.String())
+	}
+	if body := recorder.Body.String(); body != "{\"logs\":\"epoch 2 loss=0.5\\n\",\"cursor\":\"2026-03-15T10:00:02Z\"}" {
+		t.Fatalf("unexpected response body: %s", body)
+	}
+}
+
+func TestGetPodLogsSubstringMatchIsLiteral(t *testing.T) {
+	c, recorder := newPodLogsSearchContext("/pods/pod-alice-dev/logs?grep=loss%3D0.")
+
+	handler := &PodHandler{
+		log: zap.NewNop(),
+		streamPodLogsFn: func(context.Context, string, string, k8s.PodLogOptions) (io.ReadCloser, error) {
+			return io.NopCloser(strings.NewReader(podLogsSearchFixture + "2026-03-15T10:00:04Z loss=0x\n")), nil
+		},
+	}
+	handler.GetPodLogs(c)
+
+	if recorder.Code != http.StatusOK || strings.Contains(recorder.Body.String(), "0x") || !strings.Contains(recorder.Body.String(), "epoch 3") {
+		t.Fatalf("unexpected response %d: %s", recorder.Code, recorder.Body.String())
+	}
+}
+
+func TestGetPodLogsRejectsInvalidRegex(t *testing.T) {
+	c, recorder := newPodLogsSearchContext("/pods/pod-alice-dev/logs?grep=(&regex=true")
+
+	handler := &PodHandler{log: zap.NewNop()}
+	handler.GetPodLogs(c)
+
+	if recorder.Code != http.StatusBadRequest {
+		t.Fatalf("expected status 400, got %d", recorder.Code)
+	}
+}
+
+func TestGetPodLogsDownloadsGzipWithPreviousContainer(t *testing.T) {
+	c, recorder := newPodLogsSearchContext("/pods/pod-alice-dev/logs?download=true&grep=epoch")
+
+	handler := &PodHandler{
+		log: zap.NewNop(),
+		streamPodLogsFn: func(_ context.Context, _, _ string, options k8s.PodLogOptions) (io.ReadCloser, error) {
+			if options.Previous {
+				return io.NopCloser(strings.NewReader("2026-03-15T09:00:00Z epoch 0 crashed\n")), nil
+			}
+			return io.NopCloser(strings.NewReader(podLogsSearchFixture)), nil
+		},
+	}
+	handler.GetPodLogs(c)
+
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", recorder.Code)
+	}
+	if got := recorder.Header().Get("Content-Disposition"); got != "attachment; filename=pod-alice-dev-logs.gz" {
+		t.Fatalf("unexpected content disposition %q", got)
+	}
+	gz, err := gzip.NewReader(recorder.Body)
+	if err != nil {
+		t.Fatalf("gzip: %v", err)
+	}
+	body, _ := io.ReadAll(gz)
+	want := "2026-03-15T09:00:00Z epoch 0 crashed\n" +
+		"2026-03-15T10:00:00Z epoch 1 loss=0.9\n" +
+		"2026-03-15T10:00:02Z epoch 2 loss=0.5\n" +
+		"2026-03-15T10:00:03Z epoch 3 loss=0.3\n"
+	if string(body) != want {
+		t.Fatalf("unexpected archive:\n%s", body)
+	}
+}
+
+// failingLogStream 输出 lines 行不可压缩的日志后返回读取错误
+func failingLogStream(lines int) io.ReadCloser {
+	var builder strings.Builder
+	for i := 0; i < lines; i++ {
+		fmt.Fprintf(&builder, "2026-03-15T10:00:00Z step %d %x\n", i, uint64(i)*0x9e3779b97f4a7c15)
+	}
+	return io.NopCloser(io.MultiReader(strings.NewReader(builder.String()), iotest.ErrReader(errors.New("stream reset"))))
+}
+
+func TestGetPodLogsDownloadFailsInsteadOfTruncating(t *testing.T) {
+	// 尚未输出任何内容时失败：返回 500
+	c, recorder := newPodLogsSearchContext("/pods/pod-alice-dev/logs?download=true")
+	handler := &PodHandler{
+		log: zap.NewNop(),
+		streamPodLogsFn: func(_ context.Context, _, _ string, options k8s.PodLogOptions) (io.ReadCloser, error) {
+			if options.Previous {
+				return nil, errors.New("no previous container")
+			}
+			return failingLogStream(0), nil
+		},
+	}
+	handler.GetPodLogs(c)
+	if recorder.Code != http.StatusInternalServerError || recorder.Header().Get("Content-Disposition") != "" {
+		t.Fatalf("expected 500 without attachment, got %d %v", recorder.Code, recorder.Header())
+	}
+
+	// 已开始输出后失败：中断连接，不写 gzip 尾部
+	c, recorder = newPodLogsSearchContext("/pods/pod-alice-dev/logs?download=true")
+	handler.streamPodLogsFn = func(_ context.Context, _, _ string, options k8s.PodLogOptions) (io.ReadCloser, error) {
+		if options.Previous {
+			return nil, errors.New("no previous container")
+		}
+		return failingLogStream(100000), nil
+	}
+	defer func() {
+		if recovered := recover(); recovered != http.ErrAbortHandler {
+			t.Fatalf("expected http.ErrAbortHandler, got %v", recovered)
+		}
+		gz, err := gzip.NewReader(recorder.Body)
+		if err != nil {
+			t.Fatalf("gzip: %v", err)
+		}
+		if _, err := io.ReadAll(gz); err == nil {
+			t.Fatalf("expected the partial archive to be detectably incomplete")
+		}
+	}()
+	handler.GetPodLogs(c)
+	t.Fatal("expected the download to be aborted")
+}
+
+func TestGetPodHistoryLogsReadsArchive(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	archiver, err := logarchive.NewArchiver(models.LogArchiveConfig{Enabled: true, Backend: "pvc", Dir: t.TempDir()}, nil)
+	if err != nil {
+		t.Fatalf("NewArchiver: %v", err)
+	}
+	namespace := k8s.GetNamespaceForUserIdentifier(k8s.GetUserIdentifier("alice", "alice@example.com"))
+	if err := archiver.Save(context.Background(), &logarchive.Archive{
+		Pod:        "pod-alice-dev",
+		Namespace:  namespace,
+		Reason:     "cleanup",
+		Containers: []logarchive.ContainerLogs{{Name: "workspace", Logs: "2026-03-15T23:00:00Z epoch 9\n"}},
+	}); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	handler := &PodHandler{log: zap.NewNop(), logArchiver: archiver}
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Set("username", "alice")
+		c.Set("email", "alice@example.com")
+	})
+	router.GET("/api/pods/history/:id/logs", handler.GetPodHistoryLogs)
+	router.GET("/api/pods/:id/logs", handler.GetPodLogs)
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/api/pods/history/pod-alice-dev/logs", nil))
+	if recorder.Code != http.StatusOK || !strings.Contains(recorder.Body.String(), "epoch 9") || !strings.Contains(recorder.Body.String(), `"reason":"cleanup"`) {
+		t.Fatalf("unexpected response %d: %s", recorder.Code, recorder.Body.String())
+	}
+
+	recorder = httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/api/pods/history/pod-alice-gone/logs", nil))
+	if recorder.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 for missing archive, got %d", recorder.Code)
+	}
+}
@@ -0,0 +1,97 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/uc-package/genet/internal/auth"
+	"github.com/uc-package/genet/internal/k8s"
+	"github.com/uc-package/genet/internal/models"
+	"github.com/uc-package/genet/internal/prometheus"
+	"go.uber.org/zap"
+)
+
+const (
+	defaultPodMetricsRange = time.Hour
+	minPodMetricsRange     = 5 * time.Minute
+	maxPodMetricsRange     = 7 * 24 * time.Hour
+)
+
+// GetPodMetrics 查询 Pod 的 CPU/内存及加速卡利用率/显存曲线（range 如 15m、1h、1d，最长 7d）
+func (h *PodHandler) GetPodMetrics(c *gin.Context) {
+	username, _ := auth.GetUsername(c)
+	email, _ := auth.GetEmail(c)
+	podID := c.Param("id")
+	userIdentifier := k8s.GetUserIdentifier(username, email)
+	namespace := k8s.GetNamespaceForUserIdentifier(userIdentifier)
+	ctx := c.Request.Context()
+
+	window, err := parsePodMetricsRange(c.Query("range"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if h.queryPodMetricsFn == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "未配置 Prometheus，无法查询监控数据"})
+		return
+	}
+
+	pod, err := h.getPod(ctx, namespace, podID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Pod 不存在"})
+		return
+	}
+
+	// 只查询 Pod 实际使用的加速卡类型；无法识别时查询所有已配置类型
+	var acceleratorTypes []models.AcceleratorType
+	if display := h.getPodDisplayInfo(pod); display.GPUCount > 0 {
+		if accType, err := h.resolveAcceleratorTypeForRequest(display.GPUType); err == nil {
+			acceleratorTypes = []models.AcceleratorType{accType}
+		} else {
+			acceleratorTypes = h.config.GetAcceleratorTypes()
+		}
+	}
+
+	end := time.Now()
+	step := prometheus.RangeStep(window)
+	metrics, err := h.queryPodMetricsFn(ctx, namespace, podID, toPrometheusAcceleratorTypes(acceleratorTypes), end.Add(-window), end, step)
+	if err != nil {
+		h.log.Error("Failed to query pod metrics",
+			zap.String("user", username),
+			zap.String("podID", podID),
+			zap.Error(err))
+		c.JSON(http.StatusBadGateway, gin.H{"error": fmt.Sprintf("查询监控数据失败: %v", err)})
+		return
+	}
+	c.JSON(http.StatusOK, metrics)
+}
+
+// parsePodMetricsRange 解析时间范围，除 Go duration 外支持 1d 这类按天写法
+func parsePodMetricsRange(value string) (time.Duration, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return defaultPodMetricsRange, nil
+	}
+	var window time.Duration
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil {
+			return 0, fmt.Errorf("无效的 range 参数")
+		}
+		window = time.Duration(n) * 24 * time.Hour
+	} else {
+		parsed, err := time.ParseDuration(value)
+		if err != nil {
+			return 0, fmt.Errorf("无效的 range 参数")
+		}
+		window = parsed
+	}
+	if window < minPodMetricsRange || window > maxPodMetricsRange {
+		return 0, fmt.Errorf("range 需在 5m 到 7d 之间")
+	}
+	return window, nil
+}
@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/uc-package/genet/internal/auth"
+	"github.com/uc-package/genet/internal/models"
+	"github.com/uc-package/genet/internal/prometheus"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestGetPodMetricsQueriesPodAccelerator(t *testing.T) {
+	pod := newPodProxyTestPod("10.0.0.8", "")
+	pod.Annotations["genet.io/gpu-type"] = "A100"
+	pod.Annotations["genet.io/gpu-count"] = "2"
+	pod.Spec.Containers = []corev1.Container{{Name: "workspace", Image: "pytorch:2.1"}}
+	handler, _ := newPodProxyTestRouter(t, pod, "")
+	handler.config.GPU.AvailableTypes = []models.GPUType{
+		{Name: "A100", ResourceName: "nvidia.com/gpu", Type: "nvidia", MetricName: "DCGM_FI_DEV_GPU_UTIL"},
+		{Name: "910B", ResourceName: "huawei.com/Ascend910", Type: "ascend", MetricName: "npu_chip_info_utilization"},
+	}
+
+	var gotTypes []prometheus.AcceleratorTypeConfig
+	var gotWindow, gotStep time.Duration
+	handler.queryPodMetricsFn = func(_ context.Context, namespace, podName string, types []prometheus.AcceleratorTypeConfig, start, end time.Time, step time.Duration) (*prometheus.PodMetrics, error) {
+		if namespace != pod.Namespace || podName != pod.Name {
+			t.Errorf("unexpected target %s/%s", namespace, podName)
+		}
+		gotTypes = types
+		gotWindow = end.Sub(start)
+		gotStep = step
+		return &prometheus.PodMetrics{CPU: []prometheus.SamplePoint{{Time: end, Value: 1.5}}}, nil
+	}
+
+	router := gin.New()
+	router.GET("/api/pods/:id/metrics", auth.AuthMiddleware(handler.config), handler.GetPodMetrics)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, newPodProxyTestRequest(http.MethodGet, "/api/pods/"+pod.Name+"/metrics?range=1d", nil))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if gotWindow != 24*time.Hour || gotStep != 12*time.Minute {
+		t.Fatalf("unexpected window %s step %s", gotWindow, gotStep)
+	}
+	if len(gotTypes) != 1 || gotTypes[0].Type != "nvidia" {
+		t.Fatalf("expected only the pod's accelerator type, got %+v", gotTypes)
+	}
+}
+
+func TestGetPodMetricsRequiresPrometheus(t *testing.T) {
+	pod := newPodProxyTestPod("10.0.0.8", "")
+	handler, _ := newPodProxyTestRouter(t, pod, "")
+	router := gin.New()
+	router.GET("/api/pods/:id/metrics", auth.AuthMiddleware(handler.config), handler.GetPodMetrics)
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, newPodProxyTestRequest(http.MethodGet, "/api/pods/"+pod.Name+"/metrics", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503, got %d", rec.Code)
+	}
+}
+
+func TestParsePodMetricsRange(t *testing.T) {
+	cases := map[string]time.Duration{"": time.Hour, "15m": 15 * time.Minute, "7d": 7 * 24 * time.Hour}
+	for input, want := range cases {
+		if got, err := parsePodMetricsRange(input); err != nil || got != want {
+			t.Fatalf("%q: got %s, %v", input, got, err)
+		}
+	}
+	for _, input := range []string{"1m", "8d", "abc"} {
+		if _, err := parsePodMetricsRange(input); err == nil {
+			t.Fatalf("%q: expected error", input)
+		}
+	}
+}
@@ -0,0 +1,182 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/uc-package/genet/internal/auth"
+	"github.com/uc-package/genet/internal/k8s"
+	"github.com/uc-package/genet/internal/wsmux"
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/portforward"
+	"k8s.io/client-go/transport/spdy"
+)
+
+// maxPortForwardPorts 单个端口转发会话最多转发的端口数
+const maxPortForwardPorts = 16
+
+// PortForward 在一条 WebSocket 上复用转发 Pod 的多个端口（genet port-forward 使用）
+// GET /api/pods/:id/port-forward?port=8888&port=6006
+// 服务端用 client-go PortForwarder 通过一条 SPDY 连接转发到 Pod，WebSocket 上的帧格式见 wsmux 包
+func (h *PodHandler) PortForward(c *gin.Context) {
+	username, _ := auth.GetUsername(c)
+	email, _ := auth.GetEmail(c)
+	userIdentifier := k8s.GetUserIdentifier(username, email)
+	namespace := k8s.GetNamespaceForUserIdentifier(userIdentifier)
+	podID := c.Param("id")
+
+	// 在连接 Pod 之前拒绝跨站请求
+	if !h.checkWebSocketOrigin(c.Request) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "不允许跨站转发端口"})
+		return
+	}
+
+	ports, err := parsePortForwardPorts(c.QueryArray("port"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	pod, err := h.getPod(c.Request.Context(), namespace, podID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Pod 不存在"})
+		return
+	}
+	if pod.Status.Phase != corev1.PodRunning {
+		c.JSON(http.StatusConflict, gin.H{"error": "Pod 未处于运行状态，暂时无法转发端口"})
+		return
+	}
+
+	ctx, cancel := context.WithCancel(c.Request.Context())
+	defer cancel()
+	addresses, err := h.startPortForward(ctx, pod, ports)
+	if err != nil {
+		h.log.Warn("Failed to start port-forward",
+			zap.String("pod", podID),
+			zap.Ints("ports", ports),
+			zap.Error(err))
+		c.JSON(http.StatusBadGateway, gin.H{"error": fmt.Sprintf("端口转发失败: %v", err)})
+		return
+	}
+
+	conn, err := h.tunnelUpgrader().Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		return
+	}
+
+	h.log.Debug("Port-forward session opened",
+		zap.String("user", userIdentifier),
+		zap.String("pod", podID),
+		zap.Ints("ports", ports))
+	session := wsmux.NewSession(conn, func(port int) (net.Conn, error) {
+		address, ok := addresses[port]
+		if !ok {
+			return nil, fmt.Errorf("port %d is not forwarded", port)
+		}
+		var dialer net.Dialer
+		return dialer.DialContext(ctx, "tcp", address)
+	})
+	_ = session.Serve()
+	h.log.Debug("Port-forward session closed", zap.String("user", userIdentifier), zap.String("pod", podID))
+}
+
+// parsePortForwardPorts 校验并去重 port 参数
+func parsePortForwardPorts(values []string) ([]int, error) {
+	seen := make(map[int]bool)
+	var ports []int
+	for _, value := range values {
+		for _, item := range strings.Split(value, ",") {
+			port, err := strconv.Atoi(strings.TrimSpace(item))
+			if err != nil || port < 1 || port > 65535 {
+				return nil, fmt.Errorf("port 必须是 1-65535 之间的端口号")
+			}
+			if !seen[port] {
+				seen[port] = true
+				ports = append(ports, port)
+			}
+		}
+	}
+	if len(ports) == 0 {
+		return nil, fmt.Errorf("请至少指定一个 port")
+	}
+	if len(ports) > maxPortForwardPorts {
+		return nil, fmt.Errorf("一次最多转发 %d 个端口", maxPortForwardPorts)
+	}
+	return ports, nil
+}
+
+// startPortForward 用 client-go PortForwarder 把 Pod 端口转发到 API Server 本机回环地址的随机端口，
+// 返回远端端口到本地地址的映射；ctx 结束时停止转发
+func (h *PodHandler) startPortForward(ctx context.Context, pod *corev1.Pod, ports []int) (map[int]string, error) {
+	if h.startPortForwardFn != nil {
+		return h.startPortForwardFn(ctx, pod, ports)
+	}
+	restConfig := h.k8sClient.GetRESTConfig()
+	if restConfig == nil {
+		return nil, fmt.Errorf("kubernetes rest config unavailable")
+	}
+
+	transport, upgrader, err := spdy.RoundTripperFor(restConfig)
+	if err != nil {
+		return nil, err
+	}
+	req := h.k8sClient.GetClientset().CoreV1().RESTClient().Post().
+		Resource("pods").
+		Namespace(pod.Namespace).
+		Name(pod.Name).
+		SubResource("portforward")
+	dialer := spdy.NewDialer(upgrader, &http.Client{Transport: transport}, http.MethodPost, req.URL())
+
+	specs := make([]string, 0, len(ports))
+	for _, port := range ports {
+		specs = append(specs, "0:"+strconv.Itoa(port))
+	}
+	errOut, err := zap.NewStdLogAt(h.log.With(zap.String("pod", pod.Name)), zap.WarnLevel)
+	if err != nil {
+		return nil, err
+	}
+	stopCh := make(chan struct{})
+	readyCh := make(chan struct{})
+	forwarder, err := portforward.NewOnAddresses(dialer, []string{"127.0.0.1"}, specs, stopCh, readyCh, io.Discard, errOut.Writer())
+	if err != nil {
+		return nil, err
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- forwarder.ForwardPorts()
+	}()
+	go func() {
+		<-ctx.Done()
+		close(stopCh)
+	}()
+
+	select {
+	case <-readyCh:
+	case err := <-errCh:
+		if err == nil {
+			err = errors.New("port-forward stopped")
+		}
+		return nil, err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	forwarded, err := forwarder.GetPorts()
+	if err != nil {
+		return nil, err
+	}
+	addresses := make(map[int]string, len(forwarded))
+	for _, port := range forwarded {
+		addresses[int(port.Remote)] = net.JoinHostPort("127.0.0.1", strconv.Itoa(int(port.Local)))
+	}
+	return addresses, nil
+}
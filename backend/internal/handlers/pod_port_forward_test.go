@@ -0,0 +1,153 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/uc-package/genet/internal/auth"
+	"github.com/uc-package/genet/internal/wsmux"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func newPortForwardTestRouter(t *testing.T, pod *corev1.Pod) (*PodHandler, *gin.Engine) {
+	t.Helper()
+	handler, _ := newPodProxyTestRouter(t, pod, "")
+	router := gin.New()
+	pods := router.Group("/api/pods", auth.AuthMiddleware(handler.config))
+	pods.GET("/:id/port-forward", handler.PortForward)
+	return handler, router
+}
+
+// startPortForwardTestListener 模拟 PortForwarder 的本地监听，回显 "<pod>/<port>:" 前缀
+func startPortForwardTestListener(t *testing.T, pod *corev1.Pod, port int) string {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	t.Cleanup(func() { _ = listener.Close() })
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				buf := make([]byte, 64)
+				n, err := conn.Read(buf)
+				if err != nil {
+					return
+				}
+				_, _ = fmt.Fprintf(conn, "%s/%d:%s", pod.Name, port, buf[:n])
+			}()
+		}
+	}()
+	return listener.Addr().String()
+}
+
+func TestPortForwardMultiplexesRequestedPorts(t *testing.T) {
+	pod := newPodProxyTestPod("10.0.0.8", "")
+	handler, router := newPortForwardTestRouter(t, pod)
+
+	var requestedPorts []int
+	handler.startPortForwardFn = func(_ context.Context, target *corev1.Pod, ports []int) (map[int]string, error) {
+		requestedPorts = ports
+		addresses := make(map[int]string)
+		for _, port := range ports {
+			addresses[port] = startPortForwardTestListener(t, target, port)
+		}
+		return addresses, nil
+	}
+
+	server := httptest.NewServer(router)
+	defer server.Close()
+	header := http.Header{}
+	header.Set("X-Auth-Request-User", "alice")
+	header.Set("X-Auth-Request-Email", "alice@example.com")
+	wsURL := "ws" + strings.TrimPrefix(server.URL, "http") + "/api/pods/pod-alice-dev/port-forward?port=8888&port=6006"
+	conn, _, err := websocket.DefaultDialer.Dial(wsURL, header)
+	if err != nil {
+		t.Fatalf("dial port-forward: %v", err)
+	}
+	session := wsmux.NewSession(conn, nil)
+	go func() { _ = session.Serve() }()
+	defer session.Close()
+
+	if len(requestedPorts) != 2 || requestedPorts[0] != 8888 || requestedPorts[1] != 6006 {
+		t.Fatalf("unexpected forwarded ports %v", requestedPorts)
+	}
+	for _, port := range []int{8888, 6006, 8888} {
+		local, remote := net.Pipe()
+		if err := session.Open(port, remote); err != nil {
+			t.Fatalf("open %d: %v", port, err)
+		}
+		if _, err := local.Write([]byte("ping")); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+		got, err := io.ReadAll(local)
+		want := fmt.Sprintf("pod-alice-dev/%d:ping", port)
+		if err != nil || string(got) != want {
+			t.Fatalf("port %d: got %q err=%v, want %q", port, got, err, want)
+		}
+		local.Close()
+	}
+
+	// 未转发的端口直接被关闭
+	local, remote := net.Pipe()
+	if err := session.Open(9999, remote); err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	if got, _ := io.ReadAll(local); len(got) != 0 {
+		t.Fatalf("expected unforwarded port to be closed, got %q", got)
+	}
+}
+
+func TestPortForwardRejectsInvalidRequests(t *testing.T) {
+	pod := newPodProxyTestPod("10.0.0.8", "")
+	handler, router := newPortForwardTestRouter(t, pod)
+	handler.startPortForwardFn = func(context.Context, *corev1.Pod, []int) (map[int]string, error) {
+		t.Fatal("port-forward must not start for invalid requests")
+		return nil, nil
+	}
+
+	for target, want := range map[string]int{
+		"/api/pods/pod-alice-dev/port-forward":            http.StatusBadRequest,
+		"/api/pods/pod-alice-dev/port-forward?port=70000": http.StatusBadRequest,
+		"/api/pods/pod-bob-dev/port-forward?port=8888":    http.StatusNotFound,
+	} {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, newPodProxyTestRequest(http.MethodGet, target, nil))
+		if rec.Code != want {
+			t.Fatalf("%s: expected %d, got %d", target, want, rec.Code)
+		}
+	}
+
+	req := newPodProxyTestRequest(http.MethodGet, "/api/pods/pod-alice-dev/port-forward?port=8888", nil)
+	req.Header.Set("Origin", "https://evil.example.com")
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	if rec.Code != http.StatusForbidden {
+		t.Fatalf("expected cross-site origin to be rejected, got %d", rec.Code)
+	}
+}
+
+func TestParsePortForwardPorts(t *testing.T) {
+	ports, err := parsePortForwardPorts([]string{"8888", "6006,8888"})
+	if err != nil || len(ports) != 2 || ports[0] != 8888 || ports[1] != 6006 {
+		t.Fatalf("unexpected ports %v err=%v", ports, err)
+	}
+	for _, bad := range [][]string{nil, {"0"}, {"abc"}} {
+		if _, err := parsePortForwardPorts(bad); err == nil {
+			t.Fatalf("expected %v to be rejected", bad)
+		}
+	}
+}
@@ -0,0 +1,547 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/uc-package/genet/internal/auth"
+	"github.com/uc-package/genet/internal/k8s"
+	"github.com/uc-package/genet/internal/models"
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+const (
+	podProxyShareTokenType  = "pod_proxy_share"
+	defaultPodProxyShareTTL = 24 * time.Hour
+)
+
+// podProxyShareClaims 应用分享链接签名内容
+type podProxyShareClaims struct {
+	Namespace string `json:"namespace"`
+	PodName   string `json:"podName"`
+	PodUID    string `json:"podUid,omitempty"`
+	Port      int    `json:"port"`
+	TokenType string `json:"tokenType"`
+	jwt.RegisteredClaims
+}
+
+// validatePodProxyPorts 校验用户开放的代理端口是否在管理员白名单内
+func validatePodProxyPorts(cfg models.AppProxyConfig, ports []int) error {
+	if len(ports) == 0 {
+		return nil
+	}
+	if !cfg.Enabled {
+		return fmt.Errorf("管理员未开启应用代理功能")
+	}
+	for _, port := range ports {
+		if port <= 0 || port > 65535 {
+			return fmt.Errorf("无效的端口: %d", port)
+		}
+		if !cfg.IsPortAllowed(port) {
+			return fmt.Errorf("端口 %d 不在允许代理的端口列表中", port)
+		}
+	}
+	return nil
+}
+
+func podAppProxyBasePath(podName string, port int) string {
+	return fmt.Sprintf("/api/pods/%s/proxy/%d", url.PathEscape(podName), port)
+}
+
+// buildPodAppProxies 构建 Pod 已开放且管理员允许的应用代理入口
+// 入口位于 /api 下，校验权限后跳转到应用的独立域名
+func (h *PodHandler) buildPodAppProxies(pod *corev1.Pod) []models.PodAppProxy {
+	cfg := h.config.Pod.AppProxy
+	if !cfg.IsAvailable() || pod == nil {
+		return nil
+	}
+	var proxies []models.PodAppProxy
+	for _, port := range k8s.GetPodProxyPorts(pod) {
+		if !cfg.IsPortAllowed(port) {
+			continue
+		}
+		proxies = append(proxies, models.PodAppProxy{
+			Port: port,
+			URL:  podAppProxyBasePath(pod.Name, port) + "/",
+		})
+	}
+	return proxies
+}
+
+func (h *PodHandler) defaultPodAppTargetURL(pod *corev1.Pod, port int) (*url.URL, error) {
+	if pod == nil || strings.TrimSpace(pod.Status.PodIP) == "" {
+		return nil, fmt.Errorf("pod IP unavailable")
+	}
+	return url.Parse(fmt.Sprintf("http://%s:%d", pod.Status.PodIP, port))
+}
+
+// buildPodAppProxy 构建 Pod 内 Web 应用的反向代理（支持 WebSocket Upgrade）
+// basePath 为 Genet 侧的访问前缀，会通过 X-Forwarded-Prefix 告知应用，
+// 并用于改写应用返回的绝对路径重定向，避免跳出代理前缀。
+func buildPodAppProxy(target *url.URL, basePath, forwardedPath, appName string) *httputil.ReverseProxy {
+	basePath = strings.TrimRight(basePath, "/")
+	return &httputil.ReverseProxy{
+		Director: func(req *http.Request) {
+			originalHost := req.Host
+			req.URL.Scheme = target.Scheme
+			req.URL.Host = target.Host
+			if forwardedPath == "" {
+				forwardedPath = "/"
+			}
+			req.URL.Path = forwardedPath
+			req.URL.RawPath = forwardedPath
+			req.Host = target.Host
+			req.Header.Set("X-Forwarded-Host", originalHost)
+			req.Header.Set("X-Forwarded-Proto", "https")
+			if basePath != "" {
+				req.Header.Set("X-Forwarded-Prefix", basePath)
+			}
+			stripGenetCredentials(req)
+		},
+		ModifyResponse: func(resp *http.Response) error {
+			if location := resp.Header.Get("Location"); location != "" {
+				resp.Header.Set("Location", rewriteProxyLocation(location, basePath))
+			}
+			return nil
+		},
+		ErrorHandler: func(rw http.ResponseWriter, _ *http.Request, err error) {
+			payload, _ := json.Marshal(gin.H{"error": fmt.Sprintf("代理 %s 失败: %s", appName, err.Error())})
+			rw.Header().Set("Content-Type", "application/json")
+			rw.WriteHeader(http.StatusBadGateway)
+			_, _ = rw.Write(payload)
+		},
+	}
+}
+
+// stripGenetCredentials 移除 Genet 自身的认证信息，避免泄露给 Pod 内应用
+func stripGenetCredentials(req *http.Request) {
+	req.Header.Del("Authorization")
+	cookies := req.Cookies()
+	if len(cookies) == 0 {
+		return
+	}
+	req.Header.Del("Cookie")
+	for _, cookie := range cookies {
+		if cookie.Name == auth.SessionCookieName || cookie.Name == podAppSessionCookieName {
+			continue
+		}
+		req.AddCookie(cookie)
+	}
+}
+
+// rewriteProxyLocation 为应用返回的站内绝对路径补齐代理前缀
+func rewriteProxyLocation(location, basePath string) string {
+	if basePath == "" || !strings.HasPrefix(location, "/") || strings.HasPrefix(location, "//") {
+		return location
+	}
+	if location == basePath || strings.HasPrefix(location, basePath+"/") {
+		return location
+	}
+	return basePath + location
+}
+
+func parsePodProxyPort(value string) (int, error) {
+	port, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil || port <= 0 || port > 65535 {
+		return 0, fmt.Errorf("无效的端口: %s", value)
+	}
+	return port, nil
+}
+
+// checkPodAppProxyAccess 校验 Pod 状态、端口开放与白名单，返回可写入响应的状态码
+func (h *PodHandler) checkPodAppProxyAccess(pod *corev1.Pod, port int) (int, error) {
+	cfg := h.config.Pod.AppProxy
+	if !cfg.IsPortAllowed(port) {
+		return http.StatusForbidden, fmt.Errorf("端口 %d 不在允许代理的端口列表中", port)
+	}
+	opened := false
+	for _, p := range k8s.GetPodProxyPorts(pod) {
+		if p == port {
+			opened = true
+			break
+		}
+	}
+	if !opened {
+		return http.StatusForbidden, fmt.Errorf("Pod 未开放端口 %d 的应用代理", port)
+	}
+	if pod.Status.Phase != corev1.PodRunning {
+		return http.StatusConflict, fmt.Errorf("Pod 未处于运行状态，暂时无法访问应用")
+	}
+	if strings.TrimSpace(pod.Status.PodIP) == "" {
+		return http.StatusServiceUnavailable, fmt.Errorf("Pod IP 不可用，暂时无法访问应用")
+	}
+	return http.StatusOK, nil
+}
+
+// servePodAppProxy 在应用独立域名的根路径下代理 Pod 应用
+func (h *PodHandler) servePodAppProxy(c *gin.Context, pod *corev1.Pod, port int) {
+	if status, err := h.checkPodAppProxyAccess(pod, port); err != nil {
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	targetURLFn := h.podAppTargetURL
+	if targetURLFn == nil {
+		targetURLFn = h.defaultPodAppTargetURL
+	}
+	targetURL, err := targetURLFn(pod, port)
+	if err != nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+		return
+	}
+
+	buildPodAppProxy(targetURL, "", c.Request.URL.Path, fmt.Sprintf("端口 %d", port)).ServeHTTP(c.Writer, c.Request)
+}
+
+// ProxyPodApp 应用代理入口：校验 Pod 归属与端口后，带一次性票据跳转到应用的独立域名
+// （应用与 Genet 不同源，应用内脚本无法借用户 Cookie 调用 /api）
+func (h *PodHandler) ProxyPodApp(c *gin.Context) {
+	if !h.config.Pod.AppProxy.IsAvailable() {
+		c.JSON(http.StatusNotFound, gin.H{"error": "应用代理未启用"})
+		return
+	}
+
+	username, _ := auth.GetUsername(c)
+	email, _ := auth.GetEmail(c)
+	podID := c.Param("id")
+	userIdentifier := k8s.GetUserIdentifier(username, email)
+	namespace := k8s.GetNamespaceForUserIdentifier(userIdentifier)
+
+	port, err := parsePodProxyPort(c.Param("port"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	pod, err := h.getPod(c.Request.Context(), namespace, podID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Pod 不存在"})
+		return
+	}
+
+	if status, err := h.checkPodAppProxyAccess(pod, port); err != nil {
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	h.redirectToPodApp(c, podAppClaims{
+		Namespace: namespace,
+		PodName:   pod.Name,
+		PodUID:    string(pod.UID),
+		Port:      port,
+	}, userIdentifier, time.Now().Add(podAppSessionTTL))
+}
+
+// UpdatePodProxyPorts 更新 Pod 开放给应用代理的端口
+func (h *PodHandler) UpdatePodProxyPorts(c *gin.Context) {
+	if !h.config.Pod.AppProxy.Enabled {
+		c.JSON(http.StatusNotFound, gin.H{"error": "应用代理未启用"})
+		return
+	}
+
+	username, _ := auth.GetUsername(c)
+	email, _ := auth.GetEmail(c)
+	podID := c.Param("id")
+	userIdentifier := k8s.GetUserIdentifier(username, email)
+	namespace := k8s.GetNamespaceForUserIdentifier(userIdentifier)
+
+	var req models.UpdatePodProxyPortsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的请求参数"})
+		return
+	}
+	if err := validatePodProxyPorts(h.config.Pod.AppProxy, req.Ports); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	pod, err := h.k8sClient.UpdatePodProxyPorts(c.Request.Context(), namespace, podID, req.Ports)
+	if err != nil {
+		h.log.Error("Failed to update pod proxy ports",
+			zap.String("user", username),
+			zap.String("podID", podID),
+			zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	h.log.Info("Pod proxy ports updated",
+		zap.String("user", username),
+		zap.String("podID", podID),
+		zap.Ints("ports", k8s.GetPodProxyPorts(pod)))
+
+	c.JSON(http.StatusOK, gin.H{
+		"ports":      k8s.GetPodProxyPorts(pod),
+		"appProxies": h.buildPodAppProxies(pod),
+	})
+}
+
+// CreatePodProxyShare 为 Pod 应用生成带过期时间的签名分享链接
+func (h *PodHandler) CreatePodProxyShare(c *gin.Context) {
+	cfg := h.config.Pod.AppProxy
+	if !cfg.IsAvailable() || !cfg.ShareLinkEnabled {
+		c.JSON(http.StatusNotFound, gin.H{"error": "应用分享链接未启用"})
+		return
+	}
+	if h.config.OAuth.JWTSecret == "" {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "未配置签名密钥，无法生成分享链接"})
+		return
+	}
+
+	username, _ := auth.GetUsername(c)
+	email, _ := auth.GetEmail(c)
+	podID := c.Param("id")
+	userIdentifier := k8s.GetUserIdentifier(username, email)
+	namespace := k8s.GetNamespaceForUserIdentifier(userIdentifier)
+
+	var req models.CreatePodProxyShareRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请指定要分享的端口"})
+		return
+	}
+
+	pod, err := h.getPod(c.Request.Context(), namespace, podID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Pod 不存在"})
+		return
+	}
+	if status, err := h.checkPodAppProxyAccess(pod, req.Port); err != nil && status == http.StatusForbidden {
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	ttl := podProxyShareMaxTTL(cfg)
+	if req.ExpiresInMinutes > 0 {
+		requested := time.Duration(req.ExpiresInMinutes) * time.Minute
+		if requested < ttl {
+			ttl = requested
+		}
+	}
+
+	now := time.Now().UTC()
+	expiresAt := now.Add(ttl)
+	shareID, err := generatePodProxyShareID()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "生成分享链接失败"})
+		return
+	}
+	token, err := signPodProxyShareToken(h.config.OAuth.JWTSecret, podProxyShareClaims{
+		Namespace: namespace,
+		PodName:   pod.Name,
+		PodUID:    string(pod.UID),
+		Port:      req.Port,
+		TokenType: podProxyShareTokenType,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        shareID,
+			Subject:   userIdentifier,
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+			Issuer:    "genet-app-proxy",
+		},
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("生成分享链接失败: %v", err)})
+		return
+	}
+
+	// 链接只有登记在 Pod 上才有效，撤销即从 Pod 上移除
+	if err := h.k8sClient.AddPodProxyShare(c.Request.Context(), namespace, pod.Name, models.PodProxyShare{
+		ID:        shareID,
+		Port:      req.Port,
+		CreatedBy: userIdentifier,
+		CreatedAt: now,
+		ExpiresAt: expiresAt,
+	}); err != nil {
+		if errors.Is(err, k8s.ErrTooManyPodProxyShares) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		h.log.Error("Failed to record pod app share link", zap.String("podID", podID), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "生成分享链接失败"})
+		return
+	}
+
+	h.log.Info("Pod app share link created",
+		zap.String("user", username),
+		zap.String("podID", podID),
+		zap.String("share", shareID),
+		zap.Int("port", req.Port),
+		zap.Time("expiresAt", expiresAt))
+
+	c.JSON(http.StatusCreated, models.PodProxyShareResponse{
+		ID:        shareID,
+		URL:       podProxySharedBasePath(token) + "/",
+		Token:     token,
+		Port:      req.Port,
+		ExpiresAt: expiresAt,
+	})
+}
+
+// ProxySharedPodApp 分享链接入口，仅要求访问者已登录；校验链接未过期、未撤销后跳转到应用的独立域名
+func (h *PodHandler) ProxySharedPodApp(c *gin.Context) {
+	cfg := h.config.Pod.AppProxy
+	if !cfg.IsAvailable() || !cfg.ShareLinkEnabled {
+		c.JSON(http.StatusNotFound, gin.H{"error": "应用分享链接未启用"})
+		return
+	}
+
+	claims, err := parsePodProxyShareToken(h.config.OAuth.JWTSecret, c.Param("token"))
+	if err != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": "分享链接无效或已过期"})
+		return
+	}
+
+	pod, err := h.getPod(c.Request.Context(), claims.Namespace, claims.PodName)
+	if err != nil || (claims.PodUID != "" && string(pod.UID) != claims.PodUID) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "分享的 Pod 不存在"})
+		return
+	}
+	share, ok := findPodProxyShare(pod, claims.ID, claims.Port)
+	if !ok {
+		c.JSON(http.StatusForbidden, gin.H{"error": "分享链接已被撤销"})
+		return
+	}
+	if status, err := h.checkPodAppProxyAccess(pod, claims.Port); err != nil {
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	username, _ := auth.GetUsername(c)
+	email, _ := auth.GetEmail(c)
+	h.log.Debug("Serving shared pod app",
+		zap.String("viewer", username),
+		zap.String("owner", claims.Subject),
+		zap.String("podID", claims.PodName),
+		zap.Int("port", claims.Port))
+
+	expiresAt := time.Now().Add(podAppSessionTTL)
+	if share.ExpiresAt.Before(expiresAt) {
+		expiresAt = share.ExpiresAt
+	}
+	h.redirectToPodApp(c, podAppClaims{
+		Namespace: claims.Namespace,
+		PodName:   pod.Name,
+		PodUID:    string(pod.UID),
+		Port:      claims.Port,
+		ShareID:   claims.ID,
+	}, k8s.GetUserIdentifier(username, email), expiresAt)
+}
+
+// ListPodProxyShares 列出 Pod 上有效的分享链接
+// GET /api/pods/:id/proxy-shares
+func (h *PodHandler) ListPodProxyShares(c *gin.Context) {
+	username, _ := auth.GetUsername(c)
+	email, _ := auth.GetEmail(c)
+	namespace := k8s.GetNamespaceForUserIdentifier(k8s.GetUserIdentifier(username, email))
+
+	pod, err := h.getPod(c.Request.Context(), namespace, c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Pod 不存在"})
+		return
+	}
+	shares := k8s.GetPodProxyShares(pod, time.Now())
+	if shares == nil {
+		shares = []models.PodProxyShare{}
+	}
+	c.JSON(http.StatusOK, gin.H{"shares": shares})
+}
+
+// RevokePodProxyShare 撤销分享链接，已通过该链接打开的应用也随之失效
+// DELETE /api/pods/:id/proxy-shares/:shareId
+func (h *PodHandler) RevokePodProxyShare(c *gin.Context) {
+	username, _ := auth.GetUsername(c)
+	email, _ := auth.GetEmail(c)
+	namespace := k8s.GetNamespaceForUserIdentifier(k8s.GetUserIdentifier(username, email))
+	podID := c.Param("id")
+	shareID := c.Param("shareId")
+
+	found, err := h.k8sClient.RevokePodProxyShare(c.Request.Context(), namespace, podID, shareID)
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Pod 不存在"})
+			return
+		}
+		h.log.Error("Failed to revoke pod app share link",
+			zap.String("user", username),
+			zap.String("podID", podID),
+			zap.String("share", shareID),
+			zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "撤销分享链接失败"})
+		return
+	}
+	if !found {
+		c.JSON(http.StatusNotFound, gin.H{"error": "分享链接不存在或已过期"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "分享链接已撤销"})
+}
+
+// findPodProxyShare 查找 Pod 上登记且未过期的分享链接
+func findPodProxyShare(pod *corev1.Pod, id string, port int) (models.PodProxyShare, bool) {
+	if id == "" {
+		return models.PodProxyShare{}, false
+	}
+	for _, share := range k8s.GetPodProxyShares(pod, time.Now()) {
+		if share.ID == id && share.Port == port {
+			return share, true
+		}
+	}
+	return models.PodProxyShare{}, false
+}
+
+func generatePodProxyShareID() (string, error) {
+	buf := make([]byte, 12)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+func podProxySharedBasePath(token string) string {
+	return "/api/proxy-shares/" + url.PathEscape(token)
+}
+
+func podProxyShareMaxTTL(cfg models.AppProxyConfig) time.Duration {
+	if cfg.ShareLinkMaxTTLMinutes > 0 {
+		return time.Duration(cfg.ShareLinkMaxTTLMinutes) * time.Minute
+	}
+	return defaultPodProxyShareTTL
+}
+
+func signPodProxyShareToken(secret string, claims podProxyShareClaims) (string, error) {
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString([]byte(secret))
+}
+
+func parsePodProxyShareToken(secret, tokenString string) (*podProxyShareClaims, error) {
+	if secret == "" {
+		return nil, jwt.ErrSignatureInvalid
+	}
+	token, err := jwt.ParseWithClaims(tokenString, &podProxyShareClaims{}, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, jwt.ErrSignatureInvalid
+		}
+		return []byte(secret), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	claims, ok := token.Claims.(*podProxyShareClaims)
+	if !ok || !token.Valid || claims.TokenType != podProxyShareTokenType || claims.PodName == "" || claims.Port <= 0 || claims.ID == "" {
+		return nil, jwt.ErrSignatureInvalid
+	}
+	return claims, nil
+}
@@ -0,0 +1,246 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/uc-package/genet/internal/k8s"
+	"go.uber.org/zap"
+)
+
+const (
+	// podAppSessionCookieName 应用独立域名上的会话 Cookie（host-only，不会发往 Genet 或其它应用）
+	podAppSessionCookieName = "genet_app_session"
+	// podAppAuthPath 应用域名上用一次性票据换取会话 Cookie 的路径
+	podAppAuthPath = "/_genet/auth"
+
+	podAppTicketTokenType  = "pod_app_ticket"
+	podAppSessionTokenType = "pod_app_session"
+	podAppTicketTTL        = time.Minute
+	podAppSessionTTL       = 12 * time.Hour
+)
+
+// podAppClaims 应用域名的票据与会话内容，ShareID 非空表示通过分享链接访问
+type podAppClaims struct {
+	Namespace string `json:"namespace"`
+	PodName   string `json:"podName"`
+	PodUID    string `json:"podUid,omitempty"`
+	Port      int    `json:"port"`
+	ShareID   string `json:"shareId,omitempty"`
+	Path      string `json:"path,omitempty"`
+	// SessionExpiresAt 票据换取的会话 Cookie 的过期时间（Unix 秒）
+	SessionExpiresAt int64  `json:"sessionExp,omitempty"`
+	TokenType        string `json:"tokenType"`
+	// RegisteredClaims.Subject 为访问者的用户标识
+	jwt.RegisteredClaims
+}
+
+// podAppLabel 应用的子域名标签，同一 Pod 端口（以及每个分享链接）各自独立成源
+func podAppLabel(claims podAppClaims) string {
+	sum := sha256.Sum256([]byte(strings.Join([]string{
+		claims.Namespace, claims.PodName, claims.PodUID, claims.ShareID, strconv.Itoa(claims.Port),
+	}, "\x00")))
+	return "app-" + hex.EncodeToString(sum[:12])
+}
+
+// podAppOrigin 应用独立域名的 origin，协议跟随前端地址
+func (h *PodHandler) podAppOrigin(label string) string {
+	scheme := "https"
+	if frontendURL, err := url.Parse(h.config.OAuth.FrontendURL); err == nil && frontendURL.Scheme == "http" {
+		scheme = "http"
+	}
+	return scheme + "://" + label + "." + strings.TrimSpace(h.config.Pod.AppProxy.Domain)
+}
+
+// redirectToPodApp 签发短期票据并跳转到应用域名，原请求中 /proxy/:port 或分享链接之后的路径与查询参数保留
+func (h *PodHandler) redirectToPodApp(c *gin.Context, claims podAppClaims, subject string, sessionExpiresAt time.Time) {
+	if h.config.OAuth.JWTSecret == "" {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "未配置签名密钥，无法访问应用"})
+		return
+	}
+	path := c.Param("path")
+	if !strings.HasPrefix(path, "/") || strings.HasPrefix(path, "//") {
+		path = "/"
+	}
+	if c.Request.URL.RawQuery != "" {
+		path += "?" + c.Request.URL.RawQuery
+	}
+
+	now := time.Now()
+	ticketExpiresAt := now.Add(podAppTicketTTL)
+	if sessionExpiresAt.Before(ticketExpiresAt) {
+		ticketExpiresAt = sessionExpiresAt
+	}
+	claims.Path = path
+	claims.SessionExpiresAt = sessionExpiresAt.Unix()
+	claims.TokenType = podAppTicketTokenType
+	claims.RegisteredClaims = jwt.RegisteredClaims{
+		Subject:   subject,
+		IssuedAt:  jwt.NewNumericDate(now),
+		ExpiresAt: jwt.NewNumericDate(ticketExpiresAt),
+	}
+	ticket, err := signPodAppToken(h.config.OAuth.JWTSecret, claims)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "生成访问票据失败"})
+		return
+	}
+	target := h.podAppOrigin(podAppLabel(claims)) + podAppAuthPath + "?ticket=" + url.QueryEscape(ticket)
+	c.Redirect(http.StatusFound, target)
+}
+
+// PodAppOriginMiddleware 处理发往应用独立域名（*.pod.appProxy.domain）的请求，其它请求交给后续路由。
+// 应用域名只接受本域名的会话 Cookie，不接受 Genet 的会话或 Bearer token
+func (h *PodHandler) PodAppOriginMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		label, ok := h.podAppLabelFromHost(c.Request.Host)
+		if !ok {
+			c.Next()
+			return
+		}
+		c.Abort()
+		if !h.config.Pod.AppProxy.IsAvailable() {
+			c.JSON(http.StatusNotFound, gin.H{"error": "应用代理未启用"})
+			return
+		}
+		if c.Request.URL.Path == podAppAuthPath {
+			h.exchangePodAppTicket(c, label)
+			return
+		}
+		h.servePodAppOrigin(c, label)
+	}
+}
+
+func (h *PodHandler) podAppLabelFromHost(host string) (string, bool) {
+	domain := strings.ToLower(strings.TrimSpace(h.config.Pod.AppProxy.Domain))
+	if domain == "" {
+		return "", false
+	}
+	host = strings.ToLower(stripPort(host))
+	label, found := strings.CutSuffix(host, "."+stripPort(domain))
+	if !found || label == "" || strings.Contains(label, ".") {
+		return "", false
+	}
+	return label, true
+}
+
+// exchangePodAppTicket 校验票据后在应用域名上设置会话 Cookie 并跳回原路径
+func (h *PodHandler) exchangePodAppTicket(c *gin.Context, label string) {
+	claims, err := parsePodAppToken(h.config.OAuth.JWTSecret, c.Query("ticket"), podAppTicketTokenType, label)
+	if err != nil || claims.SessionExpiresAt == 0 {
+		c.JSON(http.StatusForbidden, gin.H{"error": "访问票据无效或已过期，请重新从 Genet 打开应用"})
+		return
+	}
+
+	sessionExpiresAt := time.Unix(claims.SessionExpiresAt, 0)
+	path := claims.Path
+	claims.Path = ""
+	claims.SessionExpiresAt = 0
+	claims.TokenType = podAppSessionTokenType
+	claims.RegisteredClaims = jwt.RegisteredClaims{
+		Subject:   claims.Subject,
+		IssuedAt:  jwt.NewNumericDate(time.Now()),
+		ExpiresAt: jwt.NewNumericDate(sessionExpiresAt),
+	}
+	session, err := signPodAppToken(h.config.OAuth.JWTSecret, *claims)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "生成应用会话失败"})
+		return
+	}
+
+	http.SetCookie(c.Writer, &http.Cookie{
+		Name:     podAppSessionCookieName,
+		Value:    session,
+		Path:     "/",
+		Expires:  sessionExpiresAt,
+		HttpOnly: true,
+		Secure:   h.config.OAuth.CookieSecure,
+		SameSite: http.SameSiteLaxMode,
+	})
+	if !strings.HasPrefix(path, "/") || strings.HasPrefix(path, "//") {
+		path = "/"
+	}
+	c.Redirect(http.StatusFound, path)
+}
+
+// servePodAppOrigin 按会话 Cookie 代理应用；每次请求都重新校验 Pod 与分享链接，撤销后立即失效
+func (h *PodHandler) servePodAppOrigin(c *gin.Context, label string) {
+	cookie, err := c.Request.Cookie(podAppSessionCookieName)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "请从 Genet 打开应用"})
+		return
+	}
+	claims, err := parsePodAppToken(h.config.OAuth.JWTSecret, cookie.Value, podAppSessionTokenType, label)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "应用会话已过期，请从 Genet 重新打开应用"})
+		return
+	}
+
+	pod, err := h.getPod(c.Request.Context(), claims.Namespace, claims.PodName)
+	if err != nil || (claims.PodUID != "" && string(pod.UID) != claims.PodUID) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Pod 不存在"})
+		return
+	}
+	if claims.ShareID != "" {
+		if !h.config.Pod.AppProxy.ShareLinkEnabled {
+			c.JSON(http.StatusNotFound, gin.H{"error": "应用分享链接未启用"})
+			return
+		}
+		if _, ok := findPodProxyShare(pod, claims.ShareID, claims.Port); !ok {
+			c.JSON(http.StatusForbidden, gin.H{"error": "分享链接已被撤销或已过期"})
+			return
+		}
+	} else if k8s.GetNamespaceForUserIdentifier(claims.Subject) != claims.Namespace {
+		c.JSON(http.StatusForbidden, gin.H{"error": "无权访问该应用"})
+		return
+	}
+
+	h.log.Debug("Serving pod app",
+		zap.String("user", claims.Subject),
+		zap.String("podID", claims.PodName),
+		zap.Int("port", claims.Port),
+		zap.String("share", claims.ShareID))
+	h.servePodAppProxy(c, pod, claims.Port)
+}
+
+func signPodAppToken(secret string, claims podAppClaims) (string, error) {
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(secret))
+}
+
+// parsePodAppToken 校验签名、类型与过期时间，并确认令牌属于当前子域名
+func parsePodAppToken(secret, tokenString, tokenType, label string) (*podAppClaims, error) {
+	if secret == "" || tokenString == "" {
+		return nil, jwt.ErrSignatureInvalid
+	}
+	token, err := jwt.ParseWithClaims(tokenString, &podAppClaims{}, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, jwt.ErrSignatureInvalid
+		}
+		return []byte(secret), nil
+	}, jwt.WithExpirationRequired())
+	if err != nil {
+		return nil, err
+	}
+	claims, ok := token.Claims.(*podAppClaims)
+	if !ok || !token.Valid || claims.TokenType != tokenType || claims.PodName == "" || claims.Port <= 0 {
+		return nil, jwt.ErrSignatureInvalid
+	}
+	if podAppLabel(*claims) != label {
+		return nil, jwt.ErrSignatureInvalid
+	}
+	return claims, nil
+}
+
+func stripPort(host string) string {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		return h
+	}
+	return host
+}
@@ -0,0 +1,434 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/gorilla/websocket"
+	"github.com/uc-package/genet/internal/auth"
+	"github.com/uc-package/genet/internal/k8s"
+	"github.com/uc-package/genet/internal/models"
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestValidatePodProxyPortsRequiresAllowlist(t *testing.T) {
+	cfg := models.AppProxyConfig{Enabled: true, AllowedPorts: []int{6006, 7860}}
+
+	if err := validatePodProxyPorts(cfg, []int{6006}); err != nil {
+		t.Fatalf("expected allowed port, got %v", err)
+	}
+	if err := validatePodProxyPorts(cfg, []int{22}); err == nil {
+		t.Fatalf("expected port outside allowlist to be rejected")
+	}
+	cfg.Enabled = false
+	if err := validatePodProxyPorts(cfg, []int{6006}); err == nil {
+		t.Fatalf("expected ports to be rejected when app proxy disabled")
+	}
+}
+
+func TestBuildPodConnectionsIncludesOpenedAppProxies(t *testing.T) {
+	handler := newPodProxyTestHandler("")
+	pod := newPodProxyTestPod("10.0.0.8", "6006,22")
+
+	connections := handler.buildPodConnections(context.Background(), pod)
+
+	if len(connections.Apps.AppProxies) != 1 {
+		t.Fatalf("expected only allowlisted port, got %+v", connections.Apps.AppProxies)
+	}
+	if connections.Apps.AppProxies[0].URL != "/api/pods/pod-alice-dev/proxy/6006/" {
+		t.Fatalf("unexpected proxy url: %q", connections.Apps.AppProxies[0].URL)
+	}
+}
+
+func TestRewriteProxyLocationAddsBasePath(t *testing.T) {
+	cases := map[string]string{
+		"/login":                          "/api/pods/p/proxy/6006/login",
+		"/api/pods/p/proxy/6006/data":     "/api/pods/p/proxy/6006/data",
+		"./relative":                      "./relative",
+		"https://example.com/elsewhere":   "https://example.com/elsewhere",
+		"//cdn.example.com/static/app.js": "//cdn.example.com/static/app.js",
+	}
+	for location, expected := range cases {
+		if got := rewriteProxyLocation(location, "/api/pods/p/proxy/6006"); got != expected {
+			t.Fatalf("rewrite %q: expected %q, got %q", location, expected, got)
+		}
+	}
+}
+
+func TestProxyPodAppRejectsPortNotOpenedByPod(t *testing.T) {
+	_, router := newPodProxyTestRouter(t, newPodProxyTestPod("10.0.0.8", "6006"), "")
+
+	req := newPodProxyTestRequest(http.MethodGet, "/api/pods/pod-alice-dev/proxy/7860/", nil)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusForbidden {
+		t.Fatalf("expected status 403, got %d: %s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestProxyPodAppRedirectsToIsolatedOrigin(t *testing.T) {
+	_, router := newPodProxyTestRouter(t, newPodProxyTestPod("10.0.0.8", "6006,7860"), "")
+
+	host, cookie := openPodAppForTest(t, router, newPodProxyTestRequest(http.MethodGet, "/api/pods/pod-alice-dev/proxy/6006/data?run=1", nil), "/data?run=1")
+	if !strings.HasSuffix(host, ".apps.genet.test") || cookie.HttpOnly != true || cookie.Domain != "" {
+		t.Fatalf("unexpected app origin %q / cookie %+v", host, cookie)
+	}
+
+	// 每个应用独立成源，会话 Cookie 不能用于其它应用的域名
+	otherHost, _ := openPodAppForTest(t, router, newPodProxyTestRequest(http.MethodGet, "/api/pods/pod-alice-dev/proxy/7860/", nil), "/")
+	if otherHost == host {
+		t.Fatalf("expected different ports to use different origins")
+	}
+	req := httptest.NewRequest(http.MethodGet, "http://"+otherHost+"/", nil)
+	req.AddCookie(cookie)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("expected cookie of another app to be rejected, got %d", rec.Code)
+	}
+
+	// 应用域名不接受 Genet 的认证，也不会路由到 /api
+	req = httptest.NewRequest(http.MethodGet, "http://"+host+"/api/pods", nil)
+	setPodProxyTestUser(req, "alice")
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("expected app origin to require its own session, got %d", rec.Code)
+	}
+
+	// 票据只能在签发它的子域名上使用
+	entry := httptest.NewRecorder()
+	router.ServeHTTP(entry, newPodProxyTestRequest(http.MethodGet, "/api/pods/pod-alice-dev/proxy/6006/", nil))
+	location, _ := url.Parse(entry.Header().Get("Location"))
+	location.Host = otherHost
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, location.String(), nil))
+	if rec.Code != http.StatusForbidden {
+		t.Fatalf("expected ticket replay on another origin to be rejected, got %d", rec.Code)
+	}
+}
+
+func TestProxyPodAppUnavailableWithoutDomain(t *testing.T) {
+	handler, router := newPodProxyTestRouter(t, newPodProxyTestPod("10.0.0.8", "6006"), "")
+	handler.config.Pod.AppProxy.Domain = ""
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, newPodProxyTestRequest(http.MethodGet, "/api/pods/pod-alice-dev/proxy/6006/", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("expected app proxy to be unavailable without domain, got %d", rec.Code)
+	}
+}
+
+func TestProxyPodAppForwardsRequestWithoutGenetCredentials(t *testing.T) {
+	targetServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]string{
+			"path":          r.URL.Path,
+			"query":         r.URL.RawQuery,
+			"authorization": r.Header.Get("Authorization"),
+			"cookie":        r.Header.Get("Cookie"),
+		})
+	}))
+	defer targetServer.Close()
+
+	handler, router := newPodProxyTestRouter(t, newPodProxyTestPod("10.0.0.8", "6006"), "")
+	handler.podAppTargetURL = func(_ *corev1.Pod, port int) (*url.URL, error) {
+		if port != 6006 {
+			t.Fatalf("unexpected target port %d", port)
+		}
+		return url.Parse(targetServer.URL)
+	}
+	host, session := openPodAppForTest(t, router, newPodProxyTestRequest(http.MethodGet, "/api/pods/pod-alice-dev/proxy/6006/", nil), "/")
+	appServer := httptest.NewServer(router)
+	defer appServer.Close()
+
+	req, err := http.NewRequest(http.MethodGet, appServer.URL+"/data/plugin?tag=loss", nil)
+	if err != nil {
+		t.Fatalf("failed to create request: %v", err)
+	}
+	req.Host = host
+	req.Header.Set("Authorization", "Bearer cli-token")
+	req.AddCookie(session)
+	req.AddCookie(&http.Cookie{Name: auth.SessionCookieName, Value: "secret-session"})
+	req.AddCookie(&http.Cookie{Name: "app", Value: "keep"})
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", resp.StatusCode, string(body))
+	}
+
+	var payload map[string]string
+	if err := json.Unmarshal(body, &payload); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if payload["path"] != "/data/plugin" || payload["query"] != "tag=loss" {
+		t.Fatalf("unexpected forwarded request: %+v", payload)
+	}
+	if payload["authorization"] != "" {
+		t.Fatalf("expected authorization header to be stripped, got %q", payload["authorization"])
+	}
+	if payload["cookie"] != "app=keep" {
+		t.Fatalf("expected genet cookies stripped, got %q", payload["cookie"])
+	}
+}
+
+func TestProxyPodAppSupportsWebSocket(t *testing.T) {
+	upgrader := websocket.Upgrader{}
+	targetServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		msgType, payload, err := conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		_ = conn.WriteMessage(msgType, append([]byte(r.URL.Path+":"), payload...))
+	}))
+	defer targetServer.Close()
+
+	handler, router := newPodProxyTestRouter(t, newPodProxyTestPod("10.0.0.8", "7860"), "")
+	handler.podAppTargetURL = func(*corev1.Pod, int) (*url.URL, error) {
+		return url.Parse(targetServer.URL)
+	}
+	host, session := openPodAppForTest(t, router, newPodProxyTestRequest(http.MethodGet, "/api/pods/pod-alice-dev/proxy/7860/", nil), "/")
+	appServer := httptest.NewServer(router)
+	defer appServer.Close()
+
+	header := http.Header{}
+	header.Set("Host", host)
+	header.Set("Cookie", session.String())
+	wsURL := "ws" + strings.TrimPrefix(appServer.URL, "http") + "/queue/join"
+	conn, _, err := websocket.DefaultDialer.Dial(wsURL, header)
+	if err != nil {
+		t.Fatalf("failed to dial websocket through proxy: %v", err)
+	}
+	defer conn.Close()
+
+	if err := conn.WriteMessage(websocket.TextMessage, []byte("ping")); err != nil {
+		t.Fatalf("write failed: %v", err)
+	}
+	_, payload, err := conn.ReadMessage()
+	if err != nil {
+		t.Fatalf("read failed: %v", err)
+	}
+	if string(payload) != "/queue/join:ping" {
+		t.Fatalf("unexpected echo payload: %q", string(payload))
+	}
+}
+
+func TestPodProxyShareLinkAllowsOtherUserUntilRevoked(t *testing.T) {
+	targetServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("tensorboard" + r.URL.Path))
+	}))
+	defer targetServer.Close()
+
+	pod := newPodProxyTestPod("10.0.0.8", "6006")
+	handler, router := newPodProxyTestRouter(t, pod, "")
+	clientset := fake.NewSimpleClientset(pod.DeepCopy())
+	handler.k8sClient = k8s.NewClientForTest(clientset, handler.config)
+	handler.getPodFn = func(ctx context.Context, namespace, name string) (*corev1.Pod, error) {
+		return clientset.CoreV1().Pods(namespace).Get(ctx, name, metav1.GetOptions{})
+	}
+	handler.podAppTargetURL = func(*corev1.Pod, int) (*url.URL, error) {
+		return url.Parse(targetServer.URL)
+	}
+
+	createReq := newPodProxyTestRequest(http.MethodPost, "/api/pods/pod-alice-dev/proxy-shares", bytes.NewBufferString(`{"port":6006,"expiresInMinutes":30}`))
+	createReq.Header.Set("Content-Type", "application/json")
+	createRec := httptest.NewRecorder()
+	router.ServeHTTP(createRec, createReq)
+	if createRec.Code != http.StatusCreated {
+		t.Fatalf("expected status 201, got %d: %s", createRec.Code, createRec.Body.String())
+	}
+	var share models.PodProxyShareResponse
+	if err := json.Unmarshal(createRec.Body.Bytes(), &share); err != nil {
+		t.Fatalf("failed to decode share response: %v", err)
+	}
+	if share.ID == "" || time.Until(share.ExpiresAt) > 31*time.Minute {
+		t.Fatalf("unexpected share: %+v", share)
+	}
+
+	listRec := httptest.NewRecorder()
+	router.ServeHTTP(listRec, newPodProxyTestRequest(http.MethodGet, "/api/pods/pod-alice-dev/proxy-shares", nil))
+	if listRec.Code != http.StatusOK || !strings.Contains(listRec.Body.String(), share.ID) {
+		t.Fatalf("expected share to be listed, got %d: %s", listRec.Code, listRec.Body.String())
+	}
+
+	viewEntry := httptest.NewRequest(http.MethodGet, share.URL+"scalars", nil)
+	setPodProxyTestUser(viewEntry, "bob")
+	host, session := openPodAppForTest(t, router, viewEntry, "/scalars")
+	appServer := httptest.NewServer(router)
+	defer appServer.Close()
+	view := func() (int, string) {
+		req, err := http.NewRequest(http.MethodGet, appServer.URL+"/scalars", nil)
+		if err != nil {
+			t.Fatalf("failed to create request: %v", err)
+		}
+		req.Host = host
+		req.AddCookie(session)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("request failed: %v", err)
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return resp.StatusCode, string(body)
+	}
+	if code, body := view(); code != http.StatusOK || body != "tensorboard/scalars" {
+		t.Fatalf("unexpected shared response %d: %q", code, body)
+	}
+
+	revokeRec := httptest.NewRecorder()
+	router.ServeHTTP(revokeRec, newPodProxyTestRequest(http.MethodDelete, "/api/pods/pod-alice-dev/proxy-shares/"+share.ID, nil))
+	if revokeRec.Code != http.StatusOK {
+		t.Fatalf("expected revoke to succeed, got %d: %s", revokeRec.Code, revokeRec.Body.String())
+	}
+	if code, _ := view(); code != http.StatusForbidden {
+		t.Fatalf("expected revoked share session to be rejected, got %d", code)
+	}
+	entryRec := httptest.NewRecorder()
+	entryReq := httptest.NewRequest(http.MethodGet, share.URL, nil)
+	setPodProxyTestUser(entryReq, "bob")
+	router.ServeHTTP(entryRec, entryReq)
+	if entryRec.Code != http.StatusForbidden {
+		t.Fatalf("expected revoked share link to be rejected, got %d", entryRec.Code)
+	}
+
+	expired, err := signPodProxyShareToken(handler.config.OAuth.JWTSecret, podProxyShareClaims{
+		Namespace: pod.Namespace,
+		PodName:   pod.Name,
+		Port:      6006,
+		TokenType: podProxyShareTokenType,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        share.ID,
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(-time.Minute)),
+		},
+	})
+	if err != nil {
+		t.Fatalf("failed to sign expired token: %v", err)
+	}
+	expiredReq := httptest.NewRequest(http.MethodGet, podProxySharedBasePath(expired)+"/", nil)
+	setPodProxyTestUser(expiredReq, "bob")
+	expiredRec := httptest.NewRecorder()
+	router.ServeHTTP(expiredRec, expiredReq)
+	if expiredRec.Code != http.StatusForbidden {
+		t.Fatalf("expected expired share to be rejected, got %d", expiredRec.Code)
+	}
+}
+
+// openPodAppForTest 访问 Genet 侧入口，跟随跳转换取应用域名的会话 Cookie，返回应用域名与 Cookie
+func openPodAppForTest(t *testing.T, router http.Handler, entry *http.Request, wantPath string) (string, *http.Cookie) {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, entry)
+	if rec.Code != http.StatusFound {
+		t.Fatalf("expected redirect to app origin, got %d: %s", rec.Code, rec.Body.String())
+	}
+	location, err := url.Parse(rec.Header().Get("Location"))
+	if err != nil || location.Path != podAppAuthPath {
+		t.Fatalf("unexpected app redirect %q", rec.Header().Get("Location"))
+	}
+
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, location.String(), nil))
+	if rec.Code != http.StatusFound || rec.Header().Get("Location") != wantPath {
+		t.Fatalf("expected ticket exchange to redirect to %q, got %d %q", wantPath, rec.Code, rec.Header().Get("Location"))
+	}
+	for _, cookie := range rec.Result().Cookies() {
+		if cookie.Name == podAppSessionCookieName {
+			return location.Host, cookie
+		}
+	}
+	t.Fatalf("expected app session cookie")
+	return "", nil
+}
+
+func newPodProxyTestHandler(jwtSecret string) *PodHandler {
+	cfg := models.DefaultConfig()
+	cfg.OAuth.Enabled = true
+	if jwtSecret != "" {
+		cfg.OAuth.JWTSecret = jwtSecret
+	}
+	cfg.Pod.AppProxy.Enabled = true
+	cfg.Pod.AppProxy.Domain = "apps.genet.test"
+	cfg.Pod.AppProxy.ShareLinkEnabled = true
+	return &PodHandler{
+		config: cfg,
+		log:    zap.NewNop(),
+	}
+}
+
+func newPodProxyTestPod(podIP, proxyPorts string) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "pod-alice-dev",
+			Namespace: "user-alice-alice",
+			UID:       "uid-alice-dev",
+			Annotations: map[string]string{
+				k8s.PodProxyPortsAnnotation: proxyPorts,
+			},
+		},
+		Status: corev1.PodStatus{
+			Phase: corev1.PodRunning,
+			PodIP: podIP,
+		},
+	}
+}
+
+func newPodProxyTestRouter(t *testing.T, pod *corev1.Pod, jwtSecret string) (*PodHandler, *gin.Engine) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	handler := newPodProxyTestHandler(jwtSecret)
+	auth.InitAuthMiddleware(handler.config)
+	handler.getPodFn = func(_ context.Context, namespace, name string) (*corev1.Pod, error) {
+		if namespace != pod.Namespace || name != pod.Name {
+			return nil, io.EOF
+		}
+		return pod, nil
+	}
+
+	router := gin.New()
+	router.Use(handler.PodAppOriginMiddleware())
+	pods := router.Group("/api/pods", auth.AuthMiddleware(handler.config))
+	pods.Any("/:id/proxy/:port", handler.ProxyPodApp)
+	pods.Any("/:id/proxy/:port/*path", handler.ProxyPodApp)
+	pods.PUT("/:id/proxy-ports", handler.UpdatePodProxyPorts)
+	pods.GET("/:id/proxy-shares", handler.ListPodProxyShares)
+	pods.POST("/:id/proxy-shares", handler.CreatePodProxyShare)
+	pods.DELETE("/:id/proxy-shares/:shareId", handler.RevokePodProxyShare)
+	shares := router.Group("/api/proxy-shares", auth.AuthMiddleware(handler.config), auth.RequireAuth)
+	shares.Any("/:token", handler.ProxySharedPodApp)
+	shares.Any("/:token/*path", handler.ProxySharedPodApp)
+	return handler, router
+}
+
+func newPodProxyTestRequest(method, target string, body io.Reader) *http.Request {
+	req := httptest.NewRequest(method, target, body)
+	setPodProxyTestUser(req, "alice")
+	return req
+}
+
+func setPodProxyTestUser(req *http.Request, username string) {
+	req.Header.Set("X-Auth-Request-User", username)
+	req.Header.Set("X-Auth-Request-Email", username+"@example.com")
+}
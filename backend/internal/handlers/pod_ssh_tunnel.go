@@ -0,0 +1,235 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/uc-package/genet/internal/auth"
+	"github.com/uc-package/genet/internal/k8s"
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/remotecommand"
+)
+
+const (
+	sshTunnelModeTCP  = "tcp"
+	sshTunnelModeExec = "exec"
+
+	// sshd -i 以 inetd 模式在 stdio 上说 SSH 协议；优先复用托管 SSH 生成的配置
+	sshTunnelExecScript = `SSHD_BIN="$(command -v sshd 2>/dev/null || echo /usr/sbin/sshd)"
+if [ -f /tmp/genet-sshd/sshd_config ]; then
+  exec "$SSHD_BIN" -i -f /tmp/genet-sshd/sshd_config
+fi
+exec "$SSHD_BIN" -i`
+)
+
+// SSHTunnel 通过 WebSocket 承载到 Pod sshd 的原始字节流（genet ssh-proxy 使用）
+// mode=tcp（默认）直连 Pod 的 sshd 端口；mode=exec 在容器内执行 sshd -i
+func (h *PodHandler) SSHTunnel(c *gin.Context) {
+	username, _ := auth.GetUsername(c)
+	email, _ := auth.GetEmail(c)
+	userIdentifier := k8s.GetUserIdentifier(username, email)
+	namespace := k8s.GetNamespaceForUserIdentifier(userIdentifier)
+	podID := c.Param("id")
+
+	// 在连接 Pod 之前拒绝跨站请求
+	if !h.checkWebSocketOrigin(c.Request) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "不允许跨站建立 SSH 隧道"})
+		return
+	}
+
+	mode := strings.TrimSpace(c.DefaultQuery("mode", sshTunnelModeTCP))
+	if mode != sshTunnelModeTCP && mode != sshTunnelModeExec {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "mode 仅支持 tcp 或 exec"})
+		return
+	}
+
+	pod, err := h.getPod(c.Request.Context(), namespace, podID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Pod 不存在"})
+		return
+	}
+	if pod.Status.Phase != corev1.PodRunning {
+		c.JSON(http.StatusConflict, gin.H{"error": "Pod 未处于运行状态，暂时无法建立 SSH 连接"})
+		return
+	}
+
+	// 先建立到 Pod 的连接，失败时仍可返回普通 HTTP 错误
+	var stream io.ReadWriteCloser
+	switch mode {
+	case sshTunnelModeTCP:
+		if strings.TrimSpace(pod.Status.PodIP) == "" {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Pod IP 不可用，暂时无法建立 SSH 连接"})
+			return
+		}
+		address := net.JoinHostPort(pod.Status.PodIP, strconv.Itoa(h.podSSHPort(pod)))
+		stream, err = h.sshTunnelDial(c.Request.Context(), address)
+	case sshTunnelModeExec:
+		stream, err = h.sshTunnelExec(c.Request.Context(), pod)
+	}
+	if err != nil {
+		h.log.Warn("Failed to open ssh tunnel",
+			zap.String("pod", podID),
+			zap.String("mode", mode),
+			zap.Error(err))
+		c.JSON(http.StatusBadGateway, gin.H{"error": fmt.Sprintf("连接 Pod SSH 失败: %v", err)})
+		return
+	}
+	defer stream.Close()
+
+	conn, err := h.tunnelUpgrader().Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	h.log.Info("SSH tunnel opened",
+		zap.String("user", userIdentifier),
+		zap.String("pod", podID),
+		zap.String("mode", mode))
+	bridgeWebSocketStream(conn, stream)
+	h.log.Info("SSH tunnel closed", zap.String("user", userIdentifier), zap.String("pod", podID))
+}
+
+// podSSHPort 返回 Pod 内 sshd 的监听端口
+func (h *PodHandler) podSSHPort(pod *corev1.Pod) int {
+	if port := k8s.GetPodSSHPort(pod); port > 0 {
+		return port
+	}
+	if h.config.Pod.SSH.Port > 0 {
+		return h.config.Pod.SSH.Port
+	}
+	return 22
+}
+
+func (h *PodHandler) sshTunnelDial(ctx context.Context, address string) (io.ReadWriteCloser, error) {
+	if h.sshTunnelDialFn != nil {
+		return h.sshTunnelDialFn(ctx, address)
+	}
+	dialer := &net.Dialer{Timeout: 10 * time.Second}
+	return dialer.DialContext(ctx, "tcp", address)
+}
+
+func (h *PodHandler) sshTunnelExec(ctx context.Context, pod *corev1.Pod) (io.ReadWriteCloser, error) {
+	if h.sshTunnelExecFn != nil {
+		return h.sshTunnelExecFn(ctx, pod)
+	}
+	restConfig := h.k8sClient.GetRESTConfig()
+	if restConfig == nil {
+		return nil, fmt.Errorf("kubernetes rest config unavailable")
+	}
+
+	req := h.k8sClient.GetClientset().CoreV1().RESTClient().Post().
+		Resource("pods").
+		Namespace(pod.Namespace).
+		Name(pod.Name).
+		SubResource("exec").
+		VersionedParams(&corev1.PodExecOptions{
+			Container: h.podExecContainer(pod),
+			Command:   []string{"/bin/sh", "-c", sshTunnelExecScript},
+			Stdin:     true,
+			Stdout:    true,
+			Stderr:    true,
+		}, scheme.ParameterCodec)
+	executor, err := remotecommand.NewSPDYExecutor(restConfig, http.MethodPost, req.URL())
+	if err != nil {
+		return nil, err
+	}
+
+	stdinReader, stdinWriter := io.Pipe()
+	stdoutReader, stdoutWriter := io.Pipe()
+	streamCtx, cancel := context.WithCancel(ctx)
+	go func() {
+		err := executor.Stream(remotecommand.StreamOptions{
+			Stdin:  stdinReader,
+			Stdout: stdoutWriter,
+			Stderr: io.Discard,
+		})
+		if err == nil {
+			err = io.EOF
+		}
+		_ = stdoutWriter.CloseWithError(err)
+		_ = stdinReader.Close()
+		cancel()
+	}()
+	go func() {
+		<-streamCtx.Done()
+		_ = stdinWriter.Close()
+	}()
+
+	return &pipeStream{Reader: stdoutReader, Writer: stdinWriter, close: func() error {
+		cancel()
+		_ = stdinWriter.Close()
+		return stdoutReader.Close()
+	}}, nil
+}
+
+// pipeStream 将 exec 的 stdin/stdout 组合为一个双向流
+type pipeStream struct {
+	io.Reader
+	io.Writer
+	close func() error
+}
+
+func (s *pipeStream) Close() error {
+	return s.close()
+}
+
+// bridgeWebSocketStream 在 WebSocket 二进制消息与字节流之间双向转发，任一方向结束即返回
+func bridgeWebSocketStream(conn *websocket.Conn, stream io.ReadWriteCloser) {
+	var once sync.Once
+	done := make(chan struct{})
+	finish := func() {
+		once.Do(func() {
+			close(done)
+		})
+	}
+
+	go func() {
+		defer finish()
+		for {
+			msgType, payload, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			if msgType != websocket.BinaryMessage && msgType != websocket.TextMessage {
+				continue
+			}
+			if _, err := stream.Write(payload); err != nil {
+				return
+			}
+		}
+	}()
+
+	go func() {
+		defer finish()
+		buf := make([]byte, 32*1024)
+		for {
+			n, err := stream.Read(buf)
+			if n > 0 {
+				if writeErr := conn.WriteMessage(websocket.BinaryMessage, buf[:n]); writeErr != nil {
+					return
+				}
+			}
+			if err != nil {
+				return
+			}
+		}
+	}()
+
+	<-done
+	_ = stream.Close()
+	_ = conn.WriteControl(websocket.CloseMessage,
+		websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""),
+		time.Now().Add(time.Second))
+}
@@ -0,0 +1,134 @@
+package handlers
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/uc-package/genet/internal/auth"
+	"github.com/uc-package/genet/internal/k8s"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func newSSHTunnelTestRouter(t *testing.T, pod *corev1.Pod) (*PodHandler, *gin.Engine) {
+	t.Helper()
+	handler, _ := newPodProxyTestRouter(t, pod, "")
+	router := gin.New()
+	pods := router.Group("/api/pods", auth.AuthMiddleware(handler.config))
+	pods.GET("/:id/ssh/tunnel", handler.SSHTunnel)
+	return handler, router
+}
+
+func TestSSHTunnelRelaysBytesToPodSSHPort(t *testing.T) {
+	pod := newPodProxyTestPod("10.0.0.8", "")
+	pod.Annotations[k8s.PodSSHPortAnnotation] = "30022"
+	handler, router := newSSHTunnelTestRouter(t, pod)
+
+	var dialed string
+	handler.sshTunnelDialFn = func(_ context.Context, address string) (io.ReadWriteCloser, error) {
+		dialed = address
+		serverSide, clientSide := net.Pipe()
+		go func() {
+			defer serverSide.Close()
+			_, _ = serverSide.Write([]byte("SSH-2.0-OpenSSH_9.6\r\n"))
+			buf := make([]byte, 64)
+			n, err := serverSide.Read(buf)
+			if err != nil {
+				return
+			}
+			_, _ = serverSide.Write(append([]byte("echo:"), buf[:n]...))
+		}()
+		return clientSide, nil
+	}
+
+	server := httptest.NewServer(router)
+	defer server.Close()
+	header := http.Header{}
+	header.Set("X-Auth-Request-User", "alice")
+	header.Set("X-Auth-Request-Email", "alice@example.com")
+	wsURL := "ws" + strings.TrimPrefix(server.URL, "http") + "/api/pods/pod-alice-dev/ssh/tunnel"
+	conn, _, err := websocket.DefaultDialer.Dial(wsURL, header)
+	if err != nil {
+		t.Fatalf("dial tunnel: %v", err)
+	}
+	defer conn.Close()
+
+	if dialed != "10.0.0.8:30022" {
+		t.Fatalf("expected dial to annotated ssh port, got %q", dialed)
+	}
+	msgType, banner, err := conn.ReadMessage()
+	if err != nil || msgType != websocket.BinaryMessage || string(banner) != "SSH-2.0-OpenSSH_9.6\r\n" {
+		t.Fatalf("unexpected banner: type=%d payload=%q err=%v", msgType, banner, err)
+	}
+	if err := conn.WriteMessage(websocket.BinaryMessage, []byte("client-hello")); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	_, payload, err := conn.ReadMessage()
+	if err != nil || string(payload) != "echo:client-hello" {
+		t.Fatalf("unexpected echo: %q err=%v", payload, err)
+	}
+	if _, _, err := conn.ReadMessage(); !websocket.IsCloseError(err, websocket.CloseNormalClosure) {
+		t.Fatalf("expected normal close after pod side ended, got %v", err)
+	}
+}
+
+func TestSSHTunnelRejectsInvalidRequests(t *testing.T) {
+	pod := newPodProxyTestPod("10.0.0.8", "")
+	_, router := newSSHTunnelTestRouter(t, pod)
+
+	req := newPodProxyTestRequest(http.MethodGet, "/api/pods/pod-alice-dev/ssh/tunnel?mode=udp", nil)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for unknown mode, got %d", rec.Code)
+	}
+
+	pod.Status.Phase = corev1.PodPending
+	req = newPodProxyTestRequest(http.MethodGet, "/api/pods/pod-alice-dev/ssh/tunnel", nil)
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	if rec.Code != http.StatusConflict {
+		t.Fatalf("expected 409 for pending pod, got %d", rec.Code)
+	}
+}
+
+func TestSSHTunnelRejectsCrossSiteOrigin(t *testing.T) {
+	pod := newPodProxyTestPod("10.0.0.8", "")
+	handler, router := newSSHTunnelTestRouter(t, pod)
+	dialed := false
+	handler.sshTunnelDialFn = func(_ context.Context, address string) (io.ReadWriteCloser, error) {
+		dialed = true
+		serverSide, clientSide := net.Pipe()
+		go serverSide.Close()
+		return clientSide, nil
+	}
+
+	server := httptest.NewServer(router)
+	defer server.Close()
+	header := http.Header{}
+	header.Set("X-Auth-Request-User", "alice")
+	header.Set("X-Auth-Request-Email", "alice@example.com")
+	header.Set("Origin", "https://evil.example.com")
+	wsURL := "ws" + strings.TrimPrefix(server.URL, "http") + "/api/pods/pod-alice-dev/ssh/tunnel"
+	_, resp, err := websocket.DefaultDialer.Dial(wsURL, header)
+	if err == nil || resp == nil || resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("expected 403 for cross-site origin, got resp=%v err=%v", resp, err)
+	}
+	if dialed {
+		t.Fatal("pod sshd must not be dialed for a cross-site request")
+	}
+
+	// 同源的浏览器请求允许
+	header.Set("Origin", server.URL)
+	conn, _, err := websocket.DefaultDialer.Dial(wsURL, header)
+	if err != nil {
+		t.Fatalf("expected same-origin tunnel to be allowed: %v", err)
+	}
+	conn.Close()
+}
@@ -4,14 +4,18 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"reflect"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
 	"github.com/uc-package/genet/internal/auth"
+	"github.com/uc-package/genet/internal/k8s"
 	"github.com/uc-package/genet/internal/models"
 	"go.uber.org/zap"
 	corev1 "k8s.io/api/core/v1"
@@ -117,8 +121,8 @@ func TestCreateWebShellSessionCreatesSessionForRunningPod(t *testing.T) {
 	if resp.Container != "workspace" {
 		t.Fatalf("expected workspace container, got %q", resp.Container)
 	}
-	if resp.Shell != webShellDisplayShell {
-		t.Fatalf("expected %q, got %q", webShellDisplayShell, resp.Shell)
+	if resp.Shell != webShellSessionShell(webShellMultiplexerAuto, "") || !resp.Persistent {
+		t.Fatalf("expected persistent shell, got %q persistent=%v", resp.Shell, resp.Persistent)
 	}
 	if _, ok := handler.sessions.Get(resp.SessionID); !ok {
 		t.Fatalf("expected session to be stored")
@@ -131,9 +135,135 @@ func TestBuildWebShellCommand(t *testing.T) {
 		"-lc",
 		webShellExecScript,
 	}
-	if got := buildWebShellCommand(); !reflect.DeepEqual(got, expected) {
+	if got := buildWebShellCommand("0123456789abcdef", webShellMultiplexerNone, ""); !reflect.DeepEqual(got, expected) {
 		t.Fatalf("unexpected command: %+v", got)
 	}
+
+	got := buildWebShellCommand("0123456789abcdef", webShellMultiplexerAuto, "")
+	if len(got) != 7 || got[4] != "genet-0123456789abcdef" || got[5] != webShellMultiplexerAuto || got[6] != "" {
+		t.Fatalf("unexpected persistent command: %+v", got)
+	}
+	if !strings.Contains(got[2], "tmux new-session -A") || !strings.HasSuffix(got[2], webShellExecScript) {
+		t.Fatalf("expected tmux attach with shell fallback, got %q", got[2])
+	}
+
+	got = buildWebShellCommand("0123456789abcdef", webShellMultiplexerNone, "/bin/zsh")
+	if len(got) != 7 || got[5] != webShellMultiplexerNone || got[6] != "/bin/zsh" {
+		t.Fatalf("unexpected selected shell command: %+v", got)
+	}
+}
+
+func TestPodBelongsToWorkload(t *testing.T) {
+	stsPod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+		Name:            "train-0",
+		OwnerReferences: []metav1.OwnerReference{{Kind: "StatefulSet", Name: "train"}},
+	}}
+	deployPod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+		Name:            "serve-7d9c-abcde",
+		Labels:          map[string]string{"pod-template-hash": "7d9c"},
+		OwnerReferences: []metav1.OwnerReference{{Kind: "ReplicaSet", Name: "serve-7d9c"}},
+	}}
+	labeledPod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+		Name:   "eval-1",
+		Labels: map[string]string{"genet.io/workload-kind": "statefulset", "genet.io/workload-name": "eval"},
+	}}
+
+	cases := []struct {
+		pod  *corev1.Pod
+		kind string
+		name string
+		want bool
+	}{
+		{stsPod, "statefulset", "train", true},
+		{stsPod, "statefulset", "other", false},
+		{stsPod, "deployment", "train", false},
+		{deployPod, "deployment", "serve", true},
+		{deployPod, "deployment-pod", "serve", true},
+		{deployPod, "deployment", "serve-7d9c", false},
+		{labeledPod, "statefulset", "eval", true},
+		{labeledPod, "statefulset", "", false},
+		{newPodProxyTestPod("10.0.0.8", ""), "statefulset", "train", false},
+	}
+	for _, tc := range cases {
+		if got := podBelongsToWorkload(tc.pod, tc.kind, tc.name); got != tc.want {
+			t.Fatalf("podBelongsToWorkload(%s, %s, %s) = %v, want %v", tc.pod.Name, tc.kind, tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestCreateWebShellSessionValidatesContainerShellAndWorkload(t *testing.T) {
+	pod := newPodProxyTestPod("10.0.0.8", "")
+	pod.Labels = map[string]string{"genet.io/workload-kind": "statefulset", "genet.io/workload-name": "train"}
+	pod.Spec.Containers = []corev1.Container{{Name: "workspace"}, {Name: "sidecar"}, {Name: "exporter"}}
+	pod.Status.ContainerStatuses = []corev1.ContainerStatus{
+		{Name: "workspace", State: corev1.ContainerState{Running: &corev1.ContainerStateRunning{}}},
+		{Name: "sidecar", State: corev1.ContainerState{Running: &corev1.ContainerStateRunning{}}},
+		{Name: "exporter", State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "CrashLoopBackOff"}}},
+	}
+	handler, _ := newPodProxyTestRouter(t, pod, "")
+	handler.sessions = NewWebShellSessionManager(5 * time.Minute)
+	router := gin.New()
+	router.POST("/api/pods/:id/webshell/sessions", auth.AuthMiddleware(handler.config), handler.CreateWebShellSession)
+
+	cases := []struct {
+		body string
+		want int
+	}{
+		{`{"container":"sidecar","shell":"/bin/zsh","workloadKind":"statefulset","workloadName":"train"}`, http.StatusCreated},
+		{`{"workloadKind":"deployment","workloadName":"train"}`, http.StatusForbidden},
+		{`{"workloadKind":"statefulset","workloadName":"other"}`, http.StatusForbidden},
+		{`{"container":"missing"}`, http.StatusBadRequest},
+		{`{"container":"exporter"}`, http.StatusConflict},
+		{`{"shell":"/usr/bin/python3"}`, http.StatusBadRequest},
+	}
+	for _, tc := range cases {
+		req := newPodProxyTestRequest(http.MethodPost, "/api/pods/pod-alice-dev/webshell/sessions", bytes.NewBufferString(tc.body))
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		if rec.Code != tc.want {
+			t.Fatalf("%s: expected %d, got %d: %s", tc.body, tc.want, rec.Code, rec.Body.String())
+		}
+		if tc.want != http.StatusCreated {
+			continue
+		}
+		var resp WebShellSessionResponse
+		_ = json.Unmarshal(rec.Body.Bytes(), &resp)
+		session, _ := handler.sessions.Get(resp.SessionID)
+		if session.Container != "sidecar" || session.SelectedShell != "/bin/zsh" {
+			t.Fatalf("unexpected session target %+v", session)
+		}
+	}
+}
+
+func TestGetWebShellOptionsListsContainers(t *testing.T) {
+	pod := newPodProxyTestPod("10.0.0.8", "")
+	pod.Spec.Containers = []corev1.Container{{Name: "workspace", Image: "cuda:12"}, {Name: "sidecar", Image: "busybox"}}
+	handler, _ := newPodProxyTestRouter(t, pod, "")
+	router := gin.New()
+	router.GET("/api/pods/:id/webshell/options", auth.AuthMiddleware(handler.config), handler.GetWebShellOptions)
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, newPodProxyTestRequest(http.MethodGet, "/api/pods/pod-alice-dev/webshell/options", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var resp WebShellOptionsResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(resp.Containers) != 2 || resp.DefaultContainer != "workspace" || !resp.Containers[0].Main || resp.Containers[1].Main {
+		t.Fatalf("unexpected containers %+v", resp)
+	}
+	if len(resp.Shells) == 0 || resp.WorkloadKind != "pod" {
+		t.Fatalf("unexpected options %+v", resp)
+	}
+
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, newPodProxyTestRequest(http.MethodGet, "/api/pods/pod-alice-dev/webshell/options?workloadKind=deployment&workloadName=serve", nil))
+	if rec.Code != http.StatusForbidden {
+		t.Fatalf("expected 403 for foreign workload, got %d", rec.Code)
+	}
 }
 
 func TestCreateWebShellSessionRejectsNonRunningPod(t *testing.T) {
@@ -207,6 +337,144 @@ func TestDeleteWebShellSessionRemovesSession(t *testing.T) {
 	}
 }
 
+func TestCreateWebShellSessionReattachesExistingSession(t *testing.T) {
+	handler, _ := newPodProxyTestRouter(t, newPodProxyTestPod("10.0.0.8", ""), "")
+	handler.sessions = NewWebShellSessionManager(5 * time.Minute)
+	router := gin.New()
+	router.POST("/api/pods/:id/webshell/sessions", auth.AuthMiddleware(handler.config), handler.CreateWebShellSession)
+
+	create := func(body string) (int, WebShellSessionResponse) {
+		req := newPodProxyTestRequest(http.MethodPost, "/api/pods/pod-alice-dev/webshell/sessions", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		var resp WebShellSessionResponse
+		_ = json.Unmarshal(rec.Body.Bytes(), &resp)
+		return rec.Code, resp
+	}
+
+	_, first := create(`{}`)
+	if first.Reattached {
+		t.Fatalf("expected a new session")
+	}
+	handler.sessions.Detach(first.SessionID)
+
+	code, again := create(`{"sessionId":"` + first.SessionID + `"}`)
+	if code != http.StatusCreated || again.SessionID != first.SessionID || !again.Reattached {
+		t.Fatalf("expected reattach to %s, got %d %+v", first.SessionID, code, again)
+	}
+
+	// 后端重启后未知的会话 ID 沿用原 ID，容器内 tmux 会话仍可附加
+	code, restored := create(`{"sessionId":"00112233445566778899aabbccddeeff"}`)
+	if code != http.StatusCreated || restored.SessionID != "00112233445566778899aabbccddeeff" || !restored.Reattached {
+		t.Fatalf("expected restored session, got %d %+v", code, restored)
+	}
+
+	if code, _ := create(`{"sessionId":"../../etc"}`); code != http.StatusBadRequest {
+		t.Fatalf("expected invalid session id to be rejected, got %d", code)
+	}
+}
+
+func TestListWebShellSessionsMergesContainerSessions(t *testing.T) {
+	handler, _ := newPodProxyTestRouter(t, newPodProxyTestPod("10.0.0.8", ""), "")
+	handler.sessions = NewWebShellSessionManager(5 * time.Minute)
+	owner := k8s.GetUserIdentifier("alice", "alice@example.com")
+	known := handler.sessions.Create(WebShellSessionSpec{PodID: "pod-alice-dev", UserIdentifier: owner, Multiplexer: webShellMultiplexerAuto})
+	handler.sessions.Detach(known.ID)
+	handler.podExecFn = func(_ context.Context, _ *corev1.Pod, command []string, _ io.Reader, stdout io.Writer) error {
+		if command[2] != webShellListScript {
+			t.Fatalf("unexpected command %+v", command)
+		}
+		_, _ = io.WriteString(stdout, "genet-"+known.ID+"\nmain\n\t1234.genet-0123456789abcdef0123\t(Detached)\n")
+		return nil
+	}
+	router := gin.New()
+	router.GET("/api/pods/:id/webshell/sessions", auth.AuthMiddleware(handler.config), handler.ListWebShellSessions)
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, newPodProxyTestRequest(http.MethodGet, "/api/pods/pod-alice-dev/webshell/sessions", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var resp struct {
+		Sessions []WebShellSessionInfo `json:"sessions"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(resp.Sessions) != 2 || resp.Sessions[0].ID != known.ID || resp.Sessions[1].ID != "0123456789abcdef0123" {
+		t.Fatalf("unexpected sessions %+v", resp.Sessions)
+	}
+	if resp.Sessions[1].State != webShellStateDetached {
+		t.Fatalf("expected discovered session to be detached, got %q", resp.Sessions[1].State)
+	}
+}
+
+func TestWebShellWebSocketKeepsSessionOnDetach(t *testing.T) {
+	handler, _ := newPodProxyTestRouter(t, newPodProxyTestPod("10.0.0.8", ""), "")
+	handler.sessions = NewWebShellSessionManager(5 * time.Minute)
+	owner := k8s.GetUserIdentifier("alice", "alice@example.com")
+	router := gin.New()
+	router.GET("/api/pods/:id/webshell/sessions/:sessionId/ws", auth.AuthMiddleware(handler.config), handler.WebShellWebSocket)
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	streamErr := errWebShellDetached
+	handler.webShellStreamFn = func(context.Context, WebShellSession, *websocket.Conn) error {
+		return streamErr
+	}
+	connect := func(sessionID string) {
+		header := http.Header{}
+		header.Set("X-Auth-Request-User", "alice")
+		header.Set("X-Auth-Request-Email", "alice@example.com")
+		conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/api/pods/pod-alice-dev/webshell/sessions/"+sessionID+"/ws", header)
+		if err != nil {
+			t.Fatalf("dial: %v", err)
+		}
+		_, _, _ = conn.ReadMessage()
+		_ = conn.Close()
+	}
+
+	session := handler.sessions.Create(WebShellSessionSpec{PodID: "pod-alice-dev", UserIdentifier: owner, Multiplexer: webShellMultiplexerAuto})
+	connect(session.ID)
+	if items := handler.sessions.List("pod-alice-dev", owner); len(items) != 1 || items[0].State != webShellStateDetached {
+		t.Fatalf("expected session to be kept after disconnect, got %+v", items)
+	}
+
+	// shell 退出时会话结束
+	streamErr = nil
+	connect(session.ID)
+	if _, ok := handler.sessions.Get(session.ID); ok {
+		t.Fatalf("expected session to end when the shell exits")
+	}
+}
+
+func TestDeleteWebShellSessionKillsMultiplexerSession(t *testing.T) {
+	handler, _ := newPodProxyTestRouter(t, newPodProxyTestPod("10.0.0.8", ""), "")
+	handler.sessions = NewWebShellSessionManager(5 * time.Minute)
+	owner := k8s.GetUserIdentifier("alice", "alice@example.com")
+	session := handler.sessions.Create(WebShellSessionSpec{PodID: "pod-alice-dev", UserIdentifier: owner, Multiplexer: webShellMultiplexerAuto})
+	var killed []string
+	handler.podExecFn = func(_ context.Context, _ *corev1.Pod, command []string, _ io.Reader, _ io.Writer) error {
+		killed = command
+		return nil
+	}
+	router := gin.New()
+	router.DELETE("/api/pods/:id/webshell/sessions/:sessionId", auth.AuthMiddleware(handler.config), handler.DeleteWebShellSession)
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, newPodProxyTestRequest(http.MethodDelete, "/api/pods/pod-alice-dev/webshell/sessions/"+session.ID, nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if len(killed) != 5 || killed[2] != webShellKillScript || killed[4] != "genet-"+session.ID {
+		t.Fatalf("unexpected kill command %+v", killed)
+	}
+	if _, ok := handler.sessions.Get(session.ID); ok {
+		t.Fatalf("expected session to be removed")
+	}
+}
+
 func newWebShellTestHandler() *PodHandler {
 	return &PodHandler{
 		config:   models.DefaultConfig(),
@@ -1,8 +1,10 @@
 package handlers
 
 import (
+	"context"
 	"net/http"
 	"strconv"
+	"sync"
 
 	"github.com/gin-gonic/gin"
 	"github.com/uc-package/genet/internal/models"
@@ -12,22 +14,24 @@ import (
 
 // RegistryHandler Registry API 处理器
 type RegistryHandler struct {
-	config   *models.Config
-	client   registry.Client
-	log      *zap.Logger
+	config     *models.Config
+	registries *registry.Set
+	scan       *imageScanChecker
+	log        *zap.Logger
 }
 
 // NewRegistryHandler 创建 Registry 处理器
 func NewRegistryHandler(config *models.Config, log *zap.Logger) (*RegistryHandler, error) {
-	client, err := registry.NewClient(&config.Registry)
+	registries, err := registry.NewSet(config)
 	if err != nil {
 		return nil, err
 	}
 
 	return &RegistryHandler{
-		config: config,
-		client: client,
-		log:    log,
+		config:     config,
+		registries: registries,
+		scan:       &imageScanChecker{registries: registries, config: config},
+		log:        log,
 	}, nil
 }
 
@@ -36,7 +40,7 @@ type SearchImagesResponse struct {
 	Images []registry.ImageInfo `json:"images"`
 }
 
-// SearchImages 搜索镜像
+// SearchImages 在所有用途包含 search 的仓库中搜索镜像，结果的 registry 字段为镜像所在仓库的 host
 // GET /api/registry/images?keyword=xxx&limit=20
 func (h *RegistryHandler) SearchImages(c *gin.Context) {
 	keyword := c.Query("keyword")
@@ -50,20 +54,35 @@ func (h *RegistryHandler) SearchImages(c *gin.Context) {
 		limit = 100
 	}
 
-	// 检查 registry 是否已配置
-	if !h.client.IsConfigured() {
-		c.JSON(http.StatusOK, SearchImagesResponse{Images: []registry.ImageInfo{}})
-		return
+	ctx := c.Request.Context()
+	images := []registry.ImageInfo{}
+	var lastErr error
+	failed := 0
+	entries := h.registries.ForPurpose(models.RegistryPurposeSearch)
+	for _, entry := range entries {
+		if len(images) >= limit {
+			break
+		}
+		found, err := entry.Client.SearchImages(ctx, keyword, limit-len(images))
+		if err != nil {
+			h.log.Warn("Failed to search images from registry",
+				zap.String("registry", entry.Config.DisplayName()),
+				zap.String("keyword", keyword),
+				zap.Error(err))
+			lastErr = err
+			failed++
+			continue
+		}
+		for _, image := range found {
+			image.Registry = entry.Config.Host()
+			images = append(images, image)
+		}
 	}
 
-	ctx := c.Request.Context()
-	images, err := h.client.SearchImages(ctx, keyword, limit)
-	if err != nil {
-		h.log.Warn("Failed to search images from registry",
-			zap.String("keyword", keyword),
-			zap.Error(err))
+	// 只有所有仓库都失败时才返回错误
+	if failed > 0 && failed == len(entries) {
 		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "搜索镜像失败: " + err.Error(),
+			"error": "搜索镜像失败: " + lastErr.Error(),
 		})
 		return
 	}
@@ -71,13 +90,28 @@ func (h *RegistryHandler) SearchImages(c *gin.Context) {
 	c.JSON(http.StatusOK, SearchImagesResponse{Images: images})
 }
 
+// maxTagDetails details=true 时最多查询元数据的 tag 数
+const maxTagDetails = 50
+
+// manifestFetchConcurrency 并发查询 manifest 的请求数
+const manifestFetchConcurrency = 5
+
 // GetImageTagsResponse 镜像 tags 响应
 type GetImageTagsResponse struct {
-	Tags []string `json:"tags"`
+	Tags    []string    `json:"tags"`
+	Details []TagDetail `json:"details,omitempty"` // details=true 时返回前 50 个 tag 的元数据
 }
 
-// GetImageTags 获取镜像的 tags，支持按 platform 过滤
-// GET /api/registry/tags?image=xxx&platform=amd64
+// TagDetail tag 及其 registry 元数据，查询失败时 Error 非空
+type TagDetail struct {
+	Tag      string                `json:"tag"`
+	Manifest *models.ImageManifest `json:"manifest,omitempty"`
+	Error    string                `json:"error,omitempty"`
+}
+
+// GetImageTags 获取镜像的 tags，支持按 platform 过滤；details=true 时同时返回 digest、大小等元数据。
+// registry 为仓库名称或 host，为空时使用第一个搜索仓库
+// GET /api/registry/tags?image=xxx&platform=amd64&details=true&registry=harbor.example.com
 func (h *RegistryHandler) GetImageTags(c *gin.Context) {
 	imageName := c.Query("image")
 	if imageName == "" {
@@ -87,14 +121,14 @@ func (h *RegistryHandler) GetImageTags(c *gin.Context) {
 
 	platform := c.Query("platform")
 
-	// 检查 registry 是否已配置
-	if !h.client.IsConfigured() {
+	entry, ok := h.searchRegistry(c.Query("registry"))
+	if !ok {
 		c.JSON(http.StatusOK, GetImageTagsResponse{Tags: []string{}})
 		return
 	}
 
 	ctx := c.Request.Context()
-	tags, err := h.client.GetImageTags(ctx, imageName, platform)
+	tags, err := entry.Client.GetImageTags(ctx, imageName, platform)
 	if err != nil {
 		h.log.Warn("Failed to get image tags from registry",
 			zap.String("image", imageName),
@@ -106,5 +140,78 @@ func (h *RegistryHandler) GetImageTags(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, GetImageTagsResponse{Tags: tags})
+	resp := GetImageTagsResponse{Tags: tags}
+	if c.Query("details") == "true" {
+		limited := tags
+		if len(limited) > maxTagDetails {
+			limited = limited[:maxTagDetails]
+		}
+		refs := make([]manifestRef, len(limited))
+		for i, tag := range limited {
+			refs[i] = manifestRef{Client: entry.Client, Repository: imageName, Reference: tag}
+		}
+		manifests, errs := fetchManifests(ctx, refs)
+		resp.Details = make([]TagDetail, len(limited))
+		for i, tag := range limited {
+			resp.Details[i] = TagDetail{Tag: tag, Manifest: manifests[i]}
+			if errs[i] != nil {
+				resp.Details[i].Error = errs[i].Error()
+			}
+		}
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// GetImageScan 查询镜像的漏洞扫描摘要及按管理员策略的检查结论
+// GET /api/registry/scan?image=registry.example.com/alice/train:v1
+func (h *RegistryHandler) GetImageScan(c *gin.Context) {
+	image := c.Query("image")
+	if image == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "image 参数不能为空"})
+		return
+	}
+	if err := ValidateImageName(image); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, h.scan.Report(c.Request.Context(), image))
+}
+
+// searchRegistry 按名称或 host 查找仓库，为空时返回第一个搜索仓库
+func (h *RegistryHandler) searchRegistry(nameOrHost string) (registry.Entry, bool) {
+	if nameOrHost != "" {
+		return h.registries.Lookup(nameOrHost)
+	}
+	entries := h.registries.ForPurpose(models.RegistryPurposeSearch)
+	if len(entries) == 0 {
+		return registry.Entry{}, false
+	}
+	return entries[0], true
+}
+
+// manifestRef 待查询元数据的镜像
+type manifestRef struct {
+	Client     registry.Client
+	Repository string
+	Reference  string
+}
+
+// fetchManifests 并发查询镜像元数据，结果与 refs 一一对应
+func fetchManifests(ctx context.Context, refs []manifestRef) ([]*models.ImageManifest, []error) {
+	manifests := make([]*models.ImageManifest, len(refs))
+	errs := make([]error, len(refs))
+	sem := make(chan struct{}, manifestFetchConcurrency)
+	var wg sync.WaitGroup
+	for i, ref := range refs {
+		wg.Add(1)
+		go func(i int, ref manifestRef) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			manifests[i], errs[i] = ref.Client.GetManifest(ctx, ref.Repository, ref.Reference)
+		}(i, ref)
+	}
+	wg.Wait()
+	return manifests, errs
 }
@@ -0,0 +1,139 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/uc-package/genet/internal/auth"
+	"github.com/uc-package/genet/internal/k8s"
+	"github.com/uc-package/genet/internal/logger"
+	"github.com/uc-package/genet/internal/models"
+	"github.com/uc-package/genet/internal/registry"
+	"go.uber.org/zap"
+)
+
+// RegistryCredentialHandler 用户私有镜像仓库凭据处理器
+type RegistryCredentialHandler struct {
+	k8sClient *k8s.Client
+	config    *models.Config
+	log       *zap.Logger
+	// checkCredentials 保存前访问仓库 /v2/ 校验凭据，测试中可替换
+	checkCredentials func(ctx context.Context, host, username, password string, insecure bool, trustedHosts ...string) error
+}
+
+// NewRegistryCredentialHandler 创建仓库凭据处理器
+func NewRegistryCredentialHandler(k8sClient *k8s.Client, config *models.Config) *RegistryCredentialHandler {
+	return &RegistryCredentialHandler{
+		k8sClient:        k8sClient,
+		config:           config,
+		log:              logger.Named("registry-credential-handler"),
+		checkCredentials: registry.CheckCredentials,
+	}
+}
+
+// ListRegistryCredentials 获取用户登记的仓库凭据（不含密码）
+// GET /api/registry-credentials
+func (h *RegistryCredentialHandler) ListRegistryCredentials(c *gin.Context) {
+	username, _ := auth.GetUsername(c)
+	email, _ := auth.GetEmail(c)
+	namespace := k8s.GetNamespaceForUserIdentifier(k8s.GetUserIdentifier(username, email))
+
+	credentials, err := h.k8sClient.ListUserRegistryCredentials(c.Request.Context(), namespace)
+	if err != nil {
+		h.log.Error("Failed to list registry credentials", zap.String("user", username), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取仓库凭据失败"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"credentials": credentials})
+}
+
+// SaveRegistryCredential 校验并保存仓库凭据，之后创建的 Pod、Deployment、StatefulSet 和 Open API Job 自动引用
+// POST /api/registry-credentials
+func (h *RegistryCredentialHandler) SaveRegistryCredential(c *gin.Context) {
+	username, _ := auth.GetUsername(c)
+	email, _ := auth.GetEmail(c)
+	namespace := k8s.GetNamespaceForUserIdentifier(k8s.GetUserIdentifier(username, email))
+	ctx := c.Request.Context()
+
+	var req models.SaveRegistryCredentialRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请提供仓库地址、用户名和密码"})
+		return
+	}
+	host := k8s.NormalizeRegistryHost(req.Registry)
+	if host == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的仓库地址"})
+		return
+	}
+
+	// 管理员配置的仓库可以位于内网，其它地址只允许公网
+	if err := h.checkCredentials(ctx, host, req.Username, req.Password, req.Insecure, h.trustedRegistryHosts()...); err != nil {
+		switch {
+		case errors.Is(err, registry.ErrInvalidCredentials):
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("仓库 %s 拒绝了该用户名或密码", host)})
+		case errors.Is(err, registry.ErrBlockedAddress):
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("不允许访问仓库地址 %s", host)})
+		default:
+			h.log.Warn("Failed to check registry credential",
+				zap.String("user", username), zap.String("registry", host), zap.Error(err))
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("无法校验仓库 %s 的凭据，请确认地址正确且仓库可访问", host)})
+		}
+		return
+	}
+
+	// 凭据 Secret 位于用户命名空间，首次登记时命名空间可能还不存在
+	if err := h.k8sClient.EnsureNamespace(ctx, namespace); err != nil {
+		h.log.Error("Failed to ensure namespace", zap.String("namespace", namespace), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("创建命名空间失败: %v", err)})
+		return
+	}
+
+	credential, err := h.k8sClient.SaveUserRegistryCredential(ctx, namespace, host, req.Username, req.Password)
+	if err != nil {
+		h.log.Error("Failed to save registry credential",
+			zap.String("user", username), zap.String("registry", host), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "保存仓库凭据失败"})
+		return
+	}
+
+	h.log.Info("Registry credential saved",
+		zap.String("user", username),
+		zap.String("registry", host),
+		zap.String("secret", credential.Name))
+	c.JSON(http.StatusOK, credential)
+}
+
+// trustedRegistryHosts 管理员在 registry/registries 中配置的仓库 host
+func (h *RegistryCredentialHandler) trustedRegistryHosts() []string {
+	var hosts []string
+	for _, registryConfig := range h.config.AllRegistries() {
+		hosts = append(hosts, registryConfig.Host())
+	}
+	return hosts
+}
+
+// DeleteRegistryCredential 删除仓库凭据，已创建的工作负载不受影响
+// DELETE /api/registry-credentials/:name
+func (h *RegistryCredentialHandler) DeleteRegistryCredential(c *gin.Context) {
+	username, _ := auth.GetUsername(c)
+	email, _ := auth.GetEmail(c)
+	namespace := k8s.GetNamespaceForUserIdentifier(k8s.GetUserIdentifier(username, email))
+	name := c.Param("name")
+
+	found, err := h.k8sClient.DeleteUserRegistryCredential(c.Request.Context(), namespace, name)
+	if err != nil {
+		h.log.Error("Failed to delete registry credential", zap.String("user", username), zap.String("name", name), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "删除仓库凭据失败"})
+		return
+	}
+	if !found {
+		c.JSON(http.StatusNotFound, gin.H{"error": "仓库凭据不存在"})
+		return
+	}
+
+	h.log.Info("Registry credential deleted", zap.String("user", username), zap.String("name", name))
+	c.JSON(http.StatusOK, gin.H{"message": "仓库凭据已删除"})
+}
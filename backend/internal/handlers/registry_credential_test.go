@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/uc-package/genet/internal/auth"
+	"github.com/uc-package/genet/internal/k8s"
+	"github.com/uc-package/genet/internal/models"
+	"github.com/uc-package/genet/internal/registry"
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestRegistryCredentialHandlerValidatesBeforeSaving(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	clientset := fake.NewSimpleClientset()
+	cfg := models.DefaultConfig()
+	cfg.OAuth.Enabled = true
+	auth.InitAuthMiddleware(cfg)
+
+	var checkedHost string
+	handler := &RegistryCredentialHandler{
+		k8sClient: k8s.NewClientForTest(clientset, cfg),
+		config:    cfg,
+		log:       zap.NewNop(),
+		checkCredentials: func(ctx context.Context, host, username, password string, insecure bool, trustedHosts ...string) error {
+			checkedHost = host
+			if password != "ghp_token" {
+				return registry.ErrInvalidCredentials
+			}
+			return nil
+		},
+	}
+	router := gin.New()
+	group := router.Group("/api/registry-credentials", auth.AuthMiddleware(cfg))
+	group.GET("", handler.ListRegistryCredentials)
+	group.POST("", handler.SaveRegistryCredential)
+	group.DELETE("/:name", handler.DeleteRegistryCredential)
+
+	do := func(method, target, body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, newPodProxyTestRequest(method, target, strings.NewReader(body)))
+		return rec
+	}
+
+	rec := do(http.MethodPost, "/api/registry-credentials", `{"registry":"https://ghcr.io/","username":"alice","password":"wrong"}`)
+	if rec.Code != http.StatusBadRequest || checkedHost != "ghcr.io" {
+		t.Fatalf("expected 400 for rejected credential, got %d (host %q): %s", rec.Code, checkedHost, rec.Body.String())
+	}
+	if _, err := clientset.CoreV1().Secrets("user-alice-alice").Get(context.Background(), "registry-cred-ghcr-io", metav1.GetOptions{}); err == nil {
+		t.Fatal("rejected credential must not be saved")
+	}
+
+	rec = do(http.MethodPost, "/api/registry-credentials", `{"registry":"https://ghcr.io/","username":"alice","password":"ghp_token"}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	secret, err := clientset.CoreV1().Secrets("user-alice-alice").Get(context.Background(), "registry-cred-ghcr-io", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("get credential secret: %v", err)
+	}
+	if secret.Type != corev1.SecretTypeDockerConfigJson {
+		t.Fatalf("unexpected secret type: %s", secret.Type)
+	}
+
+	rec = do(http.MethodGet, "/api/registry-credentials", "")
+	if rec.Code != http.StatusOK || strings.Contains(rec.Body.String(), "ghp_token") {
+		t.Fatalf("unexpected list response %d: %s", rec.Code, rec.Body.String())
+	}
+	var list struct {
+		Credentials []models.RegistryCredential `json:"credentials"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &list); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(list.Credentials) != 1 || list.Credentials[0].Registry != "ghcr.io" || list.Credentials[0].Username != "alice" {
+		t.Fatalf("unexpected credentials: %+v", list.Credentials)
+	}
+
+	if rec := do(http.MethodDelete, "/api/registry-credentials/registry-cred-ghcr-io", ""); rec.Code != http.StatusOK {
+		t.Fatalf("expected 200 on delete, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if rec := do(http.MethodDelete, "/api/registry-credentials/registry-cred-ghcr-io", ""); rec.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 on second delete, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,151 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/uc-package/genet/internal/auth"
+	"github.com/uc-package/genet/internal/k8s"
+	"github.com/uc-package/genet/internal/logger"
+	"github.com/uc-package/genet/internal/models"
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// SSHKeyHandler 用户 SSH 公钥处理器
+type SSHKeyHandler struct {
+	k8sClient *k8s.Client
+	config    *models.Config
+	log       *zap.Logger
+}
+
+// NewSSHKeyHandler 创建 SSH 公钥处理器
+func NewSSHKeyHandler(k8sClient *k8s.Client, config *models.Config) *SSHKeyHandler {
+	return &SSHKeyHandler{
+		k8sClient: k8sClient,
+		config:    config,
+		log:       logger.Named("ssh-key-handler"),
+	}
+}
+
+// ListSSHKeys 获取用户登记的 SSH 公钥
+func (h *SSHKeyHandler) ListSSHKeys(c *gin.Context) {
+	username, _ := auth.GetUsername(c)
+	email, _ := auth.GetEmail(c)
+	userIdentifier := k8s.GetUserIdentifier(username, email)
+	namespace := k8s.GetNamespaceForUserIdentifier(userIdentifier)
+
+	keyList, err := h.k8sClient.GetUserSSHKeys(c.Request.Context(), namespace)
+	if err != nil {
+		h.log.Error("Failed to get ssh keys", zap.String("user", username), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取 SSH 公钥失败"})
+		return
+	}
+	c.JSON(http.StatusOK, keyList)
+}
+
+// AddSSHKey 登记 SSH 公钥
+func (h *SSHKeyHandler) AddSSHKey(c *gin.Context) {
+	username, _ := auth.GetUsername(c)
+	email, _ := auth.GetEmail(c)
+	userIdentifier := k8s.GetUserIdentifier(username, email)
+	namespace := k8s.GetNamespaceForUserIdentifier(userIdentifier)
+	ctx := c.Request.Context()
+
+	var req models.AddSSHKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请提供 SSH 公钥"})
+		return
+	}
+	if _, err := k8s.ParseSSHPublicKey(req.PublicKey); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// 公钥 ConfigMap 位于用户命名空间，首次登记时命名空间可能还不存在
+	if err := h.k8sClient.EnsureNamespace(ctx, namespace); err != nil {
+		h.log.Error("Failed to ensure namespace", zap.String("namespace", namespace), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("创建命名空间失败: %v", err)})
+		return
+	}
+
+	key, err := h.k8sClient.AddUserSSHKey(ctx, namespace, req.Name, req.PublicKey)
+	if err != nil {
+		h.log.Error("Failed to add ssh key", zap.String("user", username), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "保存 SSH 公钥失败"})
+		return
+	}
+
+	h.log.Info("SSH key added",
+		zap.String("user", username),
+		zap.String("fingerprint", key.Fingerprint))
+	c.JSON(http.StatusOK, key)
+}
+
+// DeleteSSHKey 删除 SSH 公钥
+func (h *SSHKeyHandler) DeleteSSHKey(c *gin.Context) {
+	username, _ := auth.GetUsername(c)
+	email, _ := auth.GetEmail(c)
+	userIdentifier := k8s.GetUserIdentifier(username, email)
+	namespace := k8s.GetNamespaceForUserIdentifier(userIdentifier)
+	keyID := c.Param("id")
+
+	found, err := h.k8sClient.DeleteUserSSHKey(c.Request.Context(), namespace, keyID)
+	if err != nil {
+		h.log.Error("Failed to delete ssh key", zap.String("user", username), zap.String("id", keyID), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "删除 SSH 公钥失败"})
+		return
+	}
+	if !found {
+		c.JSON(http.StatusNotFound, gin.H{"error": "SSH 公钥不存在"})
+		return
+	}
+
+	h.log.Info("SSH key deleted", zap.String("user", username), zap.String("id", keyID))
+	c.JSON(http.StatusOK, gin.H{"message": "SSH 公钥已删除"})
+}
+
+// applyPodSSHConnections 根据 Pod 的 sshd 端口填充 SSH 连接信息
+func (h *PodHandler) applyPodSSHConnections(connections *models.PodConnections, pod *corev1.Pod) {
+	sshCfg := h.config.Pod.SSH
+	port := k8s.GetPodSSHPort(pod)
+	if !sshCfg.Enabled || port == 0 {
+		return
+	}
+
+	host := strings.TrimSpace(sshCfg.Host)
+	if host == "" {
+		if pod.Spec.HostNetwork {
+			host = pod.Status.HostIP
+		} else {
+			host = pod.Status.PodIP
+		}
+	}
+	if host == "" {
+		return
+	}
+	user := sshCfg.User
+	if user == "" {
+		user = "root"
+	}
+
+	connections.SSH.Host = host
+	connections.SSH.Port = strconv.Itoa(port)
+	connections.SSH.User = user
+	connections.Apps.SSHCommand = fmt.Sprintf("ssh -p %d %s@%s", port, user, host)
+	if !pod.Spec.HostNetwork && strings.TrimSpace(sshCfg.Host) == "" {
+		// Pod IP 在集群外不可达，改为经 API Server 的 WebSocket 隧道连接
+		connections.Apps.SSHCommand = fmt.Sprintf("genet ssh %s", pod.Name)
+	}
+	connections.Apps.VSCodeURI = fmt.Sprintf("vscode://vscode-remote/ssh-remote+%s@%s:%d%s",
+		user, host, port, models.DefaultWorkspaceDir)
+	connections.Apps.XshellURI = (&url.URL{
+		Scheme: "ssh",
+		User:   url.User(user),
+		Host:   fmt.Sprintf("%s:%d", host, port),
+	}).String()
+}
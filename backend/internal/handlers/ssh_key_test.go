@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/uc-package/genet/internal/k8s"
+	"github.com/uc-package/genet/internal/models"
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestBuildPodConnectionsPopulatesSSHForHostNetworkPod(t *testing.T) {
+	cfg := models.DefaultConfig()
+	cfg.Pod.SSH.Enabled = true
+	handler := &PodHandler{config: cfg, log: zap.NewNop()}
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "pod-alice-dev",
+			Namespace:   "user-alice",
+			Annotations: map[string]string{k8s.PodSSHPortAnnotation: "30023"},
+		},
+		Spec:   corev1.PodSpec{HostNetwork: true},
+		Status: corev1.PodStatus{Phase: corev1.PodRunning, HostIP: "192.168.1.10", PodIP: "192.168.1.10"},
+	}
+
+	connections := handler.buildPodConnections(context.Background(), pod)
+
+	if connections.SSH.Host != "192.168.1.10" || connections.SSH.Port != "30023" || connections.SSH.User != "root" {
+		t.Fatalf("unexpected ssh connection: %+v", connections.SSH)
+	}
+	if connections.Apps.SSHCommand != "ssh -p 30023 root@192.168.1.10" {
+		t.Fatalf("unexpected ssh command: %q", connections.Apps.SSHCommand)
+	}
+	if connections.Apps.VSCodeURI != "vscode://vscode-remote/ssh-remote+root@192.168.1.10:30023"+models.DefaultWorkspaceDir {
+		t.Fatalf("unexpected vscode uri: %q", connections.Apps.VSCodeURI)
+	}
+	if connections.Apps.XshellURI != "ssh://root@192.168.1.10:30023" {
+		t.Fatalf("unexpected xshell uri: %q", connections.Apps.XshellURI)
+	}
+}
+
+func TestBuildPodConnectionsSkipsSSHWithoutPort(t *testing.T) {
+	cfg := models.DefaultConfig()
+	cfg.Pod.SSH.Enabled = true
+	handler := &PodHandler{config: cfg, log: zap.NewNop()}
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "pod-alice-dev", Namespace: "user-alice"},
+		Status:     corev1.PodStatus{Phase: corev1.PodRunning, PodIP: "10.0.0.8"},
+	}
+
+	connections := handler.buildPodConnections(context.Background(), pod)
+
+	if connections.SSH.Host != "" || connections.Apps.SSHCommand != "" {
+		t.Fatalf("expected empty ssh connection, got %+v", connections.SSH)
+	}
+}
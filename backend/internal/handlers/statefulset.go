@@ -13,6 +13,7 @@ import (
 	"github.com/uc-package/genet/internal/auth"
 	"github.com/uc-package/genet/internal/k8s"
 	"github.com/uc-package/genet/internal/logger"
+	"github.com/uc-package/genet/internal/metrics"
 	"github.com/uc-package/genet/internal/models"
 	"go.uber.org/zap"
 	appsv1 "k8s.io/api/apps/v1"
@@ -46,6 +47,10 @@ func (h *StatefulSetHandler) CreateStatefulSet(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	warnings, ok := h.podHandler.imageScan.checkWorkloadImage(c, req.Image)
+	if !ok {
+		return
+	}
 	if err := ValidateCPU(req.CPU); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
@@ -97,6 +102,7 @@ func (h *StatefulSetHandler) CreateStatefulSet(c *gin.Context) {
 	}
 
 	if err := h.checkQuota(ctx, namespace, req.Replicas, req.GPUCount); err != nil {
+		metrics.RecordQuotaRejection("statefulset")
 		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
 		return
 	}
@@ -159,11 +165,15 @@ func (h *StatefulSetHandler) CreateStatefulSet(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusCreated, gin.H{
+	resp := gin.H{
 		"message": "StatefulSet 创建成功",
 		"id":      workloadName,
 		"name":    workloadName,
-	})
+	}
+	if len(warnings) > 0 {
+		resp["warnings"] = warnings
+	}
+	c.JSON(http.StatusCreated, resp)
 }
 
 func (h *StatefulSetHandler) ListStatefulSets(c *gin.Context) {
@@ -219,6 +229,10 @@ func (h *StatefulSetHandler) DeleteStatefulSet(c *gin.Context) {
 		c.JSON(http.StatusConflict, gin.H{"error": "外部 StatefulSet 为只读展示，不能在 Genet 中删除"})
 		return
 	}
+	// 副本随工作负载一起删除，先归档日志（失败不影响删除）
+	if pods, err := h.k8sClient.ListStatefulSetPods(ctx, namespace, name); err == nil {
+		h.podHandler.archiveWorkloadPodLogs(ctx, pods, "workload-delete")
+	}
 	if err := h.k8sClient.DeleteStatefulSet(ctx, namespace, name); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -1,17 +1,25 @@
 package handlers
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"regexp"
+	"slices"
+	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/gorilla/websocket"
 	"github.com/uc-package/genet/internal/auth"
 	"github.com/uc-package/genet/internal/k8s"
+	"go.uber.org/zap"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/client-go/kubernetes/scheme"
 	"k8s.io/client-go/tools/remotecommand"
@@ -23,11 +31,59 @@ const (
 	webShellFallbackShell = "/bin/sh"
 	webShellDisplayShell  = "/bin/bash (fallback /bin/sh)"
 	webShellExecScript    = `if [ -x /bin/bash ]; then exec /bin/bash; elif [ -x /bin/sh ]; then exec /bin/sh; else echo "No shell found" >&2; exit 127; fi`
+
+	// webShellSelectedShellScript 用户指定 Shell（$3）时先检查是否存在
+	webShellSelectedShellScript = `if [ -n "$3" ] && [ ! -x "$3" ]; then echo "Shell $3 not found" >&2; exit 127; fi; `
+	// webShellMultiplexerScript 在 tmux/screen 中创建或重新连接同名会话；二者都不存在时退回普通 shell
+	webShellMultiplexerScript = `export TERM="${TERM:-xterm-256color}"; ` +
+		`if [ "$2" = auto ] || [ "$2" = tmux ]; then command -v tmux >/dev/null 2>&1 && exec tmux new-session -A -s "$1" ${3:+"$3"}; fi; ` +
+		`if [ "$2" = auto ] || [ "$2" = screen ]; then command -v screen >/dev/null 2>&1 && exec screen -D -RR -S "$1" ${3:+"$3"}; fi; `
+	webShellDirectShellScript = `if [ -n "$3" ]; then exec "$3"; fi; `
+	webShellKillScript        = `tmux kill-session -t "$1" 2>/dev/null; screen -S "$1" -X quit >/dev/null 2>&1; true`
+	webShellListScript        = `tmux ls -F '#{session_name}' 2>/dev/null; screen -ls 2>/dev/null; true`
+
+	webShellMultiplexerAuto   = "auto"
+	webShellMultiplexerNone   = "none"
+	webShellMultiplexerPrefix = "genet-"
+	webShellDiscoverTimeout   = 5 * time.Second
+)
+
+var (
+	// errWebShellDetached 浏览器断开连接，容器内会话仍保留
+	errWebShellDetached = errors.New("web shell client detached")
+
+	webShellMultiplexerSessionPattern = regexp.MustCompile(webShellMultiplexerPrefix + `([0-9a-f]{16,64})\b`)
 )
 
+// webShellAllowedShells 可选的 Shell；留空时按 /bin/bash、/bin/sh 顺序回退
+var webShellAllowedShells = []string{"/bin/bash", "/bin/sh", "/bin/zsh", "/usr/bin/zsh", "/bin/ash", "/usr/bin/fish"}
+
 type createWebShellSessionRequest struct {
-	Cols int `json:"cols"`
-	Rows int `json:"rows"`
+	Cols      int    `json:"cols"`
+	Rows      int    `json:"rows"`
+	SessionID string `json:"sessionId"` // 重新连接已有会话
+	Container string `json:"container"` // 为空时使用主容器
+	Shell     string `json:"shell"`     // 为空时自动选择
+	// WorkloadKind/WorkloadName 从 Deployment/StatefulSet 详情进入时传入，用于校验副本归属
+	WorkloadKind string `json:"workloadKind"`
+	WorkloadName string `json:"workloadName"`
+}
+
+// WebShellContainerOption 可连接的容器
+type WebShellContainerOption struct {
+	Name    string `json:"name"`
+	Image   string `json:"image"`
+	Running bool   `json:"running"`
+	Main    bool   `json:"main"`
+}
+
+// WebShellOptionsResponse 创建会话前可选的容器与 Shell
+type WebShellOptionsResponse struct {
+	Containers       []WebShellContainerOption `json:"containers"`
+	DefaultContainer string                    `json:"defaultContainer"`
+	Shells           []string                  `json:"shells"`
+	WorkloadKind     string                    `json:"workloadKind"`
+	WorkloadName     string                    `json:"workloadName,omitempty"`
 }
 
 type webShellControlMessage struct {
@@ -37,8 +93,10 @@ type webShellControlMessage struct {
 }
 
 type webShellOutputWriter struct {
-	conn *websocket.Conn
-	mu   sync.Mutex
+	conn     *websocket.Conn
+	recorder *webShellRecorder
+	hub      *webShellHub
+	mu       sync.Mutex
 }
 
 func (w *webShellOutputWriter) Write(p []byte) (int, error) {
@@ -48,11 +106,14 @@ func (w *webShellOutputWriter) Write(p []byte) (int, error) {
 	if err := w.conn.WriteMessage(websocket.BinaryMessage, p); err != nil {
 		return 0, err
 	}
+	w.recorder.Output(p)
+	w.hub.broadcast(p)
 	return len(p), nil
 }
 
 type webShellSizeQueue struct {
-	ch chan *remotecommand.TerminalSize
+	ch       chan *remotecommand.TerminalSize
+	recorder *webShellRecorder
 }
 
 func newWebShellSizeQueue(cols, rows int) *webShellSizeQueue {
@@ -62,6 +123,7 @@ func newWebShellSizeQueue(cols, rows int) *webShellSizeQueue {
 }
 
 func (q *webShellSizeQueue) Push(cols, rows uint16) {
+	q.recorder.Resize(cols, rows)
 	size := &remotecommand.TerminalSize{Width: cols, Height: rows}
 	select {
 	case q.ch <- size:
@@ -109,20 +171,43 @@ func (h *PodHandler) CreateWebShellSession(c *gin.Context) {
 		return
 	}
 
-	container := h.getPodDisplayInfo(pod).ContainerName
-	if container == "" && len(pod.Spec.Containers) > 0 {
-		container = pod.Spec.Containers[0].Name
+	container, status, err := h.validateWebShellTarget(pod, req.WorkloadKind, req.WorkloadName, req.Container, req.Shell)
+	if err != nil {
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
 	}
 
-	session := h.sessions.Create(WebShellSessionSpec{
-		PodID:          podID,
-		Namespace:      namespace,
-		UserIdentifier: userIdentifier,
-		Container:      container,
-		Shell:          webShellDisplayShell,
-		Cols:           req.Cols,
-		Rows:           req.Rows,
-	})
+	var session WebShellSession
+	reattached := false
+	if req.SessionID != "" {
+		if !isValidWebShellSessionID(req.SessionID) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "无效的会话 ID"})
+			return
+		}
+		existing, exists, owned := h.sessions.Reattach(req.SessionID, podID, userIdentifier)
+		if exists && !owned {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Web Shell 会话不存在"})
+			return
+		}
+		session, reattached = existing, exists
+	}
+	if !reattached {
+		// 未知 ID（如后端重启后）沿用该 ID，容器内同名 tmux/screen 会话仍可重新连接
+		multiplexer := h.webShellMultiplexer()
+		session = h.sessions.Create(WebShellSessionSpec{
+			ID:             req.SessionID,
+			PodID:          podID,
+			Namespace:      namespace,
+			UserIdentifier: userIdentifier,
+			Container:      container,
+			Shell:          webShellSessionShell(multiplexer, req.Shell),
+			SelectedShell:  req.Shell,
+			Multiplexer:    multiplexer,
+			Cols:           req.Cols,
+			Rows:           req.Rows,
+		})
+		reattached = req.SessionID != "" && multiplexer != webShellMultiplexerNone
+	}
 
 	c.JSON(http.StatusCreated, WebShellSessionResponse{
 		SessionID:    session.ID,
@@ -132,9 +217,218 @@ func (h *PodHandler) CreateWebShellSession(c *gin.Context) {
 		Cols:         session.Cols,
 		Rows:         session.Rows,
 		ExpiresAt:    session.ExpiresAt,
+		Recording:    h.recordings != nil,
+		Persistent:   session.Multiplexer != "" && session.Multiplexer != webShellMultiplexerNone,
+		Reattached:   reattached,
 	})
 }
 
+// ListWebShellSessions 列出当前用户在该 Pod 上可重新连接的会话，
+// 并合并容器内由 genet 创建、但后端已不记得的 tmux/screen 会话（如后端重启后）
+func (h *PodHandler) ListWebShellSessions(c *gin.Context) {
+	username, _ := auth.GetUsername(c)
+	email, _ := auth.GetEmail(c)
+	userIdentifier := k8s.GetUserIdentifier(username, email)
+	namespace := k8s.GetNamespaceForUserIdentifier(userIdentifier)
+	podID := c.Param("id")
+
+	pod, err := h.getPod(c.Request.Context(), namespace, podID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Pod 不存在"})
+		return
+	}
+
+	sessions := h.sessions.List(podID, userIdentifier)
+	multiplexer := h.webShellMultiplexer()
+	if multiplexer != webShellMultiplexerNone && pod.Status.Phase == corev1.PodRunning {
+		known := make(map[string]bool, len(sessions))
+		for _, session := range sessions {
+			known[session.ID] = true
+		}
+		for _, id := range h.discoverWebShellSessions(c.Request.Context(), pod) {
+			if known[id] {
+				continue
+			}
+			known[id] = true
+			sessions = append(sessions, WebShellSessionInfo{
+				WebShellSession: WebShellSession{
+					ID:             id,
+					PodID:          podID,
+					Namespace:      namespace,
+					UserIdentifier: userIdentifier,
+					Container:      h.podExecContainer(pod),
+					Shell:          webShellSessionShell(multiplexer, ""),
+					Multiplexer:    multiplexer,
+				},
+				State: webShellStateDetached,
+			})
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"sessions": sessions, "persistent": multiplexer != webShellMultiplexerNone})
+}
+
+// discoverWebShellSessions 在容器内查找 genet-<id> 命名的 tmux/screen 会话，失败时返回空
+func (h *PodHandler) discoverWebShellSessions(ctx context.Context, pod *corev1.Pod) []string {
+	ctx, cancel := context.WithTimeout(ctx, webShellDiscoverTimeout)
+	defer cancel()
+
+	var stdout bytes.Buffer
+	command := []string{webShellExecShell, "-c", webShellListScript}
+	if err := h.podExec(ctx, pod, command, nil, &stdout); err != nil {
+		h.log.Debug("Failed to discover web shell sessions", zap.String("pod", pod.Name), zap.Error(err))
+		return nil
+	}
+	var ids []string
+	seen := make(map[string]bool)
+	for _, match := range webShellMultiplexerSessionPattern.FindAllStringSubmatch(stdout.String(), -1) {
+		if !seen[match[1]] {
+			seen[match[1]] = true
+			ids = append(ids, match[1])
+		}
+	}
+	return ids
+}
+
+// killWebShellSession 结束容器内的 tmux/screen 会话
+func (h *PodHandler) killWebShellSession(ctx context.Context, pod *corev1.Pod, sessionID string) error {
+	ctx, cancel := context.WithTimeout(ctx, webShellDiscoverTimeout)
+	defer cancel()
+
+	command := []string{webShellExecShell, "-c", webShellKillScript, "sh", webShellMultiplexerPrefix + sessionID}
+	return h.podExec(ctx, pod, command, nil, io.Discard)
+}
+
+func (h *PodHandler) webShellMultiplexer() string {
+	switch multiplexer := h.config.Pod.WebShell.Multiplexer; multiplexer {
+	case "tmux", "screen", webShellMultiplexerNone:
+		return multiplexer
+	default:
+		return webShellMultiplexerAuto
+	}
+}
+
+func webShellSessionShell(multiplexer, shell string) string {
+	if shell == "" {
+		shell = webShellDisplayShell
+	}
+	switch multiplexer {
+	case webShellMultiplexerNone:
+		return shell
+	case webShellMultiplexerAuto:
+		return "tmux/screen, " + shell
+	default:
+		return multiplexer + ", " + shell
+	}
+}
+
+// GetWebShellOptions 返回 Pod 内可连接的容器（含 sidecar）与可选 Shell
+func (h *PodHandler) GetWebShellOptions(c *gin.Context) {
+	username, _ := auth.GetUsername(c)
+	email, _ := auth.GetEmail(c)
+	namespace := k8s.GetNamespaceForUserIdentifier(k8s.GetUserIdentifier(username, email))
+
+	pod, err := h.getPod(c.Request.Context(), namespace, c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Pod 不存在"})
+		return
+	}
+	workloadKind, workloadName := c.Query("workloadKind"), c.Query("workloadName")
+	if workloadKind != "" && !podBelongsToWorkload(pod, workloadKind, workloadName) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Pod 不属于该工作负载"})
+		return
+	}
+
+	defaultContainer := h.podExecContainer(pod)
+	containers := make([]WebShellContainerOption, 0, len(pod.Spec.Containers))
+	for _, container := range pod.Spec.Containers {
+		containers = append(containers, WebShellContainerOption{
+			Name:    container.Name,
+			Image:   container.Image,
+			Running: podContainerRunning(pod, container.Name),
+			Main:    container.Name == defaultContainer,
+		})
+	}
+	c.JSON(http.StatusOK, WebShellOptionsResponse{
+		Containers:       containers,
+		DefaultContainer: defaultContainer,
+		Shells:           webShellAllowedShells,
+		WorkloadKind:     podWorkloadKind(pod),
+		WorkloadName:     pod.Labels["genet.io/workload-name"],
+	})
+}
+
+// validateWebShellTarget 校验副本归属、容器和 Shell，返回实际连接的容器
+func (h *PodHandler) validateWebShellTarget(pod *corev1.Pod, workloadKind, workloadName, container, shell string) (string, int, error) {
+	if workloadKind != "" && !podBelongsToWorkload(pod, workloadKind, workloadName) {
+		return "", http.StatusForbidden, fmt.Errorf("Pod 不属于该工作负载")
+	}
+	if shell != "" && !slices.Contains(webShellAllowedShells, shell) {
+		return "", http.StatusBadRequest, fmt.Errorf("不支持的 Shell: %s", shell)
+	}
+	if container == "" {
+		return h.podExecContainer(pod), http.StatusOK, nil
+	}
+	found := false
+	for _, candidate := range pod.Spec.Containers {
+		if candidate.Name == container {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return "", http.StatusBadRequest, fmt.Errorf("容器 %s 不存在", container)
+	}
+	if !podContainerRunning(pod, container) {
+		return "", http.StatusConflict, fmt.Errorf("容器 %s 未运行", container)
+	}
+	return container, http.StatusOK, nil
+}
+
+// podBelongsToWorkload 校验 Pod 是否为指定 Deployment/StatefulSet 的副本
+func podBelongsToWorkload(pod *corev1.Pod, workloadKind, workloadName string) bool {
+	if workloadName == "" {
+		return false
+	}
+	kind := strings.TrimSuffix(strings.ToLower(workloadKind), "-pod")
+	switch kind {
+	case "statefulset":
+		if podWorkloadKind(pod) != "statefulset-pod" {
+			return false
+		}
+	case "deployment":
+		if podWorkloadKind(pod) != "deployment-pod" {
+			return false
+		}
+	default:
+		return false
+	}
+	if name := pod.Labels["genet.io/workload-name"]; name != "" {
+		return name == workloadName
+	}
+	for _, owner := range pod.OwnerReferences {
+		switch {
+		case owner.Kind == "StatefulSet" && kind == "statefulset":
+			return owner.Name == workloadName
+		case owner.Kind == "ReplicaSet" && kind == "deployment":
+			return owner.Name == workloadName+"-"+pod.Labels["pod-template-hash"]
+		case owner.Kind == "Deployment" && kind == "deployment":
+			return owner.Name == workloadName
+		}
+	}
+	return false
+}
+
+// podContainerRunning 容器状态未上报时视为可用，由 exec 返回实际错误
+func podContainerRunning(pod *corev1.Pod, container string) bool {
+	for _, status := range pod.Status.ContainerStatuses {
+		if status.Name == container {
+			return status.State.Running != nil
+		}
+	}
+	return true
+}
+
 func (h *PodHandler) DeleteWebShellSession(c *gin.Context) {
 	username, _ := auth.GetUsername(c)
 	email, _ := auth.GetEmail(c)
@@ -143,12 +437,34 @@ func (h *PodHandler) DeleteWebShellSession(c *gin.Context) {
 	sessionID := c.Param("sessionId")
 
 	session, ok := h.sessions.Get(sessionID)
-	if !ok || session.PodID != podID || session.UserIdentifier != userIdentifier {
+	if ok && (session.PodID != podID || session.UserIdentifier != userIdentifier) {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Web Shell 会话不存在"})
 		return
 	}
+	if !ok {
+		// 后端不记得的会话仍可能在容器内运行（见 ListWebShellSessions）
+		if !isValidWebShellSessionID(sessionID) || h.webShellMultiplexer() == webShellMultiplexerNone {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Web Shell 会话不存在"})
+			return
+		}
+		session = WebShellSession{ID: sessionID, Multiplexer: h.webShellMultiplexer()}
+	}
 
-	h.sessions.Delete(sessionID)
+	h.sessions.End(sessionID)
+	if session.Multiplexer != "" && session.Multiplexer != webShellMultiplexerNone {
+		namespace := k8s.GetNamespaceForUserIdentifier(userIdentifier)
+		pod, err := h.getPod(c.Request.Context(), namespace, podID)
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Pod 不存在"})
+			return
+		}
+		if err := h.killWebShellSession(c.Request.Context(), pod, sessionID); err != nil {
+			h.log.Warn("Failed to kill web shell session",
+				zap.String("pod", podID),
+				zap.String("session", sessionID),
+				zap.Error(err))
+		}
+	}
 	c.JSON(http.StatusOK, gin.H{"message": "Web Shell 会话已关闭"})
 }
 
@@ -170,13 +486,20 @@ func (h *PodHandler) WebShellWebSocket(c *gin.Context) {
 		return
 	}
 	defer conn.Close()
-	defer h.sessions.Delete(sessionID)
 
 	if h.webShellStreamFn == nil {
 		h.webShellStreamFn = h.streamWebShell
 	}
-	if err := h.webShellStreamFn(c.Request.Context(), session, conn); err != nil {
+	err = h.webShellStreamFn(c.Request.Context(), session, conn)
+	switch {
+	case errors.Is(err, errWebShellDetached) && session.Multiplexer != "" && session.Multiplexer != webShellMultiplexerNone:
+		// 刷新页面或网络中断：容器内 tmux/screen 会话仍在运行，保留以便重新连接
+		h.sessions.Detach(sessionID)
+	case err != nil && !errors.Is(err, errWebShellDetached):
 		_ = conn.WriteJSON(gin.H{"type": "error", "message": err.Error()})
+		h.sessions.End(sessionID)
+	default:
+		h.sessions.End(sessionID)
 	}
 }
 
@@ -188,12 +511,20 @@ func (h *PodHandler) streamWebShell(ctx context.Context, session WebShellSession
 
 	stdinReader, stdinWriter := io.Pipe()
 	defer stdinReader.Close()
+	recorder := h.startWebShellRecording(session)
+	defer recorder.Close()
 	sizeQueue := newWebShellSizeQueue(session.Cols, session.Rows)
+	sizeQueue.recorder = recorder
 	defer sizeQueue.Close()
-	outputWriter := &webShellOutputWriter{conn: conn}
+	outputWriter := &webShellOutputWriter{conn: conn, recorder: recorder}
+	outputWriter.hub = newWebShellHub(session, outputWriter, stdinWriter)
+	h.sessions.Activate(outputWriter.hub)
+	defer h.sessions.Deactivate(outputWriter.hub)
 
+	var detached atomic.Bool
 	go func() {
 		<-ctx.Done()
+		detached.Store(true)
 		_ = conn.Close()
 		_ = stdinWriter.Close()
 	}()
@@ -203,6 +534,7 @@ func (h *PodHandler) streamWebShell(ctx context.Context, session WebShellSession
 		for {
 			msgType, payload, err := conn.ReadMessage()
 			if err != nil {
+				detached.Store(true)
 				return
 			}
 			switch msgType {
@@ -211,8 +543,9 @@ func (h *PodHandler) streamWebShell(ctx context.Context, session WebShellSession
 					return
 				}
 			case websocket.TextMessage:
-				var control webShellControlMessage
-				if err := json.Unmarshal(payload, &control); err == nil && control.Type == "resize" {
+				if isWebShellControlMessage(payload) {
+					var control webShellControlMessage
+					_ = json.Unmarshal(payload, &control)
 					sizeQueue.Push(control.Cols, control.Rows)
 					continue
 				}
@@ -232,7 +565,7 @@ func (h *PodHandler) streamWebShell(ctx context.Context, session WebShellSession
 		SubResource("exec").
 		VersionedParams(&corev1.PodExecOptions{
 			Container: session.Container,
-			Command:   buildWebShellCommand(),
+			Command:   buildWebShellCommand(session.ID, session.Multiplexer, session.SelectedShell),
 			Stdin:     true,
 			Stdout:    true,
 			Stderr:    true,
@@ -244,15 +577,37 @@ func (h *PodHandler) streamWebShell(ctx context.Context, session WebShellSession
 		return err
 	}
 
-	return executor.Stream(remotecommand.StreamOptions{
+	err = executor.Stream(remotecommand.StreamOptions{
 		Stdin:             stdinReader,
 		Stdout:            outputWriter,
 		Stderr:            outputWriter,
 		Tty:               true,
 		TerminalSizeQueue: sizeQueue,
 	})
+	if detached.Load() {
+		return errWebShellDetached
+	}
+	return err
+}
+
+func isWebShellControlMessage(payload []byte) bool {
+	var control webShellControlMessage
+	return json.Unmarshal(payload, &control) == nil && control.Type == "resize"
 }
 
-func buildWebShellCommand() []string {
-	return []string{webShellExecShell, "-lc", webShellExecScript}
+// buildWebShellCommand 启用 tmux/screen 时以 genet-<sessionId> 命名会话，重新连接时附加到同一会话；
+// shell 为空时按 /bin/bash、/bin/sh 回退
+func buildWebShellCommand(sessionID, multiplexer, shell string) []string {
+	persistent := multiplexer != "" && multiplexer != webShellMultiplexerNone
+	if !persistent && shell == "" {
+		return []string{webShellExecShell, "-lc", webShellExecScript}
+	}
+	if !persistent {
+		multiplexer = webShellMultiplexerNone
+	}
+	return []string{
+		webShellExecShell, "-lc",
+		webShellSelectedShellScript + webShellMultiplexerScript + webShellDirectShellScript + webShellExecScript,
+		"sh", webShellMultiplexerPrefix + sessionID, multiplexer, shell,
+	}
 }
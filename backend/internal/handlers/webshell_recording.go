@@ -0,0 +1,454 @@
+package handlers
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	"github.com/uc-package/genet/internal/auth"
+	"github.com/uc-package/genet/internal/k8s"
+	"github.com/uc-package/genet/internal/models"
+	"go.uber.org/zap"
+)
+
+const (
+	webShellRecordingExt     = ".cast"
+	webShellRecordingMetaExt = ".json"
+	defaultRecordingDays     = 30
+	defaultRecordingMaxMB    = 64
+)
+
+var webShellRecordingIDPattern = regexp.MustCompile(`^[a-f0-9]{32}$`)
+
+// WebShellRecording 录制元数据（与 .cast 文件同名的 .json）
+type WebShellRecording struct {
+	ID          string    `json:"id"`
+	User        string    `json:"user"`
+	PodID       string    `json:"podId"`
+	Container   string    `json:"container"`
+	Shell       string    `json:"shell"`
+	Cols        int       `json:"cols"`
+	Rows        int       `json:"rows"`
+	StartedAt   time.Time `json:"startedAt"`
+	EndedAt     time.Time `json:"endedAt,omitempty"`
+	DurationSec float64   `json:"durationSeconds"`
+	SizeBytes   int64     `json:"sizeBytes"`
+	Truncated   bool      `json:"truncated"`
+}
+
+// WebShellRecordingStore 按用户目录存放录制文件，并按保留天数清理
+// 录制作为审计记录，只能由保留期清理删除；多副本部署时 dir 必须是各副本共享的卷
+type WebShellRecordingStore struct {
+	dir       string
+	retention time.Duration
+	maxBytes  int64
+	log       *zap.Logger
+}
+
+// NewWebShellRecordingStore 未启用录制时返回 nil
+func NewWebShellRecordingStore(cfg models.WebShellRecordingConfig, log *zap.Logger) *WebShellRecordingStore {
+	if !cfg.Enabled || strings.TrimSpace(cfg.Dir) == "" {
+		return nil
+	}
+	days := cfg.RetentionDays
+	if days <= 0 {
+		days = defaultRecordingDays
+	}
+	maxMB := cfg.MaxSizeMB
+	if maxMB <= 0 {
+		maxMB = defaultRecordingMaxMB
+	}
+	return &WebShellRecordingStore{
+		dir:       cfg.Dir,
+		retention: time.Duration(days) * 24 * time.Hour,
+		maxBytes:  maxMB << 20,
+		log:       log,
+	}
+}
+
+func (s *WebShellRecordingStore) userDir(userIdentifier string) string {
+	return filepath.Join(s.dir, filepath.Base(userIdentifier))
+}
+
+// Start 创建录制文件并写入 asciicast v2 头
+func (s *WebShellRecordingStore) Start(session WebShellSession) (*webShellRecorder, error) {
+	dir := s.userDir(session.UserIdentifier)
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, err
+	}
+	s.prune(dir, time.Now())
+
+	meta := WebShellRecording{
+		ID:        newWebShellSessionID(),
+		User:      session.UserIdentifier,
+		PodID:     session.PodID,
+		Container: session.Container,
+		Shell:     session.Shell,
+		Cols:      session.Cols,
+		Rows:      session.Rows,
+		StartedAt: time.Now(),
+	}
+	file, err := os.OpenFile(filepath.Join(dir, meta.ID+webShellRecordingExt), os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o640)
+	if err != nil {
+		return nil, err
+	}
+	recorder := &webShellRecorder{
+		file:     file,
+		writer:   bufio.NewWriter(file),
+		meta:     meta,
+		metaPath: filepath.Join(dir, meta.ID+webShellRecordingMetaExt),
+		maxBytes: s.maxBytes,
+	}
+	header, _ := json.Marshal(map[string]any{
+		"version":   2,
+		"width":     meta.Cols,
+		"height":    meta.Rows,
+		"timestamp": meta.StartedAt.Unix(),
+		"title":     session.PodID + "/" + session.Container,
+		"env":       map[string]string{"SHELL": session.Shell, "TERM": "xterm-256color"},
+	})
+	if err := recorder.writeLine(header); err != nil {
+		_ = file.Close()
+		return nil, err
+	}
+	if err := recorder.saveMeta(); err != nil {
+		_ = file.Close()
+		return nil, err
+	}
+	return recorder, nil
+}
+
+// List 返回用户在指定 Pod 上的录制（podID 为空时返回全部），按开始时间倒序
+func (s *WebShellRecordingStore) List(userIdentifier, podID string) ([]WebShellRecording, error) {
+	recordings, err := s.listUser(userIdentifier, podID)
+	if err != nil {
+		return nil, err
+	}
+	sortWebShellRecordings(recordings)
+	return recordings, nil
+}
+
+// ListAll 返回所有用户的录制（管理员审计用），userIdentifier/podID 为空表示不过滤
+func (s *WebShellRecordingStore) ListAll(userIdentifier, podID string) ([]WebShellRecording, error) {
+	if userIdentifier != "" {
+		return s.List(userIdentifier, podID)
+	}
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []WebShellRecording{}, nil
+		}
+		return nil, err
+	}
+	recordings := []WebShellRecording{}
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		userRecordings, err := s.listUser(entry.Name(), podID)
+		if err != nil {
+			return nil, err
+		}
+		recordings = append(recordings, userRecordings...)
+	}
+	sortWebShellRecordings(recordings)
+	return recordings, nil
+}
+
+func (s *WebShellRecordingStore) listUser(userIdentifier, podID string) ([]WebShellRecording, error) {
+	dir := s.userDir(userIdentifier)
+	s.prune(dir, time.Now())
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []WebShellRecording{}, nil
+		}
+		return nil, err
+	}
+	recordings := make([]WebShellRecording, 0, len(entries))
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), webShellRecordingMetaExt) {
+			continue
+		}
+		meta, err := readWebShellRecordingMeta(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			continue
+		}
+		if podID != "" && meta.PodID != podID {
+			continue
+		}
+		if meta.User == "" {
+			meta.User = filepath.Base(dir)
+		}
+		recordings = append(recordings, *meta)
+	}
+	return recordings, nil
+}
+
+func sortWebShellRecordings(recordings []WebShellRecording) {
+	sort.Slice(recordings, func(i, j int) bool {
+		return recordings[i].StartedAt.After(recordings[j].StartedAt)
+	})
+}
+
+// Get 返回录制元数据与 .cast 文件路径
+func (s *WebShellRecordingStore) Get(userIdentifier, id string) (*WebShellRecording, string, error) {
+	if !webShellRecordingIDPattern.MatchString(id) {
+		return nil, "", os.ErrNotExist
+	}
+	dir := s.userDir(userIdentifier)
+	meta, err := readWebShellRecordingMeta(filepath.Join(dir, id+webShellRecordingMetaExt))
+	if err != nil {
+		return nil, "", err
+	}
+	return meta, filepath.Join(dir, id+webShellRecordingExt), nil
+}
+
+// prune 删除超过保留期的录制
+func (s *WebShellRecordingStore) prune(dir string, now time.Time) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return
+	}
+	cutoff := now.Add(-s.retention)
+	for _, entry := range entries {
+		info, err := entry.Info()
+		if err != nil || entry.IsDir() || !info.ModTime().Before(cutoff) {
+			continue
+		}
+		if err := os.Remove(filepath.Join(dir, entry.Name())); err != nil && s.log != nil {
+			s.log.Warn("Failed to prune webshell recording", zap.String("file", entry.Name()), zap.Error(err))
+		}
+	}
+}
+
+func readWebShellRecordingMeta(path string) (*WebShellRecording, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var meta WebShellRecording
+	if err := json.Unmarshal(data, &meta); err != nil {
+		return nil, err
+	}
+	return &meta, nil
+}
+
+// webShellRecorder 写入 asciicast v2 事件：[elapsed, "o", data] 与 [elapsed, "r", "COLSxROWS"]
+type webShellRecorder struct {
+	mu       sync.Mutex
+	file     *os.File
+	writer   *bufio.Writer
+	meta     WebShellRecording
+	metaPath string
+	maxBytes int64
+	written  int64
+	pending  []byte // 被拆分到下一次输出的不完整 UTF-8 字节
+	closed   bool
+}
+
+func (r *webShellRecorder) Output(p []byte) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed || r.meta.Truncated {
+		return
+	}
+	data := append(r.pending, p...)
+	// 末尾不完整的 UTF-8 序列留到下一次输出，避免 JSON 编码成替换字符
+	cut := len(data)
+	for i := len(data) - 1; i >= 0 && i >= len(data)-utf8.UTFMax; i-- {
+		if utf8.RuneStart(data[i]) {
+			if !utf8.FullRune(data[i:]) {
+				cut = i
+			}
+			break
+		}
+	}
+	r.pending = append([]byte(nil), data[cut:]...)
+	if cut == 0 {
+		return
+	}
+	r.writeEvent("o", string(data[:cut]))
+}
+
+func (r *webShellRecorder) Resize(cols, rows uint16) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed || r.meta.Truncated {
+		return
+	}
+	r.writeEvent("r", fmt.Sprintf("%dx%d", cols, rows))
+}
+
+func (r *webShellRecorder) writeEvent(kind, data string) {
+	elapsed := time.Since(r.meta.StartedAt).Seconds()
+	line, err := json.Marshal([]any{float64(int64(elapsed*1e6)) / 1e6, kind, data})
+	if err != nil {
+		return
+	}
+	if r.written+int64(len(line))+1 > r.maxBytes {
+		r.meta.Truncated = true
+		return
+	}
+	_ = r.writeLine(line)
+}
+
+func (r *webShellRecorder) writeLine(line []byte) error {
+	if _, err := r.writer.Write(append(line, '\n')); err != nil {
+		return err
+	}
+	r.written += int64(len(line)) + 1
+	return nil
+}
+
+func (r *webShellRecorder) saveMeta() error {
+	data, err := json.Marshal(r.meta)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(r.metaPath, data, 0o640)
+}
+
+// Close 刷新文件并写入最终元数据
+func (r *webShellRecorder) Close() error {
+	if r == nil {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed {
+		return nil
+	}
+	r.closed = true
+	if len(r.pending) > 0 && !r.meta.Truncated {
+		r.writeEvent("o", string(r.pending))
+	}
+	flushErr := r.writer.Flush()
+	closeErr := r.file.Close()
+	r.meta.EndedAt = time.Now()
+	r.meta.DurationSec = r.meta.EndedAt.Sub(r.meta.StartedAt).Seconds()
+	r.meta.SizeBytes = r.written
+	return errors.Join(flushErr, closeErr, r.saveMeta())
+}
+
+// startWebShellRecording 录制未启用或创建失败时返回 nil（不影响 Web Shell 使用）
+func (h *PodHandler) startWebShellRecording(session WebShellSession) *webShellRecorder {
+	if h.recordings == nil {
+		return nil
+	}
+	recorder, err := h.recordings.Start(session)
+	if err != nil {
+		h.log.Warn("Failed to start webshell recording",
+			zap.String("pod", session.PodID),
+			zap.String("user", session.UserIdentifier),
+			zap.Error(err))
+		return nil
+	}
+	return recorder
+}
+
+// ListWebShellRecordings 列出当前用户在该 Pod 上的 Web Shell 录制
+func (h *PodHandler) ListWebShellRecordings(c *gin.Context) {
+	if h.recordings == nil {
+		c.JSON(http.StatusOK, gin.H{"enabled": false, "recordings": []WebShellRecording{}})
+		return
+	}
+	recordings, err := h.recordings.List(webShellRequestUser(c), c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("读取录制列表失败: %v", err)})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"enabled": true, "recordings": recordings})
+}
+
+// GetWebShellRecording 返回 asciicast v2 文件内容
+func (h *PodHandler) GetWebShellRecording(c *gin.Context) {
+	meta, castPath, ok := h.lookupWebShellRecording(c)
+	if !ok {
+		return
+	}
+	c.Header("Content-Disposition", fmt.Sprintf("inline; filename=%s-%s.cast", meta.PodID, meta.ID))
+	c.Header("Content-Type", "application/x-asciicast")
+	c.File(castPath)
+}
+
+// AdminListWebShellRecordings 列出所有用户的 Web Shell 录制，支持 ?user= 与 ?podId= 过滤
+func (h *PodHandler) AdminListWebShellRecordings(c *gin.Context) {
+	if h.recordings == nil {
+		c.JSON(http.StatusOK, gin.H{"enabled": false, "recordings": []WebShellRecording{}})
+		return
+	}
+	user := strings.TrimSpace(c.Query("user"))
+	if user != "" && !validWebShellRecordingUser(user) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的用户"})
+		return
+	}
+	recordings, err := h.recordings.ListAll(user, strings.TrimSpace(c.Query("podId")))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("读取录制列表失败: %v", err)})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"enabled": true, "recordings": recordings})
+}
+
+// AdminGetWebShellRecording 返回任意用户录制的 asciicast v2 文件内容
+func (h *PodHandler) AdminGetWebShellRecording(c *gin.Context) {
+	if h.recordings == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Web Shell 录制未启用"})
+		return
+	}
+	user := c.Param("user")
+	if !validWebShellRecordingUser(user) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "录制不存在"})
+		return
+	}
+	meta, castPath, err := h.recordings.Get(user, c.Param("recordingId"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "录制不存在"})
+		return
+	}
+	c.Header("Content-Disposition", fmt.Sprintf("inline; filename=%s-%s-%s.cast", user, meta.PodID, meta.ID))
+	c.Header("Content-Type", "application/x-asciicast")
+	c.File(castPath)
+}
+
+// validWebShellRecordingUser 用户标识直接作为目录名，拒绝会逃出录制目录的取值
+func validWebShellRecordingUser(user string) bool {
+	return user != "" && user != "." && user != ".." && filepath.Base(user) == user
+}
+
+func (h *PodHandler) lookupWebShellRecording(c *gin.Context) (*WebShellRecording, string, bool) {
+	if h.recordings == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Web Shell 录制未启用"})
+		return nil, "", false
+	}
+	meta, castPath, err := h.recordings.Get(webShellRequestUser(c), c.Param("recordingId"))
+	if err != nil || meta.PodID != c.Param("id") {
+		c.JSON(http.StatusNotFound, gin.H{"error": "录制不存在"})
+		return nil, "", false
+	}
+	return meta, castPath, true
+}
+
+func webShellRequestUser(c *gin.Context) string {
+	username, _ := auth.GetUsername(c)
+	email, _ := auth.GetEmail(c)
+	return k8s.GetUserIdentifier(username, email)
+}
@@ -0,0 +1,259 @@
+package handlers
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/uc-package/genet/internal/auth"
+	"github.com/uc-package/genet/internal/k8s"
+	"github.com/uc-package/genet/internal/models"
+)
+
+func newTestRecordingStore(t *testing.T) *WebShellRecordingStore {
+	t.Helper()
+	return NewWebShellRecordingStore(models.WebShellRecordingConfig{
+		Enabled:       true,
+		Dir:           t.TempDir(),
+		RetentionDays: 1,
+		MaxSizeMB:     1,
+	}, nil)
+}
+
+func testWebShellSession(podID string) WebShellSession {
+	return WebShellSession{
+		PodID:          podID,
+		UserIdentifier: "alice",
+		Container:      "workspace",
+		Shell:          "/bin/bash",
+		Cols:           120,
+		Rows:           40,
+	}
+}
+
+func readCastLines(t *testing.T, path string) []string {
+	t.Helper()
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("open cast: %v", err)
+	}
+	defer file.Close()
+	var lines []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	return lines
+}
+
+func TestWebShellRecorderWritesAsciicastV2(t *testing.T) {
+	store := newTestRecordingStore(t)
+	recorder, err := store.Start(testWebShellSession("pod-alice-dev"))
+	if err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+
+	queue := newWebShellSizeQueue(120, 40)
+	queue.recorder = recorder
+	// "你" 被拆成两次输出，录制中应合并为完整字符
+	recorder.Output([]byte("$ echo \xe4\xbd"))
+	recorder.Output([]byte("\xa0\r\n"))
+	queue.Push(100, 30)
+	if err := recorder.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	queue.Close()
+
+	meta, castPath, err := store.Get("alice", recorder.meta.ID)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	lines := readCastLines(t, castPath)
+	if len(lines) != 4 {
+		t.Fatalf("expected header + 3 events, got %q", lines)
+	}
+	var header map[string]any
+	if err := json.Unmarshal([]byte(lines[0]), &header); err != nil || header["version"] != float64(2) || header["width"] != float64(120) {
+		t.Fatalf("unexpected header %q", lines[0])
+	}
+	var events []string
+	for _, line := range lines[1:] {
+		var event []any
+		if err := json.Unmarshal([]byte(line), &event); err != nil || len(event) != 3 {
+			t.Fatalf("invalid event %q", line)
+		}
+		events = append(events, event[1].(string)+":"+event[2].(string))
+	}
+	want := []string{"o:$ echo ", "o:你\r\n", "r:100x30"}
+	if strings.Join(events, "|") != strings.Join(want, "|") {
+		t.Fatalf("unexpected events %q", events)
+	}
+	if meta.SizeBytes == 0 || meta.EndedAt.IsZero() {
+		t.Fatalf("expected final metadata, got %+v", meta)
+	}
+}
+
+func TestWebShellRecorderStopsAtSizeLimit(t *testing.T) {
+	store := newTestRecordingStore(t)
+	recorder, err := store.Start(testWebShellSession("pod-alice-dev"))
+	if err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	chunk := []byte(strings.Repeat("x", 64<<10))
+	for i := 0; i < 32; i++ {
+		recorder.Output(chunk)
+	}
+	_ = recorder.Close()
+
+	meta, castPath, err := store.Get("alice", recorder.meta.ID)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	info, _ := os.Stat(castPath)
+	if !meta.Truncated || info.Size() > 1<<20 {
+		t.Fatalf("expected truncated recording under 1MiB, truncated=%v size=%d", meta.Truncated, info.Size())
+	}
+}
+
+func TestWebShellRecordingStoreListsAndPrunes(t *testing.T) {
+	store := newTestRecordingStore(t)
+	for _, podID := range []string{"pod-alice-dev", "pod-alice-train", "pod-alice-dev"} {
+		recorder, err := store.Start(testWebShellSession(podID))
+		if err != nil {
+			t.Fatalf("Start: %v", err)
+		}
+		_ = recorder.Close()
+	}
+
+	recordings, err := store.List("alice", "pod-alice-dev")
+	if err != nil || len(recordings) != 2 {
+		t.Fatalf("expected 2 recordings, got %d err=%v", len(recordings), err)
+	}
+	if others, _ := store.List("bob", ""); len(others) != 0 {
+		t.Fatalf("expected no recordings for bob, got %d", len(others))
+	}
+
+	old := time.Now().Add(-48 * time.Hour)
+	dir := store.userDir("alice")
+	for _, suffix := range []string{webShellRecordingExt, webShellRecordingMetaExt} {
+		path := filepath.Join(dir, recordings[0].ID+suffix)
+		if err := os.Chtimes(path, old, old); err != nil {
+			t.Fatalf("chtimes: %v", err)
+		}
+	}
+	if remaining, _ := store.List("alice", ""); len(remaining) != 2 {
+		t.Fatalf("expected expired recording to be pruned, got %d", len(remaining))
+	}
+}
+
+func TestWebShellRecordingEndpoints(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	handler := newPodProxyTestHandler("")
+	auth.InitAuthMiddleware(handler.config)
+	handler.recordings = newTestRecordingStore(t)
+	session := testWebShellSession("pod-alice-dev")
+	session.UserIdentifier = k8s.GetUserIdentifier("alice", "alice@example.com")
+	recorder, err := handler.recordings.Start(session)
+	if err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	recorder.Output([]byte("hello"))
+	_ = recorder.Close()
+
+	router := gin.New()
+	pods := router.Group("/api/pods", auth.AuthMiddleware(handler.config))
+	pods.GET("/:id/webshell/recordings", handler.ListWebShellRecordings)
+	pods.GET("/:id/webshell/recordings/:recordingId", handler.GetWebShellRecording)
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, newPodProxyTestRequest(http.MethodGet, "/api/pods/pod-alice-dev/webshell/recordings", nil))
+	var list struct {
+		Enabled    bool                `json:"enabled"`
+		Recordings []WebShellRecording `json:"recordings"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &list); err != nil || !list.Enabled || len(list.Recordings) != 1 {
+		t.Fatalf("unexpected list response %d %s", rec.Code, rec.Body.String())
+	}
+
+	castURL := "/api/pods/pod-alice-dev/webshell/recordings/" + recorder.meta.ID
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, newPodProxyTestRequest(http.MethodGet, castURL, nil))
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"o","hello"`) {
+		t.Fatalf("unexpected cast response %d %q", rec.Code, rec.Body.String())
+	}
+
+	bobReq := httptest.NewRequest(http.MethodGet, castURL, nil)
+	setPodProxyTestUser(bobReq, "bob")
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, bobReq)
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("expected other users to get 404, got %d", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, newPodProxyTestRequest(http.MethodGet, "/api/pods/pod-alice-dev/webshell/recordings/..%2Fsecret", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("expected invalid id to be rejected, got %d", rec.Code)
+	}
+}
+
+func TestAdminWebShellRecordingEndpoints(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	handler := newPodProxyTestHandler("")
+	handler.recordings = newTestRecordingStore(t)
+	var ids []string
+	for _, user := range []string{"alice", "bob"} {
+		session := testWebShellSession("pod-" + user + "-dev")
+		session.UserIdentifier = user
+		recorder, err := handler.recordings.Start(session)
+		if err != nil {
+			t.Fatalf("Start: %v", err)
+		}
+		recorder.Output([]byte("from " + user))
+		_ = recorder.Close()
+		ids = append(ids, recorder.meta.ID)
+	}
+
+	router := gin.New()
+	router.GET("/api/admin/webshell/recordings", handler.AdminListWebShellRecordings)
+	router.GET("/api/admin/webshell/recordings/:user/:recordingId", handler.AdminGetWebShellRecording)
+
+	list := func(query string) []WebShellRecording {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/admin/webshell/recordings"+query, nil))
+		var resp struct {
+			Recordings []WebShellRecording `json:"recordings"`
+		}
+		if rec.Code != http.StatusOK || json.Unmarshal(rec.Body.Bytes(), &resp) != nil {
+			t.Fatalf("unexpected list response %d %s", rec.Code, rec.Body.String())
+		}
+		return resp.Recordings
+	}
+	if all := list(""); len(all) != 2 {
+		t.Fatalf("expected recordings of all users, got %+v", all)
+	}
+	if bob := list("?user=bob"); len(bob) != 1 || bob[0].User != "bob" || bob[0].ID != ids[1] {
+		t.Fatalf("unexpected user filter result %+v", bob)
+	}
+	if byPod := list("?podId=pod-alice-dev"); len(byPod) != 1 || byPod[0].User != "alice" {
+		t.Fatalf("unexpected pod filter result %+v", byPod)
+	}
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/admin/webshell/recordings/bob/"+ids[1], nil))
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"o","from bob"`) {
+		t.Fatalf("unexpected cast response %d %q", rec.Code, rec.Body.String())
+	}
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/admin/webshell/recordings/../"+ids[1], nil))
+	if rec.Code == http.StatusOK {
+		t.Fatalf("expected path traversal to be rejected")
+	}
+}
@@ -3,21 +3,32 @@ package handlers
 import (
 	"crypto/rand"
 	"encoding/hex"
+	"fmt"
+	"regexp"
+	"sort"
 	"sync"
 	"time"
 )
 
 const (
-	defaultWebShellCols = 120
-	defaultWebShellRows = 40
+	defaultWebShellCols        = 120
+	defaultWebShellRows        = 40
+	defaultWebShellDetachedTTL = 24 * time.Hour
+
+	webShellStatePending  = "pending"
+	webShellStateAttached = "attached"
+	webShellStateDetached = "detached"
 )
 
 type WebShellSessionSpec struct {
+	ID             string // 重新连接时沿用已有会话 ID（容器内 tmux/screen 会话以其命名）
 	PodID          string
 	Namespace      string
 	UserIdentifier string
 	Container      string
 	Shell          string
+	SelectedShell  string // 用户选择的 Shell，为空时自动回退
+	Multiplexer    string
 	Cols           int
 	Rows           int
 }
@@ -29,10 +40,19 @@ type WebShellSession struct {
 	UserIdentifier string    `json:"userIdentifier"`
 	Container      string    `json:"container"`
 	Shell          string    `json:"shell"`
+	SelectedShell  string    `json:"selectedShell,omitempty"`
+	Multiplexer    string    `json:"multiplexer"`
 	Cols           int       `json:"cols"`
 	Rows           int       `json:"rows"`
 	CreatedAt      time.Time `json:"createdAt"`
 	ExpiresAt      time.Time `json:"expiresAt"`
+	DetachedAt     time.Time `json:"detachedAt,omitempty"`
+}
+
+// WebShellSessionInfo 会话列表项；State 为 pending / attached / detached
+type WebShellSessionInfo struct {
+	WebShellSession
+	State string `json:"state"`
 }
 
 type WebShellSessionResponse struct {
@@ -43,12 +63,18 @@ type WebShellSessionResponse struct {
 	Cols         int       `json:"cols"`
 	Rows         int       `json:"rows"`
 	ExpiresAt    time.Time `json:"expiresAt"`
+	Recording    bool      `json:"recording"`
+	Persistent   bool      `json:"persistent"`
+	Reattached   bool      `json:"reattached"`
 }
 
 type WebShellSessionManager struct {
-	ttl      time.Duration
-	mu       sync.Mutex
-	sessions map[string]WebShellSession
+	ttl         time.Duration
+	detachedTTL time.Duration // 断开后保留时长，期间可重新连接
+	mu          sync.Mutex
+	sessions    map[string]WebShellSession
+	hubs        map[string]*webShellHub  // 已连接的会话
+	shares      map[string]WebShellShare // token -> 分享
 }
 
 func NewWebShellSessionManager(ttl time.Duration) *WebShellSessionManager {
@@ -56,8 +82,11 @@ func NewWebShellSessionManager(ttl time.Duration) *WebShellSessionManager {
 		ttl = 5 * time.Minute
 	}
 	return &WebShellSessionManager{
-		ttl:      ttl,
-		sessions: make(map[string]WebShellSession),
+		ttl:         ttl,
+		detachedTTL: defaultWebShellDetachedTTL,
+		sessions:    make(map[string]WebShellSession),
+		hubs:        make(map[string]*webShellHub),
+		shares:      make(map[string]WebShellShare),
 	}
 }
 
@@ -67,14 +96,20 @@ func (m *WebShellSessionManager) Create(spec WebShellSessionSpec) WebShellSessio
 
 	m.pruneLocked(time.Now())
 
+	id := spec.ID
+	if id == "" {
+		id = newWebShellSessionID()
+	}
 	now := time.Now()
 	session := WebShellSession{
-		ID:             newWebShellSessionID(),
+		ID:             id,
 		PodID:          spec.PodID,
 		Namespace:      spec.Namespace,
 		UserIdentifier: spec.UserIdentifier,
 		Container:      spec.Container,
 		Shell:          spec.Shell,
+		SelectedShell:  spec.SelectedShell,
+		Multiplexer:    spec.Multiplexer,
 		Cols:           normalizeWebShellCols(spec.Cols),
 		Rows:           normalizeWebShellRows(spec.Rows),
 		CreatedAt:      now,
@@ -100,12 +135,181 @@ func (m *WebShellSessionManager) Delete(id string) {
 	delete(m.sessions, id)
 }
 
+// Reattach 重新连接已断开的会话，并延长等待 WebSocket 连接的时间；
+// 会话不属于该用户在该 Pod 上时 owned 为 false，且不做任何修改
+func (m *WebShellSessionManager) Reattach(id, podID, userIdentifier string) (session WebShellSession, exists, owned bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.pruneLocked(time.Now())
+	session, exists = m.sessions[id]
+	if !exists {
+		return WebShellSession{}, false, false
+	}
+	if session.PodID != podID || session.UserIdentifier != userIdentifier {
+		return WebShellSession{}, true, false
+	}
+	if deadline := time.Now().Add(m.ttl); session.ExpiresAt.Before(deadline) {
+		session.ExpiresAt = deadline
+	}
+	session.DetachedAt = time.Time{}
+	m.sessions[id] = session
+	return session, true, true
+}
+
+// Detach 浏览器断开后保留会话 detachedTTL，期间可重新连接
+func (m *WebShellSessionManager) Detach(id string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	session, ok := m.sessions[id]
+	if !ok {
+		return
+	}
+	now := time.Now()
+	session.DetachedAt = now
+	session.ExpiresAt = now.Add(m.detachedTTL)
+	m.sessions[id] = session
+}
+
+// List 返回用户在该 Pod 上的会话
+func (m *WebShellSessionManager) List(podID, userIdentifier string) []WebShellSessionInfo {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.pruneLocked(time.Now())
+	items := make([]WebShellSessionInfo, 0)
+	for id, session := range m.sessions {
+		if session.PodID != podID || session.UserIdentifier != userIdentifier {
+			continue
+		}
+		state := webShellStatePending
+		if _, ok := m.hubs[id]; ok {
+			state = webShellStateAttached
+		} else if !session.DetachedAt.IsZero() {
+			state = webShellStateDetached
+		}
+		items = append(items, WebShellSessionInfo{WebShellSession: session, State: state})
+	}
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].CreatedAt.After(items[j].CreatedAt)
+	})
+	return items
+}
+
 func (m *WebShellSessionManager) pruneLocked(now time.Time) {
 	for id, session := range m.sessions {
+		if _, attached := m.hubs[id]; attached {
+			continue
+		}
 		if !session.ExpiresAt.After(now) {
 			delete(m.sessions, id)
 		}
 	}
+	for token, share := range m.shares {
+		if !share.ExpiresAt.After(now) {
+			delete(m.shares, token)
+		}
+	}
+}
+
+// AttachedCount 当前有浏览器连接的会话数
+func (m *WebShellSessionManager) AttachedCount() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.hubs)
+}
+
+// Activate 登记已连接会话的 hub，供分享访客加入；同一会话在别处打开时断开旧连接
+func (m *WebShellSessionManager) Activate(hub *webShellHub) {
+	m.mu.Lock()
+	previous := m.hubs[hub.session.ID]
+	m.hubs[hub.session.ID] = hub
+	m.mu.Unlock()
+
+	if previous != nil {
+		previous.close()
+		previous.closeOwner("session opened elsewhere")
+	}
+}
+
+// Deactivate 连接结束时移除 hub 并断开访客；会话已被新连接接管时不影响新连接
+func (m *WebShellSessionManager) Deactivate(hub *webShellHub) {
+	m.mu.Lock()
+	if m.hubs[hub.session.ID] != hub {
+		m.mu.Unlock()
+		return
+	}
+	delete(m.hubs, hub.session.ID)
+	m.mu.Unlock()
+
+	hub.close()
+}
+
+// End 会话彻底结束（shell 退出或用户关闭）时移除会话及其所有分享
+func (m *WebShellSessionManager) End(id string) {
+	m.mu.Lock()
+	hub := m.hubs[id]
+	delete(m.hubs, id)
+	delete(m.sessions, id)
+	for token, share := range m.shares {
+		if share.SessionID == id {
+			delete(m.shares, token)
+		}
+	}
+	m.mu.Unlock()
+
+	if hub != nil {
+		hub.close()
+		hub.closeOwner("session closed")
+	}
+}
+
+func (m *WebShellSessionManager) Hub(id string) (*webShellHub, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	hub, ok := m.hubs[id]
+	return hub, ok
+}
+
+// CreateShare 为已连接会话创建分享令牌
+func (m *WebShellSessionManager) CreateShare(session WebShellSession, mode string, ttl time.Duration) (WebShellShare, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.hubs[session.ID]; !ok {
+		return WebShellShare{}, fmt.Errorf("Web Shell 会话未连接")
+	}
+	now := time.Now()
+	share := WebShellShare{
+		Token:     newWebShellShareToken(),
+		SessionID: session.ID,
+		PodID:     session.PodID,
+		Owner:     session.UserIdentifier,
+		Mode:      mode,
+		CreatedAt: now,
+		ExpiresAt: now.Add(ttl),
+	}
+	m.shares[share.Token] = share
+	return share, nil
+}
+
+// ResolveShare 校验令牌未过期且会话仍在连接
+func (m *WebShellSessionManager) ResolveShare(token string) (WebShellShare, *webShellHub, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.pruneLocked(time.Now())
+	share, ok := m.shares[token]
+	if !ok {
+		return WebShellShare{}, nil, false
+	}
+	hub, ok := m.hubs[share.SessionID]
+	if !ok {
+		return WebShellShare{}, nil, false
+	}
+	return share, hub, true
 }
 
 func normalizeWebShellCols(cols int) int {
@@ -122,6 +326,12 @@ func normalizeWebShellRows(rows int) int {
 	return rows
 }
 
+var webShellSessionIDPattern = regexp.MustCompile(`^[0-9a-f]{16,64}$`)
+
+func isValidWebShellSessionID(id string) bool {
+	return webShellSessionIDPattern.MatchString(id)
+}
+
 func newWebShellSessionID() string {
 	var raw [16]byte
 	if _, err := rand.Read(raw[:]); err != nil {
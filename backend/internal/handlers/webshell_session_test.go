@@ -54,3 +54,69 @@ func TestWebShellSessionManagerExpiresSessions(t *testing.T) {
 		t.Fatalf("expected expired session to be removed")
 	}
 }
+
+func TestWebShellSessionManagerDetachKeepsSessionForReattach(t *testing.T) {
+	manager := NewWebShellSessionManager(20 * time.Millisecond)
+	manager.detachedTTL = time.Hour
+	session := manager.Create(WebShellSessionSpec{
+		PodID:          "pod-alice-dev",
+		UserIdentifier: "alice",
+		Multiplexer:    webShellMultiplexerAuto,
+	})
+
+	hub := newWebShellHub(session, nil, nil)
+	manager.Activate(hub)
+	time.Sleep(50 * time.Millisecond)
+	if items := manager.List("pod-alice-dev", "alice"); len(items) != 1 || items[0].State != webShellStateAttached {
+		t.Fatalf("expected attached session to outlive connect ttl, got %+v", items)
+	}
+
+	manager.Deactivate(hub)
+	manager.Detach(session.ID)
+	time.Sleep(50 * time.Millisecond)
+	items := manager.List("pod-alice-dev", "alice")
+	if len(items) != 1 || items[0].State != webShellStateDetached {
+		t.Fatalf("expected detached session, got %+v", items)
+	}
+	// 其他用户或其他 Pod 不能重新连接，也不能延长会话
+	expiresAt := items[0].ExpiresAt
+	for _, owner := range [][2]string{{"pod-alice-dev", "bob"}, {"pod-alice-other", "alice"}} {
+		if _, exists, owned := manager.Reattach(session.ID, owner[0], owner[1]); !exists || owned {
+			t.Fatalf("expected %v not to own the session", owner)
+		}
+	}
+	if current, _ := manager.Get(session.ID); !current.ExpiresAt.Equal(expiresAt) || current.DetachedAt.IsZero() {
+		t.Fatalf("expected foreign reattach to leave the session untouched, got %+v", current)
+	}
+	reattached, exists, owned := manager.Reattach(session.ID, "pod-alice-dev", "alice")
+	if !exists || !owned || !reattached.DetachedAt.IsZero() || reattached.ExpiresAt.Before(expiresAt) {
+		t.Fatalf("expected detached session to be reattachable, got %+v", reattached)
+	}
+	if items := manager.List("pod-alice-dev", "bob"); len(items) != 0 {
+		t.Fatalf("expected other users not to see the session, got %+v", items)
+	}
+
+	manager.End(session.ID)
+	if _, ok := manager.Get(session.ID); ok {
+		t.Fatalf("expected ended session to be removed")
+	}
+}
+
+func TestWebShellSessionManagerActivateTakesOverConnection(t *testing.T) {
+	manager := NewWebShellSessionManager(5 * time.Minute)
+	session := manager.Create(WebShellSessionSpec{PodID: "pod-alice-dev", UserIdentifier: "alice"})
+
+	first := newWebShellHub(session, nil, nil)
+	second := newWebShellHub(session, nil, nil)
+	manager.Activate(first)
+	manager.Activate(second)
+	if !first.closed {
+		t.Fatalf("expected previous connection to be closed")
+	}
+
+	// 旧连接退出时不能移除新连接
+	manager.Deactivate(first)
+	if hub, ok := manager.Hub(session.ID); !ok || hub != second {
+		t.Fatalf("expected new connection to stay active")
+	}
+}
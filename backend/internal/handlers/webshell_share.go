@@ -0,0 +1,386 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"go.uber.org/zap"
+)
+
+const (
+	webShellShareReadOnly   = "ro"
+	webShellShareReadWrite  = "rw"
+	defaultWebShellShareTTL = time.Hour
+	maxWebShellShareTTL     = 24 * time.Hour
+
+	// 访客发送缓冲（消息数），积压超过此值的访客被断开
+	webShellGuestSendBuffer   = 128
+	webShellGuestWriteTimeout = 10 * time.Second
+)
+
+type createWebShellShareRequest struct {
+	Mode             string `json:"mode"`
+	ExpiresInMinutes int    `json:"expiresInMinutes"`
+}
+
+// WebShellShare 会话分享令牌，持有者以只读（ro）或读写（rw）方式加入同一 exec 流
+type WebShellShare struct {
+	Token     string    `json:"token"`
+	SessionID string    `json:"sessionId"`
+	PodID     string    `json:"podId"`
+	Owner     string    `json:"owner"`
+	Mode      string    `json:"mode"`
+	CreatedAt time.Time `json:"createdAt"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// WebShellShareResponse 创建分享的返回
+type WebShellShareResponse struct {
+	WebShellShare
+	URL          string `json:"url"`
+	WebSocketURL string `json:"webSocketURL"`
+}
+
+// WebShellParticipant 加入会话的审计记录
+type WebShellParticipant struct {
+	User     string     `json:"user"`
+	Mode     string     `json:"mode"`
+	JoinedAt time.Time  `json:"joinedAt"`
+	LeftAt   *time.Time `json:"leftAt,omitempty"`
+}
+
+// webShellHub 活跃会话的输出扇出与输入合并点
+type webShellHub struct {
+	session WebShellSession
+	owner   *webShellOutputWriter
+	stdin   io.Writer
+
+	mu           sync.Mutex
+	guests       map[*webShellGuest]struct{}
+	participants []WebShellParticipant
+	closed       bool
+}
+
+// webShellGuest 每个访客有独立的发送缓冲与写协程，慢访客不会阻塞会话输出
+type webShellGuest struct {
+	conn  *websocket.Conn
+	user  string
+	mode  string
+	index int
+	send  chan []byte
+	done  chan struct{}
+
+	stopOnce    sync.Once
+	closeReason string
+}
+
+func newWebShellGuest(conn *websocket.Conn, user, mode string) *webShellGuest {
+	return &webShellGuest{
+		conn: conn,
+		user: user,
+		mode: mode,
+		send: make(chan []byte, webShellGuestSendBuffer),
+		done: make(chan struct{}),
+	}
+}
+
+// enqueue 非阻塞投递输出，缓冲区已满说明访客跟不上输出
+func (g *webShellGuest) enqueue(p []byte) bool {
+	select {
+	case <-g.done:
+		return false
+	default:
+	}
+	select {
+	case g.send <- p:
+		return true
+	default:
+		return false
+	}
+}
+
+// stop 通知写协程发送关闭帧并断开连接，可重复调用
+func (g *webShellGuest) stop(reason string) {
+	g.stopOnce.Do(func() {
+		g.closeReason = reason
+		close(g.done)
+	})
+}
+
+// writeLoop 每次写入都带截止时间，写失败或超时只断开该访客
+func (g *webShellGuest) writeLoop() {
+	defer g.conn.Close()
+	for {
+		select {
+		case p := <-g.send:
+			if !g.writeOutput(p) {
+				return
+			}
+		case <-g.done:
+			// 先发完已排队的输出，再发送关闭帧
+			for len(g.send) > 0 {
+				if !g.writeOutput(<-g.send) {
+					return
+				}
+			}
+			_ = g.conn.SetWriteDeadline(time.Now().Add(webShellGuestWriteTimeout))
+			_ = g.conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, g.closeReason))
+			return
+		}
+	}
+}
+
+func (g *webShellGuest) writeOutput(p []byte) bool {
+	_ = g.conn.SetWriteDeadline(time.Now().Add(webShellGuestWriteTimeout))
+	return g.conn.WriteMessage(websocket.BinaryMessage, p) == nil
+}
+
+func newWebShellHub(session WebShellSession, owner *webShellOutputWriter, stdin io.Writer) *webShellHub {
+	return &webShellHub{
+		session: session,
+		owner:   owner,
+		stdin:   stdin,
+		guests:  make(map[*webShellGuest]struct{}),
+	}
+}
+
+// broadcast 将终端输出投递到各访客的发送缓冲，不等待网络写入；缓冲已满的访客被断开
+func (hub *webShellHub) broadcast(p []byte) {
+	if hub == nil {
+		return
+	}
+	hub.mu.Lock()
+	guests := make([]*webShellGuest, 0, len(hub.guests))
+	for guest := range hub.guests {
+		guests = append(guests, guest)
+	}
+	hub.mu.Unlock()
+	if len(guests) == 0 {
+		return
+	}
+
+	// p 由 exec 流复用，排队前需要复制
+	data := append([]byte(nil), p...)
+	for _, guest := range guests {
+		if !guest.enqueue(data) {
+			hub.removeGuest(guest)
+			guest.stop("output backlog, please reconnect")
+		}
+	}
+}
+
+// notifyOwner 在会话所有者终端中显示提示（不进入 shell，也不写入录制）
+func (hub *webShellHub) notifyOwner(text string) {
+	if hub.owner == nil {
+		return
+	}
+	hub.owner.mu.Lock()
+	defer hub.owner.mu.Unlock()
+	_ = hub.owner.conn.WriteMessage(websocket.BinaryMessage, []byte("\r\n\x1b[33m[genet] "+text+"\x1b[0m\r\n"))
+}
+
+// join 登记访客并启动其写协程，会话已结束时返回 false
+func (hub *webShellHub) join(guest *webShellGuest) bool {
+	hub.mu.Lock()
+	defer hub.mu.Unlock()
+	if hub.closed {
+		return false
+	}
+	hub.guests[guest] = struct{}{}
+	hub.participants = append(hub.participants, WebShellParticipant{User: guest.user, Mode: guest.mode, JoinedAt: time.Now()})
+	guest.index = len(hub.participants) - 1
+	go guest.writeLoop()
+	return true
+}
+
+func (hub *webShellHub) removeGuest(guest *webShellGuest) {
+	hub.mu.Lock()
+	defer hub.mu.Unlock()
+	delete(hub.guests, guest)
+}
+
+func (hub *webShellHub) markLeft(index int) {
+	hub.mu.Lock()
+	defer hub.mu.Unlock()
+	if index >= 0 && index < len(hub.participants) && hub.participants[index].LeftAt == nil {
+		now := time.Now()
+		hub.participants[index].LeftAt = &now
+	}
+}
+
+func (hub *webShellHub) Participants() []WebShellParticipant {
+	hub.mu.Lock()
+	defer hub.mu.Unlock()
+	return append([]WebShellParticipant(nil), hub.participants...)
+}
+
+// close 会话结束时断开所有访客
+func (hub *webShellHub) close() {
+	hub.mu.Lock()
+	hub.closed = true
+	guests := hub.guests
+	hub.guests = make(map[*webShellGuest]struct{})
+	hub.mu.Unlock()
+
+	for guest := range guests {
+		guest.stop("session closed")
+	}
+}
+
+// closeOwner 断开所有者连接，用于会话被新连接接管或被关闭
+func (hub *webShellHub) closeOwner(reason string) {
+	if hub.owner == nil {
+		return
+	}
+	hub.owner.mu.Lock()
+	defer hub.owner.mu.Unlock()
+	_ = hub.owner.conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, reason))
+	_ = hub.owner.conn.Close()
+}
+
+// serveGuest 读取访客输入：读写模式合并到 stdin，只读模式丢弃；resize 由所有者控制
+func (hub *webShellHub) serveGuest(guest *webShellGuest) {
+	defer hub.markLeft(guest.index)
+	defer guest.stop("")
+	defer hub.removeGuest(guest)
+
+	for {
+		msgType, payload, err := guest.conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		if guest.mode != webShellShareReadWrite {
+			continue
+		}
+		if msgType == websocket.TextMessage && isWebShellControlMessage(payload) {
+			continue
+		}
+		if msgType == websocket.BinaryMessage || msgType == websocket.TextMessage {
+			if _, err := hub.stdin.Write(payload); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// CreateWebShellShare 会话所有者为活跃会话创建分享令牌
+func (h *PodHandler) CreateWebShellShare(c *gin.Context) {
+	userIdentifier := webShellRequestUser(c)
+	hub, ok := h.sessions.Hub(c.Param("sessionId"))
+	if !ok || hub.session.PodID != c.Param("id") || hub.session.UserIdentifier != userIdentifier {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Web Shell 会话不存在或未连接"})
+		return
+	}
+
+	var req createWebShellShareRequest
+	if err := c.ShouldBindJSON(&req); err != nil && err != io.EOF {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的分享参数"})
+		return
+	}
+	if req.Mode == "" {
+		req.Mode = webShellShareReadOnly
+	}
+	if req.Mode != webShellShareReadOnly && req.Mode != webShellShareReadWrite {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "mode 仅支持 ro 或 rw"})
+		return
+	}
+	ttl := defaultWebShellShareTTL
+	if req.ExpiresInMinutes > 0 {
+		ttl = time.Duration(req.ExpiresInMinutes) * time.Minute
+		if ttl > maxWebShellShareTTL {
+			ttl = maxWebShellShareTTL
+		}
+	}
+
+	share, err := h.sessions.CreateShare(hub.session, req.Mode, ttl)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	h.log.Info("Web shell share created",
+		zap.String("owner", userIdentifier),
+		zap.String("pod", share.PodID),
+		zap.String("session", share.SessionID),
+		zap.String("mode", share.Mode),
+		zap.Time("expiresAt", share.ExpiresAt))
+
+	c.JSON(http.StatusCreated, WebShellShareResponse{
+		WebShellShare: share,
+		URL:           "/webshell/shared/" + share.Token,
+		WebSocketURL:  "/api/webshell/shares/" + share.Token + "/ws",
+	})
+}
+
+// ListWebShellParticipants 返回加入过该会话的访客（审计）
+func (h *PodHandler) ListWebShellParticipants(c *gin.Context) {
+	hub, ok := h.sessions.Hub(c.Param("sessionId"))
+	if !ok || hub.session.PodID != c.Param("id") || hub.session.UserIdentifier != webShellRequestUser(c) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Web Shell 会话不存在或未连接"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"participants": hub.Participants()})
+}
+
+// GetWebShellShare 返回分享信息（供访客页面展示）
+func (h *PodHandler) GetWebShellShare(c *gin.Context) {
+	share, _, ok := h.sessions.ResolveShare(c.Param("token"))
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "分享链接无效、已过期或会话已结束"})
+		return
+	}
+	c.JSON(http.StatusOK, share)
+}
+
+// SharedWebShellWebSocket 访客通过分享令牌加入会话
+func (h *PodHandler) SharedWebShellWebSocket(c *gin.Context) {
+	share, hub, ok := h.sessions.ResolveShare(c.Param("token"))
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "分享链接无效、已过期或会话已结束"})
+		return
+	}
+	viewer := webShellRequestUser(c)
+
+	conn, err := h.webShellUpgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	modeText := "只读"
+	if share.Mode == webShellShareReadWrite {
+		modeText = "读写"
+	}
+	h.log.Info("Web shell share joined",
+		zap.String("viewer", viewer),
+		zap.String("owner", share.Owner),
+		zap.String("pod", share.PodID),
+		zap.String("session", share.SessionID),
+		zap.String("mode", share.Mode))
+	guest := newWebShellGuest(conn, viewer, share.Mode)
+	if !hub.join(guest) {
+		return
+	}
+	hub.notifyOwner(fmt.Sprintf("%s 以%s方式加入了会话", viewer, modeText))
+	hub.serveGuest(guest)
+
+	h.log.Info("Web shell share left",
+		zap.String("viewer", viewer),
+		zap.String("owner", share.Owner),
+		zap.String("session", share.SessionID))
+	hub.notifyOwner(fmt.Sprintf("%s 离开了会话", viewer))
+}
+
+func newWebShellShareToken() string {
+	var raw [24]byte
+	if _, err := rand.Read(raw[:]); err != nil {
+		return newWebShellSessionID() + newWebShellSessionID()
+	}
+	return hex.EncodeToString(raw[:])
+}
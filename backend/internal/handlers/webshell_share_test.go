@@ -0,0 +1,256 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/uc-package/genet/internal/auth"
+	"github.com/uc-package/genet/internal/k8s"
+)
+
+// newWebSocketPair 返回一对已连接的 WebSocket（服务端、客户端）
+func newWebSocketPair(t *testing.T) (*websocket.Conn, *websocket.Conn) {
+	t.Helper()
+	serverConns := make(chan *websocket.Conn, 1)
+	upgrader := websocket.Upgrader{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		serverConns <- conn
+	}))
+	t.Cleanup(server.Close)
+	client, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http"), nil)
+	if err != nil {
+		t.Fatalf("dial pair: %v", err)
+	}
+	t.Cleanup(func() { _ = client.Close() })
+	return <-serverConns, client
+}
+
+func TestWebShellSessionManagerShares(t *testing.T) {
+	manager := NewWebShellSessionManager(5 * time.Minute)
+	session := manager.Create(WebShellSessionSpec{PodID: "pod-alice-dev", UserIdentifier: "alice"})
+
+	if _, err := manager.CreateShare(session, webShellShareReadOnly, time.Hour); err == nil {
+		t.Fatalf("expected share creation to require a connected session")
+	}
+
+	manager.Activate(newWebShellHub(session, nil, io.Discard))
+	share, err := manager.CreateShare(session, webShellShareReadOnly, time.Hour)
+	if err != nil {
+		t.Fatalf("CreateShare: %v", err)
+	}
+	if _, _, ok := manager.ResolveShare(share.Token); !ok {
+		t.Fatalf("expected share to resolve")
+	}
+	expired, _ := manager.CreateShare(session, webShellShareReadOnly, -time.Second)
+	if _, _, ok := manager.ResolveShare(expired.Token); ok {
+		t.Fatalf("expected expired share to be rejected")
+	}
+
+	manager.End(session.ID)
+	if _, _, ok := manager.ResolveShare(share.Token); ok {
+		t.Fatalf("expected share to be removed with the session")
+	}
+}
+
+func TestSharedWebShellFansOutAndMergesInput(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	handler := newPodProxyTestHandler("")
+	auth.InitAuthMiddleware(handler.config)
+	handler.sessions = NewWebShellSessionManager(5 * time.Minute)
+
+	router := gin.New()
+	pods := router.Group("/api/pods", auth.AuthMiddleware(handler.config))
+	pods.POST("/:id/webshell/sessions/:sessionId/shares", handler.CreateWebShellShare)
+	pods.GET("/:id/webshell/sessions/:sessionId/participants", handler.ListWebShellParticipants)
+	shares := router.Group("/api/webshell/shares", auth.AuthMiddleware(handler.config), auth.RequireAuth)
+	shares.GET("/:token/ws", handler.SharedWebShellWebSocket)
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	owner := k8s.GetUserIdentifier("alice", "alice@example.com")
+	session := handler.sessions.Create(WebShellSessionSpec{PodID: "pod-alice-dev", UserIdentifier: owner})
+	ownerServerSide, ownerClient := newWebSocketPair(t)
+	stdinReader, stdinWriter := io.Pipe()
+	defer stdinReader.Close()
+	outputWriter := &webShellOutputWriter{conn: ownerServerSide}
+	outputWriter.hub = newWebShellHub(session, outputWriter, stdinWriter)
+	handler.sessions.Activate(outputWriter.hub)
+
+	createShare := func(user, mode string) (int, WebShellShareResponse) {
+		body, _ := json.Marshal(createWebShellShareRequest{Mode: mode})
+		req, _ := http.NewRequest(http.MethodPost, server.URL+"/api/pods/pod-alice-dev/webshell/sessions/"+session.ID+"/shares", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		setPodProxyTestUser(req, user)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("create share: %v", err)
+		}
+		defer resp.Body.Close()
+		var share WebShellShareResponse
+		_ = json.NewDecoder(resp.Body).Decode(&share)
+		return resp.StatusCode, share
+	}
+
+	if status, _ := createShare("bob", webShellShareReadWrite); status != http.StatusNotFound {
+		t.Fatalf("expected non-owner to be rejected, got %d", status)
+	}
+	status, share := createShare("alice", webShellShareReadWrite)
+	if status != http.StatusCreated || share.Mode != webShellShareReadWrite {
+		t.Fatalf("unexpected share response %d %+v", status, share)
+	}
+
+	header := http.Header{}
+	header.Set("X-Auth-Request-User", "bob")
+	header.Set("X-Auth-Request-Email", "bob@example.com")
+	guest, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+share.WebSocketURL, header)
+	if err != nil {
+		t.Fatalf("guest dial: %v", err)
+	}
+	defer guest.Close()
+
+	// 所有者收到加入提示
+	_, notice, err := ownerClient.ReadMessage()
+	if err != nil || !strings.Contains(string(notice), "加入了会话") {
+		t.Fatalf("expected join notice, got %q err=%v", notice, err)
+	}
+
+	if _, err := outputWriter.Write([]byte("$ ")); err != nil {
+		t.Fatalf("owner write: %v", err)
+	}
+	_, payload, err := guest.ReadMessage()
+	if err != nil || string(payload) != "$ " {
+		t.Fatalf("guest did not receive output: %q err=%v", payload, err)
+	}
+
+	if err := guest.WriteMessage(websocket.TextMessage, []byte(`{"type":"resize","cols":10,"rows":10}`)); err != nil {
+		t.Fatalf("guest resize: %v", err)
+	}
+	if err := guest.WriteMessage(websocket.BinaryMessage, []byte("ls\n")); err != nil {
+		t.Fatalf("guest write: %v", err)
+	}
+	buf := make([]byte, 16)
+	n, err := stdinReader.Read(buf)
+	if err != nil || string(buf[:n]) != "ls\n" {
+		t.Fatalf("expected guest input in stdin, got %q err=%v", buf[:n], err)
+	}
+
+	req, _ := http.NewRequest(http.MethodGet, server.URL+"/api/pods/pod-alice-dev/webshell/sessions/"+session.ID+"/participants", nil)
+	setPodProxyTestUser(req, "alice")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("participants: %v", err)
+	}
+	var audit struct {
+		Participants []WebShellParticipant `json:"participants"`
+	}
+	_ = json.NewDecoder(resp.Body).Decode(&audit)
+	resp.Body.Close()
+	if len(audit.Participants) != 1 || audit.Participants[0].User != k8s.GetUserIdentifier("bob", "bob@example.com") {
+		t.Fatalf("unexpected participants %+v", audit.Participants)
+	}
+
+	handler.sessions.End(session.ID)
+	if _, _, err := guest.ReadMessage(); !websocket.IsCloseError(err, websocket.CloseNormalClosure) {
+		t.Fatalf("expected guest to be disconnected when session ends, got %v", err)
+	}
+}
+
+func TestSharedWebShellReadOnlyDropsInput(t *testing.T) {
+	session := WebShellSession{ID: "s1", PodID: "pod-alice-dev", UserIdentifier: "alice"}
+	var stdin bytes.Buffer
+	hub := newWebShellHub(session, nil, &stdin)
+	guestServerSide, guestClient := newWebSocketPair(t)
+
+	guest := newWebShellGuest(guestServerSide, "bob", webShellShareReadOnly)
+	if !hub.join(guest) {
+		t.Fatalf("expected guest to join an open session")
+	}
+	done := make(chan struct{})
+	go func() {
+		hub.serveGuest(guest)
+		close(done)
+	}()
+	if err := guestClient.WriteMessage(websocket.BinaryMessage, []byte("rm -rf /\n")); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	_ = guestClient.Close()
+	<-done
+
+	if stdin.Len() != 0 {
+		t.Fatalf("expected read-only input to be dropped, got %q", stdin.String())
+	}
+	if participants := hub.Participants(); len(participants) != 1 || participants[0].LeftAt == nil {
+		t.Fatalf("expected audit entry with leave time, got %+v", participants)
+	}
+}
+
+func TestWebShellBroadcastDropsSlowGuest(t *testing.T) {
+	session := WebShellSession{ID: "s1", PodID: "pod-alice-dev", UserIdentifier: "alice"}
+	hub := newWebShellHub(session, nil, io.Discard)
+	slowServerSide, slowClient := newWebSocketPair(t)
+	fastServerSide, fastClient := newWebSocketPair(t)
+	slow := newWebShellGuest(slowServerSide, "bob", webShellShareReadOnly)
+	fast := newWebShellGuest(fastServerSide, "carol", webShellShareReadOnly)
+	if !hub.join(slow) || !hub.join(fast) {
+		t.Fatalf("expected guests to join")
+	}
+
+	// 快访客持续读取，慢访客从不读取
+	received := make(chan int, 1)
+	go func() {
+		total := 0
+		for {
+			_, payload, err := fastClient.ReadMessage()
+			if err != nil {
+				received <- total
+				return
+			}
+			total += len(payload)
+		}
+	}()
+
+	chunk := bytes.Repeat([]byte("x"), 32<<10)
+	rounds := 4 * webShellGuestSendBuffer
+	start := time.Now()
+	for i := 0; i < rounds; i++ {
+		hub.broadcast(chunk)
+		// 给快访客的写协程留出消费时间，模拟终端持续输出
+		if i%(webShellGuestSendBuffer/2) == 0 {
+			time.Sleep(50 * time.Millisecond)
+		}
+	}
+	if elapsed := time.Since(start); elapsed > webShellGuestWriteTimeout {
+		t.Fatalf("broadcast blocked on slow guest for %s", elapsed)
+	}
+
+	hub.mu.Lock()
+	_, slowJoined := hub.guests[slow]
+	_, fastJoined := hub.guests[fast]
+	hub.mu.Unlock()
+	if slowJoined || !fastJoined {
+		t.Fatalf("expected only the slow guest to be dropped, slow=%v fast=%v", slowJoined, fastJoined)
+	}
+	select {
+	case <-slow.done:
+	default:
+		t.Fatalf("expected slow guest to be stopped")
+	}
+
+	hub.close()
+	if total := <-received; total != rounds*len(chunk) {
+		t.Fatalf("expected fast guest to receive all output, got %d of %d", total, rounds*len(chunk))
+	}
+	_ = slowClient.Close()
+}
@@ -0,0 +1,41 @@
+package handlers
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/gorilla/websocket"
+)
+
+// checkWebSocketOrigin 浏览器发起的 WebSocket 握手总会带 Origin，只允许与请求的 Host（或反向代理的 X-Forwarded-Host）
+// 以及前端地址（oauth.frontendURL）同源，防止其它网站借用户的 Cookie 打开隧道（跨站 WebSocket 劫持）。
+// genet CLI 等非浏览器客户端不带 Origin，依靠 Bearer token 认证
+func (h *PodHandler) checkWebSocketOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	originURL, err := url.Parse(origin)
+	if err != nil || originURL.Host == "" {
+		return false
+	}
+
+	allowed := []string{r.Host, r.Header.Get("X-Forwarded-Host")}
+	if h.config != nil {
+		if frontendURL, err := url.Parse(h.config.OAuth.FrontendURL); err == nil {
+			allowed = append(allowed, frontendURL.Host)
+		}
+	}
+	for _, host := range allowed {
+		if host != "" && strings.EqualFold(originURL.Host, host) {
+			return true
+		}
+	}
+	return false
+}
+
+// tunnelUpgrader 端口转发、SSH 隧道等把 Pod 端口暴露给客户端的 WebSocket 使用，校验 Origin
+func (h *PodHandler) tunnelUpgrader() *websocket.Upgrader {
+	return &websocket.Upgrader{CheckOrigin: h.checkWebSocketOrigin}
+}
@@ -0,0 +1,412 @@
+package handlers
+
+import (
+	"bufio"
+	"container/heap"
+	"context"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/uc-package/genet/internal/auth"
+	"github.com/uc-package/genet/internal/k8s"
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	workloadKindStatefulSet = "statefulset"
+	workloadKindDeployment  = "deployment"
+
+	// workloadLogReorderWindow 各副本日志到达有先后，缓冲该时长后按时间戳输出
+	workloadLogReorderWindow       = 300 * time.Millisecond
+	defaultWorkloadLogResyncPeriod = 5 * time.Second
+)
+
+// workloadLogLine 带副本信息的一行日志
+type workloadLogLine struct {
+	pod       string
+	ordinal   *int
+	content   string
+	cursor    string
+	timestamp time.Time
+	arrivedAt time.Time
+	seq       uint64
+}
+
+// workloadLogHeap 按时间戳排序；无时间戳的行按到达顺序
+type workloadLogHeap []*workloadLogLine
+
+func (q workloadLogHeap) Len() int { return len(q) }
+func (q workloadLogHeap) Less(i, j int) bool {
+	if !q[i].timestamp.Equal(q[j].timestamp) {
+		return q[i].timestamp.Before(q[j].timestamp)
+	}
+	return q[i].seq < q[j].seq
+}
+func (q workloadLogHeap) Swap(i, j int) { q[i], q[j] = q[j], q[i] }
+func (q *workloadLogHeap) Push(x any)   { *q = append(*q, x.(*workloadLogLine)) }
+func (q *workloadLogHeap) Pop() any {
+	old := *q
+	item := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return item
+}
+
+// workloadLogFollower 跟踪单个副本的日志流
+type workloadLogFollower struct {
+	cancel context.CancelFunc
+	done   chan struct{}
+	mu     sync.Mutex
+	cursor time.Time // 最后一行的时间戳，流中断后从此处续读
+	// atCursor 已输出的时间戳等于 cursor 的行数
+	atCursor int
+	// 续读时 SinceTime 只有秒级精度，会重放 resumeFrom 之后（含同一秒）的行：
+	// 早于 resumeFrom 的行和时间戳等于 resumeFrom 的前 resumeSkip 行已经输出过，需要丢弃
+	resumeFrom time.Time
+	resumeSkip int
+	// completed 日志流读到 EOF（容器已退出且日志已读完），而不是出错或被取消
+	completed bool
+}
+
+func (f *workloadLogFollower) lastCursor() time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.cursor
+}
+
+// accept 记录一行日志的时间戳并返回是否需要输出，续读时重放的行返回 false
+func (f *workloadLogFollower) accept(t time.Time) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if !f.resumeFrom.IsZero() {
+		if t.Before(f.resumeFrom) {
+			return false
+		}
+		if t.Equal(f.resumeFrom) && f.resumeSkip > 0 {
+			f.resumeSkip--
+			return false
+		}
+	}
+	switch {
+	case t.After(f.cursor):
+		f.cursor = t
+		f.atCursor = 1
+	case t.Equal(f.cursor):
+		f.atCursor++
+	}
+	return true
+}
+
+// resume 沿用上一个流的位置，丢弃续读时重放的行
+func (f *workloadLogFollower) resume(previous *workloadLogFollower) {
+	previous.mu.Lock()
+	defer previous.mu.Unlock()
+	f.cursor = previous.cursor
+	f.atCursor = previous.atCursor
+	f.resumeFrom = previous.cursor
+	f.resumeSkip = previous.atCursor
+}
+
+func (f *workloadLogFollower) streamCompleted() bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.completed
+}
+
+func (f *workloadLogFollower) finished() bool {
+	select {
+	case <-f.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// StatefulSetLogsWebSocket 合并 StatefulSet 所有副本的实时日志
+func (h *StatefulSetHandler) StatefulSetLogsWebSocket(c *gin.Context) {
+	h.podHandler.WorkloadLogsWebSocket(c, workloadKindStatefulSet)
+}
+
+// DeploymentLogsWebSocket 合并 Deployment 所有副本的实时日志
+func (h *DeploymentHandler) DeploymentLogsWebSocket(c *gin.Context) {
+	h.podHandler.WorkloadLogsWebSocket(c, workloadKindDeployment)
+}
+
+// WorkloadLogsWebSocket 跟踪工作负载的全部副本日志，每行标注 Pod 名称/序号并按时间戳合并；
+// 定期重新列出副本，新副本加入时开始跟踪，副本消失时发送 replica 事件
+func (h *PodHandler) WorkloadLogsWebSocket(c *gin.Context, kind string) {
+	username, _ := auth.GetUsername(c)
+	email, _ := auth.GetEmail(c)
+	userIdentifier := k8s.GetUserIdentifier(username, email)
+	namespace := k8s.GetNamespaceForUserIdentifier(userIdentifier)
+	name := c.Param("id")
+	sinceTime, err := parsePodLogSince(c.Query("since"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的 since 参数"})
+		return
+	}
+
+	pods, err := h.listWorkloadPods(c.Request.Context(), namespace, kind, name)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "工作负载不存在"})
+		return
+	}
+
+	conn, err := h.podLogsUpgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	ctx, cancel := context.WithCancel(c.Request.Context())
+	defer cancel()
+	go func() {
+		// 客户端不发送数据，读失败即表示断开
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				cancel()
+				return
+			}
+		}
+	}()
+
+	h.log.Debug("Streaming workload logs",
+		zap.String("user", username),
+		zap.String("kind", kind),
+		zap.String("workload", name))
+
+	lines := make(chan *workloadLogLine, 256)
+	followers := make(map[string]*workloadLogFollower)
+	defer func() {
+		for _, follower := range followers {
+			follower.cancel()
+		}
+	}()
+
+	var seq uint64
+	pending := &workloadLogHeap{}
+	writeErr := false
+	send := func(msg podLogStreamMessage) {
+		if writeErr {
+			return
+		}
+		if err := conn.WriteJSON(msg); err != nil {
+			writeErr = true
+			cancel()
+		}
+	}
+	flush := func(all bool) {
+		cutoff := time.Now().Add(-workloadLogReorderWindow)
+		for pending.Len() > 0 {
+			next := (*pending)[0]
+			if !all && next.arrivedAt.After(cutoff) {
+				return
+			}
+			heap.Pop(pending)
+			send(podLogStreamMessage{
+				Type:    "chunk",
+				Pod:     next.pod,
+				Ordinal: next.ordinal,
+				Content: next.content,
+				Cursor:  next.cursor,
+			})
+		}
+	}
+
+	syncReplicas := func(pods []corev1.Pod) {
+		present := make(map[string]bool, len(pods))
+		for i := range pods {
+			pod := &pods[i]
+			present[pod.Name] = true
+			if pod.Status.Phase == corev1.PodPending || (pod.DeletionTimestamp != nil && followers[pod.Name] == nil) {
+				continue
+			}
+			follower, ok := followers[pod.Name]
+			if ok && !follower.finished() {
+				continue
+			}
+			// 已退出（或正在删除）的副本日志已读完，不再每次同步都重新跟踪
+			if ok && follower.streamCompleted() && workloadPodTerminated(pod) {
+				continue
+			}
+			since := sinceTime
+			if ok {
+				// 容器重启等导致流中断：从最后一行所在的秒续读，重放的行由 follower 按游标丢弃
+				if cursor := follower.lastCursor(); !cursor.IsZero() {
+					since = &cursor
+				}
+			} else {
+				send(podLogStreamMessage{Type: "replica", Pod: pod.Name, Ordinal: workloadPodOrdinal(kind, pod.Name), Message: "joined"})
+			}
+			followers[pod.Name] = h.followWorkloadPodLogs(ctx, namespace, pod.Name, workloadPodOrdinal(kind, pod.Name), since, follower, lines)
+		}
+		for podName, follower := range followers {
+			if present[podName] {
+				continue
+			}
+			follower.cancel()
+			delete(followers, podName)
+			send(podLogStreamMessage{Type: "replica", Pod: podName, Ordinal: workloadPodOrdinal(kind, podName), Message: "left"})
+		}
+	}
+	syncReplicas(pods)
+
+	resyncPeriod := h.workloadLogResyncPeriod
+	if resyncPeriod <= 0 {
+		resyncPeriod = defaultWorkloadLogResyncPeriod
+	}
+	resync := time.NewTicker(resyncPeriod)
+	defer resync.Stop()
+	flushTicker := time.NewTicker(workloadLogReorderWindow / 3)
+	defer flushTicker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case line := <-lines:
+			seq++
+			line.seq = seq
+			heap.Push(pending, line)
+		case <-flushTicker.C:
+			flush(false)
+		case <-resync.C:
+			pods, err := h.listWorkloadPods(ctx, namespace, kind, name)
+			if err != nil {
+				if ctx.Err() != nil {
+					return
+				}
+				// 工作负载被删除：输出剩余日志后结束
+				flush(true)
+				send(podLogStreamMessage{Type: "error", Message: "工作负载不存在"})
+				return
+			}
+			syncReplicas(pods)
+		}
+		if writeErr {
+			return
+		}
+	}
+}
+
+// followWorkloadPodLogs 启动单个副本的 follow 日志流；previous 非空时沿用其续读位置
+func (h *PodHandler) followWorkloadPodLogs(ctx context.Context, namespace, podName string, ordinal *int, since *time.Time, previous *workloadLogFollower, lines chan<- *workloadLogLine) *workloadLogFollower {
+	followCtx, cancel := context.WithCancel(ctx)
+	follower := &workloadLogFollower{cancel: cancel, done: make(chan struct{})}
+	if previous != nil {
+		follower.resume(previous)
+	}
+
+	go func() {
+		defer close(follower.done)
+		defer cancel()
+		stream, err := h.streamPodLogs(followCtx, namespace, podName, k8s.PodLogOptions{
+			Follow:     true,
+			Timestamps: true,
+			SinceTime:  since,
+		})
+		if err != nil {
+			h.log.Debug("Failed to follow replica logs", zap.String("pod", podName), zap.Error(err))
+			return
+		}
+		defer stream.Close()
+		go func() {
+			<-followCtx.Done()
+			_ = stream.Close()
+		}()
+
+		reader := bufio.NewReader(stream)
+		for {
+			segment, readErr := reader.ReadString('\n')
+			if segment != "" {
+				content, cursor := parseTimestampedPodLogLine(segment)
+				line := &workloadLogLine{
+					pod:       podName,
+					ordinal:   ordinal,
+					content:   content,
+					cursor:    cursor,
+					arrivedAt: time.Now(),
+				}
+				emit := true
+				if parsed, err := time.Parse(time.RFC3339Nano, cursor); err == nil {
+					line.timestamp = parsed
+					emit = follower.accept(parsed)
+				} else {
+					line.timestamp = line.arrivedAt
+				}
+				if emit {
+					select {
+					case lines <- line:
+					case <-followCtx.Done():
+						return
+					}
+				}
+			}
+			if readErr != nil {
+				if readErr == io.EOF && followCtx.Err() == nil {
+					follower.mu.Lock()
+					follower.completed = true
+					follower.mu.Unlock()
+				}
+				return
+			}
+		}
+	}()
+	return follower
+}
+
+func (h *PodHandler) listWorkloadPods(ctx context.Context, namespace, kind, name string) ([]corev1.Pod, error) {
+	if h.listWorkloadPodsFn != nil {
+		return h.listWorkloadPodsFn(ctx, namespace, kind, name)
+	}
+	var (
+		pods []corev1.Pod
+		err  error
+	)
+	switch kind {
+	case workloadKindStatefulSet:
+		if _, err = h.k8sClient.GetStatefulSet(ctx, namespace, name); err != nil {
+			return nil, err
+		}
+		pods, err = h.k8sClient.ListStatefulSetPods(ctx, namespace, name)
+	default:
+		if _, err = h.k8sClient.GetDeployment(ctx, namespace, name); err != nil {
+			return nil, err
+		}
+		pods, err = h.k8sClient.ListDeploymentPods(ctx, namespace, name)
+	}
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(pods, func(i, j int) bool {
+		return pods[i].Name < pods[j].Name
+	})
+	return pods, nil
+}
+
+// workloadPodTerminated 副本已结束或正在删除，容器不会再产生新日志
+func workloadPodTerminated(pod *corev1.Pod) bool {
+	return pod.DeletionTimestamp != nil || pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed
+}
+
+// workloadPodOrdinal StatefulSet 副本名以序号结尾（name-0）；Deployment 副本没有序号
+func workloadPodOrdinal(kind, podName string) *int {
+	if kind != workloadKindStatefulSet {
+		return nil
+	}
+	idx := strings.LastIndexByte(podName, '-')
+	if idx < 0 {
+		return nil
+	}
+	ordinal, err := strconv.Atoi(podName[idx+1:])
+	if err != nil {
+		return nil
+	}
+	return &ordinal
+}
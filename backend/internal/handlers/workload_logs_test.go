@@ -0,0 +1,237 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/uc-package/genet/internal/auth"
+	"github.com/uc-package/genet/internal/k8s"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func newWorkloadLogTestPod(name string) corev1.Pod {
+	return corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "user-alice-alice"},
+		Status:     corev1.PodStatus{Phase: corev1.PodRunning},
+	}
+}
+
+func TestStatefulSetLogsMergesReplicasByTimestamp(t *testing.T) {
+	handler, _ := newPodProxyTestRouter(t, newPodProxyTestPod("10.0.0.8", ""), "")
+	handler.workloadLogResyncPeriod = 50 * time.Millisecond
+
+	var mu sync.Mutex
+	replicas := []corev1.Pod{newWorkloadLogTestPod("train-0"), newWorkloadLogTestPod("train-1")}
+	handler.listWorkloadPodsFn = func(_ context.Context, namespace, kind, name string) ([]corev1.Pod, error) {
+		if kind != workloadKindStatefulSet || name != "train" {
+			return nil, errors.New("not found")
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]corev1.Pod(nil), replicas...), nil
+	}
+	logs := map[string]string{
+		"train-0": "2026-03-15T10:00:01Z rank0 step1\n2026-03-15T10:00:03Z rank0 step2\n",
+		"train-1": "2026-03-15T10:00:02Z rank1 step1\n2026-03-15T10:00:04Z rank1 step2\n",
+		"train-2": "2026-03-15T10:00:05Z rank2 ready\n",
+	}
+	handler.streamPodLogsFn = func(_ context.Context, _ string, name string, options k8s.PodLogOptions) (io.ReadCloser, error) {
+		if !options.Follow || !options.Timestamps {
+			t.Errorf("expected follow with timestamps, got %+v", options)
+		}
+		if options.SinceTime != nil {
+			// 续读时已无新日志
+			return io.NopCloser(strings.NewReader("")), nil
+		}
+		return io.NopCloser(strings.NewReader(logs[name])), nil
+	}
+
+	router := gin.New()
+	sts := &StatefulSetHandler{podHandler: handler}
+	router.GET("/api/statefulsets/:id/logs/stream", auth.AuthMiddleware(handler.config), sts.StatefulSetLogsWebSocket)
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	header := http.Header{}
+	header.Set("X-Auth-Request-User", "alice")
+	header.Set("X-Auth-Request-Email", "alice@example.com")
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/api/statefulsets/train/logs/stream", header)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer conn.Close()
+
+	read := func() podLogStreamMessage {
+		t.Helper()
+		_ = conn.SetReadDeadline(time.Now().Add(3 * time.Second))
+		var msg podLogStreamMessage
+		if err := conn.ReadJSON(&msg); err != nil {
+			t.Fatalf("read: %v", err)
+		}
+		return msg
+	}
+
+	for i := 0; i < 2; i++ {
+		if msg := read(); msg.Type != "replica" || msg.Message != "joined" {
+			t.Fatalf("expected joined event, got %+v", msg)
+		}
+	}
+	var merged []string
+	for i := 0; i < 4; i++ {
+		msg := read()
+		if msg.Type != "chunk" || msg.Ordinal == nil {
+			t.Fatalf("expected tagged chunk, got %+v", msg)
+		}
+		merged = append(merged, msg.Pod+":"+strings.TrimSpace(msg.Content))
+	}
+	want := "train-0:rank0 step1,train-1:rank1 step1,train-0:rank0 step2,train-1:rank1 step2"
+	if got := strings.Join(merged, ","); got != want {
+		t.Fatalf("unexpected merge order:\n got %s\nwant %s", got, want)
+	}
+
+	// 扩容出新副本，缩容移除旧副本
+	mu.Lock()
+	replicas = []corev1.Pod{newWorkloadLogTestPod("train-0"), newWorkloadLogTestPod("train-2")}
+	mu.Unlock()
+
+	events := map[string]bool{}
+	for len(events) < 3 {
+		msg := read()
+		switch {
+		case msg.Type == "replica":
+			events[msg.Pod+":"+msg.Message] = true
+		case msg.Type == "chunk" && msg.Pod == "train-2":
+			if msg.Ordinal == nil || *msg.Ordinal != 2 {
+				t.Fatalf("expected ordinal 2, got %+v", msg.Ordinal)
+			}
+			events["train-2:chunk"] = true
+		}
+	}
+	for _, key := range []string{"train-2:joined", "train-1:left", "train-2:chunk"} {
+		if !events[key] {
+			t.Fatalf("missing event %s in %+v", key, events)
+		}
+	}
+}
+
+func TestWorkloadLogsRejectsUnknownWorkload(t *testing.T) {
+	handler, _ := newPodProxyTestRouter(t, newPodProxyTestPod("10.0.0.8", ""), "")
+	handler.listWorkloadPodsFn = func(context.Context, string, string, string) ([]corev1.Pod, error) {
+		return nil, errors.New("not found")
+	}
+	router := gin.New()
+	deploy := &DeploymentHandler{podHandler: handler}
+	router.GET("/api/deployments/:id/logs/stream", auth.AuthMiddleware(handler.config), deploy.DeploymentLogsWebSocket)
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, newPodProxyTestRequest(http.MethodGet, "/api/deployments/missing/logs/stream", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("expected 404, got %d", rec.Code)
+	}
+}
+
+func TestWorkloadPodOrdinal(t *testing.T) {
+	if ordinal := workloadPodOrdinal(workloadKindStatefulSet, "train-12"); ordinal == nil || *ordinal != 12 {
+		t.Fatalf("unexpected ordinal %v", ordinal)
+	}
+	if ordinal := workloadPodOrdinal(workloadKindDeployment, "serve-7d9c-abcde"); ordinal != nil {
+		t.Fatalf("expected no ordinal for deployment pods, got %v", *ordinal)
+	}
+}
+
+func TestWorkloadLogFollowerResumeSkipsReplayedLines(t *testing.T) {
+	at := func(s string) time.Time {
+		parsed, _ := time.Parse(time.RFC3339Nano, s)
+		return parsed
+	}
+	first := &workloadLogFollower{}
+	for _, ts := range []string{"2026-03-15T10:00:01.5Z", "2026-03-15T10:00:01.7Z", "2026-03-15T10:00:01.7Z"} {
+		if !first.accept(at(ts)) {
+			t.Fatalf("first stream should emit %s", ts)
+		}
+	}
+
+	// SinceTime 按秒截断，续读会从 10:00:01 重放
+	resumed := &workloadLogFollower{}
+	resumed.resume(first)
+	replay := []struct {
+		ts   string
+		emit bool
+	}{
+		{"2026-03-15T10:00:01.5Z", false},
+		{"2026-03-15T10:00:01.7Z", false},
+		{"2026-03-15T10:00:01.7Z", false},
+		{"2026-03-15T10:00:01.7Z", true}, // 同一时间戳的新行
+		{"2026-03-15T10:00:02Z", true},
+	}
+	for i, line := range replay {
+		if got := resumed.accept(at(line.ts)); got != line.emit {
+			t.Fatalf("line %d (%s): expected emit=%t, got %t", i, line.ts, line.emit, got)
+		}
+	}
+	if !resumed.lastCursor().Equal(at("2026-03-15T10:00:02Z")) {
+		t.Fatalf("unexpected cursor %s", resumed.lastCursor())
+	}
+}
+
+func TestWorkloadLogsDoesNotRefollowFinishedReplicas(t *testing.T) {
+	handler, _ := newPodProxyTestRouter(t, newPodProxyTestPod("10.0.0.8", ""), "")
+	handler.workloadLogResyncPeriod = 20 * time.Millisecond
+
+	done := newWorkloadLogTestPod("train-0")
+	done.Status.Phase = corev1.PodSucceeded
+	handler.listWorkloadPodsFn = func(context.Context, string, string, string) ([]corev1.Pod, error) {
+		return []corev1.Pod{done}, nil
+	}
+	var mu sync.Mutex
+	streams := 0
+	handler.streamPodLogsFn = func(context.Context, string, string, k8s.PodLogOptions) (io.ReadCloser, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		streams++
+		return io.NopCloser(strings.NewReader("2026-03-15T10:00:01Z finished\n")), nil
+	}
+
+	router := gin.New()
+	sts := &StatefulSetHandler{podHandler: handler}
+	router.GET("/api/statefulsets/:id/logs/stream", auth.AuthMiddleware(handler.config), sts.StatefulSetLogsWebSocket)
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	header := http.Header{}
+	header.Set("X-Auth-Request-User", "alice")
+	header.Set("X-Auth-Request-Email", "alice@example.com")
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/api/statefulsets/train/logs/stream", header)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer conn.Close()
+
+	chunks := 0
+	deadline := time.Now().Add(workloadLogReorderWindow + 500*time.Millisecond)
+	for {
+		_ = conn.SetReadDeadline(deadline)
+		var msg podLogStreamMessage
+		if err := conn.ReadJSON(&msg); err != nil {
+			break
+		}
+		if msg.Type == "chunk" {
+			chunks++
+		}
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if streams != 1 || chunks != 1 {
+		t.Fatalf("expected finished replica to be followed once, got %d streams and %d chunks", streams, chunks)
+	}
+}
@@ -0,0 +1,456 @@
+package imagegc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/uc-package/genet/internal/k8s"
+	"github.com/uc-package/genet/internal/logger"
+	"github.com/uc-package/genet/internal/models"
+	"github.com/uc-package/genet/internal/registry"
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	defaultIntervalHours = 24
+
+	// 删除原因
+	ReasonKeepLast       = "keep-last"
+	ReasonSuspendResumed = "suspend-resumed"
+	ReasonMaxSize        = "max-size"
+
+	// 触发方式
+	TriggerAdmin    = "admin"
+	TriggerSchedule = "schedule"
+
+	// suspendRepositoryPrefix 定时挂起快照的仓库名前缀，见 cleanup.buildSuspendImageName
+	suspendRepositoryPrefix = "suspend-"
+)
+
+// ErrRunning 已有 GC 在运行
+var ErrRunning = errors.New("image gc is already running")
+
+// Collector 按保留策略清理 registry 中的用户镜像
+type Collector struct {
+	k8sClient  *k8s.Client
+	registries *registry.Set
+	config     *models.Config
+	log        *zap.Logger
+	nowFn      func() time.Time
+
+	running    sync.Mutex
+	mu         sync.RWMutex
+	lastReport *models.ImageGCReport
+}
+
+// NewCollector 创建镜像 GC
+func NewCollector(config *models.Config, k8sClient *k8s.Client, registries *registry.Set) *Collector {
+	return &Collector{
+		k8sClient:  k8sClient,
+		registries: registries,
+		config:     config,
+		log:        logger.Named("imagegc"),
+		nowFn:      time.Now,
+	}
+}
+
+// Start 按 imageGC.intervalHours 定时执行 GC；未启用时不做任何事
+func (c *Collector) Start(ctx context.Context) {
+	if !c.config.ImageGC.Enabled {
+		return
+	}
+	interval := time.Duration(c.config.ImageGC.IntervalHours) * time.Hour
+	if interval <= 0 {
+		interval = defaultIntervalHours * time.Hour
+	}
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			if _, err := c.Run(ctx, false, TriggerSchedule); err != nil {
+				c.log.Warn("Scheduled image gc failed", zap.Error(err))
+			}
+		}
+	}()
+}
+
+// LastReport 最近一次 GC（含 dry-run）的结果，尚未运行过时返回 nil
+func (c *Collector) LastReport() *models.ImageGCReport {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.lastReport
+}
+
+// Run 执行一次 GC；dryRun 时只返回候选镜像。同一时间只允许一次 GC，否则返回 ErrRunning
+func (c *Collector) Run(ctx context.Context, dryRun bool, trigger string) (*models.ImageGCReport, error) {
+	if !c.registries.IsConfigured() {
+		return nil, fmt.Errorf("registry not configured")
+	}
+	if !c.running.TryLock() {
+		return nil, ErrRunning
+	}
+	defer c.running.Unlock()
+
+	report := &models.ImageGCReport{
+		DryRun:     dryRun,
+		Trigger:    trigger,
+		StartedAt:  c.nowFn(),
+		Candidates: []models.ImageGCCandidate{},
+	}
+
+	inUse, err := c.collectInUseImages(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	namespaces, err := c.k8sClient.GetClientset().CoreV1().Namespaces().List(ctx, metav1.ListOptions{
+		LabelSelector: "genet.io/managed=true",
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list namespaces: %w", err)
+	}
+
+	// 先收集所有用户的镜像：按 tag 引用的在用镜像换算成 digest，保护指向同一 digest 的其它 tag
+	type namespaceImages struct {
+		namespace string
+		entries   []imageEntry
+	}
+	var collected []namespaceImages
+	for _, ns := range namespaces.Items {
+		if !strings.HasPrefix(ns.Name, "user-") {
+			continue
+		}
+		entries, err := c.userImages(ctx, ns.Name)
+		if err != nil {
+			report.Errors = append(report.Errors, fmt.Sprintf("%s: %v", ns.Name, err))
+			continue
+		}
+		report.Scanned += len(entries)
+		for _, entry := range entries {
+			if inUse.images[entry.Image] {
+				inUse.digests[entry.Digest] = true
+			}
+		}
+		collected = append(collected, namespaceImages{namespace: ns.Name, entries: entries})
+	}
+
+	for _, item := range collected {
+		ns := item.namespace
+		for _, entry := range planDeletions(item.entries, inUse, c.config.ImageGC) {
+			candidate := models.ImageGCCandidate{
+				Username:  strings.TrimPrefix(ns, "user-"),
+				Namespace: ns,
+				Image:     entry.Image,
+				Reason:    entry.reason,
+				Size:      entry.Size,
+				SavedAt:   entry.SavedAt,
+			}
+			if !dryRun {
+				if err := c.deleteImage(ctx, ns, entry); err != nil {
+					candidate.Error = err.Error()
+					report.Failed++
+				} else {
+					candidate.Deleted = true
+					report.Deleted++
+					report.FreedBytes += entry.Size
+				}
+			}
+			report.Candidates = append(report.Candidates, candidate)
+		}
+	}
+
+	report.FinishedAt = c.nowFn()
+	c.log.Info("Image gc finished",
+		zap.Bool("dryRun", dryRun),
+		zap.String("trigger", trigger),
+		zap.Int("scanned", report.Scanned),
+		zap.Int("candidates", len(report.Candidates)),
+		zap.Int("deleted", report.Deleted),
+		zap.Int("failed", report.Failed))
+
+	c.mu.Lock()
+	c.lastReport = report
+	c.mu.Unlock()
+	return report, nil
+}
+
+// imageEntry 参与评估的用户镜像
+type imageEntry struct {
+	models.UserSavedImage
+	Repository string
+	Reference  string
+	// Digest 评估时从 registry 解析的 tag 当前指向的 manifest；保存历史在用户命名空间中可被改写，其中的 digest 不可信
+	Digest string
+	Size   int64
+	reason string
+	client registry.Client
+}
+
+// inUseSet 正在使用的镜像，按镜像名与 digest 两种方式匹配
+type inUseSet struct {
+	images  map[string]bool
+	digests map[string]bool
+}
+
+func (s inUseSet) contains(entry imageEntry) bool {
+	return s.images[entry.Image] || (entry.Digest != "" && s.digests[entry.Digest])
+}
+
+// userImages 返回用户镜像列表中由 Genet 成功推送到 push 仓库、位于该用户自己推送路径（<host>/<userIdentifier>/...）下的镜像；
+// 用户手动添加的镜像（如公共基础镜像）不在保存历史中，其他路径下的镜像可能属于别人，都不会被 GC。
+// 镜像列表与保存历史都可被用户改写，digest 一律从 registry 重新解析，registry 中已不存在的 tag 直接跳过
+func (c *Collector) userImages(ctx context.Context, namespace string) ([]imageEntry, error) {
+	saved, err := c.k8sClient.GetUserImages(ctx, namespace)
+	if err != nil {
+		return nil, err
+	}
+	history, err := c.k8sClient.GetCommitHistory(ctx, namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	// 同一 tag 推送过多次时以最近一次为准
+	pushed := make(map[string]models.CommitRecord)
+	for _, record := range history.Records {
+		if record.Status != "Succeeded" {
+			continue
+		}
+		if existing, ok := pushed[record.TargetImage]; !ok || record.CreatedAt.After(existing.CreatedAt) {
+			pushed[record.TargetImage] = record
+		}
+	}
+
+	ownPrefix := strings.TrimPrefix(namespace, "user-") + "/"
+	entries := make([]imageEntry, 0, len(saved.Images))
+	for _, image := range saved.Images {
+		record, ok := pushed[image.Image]
+		if !ok {
+			continue
+		}
+		registryEntry, repository, reference, ok := c.registries.ResolveEntry(image.Image)
+		if !ok || !registryEntry.Config.HasPurpose(models.RegistryPurposePush) || !strings.HasPrefix(repository, ownPrefix) {
+			continue
+		}
+		digest, err := resolveDigest(ctx, registryEntry.Client, repository, reference)
+		if err != nil {
+			if !errors.Is(err, registry.ErrNotFound) {
+				c.log.Warn("Failed to resolve image digest",
+					zap.String("namespace", namespace),
+					zap.String("image", image.Image),
+					zap.Error(err))
+			}
+			continue
+		}
+		entries = append(entries, imageEntry{
+			UserSavedImage: image,
+			Repository:     repository,
+			Reference:      reference,
+			Digest:         digest,
+			Size:           record.Size,
+			client:         registryEntry.Client,
+		})
+	}
+	return entries, nil
+}
+
+// resolveDigest 从 registry 查询 reference 当前指向的 manifest digest
+func resolveDigest(ctx context.Context, client registry.Client, repository, reference string) (string, error) {
+	manifest, err := client.GetManifest(ctx, repository, reference)
+	if err != nil {
+		return "", err
+	}
+	if manifest.Digest == "" {
+		return "", fmt.Errorf("registry returned no digest for %s:%s", repository, reference)
+	}
+	return manifest.Digest, nil
+}
+
+// collectInUseImages 收集所有命名空间中 Pod、Deployment、StatefulSet、Job 引用的镜像及预置镜像，
+// 以及运行中容器实际使用的镜像 digest
+func (c *Collector) collectInUseImages(ctx context.Context) (inUseSet, error) {
+	clientset := c.k8sClient.GetClientset()
+	inUse := inUseSet{images: make(map[string]bool), digests: make(map[string]bool)}
+	addImage := func(image string) {
+		inUse.images[image] = true
+		if digest := imageDigest(image); digest != "" {
+			inUse.digests[digest] = true
+		}
+	}
+	addSpec := func(spec corev1.PodSpec) {
+		for _, container := range spec.InitContainers {
+			addImage(container.Image)
+		}
+		for _, container := range spec.Containers {
+			addImage(container.Image)
+		}
+	}
+
+	pods, err := clientset.CoreV1().Pods(metav1.NamespaceAll).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return inUse, fmt.Errorf("failed to list pods: %w", err)
+	}
+	for _, pod := range pods.Items {
+		addSpec(pod.Spec)
+		for _, status := range append(pod.Status.InitContainerStatuses, pod.Status.ContainerStatuses...) {
+			if digest := imageDigest(status.ImageID); digest != "" {
+				inUse.digests[digest] = true
+			}
+		}
+	}
+
+	deployments, err := clientset.AppsV1().Deployments(metav1.NamespaceAll).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return inUse, fmt.Errorf("failed to list deployments: %w", err)
+	}
+	for _, deploy := range deployments.Items {
+		addSpec(deploy.Spec.Template.Spec)
+		if image := deploy.Annotations["genet.io/suspended-image"]; image != "" && deploy.Annotations["genet.io/suspended"] == "true" {
+			addImage(image)
+		}
+	}
+
+	statefulSets, err := clientset.AppsV1().StatefulSets(metav1.NamespaceAll).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return inUse, fmt.Errorf("failed to list statefulsets: %w", err)
+	}
+	for _, sts := range statefulSets.Items {
+		addSpec(sts.Spec.Template.Spec)
+		if image := sts.Annotations["genet.io/suspended-image"]; image != "" && sts.Annotations["genet.io/suspended"] == "true" {
+			addImage(image)
+		}
+	}
+
+	jobs, err := clientset.BatchV1().Jobs(metav1.NamespaceAll).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return inUse, fmt.Errorf("failed to list jobs: %w", err)
+	}
+	for _, job := range jobs.Items {
+		addSpec(job.Spec.Template.Spec)
+	}
+
+	for _, preset := range c.config.PresetImages {
+		addImage(preset.Image)
+	}
+	return inUse, nil
+}
+
+// imageDigest 从 repo@sha256:... 或容器状态的 imageID（如 docker-pullable://repo@sha256:...）中取出 digest
+func imageDigest(image string) string {
+	if i := strings.LastIndex(image, "@"); i >= 0 {
+		return image[i+1:]
+	}
+	if strings.HasPrefix(image, "sha256:") {
+		return image
+	}
+	return ""
+}
+
+// deleteImage 删除前重新从 registry 解析 tag 的 digest，与评估时一致才按 digest 删除并移出用户镜像列表；
+// tag 在评估后被重新推送时放弃删除，避免误删新镜像
+func (c *Collector) deleteImage(ctx context.Context, namespace string, entry imageEntry) error {
+	digest, err := resolveDigest(ctx, entry.client, entry.Repository, entry.Reference)
+	if err != nil {
+		return fmt.Errorf("failed to resolve digest: %w", err)
+	}
+	if digest != entry.Digest {
+		return fmt.Errorf("tag %s now points to %s, skipped", entry.Reference, digest)
+	}
+	if err := entry.client.DeleteManifest(ctx, entry.Repository, digest); err != nil {
+		c.log.Warn("Failed to delete image from registry",
+			zap.String("namespace", namespace),
+			zap.String("image", entry.Image),
+			zap.String("digest", digest),
+			zap.Error(err))
+		return err
+	}
+	return c.k8sClient.DeleteUserImage(ctx, namespace, entry.Image)
+}
+
+// planDeletions 按保留策略选出一个用户要删除的镜像，正在使用的镜像永远保留：
+//  1. 仓库名以 suspend- 开头且不再被引用的挂起快照（工作负载已恢复并再次挂起或已删除）
+//  2. 每个仓库超出最新 KeepLastTags 个的镜像（使用中的镜像也计入保留数）
+//  3. 总大小超过 MaxTotalSizeGB 时从最旧的开始删除
+func planDeletions(entries []imageEntry, inUse inUseSet, policy models.ImageGCConfig) []imageEntry {
+	sorted := append([]imageEntry(nil), entries...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].SavedAt.After(sorted[j].SavedAt)
+	})
+
+	for i := range sorted {
+		entry := &sorted[i]
+		if inUse.contains(*entry) {
+			continue
+		}
+		if policy.DeleteSuspendAfterResume && isSuspendRepository(entry.Repository) {
+			entry.reason = ReasonSuspendResumed
+		}
+	}
+
+	if policy.KeepLastTags > 0 {
+		kept := make(map[string]int)
+		for i := range sorted {
+			entry := &sorted[i]
+			if entry.reason != "" {
+				continue
+			}
+			if kept[entry.Repository] < policy.KeepLastTags || inUse.contains(*entry) {
+				kept[entry.Repository]++
+				continue
+			}
+			entry.reason = ReasonKeepLast
+		}
+	}
+
+	if policy.MaxTotalSizeGB > 0 {
+		limit := int64(policy.MaxTotalSizeGB) << 30
+		var total int64
+		for _, entry := range sorted {
+			if entry.reason == "" {
+				total += entry.Size
+			}
+		}
+		for i := len(sorted) - 1; i >= 0 && total > limit; i-- {
+			entry := &sorted[i]
+			if entry.reason != "" || entry.Size == 0 || inUse.contains(*entry) {
+				continue
+			}
+			entry.reason = ReasonMaxSize
+			total -= entry.Size
+		}
+	}
+
+	// 按 digest 删除会连带删除同一 manifest 的其它 tag，保留的 tag 所指向的 digest 不能删
+	keptDigests := make(map[string]bool)
+	for _, entry := range sorted {
+		if entry.reason == "" {
+			keptDigests[entry.Repository+"@"+entry.Digest] = true
+		}
+	}
+	selected := make([]imageEntry, 0)
+	for _, entry := range sorted {
+		if entry.reason != "" && !keptDigests[entry.Repository+"@"+entry.Digest] {
+			selected = append(selected, entry)
+		}
+	}
+	return selected
+}
+
+func isSuspendRepository(repository string) bool {
+	name := repository
+	if i := strings.LastIndex(repository, "/"); i >= 0 {
+		name = repository[i+1:]
+	}
+	return strings.HasPrefix(name, suspendRepositoryPrefix)
+}
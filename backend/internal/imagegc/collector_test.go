@@ -0,0 +1,235 @@
+package imagegc
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/uc-package/genet/internal/k8s"
+	"github.com/uc-package/genet/internal/models"
+	"github.com/uc-package/genet/internal/registry"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+type fakeRegistry struct {
+	// manifests repository:reference -> digest
+	manifests   map[string]string
+	deletedTags []string
+}
+
+func (r *fakeRegistry) SearchImages(ctx context.Context, keyword string, limit int) ([]registry.ImageInfo, error) {
+	return nil, nil
+}
+
+func (r *fakeRegistry) GetImageTags(ctx context.Context, imageName string, platform string) ([]string, error) {
+	return nil, nil
+}
+
+func (r *fakeRegistry) GetManifest(ctx context.Context, repository, reference string) (*models.ImageManifest, error) {
+	digest, ok := r.manifests[repository+":"+reference]
+	if !ok {
+		return nil, registry.ErrNotFound
+	}
+	return &models.ImageManifest{Digest: digest}, nil
+}
+
+func (r *fakeRegistry) DeleteTag(ctx context.Context, repository, tag string) error {
+	r.deletedTags = append(r.deletedTags, repository+":"+tag)
+	return nil
+}
+
+func (r *fakeRegistry) DeleteManifest(ctx context.Context, repository, digest string) error {
+	r.deletedTags = append(r.deletedTags, repository+"@"+digest)
+	return nil
+}
+
+func (r *fakeRegistry) IsConfigured() bool {
+	return true
+}
+
+func TestPlanDeletionsKeepsLastTagsAndInUseImages(t *testing.T) {
+	now := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	entries := []imageEntry{
+		testEntry("alice/train", "v1", now.Add(-3*time.Hour), 0),
+		testEntry("alice/train", "v2", now.Add(-2*time.Hour), 0),
+		testEntry("alice/train", "v3", now.Add(-time.Hour), 0),
+		testEntry("alice/train", "v4", now, 0),
+		testEntry("alice/eval", "v1", now.Add(-5*time.Hour), 0),
+	}
+	inUse := testInUse("registry.local/alice/train:v1")
+
+	selected := planDeletions(entries, inUse, models.ImageGCConfig{KeepLastTags: 2})
+
+	if len(selected) != 1 || selected[0].Image != "registry.local/alice/train:v2" || selected[0].reason != ReasonKeepLast {
+		t.Fatalf("expected only train:v2 to be deleted, got %+v", selected)
+	}
+}
+
+func TestPlanDeletionsSuspendSnapshotsAndMaxSize(t *testing.T) {
+	now := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	entries := []imageEntry{
+		testEntry("alice/suspend-web", "20260901-230000", now.Add(-48*time.Hour), 1<<30),
+		testEntry("alice/suspend-web", "20260902-230000", now.Add(-24*time.Hour), 1<<30),
+		testEntry("alice/train", "v1", now.Add(-72*time.Hour), 2<<30),
+		testEntry("alice/train", "v2", now.Add(-time.Hour), 2<<30),
+	}
+	inUse := testInUse("registry.local/alice/suspend-web:20260902-230000")
+
+	selected := planDeletions(entries, inUse, models.ImageGCConfig{
+		DeleteSuspendAfterResume: true,
+		MaxTotalSizeGB:           3,
+	})
+
+	reasons := make(map[string]string)
+	for _, entry := range selected {
+		reasons[entry.Image] = entry.reason
+	}
+	if len(reasons) != 2 ||
+		reasons["registry.local/alice/suspend-web:20260901-230000"] != ReasonSuspendResumed ||
+		reasons["registry.local/alice/train:v1"] != ReasonMaxSize {
+		t.Fatalf("unexpected deletions: %+v", reasons)
+	}
+}
+
+func TestPlanDeletionsProtectsSharedAndInUseDigests(t *testing.T) {
+	now := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	v1 := testEntry("alice/train", "v1", now.Add(-3*time.Hour), 0)
+	v2 := testEntry("alice/train", "v2", now.Add(-2*time.Hour), 0)
+	latest := testEntry("alice/train", "latest", now, 0)
+	// latest 与 v1 指向同一 manifest
+	latest.Digest = v1.Digest
+	eval := testEntry("alice/eval", "v1", now.Add(-5*time.Hour), 0)
+	eval2 := testEntry("alice/eval", "v2", now.Add(-4*time.Hour), 0)
+	inUse := testInUse()
+	// 运行中的容器按 digest 使用 eval:v1（tag 之后已被覆盖）
+	inUse.digests[eval.Digest] = true
+
+	selected := planDeletions([]imageEntry{v1, v2, latest, eval, eval2}, inUse, models.ImageGCConfig{KeepLastTags: 1})
+
+	if len(selected) != 1 || selected[0].Image != v2.Image {
+		t.Fatalf("expected only train:v2 to be deleted, got %+v", selected)
+	}
+}
+
+func TestRunDryRunReportsWithoutDeleting(t *testing.T) {
+	config := models.DefaultConfig()
+	config.Registry.URL = "registry.local"
+	config.ImageGC.KeepLastTags = 1
+
+	now := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	images := models.UserImageList{Images: []models.UserSavedImage{
+		{Image: "registry.local/alice/train:v2", SavedAt: now},
+		{Image: "registry.local/alice/train:v1", SavedAt: now.Add(-time.Hour)},
+		{Image: "registry.local/library/base:v0", SavedAt: now.Add(-2 * time.Hour)},
+		// registry 中已不存在的镜像不参与 GC
+		{Image: "registry.local/alice/old:v0", SavedAt: now.Add(-3 * time.Hour)},
+		// 其他用户推送路径下的镜像即使出现在列表与保存历史中也不参与 GC
+		{Image: "registry.local/bob/train:v1", SavedAt: now.Add(-4 * time.Hour)},
+		{Image: "registry.local/library/base:v1", SavedAt: now.Add(-5 * time.Hour)},
+	}}
+	history := models.CommitHistory{Records: []models.CommitRecord{
+		{ID: "commit-3", TargetImage: "registry.local/alice/old:v0", Status: "Succeeded", Size: 50},
+		{ID: "commit-2", TargetImage: "registry.local/alice/train:v2", Status: "Succeeded", Size: 200, Digest: "sha256:v2", CreatedAt: now},
+		// 保存历史可被用户改写，记录中的 digest 不可信
+		{ID: "commit-1", TargetImage: "registry.local/alice/train:v1", Status: "Succeeded", Size: 100, Digest: "sha256:bob-train-v1", CreatedAt: now.Add(-time.Hour)},
+		{ID: "commit-0", TargetImage: "registry.local/bob/train:v1", Status: "Succeeded", Size: 100, Digest: "sha256:bob-train-v1", CreatedAt: now.Add(-4 * time.Hour)},
+		{ID: "commit-9", TargetImage: "registry.local/library/base:v1", Status: "Succeeded", Size: 100, Digest: "sha256:base-v1", CreatedAt: now.Add(-5 * time.Hour)},
+	}}
+	imagesData, _ := json.Marshal(images)
+	historyData, _ := json.Marshal(history)
+
+	clientset := fake.NewSimpleClientset(
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+			Name:   "user-alice",
+			Labels: map[string]string{"genet.io/managed": "true"},
+		}},
+		&corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: k8s.UserImagesConfigMapName, Namespace: "user-alice"},
+			Data:       map[string]string{k8s.UserImagesDataKey: string(imagesData)},
+		},
+		&corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: k8s.CommitHistoryConfigMapName, Namespace: "user-alice"},
+			Data:       map[string]string{k8s.CommitHistoryDataKey: string(historyData)},
+		},
+	)
+	k8sClient := k8s.NewClientWithClientset(clientset, config)
+	fakeReg := &fakeRegistry{manifests: map[string]string{
+		"alice/train:v1":  "sha256:v1",
+		"alice/train:v2":  "sha256:v2",
+		"bob/train:v1":    "sha256:bob-train-v1",
+		"library/base:v0": "sha256:base-v0",
+		"library/base:v1": "sha256:base-v1",
+	}}
+	registries := &registry.Set{}
+	registries.Add(config.Registry, fakeReg)
+	collector := NewCollector(config, k8sClient, registries)
+
+	report, err := collector.Run(context.Background(), true, TriggerAdmin)
+	if err != nil {
+		t.Fatalf("dry run: %v", err)
+	}
+	if report.Scanned != 2 || len(report.Candidates) != 1 || report.Candidates[0].Image != "registry.local/alice/train:v1" {
+		t.Fatalf("unexpected dry-run report: %+v", report)
+	}
+	if len(fakeReg.deletedTags) != 0 {
+		t.Fatalf("dry run must not delete, got %v", fakeReg.deletedTags)
+	}
+
+	report, err = collector.Run(context.Background(), false, TriggerAdmin)
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if report.Deleted != 1 || report.FreedBytes != 100 || len(fakeReg.deletedTags) != 1 || fakeReg.deletedTags[0] != "alice/train@sha256:v1" {
+		t.Fatalf("unexpected report %+v, deleted %v", report, fakeReg.deletedTags)
+	}
+	remaining, err := k8sClient.GetUserImages(context.Background(), "user-alice")
+	if err != nil {
+		t.Fatalf("get user images: %v", err)
+	}
+	if len(remaining.Images) != 5 {
+		t.Fatalf("expected deleted image removed from user list, got %+v", remaining.Images)
+	}
+	if collector.LastReport() != report {
+		t.Fatalf("expected last report to be stored")
+	}
+}
+
+func testEntry(repository, tag string, savedAt time.Time, size int64) imageEntry {
+	image := "registry.local/" + repository + ":" + tag
+	return imageEntry{
+		UserSavedImage: models.UserSavedImage{Image: image, SavedAt: savedAt},
+		Repository:     repository,
+		Reference:      tag,
+		Digest:         "sha256:" + repository + "-" + tag,
+		Size:           size,
+	}
+}
+
+func testInUse(images ...string) inUseSet {
+	inUse := inUseSet{images: make(map[string]bool), digests: make(map[string]bool)}
+	for _, image := range images {
+		inUse.images[image] = true
+	}
+	return inUse
+}
+
+func TestDeleteImageSkipsRepushedTag(t *testing.T) {
+	config := models.DefaultConfig()
+	config.Registry.URL = "registry.local"
+	k8sClient := k8s.NewClientWithClientset(fake.NewSimpleClientset(), config)
+	fakeReg := &fakeRegistry{manifests: map[string]string{"alice/train:v1": "sha256:new"}}
+	collector := NewCollector(config, k8sClient, &registry.Set{})
+
+	entry := testEntry("alice/train", "v1", time.Now(), 0)
+	entry.Digest = "sha256:old"
+	entry.client = fakeReg
+	if err := collector.deleteImage(context.Background(), "user-alice", entry); err == nil {
+		t.Fatalf("expected re-pushed tag to be skipped")
+	}
+	if len(fakeReg.deletedTags) != 0 {
+		t.Fatalf("expected nothing deleted, got %v", fakeReg.deletedTags)
+	}
+}
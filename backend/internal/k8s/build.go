@@ -0,0 +1,272 @@
+package k8s
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilrand "k8s.io/apimachinery/pkg/util/rand"
+)
+
+const (
+	// defaultKanikoImage 未配置 images.kaniko 时使用的 Kaniko 镜像
+	defaultKanikoImage = "gcr.io/kaniko-project/executor:v1.23.2"
+	// buildDockerfileDir 内联 Dockerfile 在构建容器中的挂载目录
+	buildDockerfileDir = "/genet-build"
+)
+
+// ErrBuildPathOutsideWorkspace 构建上下文或 Dockerfile 不在任何工作空间存储卷内
+var ErrBuildPathOutsideWorkspace = errors.New("路径不在任何工作空间存储卷内")
+
+// BuildSpec Dockerfile 镜像构建规格
+type BuildSpec struct {
+	PodName        string // 源 Pod 名称（工作空间所属 Pod）
+	Namespace      string // Pod 所在 namespace
+	Username       string // 用户名
+	Email          string // 用户邮箱，与用户名一起决定推送仓库
+	UserIdentifier string // 用户标识，用于定位用户的存储卷
+	TargetImage    string // 目标镜像名称（包含 tag）
+	NodeName       string // Pod 所在节点
+	Dockerfile     string // 内联 Dockerfile 内容，与 DockerfilePath 二选一
+	DockerfilePath string // 工作空间内 Dockerfile 的绝对路径（Pod 内路径）
+	ContextPath    string // 工作空间内构建上下文目录的绝对路径（Pod 内路径）
+}
+
+// CreateBuildJob 创建 Dockerfile 镜像构建 Job
+// 使用 Kaniko 在用户态构建，不需要特权容器和 containerd socket；
+// 构建上下文所在的存储卷按 Pod 中的挂载路径原样挂载，因此 Dockerfile 中的路径与用户在 Pod 内看到的一致
+func (c *Client) CreateBuildJob(ctx context.Context, spec *BuildSpec) (*batchv1.Job, error) {
+	// 随机后缀避免同一秒内的两次构建 Job 与 Dockerfile ConfigMap 重名
+	jobName := fmt.Sprintf("build-%s-%d-%s", spec.Username, time.Now().Unix(), utilrand.String(5))
+	namespace := spec.Namespace
+
+	volumes, volumeMounts, err := c.buildWorkspaceVolumes(spec)
+	if err != nil {
+		return nil, err
+	}
+
+	dockerfile := spec.DockerfilePath
+	if spec.Dockerfile != "" {
+		dockerfile = path.Join(buildDockerfileDir, "Dockerfile")
+		volumes = append(volumes, corev1.Volume{
+			Name: "dockerfile",
+			VolumeSource: corev1.VolumeSource{
+				ConfigMap: &corev1.ConfigMapVolumeSource{
+					LocalObjectReference: corev1.LocalObjectReference{Name: jobName},
+				},
+			},
+		})
+		volumeMounts = append(volumeMounts, corev1.VolumeMount{
+			Name:      "dockerfile",
+			MountPath: buildDockerfileDir,
+			ReadOnly:  true,
+		})
+	}
+
+	// 与 commit 共用 registry 认证 Secret，目标镜像必须位于用户的推送仓库
+	pushRegistry, err := c.pushRegistryFor(spec.Username, spec.Email, spec.TargetImage)
+	if err != nil {
+		return nil, err
+	}
+	secretName := ""
+	if dockerConfigJSON := buildDockerConfigJSON(pushRegistry); dockerConfigJSON != "" {
+		secretName = fmt.Sprintf("registry-auth-%s", spec.Username)
+		if err := c.ensureRegistrySecret(ctx, namespace, secretName, dockerConfigJSON); err != nil {
+			return nil, fmt.Errorf("创建 registry secret 失败: %w", err)
+		}
+		volumes = append(volumes, corev1.Volume{
+			Name: "docker-config",
+			VolumeSource: corev1.VolumeSource{
+				Secret: &corev1.SecretVolumeSource{
+					SecretName: secretName,
+					Items: []corev1.KeyToPath{
+						{
+							Key:  ".dockerconfigjson",
+							Path: "config.json",
+						},
+					},
+				},
+			},
+		})
+		volumeMounts = append(volumeMounts, corev1.VolumeMount{
+			Name:      "docker-config",
+			MountPath: "/kaniko/.docker",
+			ReadOnly:  true,
+		})
+	}
+
+	image := c.config.Images.Kaniko
+	if image == "" {
+		image = defaultKanikoImage
+	}
+
+	ttlSeconds := int32(600)
+	backoffLimit := int32(0)
+
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      jobName,
+			Namespace: namespace,
+			Labels: map[string]string{
+				"genet.io/type":    "build",
+				"genet.io/user":    spec.Username,
+				"genet.io/pod":     spec.PodName,
+				"genet.io/managed": "true",
+			},
+			Annotations: map[string]string{
+				"genet.io/target-image": spec.TargetImage,
+				"genet.io/source-pod":   spec.PodName,
+			},
+		},
+		Spec: batchv1.JobSpec{
+			TTLSecondsAfterFinished: &ttlSeconds,
+			BackoffLimit:            &backoffLimit,
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					RestartPolicy: corev1.RestartPolicyNever,
+					// 调度到源 Pod 所在节点：ReadWriteOnce PVC 与 hostPath 卷只能在该节点访问
+					NodeName: spec.NodeName,
+					Containers: []corev1.Container{
+						{
+							Name:         "build",
+							Image:        image,
+							Args:         buildKanikoArgs(spec, dockerfile, pushRegistry.Insecure),
+							VolumeMounts: volumeMounts,
+							SecurityContext: &corev1.SecurityContext{
+								Privileged: boolPtr(false),
+							},
+						},
+					},
+					Volumes: volumes,
+				},
+			},
+		},
+	}
+
+	created, err := c.clientset.BatchV1().Jobs(namespace).Create(ctx, job, metav1.CreateOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	if spec.Dockerfile != "" {
+		// ConfigMap 归属于 Job，Job 被 TTL 清理时一并删除；构建容器会等待 ConfigMap 创建后再启动
+		configMap := &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      created.Name,
+				Namespace: namespace,
+				Labels: map[string]string{
+					"genet.io/type":    "build",
+					"genet.io/managed": "true",
+				},
+				OwnerReferences: []metav1.OwnerReference{
+					{
+						APIVersion: "batch/v1",
+						Kind:       "Job",
+						Name:       created.Name,
+						UID:        created.UID,
+					},
+				},
+			},
+			Data: map[string]string{
+				"Dockerfile": spec.Dockerfile,
+			},
+		}
+		if _, err := c.clientset.CoreV1().ConfigMaps(namespace).Create(ctx, configMap, metav1.CreateOptions{}); err != nil {
+			propagation := metav1.DeletePropagationBackground
+			_ = c.clientset.BatchV1().Jobs(namespace).Delete(ctx, created.Name, metav1.DeleteOptions{PropagationPolicy: &propagation})
+			return nil, fmt.Errorf("创建 Dockerfile ConfigMap 失败: %w", err)
+		}
+	}
+
+	return created, nil
+}
+
+// buildWorkspaceVolumes 找到构建上下文和 Dockerfile 所在的存储卷，按 Pod 中的挂载路径挂载
+func (c *Client) buildWorkspaceVolumes(spec *BuildSpec) ([]corev1.Volume, []corev1.VolumeMount, error) {
+	paths := []string{spec.ContextPath}
+	if spec.Dockerfile == "" {
+		paths = append(paths, spec.DockerfilePath)
+	}
+
+	var volumes []corev1.Volume
+	var volumeMounts []corev1.VolumeMount
+	mounted := make(map[string]bool)
+	storageVolumes := c.config.Storage.GetEffectiveVolumes()
+	for _, p := range paths {
+		index := -1
+		for i, vol := range storageVolumes {
+			if !pathWithin(p, vol.MountPath) {
+				continue
+			}
+			// 挂载路径嵌套时取最长匹配
+			if index < 0 || len(vol.MountPath) > len(storageVolumes[index].MountPath) {
+				index = i
+			}
+		}
+		if index < 0 {
+			return nil, nil, fmt.Errorf("%s: %w", p, ErrBuildPathOutsideWorkspace)
+		}
+		vol := storageVolumes[index]
+		if mounted[vol.Name] {
+			continue
+		}
+		mounted[vol.Name] = true
+		volume, volumeMount := c.buildStorageVolume(vol, spec.UserIdentifier, spec.PodName)
+		// 构建只读取工作空间，不写回
+		volumeMount.ReadOnly = true
+		volumes = append(volumes, volume)
+		volumeMounts = append(volumeMounts, volumeMount)
+	}
+	return volumes, volumeMounts, nil
+}
+
+// buildKanikoArgs 构建 Kaniko executor 参数
+func buildKanikoArgs(spec *BuildSpec, dockerfile string, insecure bool) []string {
+	args := []string{
+		"--context=dir://" + spec.ContextPath,
+		"--dockerfile=" + dockerfile,
+		"--destination=" + spec.TargetImage,
+		"--verbosity=info",
+	}
+	if insecure {
+		args = append(args, "--insecure", "--skip-tls-verify")
+	}
+	return args
+}
+
+// GetBuildJobStatus 获取 Pod 最新的构建 Job 状态，没有构建任务时返回 nil
+func (c *Client) GetBuildJobStatus(ctx context.Context, namespace, podName string) (*CommitJobStatus, error) {
+	job, err := c.latestImageJob(ctx, namespace, "build", podName)
+	if err != nil || job == nil {
+		return nil, err
+	}
+	return imageJobStatus(job), nil
+}
+
+// GetBuildJobLogs 获取 Pod 最新的构建 Job 日志
+func (c *Client) GetBuildJobLogs(ctx context.Context, namespace, podName string) (string, error) {
+	job, err := c.latestImageJob(ctx, namespace, "build", podName)
+	if err != nil {
+		return "", err
+	}
+	if job == nil {
+		return "", fmt.Errorf("没有找到相关的构建任务")
+	}
+	// Kaniko 每个构建步骤都会输出日志，保留更多行
+	return c.imageJobLogs(ctx, namespace, job.Name, 500)
+}
+
+// pathWithin 判断 p 是否为 dir 或其子路径
+func pathWithin(p, dir string) bool {
+	dir = path.Clean(dir)
+	if dir == "/" {
+		return true
+	}
+	return p == dir || strings.HasPrefix(p, dir+"/")
+}
@@ -0,0 +1,219 @@
+package k8s
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/uc-package/genet/internal/models"
+	batchv1 "k8s.io/api/batch/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestCreateBuildJobInlineDockerfile(t *testing.T) {
+	clientset := fake.NewSimpleClientset()
+	cfg := models.DefaultConfig()
+	cfg.Registry = models.RegistryConfig{URL: "registry.local", Username: "robot", Password: "secret", Insecure: true}
+	client := NewClientForTest(clientset, cfg)
+
+	job, err := client.CreateBuildJob(context.Background(), &BuildSpec{
+		PodName:        "pod-alice-dev",
+		Namespace:      "user-alice",
+		Username:       "alice",
+		UserIdentifier: "alice",
+		TargetImage:    "registry.local/alice/train:v1",
+		NodeName:       "node-1",
+		Dockerfile:     "FROM busybox\nCOPY . /app\n",
+		ContextPath:    models.DefaultWorkspaceDir + "/train",
+	})
+	if err != nil {
+		t.Fatalf("CreateBuildJob: %v", err)
+	}
+
+	if job.Labels["genet.io/type"] != "build" || job.Annotations["genet.io/target-image"] != "registry.local/alice/train:v1" {
+		t.Fatalf("unexpected job metadata: %+v", job.ObjectMeta)
+	}
+	podSpec := job.Spec.Template.Spec
+	if podSpec.NodeName != "node-1" {
+		t.Fatalf("expected job pinned to pod node, got %q", podSpec.NodeName)
+	}
+	container := podSpec.Containers[0]
+	if container.Image != cfg.Images.Kaniko {
+		t.Fatalf("unexpected image %q", container.Image)
+	}
+	args := strings.Join(container.Args, " ")
+	for _, want := range []string{
+		"--context=dir://" + models.DefaultWorkspaceDir + "/train",
+		"--dockerfile=/genet-build/Dockerfile",
+		"--destination=registry.local/alice/train:v1",
+		"--insecure",
+	} {
+		if !strings.Contains(args, want) {
+			t.Fatalf("args %q missing %q", args, want)
+		}
+	}
+
+	mounts := make(map[string]string)
+	for _, mount := range container.VolumeMounts {
+		mounts[mount.Name] = mount.MountPath
+		if !mount.ReadOnly {
+			t.Fatalf("expected read-only mount %s", mount.Name)
+		}
+	}
+	if mounts["workspace"] != models.DefaultWorkspaceDir || mounts["docker-config"] != "/kaniko/.docker" || mounts["dockerfile"] != "/genet-build" {
+		t.Fatalf("unexpected mounts %+v", mounts)
+	}
+	for _, volume := range podSpec.Volumes {
+		if volume.Name == "workspace" && (volume.PersistentVolumeClaim == nil || volume.PersistentVolumeClaim.ClaimName != "genet-alice-workspace") {
+			t.Fatalf("unexpected workspace volume %+v", volume)
+		}
+	}
+
+	configMap, err := clientset.CoreV1().ConfigMaps("user-alice").Get(context.Background(), job.Name, metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("get dockerfile configmap: %v", err)
+	}
+	if configMap.Data["Dockerfile"] != "FROM busybox\nCOPY . /app\n" || len(configMap.OwnerReferences) != 1 || configMap.OwnerReferences[0].Name != job.Name {
+		t.Fatalf("unexpected configmap %+v", configMap)
+	}
+	if _, err := clientset.CoreV1().Secrets("user-alice").Get(context.Background(), "registry-auth-alice", metav1.GetOptions{}); err != nil {
+		t.Fatalf("expected registry secret: %v", err)
+	}
+}
+
+func TestCreateBuildJobNamesDoNotCollide(t *testing.T) {
+	clientset := fake.NewSimpleClientset()
+	cfg := models.DefaultConfig()
+	cfg.Registry = models.RegistryConfig{URL: "registry.local"}
+	client := NewClientForTest(clientset, cfg)
+
+	spec := &BuildSpec{
+		PodName:        "pod-alice-dev",
+		Namespace:      "user-alice",
+		Username:       "alice",
+		UserIdentifier: "alice",
+		TargetImage:    "registry.local/alice/train:v1",
+		Dockerfile:     "FROM busybox\n",
+		ContextPath:    models.DefaultWorkspaceDir,
+	}
+	// 同一秒内连续提交两次构建
+	first, err := client.CreateBuildJob(context.Background(), spec)
+	if err != nil {
+		t.Fatalf("first build: %v", err)
+	}
+	second, err := client.CreateBuildJob(context.Background(), spec)
+	if err != nil {
+		t.Fatalf("second build: %v", err)
+	}
+	if first.Name == second.Name {
+		t.Fatalf("expected distinct job names, got %q twice", first.Name)
+	}
+	for _, job := range []string{first.Name, second.Name} {
+		if _, err := clientset.CoreV1().ConfigMaps("user-alice").Get(context.Background(), job, metav1.GetOptions{}); err != nil {
+			t.Fatalf("expected dockerfile configmap for %s: %v", job, err)
+		}
+	}
+}
+
+func TestCreateBuildJobDockerfilePath(t *testing.T) {
+	clientset := fake.NewSimpleClientset()
+	client := NewClientForTest(clientset, models.DefaultConfig())
+
+	job, err := client.CreateBuildJob(context.Background(), &BuildSpec{
+		PodName:        "pod-alice-dev",
+		Namespace:      "user-alice",
+		Username:       "alice",
+		UserIdentifier: "alice",
+		TargetImage:    "registry.local/alice/train:v1",
+		NodeName:       "node-1",
+		DockerfilePath: models.DefaultWorkspaceDir + "/train/Dockerfile.gpu",
+		ContextPath:    models.DefaultWorkspaceDir + "/train",
+	})
+	if err != nil {
+		t.Fatalf("CreateBuildJob: %v", err)
+	}
+	container := job.Spec.Template.Spec.Containers[0]
+	if !strings.Contains(strings.Join(container.Args, " "), "--dockerfile="+models.DefaultWorkspaceDir+"/train/Dockerfile.gpu") {
+		t.Fatalf("unexpected args %v", container.Args)
+	}
+	if len(container.VolumeMounts) != 1 {
+		t.Fatalf("expected only the workspace mount, got %+v", container.VolumeMounts)
+	}
+	configMaps, _ := clientset.CoreV1().ConfigMaps("user-alice").List(context.Background(), metav1.ListOptions{})
+	if len(configMaps.Items) != 0 {
+		t.Fatalf("expected no dockerfile configmap, got %d", len(configMaps.Items))
+	}
+}
+
+func TestCreateBuildJobRejectsPathOutsideWorkspace(t *testing.T) {
+	clientset := fake.NewSimpleClientset()
+	client := NewClientForTest(clientset, models.DefaultConfig())
+
+	_, err := client.CreateBuildJob(context.Background(), &BuildSpec{
+		PodName:     "pod-alice-dev",
+		Namespace:   "user-alice",
+		Username:    "alice",
+		TargetImage: "registry.local/alice/train:v1",
+		Dockerfile:  "FROM busybox",
+		ContextPath: "/etc",
+	})
+	if !errors.Is(err, ErrBuildPathOutsideWorkspace) {
+		t.Fatalf("expected ErrBuildPathOutsideWorkspace, got %v", err)
+	}
+	jobs, _ := clientset.BatchV1().Jobs("user-alice").List(context.Background(), metav1.ListOptions{})
+	if len(jobs.Items) != 0 {
+		t.Fatalf("expected no job, got %d", len(jobs.Items))
+	}
+}
+
+func TestGetBuildJobStatusIgnoresCommitJobs(t *testing.T) {
+	newJob := func(name, jobType string, created int64, succeeded int32) *batchv1.Job {
+		return &batchv1.Job{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:              name,
+				Namespace:         "user-alice",
+				Labels:            map[string]string{"genet.io/type": jobType, "genet.io/pod": "pod-alice-dev"},
+				Annotations:       map[string]string{"genet.io/target-image": name + ":latest"},
+				CreationTimestamp: metav1.Unix(created, 0),
+			},
+			Status: batchv1.JobStatus{Succeeded: succeeded},
+		}
+	}
+	client := NewClientForTest(fake.NewSimpleClientset(
+		newJob("build-old", "build", 100, 1),
+		newJob("build-new", "build", 200, 0),
+		newJob("commit-newest", "commit", 300, 1),
+	), models.DefaultConfig())
+
+	status, err := client.GetBuildJobStatus(context.Background(), "user-alice", "pod-alice-dev")
+	if err != nil {
+		t.Fatalf("GetBuildJobStatus: %v", err)
+	}
+	if status == nil || status.JobName != "build-new" || status.Status != "Pending" || status.TargetImage != "build-new:latest" {
+		t.Fatalf("unexpected status %+v", status)
+	}
+
+	status, err = client.GetBuildJobStatus(context.Background(), "user-alice", "other-pod")
+	if err != nil || status != nil {
+		t.Fatalf("expected no build job, got %+v, %v", status, err)
+	}
+}
+
+func TestPathWithin(t *testing.T) {
+	cases := []struct {
+		path, dir string
+		want      bool
+	}{
+		{"/workspace", "/workspace", true},
+		{"/workspace/a/b", "/workspace/", true},
+		{"/workspace-other", "/workspace", false},
+		{"/etc", "/", true},
+	}
+	for _, tc := range cases {
+		if got := pathWithin(tc.path, tc.dir); got != tc.want {
+			t.Fatalf("pathWithin(%q, %q) = %v, want %v", tc.path, tc.dir, got, tc.want)
+		}
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/uc-package/genet/internal/models"
 	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
@@ -18,34 +19,65 @@ type CommitSpec struct {
 	PodName     string // 源 Pod 名称
 	Namespace   string // Pod 所在 namespace
 	Username    string // 用户名
+	Email       string // 用户邮箱，与用户名一起决定推送仓库
 	TargetImage string // 目标镜像名称（包含 tag）
 	NodeName    string // Pod 所在节点
-	ContainerID string // 容器 ID（用于 nerdctl commit）
+	ContainerID string // Pod 状态中的容器 ID（<runtime>://<id>），决定使用的运行时驱动
+
+	Excludes         []string // 排除的路径 glob（已合并管理员默认规则）
+	ExcludesRequired bool     // 用户显式指定了排除路径：无法合并层时失败，而不是跳过排除
+	Squash           bool     // 合并本次提交产生的层
+	PreCommitHook    string   // 提交前在源容器内执行的清理脚本
 }
 
 // CommitJobStatus commit job 状态
 type CommitJobStatus struct {
 	JobName     string `json:"jobName"`
-	Status      string `json:"status"`      // Pending, Running, Succeeded, Failed
-	Message     string `json:"message"`     // 状态消息
-	StartTime   string `json:"startTime"`   // 开始时间
-	EndTime     string `json:"endTime"`     // 结束时间
-	TargetImage string `json:"targetImage"` // 目标镜像名称
-	SourcePod   string `json:"sourcePod"`   // 来源 Pod 名称
-	ImageSaved  bool   `json:"imageSaved"`  // 镜像记录是否已保存
+	Status      string `json:"status"`              // Pending, Running, Succeeded, Failed
+	Message     string `json:"message"`             // 状态消息
+	StartTime   string `json:"startTime"`           // 开始时间
+	EndTime     string `json:"endTime"`             // 结束时间
+	TargetImage string `json:"targetImage"`         // 目标镜像名称
+	SourcePod   string `json:"sourcePod"`           // 来源 Pod 名称
+	ImageSaved  bool   `json:"imageSaved"`          // 镜像记录是否已保存
+	Size        int64  `json:"size,omitempty"`      // 推送后的镜像大小（字节），成功后才有
+	BaseSize    int64  `json:"baseSize,omitempty"`  // 提交前容器所用镜像的大小（字节）
+	SizeDelta   int64  `json:"sizeDelta,omitempty"` // 相对原镜像的体积变化（字节）
 }
 
 // CreateCommitJob 创建镜像 commit Job
 func (c *Client) CreateCommitJob(ctx context.Context, spec *CommitSpec) (*batchv1.Job, error) {
-	jobName := fmt.Sprintf("commit-%s-%d", spec.Username, time.Now().Unix())
+	// 毫秒时间戳，允许同一 Pod 连续提交多个任务
+	jobName := fmt.Sprintf("commit-%s-%d", spec.Username, time.Now().UnixMilli())
 	namespace := spec.Namespace
 
-	// 构建 docker config.json 用于 registry 认证
-	dockerConfigJSON := c.buildDockerConfigJSON()
+	driver, containerID, err := commitRuntimeDriverFor(spec.ContainerID)
+	if err != nil {
+		return nil, err
+	}
+	if err := ValidateCommitExcludes(spec.Excludes); err != nil {
+		return nil, err
+	}
+	if _, err := ResolveCommitExcludes(spec.Excludes, spec.ExcludesRequired, spec.Squash); err != nil {
+		return nil, err
+	}
+	if err := ValidateCommitHook(spec.PreCommitHook); err != nil {
+		return nil, err
+	}
+
+	// 同一 Pod 已有未结束的 commit Job 时排队（suspend），由 SyncCommitHistory 依次放行
+	queued, err := c.hasUnfinishedCommitJob(ctx, namespace, spec.PodName)
+	if err != nil {
+		return nil, err
+	}
 
-	// 创建 registry 认证 Secret（如果配置了）
+	// 目标镜像必须位于用户的推送仓库，按该仓库创建 registry 认证 Secret（如果配置了凭据）
+	pushRegistry, err := c.pushRegistryFor(spec.Username, spec.Email, spec.TargetImage)
+	if err != nil {
+		return nil, err
+	}
 	secretName := ""
-	if c.config.Registry.URL != "" && c.config.Registry.Username != "" {
+	if dockerConfigJSON := buildDockerConfigJSON(pushRegistry); dockerConfigJSON != "" {
 		secretName = fmt.Sprintf("registry-auth-%s", spec.Username)
 		if err := c.ensureRegistrySecret(ctx, namespace, secretName, dockerConfigJSON); err != nil {
 			return nil, fmt.Errorf("创建 registry secret 失败: %w", err)
@@ -53,7 +85,7 @@ func (c *Client) CreateCommitJob(ctx context.Context, spec *CommitSpec) (*batchv
 	}
 
 	// 构建 commit 脚本
-	commitScript := c.buildCommitScript(spec, secretName != "")
+	commitScript := c.buildCommitScript(spec, driver, containerID, pushRegistry.Insecure)
 
 	// TTL 设置：Job 完成后 10 分钟自动清理
 	ttlSeconds := int32(600)
@@ -71,13 +103,15 @@ func (c *Client) CreateCommitJob(ctx context.Context, spec *CommitSpec) (*batchv
 				"genet.io/managed": "true",
 			},
 			Annotations: map[string]string{
-				"genet.io/target-image": spec.TargetImage,
-				"genet.io/source-pod":   spec.PodName,
+				"genet.io/target-image":      spec.TargetImage,
+				"genet.io/source-pod":        spec.PodName,
+				"genet.io/container-runtime": driver.Name(),
 			},
 		},
 		Spec: batchv1.JobSpec{
 			TTLSecondsAfterFinished: &ttlSeconds,
 			BackoffLimit:            &backoffLimit,
+			Suspend:                 boolPtr(queued),
 			Template: corev1.PodTemplateSpec{
 				Spec: corev1.PodSpec{
 					RestartPolicy: corev1.RestartPolicyNever,
@@ -85,28 +119,12 @@ func (c *Client) CreateCommitJob(ctx context.Context, spec *CommitSpec) (*batchv
 					Containers: []corev1.Container{
 						{
 							Name:    "commit",
-							Image:   c.config.Images.Nerdctl,
+							Image:   driver.Image(c.config),
 							Command: []string{"/bin/sh", "-c"},
 							Args:    []string{commitScript},
 							SecurityContext: &corev1.SecurityContext{
 								Privileged: boolPtr(true),
 							},
-							VolumeMounts: []corev1.VolumeMount{
-								{
-									Name:      "containerd-sock",
-									MountPath: "/run/containerd/containerd.sock",
-								},
-							},
-						},
-					},
-					Volumes: []corev1.Volume{
-						{
-							Name: "containerd-sock",
-							VolumeSource: corev1.VolumeSource{
-								HostPath: &corev1.HostPathVolumeSource{
-									Path: "/run/containerd/containerd.sock",
-								},
-							},
 						},
 					},
 				},
@@ -114,6 +132,21 @@ func (c *Client) CreateCommitJob(ctx context.Context, spec *CommitSpec) (*batchv
 		},
 	}
 
+	// 挂载运行时 socket / 存储目录到同路径
+	podSpec := &job.Spec.Template.Spec
+	for _, hostPath := range driver.HostPaths() {
+		podSpec.Containers[0].VolumeMounts = append(podSpec.Containers[0].VolumeMounts, corev1.VolumeMount{
+			Name:      hostPath.Name,
+			MountPath: hostPath.Path,
+		})
+		podSpec.Volumes = append(podSpec.Volumes, corev1.Volume{
+			Name: hostPath.Name,
+			VolumeSource: corev1.VolumeSource{
+				HostPath: &corev1.HostPathVolumeSource{Path: hostPath.Path},
+			},
+		})
+	}
+
 	// 如果有 registry 认证，添加 secret 挂载
 	if secretName != "" {
 		job.Spec.Template.Spec.Containers[0].VolumeMounts = append(
@@ -142,31 +175,71 @@ func (c *Client) CreateCommitJob(ctx context.Context, spec *CommitSpec) (*batchv
 		)
 	}
 
-	return c.clientset.BatchV1().Jobs(namespace).Create(ctx, job, metav1.CreateOptions{})
+	created, err := c.clientset.BatchV1().Jobs(namespace).Create(ctx, job, metav1.CreateOptions{})
+	if err != nil {
+		return nil, err
+	}
+	c.recordCommitSubmitted(ctx, created)
+	return created, nil
 }
 
-// GetCommitJobStatus 获取 commit job 状态
-func (c *Client) GetCommitJobStatus(ctx context.Context, namespace, podName string) (*CommitJobStatus, error) {
-	// 查找与 pod 相关的最新 commit job
+// hasUnfinishedCommitJob Pod 是否有运行中或排队中的 commit Job
+func (c *Client) hasUnfinishedCommitJob(ctx context.Context, namespace, podName string) (bool, error) {
 	jobs, err := c.clientset.BatchV1().Jobs(namespace).List(ctx, metav1.ListOptions{
 		LabelSelector: fmt.Sprintf("genet.io/type=commit,genet.io/pod=%s", podName),
 	})
 	if err != nil {
-		return nil, fmt.Errorf("获取 commit job 列表失败: %w", err)
+		return false, fmt.Errorf("获取 commit job 列表失败: %w", err)
+	}
+	for i := range jobs.Items {
+		if _, _, finished := commitJobResult(&jobs.Items[i]); !finished {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// GetCommitJobStatus 获取 commit job 状态
+func (c *Client) GetCommitJobStatus(ctx context.Context, namespace, podName string) (*CommitJobStatus, error) {
+	// 查找与 pod 相关的最新 commit job
+	latestJob, err := c.latestImageJob(ctx, namespace, "commit", podName)
+	if err != nil || latestJob == nil {
+		return nil, err // 没有 commit job 时返回 nil
 	}
+	status := imageJobStatus(latestJob)
+	if status.Status == "Succeeded" {
+		// 体积信息由 commit 脚本输出到日志
+		if logs, err := c.imageJobLogs(ctx, namespace, latestJob.Name, 200); err == nil {
+			info := parseCommitImageInfo(logs)
+			status.Size, status.BaseSize = info.Size, info.BaseSize
+			if info.Size > 0 && info.BaseSize > 0 {
+				status.SizeDelta = info.Size - info.BaseSize
+			}
+		}
+	}
+	return status, nil
+}
 
-	if len(jobs.Items) == 0 {
-		return nil, nil // 没有 commit job
+// latestImageJob 查找与 pod 相关的最新镜像 Job（jobType: commit | build），没有时返回 nil
+func (c *Client) latestImageJob(ctx context.Context, namespace, jobType, podName string) (*batchv1.Job, error) {
+	jobs, err := c.clientset.BatchV1().Jobs(namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: fmt.Sprintf("genet.io/type=%s,genet.io/pod=%s", jobType, podName),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("获取 %s job 列表失败: %w", jobType, err)
 	}
 
-	// 获取最新的 job
 	var latestJob *batchv1.Job
 	for i := range jobs.Items {
 		if latestJob == nil || jobs.Items[i].CreationTimestamp.After(latestJob.CreationTimestamp.Time) {
 			latestJob = &jobs.Items[i]
 		}
 	}
+	return latestJob, nil
+}
 
+// imageJobStatus 解析镜像 Job 状态
+func imageJobStatus(latestJob *batchv1.Job) *CommitJobStatus {
 	status := &CommitJobStatus{
 		JobName:     latestJob.Name,
 		TargetImage: latestJob.Annotations["genet.io/target-image"],
@@ -184,6 +257,9 @@ func (c *Client) GetCommitJobStatus(ctx context.Context, namespace, podName stri
 	} else if latestJob.Status.Active > 0 {
 		status.Status = "Running"
 		status.Message = "正在构建镜像..."
+	} else if isJobSuspended(latestJob) {
+		status.Status = "Queued"
+		status.Message = "排队中，等待前一个任务完成..."
 	} else {
 		status.Status = "Pending"
 		status.Message = "等待调度..."
@@ -196,7 +272,7 @@ func (c *Client) GetCommitJobStatus(ctx context.Context, namespace, podName stri
 		status.EndTime = latestJob.Status.CompletionTime.Format(time.RFC3339)
 	}
 
-	return status, nil
+	return status
 }
 
 // MarkCommitJobImageSaved 标记 commit job 的镜像记录已保存
@@ -216,28 +292,21 @@ func (c *Client) MarkCommitJobImageSaved(ctx context.Context, namespace, jobName
 // GetCommitJobLogs 获取 commit job 日志
 func (c *Client) GetCommitJobLogs(ctx context.Context, namespace, podName string) (string, error) {
 	// 查找与 pod 相关的最新 commit job
-	jobs, err := c.clientset.BatchV1().Jobs(namespace).List(ctx, metav1.ListOptions{
-		LabelSelector: fmt.Sprintf("genet.io/type=commit,genet.io/pod=%s", podName),
-	})
+	latestJob, err := c.latestImageJob(ctx, namespace, "commit", podName)
 	if err != nil {
-		return "", fmt.Errorf("获取 commit job 列表失败: %w", err)
+		return "", err
 	}
-
-	if len(jobs.Items) == 0 {
+	if latestJob == nil {
 		return "", fmt.Errorf("没有找到相关的 commit job")
 	}
+	return c.imageJobLogs(ctx, namespace, latestJob.Name, 200)
+}
 
-	// 获取最新的 job
-	var latestJob *batchv1.Job
-	for i := range jobs.Items {
-		if latestJob == nil || jobs.Items[i].CreationTimestamp.After(latestJob.CreationTimestamp.Time) {
-			latestJob = &jobs.Items[i]
-		}
-	}
-
+// imageJobLogs 获取 Job Pod 末尾 tailLines 行日志
+func (c *Client) imageJobLogs(ctx context.Context, namespace, jobName string, tailLines int64) (string, error) {
 	// 获取 job 的 pod
 	pods, err := c.clientset.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{
-		LabelSelector: fmt.Sprintf("job-name=%s", latestJob.Name),
+		LabelSelector: fmt.Sprintf("job-name=%s", jobName),
 	})
 	if err != nil {
 		return "", fmt.Errorf("获取 job pod 失败: %w", err)
@@ -249,7 +318,6 @@ func (c *Client) GetCommitJobLogs(ctx context.Context, namespace, podName string
 
 	// 获取 pod 日志
 	jobPod := pods.Items[0]
-	tailLines := int64(200)
 	req := c.clientset.CoreV1().Pods(namespace).GetLogs(jobPod.Name, &corev1.PodLogOptions{
 		TailLines: &tailLines,
 	})
@@ -262,53 +330,26 @@ func (c *Client) GetCommitJobLogs(ctx context.Context, namespace, podName string
 	return string(logs), nil
 }
 
-// buildCommitScript 构建 commit 脚本
-func (c *Client) buildCommitScript(spec *CommitSpec, hasAuth bool) string {
-	// 如果配置了 insecure，添加 --insecure-registry 参数
-	// 注意：这个参数需要宿主机 containerd 也配置了 insecure registry 才能生效
-	// 参考文档：需要在 K8s 节点上配置 /etc/containerd/certs.d/<registry>/hosts.toml
-	insecureFlag := ""
-	if c.config.Registry.Insecure {
-		insecureFlag = "--insecure-registry"
-	}
-
+// buildCommitScript 构建 commit 脚本。
+// 容器 ID 取自 Pod 状态，不按名称在节点上搜索，避免共享节点上提交到其他用户的容器。
+func (c *Client) buildCommitScript(spec *CommitSpec, driver commitRuntimeDriver, containerID string, insecure bool) string {
+	cli := driver.CLI()
+	// 未开启 squash 时跳过默认排除规则（显式指定的排除已在 CreateCommitJob 中被拒绝）
+	excludes, _ := ResolveCommitExcludes(spec.Excludes, spec.ExcludesRequired, spec.Squash)
 	script := fmt.Sprintf(`
 set -e
 echo "=== Genet Image Commit ==="
 echo "Source Pod: %s"
 echo "Target Image: %s"
 echo "Node: %s"
-echo "Insecure Registry: %s"
+echo "Container Runtime: %s"
+echo "Insecure Registry: %t"
 
-# 查找容器 ID
-echo "Searching for container..."
-
-# containerd k8s 命名空间中的容器名格式: k8s://<namespace>/<pod>/<container> 或 <pod>_<namespace>_<container>
-# 先列出所有容器看看格式
-echo "Listing containers in k8s.io namespace..."
-nerdctl -n k8s.io ps -a 2>/dev/null || true
-
-# 方式1: 通过 Pod 名称匹配（包含 pod 名称的容器，排除 POD sandbox）
-CONTAINER_ID=$(nerdctl -n k8s.io ps -a --format '{{.ID}} {{.Names}}' 2>/dev/null | grep -i '%s' | grep -vi 'POD' | grep -vi 'pause' | head -1 | awk '{print $1}' || true)
-
-# 方式2: 如果方式1没找到，尝试用更宽松的匹配
-if [ -z "$CONTAINER_ID" ]; then
-    echo "Trying alternative search..."
-    # 查找 workspace 容器
-    CONTAINER_ID=$(nerdctl -n k8s.io ps -a --format '{{.ID}} {{.Names}}' 2>/dev/null | grep -i 'workspace' | head -1 | awk '{print $1}' || true)
-fi
-
-# 方式3: 如果还是没找到，列出所有容器供调试
-if [ -z "$CONTAINER_ID" ]; then
+CONTAINER_ID=%s
+# Pod 重建或迁移后容器 ID 会变化，先确认容器仍在本节点上
+if ! %s inspect "$CONTAINER_ID" >/dev/null 2>&1; then
     echo ""
-    echo "ERROR: Container not found for pod %s"
-    echo ""
-    echo "Debug info - all containers:"
-    echo "================================"
-    nerdctl -n k8s.io ps -a 2>/dev/null || echo "Failed to list containers"
-    echo ""
-    echo "Try checking Docker namespace:"
-    nerdctl ps -a 2>/dev/null || true
+    echo "ERROR: Container $CONTAINER_ID of pod %s not found on this node"
     exit 1
 fi
 
@@ -316,51 +357,64 @@ echo ""
 echo "Found container ID: $CONTAINER_ID"
 echo ""
 
-# Commit 容器为镜像
-echo "Committing container to image: %s"
-nerdctl -n k8s.io commit "$CONTAINER_ID" %s
+# 记录提交前容器所用镜像的大小，用于计算体积变化
+BASE_IMAGE=$(%s inspect --format '{{.Image}}' "$CONTAINER_ID" 2>/dev/null || true)
+BASE_SIZE=$(%s image inspect --format '{{.Size}}' "$BASE_IMAGE" 2>/dev/null || true)
+echo "GENET_BASE_IMAGE_SIZE=$BASE_SIZE"
+%s
+%s
 
+# Commit 容器为镜像
+%s
 echo ""
 echo "Commit successful!"
 echo ""
 
 # 推送镜像
 echo "Pushing image to registry: %s"
-nerdctl -n k8s.io %s push %s
+%s
+
+# 输出推送后的镜像信息，写入保存历史
+DIGEST=$(%s image inspect --format '{{index .RepoDigests 0}}' %s 2>/dev/null || true)
+SIZE=$(%s image inspect --format '{{.Size}}' %s 2>/dev/null || true)
+echo "GENET_IMAGE_DIGEST=${DIGEST##*@}"
+echo "GENET_IMAGE_SIZE=$SIZE"
 
 echo ""
 echo "=== SUCCESS ==="
 echo "Image %s has been pushed successfully!"
-`, spec.PodName, spec.TargetImage, spec.NodeName,
-		insecureFlag,                       // 显示是否使用 insecure
-		spec.PodName,                       // 方式1 grep
-		spec.PodName,                       // 错误信息
-		spec.TargetImage, spec.TargetImage, // commit: echo, nerdctl commit
-		spec.TargetImage, insecureFlag, spec.TargetImage, // push: echo, insecureFlag, 镜像名
+`, spec.PodName, spec.TargetImage, spec.NodeName, driver.Name(), insecure,
+		containerID,
+		cli, spec.PodName, // 校验容器
+		cli, cli, // 原镜像大小
+		c.buildCommitHookSection(driver, spec.PreCommitHook),
+		driver.SquashSection(spec.Squash),
+		buildCommitImageSection(driver, spec.TargetImage, excludes, spec.ExcludesRequired),
+		spec.TargetImage, driver.PushCommand(spec.TargetImage, insecure), // 推送
+		cli, spec.TargetImage, cli, spec.TargetImage, // 镜像信息: digest, size
 		spec.TargetImage) // 成功信息
 
 	return script
 }
 
-// buildDockerConfigJSON 构建 docker config.json
-func (c *Client) buildDockerConfigJSON() string {
-	if c.config.Registry.URL == "" || c.config.Registry.Username == "" {
-		return ""
+// buildDockerConfigJSON 构建 docker config.json，未配置凭据的仓库跳过；都没有凭据时返回空字符串
+func buildDockerConfigJSON(registries ...models.RegistryConfig) string {
+	auths := make(map[string]interface{})
+	for _, registry := range registries {
+		if registry.URL == "" || registry.Username == "" {
+			continue
+		}
+		auths[registry.URL] = map[string]string{
+			"auth": base64.StdEncoding.EncodeToString(
+				[]byte(fmt.Sprintf("%s:%s", registry.Username, registry.Password)),
+			),
+		}
 	}
-
-	auth := base64.StdEncoding.EncodeToString(
-		[]byte(fmt.Sprintf("%s:%s", c.config.Registry.Username, c.config.Registry.Password)),
-	)
-
-	config := map[string]interface{}{
-		"auths": map[string]interface{}{
-			c.config.Registry.URL: map[string]string{
-				"auth": auth,
-			},
-		},
+	if len(auths) == 0 {
+		return ""
 	}
 
-	data, _ := json.Marshal(config)
+	data, _ := json.Marshal(map[string]interface{}{"auths": auths})
 	return string(data)
 }
 
@@ -0,0 +1,435 @@
+package k8s
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/uc-package/genet/internal/models"
+	"go.uber.org/zap"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/retry"
+)
+
+const (
+	// CommitHistoryConfigMapName 镜像保存历史 ConfigMap 名称
+	CommitHistoryConfigMapName = "genet-commit-history"
+	// CommitHistoryDataKey ConfigMap 中存储历史记录的 key
+	CommitHistoryDataKey = "history.json"
+
+	// maxCommitHistoryRecords 每个用户保留的历史记录数，超出后丢弃最旧的记录
+	maxCommitHistoryRecords = 200
+	// commitLogTailBytes 每条记录保留的日志末尾长度
+	commitLogTailBytes = 4096
+	// maxCommitHistoryBytes 序列化后历史记录的上限，ConfigMap 总大小不能超过 1MiB（含元数据）
+	maxCommitHistoryBytes     = 900 * 1024
+	commitHistorySyncInterval = 30 * time.Second
+
+	// commitHistoryRecordedAnnotation 结束状态已写入历史记录的 Job
+	commitHistoryRecordedAnnotation = "genet.io/history-recorded"
+
+	// commit 脚本在推送成功后输出的镜像信息
+	commitDigestMarker   = "GENET_IMAGE_DIGEST="
+	commitSizeMarker     = "GENET_IMAGE_SIZE="
+	commitBaseSizeMarker = "GENET_BASE_IMAGE_SIZE="
+)
+
+// GetCommitHistory 获取用户的镜像保存历史（最新在前）
+func (c *Client) GetCommitHistory(ctx context.Context, namespace string) (*models.CommitHistory, error) {
+	cm, err := c.clientset.CoreV1().ConfigMaps(namespace).Get(ctx, CommitHistoryConfigMapName, metav1.GetOptions{})
+	if err != nil {
+		if k8serrors.IsNotFound(err) {
+			return &models.CommitHistory{Records: []models.CommitRecord{}}, nil
+		}
+		return nil, err
+	}
+	return decodeCommitHistory(cm.Data), nil
+}
+
+// StartCommitHistorySync 定期把 commit Job 的状态写入历史记录，并按 Pod 依次放行排队中的 Job。
+// Job 在 TTL 后会被删除，必须在此之前把结果、digest 与日志末尾保存下来
+func (c *Client) StartCommitHistorySync(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(commitHistorySyncInterval)
+		defer ticker.Stop()
+		for {
+			if err := c.SyncCommitHistory(ctx, metav1.NamespaceAll); err != nil {
+				c.log.Warn("Failed to sync commit history", zap.Error(err))
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// SyncCommitHistory 同步 namespace（NamespaceAll 表示全部）内 commit Job 的状态到历史记录
+func (c *Client) SyncCommitHistory(ctx context.Context, namespace string) error {
+	jobs, err := c.clientset.BatchV1().Jobs(namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: "genet.io/type=commit",
+	})
+	if err != nil {
+		return fmt.Errorf("获取 commit job 列表失败: %w", err)
+	}
+
+	byPod := make(map[string][]*batchv1.Job)
+	for i := range jobs.Items {
+		job := &jobs.Items[i]
+		key := job.Namespace + "/" + job.Labels["genet.io/pod"]
+		byPod[key] = append(byPod[key], job)
+	}
+	for _, podJobs := range byPod {
+		c.releaseQueuedCommitJob(ctx, podJobs)
+	}
+
+	byNamespace := make(map[string][]*batchv1.Job)
+	for i := range jobs.Items {
+		job := &jobs.Items[i]
+		if job.Annotations[commitHistoryRecordedAnnotation] == "true" {
+			continue
+		}
+		byNamespace[job.Namespace] = append(byNamespace[job.Namespace], job)
+	}
+	for ns, nsJobs := range byNamespace {
+		c.recordCommitJobs(ctx, ns, nsJobs)
+	}
+
+	// 只同步单个 namespace 时能确定哪些 Job 已不存在，将其未结束的记录标记为失败
+	if namespace != metav1.NamespaceAll {
+		existing := make(map[string]bool, len(jobs.Items))
+		for _, job := range jobs.Items {
+			existing[job.Name] = true
+		}
+		return c.updateCommitHistory(ctx, namespace, func(history *models.CommitHistory) bool {
+			changed := false
+			for i := range history.Records {
+				record := &history.Records[i]
+				if existing[record.ID] || isFinalCommitStatus(record.Status) {
+					continue
+				}
+				record.Status = "Failed"
+				record.Message = "任务已被清理，未能获取最终状态"
+				changed = true
+			}
+			return changed
+		})
+	}
+	return nil
+}
+
+// releaseQueuedCommitJob 同一 Pod 同时只运行一个 commit Job：前一个结束后放行最早排队的 Job
+func (c *Client) releaseQueuedCommitJob(ctx context.Context, jobs []*batchv1.Job) {
+	var queued []*batchv1.Job
+	for _, job := range jobs {
+		if _, _, finished := commitJobResult(job); finished {
+			continue
+		}
+		if !isJobSuspended(job) {
+			return
+		}
+		queued = append(queued, job)
+	}
+	if len(queued) == 0 {
+		return
+	}
+	sort.Slice(queued, func(i, j int) bool {
+		return queued[i].CreationTimestamp.Before(&queued[j].CreationTimestamp)
+	})
+	next := queued[0].DeepCopy()
+	next.Spec.Suspend = boolPtr(false)
+	updated, err := c.clientset.BatchV1().Jobs(next.Namespace).Update(ctx, next, metav1.UpdateOptions{})
+	if err != nil {
+		c.log.Warn("Failed to release queued commit job",
+			zap.String("namespace", next.Namespace),
+			zap.String("job", next.Name),
+			zap.Error(err))
+		return
+	}
+	*queued[0] = *updated
+}
+
+// recordCommitJobs 将 Job 状态写入历史记录；已结束的 Job 会保存日志末尾、镜像信息并写入用户镜像列表
+func (c *Client) recordCommitJobs(ctx context.Context, namespace string, jobs []*batchv1.Job) {
+	records := make([]models.CommitRecord, 0, len(jobs))
+	var finished []*batchv1.Job
+	for _, job := range jobs {
+		record := commitRecordFromJob(job)
+		if _, _, done := commitJobResult(job); done {
+			logs, err := c.imageJobLogs(ctx, namespace, job.Name, 200)
+			if err != nil {
+				c.log.Warn("Failed to get commit job logs for history",
+					zap.String("namespace", namespace),
+					zap.String("job", job.Name),
+					zap.Error(err))
+			}
+			info := parseCommitImageInfo(logs)
+			record.Digest, record.Size, record.BaseSize = info.Digest, info.Size, info.BaseSize
+			record.LogTail = tailString(logs, commitLogTailBytes)
+			finished = append(finished, job)
+		}
+		records = append(records, record)
+	}
+
+	if err := c.upsertCommitRecords(ctx, namespace, records); err != nil {
+		c.log.Warn("Failed to update commit history",
+			zap.String("namespace", namespace),
+			zap.Error(err))
+		return
+	}
+
+	for _, job := range finished {
+		marked := job.DeepCopy()
+		if marked.Annotations == nil {
+			marked.Annotations = make(map[string]string)
+		}
+		succeeded, _, _ := commitJobResult(job)
+		target := job.Annotations["genet.io/target-image"]
+		if succeeded && target != "" && job.Annotations["genet.io/image-saved"] != "true" {
+			image := &models.UserSavedImage{Image: target, SourcePod: job.Annotations["genet.io/source-pod"]}
+			if err := c.SaveUserImage(ctx, namespace, image); err != nil {
+				c.log.Warn("Failed to save user image from commit history",
+					zap.String("namespace", namespace),
+					zap.String("image", target),
+					zap.Error(err))
+				continue
+			}
+			marked.Annotations["genet.io/image-saved"] = "true"
+		}
+		marked.Annotations[commitHistoryRecordedAnnotation] = "true"
+		// 冲突时下一轮重新记录，写入历史是幂等的
+		_, _ = c.clientset.BatchV1().Jobs(namespace).Update(ctx, marked, metav1.UpdateOptions{})
+	}
+}
+
+// recordCommitSubmitted 提交后立即写入一条记录，排队中的任务也能在历史中看到
+func (c *Client) recordCommitSubmitted(ctx context.Context, job *batchv1.Job) {
+	if err := c.upsertCommitRecords(ctx, job.Namespace, []models.CommitRecord{commitRecordFromJob(job)}); err != nil {
+		c.log.Warn("Failed to record submitted commit job",
+			zap.String("namespace", job.Namespace),
+			zap.String("job", job.Name),
+			zap.Error(err))
+	}
+}
+
+// upsertCommitRecords 按 ID 合并记录，保留已有记录中新记录缺失的字段
+func (c *Client) upsertCommitRecords(ctx context.Context, namespace string, records []models.CommitRecord) error {
+	return c.updateCommitHistory(ctx, namespace, func(history *models.CommitHistory) bool {
+		index := make(map[string]int, len(history.Records))
+		for i, record := range history.Records {
+			index[record.ID] = i
+		}
+		for _, record := range records {
+			i, ok := index[record.ID]
+			if !ok {
+				history.Records = append(history.Records, record)
+				index[record.ID] = len(history.Records) - 1
+				continue
+			}
+			existing := history.Records[i]
+			if !existing.CreatedAt.IsZero() {
+				record.CreatedAt = existing.CreatedAt
+			}
+			if record.Digest == "" {
+				record.Digest = existing.Digest
+			}
+			if record.Size == 0 {
+				record.Size = existing.Size
+			}
+			if record.BaseSize == 0 {
+				record.BaseSize = existing.BaseSize
+			}
+			if record.LogTail == "" {
+				record.LogTail = existing.LogTail
+			}
+			history.Records[i] = record
+		}
+		return len(records) > 0
+	})
+}
+
+// updateCommitHistory 读取-修改-写回历史记录，冲突时重试；fn 返回 false 表示无需写回
+func (c *Client) updateCommitHistory(ctx context.Context, namespace string, fn func(*models.CommitHistory) bool) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		cm, err := c.clientset.CoreV1().ConfigMaps(namespace).Get(ctx, CommitHistoryConfigMapName, metav1.GetOptions{})
+		if err != nil && !k8serrors.IsNotFound(err) {
+			return err
+		}
+		exists := err == nil
+		if !exists {
+			cm = &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      CommitHistoryConfigMapName,
+					Namespace: namespace,
+					Labels: map[string]string{
+						"genet.io/type":    "commit-history",
+						"genet.io/managed": "true",
+					},
+				},
+			}
+		}
+
+		history := decodeCommitHistory(cm.Data)
+		if !fn(history) {
+			return nil
+		}
+		sort.SliceStable(history.Records, func(i, j int) bool {
+			return history.Records[i].CreatedAt.After(history.Records[j].CreatedAt)
+		})
+		if len(history.Records) > maxCommitHistoryRecords {
+			history.Records = history.Records[:maxCommitHistoryRecords]
+		}
+		data, err := encodeCommitHistory(history, maxCommitHistoryBytes)
+		if err != nil {
+			return err
+		}
+		if cm.Data == nil {
+			cm.Data = make(map[string]string)
+		}
+		cm.Data[CommitHistoryDataKey] = string(data)
+
+		if exists {
+			_, err = c.clientset.CoreV1().ConfigMaps(namespace).Update(ctx, cm, metav1.UpdateOptions{})
+		} else {
+			_, err = c.clientset.CoreV1().ConfigMaps(namespace).Create(ctx, cm, metav1.CreateOptions{})
+			if k8serrors.IsAlreadyExists(err) {
+				// 并发创建时按冲突处理，重新读取后再写
+				return k8serrors.NewConflict(corev1.Resource("configmaps"), CommitHistoryConfigMapName, err)
+			}
+		}
+		return err
+	})
+}
+
+// encodeCommitHistory 序列化历史记录（最新在前）并控制在 limit 字节内：
+// 先从最旧的记录开始丢弃日志末尾，仍然超出时丢弃最旧的记录
+func encodeCommitHistory(history *models.CommitHistory, limit int) ([]byte, error) {
+	data, err := json.Marshal(history)
+	if err != nil || len(data) <= limit {
+		return data, err
+	}
+
+	excess := len(data) - limit
+	for i := len(history.Records) - 1; i >= 0 && excess > 0; i-- {
+		if history.Records[i].LogTail == "" {
+			continue
+		}
+		quoted, _ := json.Marshal(history.Records[i].LogTail)
+		excess -= len(`,"logTail":`) + len(quoted)
+		history.Records[i].LogTail = ""
+	}
+	for excess > 0 && len(history.Records) > 0 {
+		last := len(history.Records) - 1
+		record, _ := json.Marshal(history.Records[last])
+		excess -= len(record) + 1
+		history.Records = history.Records[:last]
+	}
+
+	// 估算不含转义差异等误差，最终以实际序列化结果为准
+	for {
+		data, err = json.Marshal(history)
+		if err != nil || len(data) <= limit || len(history.Records) == 0 {
+			return data, err
+		}
+		history.Records = history.Records[:len(history.Records)-1]
+	}
+}
+
+func decodeCommitHistory(data map[string]string) *models.CommitHistory {
+	history := &models.CommitHistory{Records: []models.CommitRecord{}}
+	raw := data[CommitHistoryDataKey]
+	if raw == "" {
+		return history
+	}
+	if err := json.Unmarshal([]byte(raw), history); err != nil || history.Records == nil {
+		return &models.CommitHistory{Records: []models.CommitRecord{}}
+	}
+	return history
+}
+
+// commitRecordFromJob 根据 Job 当前状态生成历史记录（不含日志与镜像信息）
+func commitRecordFromJob(job *batchv1.Job) models.CommitRecord {
+	status := imageJobStatus(job)
+	record := models.CommitRecord{
+		ID:          job.Name,
+		TargetImage: status.TargetImage,
+		SourcePod:   status.SourcePod,
+		Status:      status.Status,
+		Message:     status.Message,
+		CreatedAt:   job.CreationTimestamp.Time,
+	}
+	if record.CreatedAt.IsZero() {
+		record.CreatedAt = time.Now()
+	}
+	if job.Status.StartTime != nil {
+		start := job.Status.StartTime.Time
+		record.StartTime = &start
+	}
+	if _, finishedAt, done := commitJobResult(job); done && !finishedAt.IsZero() {
+		record.EndTime = &finishedAt
+		if record.StartTime != nil {
+			record.DurationSeconds = int64(finishedAt.Sub(*record.StartTime) / time.Second)
+		}
+	}
+	return record
+}
+
+// commitImageInfo commit 脚本输出的镜像信息
+type commitImageInfo struct {
+	Digest   string
+	Size     int64 // 推送后的镜像大小
+	BaseSize int64 // 提交前容器所用镜像的大小
+}
+
+// parseCommitImageInfo 从 commit 日志中解析推送后的 digest 与镜像大小
+func parseCommitImageInfo(logs string) commitImageInfo {
+	var info commitImageInfo
+	for _, line := range strings.Split(logs, "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(line, commitDigestMarker):
+			info.Digest = strings.TrimPrefix(line, commitDigestMarker)
+		case strings.HasPrefix(line, commitSizeMarker):
+			info.Size = parseSizeMarker(line, commitSizeMarker)
+		case strings.HasPrefix(line, commitBaseSizeMarker):
+			info.BaseSize = parseSizeMarker(line, commitBaseSizeMarker)
+		}
+	}
+	return info
+}
+
+func parseSizeMarker(line, marker string) int64 {
+	value, err := strconv.ParseInt(strings.TrimPrefix(line, marker), 10, 64)
+	if err != nil {
+		return 0
+	}
+	return value
+}
+
+// tailString 保留末尾不超过 limit 字节，并从完整行开始
+func tailString(s string, limit int) string {
+	if len(s) <= limit {
+		return s
+	}
+	s = s[len(s)-limit:]
+	if i := strings.IndexByte(s, '\n'); i >= 0 && i < len(s)-1 {
+		s = s[i+1:]
+	}
+	return s
+}
+
+func isJobSuspended(job *batchv1.Job) bool {
+	return job.Spec.Suspend != nil && *job.Spec.Suspend
+}
+
+func isFinalCommitStatus(status string) bool {
+	return status == "Succeeded" || status == "Failed"
+}
@@ -0,0 +1,213 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/uc-package/genet/internal/models"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestCreateCommitJobQueuesBehindUnfinishedJob(t *testing.T) {
+	clientset := fake.NewSimpleClientset()
+	client := NewClientForTest(clientset, models.DefaultConfig())
+	ctx := context.Background()
+	spec := &CommitSpec{PodName: "pod-alice-dev", Namespace: "user-alice", Username: "alice", TargetImage: "registry.local/alice/a:v1", NodeName: "node-1", ContainerID: "containerd://abc123"}
+
+	first, err := client.CreateCommitJob(ctx, spec)
+	if err != nil {
+		t.Fatalf("first commit: %v", err)
+	}
+	time.Sleep(2 * time.Millisecond)
+	spec.TargetImage = "registry.local/alice/a:v2"
+	second, err := client.CreateCommitJob(ctx, spec)
+	if err != nil {
+		t.Fatalf("second commit: %v", err)
+	}
+
+	if isJobSuspended(first) || !isJobSuspended(second) {
+		t.Fatalf("expected only the second job queued, got first=%v second=%v", isJobSuspended(first), isJobSuspended(second))
+	}
+	history, err := client.GetCommitHistory(ctx, "user-alice")
+	if err != nil {
+		t.Fatalf("GetCommitHistory: %v", err)
+	}
+	if len(history.Records) != 2 {
+		t.Fatalf("expected 2 records, got %+v", history.Records)
+	}
+	statuses := map[string]string{}
+	for _, record := range history.Records {
+		statuses[record.ID] = record.Status
+	}
+	if statuses[first.Name] != "Pending" || statuses[second.Name] != "Queued" {
+		t.Fatalf("unexpected statuses %+v", statuses)
+	}
+}
+
+func TestSyncCommitHistoryRecordsFinishedJobAndReleasesQueue(t *testing.T) {
+	clientset := fake.NewSimpleClientset()
+	client := NewClientForTest(clientset, models.DefaultConfig())
+	ctx := context.Background()
+	spec := &CommitSpec{PodName: "pod-alice-dev", Namespace: "user-alice", Username: "alice", TargetImage: "registry.local/alice/a:v1", NodeName: "node-1", ContainerID: "containerd://abc123"}
+
+	first, _ := client.CreateCommitJob(ctx, spec)
+	time.Sleep(2 * time.Millisecond)
+	spec.TargetImage = "registry.local/alice/a:v2"
+	second, _ := client.CreateCommitJob(ctx, spec)
+
+	start := metav1.NewTime(time.Now().Add(-90 * time.Second))
+	done := metav1.NewTime(time.Now())
+	first.Status = batchv1.JobStatus{
+		StartTime:      &start,
+		CompletionTime: &done,
+		Succeeded:      1,
+		Conditions: []batchv1.JobCondition{{
+			Type:               batchv1.JobComplete,
+			Status:             corev1.ConditionTrue,
+			LastTransitionTime: done,
+		}},
+	}
+	if _, err := clientset.BatchV1().Jobs("user-alice").UpdateStatus(ctx, first, metav1.UpdateOptions{}); err != nil {
+		t.Fatalf("update status: %v", err)
+	}
+
+	if err := client.SyncCommitHistory(ctx, "user-alice"); err != nil {
+		t.Fatalf("SyncCommitHistory: %v", err)
+	}
+
+	released, _ := clientset.BatchV1().Jobs("user-alice").Get(ctx, second.Name, metav1.GetOptions{})
+	if isJobSuspended(released) {
+		t.Fatal("expected queued job to be released after the first finished")
+	}
+	recorded, _ := clientset.BatchV1().Jobs("user-alice").Get(ctx, first.Name, metav1.GetOptions{})
+	if recorded.Annotations[commitHistoryRecordedAnnotation] != "true" || recorded.Annotations["genet.io/image-saved"] != "true" {
+		t.Fatalf("expected finished job marked, got %+v", recorded.Annotations)
+	}
+
+	history, _ := client.GetCommitHistory(ctx, "user-alice")
+	var record models.CommitRecord
+	for _, r := range history.Records {
+		if r.ID == first.Name {
+			record = r
+		}
+	}
+	if record.Status != "Succeeded" || record.DurationSeconds != 90 || record.EndTime == nil || record.LogTail == "" {
+		t.Fatalf("unexpected record %+v", record)
+	}
+	images, _ := client.GetUserImages(ctx, "user-alice")
+	if len(images.Images) != 1 || images.Images[0].Image != "registry.local/alice/a:v1" {
+		t.Fatalf("expected image saved from history sync, got %+v", images.Images)
+	}
+}
+
+func TestSyncCommitHistoryFailsRecordsOfDeletedJobs(t *testing.T) {
+	clientset := fake.NewSimpleClientset()
+	client := NewClientForTest(clientset, models.DefaultConfig())
+	ctx := context.Background()
+	if err := client.upsertCommitRecords(ctx, "user-alice", []models.CommitRecord{
+		{ID: "commit-alice-1", Status: "Running", CreatedAt: time.Now()},
+		{ID: "commit-alice-0", Status: "Succeeded", CreatedAt: time.Now().Add(-time.Hour)},
+	}); err != nil {
+		t.Fatalf("upsert: %v", err)
+	}
+
+	if err := client.SyncCommitHistory(ctx, "user-alice"); err != nil {
+		t.Fatalf("SyncCommitHistory: %v", err)
+	}
+	history, _ := client.GetCommitHistory(ctx, "user-alice")
+	if history.Records[0].Status != "Failed" || history.Records[1].Status != "Succeeded" {
+		t.Fatalf("unexpected records %+v", history.Records)
+	}
+}
+
+func TestUpsertCommitRecordsKeepsNewestAndMergesFields(t *testing.T) {
+	client := NewClientForTest(fake.NewSimpleClientset(), models.DefaultConfig())
+	ctx := context.Background()
+	base := time.Now()
+	var records []models.CommitRecord
+	for i := 0; i < maxCommitHistoryRecords+5; i++ {
+		records = append(records, models.CommitRecord{ID: fmt.Sprintf("job-%03d", i), CreatedAt: base.Add(time.Duration(i) * time.Second)})
+	}
+	records[len(records)-1].Digest = "sha256:abc"
+	if err := client.upsertCommitRecords(ctx, "user-alice", records); err != nil {
+		t.Fatalf("upsert: %v", err)
+	}
+	latest := records[len(records)-1]
+	latest.Digest = ""
+	latest.Status = "Succeeded"
+	if err := client.upsertCommitRecords(ctx, "user-alice", []models.CommitRecord{latest}); err != nil {
+		t.Fatalf("upsert: %v", err)
+	}
+
+	history, _ := client.GetCommitHistory(ctx, "user-alice")
+	if len(history.Records) != maxCommitHistoryRecords {
+		t.Fatalf("expected %d records, got %d", maxCommitHistoryRecords, len(history.Records))
+	}
+	if history.Records[0].ID != latest.ID || history.Records[0].Digest != "sha256:abc" || history.Records[0].Status != "Succeeded" {
+		t.Fatalf("unexpected newest record %+v", history.Records[0])
+	}
+}
+
+func TestParseCommitImageInfo(t *testing.T) {
+	logs := "GENET_BASE_IMAGE_SIZE=1073741824\nPushing image\nGENET_IMAGE_DIGEST=sha256:0123\nGENET_IMAGE_SIZE=2147483648\n=== SUCCESS ===\n"
+	info := parseCommitImageInfo(logs)
+	if info.Digest != "sha256:0123" || info.Size != 2147483648 || info.BaseSize != 1073741824 {
+		t.Fatalf("unexpected info %+v", info)
+	}
+	if info := parseCommitImageInfo("GENET_IMAGE_SIZE=\n"); info.Digest != "" || info.Size != 0 {
+		t.Fatalf("expected empty info, got %+v", info)
+	}
+}
+
+func TestTailString(t *testing.T) {
+	if got := tailString("short", 10); got != "short" {
+		t.Fatalf("unexpected %q", got)
+	}
+	if got := tailString("line1\nline2\nline3\n", 10); got != "line3\n" {
+		t.Fatalf("expected tail to start at a full line, got %q", got)
+	}
+}
+
+func TestEncodeCommitHistoryStaysWithinBudget(t *testing.T) {
+	newHistory := func() *models.CommitHistory {
+		history := &models.CommitHistory{}
+		base := time.Date(2026, 3, 15, 10, 0, 0, 0, time.UTC)
+		for i := 0; i < maxCommitHistoryRecords; i++ {
+			history.Records = append(history.Records, models.CommitRecord{
+				ID:          fmt.Sprintf("commit-alice-%03d", i),
+				TargetImage: "registry.local/alice/train:v1",
+				Status:      "Failed",
+				CreatedAt:   base.Add(-time.Duration(i) * time.Minute),
+				// 引号和控制字符在 JSON 中会被转义，实际体积大于日志本身
+				LogTail: strings.Repeat("\"<\x01", commitLogTailBytes/3),
+			})
+		}
+		return history
+	}
+
+	// 日志末尾足够腾出空间时只丢弃最旧记录的日志
+	history := newHistory()
+	data, err := encodeCommitHistory(history, maxCommitHistoryBytes)
+	if err != nil || len(data) > maxCommitHistoryBytes {
+		t.Fatalf("expected history within %d bytes, got %d err=%v", maxCommitHistoryBytes, len(data), err)
+	}
+	if len(history.Records) != maxCommitHistoryRecords || history.Records[0].LogTail == "" || history.Records[len(history.Records)-1].LogTail != "" {
+		t.Fatalf("expected oldest log tails to be trimmed first, got %d records", len(history.Records))
+	}
+
+	// 去掉全部日志仍超出时丢弃最旧的记录
+	history = newHistory()
+	data, err = encodeCommitHistory(history, 8*1024)
+	if err != nil || len(data) > 8*1024 || len(history.Records) == 0 {
+		t.Fatalf("expected history within budget, got %d bytes, %d records, err=%v", len(data), len(history.Records), err)
+	}
+	if history.Records[0].ID != "commit-alice-000" || len(history.Records) >= maxCommitHistoryRecords {
+		t.Fatalf("expected oldest records to be dropped, got %d records starting at %s", len(history.Records), history.Records[0].ID)
+	}
+}
@@ -0,0 +1,94 @@
+package k8s
+
+import (
+	"context"
+	"time"
+
+	"github.com/uc-package/genet/internal/metrics"
+	"go.uber.org/zap"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	commitJobMetricsInterval      = 30 * time.Second
+	commitJobMetricsRecordedLabel = "genet.io/metrics-recorded"
+)
+
+// StartCommitJobMetrics 定期扫描结束的 commit Job 并记录耗时与结果。
+// Job 在集群里异步运行，这里用注解标记已记录的 Job；多副本下依赖 resourceVersion 冲突保证每个 Job 只记录一次
+func (c *Client) StartCommitJobMetrics(ctx context.Context) {
+	// 启动前已结束的 Job 只打标记不计数，避免首次部署时把历史任务一次性计入
+	since := time.Now().Add(-commitJobMetricsInterval)
+	go func() {
+		ticker := time.NewTicker(commitJobMetricsInterval)
+		defer ticker.Stop()
+		for {
+			c.recordFinishedCommitJobs(ctx, since)
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+func (c *Client) recordFinishedCommitJobs(ctx context.Context, since time.Time) {
+	jobs, err := c.clientset.BatchV1().Jobs(metav1.NamespaceAll).List(ctx, metav1.ListOptions{
+		LabelSelector: "genet.io/type=commit",
+	})
+	if err != nil {
+		c.log.Warn("Failed to list commit jobs for metrics", zap.Error(err))
+		return
+	}
+	for i := range jobs.Items {
+		job := &jobs.Items[i]
+		if job.Annotations[commitJobMetricsRecordedLabel] == "true" {
+			continue
+		}
+		succeeded, finishedAt, ok := commitJobResult(job)
+		if !ok {
+			continue
+		}
+
+		marked := job.DeepCopy()
+		if marked.Annotations == nil {
+			marked.Annotations = make(map[string]string)
+		}
+		marked.Annotations[commitJobMetricsRecordedLabel] = "true"
+		if _, err := c.clientset.BatchV1().Jobs(job.Namespace).Update(ctx, marked, metav1.UpdateOptions{}); err != nil {
+			// 冲突说明其他副本已记录或 Job 刚被修改，下一轮再看
+			continue
+		}
+		if finishedAt.Before(since) {
+			continue
+		}
+		var duration time.Duration
+		if job.Status.StartTime != nil {
+			duration = finishedAt.Sub(job.Status.StartTime.Time)
+		}
+		metrics.RecordCommitJob(succeeded, duration)
+	}
+}
+
+// commitJobResult 返回 Job 是否成功及结束时间；未结束时 ok 为 false
+func commitJobResult(job *batchv1.Job) (succeeded bool, finishedAt time.Time, ok bool) {
+	for _, condition := range job.Status.Conditions {
+		if condition.Status != corev1.ConditionTrue {
+			continue
+		}
+		switch condition.Type {
+		case batchv1.JobComplete:
+			finishedAt = condition.LastTransitionTime.Time
+			if job.Status.CompletionTime != nil {
+				finishedAt = job.Status.CompletionTime.Time
+			}
+			return true, finishedAt, true
+		case batchv1.JobFailed:
+			return false, condition.LastTransitionTime.Time, true
+		}
+	}
+	return false, time.Time{}, false
+}
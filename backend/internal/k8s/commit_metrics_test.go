@@ -0,0 +1,65 @@
+package k8s
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/uc-package/genet/internal/models"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func newCommitMetricsTestJob(name string, conditionType batchv1.JobConditionType, finishedAt time.Time) *batchv1.Job {
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "user-alice",
+			Labels:    map[string]string{"genet.io/type": "commit"},
+		},
+		Status: batchv1.JobStatus{StartTime: &metav1.Time{Time: finishedAt.Add(-time.Minute)}},
+	}
+	if conditionType != "" {
+		job.Status.Conditions = []batchv1.JobCondition{{
+			Type:               conditionType,
+			Status:             corev1.ConditionTrue,
+			LastTransitionTime: metav1.Time{Time: finishedAt},
+		}}
+	}
+	return job
+}
+
+func TestRecordFinishedCommitJobsMarksJobsOnce(t *testing.T) {
+	now := time.Now()
+	clientset := fake.NewSimpleClientset(
+		newCommitMetricsTestJob("commit-ok", batchv1.JobComplete, now),
+		newCommitMetricsTestJob("commit-failed", batchv1.JobFailed, now),
+		newCommitMetricsTestJob("commit-old", batchv1.JobComplete, now.Add(-time.Hour)),
+		newCommitMetricsTestJob("commit-running", "", now),
+	)
+	client := NewClientForTest(clientset, models.DefaultConfig())
+
+	client.recordFinishedCommitJobs(context.Background(), now.Add(-time.Minute))
+
+	for name, wantMarked := range map[string]bool{"commit-ok": true, "commit-failed": true, "commit-old": true, "commit-running": false} {
+		job, err := clientset.BatchV1().Jobs("user-alice").Get(context.Background(), name, metav1.GetOptions{})
+		if err != nil {
+			t.Fatalf("get %s: %v", name, err)
+		}
+		if marked := job.Annotations[commitJobMetricsRecordedLabel] == "true"; marked != wantMarked {
+			t.Fatalf("job %s marked=%v, want %v", name, marked, wantMarked)
+		}
+	}
+}
+
+func TestCommitJobResult(t *testing.T) {
+	finishedAt := time.Unix(1773568800, 0)
+	if ok, at, done := commitJobResult(newCommitMetricsTestJob("a", batchv1.JobFailed, finishedAt)); ok || !done || !at.Equal(finishedAt) {
+		t.Fatalf("unexpected failed result ok=%v at=%v done=%v", ok, at, done)
+	}
+	if _, _, done := commitJobResult(newCommitMetricsTestJob("b", "", finishedAt)); done {
+		t.Fatal("running job should not be finished")
+	}
+}
@@ -0,0 +1,188 @@
+package k8s
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+const (
+	// defaultCommitHookTimeoutSeconds 未配置 commit.hookTimeoutSeconds 时预提交脚本的超时
+	defaultCommitHookTimeoutSeconds = 300
+	// maxCommitHookBytes 预提交脚本的最大长度，脚本会内联到 Job 参数中
+	maxCommitHookBytes = 16 * 1024
+)
+
+var (
+	// ErrInvalidCommitExclude 排除规则格式不合法
+	ErrInvalidCommitExclude = errors.New("排除规则必须是绝对路径或 **/名称 形式的 glob")
+	// ErrCommitExcludesRequireSquash 显式指定了排除规则但未开启 squash：
+	// 不合并层时删除只会新增 whiteout 层，原文件仍留在下层，镜像不会变小
+	ErrCommitExcludesRequireSquash = errors.New("排除路径需要开启合并提交层（squash），否则镜像体积不会减小")
+)
+
+// commitExcludePattern 排除规则只允许路径和 glob 字符，规则会原样写入 shell 脚本
+var commitExcludePattern = regexp.MustCompile(`^[A-Za-z0-9._\-*?\[\]/]+$`)
+
+// ValidateCommitExcludes 校验排除规则：
+//   - **/名称：删除任意目录下名称匹配的文件或目录，如 **/__pycache__
+//   - 绝对路径：可包含 glob，如 /root/.cache/pip、/tmp/*.log，首级目录不能是 glob
+func ValidateCommitExcludes(patterns []string) error {
+	for _, pattern := range patterns {
+		if err := validateCommitExclude(pattern); err != nil {
+			return fmt.Errorf("%q: %w", pattern, err)
+		}
+	}
+	return nil
+}
+
+func validateCommitExclude(pattern string) error {
+	if !commitExcludePattern.MatchString(pattern) {
+		return ErrInvalidCommitExclude
+	}
+	for _, segment := range strings.Split(pattern, "/") {
+		if segment == ".." {
+			return ErrInvalidCommitExclude
+		}
+	}
+	if name, ok := strings.CutPrefix(pattern, "**/"); ok {
+		if name == "" || strings.Contains(name, "/") || strings.Contains(name, "**") {
+			return ErrInvalidCommitExclude
+		}
+		return nil
+	}
+	if !strings.HasPrefix(pattern, "/") || strings.Contains(pattern, "**") {
+		return ErrInvalidCommitExclude
+	}
+	first := strings.SplitN(strings.TrimPrefix(pattern, "/"), "/", 2)[0]
+	if first == "" || first == "." || strings.ContainsAny(first, "*?[") {
+		return ErrInvalidCommitExclude
+	}
+	return nil
+}
+
+// ResolveCommitExcludes 返回实际生效的排除规则：开启 squash 时原样返回；
+// 未开启时，required（用户显式指定了排除路径）返回 ErrCommitExcludesRequireSquash，否则跳过排除
+func ResolveCommitExcludes(excludes []string, required, squash bool) ([]string, error) {
+	if squash || len(excludes) == 0 {
+		return excludes, nil
+	}
+	if required {
+		return nil, ErrCommitExcludesRequireSquash
+	}
+	return nil, nil
+}
+
+// MergeCommitExcludes 合并管理员默认规则与用户规则并去重，保持原有顺序
+func MergeCommitExcludes(defaults, extra []string) []string {
+	seen := make(map[string]bool, len(defaults)+len(extra))
+	var merged []string
+	for _, pattern := range append(append([]string{}, defaults...), extra...) {
+		pattern = strings.TrimSpace(pattern)
+		if pattern == "" || seen[pattern] {
+			continue
+		}
+		seen[pattern] = true
+		merged = append(merged, pattern)
+	}
+	return merged
+}
+
+// ValidateCommitHook 校验预提交脚本长度
+func ValidateCommitHook(script string) error {
+	if len(script) > maxCommitHookBytes {
+		return fmt.Errorf("预提交脚本不能超过 %d 字节", maxCommitHookBytes)
+	}
+	return nil
+}
+
+// buildCommitExcludeScript 生成在临时容器中删除排除路径的脚本
+func buildCommitExcludeScript(patterns []string) string {
+	var b strings.Builder
+	for _, pattern := range patterns {
+		if name, ok := strings.CutPrefix(pattern, "**/"); ok {
+			// -xdev 不进入 /proc、/sys 等其他文件系统
+			fmt.Fprintf(&b, "find / -xdev -name '%s' -prune -exec rm -rf {} + 2>/dev/null || true\n", name)
+			continue
+		}
+		// 绝对路径不加引号，由 shell 展开 glob；未匹配时 rm -f 忽略
+		fmt.Fprintf(&b, "rm -rf -- %s 2>/dev/null || true\n", pattern)
+	}
+	return b.String()
+}
+
+// buildCommitHookSection 生成在源容器内执行预提交脚本的片段，脚本经 base64 传入避免转义问题
+func (c *Client) buildCommitHookSection(driver commitRuntimeDriver, hook string) string {
+	if strings.TrimSpace(hook) == "" {
+		return ""
+	}
+	timeout := c.config.Commit.HookTimeoutSeconds
+	if timeout <= 0 {
+		timeout = defaultCommitHookTimeoutSeconds
+	}
+	return fmt.Sprintf(`
+# 预提交清理脚本：在源容器内执行，会直接修改正在运行的容器
+echo "Running pre-commit hook in container (timeout %ds)..."
+echo '%s' | base64 -d > /tmp/genet-pre-commit.sh
+if ! timeout %d %s exec -i "$CONTAINER_ID" /bin/sh -s < /tmp/genet-pre-commit.sh; then
+    echo "ERROR: pre-commit hook failed or timed out"
+    exit 1
+fi
+echo "Pre-commit hook finished"
+`, timeout, base64.StdEncoding.EncodeToString([]byte(hook)), timeout, driver.CLI())
+}
+
+// buildCommitImageSection 生成 commit 片段。
+// 有排除规则时先提交为临时镜像，在临时容器中删除排除路径后再带 $SQUASH_FLAG 提交为目标镜像，
+// 源容器本身不受影响。删除只有合并层后才能减小体积，运行时不支持 squash（$SQUASH_FLAG 为空）时：
+// required 为 true 直接失败，否则跳过排除、直接提交源容器。
+func buildCommitImageSection(driver commitRuntimeDriver, targetImage string, excludes []string, required bool) string {
+	cli := driver.CLI()
+	direct := fmt.Sprintf(`echo "Committing container to image: %s"
+%s commit $SQUASH_FLAG "$CONTAINER_ID" %s
+`, targetImage, cli, targetImage)
+	if len(excludes) == 0 {
+		return direct
+	}
+
+	fallback := `echo "WARNING: squash is unavailable, skipping default excludes"
+` + direct
+	if required {
+		fallback = `echo "ERROR: squash is unavailable, excluded paths cannot be removed from the image"
+exit 1
+`
+	}
+	script := buildCommitExcludeScript(excludes)
+	return fmt.Sprintf(`if [ -z "$SQUASH_FLAG" ]; then
+%selse
+CLI="%s"
+STAGE_NAME="genet-commit-stage-$HOSTNAME"
+STAGE_IMAGE="genet-commit-stage:$HOSTNAME"
+cleanup_stage() {
+    $CLI rm -f "$STAGE_NAME" >/dev/null 2>&1 || true
+    $CLI rmi -f "$STAGE_IMAGE" >/dev/null 2>&1 || true
+}
+trap cleanup_stage EXIT
+
+echo "Committing container to staging image..."
+$CLI commit "$CONTAINER_ID" "$STAGE_IMAGE"
+
+# 临时容器替换了 entrypoint，提交时恢复原镜像的 ENTRYPOINT/CMD
+ENTRYPOINT=$($CLI image inspect --format '{{json .Config.Entrypoint}}' "$STAGE_IMAGE" 2>/dev/null || echo null)
+CMD=$($CLI image inspect --format '{{json .Config.Cmd}}' "$STAGE_IMAGE" 2>/dev/null || echo null)
+[ "$ENTRYPOINT" = "null" ] && ENTRYPOINT="[]"
+[ "$CMD" = "null" ] && CMD="[]"
+
+echo "Removing excluded paths:"
+cat > /tmp/genet-exclude.sh <<'GENET_EXCLUDE_EOF'
+%sGENET_EXCLUDE_EOF
+cat /tmp/genet-exclude.sh
+$CLI run -i --name "$STAGE_NAME" --network none --user 0 --entrypoint /bin/sh "$STAGE_IMAGE" -s < /tmp/genet-exclude.sh
+
+echo "Committing container to image: %s"
+$CLI commit $SQUASH_FLAG --change "ENTRYPOINT $ENTRYPOINT" --change "CMD $CMD" "$STAGE_NAME" %s
+fi
+`, fallback, cli, script, targetImage, targetImage)
+}
@@ -0,0 +1,169 @@
+package k8s
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/uc-package/genet/internal/models"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestValidateCommitExcludes(t *testing.T) {
+	valid := []string{"**/__pycache__", "**/*.pyc", "/root/.cache/pip", "/tmp/*.log", "/opt/conda/pkgs/[a-z]*"}
+	if err := ValidateCommitExcludes(valid); err != nil {
+		t.Fatalf("expected valid patterns, got %v", err)
+	}
+	for _, pattern := range []string{
+		"/",
+		"/*",
+		"/u*/lib",
+		"relative/path",
+		"**/a/b",
+		"/root/**/cache",
+		"/root/../etc",
+		"/root/.cache; rm -rf /",
+		"/root/$HOME",
+		"",
+	} {
+		err := ValidateCommitExcludes([]string{pattern})
+		if !errors.Is(err, ErrInvalidCommitExclude) {
+			t.Fatalf("%q: expected ErrInvalidCommitExclude, got %v", pattern, err)
+		}
+	}
+}
+
+func TestMergeCommitExcludes(t *testing.T) {
+	got := MergeCommitExcludes([]string{"**/__pycache__", "/root/.cache/pip"}, []string{" /root/.cache/pip ", "", "**/node_modules"})
+	want := []string{"**/__pycache__", "/root/.cache/pip", "**/node_modules"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+}
+
+func TestBuildCommitExcludeScript(t *testing.T) {
+	script := buildCommitExcludeScript([]string{"**/__pycache__", "/root/.cache/pip"})
+	for _, want := range []string{
+		"find / -xdev -name '__pycache__' -prune -exec rm -rf {} +",
+		"rm -rf -- /root/.cache/pip",
+	} {
+		if !strings.Contains(script, want) {
+			t.Fatalf("expected %q in script:\n%s", want, script)
+		}
+	}
+}
+
+func TestBuildCommitScriptWithOptions(t *testing.T) {
+	cfg := models.DefaultConfig()
+	cfg.Commit.HookTimeoutSeconds = 60
+	client := NewClientForTest(fake.NewSimpleClientset(), cfg)
+
+	plain := client.buildCommitScript(&CommitSpec{PodName: "pod-alice-dev", TargetImage: "registry.local/alice/a:v1"}, containerdCommitDriver{}, "abc123", false)
+	if strings.Contains(plain, "STAGE_IMAGE") || strings.Contains(plain, "genet-pre-commit.sh") || strings.Contains(plain, `SQUASH_FLAG="--squash"`) {
+		t.Fatalf("plain commit should not stage, run hooks or squash:\n%s", plain)
+	}
+	if !strings.Contains(plain, "GENET_BASE_IMAGE_SIZE=") {
+		t.Fatalf("expected base image size marker:\n%s", plain)
+	}
+
+	script := client.buildCommitScript(&CommitSpec{
+		PodName:       "pod-alice-dev",
+		TargetImage:   "registry.local/alice/a:v2",
+		Excludes:      []string{"**/__pycache__"},
+		Squash:        true,
+		PreCommitHook: "pip cache purge",
+	}, containerdCommitDriver{}, "abc123", false)
+	for _, want := range []string{
+		"timeout 60 nerdctl -n k8s.io exec -i \"$CONTAINER_ID\"",
+		`SQUASH_FLAG="--squash"`,
+		`CLI="nerdctl -n k8s.io"`,
+		`$CLI commit "$CONTAINER_ID" "$STAGE_IMAGE"`,
+		"find / -xdev -name '__pycache__'",
+		`"$STAGE_NAME" registry.local/alice/a:v2`,
+	} {
+		if !strings.Contains(script, want) {
+			t.Fatalf("expected %q in script:\n%s", want, script)
+		}
+	}
+	// 预提交脚本以 base64 传入，不会原样出现在脚本中
+	if strings.Contains(script, "pip cache purge") {
+		t.Fatalf("hook should be base64 encoded:\n%s", script)
+	}
+	// 只有默认规则时，运行时不支持 squash 就跳过排除
+	if !strings.Contains(script, "skipping default excludes") || strings.Contains(script, "excluded paths cannot be removed") {
+		t.Fatalf("default excludes should be skipped without squash support:\n%s", script)
+	}
+
+	required := client.buildCommitScript(&CommitSpec{
+		PodName:          "pod-alice-dev",
+		TargetImage:      "registry.local/alice/a:v3",
+		Excludes:         []string{"/root/.cache/*"},
+		ExcludesRequired: true,
+		Squash:           true,
+	}, containerdCommitDriver{}, "abc123", false)
+	if !strings.Contains(required, "excluded paths cannot be removed from the image\"\nexit 1") {
+		t.Fatalf("explicit excludes should fail without squash support:\n%s", required)
+	}
+
+	// 未开启 squash 时默认排除规则不生效，不做无意义的暂存提交
+	unsquashed := client.buildCommitScript(&CommitSpec{
+		PodName:     "pod-alice-dev",
+		TargetImage: "registry.local/alice/a:v4",
+		Excludes:    []string{"**/__pycache__"},
+	}, containerdCommitDriver{}, "abc123", false)
+	if strings.Contains(unsquashed, "STAGE_IMAGE") || strings.Contains(unsquashed, "__pycache__") {
+		t.Fatalf("excludes without squash should be skipped:\n%s", unsquashed)
+	}
+}
+
+func TestResolveCommitExcludes(t *testing.T) {
+	excludes := []string{"**/__pycache__"}
+	if got, err := ResolveCommitExcludes(excludes, true, true); err != nil || len(got) != 1 {
+		t.Fatalf("expected excludes kept with squash, got %v err=%v", got, err)
+	}
+	if got, err := ResolveCommitExcludes(excludes, false, false); err != nil || got != nil {
+		t.Fatalf("expected default excludes skipped without squash, got %v err=%v", got, err)
+	}
+	if _, err := ResolveCommitExcludes(excludes, true, false); !errors.Is(err, ErrCommitExcludesRequireSquash) {
+		t.Fatalf("expected ErrCommitExcludesRequireSquash, got %v", err)
+	}
+}
+
+func TestCreateCommitJobRejectsInvalidOptions(t *testing.T) {
+	client := NewClientForTest(fake.NewSimpleClientset(), models.DefaultConfig())
+	_, err := client.CreateCommitJob(context.Background(), &CommitSpec{
+		PodName:     "pod-alice-dev",
+		Namespace:   "user-alice",
+		Username:    "alice",
+		TargetImage: "registry.local/alice/a:v1",
+		ContainerID: "containerd://abc123",
+		Excludes:    []string{"/"},
+	})
+	if !errors.Is(err, ErrInvalidCommitExclude) {
+		t.Fatalf("expected ErrInvalidCommitExclude, got %v", err)
+	}
+	_, err = client.CreateCommitJob(context.Background(), &CommitSpec{
+		PodName:       "pod-alice-dev",
+		Namespace:     "user-alice",
+		Username:      "alice",
+		TargetImage:   "registry.local/alice/a:v1",
+		ContainerID:   "containerd://abc123",
+		PreCommitHook: strings.Repeat("x", maxCommitHookBytes+1),
+	})
+	if err == nil {
+		t.Fatal("expected oversized hook rejected")
+	}
+	_, err = client.CreateCommitJob(context.Background(), &CommitSpec{
+		PodName:          "pod-alice-dev",
+		Namespace:        "user-alice",
+		Username:         "alice",
+		TargetImage:      "registry.local/alice/a:v1",
+		ContainerID:      "containerd://abc123",
+		Excludes:         []string{"/root/.cache/*"},
+		ExcludesRequired: true,
+	})
+	if !errors.Is(err, ErrCommitExcludesRequireSquash) {
+		t.Fatalf("expected ErrCommitExcludesRequireSquash, got %v", err)
+	}
+}
@@ -0,0 +1,156 @@
+package k8s
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/uc-package/genet/internal/models"
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	// defaultDockerCLIImage 未配置 images.docker 时使用的 Docker CLI 镜像
+	defaultDockerCLIImage = "docker:24-cli"
+)
+
+var (
+	// ErrContainerIDNotFound Pod 状态中还没有容器 ID（容器未启动或状态尚未上报）
+	ErrContainerIDNotFound = errors.New("Pod 状态中没有容器 ID，请等待容器启动后重试")
+	// ErrUnsupportedContainerRuntime 节点容器运行时不支持镜像保存
+	ErrUnsupportedContainerRuntime = errors.New("不支持的容器运行时")
+)
+
+// containerIDPattern 容器 ID 会写入 shell 脚本，只允许十六进制等安全字符
+var containerIDPattern = regexp.MustCompile(`^[A-Za-z0-9]+$`)
+
+// commitHostPath commit Job 需要挂载的宿主机路径
+type commitHostPath struct {
+	Name string
+	Path string
+}
+
+// commitRuntimeDriver 容器运行时驱动，按 containerID 前缀（containerd://、docker://）选择，
+// 负责提供执行 commit 的工具镜像、宿主机挂载以及与运行时相关的脚本片段
+type commitRuntimeDriver interface {
+	// Name 运行时名称，写入 Job 注解
+	Name() string
+	// Image commit Job 使用的工具镜像
+	Image(cfg *models.Config) string
+	// HostPaths 需要挂载到 Job 容器同路径下的宿主机 socket 或存储目录
+	HostPaths() []commitHostPath
+	// CLI 操作节点容器的命令前缀，如 "nerdctl -n k8s.io"
+	CLI() string
+	// SquashSection 设置 $SQUASH_FLAG 的脚本片段，运行时不支持时跳过层合并
+	SquashSection(squash bool) string
+	// PushCommand 推送镜像的命令
+	PushCommand(image string, insecure bool) string
+}
+
+// commitRuntimeDriverFor 解析 Pod 状态中的 containerID（<runtime>://<id>），返回对应驱动与容器 ID
+func commitRuntimeDriverFor(containerID string) (commitRuntimeDriver, string, error) {
+	runtime, id, ok := strings.Cut(containerID, "://")
+	if !ok || id == "" {
+		return nil, "", ErrContainerIDNotFound
+	}
+	if !containerIDPattern.MatchString(id) {
+		return nil, "", fmt.Errorf("无效的容器 ID: %s", containerID)
+	}
+	switch runtime {
+	case "containerd":
+		return containerdCommitDriver{}, id, nil
+	case "docker":
+		return dockerCommitDriver{}, id, nil
+	case "cri-o":
+		// CRI-O 的容器元数据不在 podman 的数据库里，podman 无法 inspect/exec/commit 这些容器
+		return nil, "", fmt.Errorf("%w: cri-o（podman 无法操作 CRI-O 管理的容器，暂不支持在 CRI-O 节点保存镜像）", ErrUnsupportedContainerRuntime)
+	default:
+		return nil, "", fmt.Errorf("%w: %s", ErrUnsupportedContainerRuntime, runtime)
+	}
+}
+
+// WorkspaceContainerID 返回 Pod 主容器（workspace）的 containerID，找不到时退回第一个已上报 ID 的容器
+func WorkspaceContainerID(pod *corev1.Pod) string {
+	if pod == nil {
+		return ""
+	}
+	fallback := ""
+	for _, status := range pod.Status.ContainerStatuses {
+		if status.ContainerID == "" {
+			continue
+		}
+		if status.Name == "workspace" {
+			return status.ContainerID
+		}
+		if fallback == "" {
+			fallback = status.ContainerID
+		}
+	}
+	return fallback
+}
+
+// containerdCommitDriver containerd 节点，通过 nerdctl 操作 k8s.io 命名空间
+type containerdCommitDriver struct{}
+
+func (containerdCommitDriver) Name() string { return "containerd" }
+
+func (containerdCommitDriver) Image(cfg *models.Config) string { return cfg.Images.Nerdctl }
+
+func (containerdCommitDriver) HostPaths() []commitHostPath {
+	return []commitHostPath{{Name: "containerd-sock", Path: "/run/containerd/containerd.sock"}}
+}
+
+func (containerdCommitDriver) CLI() string { return "nerdctl -n k8s.io" }
+
+func (containerdCommitDriver) SquashSection(squash bool) string {
+	if !squash {
+		return `SQUASH_FLAG=""`
+	}
+	return `SQUASH_FLAG=""
+if nerdctl commit --help 2>&1 | grep -q -- '--squash'; then
+    SQUASH_FLAG="--squash"
+else
+    echo "WARNING: nerdctl commit does not support --squash, layers will not be squashed"
+fi`
+}
+
+func (containerdCommitDriver) PushCommand(image string, insecure bool) string {
+	// --insecure-registry 需要宿主机 containerd 也配置了 insecure registry 才能生效
+	// 参考文档：需要在 K8s 节点上配置 /etc/containerd/certs.d/<registry>/hosts.toml
+	if insecure {
+		return "nerdctl -n k8s.io --insecure-registry push " + image
+	}
+	return "nerdctl -n k8s.io push " + image
+}
+
+// dockerCommitDriver Docker 节点（cri-dockerd），通过 docker CLI 操作宿主机 dockerd
+type dockerCommitDriver struct{}
+
+func (dockerCommitDriver) Name() string { return "docker" }
+
+func (dockerCommitDriver) Image(cfg *models.Config) string {
+	if cfg.Images.Docker != "" {
+		return cfg.Images.Docker
+	}
+	return defaultDockerCLIImage
+}
+
+func (dockerCommitDriver) HostPaths() []commitHostPath {
+	return []commitHostPath{{Name: "docker-sock", Path: "/var/run/docker.sock"}}
+}
+
+func (dockerCommitDriver) CLI() string { return "docker" }
+
+func (dockerCommitDriver) SquashSection(squash bool) string {
+	if !squash {
+		return `SQUASH_FLAG=""`
+	}
+	return `SQUASH_FLAG=""
+echo "WARNING: docker commit does not support --squash, layers will not be squashed"`
+}
+
+func (dockerCommitDriver) PushCommand(image string, insecure bool) string {
+	// HTTP 仓库需要在宿主机 dockerd 的 insecure-registries 中配置
+	return "docker push " + image
+}
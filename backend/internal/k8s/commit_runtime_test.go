@@ -0,0 +1,127 @@
+package k8s
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/uc-package/genet/internal/models"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestCommitRuntimeDriverFor(t *testing.T) {
+	for containerID, want := range map[string]string{
+		"containerd://0123abcd": "containerd",
+		"docker://0123abcd":     "docker",
+	} {
+		driver, id, err := commitRuntimeDriverFor(containerID)
+		if err != nil {
+			t.Fatalf("%s: %v", containerID, err)
+		}
+		if driver.Name() != want || id != "0123abcd" {
+			t.Fatalf("%s: got driver %s id %q", containerID, driver.Name(), id)
+		}
+	}
+
+	if _, _, err := commitRuntimeDriverFor(""); !errors.Is(err, ErrContainerIDNotFound) {
+		t.Fatalf("expected ErrContainerIDNotFound, got %v", err)
+	}
+	for _, containerID := range []string{"rkt://0123", "cri-o://0123abcd"} {
+		if _, _, err := commitRuntimeDriverFor(containerID); !errors.Is(err, ErrUnsupportedContainerRuntime) {
+			t.Fatalf("%s: expected ErrUnsupportedContainerRuntime, got %v", containerID, err)
+		}
+	}
+	if _, _, err := commitRuntimeDriverFor("containerd://abc;rm -rf /"); err == nil {
+		t.Fatal("expected unsafe container ID rejected")
+	}
+}
+
+func TestWorkspaceContainerID(t *testing.T) {
+	pod := &corev1.Pod{Status: corev1.PodStatus{ContainerStatuses: []corev1.ContainerStatus{
+		{Name: "sidecar", ContainerID: "containerd://sidecar1"},
+		{Name: "workspace", ContainerID: "containerd://workspace1"},
+	}}}
+	if got := WorkspaceContainerID(pod); got != "containerd://workspace1" {
+		t.Fatalf("expected workspace container, got %q", got)
+	}
+	pod.Status.ContainerStatuses[1].ContainerID = ""
+	if got := WorkspaceContainerID(pod); got != "containerd://sidecar1" {
+		t.Fatalf("expected fallback container, got %q", got)
+	}
+	if got := WorkspaceContainerID(&corev1.Pod{}); got != "" {
+		t.Fatalf("expected empty ID, got %q", got)
+	}
+}
+
+func TestBuildCommitScriptPerRuntime(t *testing.T) {
+	cfg := models.DefaultConfig()
+	cfg.Registry.Insecure = true
+	client := NewClientForTest(fake.NewSimpleClientset(), cfg)
+	spec := &CommitSpec{PodName: "pod-alice-dev", TargetImage: "registry.local/alice/a:v1", Squash: true}
+
+	tests := []struct {
+		driver commitRuntimeDriver
+		want   []string
+	}{
+		{containerdCommitDriver{}, []string{
+			`nerdctl -n k8s.io inspect "$CONTAINER_ID"`,
+			`nerdctl -n k8s.io commit $SQUASH_FLAG "$CONTAINER_ID" registry.local/alice/a:v1`,
+			"nerdctl -n k8s.io --insecure-registry push registry.local/alice/a:v1",
+		}},
+		{dockerCommitDriver{}, []string{
+			`docker inspect "$CONTAINER_ID"`,
+			`docker commit $SQUASH_FLAG "$CONTAINER_ID" registry.local/alice/a:v1`,
+			"docker push registry.local/alice/a:v1",
+			"docker commit does not support --squash",
+		}},
+	}
+	for _, tt := range tests {
+		script := client.buildCommitScript(spec, tt.driver, "0123abcd", true)
+		if !strings.Contains(script, "CONTAINER_ID=0123abcd") {
+			t.Fatalf("%s: expected container ID from pod status", tt.driver.Name())
+		}
+		// 不再按 Pod 名称或 workspace 搜索节点上的容器
+		if strings.Contains(script, "grep -i") {
+			t.Fatalf("%s: script should not search containers by name", tt.driver.Name())
+		}
+		for _, want := range tt.want {
+			if !strings.Contains(script, want) {
+				t.Fatalf("%s: expected %q in script:\n%s", tt.driver.Name(), want, script)
+			}
+		}
+	}
+}
+
+func TestCreateCommitJobMountsRuntimeHostPaths(t *testing.T) {
+	client := NewClientForTest(fake.NewSimpleClientset(), models.DefaultConfig())
+	job, err := client.CreateCommitJob(context.Background(), &CommitSpec{
+		PodName:     "pod-alice-dev",
+		Namespace:   "user-alice",
+		Username:    "alice",
+		TargetImage: "registry.local/alice/a:v1",
+		NodeName:    "node-1",
+		ContainerID: "docker://0123abcd",
+	})
+	if err != nil {
+		t.Fatalf("CreateCommitJob: %v", err)
+	}
+	podSpec := job.Spec.Template.Spec
+	if podSpec.Containers[0].Image != "docker:24-cli" || job.Annotations["genet.io/container-runtime"] != "docker" {
+		t.Fatalf("unexpected image %q annotations %+v", podSpec.Containers[0].Image, job.Annotations)
+	}
+	paths := map[string]bool{}
+	for _, volume := range podSpec.Volumes {
+		if volume.HostPath != nil {
+			paths[volume.HostPath.Path] = true
+		}
+	}
+	if !paths["/var/run/docker.sock"] || paths["/run/containerd/containerd.sock"] {
+		t.Fatalf("unexpected host paths %v", paths)
+	}
+
+	if _, err := client.CreateCommitJob(context.Background(), &CommitSpec{PodName: "pod-alice-dev", Namespace: "user-alice", Username: "alice", TargetImage: "a:v1"}); !errors.Is(err, ErrContainerIDNotFound) {
+		t.Fatalf("expected ErrContainerIDNotFound without container ID, got %v", err)
+	}
+}
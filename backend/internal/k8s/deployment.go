@@ -123,6 +123,12 @@ func (c *Client) CreateDeployment(ctx context.Context, spec *DeploymentSpec) (*a
 		},
 	}
 
+	pullSecrets, err := c.ensureImagePullSecrets(ctx, spec.Namespace, spec.Username, spec.Email)
+	if err != nil {
+		return nil, err
+	}
+	deploy.Spec.Template.Spec.ImagePullSecrets = pullSecrets
+
 	c.log.Info("Creating deployment",
 		zap.String("name", spec.Name),
 		zap.String("namespace", spec.Namespace),
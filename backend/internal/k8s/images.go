@@ -92,6 +92,10 @@ func (c *Client) DeleteUserImage(ctx context.Context, namespace, imageName strin
 
 // saveUserImageList 保存镜像列表到 ConfigMap
 func (c *Client) saveUserImageList(ctx context.Context, namespace string, imageList *models.UserImageList) error {
+	// registry 元数据随时可能变化，只在查询时获取
+	for i := range imageList.Images {
+		imageList.Images[i].Manifest = nil
+	}
 	data, err := json.Marshal(imageList)
 	if err != nil {
 		return err
@@ -36,6 +36,11 @@ func (c *Client) BuildJobFromOpenAPIRequest(ctx context.Context, namespace, owne
 		return nil, err
 	}
 
+	imagePullSecrets, err := c.ensureImagePullSecrets(ctx, namespace, ownerUser, "")
+	if err != nil {
+		return nil, err
+	}
+
 	restartPolicy := corev1.RestartPolicyNever
 	if req.RestartPolicy != "" {
 		restartPolicy = corev1.RestartPolicy(req.RestartPolicy)
@@ -67,6 +72,7 @@ func (c *Client) BuildJobFromOpenAPIRequest(ctx context.Context, namespace, owne
 					AutomountServiceAccountToken: boolPtr(false),
 					HostNetwork:                  runtimeSpec.HostNetwork,
 					RestartPolicy:                restartPolicy,
+					ImagePullSecrets:             imagePullSecrets,
 					Containers:                   []corev1.Container{runtimeSpec.Container},
 					Volumes:                      runtimeSpec.Volumes,
 					NodeSelector:                 runtimeSpec.NodeSelector,
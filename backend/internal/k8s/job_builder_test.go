@@ -6,10 +6,12 @@ import (
 
 	"github.com/uc-package/genet/internal/logger"
 	"github.com/uc-package/genet/internal/models"
+	"k8s.io/client-go/kubernetes/fake"
 )
 
 func TestBuildJobFromRequestUsesSharedRuntimeResources(t *testing.T) {
 	client := &Client{
+		clientset: fake.NewSimpleClientset(),
 		config: &models.Config{
 			Pod: models.PodConfig{
 				StartupScript: "echo ready",
@@ -197,7 +197,7 @@ func (c *Client) ForceDeleteNamespace(ctx context.Context, namespace string) err
 			}
 		}
 		if len(failures) > 0 {
-			return fmt.Errorf(strings.Join(failures, "; "))
+			return fmt.Errorf("%s", strings.Join(failures, "; "))
 		}
 		return nil
 	}
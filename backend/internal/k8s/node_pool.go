@@ -6,6 +6,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/uc-package/genet/internal/metrics"
 	"github.com/uc-package/genet/internal/models"
 	"go.uber.org/zap"
 	corev1 "k8s.io/api/core/v1"
@@ -80,7 +81,9 @@ func (c *Client) SyncNodePoolTaints(ctx context.Context) error {
 	return c.syncNodePoolTaints(ctx, cfg)
 }
 
-func (c *Client) syncNodePoolTaints(ctx context.Context, cfg resolvedNodePoolConfig) error {
+func (c *Client) syncNodePoolTaints(ctx context.Context, cfg resolvedNodePoolConfig) (err error) {
+	defer func() { metrics.RecordNodePoolSync(err) }()
+
 	nodes, err := c.clientset.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
 	if err != nil {
 		return fmt.Errorf("list nodes failed: %w", err)
@@ -96,6 +99,7 @@ func (c *Client) syncNodePoolTaints(ctx context.Context, cfg resolvedNodePoolCon
 		}
 		if changed {
 			updatedCount++
+			metrics.RecordNodePoolTaintChange(action)
 			c.log.Info("Node pool taint reconciled",
 				zap.String("node", node.Name),
 				zap.String("action", action))
@@ -38,9 +38,11 @@ type PodSpec struct {
 	NodeName   string             // 指定调度节点（可选）
 	GPUDevices []int              // 指定 GPU 卡编号（可选），如 [0, 2, 5]
 	UserMounts []models.UserMount // 用户自定义挂载（可选）
+	ProxyPorts []int              // 开放给通用应用代理的端口（可选）
 }
 
 type PodLogOptions struct {
+	Container  string // 为空时由 API Server 选择（单容器 Pod）
 	TailLines  int64
 	Previous   bool
 	Follow     bool
@@ -50,6 +52,7 @@ type PodLogOptions struct {
 
 func buildPodLogOptions(options PodLogOptions) *corev1.PodLogOptions {
 	podLogOptions := &corev1.PodLogOptions{
+		Container:  options.Container,
 		Previous:   options.Previous,
 		Follow:     options.Follow,
 		Timestamps: options.Timestamps,
@@ -231,6 +234,7 @@ echo "Proxy configured: HTTP_PROXY=%s, HTTPS_PROXY=%s"
 	err = tmpl.Execute(&scriptBuf, map[string]interface{}{
 		"ProxyScript":      proxySetupScript,
 		"CodeServerScript": buildCodeServerStartupScript(c.config.Pod.CodeServer),
+		"SSHScript":        buildSSHStartupScript(c.config.Pod.SSH),
 	})
 	if err != nil {
 		c.log.Error("Failed to render startup script", zap.Error(err))
@@ -522,6 +526,10 @@ echo "Proxy configured: HTTP_PROXY=%s, HTTPS_PROXY=%s"
 		},
 	}
 
+	if proxyPorts := FormatPodProxyPorts(spec.ProxyPorts); proxyPorts != "" {
+		pod.Annotations[PodProxyPortsAnnotation] = proxyPorts
+	}
+
 	// 应用 RuntimeClassName（共享模式下可能需要）
 	if runtimeClassName != nil {
 		pod.Spec.RuntimeClassName = runtimeClassName
@@ -592,8 +600,36 @@ echo "Proxy configured: HTTP_PROXY=%s, HTTPS_PROXY=%s"
 		}
 	}
 
+	pullSecrets, err := c.ensureImagePullSecrets(ctx, spec.Namespace, spec.Username, spec.Email)
+	if err != nil {
+		return nil, err
+	}
+	pod.Spec.ImagePullSecrets = pullSecrets
+
+	// 托管 SSH：hostNetwork 下每个 Pod 需要集群内唯一端口，通过集群级预留表分配
+	reservedSSHPort := 0
+	if c.config.Pod.SSH.Enabled {
+		sshPort := c.config.Pod.SSH.Port
+		if sshPort <= 0 {
+			sshPort = 22
+		}
+		if pod.Spec.HostNetwork {
+			sshPort, err = c.reserveSSHHostPort(ctx, spec.Namespace, spec.Name)
+			if err != nil {
+				c.log.Error("Failed to allocate ssh port", zap.String("name", spec.Name), zap.Error(err))
+				return nil, err
+			}
+			reservedSSHPort = sshPort
+		}
+		applyPodSSH(pod, sshPort)
+		c.log.Debug("SSH configured for pod", zap.String("name", spec.Name), zap.Int("port", sshPort))
+	}
+
 	createdPod, err := c.clientset.CoreV1().Pods(spec.Namespace).Create(ctx, pod, metav1.CreateOptions{})
 	if err != nil {
+		if reservedSSHPort > 0 {
+			c.releaseSSHHostPort(ctx, reservedSSHPort, spec.Namespace, spec.Name)
+		}
 		c.log.Error("Failed to create pod",
 			zap.String("name", spec.Name),
 			zap.String("namespace", spec.Namespace),
@@ -819,6 +855,17 @@ func (c *Client) GetPodLogs(ctx context.Context, namespace, name string, options
 	return string(logs), nil
 }
 
+// GetPodEvents 获取 Pod 相关的 Events
+func (c *Client) GetPodEvents(ctx context.Context, namespace, name string) ([]corev1.Event, error) {
+	events, err := c.clientset.CoreV1().Events(namespace).List(ctx, metav1.ListOptions{
+		FieldSelector: fmt.Sprintf("involvedObject.name=%s,involvedObject.kind=Pod", name),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return events.Items, nil
+}
+
 // StreamPodLogs 获取 Pod 日志流
 func (c *Client) StreamPodLogs(ctx context.Context, namespace, name string, options PodLogOptions) (io.ReadCloser, error) {
 	c.log.Debug("Streaming pod logs",
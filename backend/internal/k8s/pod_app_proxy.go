@@ -0,0 +1,170 @@
+package k8s
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/uc-package/genet/internal/models"
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/retry"
+)
+
+const (
+	// PodProxyPortsAnnotation 记录 Pod 主动开放给通用应用代理的端口，如 "6006,7860"
+	PodProxyPortsAnnotation = "genet.io/proxy-ports"
+	// PodProxySharesAnnotation 记录 Pod 上有效的应用分享链接（JSON），不在其中的分享链接视为已撤销
+	PodProxySharesAnnotation = "genet.io/proxy-shares"
+	// maxPodProxyShares 每个 Pod 同时有效的分享链接上限
+	maxPodProxyShares = 20
+)
+
+// ErrTooManyPodProxyShares Pod 上有效的分享链接已达上限
+var ErrTooManyPodProxyShares = errors.New("有效的分享链接过多，请先撤销不再使用的链接")
+
+// GetPodProxyPorts 读取 Pod 开放的代理端口（已排序去重）
+func GetPodProxyPorts(pod *corev1.Pod) []int {
+	if pod == nil {
+		return nil
+	}
+	return normalizeProxyPorts(parseGPUDevices(pod.Annotations[PodProxyPortsAnnotation]))
+}
+
+// FormatPodProxyPorts 将端口列表格式化为 annotation 值
+func FormatPodProxyPorts(ports []int) string {
+	return intsToCommaString(normalizeProxyPorts(ports))
+}
+
+// UpdatePodProxyPorts 更新 Pod 开放的代理端口
+func (c *Client) UpdatePodProxyPorts(ctx context.Context, namespace, name string, ports []int) (*corev1.Pod, error) {
+	pod, err := c.clientset.CoreV1().Pods(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	if pod.Annotations == nil {
+		pod.Annotations = map[string]string{}
+	}
+	value := FormatPodProxyPorts(ports)
+	if value == "" {
+		delete(pod.Annotations, PodProxyPortsAnnotation)
+	} else {
+		pod.Annotations[PodProxyPortsAnnotation] = value
+	}
+
+	updated, err := c.clientset.CoreV1().Pods(namespace).Update(ctx, pod, metav1.UpdateOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("更新代理端口失败: %w", err)
+	}
+	c.log.Info("Pod proxy ports updated",
+		zap.String("name", name),
+		zap.String("namespace", namespace),
+		zap.String("ports", value))
+	return updated, nil
+}
+
+func normalizeProxyPorts(ports []int) []int {
+	if len(ports) == 0 {
+		return nil
+	}
+	seen := make(map[int]struct{}, len(ports))
+	result := make([]int, 0, len(ports))
+	for _, port := range ports {
+		if port <= 0 || port > 65535 {
+			continue
+		}
+		if _, ok := seen[port]; ok {
+			continue
+		}
+		seen[port] = struct{}{}
+		result = append(result, port)
+	}
+	sort.Ints(result)
+	return result
+}
+
+// GetPodProxyShares 读取 Pod 上尚未过期的分享链接
+func GetPodProxyShares(pod *corev1.Pod, now time.Time) []models.PodProxyShare {
+	if pod == nil || pod.Annotations[PodProxySharesAnnotation] == "" {
+		return nil
+	}
+	var shares []models.PodProxyShare
+	if err := json.Unmarshal([]byte(pod.Annotations[PodProxySharesAnnotation]), &shares); err != nil {
+		return nil
+	}
+	active := shares[:0]
+	for _, share := range shares {
+		if share.ID != "" && now.Before(share.ExpiresAt) {
+			active = append(active, share)
+		}
+	}
+	return active
+}
+
+// AddPodProxyShare 在 Pod 上登记分享链接，顺带清理已过期的记录
+func (c *Client) AddPodProxyShare(ctx context.Context, namespace, name string, share models.PodProxyShare) error {
+	return c.updatePodProxyShares(ctx, namespace, name, func(shares []models.PodProxyShare) ([]models.PodProxyShare, error) {
+		if len(shares) >= maxPodProxyShares {
+			return nil, ErrTooManyPodProxyShares
+		}
+		return append(shares, share), nil
+	})
+}
+
+// RevokePodProxyShare 撤销分享链接，返回链接是否存在
+func (c *Client) RevokePodProxyShare(ctx context.Context, namespace, name, id string) (bool, error) {
+	found := false
+	err := c.updatePodProxyShares(ctx, namespace, name, func(shares []models.PodProxyShare) ([]models.PodProxyShare, error) {
+		found = false
+		kept := make([]models.PodProxyShare, 0, len(shares))
+		for _, share := range shares {
+			if share.ID == id {
+				found = true
+				continue
+			}
+			kept = append(kept, share)
+		}
+		return kept, nil
+	})
+	if err != nil {
+		return false, err
+	}
+	if found {
+		c.log.Info("Pod proxy share revoked",
+			zap.String("name", name),
+			zap.String("namespace", namespace),
+			zap.String("share", id))
+	}
+	return found, nil
+}
+
+func (c *Client) updatePodProxyShares(ctx context.Context, namespace, name string, mutate func([]models.PodProxyShare) ([]models.PodProxyShare, error)) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		pod, err := c.clientset.CoreV1().Pods(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		shares, err := mutate(GetPodProxyShares(pod, time.Now()))
+		if err != nil {
+			return err
+		}
+		if pod.Annotations == nil {
+			pod.Annotations = map[string]string{}
+		}
+		if len(shares) == 0 {
+			delete(pod.Annotations, PodProxySharesAnnotation)
+		} else {
+			data, err := json.Marshal(shares)
+			if err != nil {
+				return err
+			}
+			pod.Annotations[PodProxySharesAnnotation] = string(data)
+		}
+		_, err = c.clientset.CoreV1().Pods(namespace).Update(ctx, pod, metav1.UpdateOptions{})
+		return err
+	})
+}
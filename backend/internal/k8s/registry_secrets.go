@@ -0,0 +1,197 @@
+package k8s
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/uc-package/genet/internal/models"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// pullSecretPrefix 为 pull 仓库生成的 imagePullSecret 名称前缀
+	pullSecretPrefix = "registry-pull-"
+	// userCredentialSecretPrefix 用户登记的仓库凭据 Secret 名称前缀
+	userCredentialSecretPrefix = "registry-cred-"
+	// userCredentialSecretType 用户仓库凭据 Secret 的 genet.io/type 标签值
+	userCredentialSecretType = "user-registry-credential"
+
+	registryHostAnnotation     = "genet.io/registry"
+	registryUsernameAnnotation = "genet.io/registry-username"
+	registryUpdatedAnnotation  = "genet.io/updated-at"
+
+	// dockerHubHost Docker Hub 的镜像名前缀；kubelet 按 index.docker.io/v1/ 匹配其凭据
+	dockerHubHost    = "docker.io"
+	dockerHubAuthKey = "https://index.docker.io/v1/"
+)
+
+// ErrPushRegistryNotAllowed 目标镜像不在用户的推送仓库中
+var ErrPushRegistryNotAllowed = errors.New("目标镜像不属于当前用户的推送仓库")
+
+// pushRegistryFor 保存/构建镜像时使用的仓库：目标镜像必须位于用户的推送仓库（PushRegistryFor）下，
+// 推送到其它已配置仓库（其他团队的仓库、只用于 pull 的仓库）会泄露其凭据，返回 ErrPushRegistryNotAllowed；
+// 未配置任何推送仓库时不限制目标，也不带凭据
+func (c *Client) pushRegistryFor(username, email, image string) (models.RegistryConfig, error) {
+	registry := c.config.PushRegistryFor(username, email)
+	if registry == nil {
+		return models.RegistryConfig{}, nil
+	}
+	if host := registry.Host(); host != "" && strings.HasPrefix(image, host+"/") {
+		return *registry, nil
+	}
+	return models.RegistryConfig{}, fmt.Errorf("%w: %s", ErrPushRegistryNotAllowed, registry.Host())
+}
+
+// ensureImagePullSecrets 在用户命名空间中为每个用途包含 pull 且配置了凭据的仓库维护一个 docker-config Secret，
+// 限定了 users 的仓库只下发给命中的用户；返回工作负载应引用的 imagePullSecrets（另含用户自行登记的仓库凭据），都没有时返回 nil
+func (c *Client) ensureImagePullSecrets(ctx context.Context, namespace, username, email string) ([]corev1.LocalObjectReference, error) {
+	var refs []corev1.LocalObjectReference
+	for _, registry := range c.config.RegistriesFor(models.RegistryPurposePull) {
+		if len(registry.Users) > 0 && !registry.MatchesUser(username, email) {
+			continue
+		}
+		dockerConfigJSON := buildDockerConfigJSON(registry)
+		if dockerConfigJSON == "" {
+			continue
+		}
+		secretName := SanitizeK8sName(pullSecretPrefix + registry.DisplayName())
+		if err := c.ensureRegistrySecret(ctx, namespace, secretName, dockerConfigJSON); err != nil {
+			return nil, fmt.Errorf("创建 imagePullSecret %s 失败: %w", secretName, err)
+		}
+		refs = append(refs, corev1.LocalObjectReference{Name: secretName})
+	}
+
+	// 用户自行登记的仓库凭据
+	secrets, err := c.listUserRegistryCredentialSecrets(ctx, namespace)
+	if err != nil {
+		return nil, fmt.Errorf("获取用户仓库凭据失败: %w", err)
+	}
+	for _, secret := range secrets {
+		refs = append(refs, corev1.LocalObjectReference{Name: secret.Name})
+	}
+	return refs, nil
+}
+
+// NormalizeRegistryHost 去掉协议和路径，得到镜像名前缀形式的仓库 host；Docker Hub 的各种写法统一为 docker.io
+func NormalizeRegistryHost(raw string) string {
+	host := strings.ToLower(strings.TrimSpace(raw))
+	if u, err := url.Parse(host); err == nil && u.Host != "" {
+		host = u.Host
+	}
+	if i := strings.IndexByte(host, '/'); i >= 0 {
+		host = host[:i]
+	}
+	switch host {
+	case "index.docker.io", "registry-1.docker.io", "registry.hub.docker.com":
+		return dockerHubHost
+	}
+	return host
+}
+
+// UserRegistryCredentialSecretName 用户仓库凭据的 Secret 名称，每个仓库一个
+func UserRegistryCredentialSecretName(host string) string {
+	return userCredentialSecretPrefix + SanitizeK8sName(strings.ReplaceAll(host, ":", "-"))
+}
+
+// ListUserRegistryCredentials 获取用户命名空间中登记的仓库凭据
+func (c *Client) ListUserRegistryCredentials(ctx context.Context, namespace string) ([]models.RegistryCredential, error) {
+	secrets, err := c.listUserRegistryCredentialSecrets(ctx, namespace)
+	if err != nil {
+		return nil, err
+	}
+	credentials := make([]models.RegistryCredential, 0, len(secrets))
+	for i := range secrets {
+		credentials = append(credentials, registryCredentialFromSecret(&secrets[i]))
+	}
+	return credentials, nil
+}
+
+// SaveUserRegistryCredential 以 docker-config Secret 保存仓库凭据，同一仓库已存在时覆盖
+func (c *Client) SaveUserRegistryCredential(ctx context.Context, namespace, host, username, password string) (*models.RegistryCredential, error) {
+	authKey := host
+	if host == dockerHubHost {
+		authKey = dockerHubAuthKey
+	}
+	dockerConfigJSON := buildDockerConfigJSON(models.RegistryConfig{URL: authKey, Username: username, Password: password})
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      UserRegistryCredentialSecretName(host),
+			Namespace: namespace,
+			Labels: map[string]string{
+				"genet.io/type":    userCredentialSecretType,
+				"genet.io/managed": "true",
+			},
+			Annotations: map[string]string{
+				registryHostAnnotation:     host,
+				registryUsernameAnnotation: username,
+				registryUpdatedAnnotation:  time.Now().UTC().Format(time.RFC3339),
+			},
+		},
+		Type: corev1.SecretTypeDockerConfigJson,
+		Data: map[string][]byte{
+			corev1.DockerConfigJsonKey: []byte(dockerConfigJSON),
+		},
+	}
+
+	saved, err := c.clientset.CoreV1().Secrets(namespace).Create(ctx, secret, metav1.CreateOptions{})
+	if apierrors.IsAlreadyExists(err) {
+		saved, err = c.clientset.CoreV1().Secrets(namespace).Update(ctx, secret, metav1.UpdateOptions{})
+	}
+	if err != nil {
+		return nil, err
+	}
+	credential := registryCredentialFromSecret(saved)
+	return &credential, nil
+}
+
+// DeleteUserRegistryCredential 删除仓库凭据，返回是否存在；只删除用户登记的凭据 Secret
+func (c *Client) DeleteUserRegistryCredential(ctx context.Context, namespace, name string) (bool, error) {
+	secret, err := c.clientset.CoreV1().Secrets(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	if secret.Labels["genet.io/type"] != userCredentialSecretType {
+		return false, nil
+	}
+	if err := c.clientset.CoreV1().Secrets(namespace).Delete(ctx, name, metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+		return false, err
+	}
+	return true, nil
+}
+
+// listUserRegistryCredentialSecrets 按名称排序，保证生成的 imagePullSecrets 顺序稳定
+func (c *Client) listUserRegistryCredentialSecrets(ctx context.Context, namespace string) ([]corev1.Secret, error) {
+	list, err := c.clientset.CoreV1().Secrets(namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: "genet.io/type=" + userCredentialSecretType,
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(list.Items, func(i, j int) bool { return list.Items[i].Name < list.Items[j].Name })
+	return list.Items, nil
+}
+
+func registryCredentialFromSecret(secret *corev1.Secret) models.RegistryCredential {
+	credential := models.RegistryCredential{
+		Name:     secret.Name,
+		Registry: secret.Annotations[registryHostAnnotation],
+		Username: secret.Annotations[registryUsernameAnnotation],
+	}
+	if updatedAt, err := time.Parse(time.RFC3339, secret.Annotations[registryUpdatedAnnotation]); err == nil {
+		credential.UpdatedAt = updatedAt
+	} else {
+		credential.UpdatedAt = secret.CreationTimestamp.Time
+	}
+	return credential
+}
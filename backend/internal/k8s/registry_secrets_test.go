@@ -0,0 +1,220 @@
+package k8s
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/uc-package/genet/internal/models"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestCreatePodAttachesPullRegistrySecrets(t *testing.T) {
+	clientset := fake.NewSimpleClientset()
+	cfg := models.DefaultConfig()
+	cfg.Registry = models.RegistryConfig{URL: "registry.local", Username: "robot", Password: "push"}
+	cfg.Registries = []models.RegistryConfig{
+		{Name: "mirror", URL: "mirror.local", Username: "reader", Password: "pull", Purposes: []string{models.RegistryPurposePull}},
+		{Name: "public-proxy", URL: "proxy.local", Purposes: []string{models.RegistryPurposePull}},
+	}
+	client := NewClientForTest(clientset, cfg)
+
+	pod, err := client.CreatePod(context.Background(), &PodSpec{
+		Name:      "pod-alice-dev",
+		Namespace: "user-alice",
+		Username:  "alice",
+		Image:     "mirror.local/library/ubuntu:22.04",
+	})
+	if err != nil {
+		t.Fatalf("CreatePod: %v", err)
+	}
+
+	// 只有配置了凭据的 pull 仓库生成 Secret；推送仓库的凭据不会挂到用户工作负载上
+	if len(pod.Spec.ImagePullSecrets) != 1 || pod.Spec.ImagePullSecrets[0].Name != "registry-pull-mirror" {
+		t.Fatalf("unexpected imagePullSecrets: %+v", pod.Spec.ImagePullSecrets)
+	}
+	secret, err := clientset.CoreV1().Secrets("user-alice").Get(context.Background(), "registry-pull-mirror", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("get pull secret: %v", err)
+	}
+	var dockerConfig struct {
+		Auths map[string]map[string]string `json:"auths"`
+	}
+	if err := json.Unmarshal(secret.Data[".dockerconfigjson"], &dockerConfig); err != nil {
+		t.Fatalf("decode docker config: %v", err)
+	}
+	if len(dockerConfig.Auths) != 1 || dockerConfig.Auths["mirror.local"]["auth"] == "" {
+		t.Fatalf("unexpected docker config: %+v", dockerConfig.Auths)
+	}
+}
+
+func TestPushRegistryRestrictedToUserAssignment(t *testing.T) {
+	cfg := models.DefaultConfig()
+	cfg.Registry = models.RegistryConfig{URL: "registry.local", Username: "robot", Password: "push"}
+	cfg.Registries = []models.RegistryConfig{
+		{Name: "team", URL: "harbor.team.local", Username: "team-robot", Password: "team", Insecure: true, Users: []string{"alice"}},
+		{Name: "mirror", URL: "mirror.local", Username: "reader", Password: "pull", Purposes: []string{models.RegistryPurposePull}},
+	}
+	client := NewClientForTest(fake.NewSimpleClientset(), cfg)
+
+	registry, err := client.pushRegistryFor("alice", "", "harbor.team.local/alice/train:v1")
+	if err != nil || registry.Username != "team-robot" || !registry.Insecure {
+		t.Fatalf("unexpected push registry for team member: %+v, %v", registry, err)
+	}
+	// 不在 users 中的用户不能推送到团队仓库，也不能借用 pull 仓库或默认仓库以外的凭据
+	for _, image := range []string{"harbor.team.local/bob/train:v1", "mirror.local/bob/train:v1", "other.local/bob/train:v1"} {
+		if _, err := client.pushRegistryFor("bob", "", image); !errors.Is(err, ErrPushRegistryNotAllowed) {
+			t.Fatalf("expected ErrPushRegistryNotAllowed for bob -> %s, got %v", image, err)
+		}
+	}
+	if registry, err := client.pushRegistryFor("bob", "", "registry.local/bob/train:v1"); err != nil || registry.Username != "robot" {
+		t.Fatalf("expected default push registry for bob, got %+v, %v", registry, err)
+	}
+
+	_, err = client.CreateCommitJob(context.Background(), &CommitSpec{
+		PodName:     "pod-bob-dev",
+		Namespace:   "user-bob",
+		Username:    "bob",
+		TargetImage: "harbor.team.local/bob/train:v1",
+		NodeName:    "node-1",
+		ContainerID: "containerd://abc",
+	})
+	if !errors.Is(err, ErrPushRegistryNotAllowed) {
+		t.Fatalf("expected commit to team registry to be rejected, got %v", err)
+	}
+	if got := buildDockerConfigJSON(models.RegistryConfig{URL: "anonymous.local"}); got != "" {
+		t.Fatalf("expected no docker config without credentials, got %q", got)
+	}
+}
+
+func TestPullSecretsRespectRegistryUsers(t *testing.T) {
+	clientset := fake.NewSimpleClientset()
+	cfg := models.DefaultConfig()
+	cfg.Registries = []models.RegistryConfig{
+		{Name: "team-mirror", URL: "team-mirror.local", Username: "reader", Password: "pull", Purposes: []string{models.RegistryPurposePull}, Users: []string{"*@team.example.com"}},
+	}
+	client := NewClientForTest(clientset, cfg)
+
+	refs, err := client.ensureImagePullSecrets(context.Background(), "user-bob", "bob", "bob@example.com")
+	if err != nil || len(refs) != 0 {
+		t.Fatalf("expected no pull secret for non-member, got %+v, %v", refs, err)
+	}
+	if _, err := clientset.CoreV1().Secrets("user-bob").Get(context.Background(), "registry-pull-team-mirror", metav1.GetOptions{}); err == nil {
+		t.Fatal("team pull credentials must not be written to a non-member namespace")
+	}
+	refs, err = client.ensureImagePullSecrets(context.Background(), "user-alice", "alice", "alice@team.example.com")
+	if err != nil || len(refs) != 1 || refs[0].Name != "registry-pull-team-mirror" {
+		t.Fatalf("expected team pull secret for member, got %+v, %v", refs, err)
+	}
+}
+
+func TestUserRegistryCredentialsAttachedToPodsAndJobs(t *testing.T) {
+	ctx := context.Background()
+	clientset := fake.NewSimpleClientset()
+	cfg := models.DefaultConfig()
+	cfg.Registries = []models.RegistryConfig{
+		{Name: "mirror", URL: "mirror.local", Username: "reader", Password: "pull", Purposes: []string{models.RegistryPurposePull}},
+	}
+	client := NewClientForTest(clientset, cfg)
+
+	saved, err := client.SaveUserRegistryCredential(ctx, "user-alice", "ghcr.io", "alice", "ghp_token")
+	if err != nil {
+		t.Fatalf("SaveUserRegistryCredential: %v", err)
+	}
+	if saved.Name != "registry-cred-ghcr-io" || saved.Registry != "ghcr.io" || saved.Username != "alice" || saved.UpdatedAt.IsZero() {
+		t.Fatalf("unexpected credential: %+v", saved)
+	}
+	if _, err := client.SaveUserRegistryCredential(ctx, "user-alice", "docker.io", "alice", "hub"); err != nil {
+		t.Fatalf("SaveUserRegistryCredential docker.io: %v", err)
+	}
+	// 同一仓库再次保存时覆盖
+	if _, err := client.SaveUserRegistryCredential(ctx, "user-alice", "ghcr.io", "alice-bot", "ghp_new"); err != nil {
+		t.Fatalf("SaveUserRegistryCredential overwrite: %v", err)
+	}
+
+	credentials, err := client.ListUserRegistryCredentials(ctx, "user-alice")
+	if err != nil {
+		t.Fatalf("ListUserRegistryCredentials: %v", err)
+	}
+	if len(credentials) != 2 || credentials[0].Registry != "docker.io" || credentials[1].Username != "alice-bot" {
+		t.Fatalf("unexpected credentials: %+v", credentials)
+	}
+	hub, err := clientset.CoreV1().Secrets("user-alice").Get(ctx, "registry-cred-docker-io", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("get docker hub secret: %v", err)
+	}
+	var dockerConfig struct {
+		Auths map[string]map[string]string `json:"auths"`
+	}
+	if err := json.Unmarshal(hub.Data[".dockerconfigjson"], &dockerConfig); err != nil {
+		t.Fatalf("decode docker config: %v", err)
+	}
+	if dockerConfig.Auths["https://index.docker.io/v1/"]["auth"] == "" {
+		t.Fatalf("expected docker hub auth key, got %+v", dockerConfig.Auths)
+	}
+
+	pod, err := client.CreatePod(ctx, &PodSpec{
+		Name:      "pod-alice-dev",
+		Namespace: "user-alice",
+		Username:  "alice",
+		Image:     "ghcr.io/alice/train:v1",
+	})
+	if err != nil {
+		t.Fatalf("CreatePod: %v", err)
+	}
+	want := []string{"registry-pull-mirror", "registry-cred-docker-io", "registry-cred-ghcr-io"}
+	assertPullSecrets(t, pod.Spec.ImagePullSecrets, want)
+
+	job, err := client.BuildJobFromOpenAPIRequest(ctx, "user-alice", "alice", &models.OpenAPIJobRequest{
+		Name:  "job-train",
+		Image: "ghcr.io/alice/train:v1",
+	})
+	if err != nil {
+		t.Fatalf("BuildJobFromOpenAPIRequest: %v", err)
+	}
+	assertPullSecrets(t, job.Spec.Template.Spec.ImagePullSecrets, want)
+
+	// 只删除用户登记的凭据 Secret
+	if found, err := client.DeleteUserRegistryCredential(ctx, "user-alice", "registry-pull-mirror"); err != nil || found {
+		t.Fatalf("expected admin pull secret to be protected, found=%v err=%v", found, err)
+	}
+	if found, err := client.DeleteUserRegistryCredential(ctx, "user-alice", "registry-cred-ghcr-io"); err != nil || !found {
+		t.Fatalf("DeleteUserRegistryCredential: found=%v err=%v", found, err)
+	}
+	if credentials, _ := client.ListUserRegistryCredentials(ctx, "user-alice"); len(credentials) != 1 {
+		t.Fatalf("expected one credential after delete, got %+v", credentials)
+	}
+}
+
+func TestNormalizeRegistryHost(t *testing.T) {
+	cases := map[string]string{
+		"https://GHCR.io/":               "ghcr.io",
+		"registry.local:5000/team/image": "registry.local:5000",
+		"http://registry.local:5000":     "registry.local:5000",
+		"index.docker.io":                "docker.io",
+		"https://index.docker.io/v1/":    "docker.io",
+	}
+	for raw, want := range cases {
+		if got := NormalizeRegistryHost(raw); got != want {
+			t.Errorf("NormalizeRegistryHost(%q) = %q, want %q", raw, got, want)
+		}
+	}
+	if got := UserRegistryCredentialSecretName("registry.local:5000"); got != "registry-cred-registry-local-5000" {
+		t.Fatalf("unexpected secret name: %s", got)
+	}
+}
+
+func assertPullSecrets(t *testing.T, refs []corev1.LocalObjectReference, want []string) {
+	t.Helper()
+	if len(refs) != len(want) {
+		t.Fatalf("unexpected imagePullSecrets: %+v, want %v", refs, want)
+	}
+	for i, name := range want {
+		if refs[i].Name != name {
+			t.Fatalf("unexpected imagePullSecrets: %+v, want %v", refs, want)
+		}
+	}
+}
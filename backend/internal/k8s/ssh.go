@@ -0,0 +1,450 @@
+package k8s
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/uc-package/genet/internal/models"
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/retry"
+)
+
+const (
+	// UserSSHKeysConfigMapName 用户 SSH 公钥 ConfigMap 名称（位于用户命名空间，同时挂载到 Pod）
+	UserSSHKeysConfigMapName = "genet-ssh-keys"
+	// UserSSHKeysDataKey 公钥元数据
+	UserSSHKeysDataKey = "keys.json"
+	// UserSSHAuthorizedKeysKey 渲染后的 authorized_keys
+	UserSSHAuthorizedKeysKey = "authorized_keys"
+
+	// PodSSHPortAnnotation 记录 Pod 的 sshd 端口
+	PodSSHPortAnnotation = "genet.io/ssh-port"
+
+	// SSHPortReservationsConfigMapName hostNetwork SSH 端口的集群级预留表（位于系统命名空间，键为端口号）
+	SSHPortReservationsConfigMapName = "genet-ssh-port-reservations"
+	// sshPortReservationGrace 预留后等待 Pod 出现的时间，超过后仍无对应 Pod 的预留被回收
+	sshPortReservationGrace = 2 * time.Minute
+
+	sshKeysVolumeName   = "genet-ssh-keys"
+	sshKeysMountPath    = "/etc/genet/ssh"
+	sshAuthorizedKeysIn = sshKeysMountPath + "/" + UserSSHAuthorizedKeysKey
+)
+
+// sshPortReservation 端口预留记录，Pod 为 namespace/name
+type sshPortReservation struct {
+	Pod        string    `json:"pod"`
+	ReservedAt time.Time `json:"reservedAt"`
+}
+
+// ParseSSHPublicKey 解析 authorized_keys 格式的公钥，返回规范化后的记录（不含 ID 以外的用户字段）
+func ParseSSHPublicKey(raw string) (*models.UserSSHKey, error) {
+	fields := strings.Fields(strings.TrimSpace(raw))
+	if len(fields) < 2 {
+		return nil, fmt.Errorf("公钥格式无效，应为 \"<type> <base64> [comment]\"")
+	}
+	keyType := fields[0]
+	if !strings.HasPrefix(keyType, "ssh-") && !strings.HasPrefix(keyType, "ecdsa-sha2-") && !strings.HasPrefix(keyType, "sk-") {
+		return nil, fmt.Errorf("不支持的公钥类型: %s", keyType)
+	}
+	blob, err := base64.StdEncoding.DecodeString(fields[1])
+	if err != nil {
+		return nil, fmt.Errorf("公钥内容不是合法的 base64")
+	}
+	// 公钥 blob 以 uint32 长度 + 类型字符串开头，需与声明的类型一致
+	if len(blob) < 4 {
+		return nil, fmt.Errorf("公钥内容过短")
+	}
+	nameLen := int(binary.BigEndian.Uint32(blob[:4]))
+	if nameLen <= 0 || 4+nameLen > len(blob) || string(blob[4:4+nameLen]) != keyType {
+		return nil, fmt.Errorf("公钥内容与类型 %s 不匹配", keyType)
+	}
+
+	sum := sha256.Sum256(blob)
+	comment := strings.Join(fields[2:], " ")
+	publicKey := keyType + " " + fields[1]
+	if comment != "" {
+		publicKey += " " + comment
+	}
+	return &models.UserSSHKey{
+		ID:          hex.EncodeToString(sum[:6]),
+		Name:        comment,
+		Type:        keyType,
+		PublicKey:   publicKey,
+		Fingerprint: "SHA256:" + base64.RawStdEncoding.EncodeToString(sum[:]),
+	}, nil
+}
+
+// GetUserSSHKeys 获取用户登记的 SSH 公钥
+func (c *Client) GetUserSSHKeys(ctx context.Context, namespace string) (*models.UserSSHKeyList, error) {
+	cm, err := c.clientset.CoreV1().ConfigMaps(namespace).Get(ctx, UserSSHKeysConfigMapName, metav1.GetOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return &models.UserSSHKeyList{Keys: []models.UserSSHKey{}}, nil
+		}
+		return nil, err
+	}
+
+	data, ok := cm.Data[UserSSHKeysDataKey]
+	if !ok || data == "" {
+		return &models.UserSSHKeyList{Keys: []models.UserSSHKey{}}, nil
+	}
+
+	var keyList models.UserSSHKeyList
+	if err := json.Unmarshal([]byte(data), &keyList); err != nil {
+		c.log.Warn("Failed to unmarshal user ssh keys", zap.String("namespace", namespace), zap.Error(err))
+		return &models.UserSSHKeyList{Keys: []models.UserSSHKey{}}, nil
+	}
+	if keyList.Keys == nil {
+		keyList.Keys = []models.UserSSHKey{}
+	}
+	return &keyList, nil
+}
+
+// AddUserSSHKey 登记 SSH 公钥，同一指纹重复登记时返回已有记录
+func (c *Client) AddUserSSHKey(ctx context.Context, namespace, name, publicKey string) (*models.UserSSHKey, error) {
+	key, err := ParseSSHPublicKey(publicKey)
+	if err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(name) != "" {
+		key.Name = strings.TrimSpace(name)
+	}
+	key.CreatedAt = time.Now()
+
+	keyList, err := c.GetUserSSHKeys(ctx, namespace)
+	if err != nil {
+		return nil, err
+	}
+	for _, existing := range keyList.Keys {
+		if existing.Fingerprint == key.Fingerprint {
+			return &existing, nil
+		}
+	}
+	keyList.Keys = append(keyList.Keys, *key)
+
+	if err := c.saveUserSSHKeyList(ctx, namespace, keyList); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// DeleteUserSSHKey 删除 SSH 公钥，返回是否存在
+func (c *Client) DeleteUserSSHKey(ctx context.Context, namespace, id string) (bool, error) {
+	keyList, err := c.GetUserSSHKeys(ctx, namespace)
+	if err != nil {
+		return false, err
+	}
+
+	filtered := make([]models.UserSSHKey, 0, len(keyList.Keys))
+	for _, key := range keyList.Keys {
+		if key.ID != id {
+			filtered = append(filtered, key)
+		}
+	}
+	if len(filtered) == len(keyList.Keys) {
+		return false, nil
+	}
+	keyList.Keys = filtered
+	return true, c.saveUserSSHKeyList(ctx, namespace, keyList)
+}
+
+// saveUserSSHKeyList 保存公钥列表，并同步渲染 authorized_keys（已挂载的 Pod 会自动刷新）
+func (c *Client) saveUserSSHKeyList(ctx context.Context, namespace string, keyList *models.UserSSHKeyList) error {
+	data, err := json.Marshal(keyList)
+	if err != nil {
+		return err
+	}
+	authorizedKeys := renderAuthorizedKeys(keyList.Keys)
+
+	cm, err := c.clientset.CoreV1().ConfigMaps(namespace).Get(ctx, UserSSHKeysConfigMapName, metav1.GetOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			newCM := &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      UserSSHKeysConfigMapName,
+					Namespace: namespace,
+					Labels: map[string]string{
+						"genet.io/type":    "ssh-keys",
+						"genet.io/managed": "true",
+					},
+				},
+				Data: map[string]string{
+					UserSSHKeysDataKey:       string(data),
+					UserSSHAuthorizedKeysKey: authorizedKeys,
+				},
+			}
+			_, err = c.clientset.CoreV1().ConfigMaps(namespace).Create(ctx, newCM, metav1.CreateOptions{})
+			return err
+		}
+		return err
+	}
+
+	if cm.Data == nil {
+		cm.Data = make(map[string]string)
+	}
+	cm.Data[UserSSHKeysDataKey] = string(data)
+	cm.Data[UserSSHAuthorizedKeysKey] = authorizedKeys
+
+	_, err = c.clientset.CoreV1().ConfigMaps(namespace).Update(ctx, cm, metav1.UpdateOptions{})
+	return err
+}
+
+func renderAuthorizedKeys(keys []models.UserSSHKey) string {
+	var b strings.Builder
+	for _, key := range keys {
+		b.WriteString(key.PublicKey)
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// GetPodSSHPort 读取 Pod 的 sshd 端口，未启用时返回 0
+func GetPodSSHPort(pod *corev1.Pod) int {
+	if pod == nil {
+		return 0
+	}
+	port, err := strconv.Atoi(strings.TrimSpace(pod.Annotations[PodSSHPortAnnotation]))
+	if err != nil || port <= 0 || port > 65535 {
+		return 0
+	}
+	return port
+}
+
+// reserveSSHHostPort 在配置范围内为 Pod 预留一个集群内唯一的端口。
+// 预留写入系统命名空间的 ConfigMap 并带 resourceVersion 更新，多个后端副本并发分配时
+// 冲突的一方重新读取后重试，因此不会把同一端口分给两个 Pod
+func (c *Client) reserveSSHHostPort(ctx context.Context, namespace, podName string) (int, error) {
+	cfg := c.config.Pod.SSH
+	start, end := cfg.HostPortRangeStart, cfg.HostPortRangeEnd
+	if start <= 0 || end < start || end > 65535 {
+		return 0, fmt.Errorf("SSH 端口范围配置无效: %d-%d", start, end)
+	}
+	ns := c.getOpenAPINamespace()
+	if err := c.EnsureNamespace(ctx, ns); err != nil {
+		return 0, err
+	}
+
+	var port int
+	err := retry.OnError(retry.DefaultRetry, func(err error) bool {
+		return errors.IsConflict(err) || errors.IsAlreadyExists(err)
+	}, func() error {
+		port = 0
+		cm, err := c.clientset.CoreV1().ConfigMaps(ns).Get(ctx, SSHPortReservationsConfigMapName, metav1.GetOptions{})
+		if err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+		exists := err == nil
+		if !exists {
+			cm = &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      SSHPortReservationsConfigMapName,
+					Namespace: ns,
+					Labels: map[string]string{
+						"genet.io/type":    "ssh-port-reservations",
+						"genet.io/managed": "true",
+					},
+				},
+			}
+		}
+		if cm.Data == nil {
+			cm.Data = make(map[string]string)
+		}
+
+		podPorts, err := c.listSSHHostPorts(ctx)
+		if err != nil {
+			return err
+		}
+		now := time.Now()
+		used := make(map[int]struct{}, len(podPorts)+len(cm.Data))
+		for _, p := range podPorts {
+			used[p] = struct{}{}
+		}
+		for key, value := range cm.Data {
+			p, err := strconv.Atoi(key)
+			var reservation sshPortReservation
+			if err != nil || json.Unmarshal([]byte(value), &reservation) != nil {
+				delete(cm.Data, key)
+				continue
+			}
+			// Pod 已创建且仍占用该端口，或仍在等待创建的预留才有效；其余视为已释放
+			if podPorts[reservation.Pod] != p && now.Sub(reservation.ReservedAt) > sshPortReservationGrace {
+				delete(cm.Data, key)
+				continue
+			}
+			used[p] = struct{}{}
+		}
+
+		for p := start; p <= end; p++ {
+			if _, ok := used[p]; !ok {
+				port = p
+				break
+			}
+		}
+		if port == 0 {
+			return fmt.Errorf("SSH 端口已耗尽（%d-%d）", start, end)
+		}
+		data, err := json.Marshal(sshPortReservation{Pod: namespace + "/" + podName, ReservedAt: now})
+		if err != nil {
+			return err
+		}
+		cm.Data[strconv.Itoa(port)] = string(data)
+
+		if exists {
+			_, err = c.clientset.CoreV1().ConfigMaps(ns).Update(ctx, cm, metav1.UpdateOptions{})
+		} else {
+			_, err = c.clientset.CoreV1().ConfigMaps(ns).Create(ctx, cm, metav1.CreateOptions{})
+		}
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+	return port, nil
+}
+
+// releaseSSHHostPort Pod 创建失败时释放预留（尽力而为，失败时等待宽限期后回收）
+func (c *Client) releaseSSHHostPort(ctx context.Context, port int, namespace, podName string) {
+	ns := c.getOpenAPINamespace()
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		cm, err := c.clientset.CoreV1().ConfigMaps(ns).Get(ctx, SSHPortReservationsConfigMapName, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		var reservation sshPortReservation
+		key := strconv.Itoa(port)
+		if json.Unmarshal([]byte(cm.Data[key]), &reservation) != nil || reservation.Pod != namespace+"/"+podName {
+			return nil
+		}
+		delete(cm.Data, key)
+		_, err = c.clientset.CoreV1().ConfigMaps(ns).Update(ctx, cm, metav1.UpdateOptions{})
+		return err
+	})
+	if err != nil && !errors.IsNotFound(err) {
+		c.log.Warn("Failed to release ssh port reservation", zap.Int("port", port), zap.String("pod", podName), zap.Error(err))
+	}
+}
+
+// listSSHHostPorts 返回未结束的 Genet Pod（namespace/name）及其 sshd 端口
+func (c *Client) listSSHHostPorts(ctx context.Context) (map[string]int, error) {
+	pods, err := c.clientset.CoreV1().Pods(metav1.NamespaceAll).List(ctx, metav1.ListOptions{
+		LabelSelector: "genet.io/managed=true",
+	})
+	if err != nil {
+		return nil, fmt.Errorf("查询已分配 SSH 端口失败: %w", err)
+	}
+	ports := make(map[string]int, len(pods.Items))
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		if pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed {
+			continue
+		}
+		if port := GetPodSSHPort(pod); port > 0 {
+			ports[pod.Namespace+"/"+pod.Name] = port
+		}
+	}
+	return ports, nil
+}
+
+// applyPodSSH 为 Pod 注入 sshd 端口与公钥挂载
+func applyPodSSH(pod *corev1.Pod, port int) {
+	pod.Annotations[PodSSHPortAnnotation] = strconv.Itoa(port)
+
+	optional := true
+	pod.Spec.Volumes = append(pod.Spec.Volumes, corev1.Volume{
+		Name: sshKeysVolumeName,
+		VolumeSource: corev1.VolumeSource{
+			ConfigMap: &corev1.ConfigMapVolumeSource{
+				LocalObjectReference: corev1.LocalObjectReference{Name: UserSSHKeysConfigMapName},
+				Items:                []corev1.KeyToPath{{Key: UserSSHAuthorizedKeysKey, Path: UserSSHAuthorizedKeysKey}},
+				Optional:             &optional,
+			},
+		},
+	})
+	container := &pod.Spec.Containers[0]
+	container.VolumeMounts = append(container.VolumeMounts, corev1.VolumeMount{
+		Name:      sshKeysVolumeName,
+		MountPath: sshKeysMountPath,
+		ReadOnly:  true,
+	})
+	container.Env = append(container.Env,
+		corev1.EnvVar{Name: "GENET_SSH_PORT", Value: strconv.Itoa(port)},
+		corev1.EnvVar{Name: "GENET_SSH_AUTHORIZED_KEYS", Value: sshAuthorizedKeysIn},
+	)
+}
+
+// buildSSHStartupScript 生成 sshd 启动脚本片段
+// 仅在容器注入了 GENET_SSH_PORT 时生效，因此可安全用于所有工作负载
+func buildSSHStartupScript(cfg models.SSHConfig) string {
+	if !cfg.Enabled {
+		return ""
+	}
+	toolsDir := strings.TrimRight(cfg.ToolsDir, "/")
+	if toolsDir == "" {
+		toolsDir = "/workspace/.genet"
+	}
+	// host key 持久化到工作区，Pod 重建后指纹不变
+	stateDir := models.DefaultWorkspaceDir + "/.genet-ssh"
+
+	return fmt.Sprintf(`
+if [ -n "${GENET_SSH_PORT:-}" ]; then
+  echo "=== Preparing sshd ==="
+  GENET_TOOLS_DIR=%s
+  SSH_STATE_DIR=%s
+  SSHD_BIN=""
+  SSH_KEYGEN_BIN=""
+  if command -v sshd >/dev/null 2>&1; then
+    SSHD_BIN="$(command -v sshd)"
+  elif [ -x /usr/sbin/sshd ]; then
+    SSHD_BIN=/usr/sbin/sshd
+  elif [ -x "$GENET_TOOLS_DIR/bin/sshd-run" ]; then
+    SSHD_BIN="$GENET_TOOLS_DIR/bin/sshd-run"
+  fi
+  if command -v ssh-keygen >/dev/null 2>&1; then
+    SSH_KEYGEN_BIN="$(command -v ssh-keygen)"
+  elif [ -x "$GENET_TOOLS_DIR/bin/ssh-keygen" ]; then
+    export LD_LIBRARY_PATH="$GENET_TOOLS_DIR/lib:${LD_LIBRARY_PATH:-}"
+    SSH_KEYGEN_BIN="$GENET_TOOLS_DIR/bin/ssh-keygen"
+  fi
+
+  if [ -z "$SSHD_BIN" ]; then
+    echo "sshd not found in image or $GENET_TOOLS_DIR; skipping SSH setup"
+  else
+    SSH_RUN_DIR=/tmp/genet-sshd
+    mkdir -p "$SSH_STATE_DIR" "$SSH_RUN_DIR" /root/.ssh /run/sshd /var/empty 2>/dev/null || true
+    chmod 700 /root/.ssh 2>/dev/null || true
+    for KEY_TYPE in ed25519 rsa; do
+      HOST_KEY="$SSH_STATE_DIR/ssh_host_${KEY_TYPE}_key"
+      if [ ! -f "$HOST_KEY" ] && [ -n "$SSH_KEYGEN_BIN" ]; then
+        "$SSH_KEYGEN_BIN" -q -t "$KEY_TYPE" -f "$HOST_KEY" -N "" || true
+      fi
+    done
+    cat > "$SSH_RUN_DIR/sshd_config" << SSHDEOF
+Port ${GENET_SSH_PORT}
+ListenAddress 0.0.0.0
+HostKey $SSH_STATE_DIR/ssh_host_ed25519_key
+HostKey $SSH_STATE_DIR/ssh_host_rsa_key
+PermitRootLogin prohibit-password
+PasswordAuthentication no
+PubkeyAuthentication yes
+AuthorizedKeysFile .ssh/authorized_keys ${GENET_SSH_AUTHORIZED_KEYS:-/etc/genet/ssh/authorized_keys}
+StrictModes no
+PidFile $SSH_RUN_DIR/sshd.pid
+Subsystem sftp internal-sftp
+SSHDEOF
+    nohup "$SSHD_BIN" -D -e -f "$SSH_RUN_DIR/sshd_config" > "$SSH_RUN_DIR/sshd.log" 2>&1 &
+    echo "sshd started on port ${GENET_SSH_PORT}"
+  fi
+fi
+`, shellQuote(toolsDir), shellQuote(stateDir))
+}
@@ -166,6 +166,12 @@ func (c *Client) CreateStatefulSet(ctx context.Context, spec *StatefulSetSpec) (
 		},
 	}
 
+	pullSecrets, err := c.ensureImagePullSecrets(ctx, spec.Namespace, spec.Username, spec.Email)
+	if err != nil {
+		return nil, err
+	}
+	sts.Spec.Template.Spec.ImagePullSecrets = pullSecrets
+
 	c.log.Info("Creating statefulset",
 		zap.String("name", spec.Name),
 		zap.String("namespace", spec.Namespace),
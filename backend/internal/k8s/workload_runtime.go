@@ -105,6 +105,7 @@ echo "Proxy configured: HTTP_PROXY=%s, HTTPS_PROXY=%s"
 		if err := tmpl.Execute(&scriptBuf, map[string]interface{}{
 			"ProxyScript":      proxySetupScript,
 			"CodeServerScript": buildCodeServerStartupScript(c.config.Pod.CodeServer),
+			"SSHScript":        buildSSHStartupScript(c.config.Pod.SSH),
 		}); err != nil {
 			return nil, fmt.Errorf("渲染启动脚本失败: %w", err)
 		}
@@ -0,0 +1,365 @@
+package logarchive
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/uc-package/genet/internal/k8s"
+	"github.com/uc-package/genet/internal/logger"
+	"github.com/uc-package/genet/internal/models"
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	defaultMaxLogMB = 16
+	// maxArchivesPerPod 同名 Pod 列出的最近归档数，更早的归档文件仍在存储中但不再列出
+	maxArchivesPerPod = 20
+	// archiveSummarySuffix 每个归档旁的摘要对象，列出归档时按目录读取，不需要维护共享索引
+	archiveSummarySuffix = ".summary.json"
+)
+
+// Archive 单个 Pod 删除前的日志与事件快照
+type Archive struct {
+	ID         string          `json:"id"`
+	Pod        string          `json:"pod"`
+	Namespace  string          `json:"namespace"`
+	UID        string          `json:"uid,omitempty"`
+	Node       string          `json:"node,omitempty"`
+	Image      string          `json:"image,omitempty"`
+	Phase      string          `json:"phase,omitempty"`
+	Reason     string          `json:"reason"` // 删除原因：user | cleanup | update | suspend | workload-delete
+	CreatedAt  time.Time       `json:"createdAt"`
+	ArchivedAt time.Time       `json:"archivedAt"`
+	Containers []ContainerLogs `json:"containers"`
+	Events     []Event         `json:"events"`
+}
+
+// Summary 归档摘要，用于列出同名 Pod 的历次归档
+type Summary struct {
+	ID         string    `json:"id"`
+	UID        string    `json:"uid,omitempty"`
+	Reason     string    `json:"reason"`
+	CreatedAt  time.Time `json:"createdAt"`
+	ArchivedAt time.Time `json:"archivedAt"`
+}
+
+// ContainerLogs 容器日志（带时间戳）；超过大小上限时只保留末尾部分
+type ContainerLogs struct {
+	Name         string `json:"name"`
+	Logs         string `json:"logs"`
+	PreviousLogs string `json:"previousLogs,omitempty"` // 容器重启前的日志
+	Truncated    bool   `json:"truncated,omitempty"`
+	Error        string `json:"error,omitempty"`
+}
+
+// Event Pod 事件，字段与 /api/pods/:id/events 保持一致
+type Event struct {
+	Type      string    `json:"type"`
+	Reason    string    `json:"reason"`
+	Message   string    `json:"message"`
+	Count     int32     `json:"count"`
+	FirstTime time.Time `json:"firstTime"`
+	LastTime  time.Time `json:"lastTime"`
+	Source    string    `json:"source"`
+}
+
+// Archiver 在删除 Pod 前抓取日志与事件并写入归档存储
+type Archiver struct {
+	store        Store
+	maxBytes     int64
+	log          *zap.Logger
+	nowFn        func() time.Time
+	streamLogsFn func(ctx context.Context, namespace, name string, options k8s.PodLogOptions) (io.ReadCloser, error)
+	eventsFn     func(ctx context.Context, namespace, name string) ([]corev1.Event, error)
+}
+
+// NewArchiver 根据配置创建归档器；未启用时返回 nil（nil Archiver 的方法均为空操作）
+func NewArchiver(config models.LogArchiveConfig, k8sClient *k8s.Client) (*Archiver, error) {
+	if !config.Enabled {
+		return nil, nil
+	}
+	store, err := NewStore(config)
+	if err != nil {
+		return nil, err
+	}
+	maxLogMB := config.MaxLogMB
+	if maxLogMB <= 0 {
+		maxLogMB = defaultMaxLogMB
+	}
+	archiver := &Archiver{
+		store:    store,
+		maxBytes: int64(maxLogMB) << 20,
+		log:      logger.Named("logarchive"),
+		nowFn:    time.Now,
+	}
+	if k8sClient != nil {
+		archiver.streamLogsFn = k8sClient.StreamPodLogs
+		archiver.eventsFn = k8sClient.GetPodEvents
+	}
+	return archiver, nil
+}
+
+// Enabled 是否启用归档
+func (a *Archiver) Enabled() bool {
+	return a != nil
+}
+
+// ArchivePod 抓取 Pod 所有容器的当前/之前日志及事件并保存；单个容器失败只记录在归档中
+func (a *Archiver) ArchivePod(ctx context.Context, pod *corev1.Pod, reason string) error {
+	if a == nil || pod == nil {
+		return nil
+	}
+	archive := &Archive{
+		Pod:        pod.Name,
+		Namespace:  pod.Namespace,
+		UID:        string(pod.UID),
+		Node:       pod.Spec.NodeName,
+		Phase:      string(pod.Status.Phase),
+		Reason:     reason,
+		CreatedAt:  pod.CreationTimestamp.Time,
+		ArchivedAt: a.nowFn(),
+	}
+	if len(pod.Spec.Containers) > 0 {
+		archive.Image = pod.Spec.Containers[0].Image
+	}
+
+	restarted := make(map[string]bool, len(pod.Status.ContainerStatuses))
+	for _, status := range pod.Status.ContainerStatuses {
+		restarted[status.Name] = status.RestartCount > 0
+	}
+	for _, container := range pod.Spec.Containers {
+		entry := ContainerLogs{Name: container.Name}
+		logs, truncated, err := a.readLogs(ctx, pod, container.Name, false)
+		if err != nil {
+			entry.Error = err.Error()
+		}
+		entry.Logs = logs
+		entry.Truncated = truncated
+		if restarted[container.Name] {
+			if previous, prevTruncated, err := a.readLogs(ctx, pod, container.Name, true); err == nil {
+				entry.PreviousLogs = previous
+				entry.Truncated = entry.Truncated || prevTruncated
+			}
+		}
+		archive.Containers = append(archive.Containers, entry)
+	}
+
+	if a.eventsFn != nil {
+		events, err := a.eventsFn(ctx, pod.Namespace, pod.Name)
+		if err != nil {
+			a.log.Warn("Failed to get pod events for archive",
+				zap.String("pod", pod.Name),
+				zap.String("namespace", pod.Namespace),
+				zap.Error(err))
+		}
+		for _, event := range events {
+			archive.Events = append(archive.Events, Event{
+				Type:      event.Type,
+				Reason:    event.Reason,
+				Message:   event.Message,
+				Count:     event.Count,
+				FirstTime: event.FirstTimestamp.Time,
+				LastTime:  event.LastTimestamp.Time,
+				Source:    event.Source.Component,
+			})
+		}
+	}
+
+	return a.Save(ctx, archive)
+}
+
+// Save 写入归档（gzip 压缩的 JSON）。键包含归档时间和 Pod UID，同名 Pod 重建后的归档互不覆盖
+func (a *Archiver) Save(ctx context.Context, archive *Archive) error {
+	if a == nil {
+		return nil
+	}
+	if archive.ID == "" {
+		archive.ID = archiveID(archive.ArchivedAt, archive.UID)
+	}
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	if err := json.NewEncoder(gz).Encode(archive); err != nil {
+		return err
+	}
+	if err := gz.Close(); err != nil {
+		return err
+	}
+	if err := a.store.Put(ctx, archiveKey(archive.Namespace, archive.Pod, archive.ID), buf.Bytes()); err != nil {
+		return fmt.Errorf("save log archive: %w", err)
+	}
+	// 摘要在归档之后写入，列出的归档一定可以读取；每个归档一个对象，并发归档同名 Pod 互不覆盖
+	summary, err := json.Marshal(Summary{
+		ID:         archive.ID,
+		UID:        archive.UID,
+		Reason:     archive.Reason,
+		CreatedAt:  archive.CreatedAt,
+		ArchivedAt: archive.ArchivedAt,
+	})
+	if err != nil {
+		return err
+	}
+	if err := a.store.Put(ctx, archiveSummaryKey(archive.Namespace, archive.Pod, archive.ID), summary); err != nil {
+		return fmt.Errorf("save log archive summary: %w", err)
+	}
+	a.log.Info("Archived pod logs",
+		zap.String("pod", archive.Pod),
+		zap.String("namespace", archive.Namespace),
+		zap.String("id", archive.ID),
+		zap.String("reason", archive.Reason),
+		zap.Int("bytes", buf.Len()))
+	return nil
+}
+
+// List 返回同名 Pod 最近 maxArchivesPerPod 次归档，按归档时间倒序；没有归档时返回空列表
+func (a *Archiver) List(ctx context.Context, namespace, podName string) ([]Summary, error) {
+	if a == nil {
+		return []Summary{}, nil
+	}
+	keys, err := a.store.List(ctx, namespace+"/"+podName+"/")
+	if err != nil {
+		return nil, err
+	}
+	// ID 以归档时间开头，按名称倒序即按时间倒序
+	var ids []string
+	for _, key := range keys {
+		name := key[strings.LastIndex(key, "/")+1:]
+		if id := strings.TrimSuffix(name, archiveSummarySuffix); id != name && validArchiveID(id) {
+			ids = append(ids, id)
+		}
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(ids)))
+	if len(ids) > maxArchivesPerPod {
+		ids = ids[:maxArchivesPerPod]
+	}
+
+	summaries := make([]Summary, 0, len(ids))
+	for _, id := range ids {
+		data, err := a.store.Get(ctx, archiveSummaryKey(namespace, podName, id))
+		if errors.Is(err, ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		var summary Summary
+		if err := json.Unmarshal(data, &summary); err != nil {
+			return nil, err
+		}
+		summaries = append(summaries, summary)
+	}
+	return summaries, nil
+}
+
+// Load 读取 Pod 的指定归档，id 为空时读取最近一次；不存在时返回 ErrNotFound
+func (a *Archiver) Load(ctx context.Context, namespace, podName, id string) (*Archive, error) {
+	if a == nil {
+		return nil, ErrNotFound
+	}
+	key := archiveKey(namespace, podName, id)
+	if id == "" {
+		summaries, err := a.List(ctx, namespace, podName)
+		if err != nil {
+			return nil, err
+		}
+		if len(summaries) == 0 {
+			// 旧版本按 namespace/pod 名称保存的单份归档
+			key = namespace + "/" + podName + ".json.gz"
+		} else {
+			key = archiveKey(namespace, podName, summaries[0].ID)
+		}
+	} else if !validArchiveID(id) {
+		return nil, ErrNotFound
+	}
+	data, err := a.store.Get(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	gz, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer gz.Close()
+	var archive Archive
+	if err := json.NewDecoder(gz).Decode(&archive); err != nil {
+		return nil, err
+	}
+	return &archive, nil
+}
+
+func (a *Archiver) readLogs(ctx context.Context, pod *corev1.Pod, container string, previous bool) (string, bool, error) {
+	if a.streamLogsFn == nil {
+		return "", false, fmt.Errorf("log source not configured")
+	}
+	stream, err := a.streamLogsFn(ctx, pod.Namespace, pod.Name, k8s.PodLogOptions{
+		Container:  container,
+		Previous:   previous,
+		Timestamps: true,
+	})
+	if err != nil {
+		return "", false, err
+	}
+	defer stream.Close()
+	return readTail(stream, a.maxBytes)
+}
+
+// readTail 读取完整流但只保留末尾 maxBytes（事后排查通常关心最后的输出）
+func readTail(r io.Reader, maxBytes int64) (string, bool, error) {
+	var buf []byte
+	truncated := false
+	chunk := make([]byte, 32*1024)
+	for {
+		n, err := r.Read(chunk)
+		buf = append(buf, chunk[:n]...)
+		if int64(len(buf)) > 2*maxBytes {
+			buf = append(buf[:0], buf[int64(len(buf))-maxBytes:]...)
+			truncated = true
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", truncated, err
+		}
+	}
+	if int64(len(buf)) > maxBytes {
+		buf = buf[int64(len(buf))-maxBytes:]
+		truncated = true
+	}
+	if truncated {
+		// 丢弃被截断的首行
+		if idx := bytes.IndexByte(buf, '\n'); idx >= 0 {
+			buf = buf[idx+1:]
+		}
+	}
+	return string(buf), truncated, nil
+}
+
+// archiveID 归档时间在前便于按名称排序，UID 区分同一秒内被删除的同名 Pod
+func archiveID(archivedAt time.Time, uid string) string {
+	id := archivedAt.UTC().Format("20060102T150405Z")
+	if uid != "" {
+		id += "-" + uid
+	}
+	return id
+}
+
+func validArchiveID(id string) bool {
+	return id != "" && validateKey(id) == nil && !strings.Contains(id, "/")
+}
+
+func archiveKey(namespace, podName, id string) string {
+	return namespace + "/" + podName + "/" + id + ".json.gz"
+}
+
+func archiveSummaryKey(namespace, podName, id string) string {
+	return namespace + "/" + podName + "/" + id + archiveSummarySuffix
+}
@@ -0,0 +1,208 @@
+package logarchive
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/uc-package/genet/internal/k8s"
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func TestArchivePodRoundTrip(t *testing.T) {
+	archivedAt := time.Date(2026, 3, 16, 23, 0, 0, 0, time.UTC)
+	var requested []string
+	archiver := &Archiver{
+		store:    NewFileStore(t.TempDir()),
+		maxBytes: 1 << 20,
+		log:      zap.NewNop(),
+		nowFn:    func() time.Time { return archivedAt },
+		streamLogsFn: func(_ context.Context, namespace, name string, options k8s.PodLogOptions) (io.ReadCloser, error) {
+			requested = append(requested, options.Container+map[bool]string{true: ":previous", false: ""}[options.Previous])
+			if options.Container == "sidecar" {
+				return nil, errors.New("container not found")
+			}
+			if options.Previous {
+				return io.NopCloser(strings.NewReader("2026-03-16T22:00:00Z CUDA out of memory\n")), nil
+			}
+			return io.NopCloser(strings.NewReader("2026-03-16T22:59:00Z epoch 9\n")), nil
+		},
+		eventsFn: func(context.Context, string, string) ([]corev1.Event, error) {
+			return []corev1.Event{{Type: "Warning", Reason: "BackOff", Message: "Back-off restarting failed container", Count: 3}}, nil
+		},
+	}
+
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "pod-alice-dev", Namespace: "user-alice"},
+		Spec: corev1.PodSpec{
+			NodeName:   "gpu-node-1",
+			Containers: []corev1.Container{{Name: "workspace", Image: "pytorch:2.1"}, {Name: "sidecar"}},
+		},
+		Status: corev1.PodStatus{
+			Phase:             corev1.PodRunning,
+			ContainerStatuses: []corev1.ContainerStatus{{Name: "workspace", RestartCount: 2}},
+		},
+	}
+	if err := archiver.ArchivePod(context.Background(), pod, "cleanup"); err != nil {
+		t.Fatalf("ArchivePod: %v", err)
+	}
+	if got := strings.Join(requested, ","); got != "workspace,workspace:previous,sidecar" {
+		t.Fatalf("unexpected log requests %s", got)
+	}
+
+	archive, err := archiver.Load(context.Background(), "user-alice", "pod-alice-dev", "")
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if archive.Reason != "cleanup" || archive.Image != "pytorch:2.1" || !archive.ArchivedAt.Equal(archivedAt) {
+		t.Fatalf("unexpected archive metadata %+v", archive)
+	}
+	if len(archive.Containers) != 2 || archive.Containers[0].PreviousLogs == "" || archive.Containers[1].Error == "" {
+		t.Fatalf("unexpected containers %+v", archive.Containers)
+	}
+	if len(archive.Events) != 1 || archive.Events[0].Reason != "BackOff" {
+		t.Fatalf("unexpected events %+v", archive.Events)
+	}
+
+	if _, err := archiver.Load(context.Background(), "user-alice", "pod-alice-other", ""); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestArchivePodKeepsArchivesOfReusedPodNames(t *testing.T) {
+	now := time.Date(2026, 3, 16, 23, 0, 0, 0, time.UTC)
+	archiver := &Archiver{
+		store:    NewFileStore(t.TempDir()),
+		maxBytes: 1 << 20,
+		log:      zap.NewNop(),
+		nowFn:    func() time.Time { return now },
+		streamLogsFn: func(context.Context, string, string, k8s.PodLogOptions) (io.ReadCloser, error) {
+			return io.NopCloser(strings.NewReader("log\n")), nil
+		},
+	}
+	ctx := context.Background()
+	for i, uid := range []string{"uid-1", "uid-2"} {
+		pod := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "pod-alice-train", Namespace: "user-alice", UID: types.UID(uid)},
+			Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "workspace", Image: "pytorch:2." + uid}}},
+		}
+		now = now.Add(time.Duration(i) * time.Hour)
+		if err := archiver.ArchivePod(ctx, pod, "cleanup"); err != nil {
+			t.Fatalf("ArchivePod: %v", err)
+		}
+	}
+
+	summaries, err := archiver.List(ctx, "user-alice", "pod-alice-train")
+	if err != nil || len(summaries) != 2 || summaries[0].UID != "uid-2" || summaries[1].UID != "uid-1" {
+		t.Fatalf("expected both archives newest first, got %+v err=%v", summaries, err)
+	}
+	latest, err := archiver.Load(ctx, "user-alice", "pod-alice-train", "")
+	if err != nil || latest.UID != "uid-2" {
+		t.Fatalf("expected latest archive, got %+v err=%v", latest, err)
+	}
+	first, err := archiver.Load(ctx, "user-alice", "pod-alice-train", summaries[1].ID)
+	if err != nil || first.UID != "uid-1" || first.Image != "pytorch:2.uid-1" {
+		t.Fatalf("expected first archive to be kept, got %+v err=%v", first, err)
+	}
+	if _, err := archiver.Load(ctx, "user-alice", "pod-alice-train", "../pod-alice-dev"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected invalid archive id to be rejected, got %v", err)
+	}
+}
+
+func TestArchivePodConcurrentArchivesAreAllListed(t *testing.T) {
+	now := time.Date(2026, 3, 16, 23, 0, 0, 0, time.UTC)
+	archiver := &Archiver{
+		store:    NewFileStore(t.TempDir()),
+		maxBytes: 1 << 20,
+		log:      zap.NewNop(),
+		nowFn:    func() time.Time { return now },
+		streamLogsFn: func(context.Context, string, string, k8s.PodLogOptions) (io.ReadCloser, error) {
+			return io.NopCloser(strings.NewReader("log\n")), nil
+		},
+	}
+	// 同名 Pod 的多个副本同时归档
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(uid string) {
+			defer wg.Done()
+			pod := &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Name: "pod-alice-train", Namespace: "user-alice", UID: types.UID(uid)},
+				Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "workspace"}}},
+			}
+			if err := archiver.ArchivePod(context.Background(), pod, "suspend"); err != nil {
+				t.Errorf("ArchivePod: %v", err)
+			}
+		}(fmt.Sprintf("uid-%d", i))
+	}
+	wg.Wait()
+
+	summaries, err := archiver.List(context.Background(), "user-alice", "pod-alice-train")
+	if err != nil || len(summaries) != 8 {
+		t.Fatalf("expected every concurrent archive to be listed, got %d err=%v", len(summaries), err)
+	}
+}
+
+func TestArchiverLoadsLegacyArchive(t *testing.T) {
+	store := NewFileStore(t.TempDir())
+	archiver := &Archiver{store: store, log: zap.NewNop()}
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	_ = json.NewEncoder(gz).Encode(&Archive{Pod: "pod-alice-dev", Namespace: "user-alice", Reason: "user"})
+	_ = gz.Close()
+	if err := store.Put(context.Background(), "user-alice/pod-alice-dev.json.gz", buf.Bytes()); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	archive, err := archiver.Load(context.Background(), "user-alice", "pod-alice-dev", "")
+	if err != nil || archive.Reason != "user" {
+		t.Fatalf("expected legacy archive, got %+v err=%v", archive, err)
+	}
+}
+
+func TestNilArchiverIsNoop(t *testing.T) {
+	var archiver *Archiver
+	if archiver.Enabled() {
+		t.Fatal("nil archiver should be disabled")
+	}
+	if err := archiver.ArchivePod(context.Background(), &corev1.Pod{}, "user"); err != nil {
+		t.Fatalf("ArchivePod: %v", err)
+	}
+}
+
+func TestReadTailKeepsLastLines(t *testing.T) {
+	var input strings.Builder
+	for i := 0; i < 1000; i++ {
+		input.WriteString("line-")
+		input.WriteString(strings.Repeat("x", 10))
+		input.WriteString("\n")
+	}
+	input.WriteString("last\n")
+
+	out, truncated, err := readTail(strings.NewReader(input.String()), 64)
+	if err != nil || !truncated {
+		t.Fatalf("expected truncation, got %v %v", truncated, err)
+	}
+	if !strings.HasSuffix(out, "last\n") || !strings.HasPrefix(out, "line-") || len(out) > 64 {
+		t.Fatalf("unexpected tail %q", out)
+	}
+}
+
+func TestFileStoreRejectsEscapingKeys(t *testing.T) {
+	store := NewFileStore(t.TempDir())
+	for _, key := range []string{"../etc/passwd", "/abs", "a//b", "a/./b"} {
+		if err := store.Put(context.Background(), key, []byte("x")); err == nil {
+			t.Fatalf("expected %q to be rejected", key)
+		}
+	}
+}
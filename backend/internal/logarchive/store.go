@@ -0,0 +1,133 @@
+package logarchive
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/uc-package/genet/internal/models"
+)
+
+// ErrNotFound 归档不存在
+var ErrNotFound = errors.New("archive not found")
+
+// Store 归档存储接口，key 为 / 分隔的相对路径
+type Store interface {
+	Put(ctx context.Context, key string, data []byte) error
+	Get(ctx context.Context, key string) ([]byte, error)
+	// List 返回目录 prefix（以 / 结尾）下直接包含的 key，目录不存在时返回空列表
+	List(ctx context.Context, prefix string) ([]string, error)
+}
+
+// NewStore 根据配置创建归档存储
+func NewStore(config models.LogArchiveConfig) (Store, error) {
+	backend := config.Backend
+	if backend == "" {
+		backend = "pvc"
+	}
+
+	switch backend {
+	case "pvc":
+		if strings.TrimSpace(config.Dir) == "" {
+			return nil, fmt.Errorf("log archive dir not configured")
+		}
+		return NewFileStore(config.Dir), nil
+	case "s3":
+		return NewS3Store(config.S3)
+	default:
+		return nil, fmt.Errorf("unsupported log archive backend: %s", backend)
+	}
+}
+
+// FileStore 将归档写入本地目录（通常是挂载的 PVC）
+type FileStore struct {
+	dir string
+}
+
+// NewFileStore 创建目录存储
+func NewFileStore(dir string) *FileStore {
+	return &FileStore{dir: dir}
+}
+
+func (s *FileStore) Put(_ context.Context, key string, data []byte) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	// 先写临时文件再重命名，避免读到写了一半的归档
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".archive-*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+func (s *FileStore) Get(_ context.Context, key string) ([]byte, error) {
+	path, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, ErrNotFound
+	}
+	return data, err
+}
+
+func (s *FileStore) List(_ context.Context, prefix string) ([]string, error) {
+	dir, err := s.path(strings.TrimSuffix(prefix, "/"))
+	if err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	keys := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		// 跳过子目录与 Put 写到一半的临时文件
+		if !entry.Type().IsRegular() || strings.HasPrefix(entry.Name(), ".archive-") {
+			continue
+		}
+		keys = append(keys, prefix+entry.Name())
+	}
+	return keys, nil
+}
+
+func (s *FileStore) path(key string) (string, error) {
+	if err := validateKey(key); err != nil {
+		return "", err
+	}
+	return filepath.Join(s.dir, filepath.FromSlash(key)), nil
+}
+
+// validateKey 拒绝绝对路径与 .. 等可能越出归档目录的 key
+func validateKey(key string) error {
+	if key == "" || strings.HasPrefix(key, "/") {
+		return fmt.Errorf("invalid archive key %q", key)
+	}
+	for _, part := range strings.Split(key, "/") {
+		if part == "" || part == "." || part == ".." {
+			return fmt.Errorf("invalid archive key %q", key)
+		}
+	}
+	return nil
+}
@@ -1,6 +1,7 @@
 package logger
 
 import (
+	"net/http"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -63,11 +64,16 @@ func GinLogger() gin.HandlerFunc {
 	}
 }
 
-// GinRecovery 返回 Gin 恢复中间件
+// GinRecovery 返回 Gin 恢复中间件，http.ErrAbortHandler 会继续向上抛出
 func GinRecovery() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		defer func() {
 			if err := recover(); err != nil {
+				if err == http.ErrAbortHandler {
+					// 处理器主动中断响应（如下载中途失败），交给 net/http 断开连接，
+					// 不能在这里补写状态码把已截断的响应正常结束
+					panic(err)
+				}
 				L.Error("Panic recovered",
 					zap.Any("error", err),
 					zap.String("path", c.Request.URL.Path),
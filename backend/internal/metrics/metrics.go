@@ -0,0 +1,227 @@
+// Package metrics 导出 Genet 自身的 Prometheus 指标（/metrics），与查询集群指标的 internal/prometheus 包无关
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/prometheus/client_golang/prometheus/push"
+)
+
+const namespace = "genet"
+
+// 结果标签取值
+const (
+	ResultSuccess = "success"
+	ResultFailure = "failure"
+)
+
+// Registry Genet 指标注册表（不使用全局默认注册表，便于测试）
+var Registry = prometheus.NewRegistry()
+
+var (
+	httpRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "HTTP requests by method, gin route template and status code.",
+	}, []string{"method", "route", "status"})
+
+	httpRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "HTTP request latency by method and gin route template (streaming routes include the whole connection).",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route"})
+
+	podOperations = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "pod_operations_total",
+		Help:      "Pod create/delete operations by outcome.",
+	}, []string{"operation", "result"})
+
+	quotaRejections = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "quota_rejections_total",
+		Help:      "Requests rejected by per-user pod/GPU quota, by workload kind.",
+	}, []string{"kind"})
+
+	commitJobs = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "commit_jobs_total",
+		Help:      "Finished image commit jobs by outcome.",
+	}, []string{"result"})
+
+	commitJobDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "commit_job_duration_seconds",
+		Help:      "Image commit job duration from start to completion, by outcome.",
+		Buckets:   []float64{30, 60, 120, 300, 600, 1200, 1800, 3600, 7200},
+	}, []string{"result"})
+
+	webShellSessions = prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "webshell_active_sessions",
+		Help:      "Web shell sessions with an attached browser connection.",
+	}, func() float64 {
+		if fn := webShellSessionsFn; fn != nil {
+			return float64(fn())
+		}
+		return 0
+	})
+
+	nodePoolTaintChanges = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "node_pool_taint_changes_total",
+		Help:      "Node taint changes made by the node pool reconciler, by action.",
+	}, []string{"action"})
+
+	nodePoolSyncs = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "node_pool_syncs_total",
+		Help:      "Node pool taint reconcile runs by outcome.",
+	}, []string{"result"})
+
+	openAPIRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "openapi_requests_total",
+		Help:      "Open API requests by API key ID, route and status code (legacy static keys use key_id=\"legacy\").",
+	}, []string{"key_id", "method", "route", "status"})
+)
+
+// webShellSessionsFn 由 WebShell 会话管理器注册，抓取时读取当前已连接会话数
+var webShellSessionsFn func() int
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		httpRequests,
+		httpRequestDuration,
+		podOperations,
+		quotaRejections,
+		commitJobs,
+		commitJobDuration,
+		webShellSessions,
+		nodePoolTaintChanges,
+		nodePoolSyncs,
+		openAPIRequests,
+	)
+}
+
+// Handler /metrics 处理器
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{Registry: Registry})
+}
+
+// GinMiddleware 记录每个请求的延迟与状态码；route 使用 gin 路由模板（如 /api/pods/:id），
+// 未匹配路由记为 "unmatched"，避免路径参数导致标签基数爆炸
+func GinMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		method := c.Request.Method
+		status := strconv.Itoa(c.Writer.Status())
+		httpRequests.WithLabelValues(method, route, status).Inc()
+		httpRequestDuration.WithLabelValues(method, route).Observe(time.Since(start).Seconds())
+
+		if c.GetString("authMethod") == "apikey" {
+			keyID := c.GetString("openapiKeyID")
+			if keyID == "" {
+				keyID = "legacy"
+			}
+			openAPIRequests.WithLabelValues(keyID, method, route, status).Inc()
+		}
+	}
+}
+
+// RecordPodOperation 记录 Pod 创建/删除结果（operation: create | delete）
+func RecordPodOperation(operation string, err error) {
+	podOperations.WithLabelValues(operation, resultOf(err)).Inc()
+}
+
+// RecordQuotaRejection 记录一次配额拒绝（kind: pod | deployment | statefulset）
+func RecordQuotaRejection(kind string) {
+	quotaRejections.WithLabelValues(kind).Inc()
+}
+
+// RecordCommitJob 记录一个结束的镜像保存任务
+func RecordCommitJob(succeeded bool, duration time.Duration) {
+	result := ResultSuccess
+	if !succeeded {
+		result = ResultFailure
+	}
+	commitJobs.WithLabelValues(result).Inc()
+	if duration > 0 {
+		commitJobDuration.WithLabelValues(result).Observe(duration.Seconds())
+	}
+}
+
+// SetWebShellSessionsFunc 注册活跃 WebShell 会话数的读取函数
+func SetWebShellSessionsFunc(fn func() int) {
+	webShellSessionsFn = fn
+}
+
+// RecordNodePoolTaintChange 记录节点池协调器对节点污点的一次修改
+func RecordNodePoolTaintChange(action string) {
+	nodePoolTaintChanges.WithLabelValues(action).Inc()
+}
+
+// RecordNodePoolSync 记录一次节点池污点同步
+func RecordNodePoolSync(err error) {
+	nodePoolSyncs.WithLabelValues(resultOf(err)).Inc()
+}
+
+// CleanupStats 一次定时清理的统计
+type CleanupStats struct {
+	Checked   int
+	Deleted   int
+	Protected int
+	Suspended int
+	Failed    int
+	Duration  time.Duration
+	Err       error
+}
+
+// PushCleanupStats 清理任务由 CronJob 单独运行，无法被抓取，结束时推送到 Pushgateway
+func PushCleanupStats(pushgatewayURL string, stats CleanupStats) error {
+	registry := prometheus.NewRegistry()
+	gauge := func(name, help string, value float64) {
+		g := prometheus.NewGauge(prometheus.GaugeOpts{Namespace: namespace, Subsystem: "cleanup", Name: name, Help: help})
+		g.Set(value)
+		registry.MustRegister(g)
+	}
+	gauge("last_run_timestamp_seconds", "Unix time the last cleanup run finished.", float64(time.Now().Unix()))
+	gauge("last_run_duration_seconds", "Duration of the last cleanup run.", stats.Duration.Seconds())
+	gauge("last_run_success", "Whether the last cleanup run finished without error (1) or not (0).", boolValue(stats.Err == nil))
+	gauge("last_run_pods_checked", "Pods checked by the last cleanup run.", float64(stats.Checked))
+	gauge("last_run_pods_deleted", "Pods deleted by the last cleanup run.", float64(stats.Deleted))
+	gauge("last_run_pods_protected", "Pods skipped as protected by the last cleanup run.", float64(stats.Protected))
+	gauge("last_run_pods_failed", "Pods that failed to delete in the last cleanup run.", float64(stats.Failed))
+	gauge("last_run_workloads_suspended", "Deployments/StatefulSets suspended by the last cleanup run.", float64(stats.Suspended))
+
+	return push.New(pushgatewayURL, "genet_cleanup").Gatherer(registry).Push()
+}
+
+func resultOf(err error) string {
+	if err != nil {
+		return ResultFailure
+	}
+	return ResultSuccess
+}
+
+func boolValue(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,84 @@
+package metrics
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestGinMiddlewareUsesRouteTemplate(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(GinMiddleware())
+	router.GET("/api/pods/:id", func(c *gin.Context) { c.Status(http.StatusOK) })
+	router.GET("/openapi/pods", func(c *gin.Context) {
+		c.Set("authMethod", "apikey")
+		c.Set("openapiKeyID", "key-1")
+		c.Status(http.StatusForbidden)
+	})
+
+	for _, path := range []string{"/api/pods/a", "/api/pods/b", "/openapi/pods", "/nope"} {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	if got := testutil.ToFloat64(httpRequests.WithLabelValues("GET", "/api/pods/:id", "200")); got != 2 {
+		t.Fatalf("expected 2 requests on route template, got %v", got)
+	}
+	if got := testutil.ToFloat64(httpRequests.WithLabelValues("GET", "unmatched", "404")); got != 1 {
+		t.Fatalf("expected unmatched route to be grouped, got %v", got)
+	}
+	if got := testutil.ToFloat64(openAPIRequests.WithLabelValues("key-1", "GET", "/openapi/pods", "403")); got != 1 {
+		t.Fatalf("expected open api request per key, got %v", got)
+	}
+}
+
+func TestHandlerExposesGenetMetrics(t *testing.T) {
+	RecordPodOperation("create", nil)
+	RecordPodOperation("delete", errors.New("boom"))
+	RecordCommitJob(false, 90*time.Second)
+	SetWebShellSessionsFunc(func() int { return 3 })
+	defer SetWebShellSessionsFunc(nil)
+
+	recorder := httptest.NewRecorder()
+	Handler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	body := recorder.Body.String()
+	for _, want := range []string{
+		`genet_pod_operations_total{operation="create",result="success"}`,
+		`genet_pod_operations_total{operation="delete",result="failure"}`,
+		`genet_commit_job_duration_seconds_count{result="failure"} 1`,
+		`genet_webshell_active_sessions 3`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Fatalf("expected %q in metrics output:\n%s", want, body)
+		}
+	}
+}
+
+func TestPushCleanupStats(t *testing.T) {
+	var gotPath, gotBody string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		data, _ := io.ReadAll(r.Body)
+		gotBody = string(data)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	err := PushCleanupStats(server.URL, CleanupStats{Checked: 5, Deleted: 3, Failed: 1, Duration: 2 * time.Second})
+	if err != nil {
+		t.Fatalf("PushCleanupStats: %v", err)
+	}
+	if gotPath != "/metrics/job/genet_cleanup" {
+		t.Fatalf("unexpected push path %s", gotPath)
+	}
+	if !strings.Contains(gotBody, "genet_cleanup_last_run_pods_deleted") {
+		t.Fatalf("expected cleanup metrics in push body")
+	}
+}
@@ -0,0 +1,33 @@
+package models
+
+import "time"
+
+// CommitRecord 镜像保存记录，Job 被 TTL 清理后仍保留
+type CommitRecord struct {
+	ID              string     `json:"id"`                        // Job 名称
+	TargetImage     string     `json:"targetImage"`               // 目标镜像名称
+	SourcePod       string     `json:"sourcePod"`                 // 来源 Pod
+	Status          string     `json:"status"`                    // Queued, Pending, Running, Succeeded, Failed
+	Message         string     `json:"message,omitempty"`         // 状态消息
+	Digest          string     `json:"digest,omitempty"`          // 推送后的镜像 digest
+	Size            int64      `json:"size,omitempty"`            // 镜像大小（字节）
+	BaseSize        int64      `json:"baseSize,omitempty"`        // 提交前容器所用镜像的大小（字节）
+	CreatedAt       time.Time  `json:"createdAt"`                 // 提交时间
+	StartTime       *time.Time `json:"startTime,omitempty"`       // 开始执行时间
+	EndTime         *time.Time `json:"endTime,omitempty"`         // 结束时间
+	DurationSeconds int64      `json:"durationSeconds,omitempty"` // 执行耗时（秒）
+	LogTail         string     `json:"logTail,omitempty"`         // 结束时的日志末尾
+}
+
+// CommitHistory 用户镜像保存历史（最新在前）
+type CommitHistory struct {
+	Records []CommitRecord `json:"records"`
+}
+
+// CommitListResponse 镜像保存历史分页响应
+type CommitListResponse struct {
+	Items    []CommitRecord `json:"items"`
+	Total    int            `json:"total"`
+	Page     int            `json:"page"`
+	PageSize int            `json:"pageSize"`
+}
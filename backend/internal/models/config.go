@@ -2,6 +2,7 @@ package models
 
 import (
 	"os"
+	"strings"
 
 	corev1 "k8s.io/api/core/v1"
 	"sigs.k8s.io/yaml"
@@ -31,11 +32,17 @@ type Config struct {
 	UserRBAC        UserRBACConfig     `yaml:"userRBAC" json:"userRBAC"`
 	Proxy           ProxyConfig        `yaml:"proxy" json:"proxy"`
 	Registry        RegistryConfig     `yaml:"registry" json:"registry"`
+	Registries      []RegistryConfig   `yaml:"registries" json:"registries"` // 额外的镜像仓库（内网镜像、团队 Harbor、公共代理等），各自独立凭据与用途
 	Images          ImagesConfig       `yaml:"images" json:"images"`
+	Commit          CommitConfig       `yaml:"commit" json:"commit"`
+	ImageGC         ImageGCConfig      `yaml:"imageGC" json:"imageGC"`
+	ImageScan       ImageScanConfig    `yaml:"imageScan" json:"imageScan"`
 	Kubernetes      KubernetesConfig   `yaml:"kubernetes" json:"kubernetes"`
 	Kubeconfig      KubeconfigConfig   `yaml:"kubeconfig" json:"kubeconfig"`
 	PrometheusURL   string             `yaml:"prometheusURL" json:"prometheusURL"` // Prometheus 地址，如 http://prometheus.monitoring:9090
 	OpenAPI         OpenAPIConfig      `yaml:"openAPI" json:"openAPI"`
+	LogArchive      LogArchiveConfig   `yaml:"logArchive" json:"logArchive"`
+	Metrics         MetricsConfig      `yaml:"metrics" json:"metrics"`
 }
 
 // OpenAPIConfig Open API 配置
@@ -48,6 +55,34 @@ type OpenAPIConfig struct {
 // ImagesConfig 系统依赖镜像配置
 type ImagesConfig struct {
 	Nerdctl string `yaml:"nerdctl" json:"nerdctl"` // nerdctl 镜像，用于 commit 操作
+	Kaniko  string `yaml:"kaniko" json:"kaniko"`   // Kaniko executor 镜像，用于 Dockerfile 构建
+	Docker  string `yaml:"docker" json:"docker"`   // Docker CLI 镜像，用于 Docker 运行时节点的 commit
+}
+
+// CommitConfig 镜像保存（commit）默认选项，用户提交时可追加排除规则或覆盖 squash
+type CommitConfig struct {
+	DefaultExcludes    []string `yaml:"defaultExcludes" json:"defaultExcludes"`       // 默认排除的路径 glob，如 **/__pycache__、/root/.cache/pip
+	Squash             bool     `yaml:"squash" json:"squash"`                         // 默认是否合并本次提交产生的层（需要 nerdctl 支持 commit --squash）
+	HookTimeoutSeconds int      `yaml:"hookTimeoutSeconds" json:"hookTimeoutSeconds"` // 预提交清理脚本超时（秒），默认 300
+}
+
+// ImageGCConfig 用户镜像（保存的镜像与挂起快照）保留策略；只处理镜像保存历史中成功推送到 registry 的镜像，
+// 正在被 Pod/Deployment/StatefulSet 引用的镜像不会被删除
+type ImageGCConfig struct {
+	Enabled                  bool `yaml:"enabled" json:"enabled"`                                   // 启用定时 GC；管理员手动触发不受此开关影响
+	IntervalHours            int  `yaml:"intervalHours" json:"intervalHours"`                       // 定时 GC 间隔（小时），默认 24
+	KeepLastTags             int  `yaml:"keepLastTags" json:"keepLastTags"`                         // 每个用户每个仓库保留最新的 tag 数，0 表示不限制
+	DeleteSuspendAfterResume bool `yaml:"deleteSuspendAfterResume" json:"deleteSuspendAfterResume"` // 删除工作负载恢复后不再被引用的挂起快照
+	MaxTotalSizeGB           int  `yaml:"maxTotalSizeGB" json:"maxTotalSizeGB"`                     // 每个用户镜像总大小上限（GB），超出时从最旧的开始删除，0 表示不限制
+}
+
+// ImageScanConfig 创建 Pod/Deployment/StatefulSet 时按 registry 漏洞扫描结果检查镜像（目前仅 Harbor 提供扫描结果），
+// 只检查 registry 与 registries 中已配置仓库的镜像
+type ImageScanConfig struct {
+	Policy            string `yaml:"policy" json:"policy"`                       // off | warn | block，默认 off
+	SeverityThreshold string `yaml:"severityThreshold" json:"severityThreshold"` // Critical | High | Medium | Low，存在不低于该级别的漏洞时告警或阻止，默认 Critical
+	RequireScan       bool   `yaml:"requireScan" json:"requireScan"`             // 未扫描、扫描未完成或无法获取扫描结果的镜像同样告警或阻止
+	ExternalImages    string `yaml:"externalImages" json:"externalImages"`       // 不在已配置 registry 中的镜像（无法获取扫描结果）：allow | warn | block，默认 allow，与 policy 独立生效
 }
 
 // KubernetesConfig Kubernetes 客户端配置
@@ -58,11 +93,20 @@ type KubernetesConfig struct {
 
 // RegistryConfig 镜像仓库配置
 type RegistryConfig struct {
+	Name     string `yaml:"name" json:"name"`         // 仓库名称，多仓库时用于区分，默认取 url 的 host
 	URL      string `yaml:"url" json:"url"`           // 镜像仓库地址
 	Username string `yaml:"username" json:"username"` // 仓库用户名
 	Password string `yaml:"password" json:"password"` // 仓库密码
 	Insecure bool   `yaml:"insecure" json:"insecure"` // 是否使用 HTTP（不安全）协议，默认 false
-	Type     string `yaml:"type" json:"type"`         // 仓库类型：harbor | docker，默认 docker
+	Type     string `yaml:"type" json:"type"`         // 仓库类型：harbor | docker | gitlab | nexus，默认 docker
+	// APIURL GitLab / Nexus 的 REST API 地址（如 https://gitlab.example.com），为空时与 url 相同
+	APIURL string `yaml:"apiURL" json:"apiURL"`
+	// Repository Nexus 中的 docker 仓库名，用于限定搜索范围，为空时搜索所有 docker 仓库
+	Repository string `yaml:"repository" json:"repository"`
+	// Purposes 用途：search（镜像搜索）、push（保存/构建镜像推送）、pull（为工作负载生成 imagePullSecrets），为空时为 search 和 push
+	Purposes []string `yaml:"purposes" json:"purposes,omitempty"`
+	// Users 推送仓库分配给的用户名或邮箱，支持 * 通配（如 *@ml.example.com 表示整个团队），为空时作为默认推送仓库
+	Users []string `yaml:"users" json:"users,omitempty"`
 }
 
 // ProxyConfig 代理配置
@@ -229,7 +273,7 @@ type PodConfig struct {
 	ExtraVolumeMounts []corev1.VolumeMount `yaml:"extraVolumeMounts,omitempty" json:"extraVolumeMounts,omitempty"`
 
 	// StartupScript 容器启动脚本模板
-	// 可用变量: {{.ProxyScript}}, {{.CodeServerScript}}
+	// 可用变量: {{.ProxyScript}}, {{.CodeServerScript}}, {{.SSHScript}}
 	// 如果为空，使用默认脚本
 	StartupScript string `yaml:"startupScript,omitempty" json:"startupScript,omitempty"`
 
@@ -240,6 +284,18 @@ type PodConfig struct {
 
 	// CodeServer Web IDE 配置
 	CodeServer CodeServerConfig `yaml:"codeServer,omitempty" json:"codeServer,omitempty"`
+
+	// AppProxy 通用应用代理配置（TensorBoard、Gradio 等 Pod 内 Web 应用）
+	AppProxy AppProxyConfig `yaml:"appProxy,omitempty" json:"appProxy,omitempty"`
+
+	// SSH 托管 SSH 配置（公钥注入 + sshd 启动）
+	SSH SSHConfig `yaml:"ssh,omitempty" json:"ssh,omitempty"`
+
+	// FileTransfer 文件上传/下载（genet cp）配置
+	FileTransfer FileTransferConfig `yaml:"fileTransfer,omitempty" json:"fileTransfer,omitempty"`
+
+	// WebShell Web Shell 配置
+	WebShell WebShellConfig `yaml:"webShell,omitempty" json:"webShell,omitempty"`
 }
 
 // CodeServerConfig code-server 配置
@@ -253,6 +309,77 @@ type CodeServerConfig struct {
 	StartTimeoutSeconds int    `yaml:"startTimeoutSeconds" json:"startTimeoutSeconds"`
 }
 
+// AppProxyConfig Pod 内任意 Web 应用的代理配置
+// 用户需在 Pod 上显式开放端口（genet.io/proxy-ports），且端口必须在管理员白名单内
+type AppProxyConfig struct {
+	Enabled      bool  `yaml:"enabled" json:"enabled"`
+	AllowedPorts []int `yaml:"allowedPorts,omitempty" json:"allowedPorts,omitempty"` // 允许代理的端口白名单
+	// Domain 应用代理的泛域名（如 apps.genet.example.com），每个应用使用独立的子域名，
+	// 与 Genet 不同源，应用内脚本无法携带用户 Cookie 调用 /api。需要 *.Domain 的 DNS 与证书，未配置时应用代理不可用
+	Domain string `yaml:"domain,omitempty" json:"domain,omitempty"`
+	// 分享链接：签名 + 过期时间，允许无 Pod 权限的已登录用户访问
+	ShareLinkEnabled       bool `yaml:"shareLinkEnabled" json:"shareLinkEnabled"`
+	ShareLinkMaxTTLMinutes int  `yaml:"shareLinkMaxTTLMinutes,omitempty" json:"shareLinkMaxTTLMinutes,omitempty"` // 分享链接最长有效期（分钟），默认 1440
+}
+
+// IsAvailable 应用代理已开启且配置了独立域名
+func (c AppProxyConfig) IsAvailable() bool {
+	return c.Enabled && strings.TrimSpace(c.Domain) != ""
+}
+
+// IsPortAllowed 检查端口是否在管理员白名单内
+func (c AppProxyConfig) IsPortAllowed(port int) bool {
+	for _, allowed := range c.AllowedPorts {
+		if allowed == port {
+			return true
+		}
+	}
+	return false
+}
+
+// SSHConfig 托管 SSH 配置
+// 启用后 Pod 启动时会以用户登记的公钥启动 sshd（需在 startupScript 中引用 {{.SSHScript}}）
+type SSHConfig struct {
+	Enabled bool   `yaml:"enabled" json:"enabled"`
+	User    string `yaml:"user,omitempty" json:"user,omitempty"` // 登录用户，默认 root
+	// Port 非 hostNetwork 模式下 sshd 监听端口，默认 22
+	Port int `yaml:"port,omitempty" json:"port,omitempty"`
+	// hostNetwork 模式下为每个 Pod 分配的端口范围（集群内唯一）
+	HostPortRangeStart int `yaml:"hostPortRangeStart,omitempty" json:"hostPortRangeStart,omitempty"`
+	HostPortRangeEnd   int `yaml:"hostPortRangeEnd,omitempty" json:"hostPortRangeEnd,omitempty"`
+	// Host 对外展示的 SSH 地址，留空时使用节点 IP（hostNetwork）或 Pod IP
+	Host string `yaml:"host,omitempty" json:"host,omitempty"`
+	// ToolsDir ssh-tools 安装目录（镜像内没有 sshd 时使用，见 tools/ssh-tools）
+	ToolsDir string `yaml:"toolsDir,omitempty" json:"toolsDir,omitempty"`
+}
+
+// FileTransferConfig Pod 文件传输配置（tar 流，通过 exec 实现）
+type FileTransferConfig struct {
+	Enabled bool `yaml:"enabled" json:"enabled"`
+	// 单次下载/上传的 tar 流大小上限（MiB），0 表示使用默认值
+	MaxDownloadMB int64 `yaml:"maxDownloadMB,omitempty" json:"maxDownloadMB,omitempty"`
+	MaxUploadMB   int64 `yaml:"maxUploadMB,omitempty" json:"maxUploadMB,omitempty"`
+	// DeniedPaths 禁止读写的路径前缀
+	DeniedPaths []string `yaml:"deniedPaths,omitempty" json:"deniedPaths,omitempty"`
+}
+
+// WebShellConfig Web Shell 配置
+type WebShellConfig struct {
+	Recording WebShellRecordingConfig `yaml:"recording,omitempty" json:"recording,omitempty"`
+	// Multiplexer 会话保持方式：auto（优先 tmux，其次 screen）、tmux、screen、none（断开即结束），默认 auto
+	Multiplexer string `yaml:"multiplexer,omitempty" json:"multiplexer,omitempty"`
+	// DetachedSessionTTLMinutes 浏览器断开后会话保留多久可重新连接，默认 1440（24 小时）
+	DetachedSessionTTLMinutes int `yaml:"detachedSessionTTLMinutes,omitempty" json:"detachedSessionTTLMinutes,omitempty"`
+}
+
+// WebShellRecordingConfig Web Shell 会话录制（asciicast v2），按用户存放在 Dir 下
+type WebShellRecordingConfig struct {
+	Enabled       bool   `yaml:"enabled" json:"enabled"`
+	Dir           string `yaml:"dir,omitempty" json:"dir,omitempty"`                     // 录制文件目录（多副本时必须是共享的持久卷）
+	RetentionDays int    `yaml:"retentionDays,omitempty" json:"retentionDays,omitempty"` // 保留天数，默认 30
+	MaxSizeMB     int64  `yaml:"maxSizeMB,omitempty" json:"maxSizeMB,omitempty"`         // 单个录制文件上限（MiB），超出后停止录制，默认 64
+}
+
 // GPUConfig GPU 相关配置
 type GPUConfig struct {
 	// GPU 调度模式
@@ -418,6 +545,30 @@ type CleanupConfig struct {
 	Timezone string `yaml:"timezone" json:"timezone"` // 时区（如 "Asia/Shanghai"）
 }
 
+// MetricsConfig Genet 自身指标导出；API 服务始终在 /metrics 暴露指标
+type MetricsConfig struct {
+	PushgatewayURL string `yaml:"pushgatewayURL,omitempty" json:"pushgatewayURL,omitempty"` // 清理 CronJob 结束时推送统计的 Pushgateway 地址，留空不推送
+}
+
+// LogArchiveConfig Pod 删除前归档容器日志与事件，便于事后排查
+type LogArchiveConfig struct {
+	Enabled  bool               `yaml:"enabled" json:"enabled"`
+	Backend  string             `yaml:"backend" json:"backend"`                       // pvc | s3，默认 pvc
+	Dir      string             `yaml:"dir,omitempty" json:"dir,omitempty"`           // backend=pvc 时的归档目录（建议挂载持久卷）
+	MaxLogMB int                `yaml:"maxLogMB,omitempty" json:"maxLogMB,omitempty"` // 单个容器日志最多归档的大小，默认 16
+	S3       LogArchiveS3Config `yaml:"s3,omitempty" json:"s3,omitempty"`
+}
+
+// LogArchiveS3Config S3 兼容对象存储（MinIO、Ceph RGW 等），使用 path-style 访问
+type LogArchiveS3Config struct {
+	Endpoint        string `yaml:"endpoint" json:"endpoint"` // 如 http://minio.storage:9000
+	Bucket          string `yaml:"bucket" json:"bucket"`
+	Region          string `yaml:"region" json:"region"` // 默认 us-east-1
+	Prefix          string `yaml:"prefix" json:"prefix"` // 对象键前缀，如 genet/logs
+	AccessKeyID     string `yaml:"accessKeyID" json:"accessKeyID"`
+	SecretAccessKey string `yaml:"secretAccessKey" json:"secretAccessKey"`
+}
+
 // LoadConfig 从文件加载配置
 func LoadConfig(path string) (*Config, error) {
 	data, err := os.ReadFile(path)
@@ -509,6 +660,8 @@ echo "VS Code Server directory linked to ` + DefaultVSCodeServerDir + `"
 
 {{.CodeServerScript}}
 
+{{.SSHScript}}
+
 # 显示 GPU 信息（如果有）
 if command -v nvidia-smi > /dev/null 2>&1; then
     echo "===== GPU Information ====="
@@ -578,6 +731,36 @@ chmod +x "$CODE_SERVER_BIN_DIR/code-server"
 echo "code-server installed to $CODE_SERVER_BIN_DIR/code-server"`,
 				StartTimeoutSeconds: 20,
 			},
+			AppProxy: AppProxyConfig{
+				Enabled:                false,
+				AllowedPorts:           []int{6006, 7860, 8501, 8888},
+				ShareLinkEnabled:       true,
+				ShareLinkMaxTTLMinutes: 1440,
+			},
+			SSH: SSHConfig{
+				Enabled:            false,
+				User:               "root",
+				Port:               22,
+				HostPortRangeStart: 30022,
+				HostPortRangeEnd:   30999,
+				ToolsDir:           "/workspace/.genet",
+			},
+			FileTransfer: FileTransferConfig{
+				Enabled:       true,
+				MaxDownloadMB: 2048,
+				MaxUploadMB:   1024,
+				DeniedPaths:   []string{"/proc", "/sys", "/dev"},
+			},
+			WebShell: WebShellConfig{
+				Recording: WebShellRecordingConfig{
+					Enabled:       false,
+					Dir:           "/var/lib/genet/webshell-recordings",
+					RetentionDays: 30,
+					MaxSizeMB:     64,
+				},
+				Multiplexer:               "auto",
+				DetachedSessionTTLMinutes: 1440,
+			},
 		},
 		OAuth: OAuthConfig{
 			Enabled:               false,
@@ -628,6 +811,35 @@ echo "code-server installed to $CODE_SERVER_BIN_DIR/code-server"`,
 		},
 		Images: ImagesConfig{
 			Nerdctl: "ghcr.io/containerd/nerdctl:v1.7.0",
+			Kaniko:  "gcr.io/kaniko-project/executor:v1.23.2",
+			Docker:  "docker:24-cli",
+		},
+		Commit: CommitConfig{
+			DefaultExcludes: []string{
+				"**/__pycache__",
+				"/root/.cache/pip",
+				"/root/.conda/pkgs",
+				"/opt/conda/pkgs",
+				"/root/.vscode-server",
+			},
+			Squash:             false,
+			HookTimeoutSeconds: 300,
+		},
+		ImageGC: ImageGCConfig{
+			Enabled:                  false,
+			IntervalHours:            24,
+			DeleteSuspendAfterResume: true,
+		},
+		ImageScan: ImageScanConfig{
+			Policy:            "off",
+			SeverityThreshold: "Critical",
+			ExternalImages:    "allow",
+		},
+		LogArchive: LogArchiveConfig{
+			Enabled:  false,
+			Backend:  "pvc",
+			Dir:      "/var/lib/genet/log-archive",
+			MaxLogMB: 16,
 		},
 		Kubernetes: KubernetesConfig{
 			DisableProxy: true, // 默认禁用代理，避免 Windows 代理冲突
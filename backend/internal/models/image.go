@@ -4,10 +4,73 @@ import "time"
 
 // UserSavedImage 用户保存的镜像记录
 type UserSavedImage struct {
-	Image       string    `json:"image"`                 // 完整镜像名
-	Description string    `json:"description,omitempty"` // 描述（可选）
-	SourcePod   string    `json:"sourcePod,omitempty"`   // 来源 Pod
-	SavedAt     time.Time `json:"savedAt"`               // 保存时间
+	Image       string         `json:"image"`                 // 完整镜像名
+	Description string         `json:"description,omitempty"` // 描述（可选）
+	SourcePod   string         `json:"sourcePod,omitempty"`   // 来源 Pod
+	SavedAt     time.Time      `json:"savedAt"`               // 保存时间
+	Manifest    *ImageManifest `json:"manifest,omitempty"`    // registry 中的元数据，仅在 details=true 时查询，不持久化
+}
+
+// ImageManifest 镜像在 registry 中的元数据
+type ImageManifest struct {
+	Digest    string            `json:"digest"`              // manifest（多架构镜像为 index）的 digest
+	MediaType string            `json:"mediaType,omitempty"` // manifest 媒体类型
+	Size      int64             `json:"size"`                // 压缩后大小（config 与各层之和），多架构镜像为各平台之和
+	Platforms []string          `json:"platforms,omitempty"` // os/architecture[/variant]
+	Labels    map[string]string `json:"labels,omitempty"`    // 镜像 config 中的 LABEL
+	Created   *time.Time        `json:"created,omitempty"`   // 镜像构建时间
+
+	Vulnerabilities *VulnerabilitySummary `json:"vulnerabilities,omitempty"` // 漏洞扫描摘要，仅支持扫描的 registry（Harbor）返回
+}
+
+// 漏洞严重级别（与 Harbor 一致）
+const (
+	SeverityCritical   = "Critical"
+	SeverityHigh       = "High"
+	SeverityMedium     = "Medium"
+	SeverityLow        = "Low"
+	SeverityNegligible = "Negligible"
+	SeverityUnknown    = "Unknown"
+)
+
+// VulnerabilitySummary 镜像漏洞扫描摘要
+type VulnerabilitySummary struct {
+	ScanStatus string         `json:"scanStatus"`         // Success | Running | Pending | Error | Stopped | NotScanned
+	Severity   string         `json:"severity,omitempty"` // 最高严重级别，无漏洞时为 None
+	Counts     map[string]int `json:"counts,omitempty"`   // 各严重级别的漏洞数
+	Total      int            `json:"total"`
+	Fixable    int            `json:"fixable"`
+	ScannedAt  *time.Time     `json:"scannedAt,omitempty"`
+}
+
+// severityRank 严重级别排序，未知级别排在 Low 之下
+var severityRank = map[string]int{
+	SeverityCritical:   5,
+	SeverityHigh:       4,
+	SeverityMedium:     3,
+	SeverityLow:        2,
+	SeverityNegligible: 1,
+	SeverityUnknown:    1,
+}
+
+// SeverityAtLeast severity 是否不低于 threshold
+func SeverityAtLeast(severity, threshold string) bool {
+	rank, ok := severityRank[severity]
+	return ok && rank >= severityRank[threshold]
+}
+
+// CountAtLeast 严重级别不低于 threshold 的漏洞数
+func (s *VulnerabilitySummary) CountAtLeast(threshold string) int {
+	if s == nil {
+		return 0
+	}
+	total := 0
+	for severity, count := range s.Counts {
+		if SeverityAtLeast(severity, threshold) {
+			total += count
+		}
+	}
+	return total
 }
 
 // UserImageList 用户镜像列表
@@ -21,3 +84,29 @@ type AddUserImageRequest struct {
 	Description string `json:"description,omitempty"`    // 描述（可选）
 	SourcePod   string `json:"sourcePod,omitempty"`      // 来源 Pod
 }
+
+// ImageGCCandidate GC 选中删除的镜像
+type ImageGCCandidate struct {
+	Username  string    `json:"username"`
+	Namespace string    `json:"namespace"`
+	Image     string    `json:"image"`
+	Reason    string    `json:"reason"`         // keep-last | suspend-resumed | max-size
+	Size      int64     `json:"size,omitempty"` // 镜像大小（字节），来自镜像保存历史，未知时为 0
+	SavedAt   time.Time `json:"savedAt"`
+	Deleted   bool      `json:"deleted"`
+	Error     string    `json:"error,omitempty"`
+}
+
+// ImageGCReport 一次镜像 GC 的结果；DryRun 时只列出候选镜像，不做删除
+type ImageGCReport struct {
+	DryRun     bool               `json:"dryRun"`
+	Trigger    string             `json:"trigger"` // admin | schedule
+	StartedAt  time.Time          `json:"startedAt"`
+	FinishedAt time.Time          `json:"finishedAt"`
+	Scanned    int                `json:"scanned"` // 参与评估的镜像数
+	Candidates []ImageGCCandidate `json:"candidates"`
+	Deleted    int                `json:"deleted"`
+	Failed     int                `json:"failed"`
+	FreedBytes int64              `json:"freedBytes"`
+	Errors     []string           `json:"errors,omitempty"`
+}
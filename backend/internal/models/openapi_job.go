@@ -42,6 +42,7 @@ type OpenAPIJobResponse struct {
 	Status         string     `json:"status"`
 	CreatedAt      time.Time  `json:"createdAt"`
 	CompletionTime *time.Time `json:"completionTime,omitempty"`
+	Warnings       []string   `json:"warnings,omitempty"` // 创建时镜像漏洞策略的告警
 }
 
 type OpenAPIJobListResponse struct {
@@ -17,6 +17,8 @@ type PodRequest struct {
 	Name string `json:"name,omitempty"` // 自定义 Pod 名称后缀（可选），如 "train", "dev"，为空则使用时间戳
 	// 用户自定义挂载（需要管理员开启 storage.allowUserMounts）
 	UserMounts []UserMount `json:"userMounts,omitempty"`
+	// 开放给通用应用代理的端口（需在管理员白名单 pod.appProxy.allowedPorts 内）
+	ProxyPorts []int `json:"proxyPorts,omitempty"`
 }
 
 // UserMount 用户自定义挂载
@@ -60,15 +62,51 @@ type PodSSHConnections struct {
 }
 
 type PodAppConnections struct {
-	SSHCommand       string `json:"sshCommand,omitempty"`
-	VSCodeURI        string `json:"vscodeURI,omitempty"`
-	XshellURI        string `json:"xshellURI,omitempty"`
-	CodeServerURL    string `json:"codeServerURL,omitempty"`
-	CodeServerReady  bool   `json:"codeServerReady"`
-	CodeServerStatus string `json:"codeServerStatus,omitempty"`
-	WebShellURL      string `json:"webShellURL,omitempty"`
-	WebShellReady    bool   `json:"webShellReady"`
-	WebShellStatus   string `json:"webShellStatus,omitempty"`
+	SSHCommand       string        `json:"sshCommand,omitempty"`
+	VSCodeURI        string        `json:"vscodeURI,omitempty"`
+	XshellURI        string        `json:"xshellURI,omitempty"`
+	CodeServerURL    string        `json:"codeServerURL,omitempty"`
+	CodeServerReady  bool          `json:"codeServerReady"`
+	CodeServerStatus string        `json:"codeServerStatus,omitempty"`
+	WebShellURL      string        `json:"webShellURL,omitempty"`
+	WebShellReady    bool          `json:"webShellReady"`
+	WebShellStatus   string        `json:"webShellStatus,omitempty"`
+	AppProxies       []PodAppProxy `json:"appProxies,omitempty"`
+}
+
+// PodAppProxy Pod 内通过通用代理暴露的应用
+type PodAppProxy struct {
+	Port int    `json:"port"`
+	URL  string `json:"url"`
+}
+
+// UpdatePodProxyPortsRequest 更新 Pod 开放代理端口请求
+type UpdatePodProxyPortsRequest struct {
+	Ports []int `json:"ports"`
+}
+
+// CreatePodProxyShareRequest 创建应用分享链接请求
+type CreatePodProxyShareRequest struct {
+	Port             int `json:"port" binding:"required"`
+	ExpiresInMinutes int `json:"expiresInMinutes"` // 为空时使用最长有效期
+}
+
+// PodProxyShareResponse 应用分享链接响应
+type PodProxyShareResponse struct {
+	ID        string    `json:"id"`
+	URL       string    `json:"url"`
+	Token     string    `json:"token"`
+	Port      int       `json:"port"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// PodProxyShare 记录在 Pod 上的有效分享链接，撤销后链接立即失效
+type PodProxyShare struct {
+	ID        string    `json:"id"`
+	Port      int       `json:"port"`
+	CreatedBy string    `json:"createdBy,omitempty"`
+	CreatedAt time.Time `json:"createdAt"`
+	ExpiresAt time.Time `json:"expiresAt"`
 }
 
 // PodListResponse Pod 列表响应
@@ -95,6 +133,13 @@ type StorageVolumeInfo struct {
 	Scope       string `json:"scope,omitempty"`       // 作用域: user | pod
 }
 
+// RegistryInfo 镜像仓库信息（用于前端展示，不含凭据）
+type RegistryInfo struct {
+	Name string `json:"name"`           // 仓库名称
+	Host string `json:"host"`           // 镜像名前缀，如 harbor.example.com
+	Type string `json:"type,omitempty"` // 仓库类型
+}
+
 // ConfigResponse 配置响应
 type ConfigResponse struct {
 	PodLimitPerUser int           `json:"podLimitPerUser"`
@@ -112,7 +157,11 @@ type ConfigResponse struct {
 	// 用户镜像
 	UserImages []UserSavedImage `json:"userImages,omitempty"` // 用户保存的镜像列表
 	// 镜像仓库
-	RegistryURL string `json:"registryUrl,omitempty"` // 镜像仓库地址（用于前端展示）
+	RegistryURL      string         `json:"registryUrl,omitempty"`      // 当前用户的推送仓库地址（用于拼接保存镜像名）
+	SearchRegistries []RegistryInfo `json:"searchRegistries,omitempty"` // 用于镜像搜索的仓库
+	// 镜像保存
+	CommitDefaultExcludes []string `json:"commitDefaultExcludes,omitempty"` // 管理员配置的默认排除路径
+	CommitSquash          bool     `json:"commitSquash"`                    // 默认是否合并提交层
 	// Pod 清理
 	CleanupSchedule string `json:"cleanupSchedule,omitempty"` // Cron 表达式（如 "0 23 * * *"）
 	CleanupTimezone string `json:"cleanupTimezone,omitempty"` // 时区（如 "Asia/Shanghai"）
@@ -0,0 +1,136 @@
+package models
+
+import (
+	"path"
+	"strings"
+	"time"
+)
+
+// 镜像仓库用途
+const (
+	RegistryPurposeSearch = "search" // 创建 Pod 时的镜像搜索和 tag 列表
+	RegistryPurposePush   = "push"   // 保存/构建镜像、休眠快照推送
+	RegistryPurposePull   = "pull"   // 为用户工作负载生成 imagePullSecrets
+)
+
+// Host 仓库地址去掉协议和结尾的 /，即镜像名前缀
+func (r *RegistryConfig) Host() string {
+	host := strings.TrimSuffix(strings.TrimSpace(r.URL), "/")
+	return strings.TrimPrefix(strings.TrimPrefix(host, "https://"), "http://")
+}
+
+// DisplayName 仓库名称，未配置时为 host
+func (r *RegistryConfig) DisplayName() string {
+	if r.Name != "" {
+		return r.Name
+	}
+	return r.Host()
+}
+
+// HasPurpose 是否用于指定用途；未配置 purposes 时为 search 和 push，与单仓库时的行为一致
+func (r *RegistryConfig) HasPurpose(purpose string) bool {
+	if len(r.Purposes) == 0 {
+		return purpose == RegistryPurposeSearch || purpose == RegistryPurposePush
+	}
+	for _, p := range r.Purposes {
+		if p == purpose {
+			return true
+		}
+	}
+	return false
+}
+
+// MatchesUser 用户是否在 users 中（用户名或邮箱，支持 * 通配）
+func (r *RegistryConfig) MatchesUser(username, email string) bool {
+	for _, pattern := range r.Users {
+		pattern = strings.TrimSpace(pattern)
+		if pattern == "" {
+			continue
+		}
+		for _, candidate := range []string{username, email} {
+			if candidate == "" {
+				continue
+			}
+			if matched, _ := path.Match(pattern, candidate); matched || pattern == candidate {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// AllRegistries 返回 registry 与 registries 合并后的仓库列表，registry（已配置时）排在最前
+func (c *Config) AllRegistries() []RegistryConfig {
+	registries := make([]RegistryConfig, 0, len(c.Registries)+1)
+	if c.Registry.URL != "" {
+		registries = append(registries, c.Registry)
+	}
+	for _, registry := range c.Registries {
+		if registry.URL != "" {
+			registries = append(registries, registry)
+		}
+	}
+	return registries
+}
+
+// RegistriesFor 返回指定用途的仓库
+func (c *Config) RegistriesFor(purpose string) []RegistryConfig {
+	var registries []RegistryConfig
+	for _, registry := range c.AllRegistries() {
+		if registry.HasPurpose(purpose) {
+			registries = append(registries, registry)
+		}
+	}
+	return registries
+}
+
+// PushRegistryFor 用户的推送仓库：优先 users 命中该用户的推送仓库，否则为第一个未限定 users 的推送仓库；都没有时返回 nil
+func (c *Config) PushRegistryFor(username, email string) *RegistryConfig {
+	var fallback *RegistryConfig
+	registries := c.RegistriesFor(RegistryPurposePush)
+	for i := range registries {
+		registry := &registries[i]
+		if len(registry.Users) == 0 {
+			if fallback == nil {
+				fallback = registry
+			}
+			continue
+		}
+		if registry.MatchesUser(username, email) {
+			return registry
+		}
+	}
+	return fallback
+}
+
+// RegistryForImage 镜像所属的仓库（按 host 最长前缀匹配），不属于任何已配置仓库时返回 nil
+func (c *Config) RegistryForImage(image string) *RegistryConfig {
+	var matched *RegistryConfig
+	registries := c.AllRegistries()
+	for i := range registries {
+		host := registries[i].Host()
+		if host == "" || !strings.HasPrefix(image, host+"/") {
+			continue
+		}
+		if matched == nil || len(host) > len(matched.Host()) {
+			matched = &registries[i]
+		}
+	}
+	return matched
+}
+
+// RegistryCredential 用户登记的镜像仓库凭据（密码只保存在 Secret 中，不返回）
+type RegistryCredential struct {
+	Name      string    `json:"name"`     // Secret 名称，即 imagePullSecrets 中引用的名称
+	Registry  string    `json:"registry"` // 仓库 host，如 ghcr.io、registry.example.com:5000
+	Username  string    `json:"username"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// SaveRegistryCredentialRequest 保存仓库凭据请求，同一仓库重复保存时覆盖
+type SaveRegistryCredentialRequest struct {
+	Registry string `json:"registry" binding:"required"`
+	Username string `json:"username" binding:"required"`
+	Password string `json:"password" binding:"required"` // 密码或 Access Token
+	Insecure bool   `json:"insecure,omitempty"`          // 仓库只支持 HTTP 或使用自签名证书
+}
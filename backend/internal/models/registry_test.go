@@ -0,0 +1,42 @@
+package models
+
+import "testing"
+
+func TestPushRegistryForPrefersUserAssignment(t *testing.T) {
+	cfg := &Config{
+		Registry: RegistryConfig{URL: "https://registry.local/"},
+		Registries: []RegistryConfig{
+			{Name: "mirror", URL: "mirror.local", Purposes: []string{RegistryPurposePull}},
+			{Name: "ml-harbor", URL: "harbor.ml.local", Purposes: []string{RegistryPurposeSearch, RegistryPurposePush}, Users: []string{"*@ml.example.com", "carol"}},
+		},
+	}
+
+	if registry := cfg.PushRegistryFor("alice", "alice@ml.example.com"); registry == nil || registry.Name != "ml-harbor" {
+		t.Fatalf("expected team registry for alice, got %+v", registry)
+	}
+	if registry := cfg.PushRegistryFor("carol", ""); registry == nil || registry.Name != "ml-harbor" {
+		t.Fatalf("expected team registry for carol, got %+v", registry)
+	}
+	if registry := cfg.PushRegistryFor("bob", "bob@example.com"); registry == nil || registry.Host() != "registry.local" {
+		t.Fatalf("expected default registry for bob, got %+v", registry)
+	}
+	if pull := cfg.RegistriesFor(RegistryPurposePull); len(pull) != 1 || pull[0].Name != "mirror" {
+		t.Fatalf("pull must be configured explicitly, got %+v", pull)
+	}
+}
+
+func TestRegistryForImageMatchesLongestHost(t *testing.T) {
+	cfg := &Config{
+		Registry:   RegistryConfig{URL: "registry.local"},
+		Registries: []RegistryConfig{{Name: "team", URL: "registry.local:5000"}},
+	}
+	if registry := cfg.RegistryForImage("registry.local:5000/alice/train:v1"); registry == nil || registry.Name != "team" {
+		t.Fatalf("unexpected registry: %+v", registry)
+	}
+	if registry := cfg.RegistryForImage("registry.local/alice/train:v1"); registry == nil || registry.Name != "" {
+		t.Fatalf("unexpected registry: %+v", registry)
+	}
+	if registry := cfg.RegistryForImage("docker.io/library/ubuntu:22.04"); registry != nil {
+		t.Fatalf("expected no registry, got %+v", registry)
+	}
+}
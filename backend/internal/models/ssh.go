@@ -0,0 +1,24 @@
+package models
+
+import "time"
+
+// UserSSHKey 用户登记的 SSH 公钥
+type UserSSHKey struct {
+	ID          string    `json:"id"`          // 公钥指纹派生的短 ID
+	Name        string    `json:"name"`        // 备注名称（默认取公钥注释）
+	Type        string    `json:"type"`        // 公钥类型，如 ssh-ed25519
+	PublicKey   string    `json:"publicKey"`   // authorized_keys 格式的公钥
+	Fingerprint string    `json:"fingerprint"` // SHA256 指纹
+	CreatedAt   time.Time `json:"createdAt"`
+}
+
+// UserSSHKeyList 用户 SSH 公钥列表
+type UserSSHKeyList struct {
+	Keys []UserSSHKey `json:"keys"`
+}
+
+// AddSSHKeyRequest 添加 SSH 公钥请求
+type AddSSHKeyRequest struct {
+	Name      string `json:"name,omitempty"`
+	PublicKey string `json:"publicKey" binding:"required"`
+}
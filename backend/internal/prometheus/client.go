@@ -234,74 +234,7 @@ func (c *Client) queryMetricWithLabels(ctx context.Context, metricName string, l
 			Timestamp:   sample.Timestamp.Time(),
 		}
 
-		// 提取标签 - 优先使用自定义标签名，然后是默认标签名
-		for name, value := range sample.Metric {
-			labelName := string(name)
-
-			// 设备ID
-			if labelConfig.DeviceID != "" && labelName == labelConfig.DeviceID {
-				metric.DeviceID = string(value)
-			} else if labelConfig.DeviceID == "" {
-				switch labelName {
-				case "gpu", "id", "device", "gpu_id", "device_id", "GPU_I", "minor_number":
-					if metric.DeviceID == "" {
-						metric.DeviceID = string(value)
-					}
-				}
-			}
-
-			// 节点名 - 优先级: Hostname > node > hostname > kubernetes_node > node_name > instance
-			// instance 通常是 IP:port 格式，优先级最低
-			if labelConfig.Node != "" && labelName == labelConfig.Node {
-				metric.Node = string(value)
-			} else if labelConfig.Node == "" {
-				switch labelName {
-				case "Hostname", "node", "hostname", "kubernetes_node", "node_name":
-					// 高优先级标签，直接覆盖
-					metric.Node = string(value)
-				case "instance":
-					// instance 优先级最低，只在没有其他值时使用
-					if metric.Node == "" {
-						metric.Node = string(value)
-					}
-				}
-			}
-
-			// Pod名
-			if labelConfig.Pod != "" && labelName == labelConfig.Pod {
-				metric.Pod = string(value)
-			} else if labelConfig.Pod == "" {
-				switch labelName {
-				case "pod", "exported_pod", "pod_name", "kubernetes_pod":
-					if metric.Pod == "" {
-						metric.Pod = string(value)
-					}
-				}
-			}
-
-			// Namespace
-			if labelConfig.Namespace != "" && labelName == labelConfig.Namespace {
-				metric.Namespace = string(value)
-			} else if labelConfig.Namespace == "" {
-				switch labelName {
-				case "namespace", "exported_namespace", "kubernetes_namespace":
-					if metric.Namespace == "" {
-						metric.Namespace = string(value)
-					}
-				}
-			}
-		}
-
-		// 处理 node 字段：移除端口号
-		if metric.Node != "" {
-			// 如果 node 包含端口号 (例如 "node-gpu-01:9400")，移除端口部分
-			for i, ch := range metric.Node {
-				if ch == ':' {
-					metric.Node = metric.Node[:i]
-					break
-				}
-			}
-		}
+		applyMetricLabels(&metric, sample.Metric, labelConfig)
 
 		metrics = append(metrics, metric)
 	}
@@ -325,6 +258,78 @@ func (c *Client) queryMetricWithLabels(ctx context.Context, metricName string, l
 	return metrics, nil
 }
 
+// applyMetricLabels 从样本标签中提取设备ID、节点、Pod、Namespace
+func applyMetricLabels(metric *DeviceMetric, labels model.Metric, labelConfig MetricLabelConfig) {
+	// 提取标签 - 优先使用自定义标签名，然后是默认标签名
+	for name, value := range labels {
+		labelName := string(name)
+
+		// 设备ID
+		if labelConfig.DeviceID != "" && labelName == labelConfig.DeviceID {
+			metric.DeviceID = string(value)
+		} else if labelConfig.DeviceID == "" {
+			switch labelName {
+			case "gpu", "id", "device", "gpu_id", "device_id", "GPU_I", "minor_number":
+				if metric.DeviceID == "" {
+					metric.DeviceID = string(value)
+				}
+			}
+		}
+
+		// 节点名 - 优先级: Hostname > node > hostname > kubernetes_node > node_name > instance
+		// instance 通常是 IP:port 格式，优先级最低
+		if labelConfig.Node != "" && labelName == labelConfig.Node {
+			metric.Node = string(value)
+		} else if labelConfig.Node == "" {
+			switch labelName {
+			case "Hostname", "node", "hostname", "kubernetes_node", "node_name":
+				// 高优先级标签，直接覆盖
+				metric.Node = string(value)
+			case "instance":
+				// instance 优先级最低，只在没有其他值时使用
+				if metric.Node == "" {
+					metric.Node = string(value)
+				}
+			}
+		}
+
+		// Pod名
+		if labelConfig.Pod != "" && labelName == labelConfig.Pod {
+			metric.Pod = string(value)
+		} else if labelConfig.Pod == "" {
+			switch labelName {
+			case "pod", "exported_pod", "pod_name", "kubernetes_pod":
+				if metric.Pod == "" {
+					metric.Pod = string(value)
+				}
+			}
+		}
+
+		// Namespace
+		if labelConfig.Namespace != "" && labelName == labelConfig.Namespace {
+			metric.Namespace = string(value)
+		} else if labelConfig.Namespace == "" {
+			switch labelName {
+			case "namespace", "exported_namespace", "kubernetes_namespace":
+				if metric.Namespace == "" {
+					metric.Namespace = string(value)
+				}
+			}
+		}
+	}
+
+	// 处理 node 字段：移除端口号
+	if metric.Node != "" {
+		// 如果 node 包含端口号 (例如 "node-gpu-01:9400")，移除端口部分
+		for i, ch := range metric.Node {
+			if ch == ':' {
+				metric.Node = metric.Node[:i]
+				break
+			}
+		}
+	}
+}
+
 // QueryGPUUtilization 查询 GPU 利用率 (NVIDIA)
 func (c *Client) QueryGPUUtilization(ctx context.Context) ([]DeviceMetric, error) {
 	return c.queryMetric(ctx, "DCGM_FI_DEV_GPU_UTIL")
@@ -0,0 +1,213 @@
+package prometheus
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/prometheus/common/model"
+	"go.uber.org/zap"
+)
+
+// BusyUtilizationThreshold 利用率达到该值（%）的卡视为“在用”，低于该值的已分配卡视为闲置
+const BusyUtilizationThreshold = 5.0
+
+// NodeHistory 单个节点的利用率与分配曲线
+type NodeHistory struct {
+	Node        string        `json:"node"`
+	Utilization []SamplePoint `json:"utilization"` // 节点内各卡平均利用率 0-100
+	Allocated   []SamplePoint `json:"allocated"`   // 已分配卡数
+}
+
+// AcceleratorHistory 某类加速卡在一段时间内的利用率与分配曲线
+type AcceleratorHistory struct {
+	Type        string        `json:"type"`
+	Utilization []SamplePoint `json:"utilization"` // 所有上报指标的卡的平均利用率 0-100
+	Busy        []SamplePoint `json:"busy"`        // 利用率不低于 BusyUtilizationThreshold 的卡数
+	Allocated   []SamplePoint `json:"allocated"`   // 运行中 Pod 请求的卡数（来自 kube-state-metrics）
+	Nodes       []NodeHistory `json:"nodes"`       // 按节点名排序
+}
+
+// historyBucket 某一时刻若干卡的利用率累计
+type historyBucket struct {
+	sum   float64
+	count int
+	busy  int
+}
+
+// QueryAcceleratorHistory 查询某类加速卡的利用率与分配历史
+// 利用率按 step 窗口取 avg_over_time 后在本地按节点/设备聚合，复用 MetricLabels 的标签识别；
+// 分配数来自 kube-state-metrics，查询失败或无数据时返回空的分配曲线
+func (c *Client) QueryAcceleratorHistory(ctx context.Context, accType AcceleratorTypeConfig, start, end time.Time, step time.Duration) (*AcceleratorHistory, error) {
+	history := &AcceleratorHistory{
+		Type:        accType.Type,
+		Utilization: []SamplePoint{},
+		Busy:        []SamplePoint{},
+		Allocated:   []SamplePoint{},
+		Nodes:       []NodeHistory{},
+	}
+	nodes := make(map[string]*NodeHistory)
+	nodeFor := func(name string) *NodeHistory {
+		if node, ok := nodes[name]; ok {
+			return node
+		}
+		node := &NodeHistory{Node: name, Utilization: []SamplePoint{}, Allocated: []SamplePoint{}}
+		nodes[name] = node
+		return node
+	}
+
+	if accType.MetricName != "" {
+		matrix, err := c.QueryRange(ctx, fmt.Sprintf("avg_over_time(%s[%s])", accType.MetricName, model.Duration(step)), start, end, step)
+		if err != nil {
+			return nil, err
+		}
+		// 同一张卡可能因 Pod 标签变化出现多条序列，同一时刻取最大值
+		devices := make(map[string]map[int]map[int64]float64) // node -> device -> ts -> value
+		for _, stream := range matrix {
+			var metric DeviceMetric
+			applyMetricLabels(&metric, stream.Metric, accType.MetricLabels)
+			idx := ParseDeviceID(metric.DeviceID)
+			if metric.Node == "" || idx < 0 {
+				continue
+			}
+			if devices[metric.Node] == nil {
+				devices[metric.Node] = make(map[int]map[int64]float64)
+			}
+			if devices[metric.Node][idx] == nil {
+				devices[metric.Node][idx] = make(map[int64]float64)
+			}
+			values := devices[metric.Node][idx]
+			for _, pair := range stream.Values {
+				ts := int64(pair.Timestamp)
+				if current, ok := values[ts]; !ok || float64(pair.Value) > current {
+					values[ts] = float64(pair.Value)
+				}
+			}
+		}
+
+		total := make(map[int64]*historyBucket)
+		for nodeName, nodeDevices := range devices {
+			perNode := make(map[int64]*historyBucket)
+			for _, values := range nodeDevices {
+				for ts, value := range values {
+					addToBucket(perNode, ts, value)
+					addToBucket(total, ts, value)
+				}
+			}
+			nodeFor(nodeName).Utilization = bucketSeries(perNode, averageOfBucket)
+		}
+		history.Utilization = bucketSeries(total, averageOfBucket)
+		history.Busy = bucketSeries(total, func(b *historyBucket) float64 { return float64(b.busy) })
+	}
+
+	if accType.ResourceName != "" {
+		query := fmt.Sprintf(`sum by (node) (kube_pod_container_resource_requests{resource=%q} and on(namespace, pod) (kube_pod_status_phase{phase="Running"} == 1))`,
+			KubeStateResourceName(accType.ResourceName))
+		matrix, err := c.QueryRange(ctx, query, start, end, step)
+		if err != nil {
+			c.log.Warn("Failed to query accelerator allocation history",
+				zap.String("type", accType.Type),
+				zap.Error(err))
+		}
+		// 没有运行中 Pod 的时刻查询结果里没有点，按 0 补齐；
+		// 整个范围都没有结果时无法区分“未部署 kube-state-metrics”与“无人使用”，保留空曲线
+		total := zeroSeries(start, end, step)
+		for _, stream := range matrix {
+			nodeName := string(stream.Metric["node"])
+			if nodeName == "" {
+				continue
+			}
+			allocated := zeroSeries(start, end, step)
+			for _, pair := range stream.Values {
+				allocated[int64(pair.Timestamp)] = float64(pair.Value)
+				total[int64(pair.Timestamp)] += float64(pair.Value)
+			}
+			nodeFor(nodeName).Allocated = sortedSeries(allocated)
+		}
+		if len(matrix) > 0 {
+			history.Allocated = sortedSeries(total)
+		}
+	}
+
+	for _, node := range nodes {
+		history.Nodes = append(history.Nodes, *node)
+	}
+	sort.Slice(history.Nodes, func(i, j int) bool {
+		return history.Nodes[i].Node < history.Nodes[j].Node
+	})
+	return history, nil
+}
+
+// KubeStateResourceName kube-state-metrics 中的资源名（非字母数字字符替换为下划线），
+// 如 nvidia.com/gpu -> nvidia_com_gpu
+func KubeStateResourceName(resourceName string) string {
+	out := []byte(resourceName)
+	for i, ch := range out {
+		if !(ch >= 'a' && ch <= 'z' || ch >= 'A' && ch <= 'Z' || ch >= '0' && ch <= '9' || ch == '_') {
+			out[i] = '_'
+		}
+	}
+	return string(out)
+}
+
+// AverageValue 曲线的平均值，空曲线返回 0
+func AverageValue(points []SamplePoint) float64 {
+	if len(points) == 0 {
+		return 0
+	}
+	sum := 0.0
+	for _, point := range points {
+		sum += point.Value
+	}
+	return sum / float64(len(points))
+}
+
+func addToBucket(buckets map[int64]*historyBucket, ts int64, value float64) {
+	bucket, ok := buckets[ts]
+	if !ok {
+		bucket = &historyBucket{}
+		buckets[ts] = bucket
+	}
+	bucket.sum += value
+	bucket.count++
+	if value >= BusyUtilizationThreshold {
+		bucket.busy++
+	}
+}
+
+func averageOfBucket(b *historyBucket) float64 {
+	return b.sum / float64(b.count)
+}
+
+func bucketSeries(buckets map[int64]*historyBucket, valueFn func(*historyBucket) float64) []SamplePoint {
+	values := make(map[int64]float64, len(buckets))
+	for ts, bucket := range buckets {
+		values[ts] = valueFn(bucket)
+	}
+	return sortedSeries(values)
+}
+
+// zeroSeries 按范围查询的对齐方式（start + k*step）生成全 0 的点
+func zeroSeries(start, end time.Time, step time.Duration) map[int64]float64 {
+	values := make(map[int64]float64)
+	if step <= 0 {
+		return values
+	}
+	for t := start; !t.After(end); t = t.Add(step) {
+		values[int64(model.TimeFromUnixNano(t.UnixNano()))] = 0
+	}
+	return values
+}
+
+// sortedSeries 将毫秒时间戳 -> 值的映射转为按时间排序的曲线
+func sortedSeries(values map[int64]float64) []SamplePoint {
+	points := make([]SamplePoint, 0, len(values))
+	for ts, value := range values {
+		points = append(points, SamplePoint{Time: model.Time(ts).Time(), Value: value})
+	}
+	sort.Slice(points, func(i, j int) bool {
+		return points[i].Time.Before(points[j].Time)
+	})
+	return points
+}
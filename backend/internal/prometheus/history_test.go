@@ -0,0 +1,80 @@
+package prometheus
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestQueryAcceleratorHistoryAggregatesByNode(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = r.ParseForm()
+		query := r.Form.Get("query")
+		series := `[]`
+		switch {
+		case strings.HasPrefix(query, "avg_over_time(DCGM_FI_DEV_GPU_UTIL[1m])"):
+			// gpu 0 在 1773568860 时刻换了 Pod，出现两条序列
+			series = `[{"metric":{"gpu":"0","Hostname":"gpu-1","exported_pod":"a"},"values":[[1773568800,"90"],[1773568860,"10"]]},` +
+				`{"metric":{"gpu":"0","Hostname":"gpu-1","exported_pod":"b"},"values":[[1773568860,"60"]]},` +
+				`{"metric":{"gpu":"1","Hostname":"gpu-1"},"values":[[1773568800,"0"],[1773568860,"2"]]},` +
+				`{"metric":{"gpu":"0","Hostname":"gpu-2"},"values":[[1773568800,"30"]]}]`
+		case strings.Contains(query, `kube_pod_container_resource_requests{resource="nvidia_com_gpu"}`):
+			series = `[{"metric":{"node":"gpu-1"},"values":[[1773568860,"2"]]},` +
+				`{"metric":{"node":"gpu-2"},"values":[[1773568800,"1"],[1773568860,"1"]]}]`
+		default:
+			t.Errorf("unexpected query %s", query)
+		}
+		fmt.Fprintf(w, `{"status":"success","data":{"resultType":"matrix","result":%s}}`, series)
+	}))
+	defer server.Close()
+
+	client, err := NewClient(server.URL)
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	start := time.Unix(1773568800, 0)
+	history, err := client.QueryAcceleratorHistory(context.Background(), AcceleratorTypeConfig{
+		Type:         "nvidia",
+		ResourceName: "nvidia.com/gpu",
+		MetricName:   "DCGM_FI_DEV_GPU_UTIL",
+	}, start, start.Add(time.Minute), time.Minute)
+	if err != nil {
+		t.Fatalf("QueryAcceleratorHistory: %v", err)
+	}
+
+	values := func(points []SamplePoint) []float64 {
+		out := make([]float64, len(points))
+		for i, point := range points {
+			out[i] = point.Value
+		}
+		return out
+	}
+	if got := values(history.Utilization); fmt.Sprint(got) != "[40 31]" {
+		t.Fatalf("unexpected utilization %v", got)
+	}
+	if got := values(history.Busy); fmt.Sprint(got) != "[2 1]" {
+		t.Fatalf("unexpected busy %v", got)
+	}
+	if got := values(history.Allocated); fmt.Sprint(got) != "[1 3]" {
+		t.Fatalf("unexpected allocated %v", got)
+	}
+	if len(history.Nodes) != 2 || history.Nodes[0].Node != "gpu-1" {
+		t.Fatalf("unexpected nodes %+v", history.Nodes)
+	}
+	if got := values(history.Nodes[0].Allocated); fmt.Sprint(got) != "[0 2]" {
+		t.Fatalf("missing allocation points should be zero, got %v", got)
+	}
+	if got := values(history.Nodes[0].Utilization); fmt.Sprint(got) != "[45 31]" {
+		t.Fatalf("unexpected gpu-1 utilization %v", got)
+	}
+}
+
+func TestKubeStateResourceName(t *testing.T) {
+	if got := KubeStateResourceName("huawei.com/Ascend910"); got != "huawei_com_Ascend910" {
+		t.Fatalf("got %q", got)
+	}
+}
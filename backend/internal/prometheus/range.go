@@ -0,0 +1,204 @@
+package prometheus
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	v1 "github.com/prometheus/client_golang/api/prometheus/v1"
+	"github.com/prometheus/common/model"
+	"go.uber.org/zap"
+)
+
+// MaxRangePoints 范围查询每条序列的最大点数，step 据此按时间范围自动放大（降采样）
+const MaxRangePoints = 120
+
+const minRangeStep = 15 * time.Second
+
+// SamplePoint 时间序列上的一个点
+type SamplePoint struct {
+	Time  time.Time `json:"t"`
+	Value float64   `json:"v"`
+}
+
+// DeviceSeries 单张加速卡的利用率与显存曲线
+type DeviceSeries struct {
+	Type        string        `json:"type"`        // "nvidia" | "ascend"
+	DeviceID    string        `json:"deviceId"`    // 设备编号
+	Node        string        `json:"node"`        // 节点名
+	Utilization []SamplePoint `json:"utilization"` // 利用率 0-100
+	MemoryUsed  []SamplePoint `json:"memoryUsed"`  // 已用显存 (MiB)
+}
+
+// PodMetrics Pod 资源使用曲线
+type PodMetrics struct {
+	Start   time.Time      `json:"start"`
+	End     time.Time      `json:"end"`
+	Step    int64          `json:"step"`    // 采样间隔（秒）
+	CPU     []SamplePoint  `json:"cpu"`     // CPU 使用（核）
+	Memory  []SamplePoint  `json:"memory"`  // 内存工作集（字节）
+	Devices []DeviceSeries `json:"devices"` // 加速卡（按设备编号排序）
+}
+
+// RangeStep 根据时间范围计算降采样后的 step
+func RangeStep(window time.Duration) time.Duration {
+	step := window / MaxRangePoints
+	if step < minRangeStep {
+		return minRangeStep
+	}
+	return step.Truncate(time.Second)
+}
+
+// QueryRange 执行范围查询，返回原始矩阵
+func (c *Client) QueryRange(ctx context.Context, query string, start, end time.Time, step time.Duration) (model.Matrix, error) {
+	if !c.IsEnabled() {
+		return nil, fmt.Errorf("prometheus not configured")
+	}
+	result, warnings, err := c.api.QueryRange(ctx, query, v1.Range{Start: start, End: end, Step: step})
+	if err != nil {
+		return nil, fmt.Errorf("failed to query prometheus: %w", err)
+	}
+	if len(warnings) > 0 {
+		c.log.Warn("Prometheus query warnings", zap.Strings("warnings", warnings))
+	}
+	matrix, ok := result.(model.Matrix)
+	if !ok {
+		return nil, fmt.Errorf("unexpected result type: %T", result)
+	}
+	return matrix, nil
+}
+
+// QueryPodMetrics 查询 Pod 的 CPU/内存曲线及所用加速卡的利用率/显存曲线
+// 加速卡指标按 MetricLabelConfig 中的 Pod/Namespace 标签过滤；未配置时同时匹配 pod 与 exported_pod
+func (c *Client) QueryPodMetrics(ctx context.Context, namespace, pod string, acceleratorTypes []AcceleratorTypeConfig, start, end time.Time, step time.Duration) (*PodMetrics, error) {
+	result := &PodMetrics{
+		Start:   start,
+		End:     end,
+		Step:    int64(step / time.Second),
+		CPU:     []SamplePoint{},
+		Memory:  []SamplePoint{},
+		Devices: []DeviceSeries{},
+	}
+
+	containerSelector := fmt.Sprintf(`namespace=%q,pod=%q,container!="",container!="POD"`, namespace, pod)
+	rateWindow := step
+	if rateWindow < time.Minute {
+		rateWindow = time.Minute
+	}
+	cpu, err := c.QueryRange(ctx, fmt.Sprintf("sum(rate(container_cpu_usage_seconds_total{%s}[%s]))",
+		containerSelector, model.Duration(rateWindow)), start, end, step)
+	if err != nil {
+		return nil, err
+	}
+	result.CPU = firstSeries(cpu)
+
+	memory, err := c.QueryRange(ctx, fmt.Sprintf("sum(container_memory_working_set_bytes{%s})", containerSelector), start, end, step)
+	if err != nil {
+		return nil, err
+	}
+	result.Memory = firstSeries(memory)
+
+	for _, accType := range acceleratorTypes {
+		if accType.MetricName == "" {
+			continue
+		}
+		devices := make(map[string]*DeviceSeries)
+		utilization, err := c.QueryRange(ctx, podDeviceQuery(accType.MetricName, accType.MetricLabels, namespace, pod), start, end, step)
+		if err != nil {
+			c.log.Warn("Failed to query device utilization range",
+				zap.String("metric", accType.MetricName),
+				zap.Error(err))
+			continue
+		}
+		for _, stream := range utilization {
+			device := deviceSeriesFor(devices, accType, stream.Metric)
+			device.Utilization = samplePoints(stream.Values)
+		}
+
+		if memoryMetric := deviceMemoryUsedMetric(accType.Type); memoryMetric != "" {
+			memoryUsed, err := c.QueryRange(ctx, podDeviceQuery(memoryMetric, accType.MetricLabels, namespace, pod), start, end, step)
+			if err != nil {
+				c.log.Warn("Failed to query device memory range",
+					zap.String("metric", memoryMetric),
+					zap.Error(err))
+			}
+			for _, stream := range memoryUsed {
+				device := deviceSeriesFor(devices, accType, stream.Metric)
+				device.MemoryUsed = samplePoints(stream.Values)
+			}
+		}
+
+		for _, device := range devices {
+			result.Devices = append(result.Devices, *device)
+		}
+	}
+	sort.Slice(result.Devices, func(i, j int) bool {
+		a, b := result.Devices[i], result.Devices[j]
+		if a.Node != b.Node {
+			return a.Node < b.Node
+		}
+		return ParseDeviceID(a.DeviceID) < ParseDeviceID(b.DeviceID)
+	})
+	return result, nil
+}
+
+// podDeviceQuery 构造按 Pod 过滤的加速卡指标查询
+func podDeviceQuery(metricName string, labels MetricLabelConfig, namespace, pod string) string {
+	if labels.Pod != "" {
+		namespaceLabel := labels.Namespace
+		if namespaceLabel == "" {
+			namespaceLabel = "namespace"
+		}
+		return fmt.Sprintf("%s{%s=%q,%s=%q}", metricName, labels.Pod, pod, namespaceLabel, namespace)
+	}
+	// DCGM / NPU exporter 被 Prometheus 抓取时 pod 标签常被重命名为 exported_pod
+	return fmt.Sprintf("%s{pod=%q,namespace=%q} or %s{exported_pod=%q,exported_namespace=%q}",
+		metricName, pod, namespace, metricName, pod, namespace)
+}
+
+// deviceMemoryUsedMetric 显存已用指标名按加速卡类型内置，与 QueryAcceleratorMetrics 一致
+func deviceMemoryUsedMetric(acceleratorType string) string {
+	switch acceleratorType {
+	case "nvidia":
+		return "DCGM_FI_DEV_FB_USED"
+	case "ascend":
+		return "npu_chip_info_hbm_used_memory"
+	}
+	return ""
+}
+
+func deviceSeriesFor(devices map[string]*DeviceSeries, accType AcceleratorTypeConfig, labels model.Metric) *DeviceSeries {
+	var metric DeviceMetric
+	applyMetricLabels(&metric, labels, accType.MetricLabels)
+	key := metric.Node + "/" + strconv.Itoa(ParseDeviceID(metric.DeviceID))
+	if device, ok := devices[key]; ok {
+		return device
+	}
+	device := &DeviceSeries{
+		Type:        accType.Type,
+		DeviceID:    strings.TrimSpace(metric.DeviceID),
+		Node:        metric.Node,
+		Utilization: []SamplePoint{},
+		MemoryUsed:  []SamplePoint{},
+	}
+	devices[key] = device
+	return device
+}
+
+func firstSeries(matrix model.Matrix) []SamplePoint {
+	if len(matrix) == 0 {
+		return []SamplePoint{}
+	}
+	return samplePoints(matrix[0].Values)
+}
+
+func samplePoints(values []model.SamplePair) []SamplePoint {
+	points := make([]SamplePoint, 0, len(values))
+	for _, value := range values {
+		points = append(points, SamplePoint{Time: value.Timestamp.Time(), Value: float64(value.Value)})
+	}
+	return points
+}
@@ -0,0 +1,95 @@
+package prometheus
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRangeStepDownsamples(t *testing.T) {
+	if got := RangeStep(10 * time.Minute); got != minRangeStep {
+		t.Fatalf("expected minimum step, got %s", got)
+	}
+	if got := RangeStep(24 * time.Hour); got != 12*time.Minute {
+		t.Fatalf("expected 12m step for 1d, got %s", got)
+	}
+}
+
+func TestQueryPodMetricsBuildsSeries(t *testing.T) {
+	var mu sync.Mutex
+	var queries []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/query_range" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		_ = r.ParseForm()
+		query := r.Form.Get("query")
+		mu.Lock()
+		queries = append(queries, query)
+		mu.Unlock()
+
+		series := `[]`
+		switch {
+		case strings.Contains(query, "container_cpu_usage_seconds_total"):
+			series = `[{"metric":{},"values":[[1773568800,"1.5"],[1773568860,"2.25"]]}]`
+		case strings.Contains(query, "container_memory_working_set_bytes"):
+			series = `[{"metric":{},"values":[[1773568800,"1073741824"]]}]`
+		case strings.HasPrefix(query, "DCGM_FI_DEV_GPU_UTIL"):
+			series = `[{"metric":{"gpu":"1","Hostname":"gpu-node-1"},"values":[[1773568800,"80"]]},` +
+				`{"metric":{"gpu":"0","Hostname":"gpu-node-1"},"values":[[1773568800,"95"]]}]`
+		case strings.HasPrefix(query, "DCGM_FI_DEV_FB_USED"):
+			series = `[{"metric":{"gpu":"0","Hostname":"gpu-node-1"},"values":[[1773568800,"40960"]]}]`
+		}
+		fmt.Fprintf(w, `{"status":"success","data":{"resultType":"matrix","result":%s}}`, series)
+	}))
+	defer server.Close()
+
+	client, err := NewClient(server.URL)
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	end := time.Unix(1773568860, 0)
+	metrics, err := client.QueryPodMetrics(context.Background(), "user-alice", "pod-alice-dev",
+		[]AcceleratorTypeConfig{{Type: "nvidia", MetricName: "DCGM_FI_DEV_GPU_UTIL"}},
+		end.Add(-time.Hour), end, time.Minute)
+	if err != nil {
+		t.Fatalf("QueryPodMetrics: %v", err)
+	}
+
+	if len(metrics.CPU) != 2 || metrics.CPU[1].Value != 2.25 || metrics.Step != 60 {
+		t.Fatalf("unexpected cpu series %+v", metrics)
+	}
+	if len(metrics.Memory) != 1 || metrics.Memory[0].Value != 1<<30 {
+		t.Fatalf("unexpected memory series %+v", metrics.Memory)
+	}
+	if len(metrics.Devices) != 2 || metrics.Devices[0].DeviceID != "0" || metrics.Devices[1].DeviceID != "1" {
+		t.Fatalf("unexpected devices %+v", metrics.Devices)
+	}
+	if len(metrics.Devices[0].MemoryUsed) != 1 || len(metrics.Devices[1].MemoryUsed) != 0 {
+		t.Fatalf("device memory not merged by id: %+v", metrics.Devices)
+	}
+
+	wantSelector := `DCGM_FI_DEV_GPU_UTIL{pod="pod-alice-dev",namespace="user-alice"} or DCGM_FI_DEV_GPU_UTIL{exported_pod="pod-alice-dev",exported_namespace="user-alice"}`
+	found := false
+	for _, query := range queries {
+		if query == wantSelector {
+			found = true
+		}
+	}
+	if !found {
+		t.Fatalf("device query not scoped to pod: %v", queries)
+	}
+}
+
+func TestPodDeviceQueryUsesConfiguredLabels(t *testing.T) {
+	got := podDeviceQuery("npu_chip_info_utilization", MetricLabelConfig{Pod: "pod_name", Namespace: "pod_namespace"}, "user-alice", "pod-alice-dev")
+	want := `npu_chip_info_utilization{pod_name="pod-alice-dev",pod_namespace="user-alice"}`
+	if got != want {
+		t.Fatalf("got %s, want %s", got, want)
+	}
+}
@@ -3,6 +3,8 @@ package registry
 import (
 	"context"
 	"fmt"
+	"strings"
+	"sync"
 
 	"github.com/uc-package/genet/internal/models"
 )
@@ -12,6 +14,7 @@ type ImageInfo struct {
 	Name        string   `json:"name"`                  // 镜像名称（不含 registry 前缀）
 	Tags        []string `json:"tags,omitempty"`        // 可用 tags
 	Description string   `json:"description,omitempty"` // 描述信息（Harbor 支持）
+	Registry    string   `json:"registry,omitempty"`    // 镜像所在仓库的 host，多仓库搜索时用于拼接完整镜像名
 }
 
 // Client Registry 客户端接口
@@ -20,10 +23,100 @@ type Client interface {
 	SearchImages(ctx context.Context, keyword string, limit int) ([]ImageInfo, error)
 	// GetImageTags 获取镜像的 tags，platform 为空时返回所有 tags
 	GetImageTags(ctx context.Context, imageName string, platform string) ([]string, error)
+	// GetManifest 获取 tag 或 digest 对应镜像的 digest、压缩大小、平台、labels 与创建时间；不存在时返回 ErrNotFound
+	GetManifest(ctx context.Context, repository, reference string) (*models.ImageManifest, error)
+	// DeleteTag 删除 tag 指向的镜像，同一 digest 的其它 tag 会一并删除；镜像不存在时返回 nil
+	DeleteTag(ctx context.Context, repository, tag string) error
+	// DeleteManifest 按 digest 删除镜像；镜像不存在时返回 nil
+	DeleteManifest(ctx context.Context, repository, digest string) error
 	// IsConfigured 检查 registry 是否已配置
 	IsConfigured() bool
 }
 
+// manifestAcceptHeader 获取 manifest 时接受的媒体类型（单架构 manifest、manifest list 与 OCI index）
+const manifestAcceptHeader = "application/vnd.docker.distribution.manifest.v2+json, " +
+	"application/vnd.docker.distribution.manifest.list.v2+json, " +
+	"application/vnd.oci.image.manifest.v1+json, " +
+	"application/vnd.oci.image.index.v1+json"
+
+// ParseImageReference 将完整镜像名拆分为仓库内的 repository 与 reference（tag 或 digest）。
+// 镜像不属于 registryURL 时 ok 为 false；未带 tag 时 reference 为 latest。
+func ParseImageReference(image, registryURL string) (repository, reference string, ok bool) {
+	host := strings.TrimSuffix(registryURL, "/")
+	host = strings.TrimPrefix(strings.TrimPrefix(host, "https://"), "http://")
+	if host == "" || !strings.HasPrefix(image, host+"/") {
+		return "", "", false
+	}
+	name := strings.TrimPrefix(image, host+"/")
+	if i := strings.Index(name, "@"); i >= 0 {
+		return name[:i], name[i+1:], name[:i] != ""
+	}
+	reference = "latest"
+	if i := strings.LastIndex(name, ":"); i > strings.LastIndex(name, "/") {
+		name, reference = name[:i], name[i+1:]
+	}
+	return name, reference, name != "" && reference != ""
+}
+
+// platformFilterConcurrency 按 platform 过滤 tag 时并发读取 manifest 的数量
+const platformFilterConcurrency = 5
+
+// filterTagsByPlatform 逐个读取 tag 的 manifest，保留包含指定架构（如 amd64）的 tag；
+// 读取失败或无法判断架构的 tag 保留，与 Harbor 的处理一致
+func filterTagsByPlatform(ctx context.Context, client Client, repository string, tags []string, platform string) []string {
+	if platform == "" || len(tags) == 0 {
+		return tags
+	}
+	keep := make([]bool, len(tags))
+	sem := make(chan struct{}, platformFilterConcurrency)
+	var wg sync.WaitGroup
+	for i, tag := range tags {
+		wg.Add(1)
+		go func(i int, tag string) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			manifest, err := client.GetManifest(ctx, repository, tag)
+			keep[i] = err != nil || matchesPlatform(manifest.Platforms, platform)
+		}(i, tag)
+	}
+	wg.Wait()
+
+	filtered := make([]string, 0, len(tags))
+	for i, tag := range tags {
+		if keep[i] {
+			filtered = append(filtered, tag)
+		}
+	}
+	return filtered
+}
+
+// matchesPlatform platforms 为 os/arch[/variant]，platform 可以是架构或完整平台
+func matchesPlatform(platforms []string, platform string) bool {
+	if len(platforms) == 0 {
+		return true
+	}
+	for _, p := range platforms {
+		parts := strings.Split(p, "/")
+		if p == platform || (len(parts) > 1 && parts[1] == platform) {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeBaseURL 去掉结尾的 /，未带协议时按 insecure 补全 http:// 或 https://
+func normalizeBaseURL(raw string, insecure bool) string {
+	baseURL := strings.TrimSuffix(raw, "/")
+	if baseURL == "" || strings.HasPrefix(baseURL, "http://") || strings.HasPrefix(baseURL, "https://") {
+		return baseURL
+	}
+	if insecure {
+		return "http://" + baseURL
+	}
+	return "https://" + baseURL
+}
+
 // NewClient 根据配置创建 Registry 客户端
 func NewClient(config *models.RegistryConfig) (Client, error) {
 	if config == nil || config.URL == "" {
@@ -38,8 +131,16 @@ func NewClient(config *models.RegistryConfig) (Client, error) {
 	switch registryType {
 	case "harbor":
 		return NewHarborClient(config), nil
+	case "gitlab":
+		return NewGitLabClient(config), nil
+	case "nexus":
+		return NewNexusClient(config), nil
 	case "docker":
 		return NewDockerClient(config), nil
+	case "ghcr", "acr", "ecr":
+		// GHCR、ECR 不提供 /v2/_catalog，ECR 的密码是 12 小时过期的临时 token，
+		// 通用 V2 客户端无法可靠地搜索和鉴权，不再作为别名接受
+		return nil, fmt.Errorf("unsupported registry type: %s (no /v2/_catalog or expiring credentials)", registryType)
 	default:
 		return nil, fmt.Errorf("unsupported registry type: %s", registryType)
 	}
@@ -56,6 +157,18 @@ func (c *noopClient) GetImageTags(ctx context.Context, imageName string, platfor
 	return []string{}, nil
 }
 
+func (c *noopClient) GetManifest(ctx context.Context, repository, reference string) (*models.ImageManifest, error) {
+	return nil, fmt.Errorf("registry not configured")
+}
+
+func (c *noopClient) DeleteTag(ctx context.Context, repository, tag string) error {
+	return fmt.Errorf("registry not configured")
+}
+
+func (c *noopClient) DeleteManifest(ctx context.Context, repository, digest string) error {
+	return fmt.Errorf("registry not configured")
+}
+
 func (c *noopClient) IsConfigured() bool {
 	return false
 }
@@ -0,0 +1,187 @@
+package registry
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrInvalidCredentials 仓库拒绝了用户名密码
+	ErrInvalidCredentials = errors.New("invalid registry credentials")
+	// ErrBlockedAddress 仓库或 token 服务解析到内网、回环或链路本地地址
+	ErrBlockedAddress = errors.New("registry address is not allowed")
+)
+
+const (
+	// credentialCheckTimeout 校验凭据的超时时间（包含换取 token）
+	credentialCheckTimeout = 15 * time.Second
+	// credentialCheckMaxBody 读取（丢弃）响应体的上限
+	credentialCheckMaxBody = 64 << 10
+)
+
+// CheckCredentials 用用户名密码访问仓库的 /v2/ 校验凭据，host 为镜像名前缀（如 ghcr.io、docker.io）。
+// Basic 认证的仓库直接返回 200；Bearer 认证的仓库按挑战向 token 服务换取 token，换取成功即视为凭据有效。
+// host 由用户提供，仓库与 token 服务的地址在 DNS 解析后不允许落在内网、回环或链路本地网段（trustedHosts 中管理员已配置的仓库除外），
+// 不跟随重定向，错误中不包含上游响应内容。凭据被拒绝时返回 ErrInvalidCredentials
+func CheckCredentials(ctx context.Context, host, username, password string, insecure bool, trustedHosts ...string) error {
+	if host == "docker.io" {
+		// Docker Hub 的镜像名前缀与 V2 API 地址不同
+		host = "registry-1.docker.io"
+	}
+	guard := &dialGuard{trusted: make(map[string]bool)}
+	for _, trusted := range trustedHosts {
+		guard.trusted[hostnameOf(trusted)] = true
+	}
+	httpClient := &http.Client{
+		Transport: &http.Transport{
+			DialContext:     guard.DialContext,
+			TLSClientConfig: &tls.Config{InsecureSkipVerify: insecure},
+		},
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+		Timeout: credentialCheckTimeout,
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, normalizeBaseURL(host, insecure)+"/v2/", nil)
+	if err != nil {
+		return fmt.Errorf("create request failed: %w", err)
+	}
+	req.SetBasicAuth(username, password)
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return wrapDialError("connect registry failed", err)
+	}
+	drainBody(resp)
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return nil
+	case http.StatusUnauthorized:
+	case http.StatusForbidden:
+		return ErrInvalidCredentials
+	default:
+		return fmt.Errorf("registry returned status %d", resp.StatusCode)
+	}
+
+	scheme, params := parseChallenge(resp.Header.Get("WWW-Authenticate"))
+	if scheme != "bearer" || params["realm"] == "" {
+		// Basic 认证的仓库带着凭据仍然 401
+		return ErrInvalidCredentials
+	}
+	return checkTokenCredentials(ctx, httpClient, params, username, password)
+}
+
+// checkTokenCredentials 按 docker login 的方式（不带 scope，带 account）向 token 服务认证
+func checkTokenCredentials(ctx context.Context, httpClient *http.Client, params map[string]string, username, password string) error {
+	realm, err := url.Parse(params["realm"])
+	if err != nil || (realm.Scheme != "https" && realm.Scheme != "http") || realm.Host == "" {
+		return fmt.Errorf("invalid token realm")
+	}
+	query := realm.Query()
+	if service := params["service"]; service != "" {
+		query.Set("service", service)
+	}
+	query.Set("account", username)
+	realm.RawQuery = query.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, realm.String(), nil)
+	if err != nil {
+		return fmt.Errorf("create token request failed: %w", err)
+	}
+	req.SetBasicAuth(username, password)
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return wrapDialError("token request failed", err)
+	}
+	drainBody(resp)
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return nil
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return ErrInvalidCredentials
+	default:
+		return fmt.Errorf("token request failed with status %d", resp.StatusCode)
+	}
+}
+
+// dialGuard 解析目标地址并拒绝内网、回环、链路本地等地址，直接连接校验过的 IP，避免 DNS rebinding
+type dialGuard struct {
+	trusted map[string]bool
+	dialer  net.Dialer
+}
+
+func (g *dialGuard) DialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	hostname, port, err := net.SplitHostPort(address)
+	if err != nil {
+		return nil, err
+	}
+	if g.trusted[strings.ToLower(hostname)] {
+		return g.dialer.DialContext(ctx, network, address)
+	}
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, hostname)
+	if err != nil {
+		return nil, err
+	}
+	if len(addrs) == 0 {
+		return nil, fmt.Errorf("no address for %s", hostname)
+	}
+	for _, addr := range addrs {
+		if isBlockedIP(addr.IP) {
+			return nil, ErrBlockedAddress
+		}
+	}
+	return g.dialer.DialContext(ctx, network, net.JoinHostPort(addrs[0].IP.String(), port))
+}
+
+// isBlockedIP 内网、回环、链路本地（含云厂商元数据地址 169.254.169.254）、组播和未指定地址
+func isBlockedIP(ip net.IP) bool {
+	return ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() || ip.IsUnspecified() || isSharedAddress(ip)
+}
+
+// isSharedAddress 100.64.0.0/10 运营商级 NAT 地址，常被用作集群内网段
+func isSharedAddress(ip net.IP) bool {
+	ip4 := ip.To4()
+	return ip4 != nil && ip4[0] == 100 && ip4[1]&0xc0 == 64
+}
+
+func hostnameOf(host string) string {
+	host = strings.ToLower(strings.TrimSpace(host))
+	if u, err := url.Parse(host); err == nil && u.Host != "" {
+		host = u.Host
+	}
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		return h
+	}
+	return host
+}
+
+// wrapDialError 地址被拒绝时返回 ErrBlockedAddress，其它错误不带上游细节
+func wrapDialError(msg string, err error) error {
+	if errors.Is(err, ErrBlockedAddress) {
+		return ErrBlockedAddress
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return fmt.Errorf("%s: timeout", msg)
+	}
+	return fmt.Errorf("%s: connection error", msg)
+}
+
+func drainBody(resp *http.Response) {
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, credentialCheckMaxBody))
+	resp.Body.Close()
+}
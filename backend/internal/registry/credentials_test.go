@@ -0,0 +1,126 @@
+package registry
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// 测试服务监听在回环地址上，需显式信任
+const testTrustedHost = "127.0.0.1"
+
+func TestCheckCredentialsBasicAuth(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, pass, _ := r.BasicAuth(); r.URL.Path != "/v2/" || user != "alice" || pass != "secret" {
+			w.Header().Set("WWW-Authenticate", `Basic realm="registry"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	if err := CheckCredentials(context.Background(), server.URL, "alice", "secret", false, testTrustedHost); err != nil {
+		t.Fatalf("expected valid credentials, got %v", err)
+	}
+	if err := CheckCredentials(context.Background(), server.URL, "alice", "wrong", false, testTrustedHost); !errors.Is(err, ErrInvalidCredentials) {
+		t.Fatalf("expected ErrInvalidCredentials, got %v", err)
+	}
+}
+
+func TestCheckCredentialsBearerToken(t *testing.T) {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/token":
+			user, pass, _ := r.BasicAuth()
+			if user != "alice" || pass != "token" || r.URL.Query().Get("service") != "test" || r.URL.Query().Get("account") != "alice" {
+				http.Error(w, "unauthorized", http.StatusUnauthorized)
+				return
+			}
+			_, _ = w.Write([]byte(`{"token":"t-1"}`))
+		case "/v2/":
+			w.Header().Set("WWW-Authenticate", `Bearer realm="`+server.URL+`/token",service="test"`)
+			w.WriteHeader(http.StatusUnauthorized)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	if err := CheckCredentials(context.Background(), server.URL, "alice", "token", false, testTrustedHost); err != nil {
+		t.Fatalf("expected valid credentials, got %v", err)
+	}
+	if err := CheckCredentials(context.Background(), server.URL, "alice", "wrong", false, testTrustedHost); !errors.Is(err, ErrInvalidCredentials) {
+		t.Fatalf("expected ErrInvalidCredentials, got %v", err)
+	}
+}
+
+func TestCheckCredentialsDoesNotEchoUpstreamBody(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "internal-secret-body", http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	err := CheckCredentials(context.Background(), server.URL, "alice", "secret", false, testTrustedHost)
+	if err == nil || errors.Is(err, ErrInvalidCredentials) {
+		t.Fatalf("expected non-credential error for a non-registry endpoint, got %v", err)
+	}
+	if strings.Contains(err.Error(), "internal-secret-body") {
+		t.Fatalf("error must not contain the upstream body: %v", err)
+	}
+}
+
+func TestCheckCredentialsBlocksInternalTargets(t *testing.T) {
+	hits := 0
+	internal := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits++
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer internal.Close()
+
+	// 未信任的回环地址
+	if err := CheckCredentials(context.Background(), internal.URL, "alice", "secret", false); !errors.Is(err, ErrBlockedAddress) {
+		t.Fatalf("expected ErrBlockedAddress for loopback target, got %v", err)
+	}
+
+	// 受信任的仓库把 token realm 或重定向指向内网地址
+	_, port, _ := net.SplitHostPort(strings.TrimPrefix(internal.URL, "http://"))
+	internalURL := "http://localhost:" + port + "/v2/"
+	challenging := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="`+internalURL+`"`)
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer challenging.Close()
+	redirecting := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, internalURL, http.StatusFound)
+	}))
+	defer redirecting.Close()
+
+	if err := CheckCredentials(context.Background(), challenging.URL, "alice", "secret", false, testTrustedHost); !errors.Is(err, ErrBlockedAddress) {
+		t.Fatalf("expected ErrBlockedAddress for internal realm, got %v", err)
+	}
+	if err := CheckCredentials(context.Background(), redirecting.URL, "alice", "secret", false, testTrustedHost); err == nil {
+		t.Fatal("expected redirect not to be followed")
+	}
+	if hits != 0 {
+		t.Fatalf("internal service must not be contacted, got %d requests", hits)
+	}
+}
+
+func TestIsBlockedIP(t *testing.T) {
+	for _, raw := range []string{"127.0.0.1", "10.1.2.3", "172.16.0.1", "192.168.1.1", "169.254.169.254", "100.64.0.1", "::1", "fe80::1", "fd00::1", "0.0.0.0"} {
+		if !isBlockedIP(net.ParseIP(raw)) {
+			t.Errorf("expected %s to be blocked", raw)
+		}
+	}
+	for _, raw := range []string{"140.82.112.34", "2606:4700::1"} {
+		if isBlockedIP(net.ParseIP(raw)) {
+			t.Errorf("expected %s to be allowed", raw)
+		}
+	}
+}
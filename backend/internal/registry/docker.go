@@ -21,19 +21,8 @@ type DockerClient struct {
 	httpClient *http.Client
 }
 
-// NewDockerClient 创建 Docker Registry 客户端
+// NewDockerClient 创建 Docker Registry 客户端，同时支持 Basic 与 Bearer token 认证
 func NewDockerClient(config *models.RegistryConfig) *DockerClient {
-	baseURL := strings.TrimSuffix(config.URL, "/")
-
-	// 确保 URL 包含协议
-	if !strings.HasPrefix(baseURL, "http://") && !strings.HasPrefix(baseURL, "https://") {
-		if config.Insecure {
-			baseURL = "http://" + baseURL
-		} else {
-			baseURL = "https://" + baseURL
-		}
-	}
-
 	transport := &http.Transport{
 		TLSClientConfig: &tls.Config{
 			InsecureSkipVerify: config.Insecure,
@@ -41,11 +30,12 @@ func NewDockerClient(config *models.RegistryConfig) *DockerClient {
 	}
 
 	return &DockerClient{
-		baseURL:  baseURL,
+		baseURL:  normalizeBaseURL(config.URL, config.Insecure),
 		username: config.Username,
 		password: config.Password,
 		httpClient: &http.Client{
-			Transport: transport,
+			// 支持 Bearer token 认证的 registry（Docker Hub、GHCR、ACR、GitLab 等）
+			Transport: newTokenTransport(transport, config.Username, config.Password),
 			Timeout:   30 * time.Second,
 		},
 	}
@@ -115,7 +105,7 @@ func (c *DockerClient) SearchImages(ctx context.Context, keyword string, limit i
 	return images, nil
 }
 
-// GetImageTags 获取镜像的 tags（Docker Registry V2 不支持 platform 过滤，忽略 platform 参数）
+// GetImageTags 获取镜像的 tags；V2 API 没有 platform 过滤，指定 platform 时逐个读取 manifest 过滤
 func (c *DockerClient) GetImageTags(ctx context.Context, imageName string, platform string) ([]string, error) {
 	if imageName == "" {
 		return []string{}, nil
@@ -149,5 +139,174 @@ func (c *DockerClient) GetImageTags(ctx context.Context, imageName string, platf
 		return nil, fmt.Errorf("decode response failed: %w", err)
 	}
 
-	return tags.Tags, nil
+	return filterTagsByPlatform(ctx, c, imageName, tags.Tags, platform), nil
+}
+
+// DeleteTag 删除 tag 指向的 manifest。Registry V2 不支持单独删除 tag，
+// 需要 registry 开启 REGISTRY_STORAGE_DELETE_ENABLED，磁盘空间在 registry garbage-collect 后释放
+func (c *DockerClient) DeleteTag(ctx context.Context, repository, tag string) error {
+	manifestURL := fmt.Sprintf("%s/v2/%s/manifests/%s", c.baseURL, repository, tag)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, manifestURL, nil)
+	if err != nil {
+		return fmt.Errorf("create request failed: %w", err)
+	}
+	if c.username != "" && c.password != "" {
+		req.SetBasicAuth(c.username, c.password)
+	}
+	req.Header.Set("Accept", manifestAcceptHeader)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("manifest request failed: %w", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("get manifest failed with status %d", resp.StatusCode)
+	}
+	digest := resp.Header.Get("Docker-Content-Digest")
+	if digest == "" {
+		return fmt.Errorf("registry did not return Docker-Content-Digest for %s:%s", repository, tag)
+	}
+	return c.DeleteManifest(ctx, repository, digest)
+}
+
+// DeleteManifest 按 digest 删除 manifest
+func (c *DockerClient) DeleteManifest(ctx context.Context, repository, digest string) error {
+	manifestURL := fmt.Sprintf("%s/v2/%s/manifests/%s", c.baseURL, repository, digest)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, manifestURL, nil)
+	if err != nil {
+		return fmt.Errorf("create request failed: %w", err)
+	}
+	if c.username != "" && c.password != "" {
+		req.SetBasicAuth(c.username, c.password)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("delete request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusAccepted, http.StatusOK, http.StatusNotFound:
+		return nil
+	case http.StatusMethodNotAllowed:
+		return fmt.Errorf("registry does not allow deletes (enable REGISTRY_STORAGE_DELETE_ENABLED)")
+	default:
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("delete manifest failed with status %d: %s", resp.StatusCode, string(body))
+	}
+}
+
+// GetManifest 获取镜像元数据；多架构镜像会逐个读取各平台的 manifest 汇总大小，
+// labels 与创建时间取自第一个平台的镜像 config
+func (c *DockerClient) GetManifest(ctx context.Context, repository, reference string) (*models.ImageManifest, error) {
+	data, header, err := c.fetch(ctx, fmt.Sprintf("/v2/%s/manifests/%s", repository, reference), manifestAcceptHeader)
+	if err != nil {
+		return nil, err
+	}
+	manifest, err := decodeManifest(data)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &models.ImageManifest{
+		Digest:    header.Get("Docker-Content-Digest"),
+		MediaType: manifest.MediaType,
+	}
+	if result.MediaType == "" {
+		result.MediaType = header.Get("Content-Type")
+	}
+	if result.Digest == "" {
+		result.Digest = digestOf(data)
+	}
+
+	if !manifest.isIndex(header.Get("Content-Type")) {
+		result.Size = manifest.compressedSize()
+		config, err := c.getConfig(ctx, repository, manifest.Config.Digest)
+		if err != nil {
+			return nil, err
+		}
+		applyImageConfig(result, config, true)
+		return result, nil
+	}
+
+	for _, child := range manifest.Manifests {
+		if isAttestation(child) {
+			continue
+		}
+		childData, _, err := c.fetch(ctx, fmt.Sprintf("/v2/%s/manifests/%s", repository, child.Digest), manifestAcceptHeader)
+		if err != nil {
+			return nil, err
+		}
+		childManifest, err := decodeManifest(childData)
+		if err != nil {
+			return nil, err
+		}
+		result.Size += childManifest.compressedSize()
+		if child.Platform != nil {
+			result.Platforms = append(result.Platforms, child.Platform.String())
+		}
+		if result.Created == nil {
+			config, err := c.getConfig(ctx, repository, childManifest.Config.Digest)
+			if err != nil {
+				return nil, err
+			}
+			applyImageConfig(result, config, child.Platform == nil)
+		}
+	}
+	return result, nil
+}
+
+func (c *DockerClient) getConfig(ctx context.Context, repository, digest string) (*imageConfig, error) {
+	if digest == "" {
+		return &imageConfig{}, nil
+	}
+	data, _, err := c.fetch(ctx, fmt.Sprintf("/v2/%s/blobs/%s", repository, digest), "")
+	if err != nil {
+		return nil, err
+	}
+	var config imageConfig
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("decode image config failed: %w", err)
+	}
+	return &config, nil
+}
+
+// fetch 以 GET 读取 registry 路径，blob 请求会跟随重定向到存储后端
+func (c *DockerClient) fetch(ctx context.Context, path, accept string) ([]byte, http.Header, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+path, nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("create request failed: %w", err)
+	}
+	if c.username != "" && c.password != "" {
+		req.SetBasicAuth(c.username, c.password)
+	}
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, nil, fmt.Errorf("read response failed: %w", err)
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, nil, fmt.Errorf("%w: %s", ErrNotFound, path)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, nil, fmt.Errorf("request %s failed with status %d: %s", path, resp.StatusCode, string(body))
+	}
+	return body, resp.Header, nil
 }
@@ -0,0 +1,90 @@
+package registry
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/uc-package/genet/internal/models"
+)
+
+func TestDockerClientGetManifestSinglePlatform(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v2/alice/train/manifests/v1":
+			w.Header().Set("Content-Type", "application/vnd.docker.distribution.manifest.v2+json")
+			w.Header().Set("Docker-Content-Digest", "sha256:aaa")
+			_, _ = w.Write([]byte(`{"mediaType":"application/vnd.docker.distribution.manifest.v2+json",
+				"config":{"digest":"sha256:cfg","size":100},
+				"layers":[{"digest":"sha256:l1","size":1000},{"digest":"sha256:l2","size":2000}]}`))
+		case "/v2/alice/train/blobs/sha256:cfg":
+			_, _ = w.Write([]byte(`{"architecture":"amd64","os":"linux","created":"2026-09-01T10:00:00Z",
+				"config":{"Labels":{"maintainer":"alice"}}}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	client := NewDockerClient(&models.RegistryConfig{URL: server.URL})
+	manifest, err := client.GetManifest(context.Background(), "alice/train", "v1")
+	if err != nil {
+		t.Fatalf("GetManifest: %v", err)
+	}
+	if manifest.Digest != "sha256:aaa" || manifest.Size != 3100 {
+		t.Fatalf("unexpected digest/size: %+v", manifest)
+	}
+	if len(manifest.Platforms) != 1 || manifest.Platforms[0] != "linux/amd64" {
+		t.Fatalf("unexpected platforms: %v", manifest.Platforms)
+	}
+	if manifest.Labels["maintainer"] != "alice" || manifest.Created == nil || manifest.Created.Day() != 1 {
+		t.Fatalf("unexpected labels/created: %+v", manifest)
+	}
+}
+
+func TestDockerClientGetManifestIndexSkipsAttestations(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v2/library/base/manifests/latest":
+			w.Header().Set("Content-Type", "application/vnd.oci.image.index.v1+json")
+			_, _ = w.Write([]byte(`{"mediaType":"application/vnd.oci.image.index.v1+json","manifests":[
+				{"digest":"sha256:amd","platform":{"os":"linux","architecture":"amd64"}},
+				{"digest":"sha256:arm","platform":{"os":"linux","architecture":"arm64","variant":"v8"}},
+				{"digest":"sha256:att","platform":{"os":"unknown","architecture":"unknown"}}]}`))
+		case "/v2/library/base/manifests/sha256:amd":
+			_, _ = w.Write([]byte(`{"config":{"digest":"sha256:cfg-amd","size":10},"layers":[{"size":90}]}`))
+		case "/v2/library/base/manifests/sha256:arm":
+			_, _ = w.Write([]byte(`{"config":{"digest":"sha256:cfg-arm","size":20},"layers":[{"size":180}]}`))
+		case "/v2/library/base/blobs/sha256:cfg-amd":
+			_, _ = w.Write([]byte(`{"architecture":"amd64","os":"linux","created":"2026-08-01T00:00:00Z"}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	client := NewDockerClient(&models.RegistryConfig{URL: server.URL})
+	manifest, err := client.GetManifest(context.Background(), "library/base", "latest")
+	if err != nil {
+		t.Fatalf("GetManifest: %v", err)
+	}
+	if manifest.Size != 300 || manifest.Digest == "" || manifest.Created == nil {
+		t.Fatalf("unexpected manifest: %+v", manifest)
+	}
+	if len(manifest.Platforms) != 2 || manifest.Platforms[0] != "linux/amd64" || manifest.Platforms[1] != "linux/arm64/v8" {
+		t.Fatalf("unexpected platforms: %v", manifest.Platforms)
+	}
+}
+
+func TestNewClientRejectsHostedRegistryAliases(t *testing.T) {
+	for _, registryType := range []string{"ghcr", "acr", "ecr"} {
+		if _, err := NewClient(&models.RegistryConfig{URL: "registry.example.com", Type: registryType}); err == nil {
+			t.Fatalf("expected type %s to be rejected", registryType)
+		}
+	}
+	client, err := NewClient(&models.RegistryConfig{URL: "registry.example.com", Type: "docker"})
+	if err != nil || !client.IsConfigured() {
+		t.Fatalf("expected docker type to be accepted, err=%v", err)
+	}
+}
@@ -0,0 +1,133 @@
+package registry
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/uc-package/genet/internal/models"
+)
+
+// gitlabMaxProjects 搜索时最多展开的项目数，每个项目需要一次 registry 仓库列表请求
+const gitlabMaxProjects = 20
+
+// GitLabClient GitLab Container Registry 客户端。
+// 镜像搜索通过 GitLab REST API 按项目列出 registry 仓库，tag、manifest 与删除走 registry 的 V2 API（JWT token 认证）
+type GitLabClient struct {
+	*DockerClient
+	apiURL string
+	token  string
+}
+
+// NewGitLabClient 创建 GitLab 客户端；password 作为 Personal / Project Access Token 同时用于 REST API 和 registry 认证
+func NewGitLabClient(config *models.RegistryConfig) *GitLabClient {
+	apiURL := config.APIURL
+	if apiURL == "" {
+		apiURL = config.URL
+	}
+	return &GitLabClient{
+		DockerClient: NewDockerClient(config),
+		apiURL:       normalizeBaseURL(apiURL, config.Insecure),
+		token:        config.Password,
+	}
+}
+
+// gitlabProject GitLab 项目（simple=true）
+type gitlabProject struct {
+	ID                int    `json:"id"`
+	PathWithNamespace string `json:"path_with_namespace"`
+	Description       string `json:"description"`
+}
+
+// gitlabRepository GitLab registry 仓库
+type gitlabRepository struct {
+	ID   int    `json:"id"`
+	Path string `json:"path"` // 如 group/project/image，即仓库内的镜像名
+}
+
+// SearchImages 按关键字搜索项目，返回匹配项目下的 registry 仓库；关键字为空时列出当前用户所属的项目
+func (c *GitLabClient) SearchImages(ctx context.Context, keyword string, limit int) ([]ImageInfo, error) {
+	query := url.Values{}
+	query.Set("simple", "true")
+	query.Set("order_by", "last_activity_at")
+	query.Set("per_page", strconv.Itoa(gitlabMaxProjects))
+	if keyword != "" {
+		query.Set("search", keyword)
+		query.Set("search_namespaces", "true")
+	} else {
+		query.Set("membership", "true")
+	}
+
+	var projects []gitlabProject
+	if err := c.getJSON(ctx, "/api/v4/projects?"+query.Encode(), &projects); err != nil {
+		return nil, fmt.Errorf("search projects failed: %w", err)
+	}
+
+	images := make([]ImageInfo, 0)
+	for _, project := range projects {
+		var repositories []gitlabRepository
+		err := c.getJSON(ctx, fmt.Sprintf("/api/v4/projects/%d/registry/repositories?per_page=100", project.ID), &repositories)
+		if err != nil {
+			// 未启用 container registry 或无权限的项目跳过
+			if isGitLabForbidden(err) {
+				continue
+			}
+			return nil, fmt.Errorf("list registry repositories of %s failed: %w", project.PathWithNamespace, err)
+		}
+		for _, repo := range repositories {
+			images = append(images, ImageInfo{
+				Name:        repo.Path,
+				Description: project.Description,
+			})
+			if limit > 0 && len(images) >= limit {
+				return images, nil
+			}
+		}
+	}
+	return images, nil
+}
+
+// gitlabStatusError GitLab API 的非 200 响应
+type gitlabStatusError struct {
+	status int
+	body   string
+}
+
+func (e *gitlabStatusError) Error() string {
+	return fmt.Sprintf("gitlab api failed with status %d: %s", e.status, e.body)
+}
+
+func isGitLabForbidden(err error) bool {
+	statusErr, ok := err.(*gitlabStatusError)
+	return ok && (statusErr.status == http.StatusForbidden || statusErr.status == http.StatusNotFound)
+}
+
+func (c *GitLabClient) getJSON(ctx context.Context, path string, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.apiURL+path, nil)
+	if err != nil {
+		return fmt.Errorf("create request failed: %w", err)
+	}
+	if c.token != "" {
+		req.Header.Set("PRIVATE-TOKEN", c.token)
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return &gitlabStatusError{status: resp.StatusCode, body: string(body)}
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("decode response failed: %w", err)
+	}
+	return nil
+}
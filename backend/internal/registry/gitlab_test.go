@@ -0,0 +1,82 @@
+package registry
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/uc-package/genet/internal/models"
+)
+
+func TestGitLabClientSearchImagesListsProjectRepositories(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("PRIVATE-TOKEN") != "glpat" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.Path {
+		case "/api/v4/projects":
+			if r.URL.Query().Get("search") != "train" {
+				http.Error(w, "bad search", http.StatusBadRequest)
+				return
+			}
+			_, _ = w.Write([]byte(`[{"id":1,"path_with_namespace":"ml/train","description":"training"},
+				{"id":2,"path_with_namespace":"ml/train-docs"}]`))
+		case "/api/v4/projects/1/registry/repositories":
+			_, _ = w.Write([]byte(`[{"id":10,"path":"ml/train"},{"id":11,"path":"ml/train/cuda"}]`))
+		case "/api/v4/projects/2/registry/repositories":
+			http.Error(w, "registry disabled", http.StatusForbidden)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	client, err := NewClient(&models.RegistryConfig{URL: "registry.invalid", APIURL: server.URL, Password: "glpat", Type: "gitlab"})
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	images, err := client.SearchImages(context.Background(), "train", 10)
+	if err != nil {
+		t.Fatalf("SearchImages: %v", err)
+	}
+	if len(images) != 2 || images[0].Name != "ml/train" || images[1].Name != "ml/train/cuda" || images[0].Description != "training" {
+		t.Fatalf("unexpected images: %+v", images)
+	}
+}
+
+func TestGitLabClientGetImageTagsFiltersPlatform(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v2/ml/train/tags/list":
+			_, _ = w.Write([]byte(`{"name":"ml/train","tags":["amd","multi","arm"]}`))
+		case "/v2/ml/train/manifests/amd":
+			_, _ = w.Write([]byte(`{"config":{"digest":"sha256:cfg-amd"},"layers":[]}`))
+		case "/v2/ml/train/manifests/arm":
+			_, _ = w.Write([]byte(`{"config":{"digest":"sha256:cfg-arm"},"layers":[]}`))
+		case "/v2/ml/train/manifests/multi":
+			w.Header().Set("Content-Type", "application/vnd.oci.image.index.v1+json")
+			_, _ = w.Write([]byte(`{"mediaType":"application/vnd.oci.image.index.v1+json","manifests":[
+				{"digest":"sha256:m-amd","platform":{"os":"linux","architecture":"amd64"}}]}`))
+		case "/v2/ml/train/manifests/sha256:m-amd":
+			_, _ = w.Write([]byte(`{"config":{"digest":"sha256:cfg-amd"},"layers":[]}`))
+		case "/v2/ml/train/blobs/sha256:cfg-amd":
+			_, _ = w.Write([]byte(`{"architecture":"amd64","os":"linux"}`))
+		case "/v2/ml/train/blobs/sha256:cfg-arm":
+			_, _ = w.Write([]byte(`{"architecture":"arm64","os":"linux"}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	client := NewGitLabClient(&models.RegistryConfig{URL: server.URL, Type: "gitlab"})
+	tags, err := client.GetImageTags(context.Background(), "ml/train", "amd64")
+	if err != nil {
+		t.Fatalf("GetImageTags: %v", err)
+	}
+	if len(tags) != 2 || tags[0] != "amd" || tags[1] != "multi" {
+		t.Fatalf("unexpected tags: %v", tags)
+	}
+}
@@ -224,3 +224,243 @@ func (c *HarborClient) GetImageTags(ctx context.Context, imageName string, platf
 
 	return tags, nil
 }
+
+// harborRepositoryPath Harbor API 要求 repository 中的 / 做两次 URL 编码
+func harborRepositoryPath(repository string) (project, repo string, err error) {
+	parts := strings.SplitN(repository, "/", 2)
+	if len(parts) != 2 {
+		return "", "", fmt.Errorf("invalid image name format, expected: project/repository")
+	}
+	return parts[0], url.PathEscape(url.PathEscape(parts[1])), nil
+}
+
+// DeleteTag 只删除 tag 本身，artifact 及其它 tag 保留
+func (c *HarborClient) DeleteTag(ctx context.Context, repository, tag string) error {
+	project, repo, err := harborRepositoryPath(repository)
+	if err != nil {
+		return err
+	}
+	return c.delete(ctx, fmt.Sprintf("%s/api/v2.0/projects/%s/repositories/%s/artifacts/%s/tags/%s",
+		c.baseURL, project, repo, url.PathEscape(tag), url.PathEscape(tag)), "delete tag")
+}
+
+// DeleteManifest 按 digest 删除 artifact（连同指向它的所有 tag），磁盘空间在 Harbor GC 后释放
+func (c *HarborClient) DeleteManifest(ctx context.Context, repository, digest string) error {
+	project, repo, err := harborRepositoryPath(repository)
+	if err != nil {
+		return err
+	}
+	return c.delete(ctx, fmt.Sprintf("%s/api/v2.0/projects/%s/repositories/%s/artifacts/%s",
+		c.baseURL, project, repo, url.PathEscape(digest)), "delete artifact")
+}
+
+func (c *HarborClient) delete(ctx context.Context, targetURL, action string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, targetURL, nil)
+	if err != nil {
+		return fmt.Errorf("create request failed: %w", err)
+	}
+	if c.username != "" && c.password != "" {
+		req.SetBasicAuth(c.username, c.password)
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("delete request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusOK || resp.StatusCode == http.StatusNotFound {
+		return nil
+	}
+	body, _ := io.ReadAll(resp.Body)
+	return fmt.Errorf("%s failed with status %d: %s", action, resp.StatusCode, string(body))
+}
+
+// harborArtifactDetail 单个 artifact 的元数据
+type harborArtifactDetail struct {
+	Digest            string    `json:"digest"`
+	ManifestMediaType string    `json:"manifest_media_type"`
+	Size              int64     `json:"size"`
+	PushTime          time.Time `json:"push_time"`
+	ExtraAttrs        struct {
+		Architecture string     `json:"architecture"`
+		OS           string     `json:"os"`
+		Variant      string     `json:"variant"`
+		Created      *time.Time `json:"created"`
+		Config       struct {
+			Labels map[string]string `json:"Labels"`
+		} `json:"config"`
+	} `json:"extra_attrs"`
+	References []struct {
+		ChildDigest string   `json:"child_digest"`
+		Platform    platform `json:"platform"`
+	} `json:"references"`
+	// ScanOverview 按报告 MIME 类型索引的扫描摘要，未扫描时为空
+	ScanOverview map[string]harborScanOverview `json:"scan_overview"`
+}
+
+// harborVulnerabilityReportTypes 请求 scan_overview 时接受的报告类型
+const harborVulnerabilityReportTypes = "application/vnd.security.vulnerability.report; version=1.1, " +
+	"application/vnd.scanner.adapter.vuln.report.harbor+json; version=1.0"
+
+// harborScanOverview Harbor 漏洞扫描摘要
+type harborScanOverview struct {
+	ScanStatus string     `json:"scan_status"`
+	Severity   string     `json:"severity"`
+	EndTime    *time.Time `json:"end_time"`
+	Summary    *struct {
+		Total   int            `json:"total"`
+		Fixable int            `json:"fixable"`
+		Summary map[string]int `json:"summary"`
+	} `json:"summary"`
+}
+
+// vulnerabilities 转换扫描摘要；未扫描时返回 nil
+func (a *harborArtifactDetail) vulnerabilities() *models.VulnerabilitySummary {
+	for _, overview := range a.ScanOverview {
+		summary := &models.VulnerabilitySummary{
+			ScanStatus: overview.ScanStatus,
+			Severity:   overview.Severity,
+			Counts:     map[string]int{},
+			ScannedAt:  overview.EndTime,
+		}
+		if overview.Summary != nil {
+			summary.Total = overview.Summary.Total
+			summary.Fixable = overview.Summary.Fixable
+			for severity, count := range overview.Summary.Summary {
+				summary.Counts[severity] = count
+			}
+		}
+		return summary
+	}
+	return nil
+}
+
+// mergeVulnerabilities 汇总多架构镜像各平台的扫描结果：计数相加，取最高严重级别，任一平台未完成则整体未完成
+func mergeVulnerabilities(dst, src *models.VulnerabilitySummary) *models.VulnerabilitySummary {
+	if src == nil {
+		src = &models.VulnerabilitySummary{ScanStatus: "NotScanned"}
+	}
+	if dst == nil {
+		merged := *src
+		merged.Counts = make(map[string]int, len(src.Counts))
+		for severity, count := range src.Counts {
+			merged.Counts[severity] = count
+		}
+		return &merged
+	}
+	if dst.ScanStatus == "Success" && src.ScanStatus != "Success" {
+		dst.ScanStatus = src.ScanStatus
+	}
+	if models.SeverityAtLeast(src.Severity, models.SeverityUnknown) && !models.SeverityAtLeast(dst.Severity, src.Severity) {
+		dst.Severity = src.Severity
+	}
+	for severity, count := range src.Counts {
+		dst.Counts[severity] += count
+	}
+	dst.Total += src.Total
+	dst.Fixable += src.Fixable
+	if src.ScannedAt != nil && (dst.ScannedAt == nil || src.ScannedAt.After(*dst.ScannedAt)) {
+		dst.ScannedAt = src.ScannedAt
+	}
+	return dst
+}
+
+// GetManifest 通过 Harbor artifact API 获取镜像元数据与漏洞扫描摘要；多架构镜像的大小由 Harbor 汇总各平台，
+// labels 与创建时间取自第一个平台的 artifact，index 本身没有扫描结果时汇总各平台的结果
+func (c *HarborClient) GetManifest(ctx context.Context, repository, reference string) (*models.ImageManifest, error) {
+	artifact, err := c.getArtifact(ctx, repository, reference)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &models.ImageManifest{
+		Digest:          artifact.Digest,
+		MediaType:       artifact.ManifestMediaType,
+		Size:            artifact.Size,
+		Vulnerabilities: artifact.vulnerabilities(),
+	}
+	source := artifact
+	if len(artifact.References) == 0 {
+		if artifact.ExtraAttrs.OS != "" && artifact.ExtraAttrs.Architecture != "" {
+			p := platform{OS: artifact.ExtraAttrs.OS, Architecture: artifact.ExtraAttrs.Architecture, Variant: artifact.ExtraAttrs.Variant}
+			result.Platforms = append(result.Platforms, p.String())
+		}
+	} else {
+		source = nil
+		aggregateScan := result.Vulnerabilities == nil
+		for _, ref := range artifact.References {
+			if ref.Platform.OS == "unknown" && ref.Platform.Architecture == "unknown" {
+				continue
+			}
+			result.Platforms = append(result.Platforms, ref.Platform.String())
+			if source != nil && !aggregateScan {
+				continue
+			}
+			child, err := c.getArtifact(ctx, repository, ref.ChildDigest)
+			if err != nil {
+				return nil, err
+			}
+			if source == nil {
+				source = child
+			}
+			if aggregateScan {
+				result.Vulnerabilities = mergeVulnerabilities(result.Vulnerabilities, child.vulnerabilities())
+			}
+		}
+	}
+	if source != nil {
+		if len(source.ExtraAttrs.Config.Labels) > 0 {
+			result.Labels = source.ExtraAttrs.Config.Labels
+		}
+		result.Created = source.ExtraAttrs.Created
+	}
+	if result.Created == nil && !artifact.PushTime.IsZero() {
+		pushTime := artifact.PushTime
+		result.Created = &pushTime
+	}
+	if result.Vulnerabilities == nil {
+		result.Vulnerabilities = &models.VulnerabilitySummary{ScanStatus: "NotScanned"}
+	}
+	return result, nil
+}
+
+func (c *HarborClient) getArtifact(ctx context.Context, repository, reference string) (*harborArtifactDetail, error) {
+	project, repo, err := harborRepositoryPath(repository)
+	if err != nil {
+		return nil, err
+	}
+	artifactURL := fmt.Sprintf("%s/api/v2.0/projects/%s/repositories/%s/artifacts/%s?with_scan_overview=true",
+		c.baseURL, project, repo, url.PathEscape(reference))
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, artifactURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("create request failed: %w", err)
+	}
+	if c.username != "" && c.password != "" {
+		req.SetBasicAuth(c.username, c.password)
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("X-Accept-Vulnerabilities", harborVulnerabilityReportTypes)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("artifact request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("%w: %s:%s", ErrNotFound, repository, reference)
+	}
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("get artifact failed with status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var artifact harborArtifactDetail
+	if err := json.NewDecoder(resp.Body).Decode(&artifact); err != nil {
+		return nil, fmt.Errorf("decode response failed: %w", err)
+	}
+	return &artifact, nil
+}
@@ -0,0 +1,89 @@
+package registry
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/uc-package/genet/internal/models"
+)
+
+func TestHarborClientGetManifestUsesArtifactAPI(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.EscapedPath() != "/api/v2.0/projects/team/repositories/ml%252Ftrain/artifacts/v2" {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte(`{"digest":"sha256:bbb","manifest_media_type":"application/vnd.oci.image.manifest.v1+json",
+			"size":4096,"push_time":"2026-09-02T00:00:00Z",
+			"extra_attrs":{"architecture":"arm64","os":"linux","config":{"Labels":{"team":"ml"}}}}`))
+	}))
+	defer server.Close()
+
+	client := NewHarborClient(&models.RegistryConfig{URL: server.URL, Type: "harbor"})
+	manifest, err := client.GetManifest(context.Background(), "team/ml/train", "v2")
+	if err != nil {
+		t.Fatalf("GetManifest: %v", err)
+	}
+	if manifest.Digest != "sha256:bbb" || manifest.Size != 4096 || manifest.Labels["team"] != "ml" {
+		t.Fatalf("unexpected manifest: %+v", manifest)
+	}
+	if len(manifest.Platforms) != 1 || manifest.Platforms[0] != "linux/arm64" || manifest.Created == nil {
+		t.Fatalf("unexpected platforms/created: %+v", manifest)
+	}
+}
+
+func TestHarborClientGetManifestIncludesScanOverview(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("with_scan_overview") != "true" || r.Header.Get("X-Accept-Vulnerabilities") == "" {
+			http.Error(w, "scan overview not requested", http.StatusBadRequest)
+			return
+		}
+		_, _ = w.Write([]byte(`{"digest":"sha256:ccc","size":10,
+			"scan_overview":{"application/vnd.security.vulnerability.report; version=1.1":{
+				"scan_status":"Success","severity":"High","end_time":"2026-09-03T00:00:00Z",
+				"summary":{"total":5,"fixable":2,"summary":{"High":2,"Medium":3}}}}}`))
+	}))
+	defer server.Close()
+
+	client := NewHarborClient(&models.RegistryConfig{URL: server.URL, Type: "harbor"})
+	manifest, err := client.GetManifest(context.Background(), "team/train", "v1")
+	if err != nil {
+		t.Fatalf("GetManifest: %v", err)
+	}
+	vulns := manifest.Vulnerabilities
+	if vulns == nil || vulns.ScanStatus != "Success" || vulns.Severity != "High" || vulns.Total != 5 || vulns.Fixable != 2 {
+		t.Fatalf("unexpected vulnerabilities: %+v", vulns)
+	}
+	if vulns.CountAtLeast(models.SeverityHigh) != 2 || vulns.CountAtLeast(models.SeverityCritical) != 0 {
+		t.Fatalf("unexpected severity counts: %+v", vulns.Counts)
+	}
+}
+
+func TestHarborClientDeleteTagKeepsArtifact(t *testing.T) {
+	var deleted []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodDelete {
+			http.Error(w, "unexpected method", http.StatusMethodNotAllowed)
+			return
+		}
+		deleted = append(deleted, r.URL.EscapedPath())
+	}))
+	defer server.Close()
+
+	client := NewHarborClient(&models.RegistryConfig{URL: server.URL, Type: "harbor"})
+	if err := client.DeleteTag(context.Background(), "team/train", "v1"); err != nil {
+		t.Fatalf("DeleteTag: %v", err)
+	}
+	if err := client.DeleteManifest(context.Background(), "team/train", "sha256:aaa"); err != nil {
+		t.Fatalf("DeleteManifest: %v", err)
+	}
+	want := []string{
+		"/api/v2.0/projects/team/repositories/train/artifacts/v1/tags/v1",
+		"/api/v2.0/projects/team/repositories/train/artifacts/sha256:aaa",
+	}
+	if len(deleted) != 2 || deleted[0] != want[0] || deleted[1] != want[1] {
+		t.Fatalf("unexpected delete requests %v", deleted)
+	}
+}
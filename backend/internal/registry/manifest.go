@@ -0,0 +1,113 @@
+package registry
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/uc-package/genet/internal/models"
+)
+
+// ErrNotFound 镜像或 manifest 不存在
+var ErrNotFound = errors.New("not found in registry")
+
+// 多架构镜像的媒体类型
+const (
+	mediaTypeDockerManifestList = "application/vnd.docker.distribution.manifest.list.v2+json"
+	mediaTypeOCIIndex           = "application/vnd.oci.image.index.v1+json"
+)
+
+// descriptor manifest 中引用的内容
+type descriptor struct {
+	MediaType string    `json:"mediaType"`
+	Digest    string    `json:"digest"`
+	Size      int64     `json:"size"`
+	Platform  *platform `json:"platform,omitempty"`
+}
+
+type platform struct {
+	Architecture string `json:"architecture"`
+	OS           string `json:"os"`
+	Variant      string `json:"variant,omitempty"`
+}
+
+// String 返回 os/architecture[/variant]
+func (p platform) String() string {
+	if p.Variant != "" {
+		return p.OS + "/" + p.Architecture + "/" + p.Variant
+	}
+	return p.OS + "/" + p.Architecture
+}
+
+// imageManifest Docker v2 schema 2 manifest、manifest list 与 OCI manifest/index 的公共字段
+type imageManifest struct {
+	MediaType string       `json:"mediaType"`
+	Config    descriptor   `json:"config"`
+	Layers    []descriptor `json:"layers"`
+	Manifests []descriptor `json:"manifests"`
+}
+
+func (m *imageManifest) isIndex(contentType string) bool {
+	switch m.MediaType {
+	case mediaTypeDockerManifestList, mediaTypeOCIIndex:
+		return true
+	}
+	if contentType == mediaTypeDockerManifestList || contentType == mediaTypeOCIIndex {
+		return true
+	}
+	return m.MediaType == "" && len(m.Manifests) > 0
+}
+
+// compressedSize config 与各层压缩后大小之和
+func (m *imageManifest) compressedSize() int64 {
+	size := m.Config.Size
+	for _, layer := range m.Layers {
+		size += layer.Size
+	}
+	return size
+}
+
+// imageConfig 镜像 config blob 中需要的字段
+type imageConfig struct {
+	Architecture string     `json:"architecture"`
+	OS           string     `json:"os"`
+	Variant      string     `json:"variant,omitempty"`
+	Created      *time.Time `json:"created,omitempty"`
+	Config       struct {
+		Labels map[string]string `json:"Labels"`
+	} `json:"config"`
+}
+
+func decodeManifest(data []byte) (*imageManifest, error) {
+	var manifest imageManifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("decode manifest failed: %w", err)
+	}
+	return &manifest, nil
+}
+
+// isAttestation buildx 在 index 中附带的 provenance/SBOM 条目，平台为 unknown/unknown
+func isAttestation(d descriptor) bool {
+	return d.Platform != nil && d.Platform.OS == "unknown" && d.Platform.Architecture == "unknown"
+}
+
+// applyImageConfig 写入 labels 与创建时间；withPlatform 为 true 时同时记录 config 中的平台（单架构镜像）
+func applyImageConfig(result *models.ImageManifest, config *imageConfig, withPlatform bool) {
+	if len(config.Config.Labels) > 0 {
+		result.Labels = config.Config.Labels
+	}
+	result.Created = config.Created
+	if withPlatform && config.OS != "" && config.Architecture != "" {
+		p := platform{OS: config.OS, Architecture: config.Architecture, Variant: config.Variant}
+		result.Platforms = append(result.Platforms, p.String())
+	}
+}
+
+// digestOf registry 未返回 Docker-Content-Digest 时按内容计算 digest
+func digestOf(data []byte) string {
+	sum := sha256.Sum256(data)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,112 @@
+package registry
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+
+	"github.com/uc-package/genet/internal/models"
+)
+
+// nexusMaxSearchPages 搜索时最多翻页数，Nexus 每页 50 个组件（一个 tag 一个组件）
+const nexusMaxSearchPages = 10
+
+// NexusClient Sonatype Nexus Repository 客户端。
+// url 指向 docker 仓库的 connector（端口或子域名），镜像搜索走 Nexus REST API，tag、manifest 与删除走 V2 API
+type NexusClient struct {
+	*DockerClient
+	apiURL     string
+	repository string
+}
+
+// NewNexusClient 创建 Nexus 客户端
+func NewNexusClient(config *models.RegistryConfig) *NexusClient {
+	apiURL := config.APIURL
+	if apiURL == "" {
+		apiURL = config.URL
+	}
+	return &NexusClient{
+		DockerClient: NewDockerClient(config),
+		apiURL:       normalizeBaseURL(apiURL, config.Insecure),
+		repository:   config.Repository,
+	}
+}
+
+// nexusSearchResponse Nexus search API 响应，docker 组件的 version 即 tag
+type nexusSearchResponse struct {
+	Items []struct {
+		Name       string `json:"name"`
+		Version    string `json:"version"`
+		Repository string `json:"repository"`
+	} `json:"items"`
+	ContinuationToken string `json:"continuationToken"`
+}
+
+// SearchImages 通过 /service/rest/v1/search 搜索 docker 组件，按镜像名合并 tag
+func (c *NexusClient) SearchImages(ctx context.Context, keyword string, limit int) ([]ImageInfo, error) {
+	query := url.Values{}
+	query.Set("format", "docker")
+	if c.repository != "" {
+		query.Set("repository", c.repository)
+	}
+	if keyword != "" {
+		query.Set("q", keyword)
+	}
+
+	images := make([]ImageInfo, 0)
+	index := make(map[string]int)
+	for page := 0; page < nexusMaxSearchPages; page++ {
+		var result nexusSearchResponse
+		if err := c.search(ctx, query, &result); err != nil {
+			return nil, err
+		}
+		for _, item := range result.Items {
+			i, ok := index[item.Name]
+			if !ok {
+				if limit > 0 && len(images) >= limit {
+					continue
+				}
+				i = len(images)
+				index[item.Name] = i
+				images = append(images, ImageInfo{Name: item.Name})
+			}
+			if item.Version != "" {
+				images[i].Tags = append(images[i].Tags, item.Version)
+			}
+		}
+		if result.ContinuationToken == "" || (limit > 0 && len(images) >= limit) {
+			break
+		}
+		query.Set("continuationToken", result.ContinuationToken)
+	}
+	return images, nil
+}
+
+func (c *NexusClient) search(ctx context.Context, query url.Values, out *nexusSearchResponse) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.apiURL+"/service/rest/v1/search?"+query.Encode(), nil)
+	if err != nil {
+		return fmt.Errorf("create request failed: %w", err)
+	}
+	if c.username != "" && c.password != "" {
+		req.SetBasicAuth(c.username, c.password)
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("search request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("search failed with status %d: %s", resp.StatusCode, string(body))
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("decode response failed: %w", err)
+	}
+	return nil
+}
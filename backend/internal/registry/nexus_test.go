@@ -0,0 +1,68 @@
+package registry
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/uc-package/genet/internal/models"
+)
+
+func TestNexusClientSearchImagesMergesTagsAcrossPages(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		if r.URL.Path != "/service/rest/v1/search" || query.Get("format") != "docker" ||
+			query.Get("repository") != "docker-hosted" || query.Get("q") != "train" {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		if user, pass, _ := r.BasicAuth(); user != "admin" || pass != "pw" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		if query.Get("continuationToken") == "" {
+			_, _ = w.Write([]byte(`{"items":[{"name":"ml/train","version":"v1"},{"name":"ml/train","version":"v2"}],
+				"continuationToken":"next"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"items":[{"name":"ml/train-cuda","version":"v1"}],"continuationToken":null}`))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(&models.RegistryConfig{
+		URL:        "docker.nexus.invalid",
+		APIURL:     server.URL,
+		Username:   "admin",
+		Password:   "pw",
+		Repository: "docker-hosted",
+		Type:       "nexus",
+	})
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	images, err := client.SearchImages(context.Background(), "train", 10)
+	if err != nil {
+		t.Fatalf("SearchImages: %v", err)
+	}
+	if len(images) != 2 || images[0].Name != "ml/train" || len(images[0].Tags) != 2 || images[1].Name != "ml/train-cuda" {
+		t.Fatalf("unexpected images: %+v", images)
+	}
+}
+
+func TestNexusClientGetImageTagsUsesV2API(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v2/ml/train/tags/list" {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte(`{"name":"ml/train","tags":["v1","v2"]}`))
+	}))
+	defer server.Close()
+
+	client := NewNexusClient(&models.RegistryConfig{URL: server.URL, APIURL: "http://nexus.invalid", Type: "nexus"})
+	tags, err := client.GetImageTags(context.Background(), "ml/train", "")
+	if err != nil || len(tags) != 2 {
+		t.Fatalf("unexpected tags %v, err %v", tags, err)
+	}
+}
@@ -0,0 +1,103 @@
+package registry
+
+import (
+	"fmt"
+
+	"github.com/uc-package/genet/internal/models"
+)
+
+// Entry 一个已配置的仓库及其客户端
+type Entry struct {
+	Config models.RegistryConfig
+	Client Client
+}
+
+// Set 按 registry 与 registries 配置创建的多个仓库客户端
+type Set struct {
+	entries []Entry
+}
+
+// NewSet 为所有已配置的仓库创建客户端；未配置任何仓库时返回空 Set
+func NewSet(config *models.Config) (*Set, error) {
+	set := &Set{}
+	for _, registryConfig := range config.AllRegistries() {
+		registryConfig := registryConfig
+		client, err := NewClient(&registryConfig)
+		if err != nil {
+			return nil, fmt.Errorf("registry %s: %w", registryConfig.DisplayName(), err)
+		}
+		set.Add(registryConfig, client)
+	}
+	return set, nil
+}
+
+// Add 添加仓库（测试中用于注入假客户端）
+func (s *Set) Add(config models.RegistryConfig, client Client) {
+	s.entries = append(s.entries, Entry{Config: config, Client: client})
+}
+
+// IsConfigured 是否至少配置了一个仓库
+func (s *Set) IsConfigured() bool {
+	if s == nil {
+		return false
+	}
+	for _, entry := range s.entries {
+		if entry.Client.IsConfigured() {
+			return true
+		}
+	}
+	return false
+}
+
+// ForPurpose 返回指定用途的仓库
+func (s *Set) ForPurpose(purpose string) []Entry {
+	if s == nil {
+		return nil
+	}
+	var entries []Entry
+	for _, entry := range s.entries {
+		if entry.Config.HasPurpose(purpose) {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
+
+// Lookup 按名称或 host 查找仓库
+func (s *Set) Lookup(nameOrHost string) (Entry, bool) {
+	if s == nil {
+		return Entry{}, false
+	}
+	for _, entry := range s.entries {
+		if entry.Config.Name == nameOrHost || entry.Config.Host() == nameOrHost {
+			return entry, true
+		}
+	}
+	return Entry{}, false
+}
+
+// Resolve 找到镜像所属的仓库（host 最长前缀匹配）并拆分出 repository 与 reference；不属于任何仓库时 ok 为 false
+func (s *Set) Resolve(image string) (client Client, repository, reference string, ok bool) {
+	entry, repository, reference, ok := s.ResolveEntry(image)
+	return entry.Client, repository, reference, ok
+}
+
+// ResolveEntry 与 Resolve 相同，但返回匹配到的仓库配置，便于调用方检查用途
+func (s *Set) ResolveEntry(image string) (entry Entry, repository, reference string, ok bool) {
+	if s == nil {
+		return Entry{}, "", "", false
+	}
+	matchedHost := ""
+	for _, candidate := range s.entries {
+		host := candidate.Config.Host()
+		if len(host) <= len(matchedHost) {
+			continue
+		}
+		repo, ref, matched := ParseImageReference(image, host)
+		if !matched {
+			continue
+		}
+		entry, repository, reference, ok, matchedHost = candidate, repo, ref, true, host
+	}
+	return entry, repository, reference, ok
+}
@@ -0,0 +1,224 @@
+package registry
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// defaultTokenTTL token 服务未返回 expires_in 时的有效期（Docker token 规范默认 60 秒）
+	defaultTokenTTL = 60 * time.Second
+	// tokenExpiryMargin 提前刷新 token，避免请求途中过期
+	tokenExpiryMargin = 10 * time.Second
+)
+
+// tokenTransport 处理 Registry V2 的 Bearer token 认证：
+// 收到 401 且 WWW-Authenticate 为 Bearer 挑战时，用配置的用户名密码向 realm 换取 token 并重试，
+// token 按 host、仓库和读写类型缓存。Basic 认证的 registry 不受影响。
+type tokenTransport struct {
+	base     http.RoundTripper
+	username string
+	password string
+
+	mu     sync.Mutex
+	tokens map[string]cachedToken
+	nowFn  func() time.Time
+}
+
+type cachedToken struct {
+	token     string
+	expiresAt time.Time
+}
+
+func newTokenTransport(base http.RoundTripper, username, password string) *tokenTransport {
+	return &tokenTransport{
+		base:     base,
+		username: username,
+		password: password,
+		tokens:   make(map[string]cachedToken),
+		nowFn:    time.Now,
+	}
+}
+
+// RoundTrip 实现 http.RoundTripper
+func (t *tokenTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	key := tokenCacheKey(req)
+	if token, ok := t.cached(key); ok {
+		req = withBearerToken(req, token)
+	}
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil || resp.StatusCode != http.StatusUnauthorized {
+		return resp, err
+	}
+	scheme, params := parseChallenge(resp.Header.Get("WWW-Authenticate"))
+	if scheme != "bearer" || params["realm"] == "" {
+		return resp, nil
+	}
+	if req.Body != nil && req.GetBody == nil {
+		// 请求体无法重放，直接返回 401
+		return resp, nil
+	}
+	resp.Body.Close()
+
+	token, ttl, err := t.fetchToken(req, params)
+	if err != nil {
+		return nil, err
+	}
+	t.store(key, token, ttl)
+
+	retry := withBearerToken(req, token)
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		retry.Body = body
+	}
+	return t.base.RoundTrip(retry)
+}
+
+// fetchToken 按挑战参数向 token 服务申请 token
+func (t *tokenTransport) fetchToken(req *http.Request, params map[string]string) (string, time.Duration, error) {
+	realm, err := url.Parse(params["realm"])
+	if err != nil {
+		return "", 0, fmt.Errorf("invalid token realm %q: %w", params["realm"], err)
+	}
+	query := realm.Query()
+	if service := params["service"]; service != "" {
+		query.Set("service", service)
+	}
+	for _, scope := range strings.Fields(params["scope"]) {
+		query.Add("scope", scope)
+	}
+	realm.RawQuery = query.Encode()
+
+	tokenReq, err := http.NewRequestWithContext(req.Context(), http.MethodGet, realm.String(), nil)
+	if err != nil {
+		return "", 0, fmt.Errorf("create token request failed: %w", err)
+	}
+	if t.username != "" && t.password != "" {
+		tokenReq.SetBasicAuth(t.username, t.password)
+	}
+	tokenReq.Header.Set("Accept", "application/json")
+
+	resp, err := t.base.RoundTrip(tokenReq)
+	if err != nil {
+		return "", 0, fmt.Errorf("token request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return "", 0, fmt.Errorf("token request failed with status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var result struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", 0, fmt.Errorf("decode token response failed: %w", err)
+	}
+	token := result.Token
+	if token == "" {
+		token = result.AccessToken
+	}
+	if token == "" {
+		return "", 0, fmt.Errorf("token service returned empty token")
+	}
+	ttl := defaultTokenTTL
+	if result.ExpiresIn > 0 {
+		ttl = time.Duration(result.ExpiresIn) * time.Second
+	}
+	return token, ttl, nil
+}
+
+func (t *tokenTransport) cached(key string) (string, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	entry, ok := t.tokens[key]
+	if !ok || !t.nowFn().Before(entry.expiresAt) {
+		delete(t.tokens, key)
+		return "", false
+	}
+	return entry.token, true
+}
+
+func (t *tokenTransport) store(key, token string, ttl time.Duration) {
+	if ttl > tokenExpiryMargin {
+		ttl -= tokenExpiryMargin
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.tokens[key] = cachedToken{token: token, expiresAt: t.nowFn().Add(ttl)}
+}
+
+// tokenCacheKey 按 host、仓库（/v2/<仓库>/manifests|blobs|tags/...）和读写类型区分 token 的作用域
+func tokenCacheKey(req *http.Request) string {
+	access := "pull"
+	if req.Method != http.MethodGet && req.Method != http.MethodHead {
+		access = "push"
+	}
+	scope := req.URL.Path
+	if path := strings.TrimPrefix(req.URL.Path, "/v2/"); path != req.URL.Path {
+		scope = path
+		for _, marker := range []string{"/manifests/", "/blobs/", "/tags/"} {
+			if i := strings.LastIndex(path, marker); i >= 0 {
+				scope = path[:i]
+				break
+			}
+		}
+	}
+	return req.URL.Host + " " + scope + " " + access
+}
+
+func withBearerToken(req *http.Request, token string) *http.Request {
+	clone := req.Clone(req.Context())
+	clone.Header.Set("Authorization", "Bearer "+token)
+	return clone
+}
+
+// parseChallenge 解析 WWW-Authenticate，如
+// Bearer realm="https://auth.example.com/token",service="registry",scope="repository:a/b:pull"
+func parseChallenge(header string) (scheme string, params map[string]string) {
+	params = make(map[string]string)
+	header = strings.TrimSpace(header)
+	i := strings.IndexByte(header, ' ')
+	if i < 0 {
+		return strings.ToLower(header), params
+	}
+	scheme = strings.ToLower(header[:i])
+	rest := header[i+1:]
+	for rest != "" {
+		rest = strings.TrimLeft(rest, " ,")
+		eq := strings.IndexByte(rest, '=')
+		if eq < 0 {
+			break
+		}
+		name := strings.ToLower(strings.TrimSpace(rest[:eq]))
+		rest = rest[eq+1:]
+		var value string
+		if strings.HasPrefix(rest, `"`) {
+			end := strings.IndexByte(rest[1:], '"')
+			if end < 0 {
+				value, rest = rest[1:], ""
+			} else {
+				value, rest = rest[1:end+1], rest[end+2:]
+			}
+		} else if comma := strings.IndexByte(rest, ','); comma >= 0 {
+			value, rest = rest[:comma], rest[comma+1:]
+		} else {
+			value, rest = rest, ""
+		}
+		params[name] = value
+	}
+	return scheme, params
+}
@@ -0,0 +1,60 @@
+package registry
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/uc-package/genet/internal/models"
+)
+
+func TestParseChallenge(t *testing.T) {
+	scheme, params := parseChallenge(`Bearer realm="https://auth.example.com/token",service="registry.example.com",scope="repository:a/b:pull,push"`)
+	if scheme != "bearer" || params["realm"] != "https://auth.example.com/token" ||
+		params["service"] != "registry.example.com" || params["scope"] != "repository:a/b:pull,push" {
+		t.Fatalf("unexpected challenge: %s %v", scheme, params)
+	}
+	if scheme, _ := parseChallenge(`Basic realm="registry"`); scheme != "basic" {
+		t.Fatalf("unexpected scheme: %s", scheme)
+	}
+}
+
+func TestDockerClientBearerTokenFlow(t *testing.T) {
+	tokenRequests := 0
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/token" {
+			tokenRequests++
+			user, pass, _ := r.BasicAuth()
+			if user != "bot" || pass != "secret" || r.URL.Query().Get("scope") != "repository:team/app:pull" {
+				http.Error(w, "bad token request", http.StatusUnauthorized)
+				return
+			}
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"token": "t-1", "expires_in": 300})
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer t-1" {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="`+server.URL+`/token",service="test",scope="repository:team/app:pull"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_, _ = w.Write([]byte(`{"name":"team/app","tags":["v1","v2"]}`))
+	}))
+	defer server.Close()
+
+	client := NewDockerClient(&models.RegistryConfig{URL: server.URL, Username: "bot", Password: "secret", Type: "docker"})
+	for i := 0; i < 2; i++ {
+		tags, err := client.GetImageTags(context.Background(), "team/app", "")
+		if err != nil {
+			t.Fatalf("GetImageTags: %v", err)
+		}
+		if len(tags) != 2 {
+			t.Fatalf("unexpected tags: %v", tags)
+		}
+	}
+	if tokenRequests != 1 {
+		t.Fatalf("expected token to be cached, got %d token requests", tokenRequests)
+	}
+}
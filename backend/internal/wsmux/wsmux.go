@@ -0,0 +1,322 @@
+// Package wsmux 在一条 WebSocket 连接上复用多条 TCP 流（genet port-forward 使用）。
+//
+// 每个 WebSocket 二进制消息是一帧：1 字节帧类型 + 4 字节流 ID（大端）+ 负载。
+// 客户端用 Open 帧打开流（负载为 2 字节远端端口），双方用 Data 帧传输数据，
+// 任一方用 Close 帧关闭流；未知流 ID 的帧直接忽略。
+// 单条流的本地连接写不过来、缓存的帧积压满时，只重置这一条流（关闭连接并向对端发 Close 帧），
+// 不阻塞读取循环，其他流不受影响。
+package wsmux
+
+import (
+	"encoding/binary"
+	"errors"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+const (
+	// FrameOpen 打开流，负载为 2 字节远端端口
+	FrameOpen byte = 1
+	// FrameData 流数据
+	FrameData byte = 2
+	// FrameClose 关闭流
+	FrameClose byte = 3
+
+	frameHeaderSize = 5
+	// streamInboxSize 每条流缓存的待写入帧数，写满后重置该流
+	streamInboxSize = 64
+	readBufferSize  = 32 * 1024
+	// maxFrameSize 单帧上限，对端按 readBufferSize 分片发送数据
+	maxFrameSize = frameHeaderSize + readBufferSize
+	writeTimeout = 30 * time.Second
+)
+
+// ErrSessionClosed 会话已关闭
+var ErrSessionClosed = errors.New("wsmux: session closed")
+
+// AcceptFunc 服务端收到 Open 帧时连接远端端口
+type AcceptFunc func(port int) (net.Conn, error)
+
+// Session 一条 WebSocket 上的多路复用会话
+type Session struct {
+	conn   *websocket.Conn
+	accept AcceptFunc
+
+	writeMu sync.Mutex
+
+	mu      sync.Mutex
+	streams map[uint32]*stream
+	nextID  uint32
+
+	closed    chan struct{}
+	closeOnce sync.Once
+}
+
+type stream struct {
+	inbox chan []byte
+	done  chan struct{}
+
+	mu       sync.Mutex
+	conn     net.Conn
+	shutOnce sync.Once
+}
+
+// NewSession 创建会话；accept 为 nil 时不接受对端打开的流（客户端）
+func NewSession(conn *websocket.Conn, accept AcceptFunc) *Session {
+	conn.SetReadLimit(maxFrameSize)
+	return &Session{
+		conn:    conn,
+		accept:  accept,
+		streams: make(map[uint32]*stream),
+		closed:  make(chan struct{}),
+	}
+}
+
+// Serve 读取并分发帧，直到 WebSocket 断开；返回前关闭所有流
+func (s *Session) Serve() error {
+	defer s.Close()
+	for {
+		msgType, msg, err := s.conn.ReadMessage()
+		if err != nil {
+			return err
+		}
+		if msgType != websocket.BinaryMessage || len(msg) < frameHeaderSize {
+			continue
+		}
+		kind, id, payload := msg[0], binary.BigEndian.Uint32(msg[1:frameHeaderSize]), msg[frameHeaderSize:]
+		switch kind {
+		case FrameOpen:
+			s.handleOpen(id, payload)
+		case FrameData:
+			if len(payload) > 0 {
+				s.deliver(id, payload)
+			}
+		case FrameClose:
+			// nil 作为结束标记排在已收到的数据之后
+			s.deliver(id, nil)
+		}
+	}
+}
+
+// Open 打开一条到远端 port 的流，并在 local 与该流之间双向转发
+func (s *Session) Open(port int, local net.Conn) error {
+	s.mu.Lock()
+	if s.isClosed() {
+		s.mu.Unlock()
+		_ = local.Close()
+		return ErrSessionClosed
+	}
+	s.nextID++
+	id := s.nextID
+	st := newStream()
+	s.streams[id] = st
+	s.mu.Unlock()
+
+	payload := make([]byte, 2)
+	binary.BigEndian.PutUint16(payload, uint16(port))
+	if err := s.writeFrame(FrameOpen, id, payload); err != nil {
+		s.finish(id, st, false)
+		_ = local.Close()
+		return err
+	}
+	s.run(id, st, local)
+	return nil
+}
+
+// Done 会话关闭时关闭
+func (s *Session) Done() <-chan struct{} {
+	return s.closed
+}
+
+// Close 关闭 WebSocket 与所有流
+func (s *Session) Close() error {
+	s.closeOnce.Do(func() {
+		s.mu.Lock()
+		close(s.closed)
+		streams := s.streams
+		s.streams = make(map[uint32]*stream)
+		s.mu.Unlock()
+		for _, st := range streams {
+			st.shutdown()
+		}
+		s.writeMu.Lock()
+		_ = s.conn.WriteControl(websocket.CloseMessage,
+			websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""),
+			time.Now().Add(time.Second))
+		s.writeMu.Unlock()
+		_ = s.conn.Close()
+	})
+	return nil
+}
+
+func (s *Session) isClosed() bool {
+	select {
+	case <-s.closed:
+		return true
+	default:
+		return false
+	}
+}
+
+func (s *Session) handleOpen(id uint32, payload []byte) {
+	if s.accept == nil || len(payload) != 2 {
+		_ = s.writeFrame(FrameClose, id, nil)
+		return
+	}
+	s.mu.Lock()
+	if _, exists := s.streams[id]; exists || s.isClosed() {
+		s.mu.Unlock()
+		return
+	}
+	// 先登记流，连接建立前到达的数据缓存在 inbox 中
+	st := newStream()
+	s.streams[id] = st
+	s.mu.Unlock()
+
+	port := int(binary.BigEndian.Uint16(payload))
+	go func() {
+		conn, err := s.accept(port)
+		if err != nil {
+			s.finish(id, st, true)
+			return
+		}
+		s.run(id, st, conn)
+	}()
+}
+
+func (s *Session) deliver(id uint32, payload []byte) {
+	s.mu.Lock()
+	st := s.streams[id]
+	s.mu.Unlock()
+	if st == nil {
+		return
+	}
+	select {
+	case st.inbox <- payload:
+		return
+	case <-st.done:
+		return
+	default:
+	}
+	if payload == nil {
+		// 对端已关闭：结束标记排在积压的数据之后，由单独的协程等待写入，不阻塞读取循环
+		go func() {
+			select {
+			case st.inbox <- nil:
+			case <-st.done:
+			}
+		}()
+		return
+	}
+	// 本地连接写不过来，重置这条流而不是阻塞所有流
+	s.finish(id, st, true)
+}
+
+// run 启动流的读写协程，流结束前 conn 归流所有
+func (s *Session) run(id uint32, st *stream, conn net.Conn) {
+	if !st.attach(conn) {
+		return
+	}
+	go func() {
+		buf := make([]byte, readBufferSize)
+		for {
+			n, err := conn.Read(buf)
+			if n > 0 {
+				if writeErr := s.writeFrame(FrameData, id, buf[:n]); writeErr != nil {
+					s.finish(id, st, false)
+					return
+				}
+			}
+			if err != nil {
+				s.finish(id, st, true)
+				return
+			}
+		}
+	}()
+	go func() {
+		for {
+			select {
+			case payload := <-st.inbox:
+				if payload == nil {
+					s.finish(id, st, false)
+					return
+				}
+				if _, err := conn.Write(payload); err != nil {
+					s.finish(id, st, true)
+					return
+				}
+			case <-st.done:
+				return
+			}
+		}
+	}()
+}
+
+// finish 移除并关闭流，notify 为 true 时通知对端
+func (s *Session) finish(id uint32, st *stream, notify bool) {
+	s.mu.Lock()
+	owned := s.streams[id] == st
+	if owned {
+		delete(s.streams, id)
+	}
+	s.mu.Unlock()
+	st.shutdown()
+	if owned && notify {
+		_ = s.writeFrame(FrameClose, id, nil)
+	}
+}
+
+func (s *Session) writeFrame(kind byte, id uint32, payload []byte) error {
+	msg := make([]byte, frameHeaderSize+len(payload))
+	msg[0] = kind
+	binary.BigEndian.PutUint32(msg[1:frameHeaderSize], id)
+	copy(msg[frameHeaderSize:], payload)
+
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	if s.isClosed() {
+		return ErrSessionClosed
+	}
+	_ = s.conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+	if err := s.conn.WriteMessage(websocket.BinaryMessage, msg); err != nil {
+		go s.Close()
+		return err
+	}
+	return nil
+}
+
+func newStream() *stream {
+	return &stream{
+		inbox: make(chan []byte, streamInboxSize),
+		done:  make(chan struct{}),
+	}
+}
+
+// attach 绑定连接，流已关闭时关闭连接并返回 false
+func (st *stream) attach(conn net.Conn) bool {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	select {
+	case <-st.done:
+		_ = conn.Close()
+		return false
+	default:
+	}
+	st.conn = conn
+	return true
+}
+
+func (st *stream) shutdown() {
+	st.shutOnce.Do(func() {
+		st.mu.Lock()
+		close(st.done)
+		conn := st.conn
+		st.mu.Unlock()
+		if conn != nil {
+			_ = conn.Close()
+		}
+	})
+}
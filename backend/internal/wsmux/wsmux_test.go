@@ -0,0 +1,143 @@
+package wsmux
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// newTestSessions 建立一对会话，服务端把端口 p 的流接到 "p:" 前缀的回显服务；
+// 端口 1 拒绝连接，端口 2 的连接从不读取
+func newTestSessions(t *testing.T) *Session {
+	t.Helper()
+	upgrader := websocket.Upgrader{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		session := NewSession(conn, func(port int) (net.Conn, error) {
+			if port == 1 {
+				return nil, fmt.Errorf("refused")
+			}
+			if port == 2 {
+				// 从不读取的连接
+				_, clientSide := net.Pipe()
+				return clientSide, nil
+			}
+			serverSide, clientSide := net.Pipe()
+			go func() {
+				defer serverSide.Close()
+				buf := make([]byte, 1024)
+				for {
+					n, err := serverSide.Read(buf)
+					if err != nil {
+						return
+					}
+					if _, err := fmt.Fprintf(serverSide, "%d:%s", port, buf[:n]); err != nil {
+						return
+					}
+				}
+			}()
+			return clientSide, nil
+		})
+		_ = session.Serve()
+	}))
+	t.Cleanup(server.Close)
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http"), nil)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	client := NewSession(conn, nil)
+	go func() { _ = client.Serve() }()
+	t.Cleanup(func() { _ = client.Close() })
+	return client
+}
+
+func TestSessionMultiplexesStreams(t *testing.T) {
+	client := newTestSessions(t)
+
+	var wg sync.WaitGroup
+	for _, port := range []int{8080, 6006, 8080} {
+		local, remote := net.Pipe()
+		if err := client.Open(port, remote); err != nil {
+			t.Fatalf("open %d: %v", port, err)
+		}
+		wg.Add(1)
+		go func(port int, local net.Conn) {
+			defer wg.Done()
+			defer local.Close()
+			for i := 0; i < 3; i++ {
+				msg := fmt.Sprintf("msg-%d", i)
+				if _, err := local.Write([]byte(msg)); err != nil {
+					t.Errorf("write: %v", err)
+					return
+				}
+				want := fmt.Sprintf("%d:%s", port, msg)
+				buf := make([]byte, len(want))
+				if _, err := io.ReadFull(local, buf); err != nil || string(buf) != want {
+					t.Errorf("port %d: got %q err=%v, want %q", port, buf, err, want)
+					return
+				}
+			}
+		}(port, local)
+	}
+	wg.Wait()
+}
+
+func TestSessionClosesRefusedStream(t *testing.T) {
+	client := newTestSessions(t)
+
+	local, remote := net.Pipe()
+	defer local.Close()
+	if err := client.Open(1, remote); err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	if _, err := io.ReadAll(local); err != nil {
+		t.Fatalf("expected clean EOF, got %v", err)
+	}
+}
+
+func TestSessionResetsStalledStreamWithoutBlockingOthers(t *testing.T) {
+	client := newTestSessions(t)
+
+	stalled, remote := net.Pipe()
+	defer stalled.Close()
+	if err := client.Open(2, remote); err != nil {
+		t.Fatalf("open stalled: %v", err)
+	}
+	// 持续写入直到服务端重置该流；写入出错说明流已被关闭
+	_ = stalled.SetWriteDeadline(time.Now().Add(10 * time.Second))
+	for {
+		if _, err := stalled.Write([]byte("x")); err != nil {
+			if errors.Is(err, os.ErrDeadlineExceeded) {
+				t.Fatalf("expected stalled stream to be reset")
+			}
+			break
+		}
+	}
+
+	local, remote := net.Pipe()
+	defer local.Close()
+	if err := client.Open(8080, remote); err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	if _, err := local.Write([]byte("ping")); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	buf := make([]byte, len("8080:ping"))
+	if _, err := io.ReadFull(local, buf); err != nil || string(buf) != "8080:ping" {
+		t.Fatalf("got %q err=%v", buf, err)
+	}
+}
@@ -11,6 +11,8 @@ import AdminAPIKeys from './pages/AdminAPIKeys';
 import ProfilePage from './pages/Profile';
 import PodDetail from './pages/PodDetail';
 import WebShellPage from './pages/WebShell';
+import WebShellRecordingsPage from './pages/WebShellRecordings';
+import SharedWebShellPage from './pages/SharedWebShell';
 import { getAuthStatus, AuthStatus } from './services/api';
 import './App.css';
 
@@ -83,6 +85,8 @@ const AppContent: React.FC = () => {
           <Route path="/admin" element={<AdminPage />} />
           <Route path="/pods/:id" element={<PodDetail />} />
           <Route path="/pods/:id/webshell" element={<WebShellPage />} />
+          <Route path="/pods/:id/webshell/recordings" element={<WebShellRecordingsPage />} />
+          <Route path="/webshell/shared/:token" element={<SharedWebShellPage />} />
           <Route path="/admin/apikeys" element={<AdminAPIKeys />} />
         </Routes>
       </Router>
@@ -0,0 +1,48 @@
+import React from 'react';
+import { MetricPoint } from '../../services/api';
+import './MetricChart.css';
+
+interface MetricChartProps {
+  title: string;
+  points: MetricPoint[];
+  format: (value: number) => string;
+  color?: string;
+  max?: number;
+}
+
+const VIEW_WIDTH = 300;
+const VIEW_HEIGHT = 80;
+
+// 轻量折线图：按时间均匀铺满宽度，纵轴从 0 到 max（未指定时取序列最大值）
+const MetricChart: React.FC<MetricChartProps> = ({ title, points, format, color = '#1677ff', max }) => {
+  const latest = points.length > 0 ? points[points.length - 1].v : undefined;
+  const peak = points.reduce((acc, point) => Math.max(acc, point.v), 0);
+  const upper = max ?? (peak > 0 ? peak : 1);
+
+  const path = points.map((point, index) => {
+    const x = points.length > 1 ? (index / (points.length - 1)) * VIEW_WIDTH : VIEW_WIDTH;
+    const y = VIEW_HEIGHT - (Math.min(point.v, upper) / upper) * (VIEW_HEIGHT - 4);
+    return `${index === 0 ? 'M' : 'L'}${x.toFixed(1)},${y.toFixed(1)}`;
+  }).join(' ');
+
+  return (
+    <div className="metric-chart">
+      <div className="metric-chart-header">
+        <span className="metric-chart-title">{title}</span>
+        <span className="metric-chart-value">
+          {latest !== undefined ? `${format(latest)}（峰值 ${format(peak)}）` : '-'}
+        </span>
+      </div>
+      {points.length > 0 ? (
+        <svg viewBox={`0 0 ${VIEW_WIDTH} ${VIEW_HEIGHT}`} preserveAspectRatio="none" role="img" aria-label={title}>
+          <path d={`${path} L${VIEW_WIDTH},${VIEW_HEIGHT} L0,${VIEW_HEIGHT} Z`} fill={color} fillOpacity={0.12} stroke="none" />
+          <path d={path} fill="none" stroke={color} strokeWidth={1.5} vectorEffect="non-scaling-stroke" />
+        </svg>
+      ) : (
+        <div className="metric-chart-empty">暂无数据</div>
+      )}
+    </div>
+  );
+};
+
+export default MetricChart;
@@ -22,6 +22,8 @@ jest.mock('../../components/GlassCard', () => ({ children, title }: any) => (
 ));
 jest.mock('../../components/ThemeToggle', () => () => <button type="button">theme</button>);
 jest.mock('../AdminAPIKeys/Panel', () => ({ AdminAPIKeysPanel: () => <div>apikey panel</div> }));
+jest.mock('../AdminGPUHistory/Panel', () => ({ AdminGPUHistoryPanel: () => <div>gpu history panel</div> }));
+jest.mock('../AdminWebShellRecordings/Panel', () => ({ AdminWebShellRecordingsPanel: () => <div>webshell recordings panel</div> }));
 jest.mock('../../services/api', () => {
   const { fn } = require('jest-mock');
   return {
@@ -86,6 +88,7 @@ describe('AdminPage', () => {
 
     expect(container.textContent).toContain('卡池管理');
     expect(container.textContent).toContain('用户管理');
+    expect(container.textContent).toContain('利用率趋势');
     expect(container.textContent).toContain('API Key 管理');
     expect(mockedListAdminNodePools).toHaveBeenCalled();
     expect(mockedListAdminUserPools).toHaveBeenCalled();
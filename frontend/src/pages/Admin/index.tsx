@@ -5,6 +5,9 @@ import { useNavigate } from 'react-router-dom';
 import GlassCard from '../../components/GlassCard';
 import ThemeToggle from '../../components/ThemeToggle';
 import { AdminAPIKeysPanel } from '../AdminAPIKeys/Panel';
+import { AdminGPUHistoryPanel } from '../AdminGPUHistory/Panel';
+import { AdminImageGCPanel } from '../AdminImageGC/Panel';
+import { AdminWebShellRecordingsPanel } from '../AdminWebShellRecordings/Panel';
 import {
   AdminNodePoolItem,
   AdminOverviewResponse,
@@ -345,6 +348,21 @@ const AdminPage: React.FC = () => {
                 </div>
               ),
             },
+            {
+              key: 'gpu-history',
+              label: '利用率趋势',
+              children: <AdminGPUHistoryPanel />,
+            },
+            {
+              key: 'image-gc',
+              label: '镜像清理',
+              children: <AdminImageGCPanel />,
+            },
+            {
+              key: 'webshell-recordings',
+              label: 'Web Shell 录制',
+              children: <AdminWebShellRecordingsPanel />,
+            },
             {
               key: 'apikeys',
               label: 'API Key 管理',
@@ -0,0 +1,106 @@
+import { ReloadOutlined } from '@ant-design/icons';
+import { Alert, Button, Segmented, Space, Statistic, Table, Tag, Typography, message } from 'antd';
+import React, { useEffect, useState } from 'react';
+import GlassCard from '../../components/GlassCard';
+import MetricChart from '../../components/MetricChart';
+import { getAdminGPUHistory, GPUHistoryGroup, GPUHistoryNode, GPUHistoryResponse } from '../../services/api';
+
+const { Text } = Typography;
+
+// 分配率为 -1 表示没有 kube-state-metrics 分配数据
+const formatRate = (value: number) => (value < 0 ? '-' : `${value.toFixed(1)}%`);
+
+export const AdminGPUHistoryPanel: React.FC = () => {
+  const [range, setRange] = useState('1d');
+  const [loading, setLoading] = useState(false);
+  const [data, setData] = useState<GPUHistoryResponse | null>(null);
+  const [error, setError] = useState('');
+
+  const loadData = async (nextRange = range) => {
+    setLoading(true);
+    try {
+      setData(await getAdminGPUHistory(nextRange));
+      setError('');
+    } catch (err: any) {
+      const detail = err.response?.data?.error || err.message;
+      setError(detail);
+      message.error(`加载历史利用率失败: ${detail}`);
+    } finally {
+      setLoading(false);
+    }
+  };
+
+  useEffect(() => {
+    void loadData();
+  }, []);
+
+  const nodeColumns = [
+    { title: '节点', dataIndex: 'nodeName', key: 'nodeName' },
+    { title: '型号', dataIndex: 'deviceType', key: 'deviceType', render: (value: string) => value || '-' },
+    {
+      title: '卡池',
+      dataIndex: 'poolType',
+      key: 'poolType',
+      render: (value: string) => (value ? <Tag color={value === 'exclusive' ? 'purple' : 'blue'}>{value === 'exclusive' ? '独占' : '共享'}</Tag> : <Tag>已下线</Tag>),
+    },
+    { title: '卡数', dataIndex: 'totalDevices', key: 'totalDevices' },
+    {
+      title: '平均分配率',
+      dataIndex: 'avgAllocationRate',
+      key: 'avgAllocationRate',
+      sorter: (a: GPUHistoryNode, b: GPUHistoryNode) => a.avgAllocationRate - b.avgAllocationRate,
+      render: formatRate,
+    },
+    {
+      title: '平均利用率',
+      dataIndex: 'avgUtilization',
+      key: 'avgUtilization',
+      sorter: (a: GPUHistoryNode, b: GPUHistoryNode) => a.avgUtilization - b.avgUtilization,
+      render: (value: number) => `${value.toFixed(1)}%`,
+    },
+  ];
+
+  const renderGroup = (group: GPUHistoryGroup) => (
+    <GlassCard key={group.type} hover={false} className="gpu-history-group">
+      <h3>{group.label || group.type}</h3>
+      <Space size="large" wrap>
+        <Statistic title="卡数" value={group.totalDevices} />
+        <Statistic title="平均分配率" value={formatRate(group.avgAllocationRate)} />
+        <Statistic title="平均利用率" value={`${group.avgUtilization.toFixed(1)}%`} />
+        <Statistic title={`平均在用率（利用率 ≥ ${data?.busyThreshold ?? 5}%）`} value={`${group.avgBusyRate.toFixed(1)}%`} />
+      </Space>
+      <div className="gpu-history-charts">
+        <MetricChart title="已分配卡数" points={group.allocated} format={(v) => v.toFixed(0)} max={group.totalDevices || undefined} />
+        <MetricChart title="在用卡数" points={group.busy} format={(v) => v.toFixed(0)} max={group.totalDevices || undefined} color="#52c41a" />
+        <MetricChart title="平均利用率" points={group.utilization} format={(v) => `${v.toFixed(1)}%`} max={100} color="#fa8c16" />
+      </div>
+      <Table
+        rowKey="nodeName"
+        size="small"
+        pagination={false}
+        columns={nodeColumns}
+        dataSource={group.nodes}
+      />
+    </GlassCard>
+  );
+
+  return (
+    <div className="gpu-history-panel">
+      <Space wrap style={{ marginBottom: 16 }}>
+        <Segmented
+          value={range}
+          options={['6h', '1d', '7d']}
+          onChange={(value) => {
+            setRange(String(value));
+            void loadData(String(value));
+          }}
+        />
+        <Button icon={<ReloadOutlined />} onClick={() => { void loadData(); }} loading={loading}>刷新</Button>
+        <Text type="secondary">分配数来自 kube-state-metrics，利用率来自加速卡 exporter</Text>
+      </Space>
+      {error && <Alert type="warning" showIcon message={error} style={{ marginBottom: 16 }} />}
+      {data && data.groups.length === 0 && <Text type="secondary">暂无加速卡数据</Text>}
+      {data?.groups.map(renderGroup)}
+    </div>
+  );
+};
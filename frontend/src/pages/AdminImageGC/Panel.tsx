@@ -0,0 +1,116 @@
+import { DeleteOutlined, ReloadOutlined, SearchOutlined } from '@ant-design/icons';
+import { Alert, Button, Descriptions, Popconfirm, Space, Table, Tag, Typography, message } from 'antd';
+import React, { useEffect, useState } from 'react';
+import { getAdminImageGC, ImageGCCandidate, ImageGCReport, ImageGCStatusResponse, runAdminImageGC } from '../../services/api';
+
+const { Text } = Typography;
+
+const reasonLabels: Record<string, string> = {
+  'keep-last': '超出保留数',
+  'suspend-resumed': '休眠快照已不再使用',
+  'max-size': '超出容量上限',
+};
+
+const formatSize = (bytes: number) => {
+  if (!bytes) {
+    return '-';
+  }
+  if (bytes >= 1024 * 1024 * 1024) {
+    return `${(bytes / 1024 / 1024 / 1024).toFixed(2)} GiB`;
+  }
+  return `${(bytes / 1024 / 1024).toFixed(1)} MiB`;
+};
+
+export const AdminImageGCPanel: React.FC = () => {
+  const [status, setStatus] = useState<ImageGCStatusResponse | null>(null);
+  const [report, setReport] = useState<ImageGCReport | null>(null);
+  const [running, setRunning] = useState(false);
+  const [loading, setLoading] = useState(false);
+
+  const loadStatus = async () => {
+    setLoading(true);
+    try {
+      const data = await getAdminImageGC();
+      setStatus(data);
+      setReport(data.lastReport || null);
+    } catch (err: any) {
+      message.error(`加载镜像 GC 状态失败: ${err.response?.data?.error || err.message}`);
+    } finally {
+      setLoading(false);
+    }
+  };
+
+  useEffect(() => {
+    void loadStatus();
+  }, []);
+
+  const handleRun = async (dryRun: boolean) => {
+    setRunning(true);
+    try {
+      const result = await runAdminImageGC(dryRun);
+      setReport(result);
+      message.success(dryRun ? `预览完成，${result.candidates.length} 个镜像将被删除` : `已删除 ${result.deleted} 个镜像`);
+    } catch (err: any) {
+      message.error(`镜像 GC 失败: ${err.response?.data?.error || err.message}`);
+    } finally {
+      setRunning(false);
+    }
+  };
+
+  const policy = status?.policy;
+  const columns = [
+    { title: '用户', dataIndex: 'username', key: 'username' },
+    { title: '镜像', dataIndex: 'image', key: 'image', render: (value: string) => <Text code>{value}</Text> },
+    { title: '原因', dataIndex: 'reason', key: 'reason', render: (value: string) => <Tag>{reasonLabels[value] || value}</Tag> },
+    { title: '大小', dataIndex: 'size', key: 'size', render: formatSize },
+    { title: '保存时间', dataIndex: 'savedAt', key: 'savedAt', render: (value: string) => new Date(value).toLocaleString() },
+    {
+      title: '结果',
+      key: 'result',
+      render: (_: unknown, item: ImageGCCandidate) => {
+        if (report?.dryRun) {
+          return <Tag color="blue">待删除</Tag>;
+        }
+        return item.deleted ? <Tag color="green">已删除</Tag> : <Tag color="red" title={item.error}>失败</Tag>;
+      },
+    },
+  ];
+
+  return (
+    <div className="image-gc-panel">
+      {policy && (
+        <Descriptions size="small" column={3} style={{ marginBottom: 16 }}>
+          <Descriptions.Item label="定时 GC">{policy.enabled ? `每 ${policy.intervalHours || 24} 小时` : '未启用'}</Descriptions.Item>
+          <Descriptions.Item label="每仓库保留">{policy.keepLastTags > 0 ? `${policy.keepLastTags} 个` : '不限'}</Descriptions.Item>
+          <Descriptions.Item label="每用户容量上限">{policy.maxTotalSizeGB > 0 ? `${policy.maxTotalSizeGB} GB` : '不限'}</Descriptions.Item>
+          <Descriptions.Item label="清理休眠快照">{policy.deleteSuspendAfterResume ? '是' : '否'}</Descriptions.Item>
+        </Descriptions>
+      )}
+      <Space wrap style={{ marginBottom: 16 }}>
+        <Button icon={<SearchOutlined />} onClick={() => { void handleRun(true); }} loading={running}>预览（dry-run）</Button>
+        <Popconfirm title="确认按保留策略删除镜像？" onConfirm={() => handleRun(false)}>
+          <Button danger icon={<DeleteOutlined />} loading={running}>立即执行</Button>
+        </Popconfirm>
+        <Button icon={<ReloadOutlined />} onClick={() => { void loadStatus(); }} loading={loading}>刷新</Button>
+        <Text type="secondary">只处理 Genet 推送的镜像，使用中的镜像不会被删除</Text>
+      </Space>
+      {report && (
+        <>
+          <Alert
+            type={report.failed > 0 ? 'warning' : 'info'}
+            showIcon
+            style={{ marginBottom: 16 }}
+            message={`${report.dryRun ? '预览' : '执行'}于 ${new Date(report.finishedAt).toLocaleString()}：评估 ${report.scanned} 个镜像，候选 ${report.candidates.length} 个${report.dryRun ? '' : `，删除 ${report.deleted} 个，失败 ${report.failed} 个，释放 ${formatSize(report.freedBytes)}`}`}
+            description={report.errors?.join('\n')}
+          />
+          <Table
+            rowKey={(item: ImageGCCandidate) => `${item.namespace}/${item.image}`}
+            size="small"
+            columns={columns}
+            dataSource={report.candidates}
+          />
+        </>
+      )}
+    </div>
+  );
+};
@@ -0,0 +1,93 @@
+import { DownloadOutlined, ReloadOutlined, SearchOutlined } from '@ant-design/icons';
+import { Alert, Button, Input, Space, Table, Tag, Typography, message } from 'antd';
+import React, { useEffect, useState } from 'react';
+import { getAdminWebShellRecordingURL, listAdminWebShellRecordings, WebShellRecording } from '../../services/api';
+
+const { Text } = Typography;
+
+const formatSize = (bytes: number) => {
+  if (bytes >= 1024 * 1024) {
+    return `${(bytes / 1024 / 1024).toFixed(1)} MiB`;
+  }
+  return `${(bytes / 1024).toFixed(1)} KiB`;
+};
+
+export const AdminWebShellRecordingsPanel: React.FC = () => {
+  const [enabled, setEnabled] = useState(true);
+  const [loading, setLoading] = useState(false);
+  const [recordings, setRecordings] = useState<WebShellRecording[]>([]);
+  const [user, setUser] = useState('');
+  const [podId, setPodId] = useState('');
+
+  const loadRecordings = async () => {
+    setLoading(true);
+    try {
+      const data = await listAdminWebShellRecordings({
+        user: user.trim() || undefined,
+        podId: podId.trim() || undefined,
+      });
+      setEnabled(data.enabled);
+      setRecordings(data.recordings || []);
+    } catch (err: any) {
+      message.error(`加载录制列表失败: ${err.response?.data?.error || err.message}`);
+    } finally {
+      setLoading(false);
+    }
+  };
+
+  useEffect(() => {
+    void loadRecordings();
+    // eslint-disable-next-line react-hooks/exhaustive-deps
+  }, []);
+
+  const columns = [
+    { title: '用户', dataIndex: 'user', key: 'user' },
+    { title: 'Pod', dataIndex: 'podId', key: 'podId', render: (value: string) => <Text code>{value}</Text> },
+    { title: '容器', dataIndex: 'container', key: 'container' },
+    { title: '开始时间', dataIndex: 'startedAt', key: 'startedAt', render: (value: string) => new Date(value).toLocaleString() },
+    {
+      title: '大小',
+      dataIndex: 'sizeBytes',
+      key: 'sizeBytes',
+      render: (value: number, record: WebShellRecording) => (
+        <Space size={4}>
+          <span>{formatSize(value)}</span>
+          {!record.endedAt && <Tag color="blue">进行中</Tag>}
+          {record.truncated && <Tag color="orange">已截断</Tag>}
+        </Space>
+      ),
+    },
+    {
+      title: '操作',
+      key: 'actions',
+      render: (_: unknown, record: WebShellRecording) => (
+        <Button size="small" icon={<DownloadOutlined />} href={getAdminWebShellRecordingURL(record.user, record.id)} target="_blank">
+          下载
+        </Button>
+      ),
+    },
+  ];
+
+  return (
+    <div className="webshell-recordings-panel">
+      {!enabled && (
+        <Alert type="info" showIcon style={{ marginBottom: 16 }} message="未启用 Web Shell 录制（pod.webShell.recording.enabled）" />
+      )}
+      <Space wrap style={{ marginBottom: 16 }}>
+        <Input placeholder="用户标识" allowClear value={user} onChange={(e) => setUser(e.target.value)} style={{ width: 180 }} />
+        <Input placeholder="Pod ID" allowClear value={podId} onChange={(e) => setPodId(e.target.value)} style={{ width: 220 }} />
+        <Button type="primary" icon={<SearchOutlined />} onClick={() => { void loadRecordings(); }} loading={loading}>查询</Button>
+        <Button icon={<ReloadOutlined />} onClick={() => { void loadRecordings(); }} loading={loading}>刷新</Button>
+        <Text type="secondary">录制只按保留期自动清理，用户无法删除</Text>
+      </Space>
+      <Table
+        rowKey={(item: WebShellRecording) => `${item.user}/${item.id}`}
+        size="small"
+        loading={loading}
+        columns={columns}
+        dataSource={recordings}
+        pagination={{ pageSize: 20 }}
+      />
+    </div>
+  );
+};
@@ -2,7 +2,7 @@ import React, { useState, useEffect, useCallback, useRef } from 'react';
 import { Modal, Form, Select, InputNumber, Input, message, Alert, AutoComplete, Collapse, Typography, Tooltip, Button, Space, Spin } from 'antd';
 import { PlusOutlined, SettingOutlined, QuestionCircleOutlined, EnvironmentOutlined, FolderOutlined, DeleteOutlined, DatabaseOutlined, ThunderboltOutlined, AppstoreOutlined } from '@ant-design/icons';
 import dayjs from 'dayjs';
-import { getConfig, createDeployment, createPod, createStatefulSet, getGPUOverview, GPUOverviewResponse, NodeGPUInfo, CreateDeploymentRequest, CreatePodRequest, CreateStatefulSetRequest, UserMount, StorageVolumeInfo, UserSavedImage, searchRegistryImages, RegistryImageInfo, getRegistryImageTags } from '../../services/api';
+import { getConfig, createDeployment, createPod, createStatefulSet, getGPUOverview, GPUOverviewResponse, NodeGPUInfo, CreateDeploymentRequest, CreatePodRequest, CreateStatefulSetRequest, UserMount, StorageVolumeInfo, UserSavedImage, searchRegistryImages, RegistryImageInfo, RegistryInfo, getRegistryImageTags } from '../../services/api';
 import GPUSelector from '../../components/GPUSelector';
 import { getCleanupLabel } from '../../utils/cleanup';
 import './CreatePodModal.css';
@@ -38,6 +38,14 @@ interface CreatePodModalProps {
   currentQuota: any;
 }
 
+// 镜像搜索仓库的 host；未返回 searchRegistries 的旧后端回退到 registryUrl
+const getSearchRegistryHosts = (config: any): string[] => {
+  if (config?.searchRegistries?.length) {
+    return config.searchRegistries.map((registry: RegistryInfo) => registry.host);
+  }
+  return config?.registryUrl ? [config.registryUrl] : [];
+};
+
 const CreatePodModal: React.FC<CreatePodModalProps> = ({
   visible,
   isAdmin = false,
@@ -67,6 +75,7 @@ const CreatePodModal: React.FC<CreatePodModalProps> = ({
 
   // Tag 选择相关状态
   const [selectedRegistryImage, setSelectedRegistryImage] = useState<string>('');
+  const [selectedRegistryHost, setSelectedRegistryHost] = useState<string>('');
   const [imageTags, setImageTags] = useState<string[]>([]);
   const [tagsLoading, setTagsLoading] = useState(false);
 
@@ -213,11 +222,12 @@ const CreatePodModal: React.FC<CreatePodModalProps> = ({
     // 显式设置表单值，防止 onSearch 干扰 Form 的值同步
     form.setFieldsValue({ image: value });
 
-    // 从 value 中提取镜像名（去掉 registryUrl 前缀）
-    const registryUrl = config?.registryUrl || '';
-    const imageName = registryUrl && value.startsWith(registryUrl + '/')
-      ? value.slice(registryUrl.length + 1)
-      : '';
+    // 从 value 中提取镜像名（去掉所属搜索仓库的 host 前缀，多个匹配时取最长）
+    const registryHost = getSearchRegistryHosts(config)
+      .filter((host) => value.startsWith(host + '/'))
+      .sort((a, b) => b.length - a.length)[0] || '';
+    const imageName = registryHost ? value.slice(registryHost.length + 1) : '';
+    setSelectedRegistryHost(registryHost);
 
     if (!imageName) {
       // 非 Registry 镜像（预设/用户保存的），不获取 tags
@@ -237,7 +247,7 @@ const CreatePodModal: React.FC<CreatePodModalProps> = ({
     setImageTags([]);
     setTagsLoading(true);
     try {
-      const result = await getRegistryImageTags(imageName, selectedPlatform || undefined);
+      const result = await getRegistryImageTags(imageName, selectedPlatform || undefined, false, registryHost);
       setImageTags(result.tags || []);
     } catch (error) {
       console.error('Failed to fetch image tags:', error);
@@ -245,7 +255,7 @@ const CreatePodModal: React.FC<CreatePodModalProps> = ({
     } finally {
       setTagsLoading(false);
     }
-  }, [config?.registryUrl, form, selectedPlatform]);
+  }, [config, form, selectedPlatform]);
 
   // 获取过滤后的预设镜像（根据 platform 过滤）
   const getFilteredPresetImages = useCallback(() => {
@@ -289,9 +299,8 @@ const CreatePodModal: React.FC<CreatePodModalProps> = ({
       let finalImage = values.image;
 
       // 防御性修复：如果选中了 Registry 镜像但 image 缺少 registry 前缀，补上前缀
-      const registryUrl = config?.registryUrl || '';
-      if (selectedRegistryImage && registryUrl && !finalImage.startsWith(registryUrl + '/')) {
-        finalImage = `${registryUrl}/${selectedRegistryImage}`;
+      if (selectedRegistryImage && selectedRegistryHost && !finalImage.startsWith(selectedRegistryHost + '/')) {
+        finalImage = `${selectedRegistryHost}/${selectedRegistryImage}`;
       }
 
       // 检查镜像名最后一段是否已有 tag（避免端口号中的 : 干扰判断）
@@ -346,22 +355,28 @@ const CreatePodModal: React.FC<CreatePodModalProps> = ({
         basePayload.userMounts = validUserMounts;
       }
 
+      let result: { warnings?: string[] };
       if (workloadType === 'statefulset') {
         const payload: CreateStatefulSetRequest = {
           ...basePayload,
           replicas: requestedReplicas,
         };
-        await createStatefulSet(payload);
+        result = await createStatefulSet(payload);
       } else if (workloadType === 'deployment') {
         const payload: CreateDeploymentRequest = {
           ...basePayload,
           replicas: requestedReplicas,
         };
-        await createDeployment(payload);
+        result = await createDeployment(payload);
       } else {
-        await createPod(basePayload);
+        result = await createPod(basePayload);
       }
 
+      // 镜像漏洞策略为 warn 时后端返回告警
+      result?.warnings?.forEach((warning) => {
+        message.warning({ content: `镜像漏洞告警: ${warning}`, duration: 8 });
+      });
+
       // 显示创建中状态
       message.loading({
         content: `${requestedWorkloadLabel} 创建中，等待调度...`,
@@ -826,11 +841,11 @@ const CreatePodModal: React.FC<CreatePodModalProps> = ({
                 <Spin spinning={registrySearchLoading} size="small">
                   <AutoComplete
                     placeholder={config?.registryUrl ? `点击选择预设镜像，或输入关键字搜索 ${config.registryUrl}` : "输入或选择镜像名称"}
-                    onSearch={config?.registryUrl ? handleRegistrySearch : undefined}
-                    onSelect={config?.registryUrl ? handleImageSelect : undefined}
+                    onSearch={getSearchRegistryHosts(config).length ? handleRegistrySearch : undefined}
+                    onSelect={getSearchRegistryHosts(config).length ? handleImageSelect : undefined}
                     notFoundContent={registrySearchLoading ? <Spin size="small" tip="搜索中..." /> : null}
                     defaultActiveFirstOption={false}
-                    filterOption={!config?.registryUrl ? (inputValue, option) => {
+                    filterOption={!getSearchRegistryHosts(config).length ? (inputValue, option) => {
                       if (!option) return false;
                       const val = (option as any).value;
                       return val ? String(val).toUpperCase().indexOf(inputValue.toUpperCase()) !== -1 : false;
@@ -839,7 +854,7 @@ const CreatePodModal: React.FC<CreatePodModalProps> = ({
                       ...(registryImages.length > 0 ? [{
                         label: 'Registry 镜像',
                         options: registryImages.map((img: RegistryImageInfo) => ({
-                          value: `${config?.registryUrl}/${img.name}`,
+                          value: `${img.registry || config?.registryUrl}/${img.name}`,
                           label: img.name + (img.description ? ` - ${img.description}` : ''),
                         })),
                       }] : []),
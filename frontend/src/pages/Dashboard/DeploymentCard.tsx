@@ -1,10 +1,11 @@
-import { DeleteOutlined, DownOutlined, HddOutlined } from '@ant-design/icons';
+import { CodeOutlined, DeleteOutlined, DownOutlined, HddOutlined } from '@ant-design/icons';
 import { Button, Divider, Modal, Space, Tag, Typography, message } from 'antd';
 import dayjs from 'dayjs';
 import React, { useMemo, useState } from 'react';
 import GlassCard from '../../components/GlassCard';
 import StatusBadge from '../../components/StatusBadge';
 import { deleteDeployment, resumeDeployment } from '../../services/api';
+import { workloadWebShellURL } from '../../utils/webshell';
 import PodCard from './PodCard';
 import './StatefulSetCard.css';
 
@@ -23,6 +24,7 @@ const DeploymentCard: React.FC<DeploymentCardProps> = ({ deployment, onUpdate, c
   const [resuming, setResuming] = useState(false);
   const isSuspended = deployment.suspended || deployment.status === 'Suspended';
   const isManaged = deployment.managed !== false;
+  const webShellURL = isSuspended ? undefined : workloadWebShellURL('deployment', deployment.name, deployment.pods);
 
   const subtitle = useMemo(() => {
     const parts = [
@@ -95,6 +97,11 @@ const DeploymentCard: React.FC<DeploymentCardProps> = ({ deployment, onUpdate, c
         <Button size="small" icon={<DownOutlined rotate={expanded ? 180 : 0} />} onClick={() => setExpanded((v) => !v)}>
           {expanded ? '收起副本' : '展开副本'}
         </Button>
+        {webShellURL && (
+          <Button size="small" icon={<CodeOutlined />} onClick={() => window.open(webShellURL, '_blank', 'noopener,noreferrer')}>
+            Web Shell
+          </Button>
+        )}
         {isManaged && isSuspended && (
           <Button size="small" type="primary" onClick={handleResume} loading={resuming}>
             恢复
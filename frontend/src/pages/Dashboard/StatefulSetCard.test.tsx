@@ -8,6 +8,7 @@ declare const jest: typeof import('@jest/globals').jest;
 
 jest.mock('../../components/GlassCard', () => ({ children }: any) => <div>{children}</div>);
 jest.mock('../../components/StatusBadge', () => ({ status }: any) => <span>{status}</span>);
+jest.mock('./PodCard', () => ({ pod }: any) => <div>{pod.name}</div>);
 jest.mock('../../services/api', () => {
   const { fn } = require('jest-mock');
   return {
@@ -100,4 +101,49 @@ describe('StatefulSetCard suspend state', () => {
     expect(resumeStatefulSet).toHaveBeenCalledWith('sts-alice-train');
     expect(onUpdate).toHaveBeenCalled();
   });
+
+  it('opens the web shell on a ready replica with the workload attached', async () => {
+    const open = jest.spyOn(window, 'open').mockImplementation(() => null);
+    await act(async () => {
+      root.render(
+        <StatefulSetCard
+          statefulSet={{
+            id: 'sts-alice-train',
+            name: 'sts-alice-train',
+            status: 'Running',
+            replicas: 2,
+            readyReplicas: 1,
+            gpuCount: 0,
+            cpu: '4',
+            memory: '8Gi',
+            serviceName: 'sts-alice-train-headless',
+            createdAt: '2026-03-15T10:00:00Z',
+            pods: [
+              { id: 'sts-alice-train-0', name: 'sts-alice-train-0', status: 'Pending', connections: { apps: { webShellReady: false } } },
+              { id: 'sts-alice-train-1', name: 'sts-alice-train-1', status: 'Running', connections: { apps: { webShellReady: true } } },
+            ],
+          }}
+          onUpdate={onUpdate}
+        />,
+      );
+    });
+
+    await flushEffects();
+
+    const webShellButton = Array.from(container.querySelectorAll('button')).find(
+      (candidate) => candidate.textContent?.replace(/\s+/g, '') === 'WebShell',
+    );
+    expect(webShellButton).toBeTruthy();
+
+    await act(async () => {
+      webShellButton?.dispatchEvent(new MouseEvent('click', { bubbles: true }));
+    });
+
+    expect(open).toHaveBeenCalledWith(
+      '/pods/sts-alice-train-1/webshell?workloadKind=statefulset&workloadName=sts-alice-train',
+      '_blank',
+      'noopener,noreferrer',
+    );
+    open.mockRestore();
+  });
 });
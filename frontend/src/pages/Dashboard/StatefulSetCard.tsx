@@ -1,10 +1,11 @@
-import { DeleteOutlined, DownOutlined, HddOutlined } from '@ant-design/icons';
+import { CodeOutlined, DeleteOutlined, DownOutlined, HddOutlined } from '@ant-design/icons';
 import { Button, Divider, Modal, Space, Tag, Typography, message } from 'antd';
 import dayjs from 'dayjs';
 import React, { useMemo, useState } from 'react';
 import GlassCard from '../../components/GlassCard';
 import StatusBadge from '../../components/StatusBadge';
 import { deleteStatefulSet, resumeStatefulSet } from '../../services/api';
+import { workloadWebShellURL } from '../../utils/webshell';
 import PodCard from './PodCard';
 import './StatefulSetCard.css';
 
@@ -23,6 +24,7 @@ const StatefulSetCard: React.FC<StatefulSetCardProps> = ({ statefulSet, onUpdate
   const [resuming, setResuming] = useState(false);
   const isSuspended = statefulSet.suspended || statefulSet.status === 'Suspended';
   const isManaged = statefulSet.managed !== false;
+  const webShellURL = isSuspended ? undefined : workloadWebShellURL('statefulset', statefulSet.name, statefulSet.pods);
 
   const subtitle = useMemo(() => {
     const parts = [
@@ -96,6 +98,11 @@ const StatefulSetCard: React.FC<StatefulSetCardProps> = ({ statefulSet, onUpdate
         <Button size="small" icon={<DownOutlined rotate={expanded ? 180 : 0} />} onClick={() => setExpanded((v) => !v)}>
           {expanded ? '收起副本' : '展开副本'}
         </Button>
+        {webShellURL && (
+          <Button size="small" icon={<CodeOutlined />} onClick={() => window.open(webShellURL, '_blank', 'noopener,noreferrer')}>
+            Web Shell
+          </Button>
+        )}
         {isManaged && isSuspended && (
           <Button size="small" type="primary" onClick={handleResume} loading={resuming}>
             恢复
@@ -6,8 +6,11 @@ import { createRoot, Root } from 'react-dom/client';
 import { MemoryRouter, Route, Routes } from 'react-router-dom';
 import PodDetail from './index';
 import {
+  buildImage,
   commitImage,
   deleteUserImage,
+  downloadPodLogs,
+  getBuildStatus,
   getCommitLogs,
   getCommitStatus,
   getConfig,
@@ -17,6 +20,7 @@ import {
   getPodLogs,
   getPodLogStreamURL,
   getSharedGPUPods,
+  listCommits,
   listUserImages,
 } from '../../services/api';
 
@@ -34,23 +38,31 @@ jest.mock('../../components/ThemeToggle', () => () => <button type="button">them
 jest.mock('../../services/api', () => {
   const { fn } = require('jest-mock');
   return {
+    buildImage: fn(),
     commitImage: fn(),
     deleteUserImage: fn(),
+    downloadPodLogs: fn(),
+    getBuildLogs: fn(),
+    getBuildStatus: fn(),
     getCommitLogs: fn(),
     getCommitStatus: fn(),
     getConfig: fn(),
     getPod: fn(),
     getPodDescribe: fn(),
     getPodEvents: fn(),
+    getPodMetrics: fn(),
     getPodLogs: fn(),
     getPodLogStreamURL: fn(),
     getSharedGPUPods: fn(),
+    listCommits: fn(),
     listUserImages: fn(),
   };
 });
 
 const mockedGetPod = getPod as MockedFunction<typeof getPod>;
 const mockedGetCommitStatus = getCommitStatus as MockedFunction<typeof getCommitStatus>;
+const mockedGetBuildStatus = getBuildStatus as MockedFunction<typeof getBuildStatus>;
+const mockedBuildImage = buildImage as MockedFunction<typeof buildImage>;
 const mockedGetSharedGPUPods = getSharedGPUPods as MockedFunction<typeof getSharedGPUPods>;
 const mockedGetConfig = getConfig as MockedFunction<typeof getConfig>;
 const mockedGetPodDescribe = getPodDescribe as MockedFunction<typeof getPodDescribe>;
@@ -58,9 +70,11 @@ const mockedGetPodLogs = getPodLogs as MockedFunction<typeof getPodLogs>;
 const mockedGetPodLogStreamURL = getPodLogStreamURL as MockedFunction<typeof getPodLogStreamURL>;
 const mockedGetPodEvents = getPodEvents as MockedFunction<typeof getPodEvents>;
 const mockedListUserImages = listUserImages as MockedFunction<typeof listUserImages>;
+const mockedListCommits = listCommits as MockedFunction<typeof listCommits>;
 const mockedCommitImage = commitImage as MockedFunction<typeof commitImage>;
 const mockedDeleteUserImage = deleteUserImage as MockedFunction<typeof deleteUserImage>;
 const mockedGetCommitLogs = getCommitLogs as MockedFunction<typeof getCommitLogs>;
+const mockedDownloadPodLogs = downloadPodLogs as MockedFunction<typeof downloadPodLogs>;
 
 class MockWebSocket {
   constructor(url: string) {
@@ -132,6 +146,7 @@ describe('PodDetail code-server entry', () => {
       },
     } as any);
     mockedGetCommitStatus.mockResolvedValue({ hasJob: false } as any);
+    mockedGetBuildStatus.mockResolvedValue({ hasJob: false } as any);
     mockedGetSharedGPUPods.mockResolvedValue({ pods: [] } as any);
     mockedGetConfig.mockResolvedValue({ storageVolumes: [], registryUrl: '' } as any);
     mockedGetPodDescribe.mockResolvedValue({ mounts: [], injectedEnvVars: [] } as any);
@@ -142,6 +157,7 @@ describe('PodDetail code-server entry', () => {
     });
     mockedGetPodEvents.mockResolvedValue({ events: [] } as any);
     mockedListUserImages.mockResolvedValue({ images: [] } as any);
+    mockedListCommits.mockResolvedValue({ items: [], total: 0, page: 1, pageSize: 10 } as any);
     mockedCommitImage.mockResolvedValue({} as any);
     mockedDeleteUserImage.mockResolvedValue({} as any);
     mockedGetCommitLogs.mockResolvedValue({ logs: '' } as any);
@@ -306,6 +322,67 @@ describe('PodDetail code-server entry', () => {
     expect(container.textContent).toContain('previous logs');
   });
 
+  it('searches logs on the server and downloads the filtered archive', async () => {
+    mockedGetPodLogs.mockImplementation(async (_id: string, options?: any) => (
+      { logs: options?.grep ? 'epoch 2 loss=0.5\n' : 'current logs', cursor: '2026-03-15T10:00:00Z' } as any
+    ));
+
+    await act(async () => {
+      root.render(
+        <MemoryRouter initialEntries={['/pods/pod-alice-dev']}>
+          <Routes>
+            <Route path="/pods/:id" element={<PodDetail />} />
+          </Routes>
+        </MemoryRouter>,
+      );
+    });
+
+    await flushEffects();
+
+    const logsTab = Array.from(document.querySelectorAll('[role="tab"]')).find(
+      (tab) => tab.textContent?.includes('实时日志'),
+    );
+
+    await act(async () => {
+      logsTab?.dispatchEvent(new MouseEvent('click', { bubbles: true }));
+    });
+
+    await flushEffects();
+
+    const searchInput = document.querySelector('input[placeholder="服务端搜索日志"]') as HTMLInputElement;
+    expect(searchInput).toBeTruthy();
+
+    await act(async () => {
+      const setValue = Object.getOwnPropertyDescriptor(HTMLInputElement.prototype, 'value')?.set;
+      setValue?.call(searchInput, 'loss=');
+      searchInput.dispatchEvent(new Event('input', { bubbles: true }));
+    });
+
+    await act(async () => {
+      searchInput.dispatchEvent(new KeyboardEvent('keydown', { bubbles: true, key: 'Enter', keyCode: 13 } as any));
+    });
+
+    await flushEffects();
+
+    expect(mockedGetPodLogs as any).toHaveBeenLastCalledWith('pod-alice-dev', {
+      grep: 'loss=',
+      ignoreCase: true,
+      previous: false,
+      tailLines: 3000,
+    });
+    expect(container.textContent).toContain('epoch 2 loss=0.5');
+
+    const downloadButton = Array.from(document.querySelectorAll('button')).find(
+      (candidate) => candidate.textContent?.includes('下载日志'),
+    );
+
+    await act(async () => {
+      downloadButton?.dispatchEvent(new MouseEvent('click', { bubbles: true }));
+    });
+
+    expect(mockedDownloadPodLogs).toHaveBeenCalledWith('pod-alice-dev', { grep: 'loss=', ignoreCase: true });
+  });
+
   it('loads current logs once and appends streaming log chunks', async () => {
     mockedGetPodLogs.mockResolvedValueOnce({
       logs: 'line 1\nline 2\n',
@@ -404,4 +481,188 @@ describe('PodDetail code-server entry', () => {
     expect(container.textContent).toContain('entry-3001');
     expect(container.textContent).toContain('entry-3002');
   });
+
+  it('submits a Dockerfile build using the workspace Dockerfile by default', async () => {
+    mockedGetConfig.mockResolvedValue({ storageVolumes: [{ name: 'workspace', mountPath: '/workspace-genet' }], registryUrl: '' } as any);
+    mockedBuildImage.mockResolvedValue({ jobName: 'build-alice-1' } as any);
+
+    await act(async () => {
+      root.render(
+        <MemoryRouter initialEntries={['/pods/pod-alice-dev']}>
+          <Routes>
+            <Route path="/pods/:id" element={<PodDetail />} />
+          </Routes>
+        </MemoryRouter>,
+      );
+    });
+
+    await flushEffects();
+
+    const commitTab = Array.from(document.querySelectorAll('[role="tab"]')).find(
+      (tab) => tab.textContent?.includes('镜像保存'),
+    );
+
+    await act(async () => {
+      commitTab?.dispatchEvent(new MouseEvent('click', { bubbles: true }));
+    });
+
+    await flushEffects();
+
+    const buildButton = Array.from(document.querySelectorAll('button')).find(
+      (candidate) => candidate.textContent?.includes('Dockerfile 构建'),
+    );
+    expect(buildButton).toBeTruthy();
+
+    await act(async () => {
+      buildButton?.dispatchEvent(new MouseEvent('click', { bubbles: true }));
+    });
+
+    await flushEffects();
+
+    const modal = Array.from(document.querySelectorAll('.ant-modal')).find(
+      (candidate) => candidate.textContent?.includes('Dockerfile 构建镜像'),
+    ) as HTMLElement;
+    expect(modal).toBeTruthy();
+    const imageInput = modal.querySelector('input[placeholder="registry.example.com/namespace/image:tag"]') as HTMLInputElement;
+
+    await act(async () => {
+      const setValue = Object.getOwnPropertyDescriptor(HTMLInputElement.prototype, 'value')?.set;
+      setValue?.call(imageInput, 'registry.local/alice/train:v1');
+      imageInput.dispatchEvent(new Event('input', { bubbles: true }));
+    });
+
+    const submitButton = Array.from(modal.querySelectorAll('button')).find(
+      (candidate) => candidate.textContent?.includes('开始构建'),
+    );
+
+    await act(async () => {
+      submitButton?.dispatchEvent(new MouseEvent('click', { bubbles: true }));
+    });
+
+    await flushEffects();
+
+    expect(mockedBuildImage).toHaveBeenCalledWith('pod-alice-dev', {
+      imageName: 'registry.local/alice/train:v1',
+      dockerfile: undefined,
+      dockerfilePath: '/workspace-genet/Dockerfile',
+      context: undefined,
+    });
+  });
+
+  it('submits a commit with the admin default excludes and squash', async () => {
+    mockedGetConfig.mockResolvedValue({
+      storageVolumes: [],
+      registryUrl: '',
+      commitDefaultExcludes: ['**/__pycache__', '/root/.cache/pip'],
+      commitSquash: true,
+    } as any);
+
+    await act(async () => {
+      root.render(
+        <MemoryRouter initialEntries={['/pods/pod-alice-dev']}>
+          <Routes>
+            <Route path="/pods/:id" element={<PodDetail />} />
+          </Routes>
+        </MemoryRouter>,
+      );
+    });
+
+    await flushEffects();
+
+    const commitTab = Array.from(document.querySelectorAll('[role="tab"]')).find(
+      (tab) => tab.textContent?.includes('镜像保存'),
+    );
+
+    await act(async () => {
+      commitTab?.dispatchEvent(new MouseEvent('click', { bubbles: true }));
+    });
+
+    await flushEffects();
+
+    const commitButton = Array.from(document.querySelectorAll('button')).find(
+      (candidate) => candidate.textContent?.includes('保存为镜像'),
+    );
+
+    await act(async () => {
+      commitButton?.dispatchEvent(new MouseEvent('click', { bubbles: true }));
+    });
+
+    await flushEffects();
+
+    const modal = Array.from(document.querySelectorAll('.ant-modal')).find(
+      (candidate) => candidate.textContent?.includes('保存为镜像'),
+    ) as HTMLElement;
+    expect(modal.textContent).toContain('**/__pycache__');
+    const imageInput = modal.querySelector('input[placeholder="registry.example.com/namespace/image:tag"]') as HTMLInputElement;
+
+    await act(async () => {
+      const setValue = Object.getOwnPropertyDescriptor(HTMLInputElement.prototype, 'value')?.set;
+      setValue?.call(imageInput, 'registry.local/alice/train:v2');
+      imageInput.dispatchEvent(new Event('input', { bubbles: true }));
+    });
+
+    const submitButton = Array.from(modal.querySelectorAll('button')).find(
+      (candidate) => candidate.textContent?.includes('开始保存'),
+    );
+
+    await act(async () => {
+      submitButton?.dispatchEvent(new MouseEvent('click', { bubbles: true }));
+    });
+
+    await flushEffects();
+
+    expect(mockedCommitImage).toHaveBeenCalledWith('pod-alice-dev', 'registry.local/alice/train:v2', {
+      exclude: [],
+      noDefaultExcludes: false,
+      squash: true,
+      preCommitHook: undefined,
+    });
+  });
+
+  it('shows the commit history of the pod in the image tab', async () => {
+    mockedListCommits.mockResolvedValue({
+      items: [
+        {
+          id: 'commit-alice-1700000000000',
+          targetImage: 'registry.local/alice/train:v3',
+          sourcePod: 'pod-alice-dev',
+          status: 'Failed',
+          message: '镜像构建失败',
+          createdAt: '2026-03-15T10:00:00Z',
+          durationSeconds: 75,
+          logTail: 'ERROR: push denied',
+        },
+      ],
+      total: 1,
+      page: 1,
+      pageSize: 10,
+    } as any);
+
+    await act(async () => {
+      root.render(
+        <MemoryRouter initialEntries={['/pods/pod-alice-dev']}>
+          <Routes>
+            <Route path="/pods/:id" element={<PodDetail />} />
+          </Routes>
+        </MemoryRouter>,
+      );
+    });
+
+    await flushEffects();
+
+    const commitTab = Array.from(document.querySelectorAll('[role="tab"]')).find(
+      (tab) => tab.textContent?.includes('镜像保存'),
+    );
+
+    await act(async () => {
+      commitTab?.dispatchEvent(new MouseEvent('click', { bubbles: true }));
+    });
+
+    await flushEffects();
+
+    expect(mockedListCommits).toHaveBeenCalledWith({ pod: 'pod-alice-dev', pageSize: 10 });
+    expect(container.textContent).toContain('保存历史');
+    expect(container.textContent).toContain('registry.local/alice/train:v3');
+    expect(container.textContent).toContain('1m15s');
+  });
 });
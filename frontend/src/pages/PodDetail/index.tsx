@@ -1,12 +1,13 @@
-import { ArrowLeftOutlined, CloudServerOutlined, CodeOutlined, CopyOutlined, DatabaseOutlined, DeleteOutlined, DesktopOutlined, DownloadOutlined, ReloadOutlined, SaveOutlined } from '@ant-design/icons';
-import { Alert, Button, Descriptions, Input, Layout, message, Modal, Popconfirm, Progress, Skeleton, Space, Switch, Table, Tabs, Tag, Tooltip, Typography } from 'antd';
+import { ArrowLeftOutlined, BuildOutlined, CloudServerOutlined, CodeOutlined, CopyOutlined, DatabaseOutlined, DeleteOutlined, DesktopOutlined, DownloadOutlined, PlayCircleOutlined, ReloadOutlined, SaveOutlined } from '@ant-design/icons';
+import { Alert, Button, Descriptions, Input, Layout, message, Modal, Popconfirm, Progress, Radio, Segmented, Skeleton, Space, Switch, Table, Tabs, Tag, Tooltip, Typography } from 'antd';
 import dayjs from 'dayjs';
 import React, { useEffect, useRef, useState } from 'react';
 import { useNavigate, useParams } from 'react-router-dom';
 import GlassCard from '../../components/GlassCard';
+import MetricChart from '../../components/MetricChart';
 import StatusBadge from '../../components/StatusBadge';
 import ThemeToggle from '../../components/ThemeToggle';
-import { commitImage, CommitStatus, deleteUserImage, getCommitLogs, getCommitStatus, getConfig, getPod, getPodDescribe, getPodEvents, getPodLogs, getPodLogStreamURL, getSharedGPUPods, listUserImages, SharedGPUPod, StorageVolumeInfo, UserSavedImage } from '../../services/api';
+import { buildImage, commitImage, CommitRecord, CommitStatus, deleteUserImage, downloadPodLogs, getBuildLogs, getBuildStatus, getCommitLogs, getCommitStatus, getConfig, getPod, getPodDescribe, getPodEvents, getPodLogs, getPodLogStreamURL, getPodMetrics, getSharedGPUPods, listCommits, listUserImages, PodMetrics, SharedGPUPod, StorageVolumeInfo, UserSavedImage, VulnerabilitySummary } from '../../services/api';
 import './index.css';
 
 const { Header, Content } = Layout;
@@ -24,6 +25,32 @@ interface PodLogStreamMessage {
   message?: string;
 }
 
+// 镜像大小及相对原镜像的变化，如 "2.10 GiB（+350.0 MiB）"
+const formatImageSize = (size?: number, baseSize?: number) => {
+  if (!size) return '-';
+  const text = `${(size / 1024 / 1024 / 1024).toFixed(2)} GiB`;
+  if (!baseSize) return text;
+  const delta = (size - baseSize) / 1024 / 1024;
+  return `${text}（${delta >= 0 ? '+' : ''}${delta.toFixed(1)} MiB）`;
+};
+
+// Harbor 漏洞扫描摘要：未扫描显示状态，否则显示 Critical/High 数量
+const renderVulnerabilities = (summary?: VulnerabilitySummary) => {
+  if (!summary) return '-';
+  if (summary.scanStatus !== 'Success') return <Tag>{summary.scanStatus}</Tag>;
+  const critical = summary.counts?.Critical || 0;
+  const high = summary.counts?.High || 0;
+  if (!critical && !high) {
+    return <Tooltip title={`共 ${summary.total} 个漏洞`}><Tag color="green">无高危</Tag></Tooltip>;
+  }
+  return (
+    <Tooltip title={`共 ${summary.total} 个漏洞，${summary.fixable || 0} 个可修复`}>
+      {critical > 0 && <Tag color="red">严重 {critical}</Tag>}
+      {high > 0 && <Tag color="orange">高危 {high}</Tag>}
+    </Tooltip>
+  );
+};
+
 const toWebSocketURL = (value: string) => {
   const baseURL = new URL(value, window.location.origin);
   const protocol = baseURL.protocol === 'https:' ? 'wss:' : 'ws:';
@@ -66,19 +93,43 @@ const PodDetail: React.FC = () => {
   const [loading, setLoading] = useState(false);
   const [logsLoading, setLogsLoading] = useState(false);
   const [eventsLoading, setEventsLoading] = useState(false);
+  const [metrics, setMetrics] = useState<PodMetrics | null>(null);
+  const [metricsRange, setMetricsRange] = useState('1h');
+  const [metricsLoading, setMetricsLoading] = useState(false);
+  const [metricsError, setMetricsError] = useState('');
   const [describeLoading, setDescribeLoading] = useState(false);
   const [showPassword, setShowPassword] = useState(false);
   const [commitModalVisible, setCommitModalVisible] = useState(false);
   const [commitImageName, setCommitImageName] = useState('');
+  const [commitDefaultExcludes, setCommitDefaultExcludes] = useState<string[]>([]);
+  const [commitUseDefaultExcludes, setCommitUseDefaultExcludes] = useState(true);
+  const [commitExcludes, setCommitExcludes] = useState('');
+  const [commitSquash, setCommitSquash] = useState(false);
+  const [commitHook, setCommitHook] = useState('');
   const [commitStatus, setCommitStatus] = useState<CommitStatus | null>(null);
   const [commitLogs, setCommitLogs] = useState<string>('');
   const [commitSubmitting, setCommitSubmitting] = useState(false);
   const commitPollRef = useRef<NodeJS.Timeout | null>(null);
+  const [commitHistory, setCommitHistory] = useState<CommitRecord[]>([]);
+  const [commitHistoryLoading, setCommitHistoryLoading] = useState(false);
+  const [buildModalVisible, setBuildModalVisible] = useState(false);
+  const [buildImageName, setBuildImageName] = useState('');
+  const [buildDockerfileMode, setBuildDockerfileMode] = useState<'path' | 'inline'>('path');
+  const [buildDockerfilePath, setBuildDockerfilePath] = useState('');
+  const [buildDockerfile, setBuildDockerfile] = useState('');
+  const [buildContext, setBuildContext] = useState('');
+  const [buildStatus, setBuildStatus] = useState<CommitStatus | null>(null);
+  const [buildLogs, setBuildLogs] = useState<string>('');
+  const [buildSubmitting, setBuildSubmitting] = useState(false);
+  const buildPollRef = useRef<NodeJS.Timeout | null>(null);
   const [sharedGPUPods, setSharedGPUPods] = useState<SharedGPUPod[]>([]);
   const [sharedGPULoading, setSharedGPULoading] = useState(false);
   const [autoRefreshLogs, setAutoRefreshLogs] = useState(true);
   const [followLatestLogs, setFollowLatestLogs] = useState(true);
   const [showPreviousLogs, setShowPreviousLogs] = useState(false);
+  const [logSearch, setLogSearch] = useState('');
+  const [logSearchResult, setLogSearchResult] = useState<string | null>(null);
+  const [logSearchLoading, setLogSearchLoading] = useState(false);
   const [currentLogStreamState, setCurrentLogStreamState] = useState<CurrentLogStreamState>('disconnected');
   const [activeTab, setActiveTab] = useState('overview');
   const logsContainerRef = useRef<HTMLDivElement | null>(null);
@@ -100,6 +151,7 @@ const PodDetail: React.FC = () => {
       setCurrentLogStreamState('disconnected');
       loadPod();
       loadCommitStatus();
+      loadBuildStatus();
       loadSharedGPUPods();
       loadStorageVolumes();
       loadDescribe();
@@ -108,6 +160,9 @@ const PodDetail: React.FC = () => {
       if (commitPollRef.current) {
         clearInterval(commitPollRef.current);
       }
+      if (buildPollRef.current) {
+        clearInterval(buildPollRef.current);
+      }
       closeLogStream();
     };
     // eslint-disable-next-line react-hooks/exhaustive-deps
@@ -118,6 +173,8 @@ const PodDetail: React.FC = () => {
       const data: any = await getConfig();
       setStorageVolumes(data.storageVolumes || []);
       setRegistryUrl(data.registryUrl || '');
+      setCommitDefaultExcludes(data.commitDefaultExcludes || []);
+      setCommitSquash(!!data.commitSquash);
     } catch (error) {
       // 静默失败
       console.error('Failed to load storage volumes:', error);
@@ -127,7 +184,7 @@ const PodDetail: React.FC = () => {
   const loadUserImages = async () => {
     setUserImagesLoading(true);
     try {
-      const data = await listUserImages();
+      const data = await listUserImages(true);
       setUserImages(data.images || []);
     } catch (error) {
       console.error('Failed to load user images:', error);
@@ -136,6 +193,18 @@ const PodDetail: React.FC = () => {
     }
   };
 
+  const loadCommitHistory = async () => {
+    setCommitHistoryLoading(true);
+    try {
+      const data = await listCommits({ pod: id, pageSize: 10 });
+      setCommitHistory(data.items || []);
+    } catch (error) {
+      console.error('Failed to load commit history:', error);
+    } finally {
+      setCommitHistoryLoading(false);
+    }
+  };
+
   const handleDeleteUserImage = async (imageName: string) => {
     try {
       await deleteUserImage(imageName);
@@ -247,8 +316,28 @@ const PodDetail: React.FC = () => {
     }
   };
 
+  const searchLogs = async (keyword: string, previous: boolean) => {
+    setLogSearch(keyword);
+    if (!keyword.trim()) {
+      setLogSearchResult(null);
+      return;
+    }
+    setLogSearchLoading(true);
+    try {
+      const data = await getPodLogs(id!, { grep: keyword, ignoreCase: true, previous, tailLines: MAX_LOG_LINES });
+      setLogSearchResult(data.logs || '');
+    } catch (error: any) {
+      message.error(`搜索日志失败: ${error.message}`);
+    } finally {
+      setLogSearchLoading(false);
+    }
+  };
+
   const handleLogSourceChange = (nextShowPreviousLogs: boolean) => {
     setShowPreviousLogs(nextShowPreviousLogs);
+    if (logSearch.trim()) {
+      void searchLogs(logSearch, nextShowPreviousLogs);
+    }
   };
 
   const connectCurrentLogStream = async (reason: 'initial' | 'reconnect' | 'manual') => {
@@ -347,6 +436,23 @@ const PodDetail: React.FC = () => {
     // eslint-disable-next-line react-hooks/exhaustive-deps
   }, [activeTab, showPreviousLogs]);
 
+  const loadMetrics = async (range = metricsRange) => {
+    setMetricsLoading(true);
+    try {
+      setMetrics(await getPodMetrics(id!, range));
+      setMetricsError('');
+    } catch (error: any) {
+      setMetricsError(error.response?.data?.error || error.message);
+    } finally {
+      setMetricsLoading(false);
+    }
+  };
+
+  const handleMetricsRangeChange = (range: string) => {
+    setMetricsRange(range);
+    void loadMetrics(range);
+  };
+
   const loadEvents = async () => {
     setEventsLoading(true);
     try {
@@ -441,7 +547,7 @@ const PodDetail: React.FC = () => {
     try {
       const status = await getCommitStatus(id!);
       setCommitStatus(status);
-      if (status.hasJob && (status.status === 'Pending' || status.status === 'Running')) {
+      if (status.hasJob && (status.status === 'Queued' || status.status === 'Pending' || status.status === 'Running')) {
         startCommitPolling();
       }
     } catch (error) {}
@@ -469,6 +575,7 @@ const PodDetail: React.FC = () => {
           } else {
             message.error('镜像保存失败');
           }
+          loadCommitHistory();
         }
       } catch (error) {}
     }, 3000);
@@ -485,11 +592,17 @@ const PodDetail: React.FC = () => {
       const fullImageName = registryUrl
         ? `${registryUrl}/${commitImageName.trim()}`
         : commitImageName.trim();
-      await commitImage(id!, fullImageName);
+      await commitImage(id!, fullImageName, {
+        exclude: commitExcludes.split('\n').map((line) => line.trim()).filter(Boolean),
+        noDefaultExcludes: !commitUseDefaultExcludes,
+        squash: commitSquash,
+        preCommitHook: commitHook.trim() || undefined,
+      });
       message.success('镜像保存任务已创建');
       setCommitModalVisible(false);
       setCommitImageName('');
       loadCommitStatus();
+      loadCommitHistory();
       startCommitPolling();
     } catch (error: any) {
       message.error(`创建任务失败: ${error.message}`);
@@ -507,9 +620,103 @@ const PodDetail: React.FC = () => {
     }
   };
 
-  const getCommitProgress = () => {
-    if (!commitStatus?.hasJob) return 0;
-    switch (commitStatus.status) {
+  const loadBuildStatus = async () => {
+    try {
+      const status = await getBuildStatus(id!);
+      setBuildStatus(status);
+      if (status.hasJob && (status.status === 'Pending' || status.status === 'Running')) {
+        startBuildPolling();
+      }
+    } catch (error) {}
+  };
+
+  const startBuildPolling = () => {
+    if (buildPollRef.current) {
+      clearInterval(buildPollRef.current);
+    }
+    buildPollRef.current = setInterval(async () => {
+      try {
+        const status = await getBuildStatus(id!);
+        setBuildStatus(status);
+        try {
+          const logsData = await getBuildLogs(id!);
+          setBuildLogs(logsData.logs || '');
+        } catch (e) {}
+        if (status.status === 'Succeeded' || status.status === 'Failed') {
+          if (buildPollRef.current) {
+            clearInterval(buildPollRef.current);
+            buildPollRef.current = null;
+          }
+          if (status.status === 'Succeeded') {
+            message.success('镜像构建成功！');
+            loadUserImages();
+          } else {
+            message.error('镜像构建失败');
+          }
+        }
+      } catch (error) {}
+    }, 3000);
+  };
+
+  const openBuildModal = () => {
+    // 默认使用第一个工作空间卷中的 Dockerfile
+    const workspace = storageVolumes[0]?.mountPath;
+    if (!buildDockerfilePath && workspace) {
+      setBuildDockerfilePath(`${workspace}/Dockerfile`);
+    }
+    setBuildModalVisible(true);
+  };
+
+  const handleBuild = async () => {
+    if (!buildImageName.trim()) {
+      message.error('请输入目标镜像名称');
+      return;
+    }
+    if (buildDockerfileMode === 'path' ? !buildDockerfilePath.trim() : !buildDockerfile.trim()) {
+      message.error(buildDockerfileMode === 'path' ? '请输入 Dockerfile 路径' : '请输入 Dockerfile 内容');
+      return;
+    }
+    if (buildDockerfileMode === 'inline' && !buildContext.trim()) {
+      message.error('使用内联 Dockerfile 时必须指定构建上下文目录');
+      return;
+    }
+    setBuildSubmitting(true);
+    try {
+      const fullImageName = registryUrl
+        ? `${registryUrl}/${buildImageName.trim()}`
+        : buildImageName.trim();
+      await buildImage(id!, {
+        imageName: fullImageName,
+        dockerfile: buildDockerfileMode === 'inline' ? buildDockerfile : undefined,
+        dockerfilePath: buildDockerfileMode === 'path' ? buildDockerfilePath.trim() : undefined,
+        context: buildContext.trim() || undefined,
+      });
+      message.success('镜像构建任务已创建');
+      setBuildModalVisible(false);
+      setBuildImageName('');
+      setBuildLogs('');
+      loadBuildStatus();
+      startBuildPolling();
+    } catch (error: any) {
+      message.error(`创建任务失败: ${error.message}`);
+    } finally {
+      setBuildSubmitting(false);
+    }
+  };
+
+  const loadBuildLogs = async () => {
+    try {
+      const logsData = await getBuildLogs(id!);
+      setBuildLogs(logsData.logs || '暂无日志');
+    } catch (error: any) {
+      message.error(`获取日志失败: ${error.message}`);
+    }
+  };
+
+  const getJobProgress = (status: CommitStatus | null) => {
+    if (!status?.hasJob) return 0;
+    switch (status.status) {
+      case 'Queued': return 5;
       case 'Pending': return 10;
       case 'Running': return 50;
       case 'Succeeded': return 100;
@@ -546,7 +753,7 @@ const PodDetail: React.FC = () => {
   const hasCodeServer = Boolean(connections?.apps?.codeServerURL);
   const hasWebShell = Boolean(connections?.apps?.webShellURL);
   const hasConnections = hasSSHConnection || hasCodeServer || hasWebShell;
-  const visibleLogs = showPreviousLogs ? previousLogs : currentLogs;
+  const visibleLogs = logSearchResult ?? (showPreviousLogs ? previousLogs : currentLogs);
   const currentLogStreamLabel = currentLogStreamState === 'connected'
     ? '已连接'
     : currentLogStreamState === 'connecting'
@@ -757,6 +964,16 @@ const PodDetail: React.FC = () => {
               ) : (
                 <Button onClick={() => { void connectCurrentLogStream('manual'); }} loading={logsLoading} icon={<ReloadOutlined />}>重新连接</Button>
               )}
+              <Input.Search
+                allowClear
+                placeholder="服务端搜索日志"
+                style={{ width: 220 }}
+                loading={logSearchLoading}
+                onSearch={(value) => { void searchLogs(value, showPreviousLogs); }}
+              />
+              <Tooltip title="下载之前与当前容器的完整日志（gzip），会应用搜索条件">
+                <Button icon={<DownloadOutlined />} onClick={() => downloadPodLogs(id!, { grep: logSearch.trim() || undefined, ignoreCase: true })}>下载日志</Button>
+              </Tooltip>
             </Space>
           </div>
           <div
@@ -764,7 +981,7 @@ const PodDetail: React.FC = () => {
             className="logs-container"
             onScroll={handleLogsScroll}
           >
-            <pre className="mono">{visibleLogs || (showPreviousLogs ? '点击刷新按钮加载日志' : '正在等待日志流...')}</pre>
+            <pre className="mono">{visibleLogs || (logSearchResult !== null ? '没有匹配的日志' : showPreviousLogs ? '点击刷新按钮加载日志' : '正在等待日志流...')}</pre>
           </div>
         </div>
       ),
@@ -834,10 +1051,18 @@ const PodDetail: React.FC = () => {
           <Space direction="vertical" style={{ width: '100%' }} size="middle">
             <Alert message="将当前 Pod 保存为镜像" description="此功能会将 Pod 当前状态打包为一个新镜像，并推送到镜像仓库。" type="info" showIcon />
             <Button type="primary" icon={<SaveOutlined />} onClick={() => setCommitModalVisible(true)}
-              disabled={pod.status !== 'Running' || (commitStatus?.hasJob && (commitStatus.status === 'Pending' || commitStatus.status === 'Running'))} size="large">
+              disabled={pod.status !== 'Running'} size="large">
               保存为镜像
             </Button>
             {pod.status !== 'Running' && <Text type="warning">只有运行中的 Pod 才能保存为镜像</Text>}
+            {pod.status === 'Running' && commitStatus?.hasJob && ['Queued', 'Pending', 'Running'].includes(commitStatus.status || '') && (
+              <Text type="secondary">当前有未完成的保存任务，新任务将排队依次执行</Text>
+            )}
+            <Alert message="使用 Dockerfile 构建镜像" description="使用工作空间中的 Dockerfile 和构建上下文构建镜像，构建过程可复现，不包含 Pod 运行时产生的临时文件。" type="info" showIcon />
+            <Button icon={<BuildOutlined />} onClick={openBuildModal}
+              disabled={pod.status !== 'Running' || (buildStatus?.hasJob && (buildStatus.status === 'Pending' || buildStatus.status === 'Running'))} size="large">
+              Dockerfile 构建
+            </Button>
           </Space>
           {commitStatus?.hasJob && (
             <GlassCard hover={false} title="当前任务状态" style={{ marginTop: 24 }}>
@@ -851,8 +1076,11 @@ const PodDetail: React.FC = () => {
                     <Text code className="mono" copyable>{commitStatus.targetImage}</Text>
                   </Descriptions.Item>
                   <Descriptions.Item label="消息" span={2}>{commitStatus.message}</Descriptions.Item>
+                  {commitStatus.size ? (
+                    <Descriptions.Item label="镜像大小" span={2}>{formatImageSize(commitStatus.size, commitStatus.baseSize)}</Descriptions.Item>
+                  ) : null}
                 </Descriptions>
-                <Progress percent={getCommitProgress()} status={commitStatus.status === 'Failed' ? 'exception' : commitStatus.status === 'Succeeded' ? 'success' : 'active'} />
+                <Progress percent={getJobProgress(commitStatus)} status={commitStatus.status === 'Failed' ? 'exception' : commitStatus.status === 'Succeeded' ? 'success' : 'active'} />
               </Space>
             </GlassCard>
           )}
@@ -861,6 +1089,95 @@ const PodDetail: React.FC = () => {
               <div className="logs-container"><pre className="mono">{commitLogs || '暂无日志'}</pre></div>
             </GlassCard>
           )}
+          {buildStatus?.hasJob && (
+            <GlassCard hover={false} title="Dockerfile 构建任务" style={{ marginTop: 24 }}>
+              <Space direction="vertical" style={{ width: '100%' }}>
+                <Descriptions bordered column={2} size="small">
+                  <Descriptions.Item label="任务名称">{buildStatus.jobName}</Descriptions.Item>
+                  <Descriptions.Item label="状态">
+                    <Tag color={buildStatus.status === 'Succeeded' ? 'green' : buildStatus.status === 'Failed' ? 'red' : buildStatus.status === 'Running' ? 'blue' : 'default'}>{buildStatus.status}</Tag>
+                  </Descriptions.Item>
+                  <Descriptions.Item label="目标镜像" span={2}>
+                    <Text code className="mono" copyable>{buildStatus.targetImage}</Text>
+                  </Descriptions.Item>
+                  <Descriptions.Item label="消息" span={2}>{buildStatus.message}</Descriptions.Item>
+                </Descriptions>
+                <Progress percent={getJobProgress(buildStatus)} status={buildStatus.status === 'Failed' ? 'exception' : buildStatus.status === 'Succeeded' ? 'success' : 'active'} />
+              </Space>
+            </GlassCard>
+          )}
+          {buildStatus?.hasJob && (
+            <GlassCard hover={false} title="Dockerfile 构建日志" extra={<Button onClick={loadBuildLogs} icon={<ReloadOutlined />} size="small">刷新</Button>} style={{ marginTop: 16 }}>
+              <div className="logs-container"><pre className="mono">{buildLogs || '暂无日志'}</pre></div>
+            </GlassCard>
+          )}
+          <GlassCard
+            hover={false}
+            title="保存历史"
+            extra={<Button onClick={loadCommitHistory} icon={<ReloadOutlined />} size="small" loading={commitHistoryLoading}>刷新</Button>}
+            style={{ marginTop: 24 }}
+          >
+            {commitHistory.length > 0 ? (
+              <Table
+                dataSource={commitHistory}
+                rowKey="id"
+                pagination={false}
+                size="small"
+                expandable={{
+                  expandedRowRender: (record: CommitRecord) => (
+                    <div className="logs-container"><pre className="mono">{record.logTail || '暂无日志'}</pre></div>
+                  ),
+                  rowExpandable: (record: CommitRecord) => Boolean(record.logTail),
+                }}
+                columns={[
+                  {
+                    title: '目标镜像',
+                    dataIndex: 'targetImage',
+                    key: 'targetImage',
+                    render: (image: string, record: CommitRecord) => (
+                      <Tooltip title={record.digest}>
+                        <Text code className="mono" copyable={{ text: image }}>{image}</Text>
+                      </Tooltip>
+                    ),
+                  },
+                  {
+                    title: '状态',
+                    dataIndex: 'status',
+                    key: 'status',
+                    width: 100,
+                    render: (status: string, record: CommitRecord) => (
+                      <Tooltip title={record.message}>
+                        <Tag color={status === 'Succeeded' ? 'green' : status === 'Failed' ? 'red' : status === 'Running' ? 'blue' : 'default'}>{status}</Tag>
+                      </Tooltip>
+                    ),
+                  },
+                  {
+                    title: '大小',
+                    dataIndex: 'size',
+                    key: 'size',
+                    width: 180,
+                    render: (size: number | undefined, record: CommitRecord) => formatImageSize(size, record.baseSize),
+                  },
+                  {
+                    title: '耗时',
+                    dataIndex: 'durationSeconds',
+                    key: 'durationSeconds',
+                    width: 90,
+                    render: (seconds?: number) => (seconds ? `${Math.floor(seconds / 60)}m${seconds % 60}s` : '-'),
+                  },
+                  {
+                    title: '提交时间',
+                    dataIndex: 'createdAt',
+                    key: 'createdAt',
+                    width: 170,
+                    render: (time: string) => dayjs(time).format('YYYY-MM-DD HH:mm:ss'),
+                  },
+                ]}
+              />
+            ) : (
+              <Text type="secondary">暂无保存记录</Text>
+            )}
+          </GlassCard>
           <GlassCard
             hover={false}
             title={`已保存的镜像${userImages.length > 0 ? ` (${userImages.length})` : ''}`}
@@ -890,6 +1207,46 @@ const PodDetail: React.FC = () => {
                     width: 180,
                     render: (pod: string) => pod || '-',
                   },
+                  {
+                    title: 'Digest',
+                    key: 'digest',
+                    width: 140,
+                    render: (_: any, record: UserSavedImage) => (
+                      record.manifest?.digest
+                        ? <Text className="mono" copyable={{ text: record.manifest.digest }}>{record.manifest.digest.slice(7, 19)}</Text>
+                        : '-'
+                    ),
+                  },
+                  {
+                    title: '大小',
+                    key: 'size',
+                    width: 100,
+                    render: (_: any, record: UserSavedImage) => formatImageSize(record.manifest?.size),
+                  },
+                  {
+                    title: '平台',
+                    key: 'platforms',
+                    width: 160,
+                    render: (_: any, record: UserSavedImage) => (
+                      record.manifest?.platforms?.length
+                        ? record.manifest.platforms.map((p) => <Tag key={p}>{p}</Tag>)
+                        : '-'
+                    ),
+                  },
+                  {
+                    title: '漏洞',
+                    key: 'vulnerabilities',
+                    width: 140,
+                    render: (_: any, record: UserSavedImage) => renderVulnerabilities(record.manifest?.vulnerabilities),
+                  },
+                  {
+                    title: '构建时间',
+                    key: 'created',
+                    width: 120,
+                    render: (_: any, record: UserSavedImage) => (
+                      record.manifest?.created ? dayjs(record.manifest.created).format('MM-DD HH:mm') : '-'
+                    ),
+                  },
                   {
                     title: '保存时间',
                     dataIndex: 'savedAt',
@@ -916,6 +1273,41 @@ const PodDetail: React.FC = () => {
         </div>
       ),
     },
+    {
+      key: 'metrics',
+      label: '资源监控',
+      children: (
+        <div className="tab-content">
+          <div className="tab-actions">
+            <Space wrap>
+              <Segmented
+                value={metricsRange}
+                options={['15m', '1h', '6h', '1d', '7d']}
+                onChange={(value) => handleMetricsRangeChange(String(value))}
+              />
+              <Button onClick={() => { void loadMetrics(); }} loading={metricsLoading} icon={<ReloadOutlined />}>刷新</Button>
+            </Space>
+          </div>
+          {metricsError && <Alert type="warning" showIcon message={metricsError} style={{ marginBottom: 16 }} />}
+          {metrics ? (
+            <Space direction="vertical" size="large" style={{ width: '100%' }}>
+              <MetricChart title="CPU（核）" points={metrics.cpu} format={(v) => v.toFixed(2)} />
+              <MetricChart title="内存" points={metrics.memory} format={(v) => `${(v / 1024 / 1024 / 1024).toFixed(2)} GiB`} color="#52c41a" />
+              {metrics.devices.map((device) => (
+                <React.Fragment key={`${device.node}/${device.deviceId}`}>
+                  <MetricChart title={`卡 ${device.deviceId} 利用率`} points={device.utilization} format={(v) => `${v.toFixed(0)}%`} max={100} color="#fa8c16" />
+                  {device.memoryUsed.length > 0 && (
+                    <MetricChart title={`卡 ${device.deviceId} 显存`} points={device.memoryUsed} format={(v) => `${(v / 1024).toFixed(1)} GiB`} color="#722ed1" />
+                  )}
+                </React.Fragment>
+              ))}
+            </Space>
+          ) : (
+            !metricsError && <Text type="secondary">{metricsLoading ? '加载中...' : '点击刷新按钮加载监控数据'}</Text>
+          )}
+        </div>
+      ),
+    },
     {
       key: 'events',
       label: '事件',
@@ -1006,6 +1398,13 @@ const PodDetail: React.FC = () => {
                       </Button>
                     </Tooltip>
                   )}
+                  {hasWebShell && (
+                    <Tooltip title="回放 Web Shell 会话录制">
+                      <Button icon={<PlayCircleOutlined />} size="large" onClick={() => navigate(`/pods/${id}/webshell/recordings`)}>
+                        会话录制
+                      </Button>
+                    </Tooltip>
+                  )}
                   {hasSSHConnection && connections?.apps?.vscodeURI && (
                     <Tooltip title="VSCode Remote SSH"><Button type="primary" icon={<CodeOutlined />} size="large" onClick={() => openVSCode(connections.apps.vscodeURI)}>VSCode</Button></Tooltip>
                   )}
@@ -1053,7 +1452,11 @@ const PodDetail: React.FC = () => {
         <GlassCard hover={false} className="detail-card animate-slide-up">
           <Tabs defaultActiveKey="overview" items={tabItems} onChange={(key) => {
             setActiveTab(key);
-            if (key === 'commit') loadUserImages();
+            if (key === 'commit') {
+              loadUserImages();
+              loadCommitHistory();
+            }
+            if (key === 'metrics' && !metrics) void loadMetrics();
           }} />
         </GlassCard>
 
@@ -1084,7 +1487,100 @@ const PodDetail: React.FC = () => {
                   : '请输入完整的镜像名称，包括仓库地址和标签'}
               </Text>
             </div>
-            <Alert message="注意" description={<ul style={{ margin: 0, paddingLeft: 20 }}><li>保存过程可能需要几分钟</li><li>确保镜像仓库配置正确且有推送权限</li></ul>} type="warning" showIcon />
+            <div>
+              <Space>
+                <Text strong>默认排除规则：</Text>
+                <Switch size="small" checked={commitUseDefaultExcludes} onChange={setCommitUseDefaultExcludes} />
+              </Space>
+              {commitDefaultExcludes.length > 0 && (
+                <div style={{ marginTop: 8 }}>
+                  {commitDefaultExcludes.map((pattern) => (
+                    <Tag key={pattern} className="mono" color={commitUseDefaultExcludes ? 'blue' : 'default'}>{pattern}</Tag>
+                  ))}
+                </div>
+              )}
+            </div>
+            <div>
+              <Text strong>额外排除路径：</Text>
+              <Input.TextArea
+                rows={2}
+                className="mono"
+                placeholder={'每行一个，如 /root/.cache/*\n**/node_modules'}
+                value={commitExcludes}
+                onChange={(e) => setCommitExcludes(e.target.value)}
+                style={{ marginTop: 8 }}
+              />
+              <Text type="secondary" style={{ display: 'block', marginTop: 4 }}>
+                排除在临时容器中进行，不影响正在运行的 Pod；排除需要开启合并提交层（squash），未开启时默认规则会被跳过
+              </Text>
+            </div>
+            <Space>
+              <Text strong>合并提交层（squash）：</Text>
+              <Switch size="small" checked={commitSquash} onChange={setCommitSquash} />
+            </Space>
+            <div>
+              <Text strong>预提交清理脚本（可选）：</Text>
+              <Input.TextArea
+                rows={3}
+                className="mono"
+                placeholder={'在当前容器内执行，如\npip cache purge\napt-get clean'}
+                value={commitHook}
+                onChange={(e) => setCommitHook(e.target.value)}
+                style={{ marginTop: 8 }}
+              />
+            </div>
+            <Alert message="注意" description={<ul style={{ margin: 0, paddingLeft: 20 }}><li>保存过程可能需要几分钟</li><li>确保镜像仓库配置正确且有推送权限</li><li>预提交脚本会直接修改正在运行的容器</li></ul>} type="warning" showIcon />
+          </Space>
+        </Modal>
+
+        <Modal title="Dockerfile 构建镜像" open={buildModalVisible} onCancel={() => setBuildModalVisible(false)} onOk={handleBuild} confirmLoading={buildSubmitting} okText="开始构建" cancelText="取消">
+          <Space direction="vertical" style={{ width: '100%' }} size="middle">
+            <div>
+              <Text strong>目标镜像名称：</Text>
+              <Input
+                addonBefore={registryUrl ? `${registryUrl}/` : undefined}
+                placeholder={registryUrl ? 'myimage:v1.0' : 'registry.example.com/namespace/image:tag'}
+                value={buildImageName}
+                onChange={(e) => setBuildImageName(e.target.value)}
+                style={{ marginTop: 8 }}
+              />
+            </div>
+            <div>
+              <Text strong>Dockerfile：</Text>
+              <Radio.Group value={buildDockerfileMode} onChange={(e) => setBuildDockerfileMode(e.target.value)} style={{ display: 'block', marginTop: 8 }}>
+                <Radio value="path">工作空间中的文件</Radio>
+                <Radio value="inline">直接输入</Radio>
+              </Radio.Group>
+              {buildDockerfileMode === 'path' ? (
+                <Input
+                  placeholder="/workspace/project/Dockerfile"
+                  value={buildDockerfilePath}
+                  onChange={(e) => setBuildDockerfilePath(e.target.value)}
+                  style={{ marginTop: 8 }}
+                />
+              ) : (
+                <Input.TextArea
+                  className="mono"
+                  rows={8}
+                  placeholder={'FROM pytorch/pytorch:2.1.0-cuda12.1-cudnn8-runtime\nCOPY . /app'}
+                  value={buildDockerfile}
+                  onChange={(e) => setBuildDockerfile(e.target.value)}
+                  style={{ marginTop: 8 }}
+                />
+              )}
+            </div>
+            <div>
+              <Text strong>构建上下文目录：</Text>
+              <Input
+                placeholder={buildDockerfileMode === 'path' ? '默认为 Dockerfile 所在目录' : '/workspace/project'}
+                value={buildContext}
+                onChange={(e) => setBuildContext(e.target.value)}
+                style={{ marginTop: 8 }}
+              />
+              <Text type="secondary" style={{ display: 'block', marginTop: 4 }}>
+                路径为 Pod 内的绝对路径，且必须位于工作空间存储卷内
+              </Text>
+            </div>
           </Space>
         </Modal>
       </Content>
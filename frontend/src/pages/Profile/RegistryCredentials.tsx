@@ -0,0 +1,112 @@
+import { DeleteOutlined, PlusOutlined } from '@ant-design/icons';
+import { Button, Checkbox, Form, Input, Popconfirm, Space, Table, Typography, message } from 'antd';
+import React, { useEffect, useState } from 'react';
+import {
+  deleteRegistryCredential,
+  getRegistryCredentials,
+  RegistryCredential,
+  saveRegistryCredential,
+  SaveRegistryCredentialRequest,
+} from '../../services/api';
+
+const { Text } = Typography;
+
+export const RegistryCredentialsPanel: React.FC = () => {
+  const [form] = Form.useForm<SaveRegistryCredentialRequest>();
+  const [credentials, setCredentials] = useState<RegistryCredential[]>([]);
+  const [loading, setLoading] = useState(false);
+  const [saving, setSaving] = useState(false);
+
+  const loadCredentials = async () => {
+    setLoading(true);
+    try {
+      const data = await getRegistryCredentials();
+      setCredentials(data.credentials || []);
+    } catch (err: any) {
+      message.error(`加载仓库凭据失败: ${err.response?.data?.error || err.message}`);
+    } finally {
+      setLoading(false);
+    }
+  };
+
+  useEffect(() => {
+    void loadCredentials();
+  }, []);
+
+  const handleSave = async (values: SaveRegistryCredentialRequest) => {
+    setSaving(true);
+    try {
+      const saved = await saveRegistryCredential(values);
+      message.success(`已保存 ${saved.registry} 的凭据`);
+      form.resetFields();
+      await loadCredentials();
+    } catch (err: any) {
+      message.error(err.response?.data?.error || err.message);
+    } finally {
+      setSaving(false);
+    }
+  };
+
+  const handleDelete = async (item: RegistryCredential) => {
+    try {
+      await deleteRegistryCredential(item.name);
+      message.success(`已删除 ${item.registry} 的凭据`);
+      await loadCredentials();
+    } catch (err: any) {
+      message.error(`删除失败: ${err.response?.data?.error || err.message}`);
+    }
+  };
+
+  const columns = [
+    { title: '仓库', dataIndex: 'registry', key: 'registry', render: (value: string) => <Text code>{value}</Text> },
+    { title: '用户名', dataIndex: 'username', key: 'username' },
+    { title: '更新时间', dataIndex: 'updatedAt', key: 'updatedAt', render: (value: string) => new Date(value).toLocaleString() },
+    {
+      title: '操作',
+      key: 'action',
+      render: (_: unknown, item: RegistryCredential) => (
+        <Popconfirm title="确定删除该凭据？已创建的工作负载不受影响" onConfirm={() => handleDelete(item)}>
+          <Button size="small" danger icon={<DeleteOutlined />}>删除</Button>
+        </Popconfirm>
+      ),
+    },
+  ];
+
+  return (
+    <div className="registry-credentials-panel">
+      <Text type="secondary">
+        保存后新建的 Pod、Deployment、StatefulSet 和 Open API Job 会自动使用这些凭据拉取私有镜像，保存前会向仓库校验用户名和密码。
+      </Text>
+      <Form form={form} layout="inline" onFinish={handleSave} style={{ margin: '16px 0' }}>
+        <Form.Item name="registry" rules={[{ required: true, message: '请输入仓库地址' }]}>
+          <Input placeholder="仓库地址，如 ghcr.io" style={{ width: 220 }} />
+        </Form.Item>
+        <Form.Item name="username" rules={[{ required: true, message: '请输入用户名' }]}>
+          <Input placeholder="用户名" autoComplete="off" style={{ width: 160 }} />
+        </Form.Item>
+        <Form.Item name="password" rules={[{ required: true, message: '请输入密码或 Token' }]}>
+          <Input.Password placeholder="密码或 Access Token" autoComplete="new-password" style={{ width: 200 }} />
+        </Form.Item>
+        <Form.Item name="insecure" valuePropName="checked">
+          <Checkbox>HTTP / 自签名证书</Checkbox>
+        </Form.Item>
+        <Form.Item>
+          <Space>
+            <Button type="primary" htmlType="submit" icon={<PlusOutlined />} loading={saving}>校验并保存</Button>
+          </Space>
+        </Form.Item>
+      </Form>
+      <Table
+        rowKey="name"
+        size="small"
+        loading={loading}
+        columns={columns}
+        dataSource={credentials}
+        pagination={false}
+        locale={{ emptyText: '尚未添加私有仓库凭据' }}
+      />
+    </div>
+  );
+};
+
+export default RegistryCredentialsPanel;
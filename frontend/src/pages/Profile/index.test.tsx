@@ -4,7 +4,7 @@ import type { MockedFunction } from 'jest-mock';
 import { createRoot, Root } from 'react-dom/client';
 import { MemoryRouter } from 'react-router-dom';
 import ProfilePage from './index';
-import { getAuthStatus } from '../../services/api';
+import { getAuthStatus, getRegistryCredentials } from '../../services/api';
 
 declare const jest: typeof import('@jest/globals').jest;
 
@@ -19,10 +19,14 @@ jest.mock('../../services/api', () => {
   const { fn } = require('jest-mock');
   return {
     getAuthStatus: fn(),
+    getRegistryCredentials: fn(),
+    saveRegistryCredential: fn(),
+    deleteRegistryCredential: fn(),
   };
 });
 
 const mockedGetAuthStatus = getAuthStatus as MockedFunction<typeof getAuthStatus>;
+const mockedGetRegistryCredentials = getRegistryCredentials as MockedFunction<typeof getRegistryCredentials>;
 
 describe('ProfilePage', () => {
   let container: HTMLDivElement;
@@ -50,6 +54,9 @@ describe('ProfilePage', () => {
       isAdmin: false,
       poolType: 'exclusive',
     } as any);
+    mockedGetRegistryCredentials.mockResolvedValue({
+      credentials: [{ name: 'registry-cred-ghcr-io', registry: 'ghcr.io', username: 'alice-bot', updatedAt: '2026-10-01T00:00:00Z' }],
+    });
     container = document.createElement('div');
     document.body.appendChild(container);
     root = createRoot(container);
@@ -80,4 +87,24 @@ describe('ProfilePage', () => {
     expect(container.textContent).toContain('alice');
     expect(container.textContent).toContain('exclusive');
   });
+
+  it('lists registered registry credentials without passwords', async () => {
+    await act(async () => {
+      root.render(
+        <MemoryRouter>
+          <ProfilePage />
+        </MemoryRouter>,
+      );
+    });
+
+    await act(async () => {
+      await Promise.resolve();
+      await Promise.resolve();
+    });
+
+    expect(mockedGetRegistryCredentials).toHaveBeenCalled();
+    expect(container.textContent).toContain('私有仓库凭据');
+    expect(container.textContent).toContain('ghcr.io');
+    expect(container.textContent).toContain('alice-bot');
+  });
 });
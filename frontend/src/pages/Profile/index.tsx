@@ -5,6 +5,7 @@ import { useNavigate } from 'react-router-dom';
 import GlassCard from '../../components/GlassCard';
 import ThemeToggle from '../../components/ThemeToggle';
 import { AuthStatus, getAuthStatus } from '../../services/api';
+import RegistryCredentialsPanel from './RegistryCredentials';
 import './index.css';
 
 const { Header, Content } = Layout;
@@ -36,7 +37,7 @@ const ProfilePage: React.FC = () => {
           </Button>
           <div>
             <h2>个人详情</h2>
-            <Text type="secondary">查看当前登录身份与卡池归属</Text>
+            <Text type="secondary">查看当前登录身份与卡池归属，管理私有仓库凭据</Text>
           </div>
         </div>
         <ThemeToggle />
@@ -71,6 +72,10 @@ const ProfilePage: React.FC = () => {
             </Descriptions.Item>
           </Descriptions>
         </GlassCard>
+
+        <GlassCard hover={false} title="私有仓库凭据">
+          <RegistryCredentialsPanel />
+        </GlassCard>
       </Content>
     </Layout>
   );
@@ -0,0 +1,153 @@
+// @ts-nocheck
+import React, { act } from 'react';
+import { afterEach, beforeEach, describe, expect, it } from '@jest/globals';
+import { createRoot } from 'react-dom/client';
+import { MemoryRouter, Route, Routes } from 'react-router-dom';
+import SharedWebShellPage from './index';
+import { getWebShellShare, getWebShellShareWebSocketURL } from '../../services/api';
+
+const mockTerminalState = {
+  options: undefined,
+  write: jest.fn(),
+  onDataCallback: undefined,
+};
+
+jest.mock('@xterm/xterm', () => {
+  const { fn } = require('jest-mock');
+  return {
+    Terminal: fn().mockImplementation((options) => {
+      mockTerminalState.options = options;
+      return {
+        open: fn(),
+        loadAddon: fn(),
+        focus: fn(),
+        write: mockTerminalState.write,
+        dispose: fn(),
+        onData: (cb) => {
+          mockTerminalState.onDataCallback = cb;
+          return { dispose: fn() };
+        },
+      };
+    }),
+  };
+});
+
+jest.mock('@xterm/addon-fit', () => {
+  const { fn } = require('jest-mock');
+  return {
+    FitAddon: fn().mockImplementation(() => ({ fit: fn() })),
+  };
+});
+
+jest.mock('../../components/GlassCard', () => (props) => <div>{props.children}</div>);
+jest.mock('../../components/ThemeToggle', () => () => <button type="button">theme</button>);
+
+jest.mock('../../services/api', () => {
+  const { fn } = require('jest-mock');
+  return {
+    getWebShellShare: fn(),
+    getWebShellShareWebSocketURL: fn(),
+  };
+});
+
+class MockWebSocket {
+  constructor(url) {
+    this.url = url;
+    this.readyState = 1;
+    this.send = jest.fn();
+    this.close = jest.fn();
+    MockWebSocket.instances.push(this);
+  }
+}
+MockWebSocket.OPEN = 1;
+MockWebSocket.instances = [];
+
+describe('SharedWebShellPage', () => {
+  let container;
+  let root;
+  const originalWebSocket = global.WebSocket;
+
+  beforeEach(() => {
+    globalThis.IS_REACT_ACT_ENVIRONMENT = true;
+    Object.defineProperty(window, 'matchMedia', {
+      writable: true,
+      value: (query) => ({
+        matches: false,
+        media: query,
+        addListener: () => undefined,
+        removeListener: () => undefined,
+        addEventListener: () => undefined,
+        removeEventListener: () => undefined,
+        dispatchEvent: () => false,
+      }),
+    });
+    global.WebSocket = MockWebSocket;
+    MockWebSocket.instances = [];
+    getWebShellShareWebSocketURL.mockReturnValue('/api/webshell/shares/token-1/ws');
+    container = document.createElement('div');
+    document.body.appendChild(container);
+    root = createRoot(container);
+  });
+
+  afterEach(async () => {
+    await act(async () => {
+      root.unmount();
+    });
+    document.body.removeChild(container);
+    global.WebSocket = originalWebSocket;
+    jest.clearAllMocks();
+  });
+
+  const renderPage = async () => {
+    await act(async () => {
+      root.render(
+        <MemoryRouter initialEntries={['/webshell/shared/token-1']}>
+          <Routes>
+            <Route path="/webshell/shared/:token" element={<SharedWebShellPage />} />
+          </Routes>
+        </MemoryRouter>,
+      );
+    });
+    await act(async () => {
+      await Promise.resolve();
+    });
+  };
+
+  it('joins a read-only share without sending input', async () => {
+    getWebShellShare.mockResolvedValue({
+      token: 'token-1',
+      sessionId: 'session-1',
+      podId: 'pod-alice-dev',
+      owner: 'alice',
+      mode: 'ro',
+      createdAt: '2026-03-15T09:30:00Z',
+      expiresAt: '2026-03-15T10:30:00Z',
+    });
+
+    await renderPage();
+
+    expect(getWebShellShare).toHaveBeenCalledWith('token-1');
+    expect(MockWebSocket.instances).toHaveLength(1);
+    expect(MockWebSocket.instances[0].url).toContain('/api/webshell/shares/token-1/ws');
+    expect(mockTerminalState.options.disableStdin).toBe(true);
+    expect(container.textContent).toContain('只读');
+
+    await act(async () => {
+      MockWebSocket.instances[0].onopen();
+      MockWebSocket.instances[0].onmessage({ data: new ArrayBuffer(2) });
+      mockTerminalState.onDataCallback('ls\n');
+    });
+
+    expect(mockTerminalState.write).toHaveBeenCalled();
+    expect(MockWebSocket.instances[0].send).not.toHaveBeenCalled();
+  });
+
+  it('shows an error for an expired share', async () => {
+    getWebShellShare.mockRejectedValue(new Error('分享链接无效、已过期或会话已结束'));
+
+    await renderPage();
+
+    expect(MockWebSocket.instances).toHaveLength(0);
+    expect(container.textContent).toContain('分享链接无效、已过期或会话已结束');
+  });
+});
@@ -0,0 +1,146 @@
+import { ArrowLeftOutlined } from '@ant-design/icons';
+import { Alert, Button, Layout, Space, Tag, Typography } from 'antd';
+import { FitAddon } from '@xterm/addon-fit';
+import { Terminal } from '@xterm/xterm';
+import '@xterm/xterm/css/xterm.css';
+import dayjs from 'dayjs';
+import React, { useEffect, useRef, useState } from 'react';
+import { useNavigate, useParams } from 'react-router-dom';
+import GlassCard from '../../components/GlassCard';
+import ThemeToggle from '../../components/ThemeToggle';
+import { getWebShellShare, getWebShellShareWebSocketURL, WebShellShare } from '../../services/api';
+import '../WebShell/index.css';
+
+const { Header, Content } = Layout;
+const { Text } = Typography;
+
+type ConnectionState = 'connecting' | 'connected' | 'disconnected' | 'error';
+
+const toWebSocketURL = (value: string) => {
+  const baseURL = new URL(value, window.location.origin);
+  const protocol = baseURL.protocol === 'https:' ? 'wss:' : 'ws:';
+  return `${protocol}//${baseURL.host}${baseURL.pathname}${baseURL.search}`;
+};
+
+// SharedWebShellPage 通过分享链接加入他人的 Web Shell 会话；终端尺寸由会话所有者决定
+const SharedWebShellPage: React.FC = () => {
+  const { token } = useParams<{ token: string }>();
+  const navigate = useNavigate();
+  const terminalContainerRef = useRef<HTMLDivElement | null>(null);
+  const [share, setShare] = useState<WebShellShare | null>(null);
+  const [connectionState, setConnectionState] = useState<ConnectionState>('connecting');
+  const [statusText, setStatusText] = useState('正在加载分享信息...');
+
+  useEffect(() => {
+    if (!token) {
+      setConnectionState('error');
+      setStatusText('分享链接无效。');
+      return;
+    }
+    let cancelled = false;
+    getWebShellShare(token)
+      .then((resp) => {
+        if (!cancelled) {
+          setShare(resp);
+        }
+      })
+      .catch((error: any) => {
+        if (!cancelled) {
+          setConnectionState('error');
+          setStatusText(error.message || '分享链接无效、已过期或会话已结束');
+        }
+      });
+    return () => {
+      cancelled = true;
+    };
+  }, [token]);
+
+  useEffect(() => {
+    if (!token || !share || !terminalContainerRef.current) {
+      return;
+    }
+    const readOnly = share.mode !== 'rw';
+    const terminal = new Terminal({
+      cursorBlink: !readOnly,
+      disableStdin: readOnly,
+      fontFamily: 'SFMono-Regular, Consolas, Liberation Mono, Menlo, monospace',
+      fontSize: 14,
+      lineHeight: 1.3,
+      theme: {
+        background: '#111827',
+      },
+    });
+    const fitAddon = new FitAddon();
+    terminal.loadAddon(fitAddon);
+    terminal.open(terminalContainerRef.current);
+    fitAddon.fit();
+
+    const socket = new WebSocket(toWebSocketURL(getWebShellShareWebSocketURL(token)));
+    socket.binaryType = 'arraybuffer';
+    socket.onopen = () => {
+      setConnectionState('connected');
+      setStatusText(readOnly ? `正在只读观看 ${share.owner} 的会话` : `已加入 ${share.owner} 的会话，输入将发送到共享终端`);
+      if (!readOnly) {
+        terminal.focus();
+      }
+    };
+    socket.onmessage = (event) => {
+      terminal.write(typeof event.data === 'string' ? event.data : new Uint8Array(event.data));
+    };
+    socket.onclose = () => {
+      setConnectionState('disconnected');
+      setStatusText('会话已结束或连接已断开。');
+    };
+
+    const dataDisposable = terminal.onData((data) => {
+      if (!readOnly && socket.readyState === WebSocket.OPEN) {
+        socket.send(new Blob([data]));
+      }
+    });
+
+    return () => {
+      dataDisposable.dispose();
+      socket.onclose = null;
+      socket.close();
+      terminal.dispose();
+    };
+  }, [token, share]);
+
+  const statusType =
+    connectionState === 'connected'
+      ? 'success'
+      : connectionState === 'error'
+        ? 'error'
+        : connectionState === 'disconnected'
+          ? 'warning'
+          : 'info';
+
+  return (
+    <Layout className="web-shell-layout">
+      <Header className="web-shell-header glass-header">
+        <Space size="middle">
+          <Button icon={<ArrowLeftOutlined />} onClick={() => navigate('/')} className="glass-button">
+            返回
+          </Button>
+          <div className="web-shell-title">
+            <h2>共享 Web Shell</h2>
+            <Text className="subtitle">{share ? `${share.podId} · ${share.owner}` : '-'}</Text>
+          </div>
+        </Space>
+        <Space size="middle">
+          {share && <Tag color={share.mode === 'rw' ? 'orange' : 'blue'}>{share.mode === 'rw' ? '读写' : '只读'}</Tag>}
+          {share && <Text type="secondary">有效期至 {dayjs(share.expiresAt).format('MM-DD HH:mm')}</Text>}
+          <ThemeToggle />
+        </Space>
+      </Header>
+      <Content className="web-shell-content">
+        <GlassCard hover={false} className="web-shell-card">
+          <Alert className="web-shell-status" message={statusText} type={statusType} showIcon />
+          {share && <div className="web-shell-terminal" ref={terminalContainerRef} />}
+        </GlassCard>
+      </Content>
+    </Layout>
+  );
+};
+
+export default SharedWebShellPage;
@@ -55,8 +55,14 @@ jest.mock('../../services/api', () => {
   const { fn } = require('jest-mock');
   return {
     createWebShellSession: fn(),
+    createWebShellShare: fn(),
     deleteWebShellSession: fn(),
+    getDeployment: fn(),
     getPod: fn(),
+    getStatefulSet: fn(),
+    getWebShellOptions: fn(),
+    listWebShellParticipants: fn(),
+    listWebShellSessions: fn(),
   };
 });
 
@@ -127,6 +133,12 @@ describe('WebShellPage', () => {
       expiresAt: '2026-03-13T11:00:00Z',
     });
     deleteWebShellSession.mockResolvedValue({ message: 'closed' });
+    getWebShellOptions.mockResolvedValue({
+      containers: [{ name: 'workspace', image: 'cuda', running: true, main: true }],
+      defaultContainer: 'workspace',
+      shells: ['/bin/bash', '/bin/sh'],
+      workloadKind: 'pod',
+    });
     getPod.mockResolvedValue({
       id: 'pod-alice-dev',
       name: 'pod-alice-dev',
@@ -206,6 +218,74 @@ describe('WebShellPage', () => {
     expect(deleteWebShellSession).toHaveBeenCalledWith('pod-alice-dev', 'session-1');
   });
 
+  it('reattaches a persistent session after reload and keeps it on unmount', async () => {
+    window.sessionStorage.setItem('genet.webshell.pod-alice-dev', 'session-9');
+    createWebShellSession.mockResolvedValueOnce({
+      sessionId: 'session-9',
+      webSocketURL: '/api/pods/pod-alice-dev/webshell/sessions/session-9/ws',
+      container: 'workspace',
+      shell: 'tmux/screen, /bin/bash (fallback /bin/sh)',
+      cols: 120,
+      rows: 40,
+      expiresAt: '2026-03-13T11:00:00Z',
+      persistent: true,
+      reattached: true,
+    });
+
+    await act(async () => {
+      root.render(
+        <MemoryRouter initialEntries={['/pods/pod-alice-dev/webshell']}>
+          <Routes>
+            <Route path="/pods/:id/webshell" element={<WebShellPage />} />
+          </Routes>
+        </MemoryRouter>,
+      );
+    });
+
+    await flushEffects();
+
+    expect(createWebShellSession).toHaveBeenCalledWith('pod-alice-dev', { cols: 120, rows: 40, sessionId: 'session-9' });
+    expect(container.textContent).toContain('已恢复会话');
+
+    await act(async () => {
+      root.unmount();
+    });
+
+    expect(deleteWebShellSession).not.toHaveBeenCalled();
+    expect(window.sessionStorage.getItem('genet.webshell.pod-alice-dev')).toBe('session-9');
+    window.sessionStorage.clear();
+    root = createRoot(container);
+  });
+
+  it('opens a replica sidecar with the requested shell', async () => {
+    getStatefulSet.mockResolvedValue({ pods: [{ name: 'train-0' }, { name: 'train-1' }] });
+
+    await act(async () => {
+      root.render(
+        <MemoryRouter
+          initialEntries={['/pods/train-1/webshell?workloadKind=statefulset&workloadName=train&container=sidecar&shell=%2Fbin%2Fsh']}
+        >
+          <Routes>
+            <Route path="/pods/:id/webshell" element={<WebShellPage />} />
+          </Routes>
+        </MemoryRouter>,
+      );
+    });
+
+    await flushEffects();
+
+    expect(getWebShellOptions).toHaveBeenCalledWith('train-1', { workloadKind: 'statefulset', workloadName: 'train' });
+    expect(getStatefulSet).toHaveBeenCalledWith('train');
+    expect(createWebShellSession).toHaveBeenCalledWith('train-1', {
+      cols: 120,
+      rows: 40,
+      container: 'sidecar',
+      shell: '/bin/sh',
+      workloadKind: 'statefulset',
+      workloadName: 'train',
+    });
+  });
+
   it('retries failed sessions before showing the terminal', async () => {
     createWebShellSession
       .mockRejectedValueOnce(new Error('连接关闭'))
@@ -1,15 +1,30 @@
-import { ArrowLeftOutlined, ReloadOutlined } from '@ant-design/icons';
-import { Alert, Button, Layout, Space, Tag, Typography, message } from 'antd';
+import { ArrowLeftOutlined, HistoryOutlined, PoweroffOutlined, ReloadOutlined, ShareAltOutlined } from '@ant-design/icons';
+import { Alert, Button, Dropdown, Input, Layout, List, Modal, Popconfirm, Select, Space, Tag, Typography, message } from 'antd';
 import { FitAddon } from '@xterm/addon-fit';
 import { Terminal } from '@xterm/xterm';
 import '@xterm/xterm/css/xterm.css';
 import dayjs from 'dayjs';
 import React, { useEffect, useRef, useState } from 'react';
-import { useNavigate, useParams } from 'react-router-dom';
+import { useNavigate, useParams, useSearchParams } from 'react-router-dom';
 import GlassCard from '../../components/GlassCard';
 import StatusBadge from '../../components/StatusBadge';
 import ThemeToggle from '../../components/ThemeToggle';
-import { createWebShellSession, deleteWebShellSession, getPod } from '../../services/api';
+import {
+  createWebShellSession,
+  createWebShellShare,
+  deleteWebShellSession,
+  getDeployment,
+  getPod,
+  getStatefulSet,
+  getWebShellOptions,
+  listWebShellParticipants,
+  listWebShellSessions,
+  WebShellOptions,
+  WebShellSessionInfo,
+  WebShellParticipant,
+  WebShellShareMode,
+  WebShellShareResponse,
+} from '../../services/api';
 import './index.css';
 
 const { Header, Content } = Layout;
@@ -23,6 +38,29 @@ const CONNECT_STABLE_DELAY_MS = 300;
 
 type ConnectionState = 'connecting' | 'connected' | 'disconnected' | 'error';
 
+// 记录每个 Pod 最近一次的持久会话，刷新页面后自动重新连接
+const sessionStorageKey = (podID: string) => `genet.webshell.${podID}`;
+
+const readStoredSession = (podID: string) => {
+  try {
+    return window.sessionStorage.getItem(sessionStorageKey(podID)) || undefined;
+  } catch {
+    return undefined;
+  }
+};
+
+const writeStoredSession = (podID: string, sessionId: string | null) => {
+  try {
+    if (sessionId) {
+      window.sessionStorage.setItem(sessionStorageKey(podID), sessionId);
+    } else {
+      window.sessionStorage.removeItem(sessionStorageKey(podID));
+    }
+  } catch {
+    // sessionStorage 不可用时仅失去自动重连
+  }
+};
+
 interface WebShellPodSummary {
   name: string;
   status: string;
@@ -62,6 +100,12 @@ const decodeOutput = async (payload: Blob | ArrayBuffer | Uint8Array | string) =
 
 const WebShellPage: React.FC = () => {
   const { id } = useParams<{ id: string }>();
+  const [searchParams] = useSearchParams();
+  const requestedSessionId = searchParams.get('session') || undefined;
+  const requestedContainer = searchParams.get('container') || undefined;
+  const requestedShell = searchParams.get('shell') || undefined;
+  const workloadKind = searchParams.get('workloadKind') || undefined;
+  const workloadName = searchParams.get('workloadName') || undefined;
   const navigate = useNavigate();
   const terminalContainerRef = useRef<HTMLDivElement | null>(null);
   const terminalRef = useRef<Terminal | null>(null);
@@ -72,12 +116,22 @@ const WebShellPage: React.FC = () => {
   const socketRef = useRef<WebSocket | null>(null);
   const sessionIdRef = useRef<string | null>(null);
   const sessionClosedRef = useRef(false);
+  const persistentRef = useRef(false);
   const reconnectTimerRef = useRef<number | null>(null);
   const outputBufferRef = useRef<Array<string | Uint8Array>>([]);
   const [connectionState, setConnectionState] = useState<ConnectionState>('connecting');
   const [statusText, setStatusText] = useState('正在创建终端会话...');
   const [podSummary, setPodSummary] = useState<WebShellPodSummary | null>(null);
   const [showTerminal, setShowTerminal] = useState(false);
+  const [recording, setRecording] = useState(false);
+  const [share, setShare] = useState<WebShellShareResponse | null>(null);
+  const [participants, setParticipants] = useState<WebShellParticipant[]>([]);
+  const [persistent, setPersistent] = useState(false);
+  const [reattached, setReattached] = useState(false);
+  const [sessions, setSessions] = useState<WebShellSessionInfo[]>([]);
+  const [sessionNonce, setSessionNonce] = useState(0);
+  const [options, setOptions] = useState<WebShellOptions | null>(null);
+  const [replicas, setReplicas] = useState<string[]>([]);
   const handleBack = () => {
     navigate(id ? `/pods/${id}` : '/');
   };
@@ -240,6 +294,41 @@ const WebShellPage: React.FC = () => {
     };
   }, [id]);
 
+  useEffect(() => {
+    if (!id) {
+      return;
+    }
+    let cancelled = false;
+    getWebShellOptions(id, workloadKind ? { workloadKind, workloadName } : undefined)
+      .then((resp) => {
+        if (!cancelled) {
+          setOptions(resp);
+        }
+      })
+      .catch(() => {
+        if (!cancelled) {
+          setOptions(null);
+        }
+      });
+    if (workloadKind && workloadName) {
+      const loadWorkload = workloadKind === 'statefulset' ? getStatefulSet : getDeployment;
+      loadWorkload(workloadName)
+        .then((workload: any) => {
+          if (!cancelled) {
+            setReplicas((workload.pods || []).map((pod: any) => pod.name));
+          }
+        })
+        .catch(() => {
+          if (!cancelled) {
+            setReplicas([]);
+          }
+        });
+    }
+    return () => {
+      cancelled = true;
+    };
+  }, [id, workloadKind, workloadName]);
+
   useEffect(() => {
     if (!id) {
       setConnectionState('error');
@@ -269,7 +358,8 @@ const WebShellPage: React.FC = () => {
     };
 
     const releaseSession = async (sessionId: string | null) => {
-      if (!id || !sessionId) {
+      // 持久会话保留在容器内，下次重试时重新连接
+      if (!id || !sessionId || persistentRef.current) {
         return;
       }
       try {
@@ -365,6 +455,7 @@ const WebShellPage: React.FC = () => {
 
     const openSession = async () => {
       let lastError = '终端连接失败，请稍后重试。';
+      let reattachSessionId = requestedSessionId || readStoredSession(id);
       setConnectionState('connecting');
 
       for (let attempt = 1; attempt <= MAX_CONNECT_ATTEMPTS; attempt += 1) {
@@ -376,6 +467,10 @@ const WebShellPage: React.FC = () => {
           const session = await createWebShellSession(id, {
             cols: DEFAULT_COLS,
             rows: DEFAULT_ROWS,
+            ...(reattachSessionId ? { sessionId: reattachSessionId } : {}),
+            ...(requestedContainer ? { container: requestedContainer } : {}),
+            ...(requestedShell ? { shell: requestedShell } : {}),
+            ...(workloadKind ? { workloadKind, workloadName } : {}),
           });
 
           if (cancelled) {
@@ -384,6 +479,12 @@ const WebShellPage: React.FC = () => {
 
           sessionId = session.sessionId;
           sessionIdRef.current = session.sessionId;
+          persistentRef.current = Boolean(session.persistent);
+          reattachSessionId = session.persistent ? session.sessionId : undefined;
+          writeStoredSession(id, session.persistent ? session.sessionId : null);
+          setRecording(Boolean(session.recording));
+          setPersistent(Boolean(session.persistent));
+          setReattached(Boolean(session.reattached));
           setStatusText(`正在连接 ${session.container} 容器（第 ${attempt}/${MAX_CONNECT_ATTEMPTS} 次）...`);
 
           const connected = await connectSocket(session, attempt);
@@ -397,6 +498,11 @@ const WebShellPage: React.FC = () => {
             return;
           }
           lastError = error.message || '创建终端会话失败';
+          if (reattachSessionId) {
+            // 记录的会话已失效，改为新建
+            reattachSessionId = undefined;
+            writeStoredSession(id, null);
+          }
         }
 
         await releaseSession(sessionId);
@@ -425,12 +531,114 @@ const WebShellPage: React.FC = () => {
       clearReconnectTimer();
       socketRef.current?.close();
       socketRef.current = null;
-      if (sessionIdRef.current) {
+      if (sessionIdRef.current && !persistentRef.current) {
         deleteWebShellSession(id, sessionIdRef.current).catch(() => undefined);
-        sessionIdRef.current = null;
       }
+      sessionIdRef.current = null;
     };
-  }, [id]);
+  }, [id, requestedSessionId, requestedContainer, requestedShell, workloadKind, workloadName, sessionNonce]);
+
+  // 切换副本、容器或 Shell 时打开新会话；原持久会话保留在容器内
+  const openTarget = (podID: string, overrides: { container?: string; shell?: string }) => {
+    const params = new URLSearchParams();
+    if (workloadKind && workloadName) {
+      params.set('workloadKind', workloadKind);
+      params.set('workloadName', workloadName);
+    }
+    const container = 'container' in overrides ? overrides.container : requestedContainer;
+    const shell = 'shell' in overrides ? overrides.shell : requestedShell;
+    if (container) {
+      params.set('container', container);
+    }
+    if (shell) {
+      params.set('shell', shell);
+    }
+    writeStoredSession(podID, null);
+    const query = params.toString();
+    navigate(`/pods/${encodeURIComponent(podID)}/webshell${query ? `?${query}` : ''}`);
+    setSessionNonce((value) => value + 1);
+  };
+
+  const loadSessions = async () => {
+    if (!id) {
+      return;
+    }
+    try {
+      const resp = await listWebShellSessions(id);
+      setSessions(resp.sessions || []);
+    } catch {
+      setSessions([]);
+    }
+  };
+
+  const switchSession = (sessionId?: string) => {
+    if (!id) {
+      return;
+    }
+    if (!sessionId) {
+      // 新建会话：清除记录，避免自动重连到当前会话；当前会话保留在容器内
+      writeStoredSession(id, null);
+      navigate(`/pods/${id}/webshell`, { replace: true });
+      setSessionNonce((value) => value + 1);
+      return;
+    }
+    navigate(`/pods/${id}/webshell?session=${encodeURIComponent(sessionId)}`);
+  };
+
+  const handleEndSession = async () => {
+    if (!id || !sessionIdRef.current) {
+      return;
+    }
+    const sessionId = sessionIdRef.current;
+    sessionClosedRef.current = true;
+    try {
+      await deleteWebShellSession(id, sessionId);
+      writeStoredSession(id, null);
+      sessionIdRef.current = null;
+      socketRef.current?.close();
+      setConnectionState('disconnected');
+      setStatusText('终端会话已结束。');
+    } catch (error: any) {
+      sessionClosedRef.current = false;
+      message.error(error.message || '结束会话失败');
+    }
+  };
+
+  const loadParticipants = async () => {
+    if (!id || !sessionIdRef.current) {
+      return;
+    }
+    try {
+      const resp = await listWebShellParticipants(id, sessionIdRef.current);
+      setParticipants(resp.participants || []);
+    } catch {
+      setParticipants([]);
+    }
+  };
+
+  const handleShare = async (mode: WebShellShareMode) => {
+    if (!id || !sessionIdRef.current) {
+      return;
+    }
+    try {
+      const resp = await createWebShellShare(id, sessionIdRef.current, { mode });
+      setShare(resp);
+      loadParticipants();
+    } catch (error: any) {
+      message.error(error.message || '创建分享链接失败');
+    }
+  };
+
+  const shareLink = share ? `${window.location.origin}${share.url}` : '';
+
+  const copyShareLink = async () => {
+    try {
+      await navigator.clipboard.writeText(shareLink);
+      message.success('分享链接已复制');
+    } catch {
+      message.warning('复制失败，请手动复制链接');
+    }
+  };
 
   const statusType =
     connectionState === 'connected'
@@ -454,6 +662,83 @@ const WebShellPage: React.FC = () => {
           </div>
         </Space>
         <Space size="middle">
+          {replicas.length > 1 && id && (
+            <Select
+              size="small"
+              style={{ minWidth: 160 }}
+              value={id}
+              onChange={(podID) => openTarget(podID, { container: undefined })}
+              options={replicas.map((name) => ({ value: name, label: name }))}
+              aria-label="副本"
+            />
+          )}
+          {options && options.containers.length > 1 && id && (
+            <Select
+              size="small"
+              style={{ minWidth: 140 }}
+              value={requestedContainer || options.defaultContainer}
+              onChange={(container) => openTarget(id, { container })}
+              options={options.containers.map((item) => ({
+                value: item.name,
+                disabled: !item.running,
+                label: `${item.name}${item.main ? '（主容器）' : ''}${item.running ? '' : '（未运行）'}`,
+              }))}
+              aria-label="容器"
+            />
+          )}
+          {options && id && (
+            <Select
+              size="small"
+              style={{ minWidth: 120 }}
+              value={requestedShell || ''}
+              onChange={(shell) => openTarget(id, { shell: shell || undefined })}
+              options={[{ value: '', label: '自动 Shell' }, ...options.shells.map((shell) => ({ value: shell, label: shell }))]}
+              aria-label="Shell"
+            />
+          )}
+          {recording && <Tag color="red">录制中</Tag>}
+          {persistent && <Tag color="purple">{reattached ? '已恢复会话' : '持久会话'}</Tag>}
+          {persistent && (
+            <Dropdown
+              trigger={['click']}
+              onOpenChange={(open) => open && loadSessions()}
+              menu={{
+                items: [
+                  ...sessions.map((item) => ({
+                    key: item.sessionId,
+                    disabled: item.sessionId === sessionIdRef.current,
+                    label: `${item.sessionId.slice(0, 8)} · ${item.state === 'attached' ? '已连接' : '已断开'}${
+                      item.createdAt && !item.createdAt.startsWith('0001') ? ` · ${dayjs(item.createdAt).format('MM-DD HH:mm')}` : ''
+                    }`,
+                  })),
+                  { type: 'divider' as const },
+                  { key: '__new__', label: '新建会话' },
+                ],
+                onClick: ({ key }) => switchSession(key === '__new__' ? undefined : key),
+              }}
+            >
+              <Button icon={<HistoryOutlined />}>会话</Button>
+            </Dropdown>
+          )}
+          {persistent && connectionState === 'connected' && (
+            <Popconfirm title="结束会话将终止容器内的 tmux/screen 会话及其中运行的程序，确定？" onConfirm={handleEndSession}>
+              <Button danger icon={<PoweroffOutlined />}>
+                结束会话
+              </Button>
+            </Popconfirm>
+          )}
+          <Dropdown
+            disabled={connectionState !== 'connected'}
+            menu={{
+              items: [
+                { key: 'ro', label: '只读分享（仅观看）' },
+                { key: 'rw', label: '读写分享（可共同输入）' },
+              ],
+              onClick: ({ key }) => handleShare(key as WebShellShareMode),
+            }}
+          >
+            <Button icon={<ShareAltOutlined />}>分享</Button>
+          </Dropdown>
           <Tag color={connectionState === 'connected' ? 'green' : connectionState === 'error' ? 'red' : 'blue'}>
             {connectionState === 'connected' ? 'Connected' : connectionState === 'error' ? 'Error' : connectionState === 'disconnected' ? 'Closed' : 'Connecting'}
           </Tag>
@@ -520,6 +805,49 @@ const WebShellPage: React.FC = () => {
           )}
         </GlassCard>
       </Content>
+      <Modal
+        open={Boolean(share)}
+        title={share?.mode === 'rw' ? '读写分享链接' : '只读分享链接'}
+        onCancel={() => setShare(null)}
+        footer={null}
+      >
+        {share && (
+          <Space direction="vertical" style={{ width: '100%' }}>
+            <Space.Compact style={{ width: '100%' }}>
+              <Input readOnly value={shareLink} />
+              <Button type="primary" onClick={copyShareLink}>
+                复制
+              </Button>
+            </Space.Compact>
+            <Text type="secondary">
+              登录后的用户可通过该链接加入当前会话，链接将于 {dayjs(share.expiresAt).format('MM-DD HH:mm')} 失效，关闭终端后立即失效。
+            </Text>
+            <Space>
+              <Text strong>参与者</Text>
+              <Button size="small" icon={<ReloadOutlined />} onClick={loadParticipants}>
+                刷新
+              </Button>
+            </Space>
+            <List
+              size="small"
+              dataSource={participants}
+              locale={{ emptyText: '暂无访客加入' }}
+              renderItem={(item) => (
+                <List.Item>
+                  <Space>
+                    <span>{item.user}</span>
+                    <Tag color={item.mode === 'rw' ? 'orange' : 'blue'}>{item.mode === 'rw' ? '读写' : '只读'}</Tag>
+                    <Text type="secondary">
+                      {dayjs(item.joinedAt).format('HH:mm:ss')}
+                      {item.leftAt ? ` - ${dayjs(item.leftAt).format('HH:mm:ss')}` : ' 在线'}
+                    </Text>
+                  </Space>
+                </List.Item>
+              )}
+            />
+          </Space>
+        )}
+      </Modal>
     </Layout>
   );
 };
@@ -0,0 +1,41 @@
+// @ts-nocheck
+import { describe, expect, it } from '@jest/globals';
+import { parseAsciicast } from './index';
+
+jest.mock('@xterm/xterm', () => ({ Terminal: jest.fn() }));
+jest.mock('../../components/GlassCard', () => (props) => <div>{props.children}</div>);
+jest.mock('../../components/ThemeToggle', () => () => <button type="button">theme</button>);
+jest.mock('../../services/api', () => ({
+  getWebShellRecording: jest.fn(),
+  getWebShellRecordingURL: jest.fn(),
+  listWebShellRecordings: jest.fn(),
+}));
+
+describe('parseAsciicast', () => {
+  it('parses header and output/resize events', () => {
+    const cast = [
+      '{"version":2,"width":100,"height":30,"timestamp":1700000000}',
+      '[0.5,"o","$ ls\\r\\n"]',
+      '[1.25,"r","120x40"]',
+      '',
+    ].join('\n');
+
+    expect(parseAsciicast(cast)).toEqual({
+      width: 100,
+      height: 30,
+      events: [
+        { time: 0.5, type: 'o', data: '$ ls\r\n' },
+        { time: 1.25, type: 'r', data: '120x40' },
+      ],
+    });
+  });
+
+  it('ignores a truncated trailing line', () => {
+    const cast = '{"version":2,"width":80,"height":24}\n[0.1,"o","a"]\n[0.2,"o","b';
+    expect(parseAsciicast(cast).events).toHaveLength(1);
+  });
+
+  it('rejects unsupported versions', () => {
+    expect(() => parseAsciicast('{"version":1}')).toThrow('不支持的录制格式');
+  });
+});
@@ -0,0 +1,314 @@
+import { ArrowLeftOutlined, DownloadOutlined, PauseCircleOutlined, PlayCircleOutlined, ReloadOutlined } from '@ant-design/icons';
+import { Alert, Button, Empty, Layout, Select, Space, Table, Tag, Typography, message } from 'antd';
+import { Terminal } from '@xterm/xterm';
+import '@xterm/xterm/css/xterm.css';
+import dayjs from 'dayjs';
+import React, { useCallback, useEffect, useRef, useState } from 'react';
+import { useNavigate, useParams } from 'react-router-dom';
+import GlassCard from '../../components/GlassCard';
+import ThemeToggle from '../../components/ThemeToggle';
+import {
+  getWebShellRecording,
+  getWebShellRecordingURL,
+  listWebShellRecordings,
+  WebShellRecording,
+} from '../../services/api';
+import '../WebShell/index.css';
+import './index.css';
+
+const { Header, Content } = Layout;
+const { Text } = Typography;
+
+// 回放时压缩长时间无输出的间隔（与 asciinema idle_time_limit 类似）
+const IDLE_TIME_LIMIT_SECONDS = 2;
+
+export interface AsciicastEvent {
+  time: number;
+  type: string;
+  data: string;
+}
+
+export interface Asciicast {
+  width: number;
+  height: number;
+  events: AsciicastEvent[];
+}
+
+// parseAsciicast 解析 asciicast v2：首行为头，其余每行一个 [time, type, data] 事件
+export const parseAsciicast = (content: string): Asciicast => {
+  const lines = content.split('\n').filter((line) => line.trim() !== '');
+  if (lines.length === 0) {
+    throw new Error('录制文件为空');
+  }
+  const header = JSON.parse(lines[0]);
+  if (header.version !== 2) {
+    throw new Error('不支持的录制格式');
+  }
+  const events: AsciicastEvent[] = [];
+  for (const line of lines.slice(1)) {
+    try {
+      const [time, type, data] = JSON.parse(line);
+      events.push({ time: Number(time), type: String(type), data: String(data) });
+    } catch {
+      // 录制被截断时最后一行可能不完整
+    }
+  }
+  return { width: header.width || 120, height: header.height || 40, events };
+};
+
+const formatDuration = (seconds: number) => {
+  const total = Math.max(0, Math.round(seconds));
+  const minutes = Math.floor(total / 60);
+  const rest = total % 60;
+  return minutes > 0 ? `${minutes}m${rest}s` : `${rest}s`;
+};
+
+const formatSize = (bytes: number) => {
+  if (bytes >= 1024 * 1024) {
+    return `${(bytes / 1024 / 1024).toFixed(1)} MiB`;
+  }
+  if (bytes >= 1024) {
+    return `${(bytes / 1024).toFixed(1)} KiB`;
+  }
+  return `${bytes} B`;
+};
+
+const WebShellRecordingsPage: React.FC = () => {
+  const { id } = useParams<{ id: string }>();
+  const navigate = useNavigate();
+  const terminalContainerRef = useRef<HTMLDivElement | null>(null);
+  const terminalRef = useRef<Terminal | null>(null);
+  const timerRef = useRef<number | null>(null);
+  const castRef = useRef<Asciicast | null>(null);
+  const cursorRef = useRef(0);
+  const [enabled, setEnabled] = useState(true);
+  const [loading, setLoading] = useState(false);
+  const [recordings, setRecordings] = useState<WebShellRecording[]>([]);
+  const [selected, setSelected] = useState<WebShellRecording | null>(null);
+  const [playing, setPlaying] = useState(false);
+  const [speed, setSpeed] = useState(1);
+
+  const loadRecordings = useCallback(async () => {
+    if (!id) {
+      return;
+    }
+    setLoading(true);
+    try {
+      const resp = await listWebShellRecordings(id);
+      setEnabled(resp.enabled);
+      setRecordings(resp.recordings || []);
+    } catch (error: any) {
+      message.error(error.message || '加载录制列表失败');
+    } finally {
+      setLoading(false);
+    }
+  }, [id]);
+
+  useEffect(() => {
+    loadRecordings();
+  }, [loadRecordings]);
+
+  const stopTimer = () => {
+    if (timerRef.current !== null) {
+      window.clearTimeout(timerRef.current);
+      timerRef.current = null;
+    }
+  };
+
+  const scheduleNext = useCallback(() => {
+    const cast = castRef.current;
+    const terminal = terminalRef.current;
+    if (!cast || !terminal) {
+      return;
+    }
+    const index = cursorRef.current;
+    if (index >= cast.events.length) {
+      setPlaying(false);
+      return;
+    }
+    const previous = index > 0 ? cast.events[index - 1].time : 0;
+    const gap = Math.min(cast.events[index].time - previous, IDLE_TIME_LIMIT_SECONDS);
+    timerRef.current = window.setTimeout(() => {
+      const event = cast.events[index];
+      if (event.type === 'o') {
+        terminal.write(event.data);
+      } else if (event.type === 'r') {
+        const [cols, rows] = event.data.split('x').map((value) => parseInt(value, 10));
+        if (cols > 0 && rows > 0) {
+          terminal.resize(cols, rows);
+        }
+      }
+      cursorRef.current = index + 1;
+      scheduleNext();
+    }, Math.max(0, gap * 1000) / speed);
+  }, [speed]);
+
+  useEffect(() => {
+    if (playing) {
+      stopTimer();
+      scheduleNext();
+    }
+    return stopTimer;
+  }, [playing, scheduleNext]);
+
+  useEffect(() => {
+    return () => {
+      stopTimer();
+      terminalRef.current?.dispose();
+      terminalRef.current = null;
+    };
+  }, []);
+
+  const playRecording = async (recording: WebShellRecording) => {
+    if (!id) {
+      return;
+    }
+    stopTimer();
+    setPlaying(false);
+    setSelected(recording);
+    try {
+      const content = await getWebShellRecording(id, recording.id);
+      const cast = parseAsciicast(content);
+      castRef.current = cast;
+      cursorRef.current = 0;
+      terminalRef.current?.dispose();
+      if (!terminalContainerRef.current) {
+        return;
+      }
+      const terminal = new Terminal({
+        cols: cast.width,
+        rows: cast.height,
+        disableStdin: true,
+        fontFamily: 'SFMono-Regular, Consolas, Liberation Mono, Menlo, monospace',
+        fontSize: 14,
+        lineHeight: 1.3,
+        theme: {
+          background: '#111827',
+        },
+      });
+      terminal.open(terminalContainerRef.current);
+      terminalRef.current = terminal;
+      setPlaying(true);
+    } catch (error: any) {
+      message.error(error.message || '加载录制失败');
+    }
+  };
+
+  const togglePlaying = () => {
+    if (!castRef.current) {
+      return;
+    }
+    if (!playing && cursorRef.current >= castRef.current.events.length) {
+      terminalRef.current?.reset();
+      cursorRef.current = 0;
+    }
+    setPlaying(!playing);
+  };
+
+  const columns = [
+    {
+      title: '开始时间',
+      dataIndex: 'startedAt',
+      key: 'startedAt',
+      render: (value: string) => dayjs(value).format('YYYY-MM-DD HH:mm:ss'),
+    },
+    {
+      title: '时长',
+      dataIndex: 'durationSeconds',
+      key: 'durationSeconds',
+      render: (value: number, record: WebShellRecording) => (record.endedAt ? formatDuration(value) : <Tag color="blue">进行中</Tag>),
+    },
+    {
+      title: '容器',
+      dataIndex: 'container',
+      key: 'container',
+    },
+    {
+      title: '大小',
+      dataIndex: 'sizeBytes',
+      key: 'sizeBytes',
+      render: (value: number, record: WebShellRecording) => (
+        <Space size={4}>
+          <span>{formatSize(value)}</span>
+          {record.truncated && <Tag color="orange">已截断</Tag>}
+        </Space>
+      ),
+    },
+    {
+      title: '操作',
+      key: 'actions',
+      render: (_: unknown, record: WebShellRecording) => (
+        <Space>
+          <Button size="small" type="primary" icon={<PlayCircleOutlined />} onClick={() => playRecording(record)}>
+            回放
+          </Button>
+          <Button size="small" icon={<DownloadOutlined />} href={getWebShellRecordingURL(id || '', record.id)} target="_blank">
+            下载
+          </Button>
+        </Space>
+      ),
+    },
+  ];
+
+  return (
+    <Layout className="web-shell-layout">
+      <Header className="web-shell-header glass-header">
+        <Space size="middle">
+          <Button icon={<ArrowLeftOutlined />} onClick={() => navigate(id ? `/pods/${id}` : '/')} className="glass-button">
+            返回
+          </Button>
+          <div className="web-shell-title">
+            <h2>Web Shell 录制</h2>
+            <Text className="subtitle">{id || '未知 Pod'}</Text>
+          </div>
+        </Space>
+        <Space size="middle">
+          <Button icon={<ReloadOutlined />} onClick={loadRecordings} loading={loading}>
+            刷新
+          </Button>
+          <ThemeToggle />
+        </Space>
+      </Header>
+      <Content className="web-shell-content">
+        <GlassCard hover={false} className="web-shell-card">
+          {!enabled && (
+            <Alert className="web-shell-status" type="info" showIcon message="管理员未启用 Web Shell 录制（pod.webShell.recording.enabled）" />
+          )}
+          {enabled && (
+            <Alert className="web-shell-status" type="info" showIcon message="录制用于审计，保留期满后自动清理，管理员可查看所有录制" />
+          )}
+          <Table
+            rowKey="id"
+            size="small"
+            loading={loading}
+            dataSource={recordings}
+            columns={columns}
+            pagination={{ pageSize: 10 }}
+            locale={{ emptyText: <Empty description="暂无录制" /> }}
+          />
+          {selected && (
+            <div className="web-shell-recording-player">
+              <Space className="web-shell-recording-controls">
+                <Button icon={playing ? <PauseCircleOutlined /> : <PlayCircleOutlined />} onClick={togglePlaying}>
+                  {playing ? '暂停' : '播放'}
+                </Button>
+                <Select
+                  value={speed}
+                  onChange={setSpeed}
+                  style={{ width: 96 }}
+                  options={[0.5, 1, 2, 4].map((value) => ({ value, label: `${value}x` }))}
+                />
+                <Text type="secondary">
+                  {dayjs(selected.startedAt).format('YYYY-MM-DD HH:mm:ss')} · {selected.container}
+                </Text>
+              </Space>
+            </div>
+          )}
+          <div className={selected ? 'web-shell-terminal' : 'web-shell-recording-hidden'} ref={terminalContainerRef} />
+        </GlassCard>
+      </Content>
+    </Layout>
+  );
+};
+
+export default WebShellRecordingsPage;
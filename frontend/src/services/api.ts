@@ -149,6 +149,42 @@ export const getAdminOverview = (): Promise<AdminOverviewResponse> => {
   return api.get('/admin/overview');
 };
 
+export interface GPUHistoryNode {
+  nodeName: string;
+  poolType: string;
+  deviceType: string;
+  totalDevices: number;
+  avgAllocationRate: number;
+  avgUtilization: number;
+  utilization: MetricPoint[];
+  allocated: MetricPoint[];
+}
+
+export interface GPUHistoryGroup {
+  type: string;
+  label: string;
+  totalDevices: number;
+  avgAllocationRate: number;
+  avgUtilization: number;
+  avgBusyRate: number;
+  utilization: MetricPoint[];
+  allocated: MetricPoint[];
+  busy: MetricPoint[];
+  nodes: GPUHistoryNode[];
+}
+
+export interface GPUHistoryResponse {
+  start: string;
+  end: string;
+  step: number;
+  busyThreshold: number;
+  groups: GPUHistoryGroup[];
+}
+
+export const getAdminGPUHistory = (range = '1d'): Promise<GPUHistoryResponse> => {
+  return api.get('/admin/gpu-history', { params: { range } });
+};
+
 export const listAdminNodePools = (): Promise<AdminNodePoolListResponse> => {
   return api.get('/admin/nodes/pools');
 };
@@ -185,6 +221,51 @@ export const deleteAdminAPIKey = (id: string): Promise<{ message: string }> => {
   return api.delete(`/admin/apikeys/${encodeURIComponent(id)}`);
 };
 
+export interface ImageGCPolicy {
+  enabled: boolean;
+  intervalHours: number;
+  keepLastTags: number;
+  deleteSuspendAfterResume: boolean;
+  maxTotalSizeGB: number;
+}
+
+export interface ImageGCCandidate {
+  username: string;
+  namespace: string;
+  image: string;
+  reason: 'keep-last' | 'suspend-resumed' | 'max-size';
+  size?: number;
+  savedAt: string;
+  deleted: boolean;
+  error?: string;
+}
+
+export interface ImageGCReport {
+  dryRun: boolean;
+  trigger: 'admin' | 'schedule';
+  startedAt: string;
+  finishedAt: string;
+  scanned: number;
+  candidates: ImageGCCandidate[];
+  deleted: number;
+  failed: number;
+  freedBytes: number;
+  errors?: string[];
+}
+
+export interface ImageGCStatusResponse {
+  policy: ImageGCPolicy;
+  lastReport?: ImageGCReport;
+}
+
+export const getAdminImageGC = (): Promise<ImageGCStatusResponse> => {
+  return api.get('/admin/images/gc');
+};
+
+export const runAdminImageGC = (dryRun: boolean): Promise<ImageGCReport> => {
+  return api.post('/admin/images/gc', { dryRun });
+};
+
 // 配置相关
 export const getConfig = () => {
   return api.get('/config');
@@ -403,6 +484,10 @@ export interface GetPodLogsOptions {
   previous?: boolean;
   tailLines?: number;
   since?: string;
+  until?: string;
+  grep?: string;
+  regex?: boolean;
+  ignoreCase?: boolean;
 }
 
 export interface PodLogsResponse {
@@ -421,12 +506,88 @@ export const getPodLogs = (id: string, options?: GetPodLogsOptions): Promise<Pod
   if (options?.since) {
     params.since = options.since;
   }
+  if (options?.until) {
+    params.until = options.until;
+  }
+  if (options?.grep) {
+    params.grep = options.grep;
+    if (options.regex) {
+      params.regex = true;
+    }
+    if (options.ignoreCase) {
+      params.ignoreCase = true;
+    }
+  }
 
   return api.get(`/pods/${id}/logs`, {
     params: Object.keys(params).length > 0 ? params : undefined,
   });
 };
 
+export const downloadPodLogs = (id: string, options?: Pick<GetPodLogsOptions, 'grep' | 'regex' | 'ignoreCase'>) => {
+  const params = new URLSearchParams({ download: 'true' });
+  if (options?.grep) {
+    params.set('grep', options.grep);
+    if (options.regex) {
+      params.set('regex', 'true');
+    }
+    if (options.ignoreCase) {
+      params.set('ignoreCase', 'true');
+    }
+  }
+  // 使用 window.location 触发文件下载（之前容器与当前容器的完整日志，gzip 压缩）
+  window.location.href = `/api/pods/${encodeURIComponent(id)}/logs?${params.toString()}`;
+};
+
+export type PodLogArchiveReason = 'user' | 'cleanup' | 'update' | 'suspend' | 'workload-delete';
+
+export interface PodLogArchive {
+  id: string;
+  pod: string;
+  namespace: string;
+  uid?: string;
+  node?: string;
+  image?: string;
+  phase?: string;
+  reason: PodLogArchiveReason;
+  createdAt: string;
+  archivedAt: string;
+  containers: Array<{
+    name: string;
+    logs: string;
+    previousLogs?: string;
+    truncated?: boolean;
+    error?: string;
+  }>;
+  events: Array<{
+    type: string;
+    reason: string;
+    message: string;
+    count: number;
+    firstTime: string;
+    lastTime: string;
+    source: string;
+  }>;
+}
+
+export interface PodLogArchiveSummary {
+  id: string;
+  uid?: string;
+  reason: PodLogArchiveReason;
+  createdAt: string;
+  archivedAt: string;
+}
+
+// 已删除 Pod 的归档日志与事件，archiveId 为空时返回最近一次归档
+export const getPodHistoryLogs = (id: string, archiveId?: string): Promise<PodLogArchive> => {
+  return api.get(`/pods/history/${encodeURIComponent(id)}/logs`, { params: archiveId ? { archive: archiveId } : undefined });
+};
+
+// 同名 Pod 的历次归档（按归档时间倒序）
+export const listPodHistoryArchives = (id: string): Promise<{ archives: PodLogArchiveSummary[] }> => {
+  return api.get(`/pods/history/${encodeURIComponent(id)}/archives`);
+};
+
 export const getPodLogStreamURL = (id: string, options?: { since?: string }) => {
   const params = new URLSearchParams();
   if (options?.since) {
@@ -436,6 +597,33 @@ export const getPodLogStreamURL = (id: string, options?: { since?: string }) =>
   return `/api/pods/${encodeURIComponent(id)}/logs/stream${query ? `?${query}` : ''}`;
 };
 
+export interface MetricPoint {
+  t: string;
+  v: number;
+}
+
+export interface PodDeviceMetrics {
+  type: string;
+  deviceId: string;
+  node: string;
+  utilization: MetricPoint[];
+  memoryUsed: MetricPoint[];
+}
+
+export interface PodMetrics {
+  start: string;
+  end: string;
+  step: number;
+  cpu: MetricPoint[];
+  memory: MetricPoint[];
+  devices: PodDeviceMetrics[];
+}
+
+// range 如 15m、1h、1d；后端按范围自动降采样（每条曲线最多 120 个点）
+export const getPodMetrics = (id: string, range = '1h'): Promise<PodMetrics> => {
+  return api.get(`/pods/${id}/metrics`, { params: { range } });
+};
+
 export const getPodEvents = (id: string) => {
   return api.get(`/pods/${id}/events`);
 };
@@ -451,8 +639,38 @@ export const buildImage = (id: string) => {
 export interface CreateWebShellSessionRequest {
   cols: number;
   rows: number;
+  // 重新连接已有会话（容器内 tmux/screen 会话）
+  sessionId?: string;
+  // 为空时使用主容器 / 自动选择 Shell
+  container?: string;
+  shell?: string;
+  // 从 Deployment/StatefulSet 副本进入时用于校验归属
+  workloadKind?: string;
+  workloadName?: string;
+}
+
+export interface WebShellContainerOption {
+  name: string;
+  image: string;
+  running: boolean;
+  main: boolean;
+}
+
+export interface WebShellOptions {
+  containers: WebShellContainerOption[];
+  defaultContainer: string;
+  shells: string[];
+  workloadKind: string;
+  workloadName?: string;
 }
 
+export const getWebShellOptions = (
+  id: string,
+  workload?: { workloadKind?: string; workloadName?: string },
+): Promise<WebShellOptions> => {
+  return api.get(`/pods/${encodeURIComponent(id)}/webshell/options`, { params: workload });
+};
+
 export interface WebShellSessionResponse {
   sessionId: string;
   webSocketURL: string;
@@ -461,8 +679,26 @@ export interface WebShellSessionResponse {
   cols: number;
   rows: number;
   expiresAt: string;
+  recording?: boolean;
+  persistent?: boolean;
+  reattached?: boolean;
+}
+
+export interface WebShellSessionInfo {
+  sessionId: string;
+  podId: string;
+  container: string;
+  shell: string;
+  multiplexer: string;
+  createdAt: string;
+  detachedAt?: string;
+  state: 'pending' | 'attached' | 'detached';
 }
 
+export const listWebShellSessions = (id: string): Promise<{ sessions: WebShellSessionInfo[]; persistent: boolean }> => {
+  return api.get(`/pods/${encodeURIComponent(id)}/webshell/sessions`);
+};
+
 export const createWebShellSession = (
   id: string,
   data: CreateWebShellSessionRequest,
@@ -474,23 +710,151 @@ export const deleteWebShellSession = (id: string, sessionId: string): Promise<{
   return api.delete(`/pods/${encodeURIComponent(id)}/webshell/sessions/${encodeURIComponent(sessionId)}`);
 };
 
+// Web Shell 会话分享：ro 只读观看，rw 可共同输入
+export type WebShellShareMode = 'ro' | 'rw';
+
+export interface WebShellShare {
+  token: string;
+  sessionId: string;
+  podId: string;
+  owner: string;
+  mode: WebShellShareMode;
+  createdAt: string;
+  expiresAt: string;
+}
+
+export interface WebShellShareResponse extends WebShellShare {
+  url: string;
+  webSocketURL: string;
+}
+
+export interface WebShellParticipant {
+  user: string;
+  mode: WebShellShareMode;
+  joinedAt: string;
+  leftAt?: string;
+}
+
+export const createWebShellShare = (
+  id: string,
+  sessionId: string,
+  data: { mode: WebShellShareMode; expiresInMinutes?: number },
+): Promise<WebShellShareResponse> => {
+  return api.post(`/pods/${encodeURIComponent(id)}/webshell/sessions/${encodeURIComponent(sessionId)}/shares`, data);
+};
+
+export const listWebShellParticipants = (
+  id: string,
+  sessionId: string,
+): Promise<{ participants: WebShellParticipant[] }> => {
+  return api.get(`/pods/${encodeURIComponent(id)}/webshell/sessions/${encodeURIComponent(sessionId)}/participants`);
+};
+
+export const getWebShellShare = (token: string): Promise<WebShellShare> => {
+  return api.get(`/webshell/shares/${encodeURIComponent(token)}`);
+};
+
+export const getWebShellShareWebSocketURL = (token: string) => {
+  return `/api/webshell/shares/${encodeURIComponent(token)}/ws`;
+};
+
+// Web Shell 录制（asciicast v2）
+export interface WebShellRecording {
+  id: string;
+  user: string;
+  podId: string;
+  container: string;
+  shell: string;
+  cols: number;
+  rows: number;
+  startedAt: string;
+  endedAt?: string;
+  durationSeconds: number;
+  sizeBytes: number;
+  truncated: boolean;
+}
+
+export interface WebShellRecordingListResponse {
+  enabled: boolean;
+  recordings: WebShellRecording[];
+}
+
+export const getWebShellRecordingURL = (id: string, recordingId: string) => {
+  return `/api/pods/${encodeURIComponent(id)}/webshell/recordings/${encodeURIComponent(recordingId)}`;
+};
+
+export const listWebShellRecordings = (id: string): Promise<WebShellRecordingListResponse> => {
+  return api.get(`/pods/${encodeURIComponent(id)}/webshell/recordings`);
+};
+
+export const getWebShellRecording = (id: string, recordingId: string): Promise<string> => {
+  return api.get(`/pods/${encodeURIComponent(id)}/webshell/recordings/${encodeURIComponent(recordingId)}`, {
+    responseType: 'text',
+    transformResponse: [(data) => data],
+  });
+};
+
+// 管理员审计：查看所有用户的录制（录制只按保留期清理，不提供删除）
+export const listAdminWebShellRecordings = (params?: { user?: string; podId?: string }): Promise<WebShellRecordingListResponse> => {
+  return api.get('/admin/webshell/recordings', { params });
+};
+
+export const getAdminWebShellRecordingURL = (user: string, recordingId: string) => {
+  return `/api/admin/webshell/recordings/${encodeURIComponent(user)}/${encodeURIComponent(recordingId)}`;
+};
+
 // 镜像 Commit 相关
 export interface CommitImageRequest {
   imageName: string;
+  exclude?: string[]; // 追加排除的路径 glob
+  noDefaultExcludes?: boolean; // 不使用管理员配置的默认排除规则
+  squash?: boolean; // 合并本次提交产生的层，未指定时使用管理员默认值
+  preCommitHook?: string; // 提交前在容器内执行的清理脚本
 }
 
 export interface CommitStatus {
   hasJob: boolean;
   jobName?: string;
-  status?: 'Pending' | 'Running' | 'Succeeded' | 'Failed';
+  status?: 'Queued' | 'Pending' | 'Running' | 'Succeeded' | 'Failed';
   message?: string;
   startTime?: string;
   endTime?: string;
   targetImage?: string;
+  size?: number; // 推送后的镜像大小（字节）
+  baseSize?: number; // 提交前容器所用镜像的大小（字节）
+  sizeDelta?: number; // 相对原镜像的体积变化（字节）
+}
+
+// 镜像保存历史记录（Job 清理后仍保留）
+export interface CommitRecord {
+  id: string;
+  targetImage: string;
+  sourcePod: string;
+  status: 'Queued' | 'Pending' | 'Running' | 'Succeeded' | 'Failed';
+  message?: string;
+  digest?: string;
+  size?: number;
+  baseSize?: number;
+  createdAt: string;
+  startTime?: string;
+  endTime?: string;
+  durationSeconds?: number;
+  logTail?: string;
+}
+
+export interface CommitListResponse {
+  items: CommitRecord[];
+  total: number;
+  page: number;
+  pageSize: number;
 }
 
-export const commitImage = (id: string, imageName: string): Promise<any> => {
-  return api.post(`/pods/${id}/commit`, { imageName });
+export const listCommits = (params?: { page?: number; pageSize?: number; pod?: string; status?: string }): Promise<CommitListResponse> => {
+  return api.get('/commits', { params });
+};
+
+export const commitImage = (id: string, imageName: string, options?: Omit<CommitImageRequest, 'imageName'>): Promise<any> => {
+  return api.post(`/pods/${id}/commit`, { imageName, ...options });
 };
 
 export const getCommitStatus = (id: string): Promise<CommitStatus> => {
@@ -501,6 +865,26 @@ export const getCommitLogs = (id: string): Promise<{ logs: string }> => {
   return api.get(`/pods/${id}/commit/logs`);
 };
 
+// Dockerfile 构建：dockerfile 与 dockerfilePath 二选一，路径为 Pod 内绝对路径
+export interface BuildImageRequest {
+  imageName: string;
+  dockerfile?: string;
+  dockerfilePath?: string;
+  context?: string;
+}
+
+export const buildImage = (id: string, req: BuildImageRequest): Promise<any> => {
+  return api.post(`/pods/${id}/build`, req);
+};
+
+export const getBuildStatus = (id: string): Promise<CommitStatus> => {
+  return api.get(`/pods/${id}/build/status`);
+};
+
+export const getBuildLogs = (id: string): Promise<{ logs: string }> => {
+  return api.get(`/pods/${id}/build/logs`);
+};
+
 // Kubeconfig 相关
 export interface ClusterInfo {
   oidcEnabled: boolean;
@@ -599,20 +983,50 @@ export const getGPUOverview = (): Promise<GPUOverviewResponse> => {
   return api.get('/cluster/gpu-overview');
 };
 
+// 镜像在 registry 中的元数据
+export interface ImageManifest {
+  digest: string;
+  mediaType?: string;
+  size: number;
+  platforms?: string[];
+  labels?: Record<string, string>;
+  created?: string;
+  vulnerabilities?: VulnerabilitySummary;  // Harbor 漏洞扫描摘要
+}
+
+// 镜像漏洞扫描摘要
+export interface VulnerabilitySummary {
+  scanStatus: string;  // Success | Running | Error | NotScanned ...
+  severity?: string;   // 最高严重级别
+  counts?: Record<string, number>;
+  total: number;
+  fixable?: number;
+  scannedAt?: string;
+}
+
+// 镜像按漏洞策略检查的结果
+export interface ImageScanResult {
+  image: string;
+  verdict: 'allow' | 'warn' | 'block';
+  message?: string;
+  vulnerabilities?: VulnerabilitySummary;
+}
+
 // 用户保存的镜像
 export interface UserSavedImage {
   image: string;
   description?: string;
   sourcePod?: string;
   savedAt: string;
+  manifest?: ImageManifest;  // details=true 时返回
 }
 
 export interface UserImageListResponse {
   images: UserSavedImage[];
 }
 
-export const listUserImages = (): Promise<UserImageListResponse> => {
-  return api.get('/images');
+export const listUserImages = (details = false): Promise<UserImageListResponse> => {
+  return api.get('/images', { params: details ? { details: true } : undefined });
 };
 
 export const addUserImage = (data: { image: string; description?: string; sourcePod?: string }) => {
@@ -646,26 +1060,78 @@ export interface RegistryImageInfo {
   name: string;
   tags?: string[];
   description?: string;
+  registry?: string;  // 镜像所在仓库的 host
+}
+
+// 镜像仓库（不含凭据）
+export interface RegistryInfo {
+  name: string;
+  host: string;
+  type?: string;
 }
 
 export interface SearchImagesResponse {
   images: RegistryImageInfo[];
 }
 
+export interface TagDetail {
+  tag: string;
+  manifest?: ImageManifest;
+  error?: string;
+}
+
 export interface GetImageTagsResponse {
   tags: string[];
+  details?: TagDetail[];  // details=true 时返回前 50 个 tag 的元数据
 }
 
 export const searchRegistryImages = (keyword: string, limit: number = 20): Promise<SearchImagesResponse> => {
   return api.get(`/registry/images?keyword=${encodeURIComponent(keyword)}&limit=${limit}`);
 };
 
-export const getRegistryImageTags = (imageName: string, platform?: string): Promise<GetImageTagsResponse> => {
+export const getImageScan = (image: string): Promise<ImageScanResult> => {
+  return api.get(`/registry/scan?image=${encodeURIComponent(image)}`);
+};
+
+export const getRegistryImageTags = (imageName: string, platform?: string, details = false, registry?: string): Promise<GetImageTagsResponse> => {
   let url = `/registry/tags?image=${encodeURIComponent(imageName)}`;
+  if (registry) {
+    url += `&registry=${encodeURIComponent(registry)}`;
+  }
   if (platform) {
     url += `&platform=${encodeURIComponent(platform)}`;
   }
+  if (details) {
+    url += '&details=true';
+  }
   return api.get(url);
 };
 
+// 用户私有仓库凭据（密码只保存在 Secret 中，不返回）
+export interface RegistryCredential {
+  name: string;       // Secret 名称
+  registry: string;   // 仓库 host
+  username: string;
+  updatedAt: string;
+}
+
+export interface SaveRegistryCredentialRequest {
+  registry: string;
+  username: string;
+  password: string;
+  insecure?: boolean;
+}
+
+export const getRegistryCredentials = (): Promise<{ credentials: RegistryCredential[] }> => {
+  return api.get('/registry-credentials');
+};
+
+export const saveRegistryCredential = (data: SaveRegistryCredentialRequest): Promise<RegistryCredential> => {
+  return api.post('/registry-credentials', data);
+};
+
+export const deleteRegistryCredential = (name: string): Promise<{ message: string }> => {
+  return api.delete(`/registry-credentials/${encodeURIComponent(name)}`);
+};
+
 export default api;
@@ -0,0 +1,18 @@
+/**
+ * 工作负载（StatefulSet/Deployment）的 Web Shell 入口
+ *
+ * 打开第一个 Web Shell 可用的副本，并带上 workloadKind/workloadName，
+ * Web Shell 页面据此列出全部副本供切换；没有可用副本时返回 undefined
+ */
+export const workloadWebShellURL = (
+  kind: 'statefulset' | 'deployment',
+  workloadName: string,
+  pods: any[] = [],
+): string | undefined => {
+  const replica = pods.find((pod) => pod?.connections?.apps?.webShellReady);
+  if (!replica) {
+    return undefined;
+  }
+  const params = new URLSearchParams({ workloadKind: kind, workloadName });
+  return `/pods/${encodeURIComponent(replica.id || replica.name)}/webshell?${params.toString()}`;
+};